@@ -0,0 +1,15 @@
+// Command padding-size-vet runs the paddingcheck analyzer. It can be used on
+// its own or as a vet tool:
+//
+//	go vet -vettool=$(which padding-size-vet) ./...
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/singlechecker"
+
+	"github.com/zakon47/padding-size/paddingcheck"
+)
+
+func main() {
+	singlechecker.Main(paddingcheck.Analyzer)
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"io"
+	"path/filepath"
+	"slices"
+	"text/tabwriter"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// allocSites holds the number of allocation sites of package-level struct
+// types.
+type allocSites map[structKey]int64
+
+// structKey identifies a package-level struct type by the directory of the
+// declaring package, as returned by realDir, and the type name.
+type structKey struct {
+	dir, name string
+}
+
+// countAllocSites type-checks the package in dir, and with recursive the
+// packages below it as well, and counts the sites
+// allocating each of their struct types: &T{...}, new(T), make([]T, n) and
+// []T{...}. A make call with a constant length or capacity counts as that
+// many sites, and a slice literal as one per element, so the count estimates
+// the number of objects the sites allocate. Allocations inside the New
+// function of a sync.Pool are counted like any other.
+func countAllocSites(dir string, recursive bool) (allocSites, error) {
+	pkgs, loadErr := loadPackages(dir, recursive, packages.NeedTypes|packages.NeedSyntax|packages.NeedTypesInfo)
+
+	sites := make(allocSites)
+	dirs := make(map[string]string) // file name to realDir
+	for _, pkg := range pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		count := func(t types.Type, n int64) {
+			obj := structTypeName(t)
+			if obj == nil {
+				return
+			}
+			file := pkg.Fset.Position(obj.Pos()).Filename
+			dir, ok := dirs[file]
+			if !ok {
+				dir = realDir(file)
+				dirs[file] = dir
+			}
+			sites[structKey{dir, obj.Name()}] += n
+		}
+		for _, file := range pkg.Syntax {
+			ast.Inspect(file, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.UnaryExpr:
+					if lit, ok := ast.Unparen(n.X).(*ast.CompositeLit); ok && n.Op == token.AND {
+						count(pkg.TypesInfo.TypeOf(lit), 1)
+					}
+				case *ast.CompositeLit:
+					if s, ok := types.Unalias(pkg.TypesInfo.TypeOf(n)).(*types.Slice); ok {
+						count(s.Elem(), int64(len(n.Elts)))
+					}
+				case *ast.CallExpr:
+					countCall(pkg.TypesInfo, n, count)
+				}
+				return true
+			})
+		}
+	}
+	return sites, loadErr
+}
+
+// countCall counts the allocation by a call of the builtin new or make.
+func countCall(info *types.Info, call *ast.CallExpr, count func(types.Type, int64)) {
+	id, ok := ast.Unparen(call.Fun).(*ast.Ident)
+	if !ok || len(call.Args) == 0 {
+		return
+	}
+	if _, ok := info.Uses[id].(*types.Builtin); !ok {
+		return
+	}
+	switch id.Name {
+	case "new":
+		count(info.TypeOf(call.Args[0]), 1)
+	case "make":
+		s, ok := types.Unalias(info.TypeOf(call.Args[0])).(*types.Slice)
+		if !ok {
+			return
+		}
+		n := int64(1)
+		for _, arg := range call.Args[1:] {
+			v := info.Types[arg].Value
+			if v == nil || v.Kind() != constant.Int {
+				continue
+			}
+			if v, ok := constant.Int64Val(v); ok && v > n {
+				n = v
+			}
+		}
+		count(s.Elem(), n)
+	}
+}
+
+// structTypeName returns the declaration of t if it is a package-level
+// struct type, or of its generic origin, and nil otherwise.
+func structTypeName(t types.Type) *types.TypeName {
+	named, ok := types.Unalias(t).(*types.Named)
+	if !ok {
+		return nil
+	}
+	obj := named.Origin().Obj()
+	if _, ok := named.Underlying().(*types.Struct); !ok || obj.Pkg() == nil || obj.Parent() != obj.Pkg().Scope() {
+		return nil
+	}
+	return obj
+}
+
+// realDir returns the absolute directory of the file path with symbolic
+// links resolved, so that the same directory gets the same key however it
+// was reached.
+func realDir(path string) string {
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return filepath.Dir(path)
+	}
+	if real, err := filepath.EvalSymlinks(dir); err == nil {
+		return real
+	}
+	return dir
+}
+
+// topLevelStructs returns the struct types of package-level type
+// declarations of file, the only ones allocation sites are counted for.
+func topLevelStructs(file *ast.File) map[*ast.StructType]bool {
+	structs := make(map[*ast.StructType]bool)
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.TYPE {
+			for _, spec := range gen.Specs {
+				if st, ok := spec.(*ast.TypeSpec).Type.(*ast.StructType); ok {
+					structs[st] = true
+				}
+			}
+		}
+	}
+	return structs
+}
+
+// weigh sets the allocation sites of r, a package-level struct.
+func (a allocSites) weigh(r *padding.StructReport) {
+	r.AllocSites = a[structKey{realDir(r.File), r.Name}]
+}
+
+// writeAllocRanking writes the structs of r that waste space to w, ranked by
+// their allocation sites and then by the bytes a single instance wastes. If
+// top is positive, only the first top structs are written.
+func writeAllocRanking(w io.Writer, r padding.Report, top int) error {
+	var structs []padding.StructReport
+	for _, s := range r.Structs {
+		if s.WastedBytes > 0 {
+			structs = append(structs, s)
+		}
+	}
+	slices.SortStableFunc(structs, func(a, b padding.StructReport) int {
+		return cmp.Or(
+			cmp.Compare(b.AllocSites, a.AllocSites),
+			cmp.Compare(b.WastedBytes, a.WastedBytes),
+		)
+	})
+	if top > 0 && len(structs) > top {
+		structs = structs[:top]
+	}
+
+	fmt.Fprintf(w, "Structs ranked by allocation sites:\n")
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "ALLOCATION SITES\tWASTED/OBJECT\tSTRUCT\n")
+	for _, s := range structs {
+		fmt.Fprintf(tw, "≈%d\t%d\t%s.%s (%s)\n", s.AllocSites, s.WastedBytes, s.Package, s.Name, s.File)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
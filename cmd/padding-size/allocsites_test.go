@@ -0,0 +1,63 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAllocSites(t *testing.T) {
+	dir := filepath.Join("testdata", "allocs")
+	opts := options{collect: new(reportCollector), allocSites: true}
+	report := captureReport(t, func() error { return processPath(dir, opts, newFileRegistry()) })
+
+	want := []struct {
+		name  string
+		sites int64
+	}{
+		{"Node", 2},
+		{"Sample", 83},
+		{"Buffer", 1},
+		{"Config", 0},
+		{"Node", 0}, // declared in a function
+	}
+	structs := opts.collect.report().Structs
+	if len(structs) != len(want) {
+		t.Fatalf("got %d structs, want %d", len(structs), len(want))
+	}
+	for i, s := range structs {
+		if s.Name != want[i].name || s.AllocSites != want[i].sites {
+			t.Errorf("struct %d: %s with %d allocation sites, want %s with %d", i, s.Name, s.AllocSites, want[i].name, want[i].sites)
+		}
+	}
+
+	for _, line := range []string{
+		"Struct: Sample (size: 24 bytes, align: 8, optimal: 16 bytes, packed minimum: 10 bytes, wasted: 8 bytes, padding: 7 inter-field + 7 trailing, ≈83 allocation sites)\n",
+		"Struct: Buffer (size: 24 bytes, align: 8, optimal: 16 bytes, packed minimum: 10 bytes, wasted: 8 bytes, padding: 7 inter-field + 7 trailing, ≈1 allocation site)\n",
+		"Struct: Config (size: 24 bytes, align: 8, optimal: 16 bytes, packed minimum: 10 bytes, wasted: 8 bytes, padding: 7 inter-field + 7 trailing)\n",
+	} {
+		if !strings.Contains(report, line) {
+			t.Errorf("report lacks %q:\n%s", line, report)
+		}
+	}
+}
+
+func TestAllocRanking(t *testing.T) {
+	dir := filepath.Join("testdata", "allocs")
+	opts := options{collect: new(reportCollector), allocSites: true}
+	captureReport(t, func() error { return processPath(dir, opts, newFileRegistry()) })
+
+	var b strings.Builder
+	if err := writeAllocRanking(&b, opts.collect.report(), 3); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "allocs.go")
+	want := "Structs ranked by allocation sites:\n" +
+		"ALLOCATION SITES  WASTED/OBJECT  STRUCT\n" +
+		"≈83               8              allocs.Sample (" + file + ")\n" +
+		"≈2                8              allocs.Node (" + file + ")\n" +
+		"≈1                8              allocs.Buffer (" + file + ")\n\n"
+	if b.String() != want {
+		t.Errorf("ranking:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
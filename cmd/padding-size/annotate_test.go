@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAnnotateGolden(t *testing.T) {
+	src, err := os.ReadFile(filepath.Join("testdata", "annotate", "annotate.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile(filepath.Join("testdata", "annotate", "annotate.go.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "annotate.go")
+	if err := os.WriteFile(path, src, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := options{annotate: true}
+	captureReport(t, func() error { return processFile(path, opts) })
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("annotated:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Annotating again changes nothing.
+	captureReport(t, func() error { return processFile(path, opts) })
+	again, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != string(got) {
+		t.Errorf("annotating again changed the file:\n%s", again)
+	}
+}
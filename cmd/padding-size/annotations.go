@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"slices"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// checkAnnotation compares the size of s with the size recorded by its
+// //padding-size:ok annotation. It returns a finding describing the layout
+// drift or the malformed annotation, or the empty string if s has no
+// annotation or matches it.
+func checkAnnotation(s padding.StructInfo) string {
+	a, err := padding.ParseAnnotation(s.Doc)
+	switch {
+	case err != nil:
+		return fmt.Sprintf("Invalid annotation: struct %s: %v", s.Name, err)
+	case a != nil && a.Size != s.Size:
+		return fmt.Sprintf("Layout drift: struct %s is %d bytes but annotated size=%d", s.Name, s.Size, a.Size)
+	}
+	return ""
+}
+
+// writeAnnotations records the current size of every struct of f in its
+// //padding-size:ok annotation, after applying fixes if fix is set, and
+// writes the result back to f.
+func writeAnnotations(f *FileResult, fix bool) error {
+	src := f.Src
+	if fix {
+		var err error
+		src, err = padding.Rewrite(f.Fset, f.Node, f.Src, f.Structs)
+		if err != nil {
+			return err
+		}
+	}
+	src, err := annotate(f.Path, src)
+	if err != nil {
+		return err
+	}
+	return replaceFile(f.Path, f.Src, src)
+}
+
+// annotate returns src with a //padding-size:ok annotation recording the
+// size of each struct type declared in it. Existing annotations are updated;
+// missing ones are added as the last line of the declaration's doc comment.
+func annotate(path string, src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	structs, err := padding.Analyze(fset, file, padding.Options{Compiler: goBuild.compiler, Arch: goBuild.arch})
+	if err != nil {
+		return nil, err
+	}
+
+	var edits []edit
+	tf := fset.File(file.Pos())
+	for _, s := range structs {
+		if s.Anonymous || s.Ignored() {
+			continue // there is no type declaration to annotate, or it opts out
+		}
+		a, err := padding.ParseAnnotation(s.Doc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: struct %s: %v", path, s.Name, err)
+		}
+		text := padding.FormatAnnotation(s.Size)
+		if a != nil {
+			if a.Size != s.Size {
+				edits = append(edits, edit{tf.Offset(a.Comment.Pos()), tf.Offset(a.Comment.End()), text})
+			}
+			continue
+		}
+		// Insert the annotation above the line declaring the type,
+		// indented like it.
+		start := tf.Offset(tf.LineStart(tf.Line(s.Node.Pos())))
+		indent := lineIndent(src, start)
+		edits = append(edits, edit{start, start, indent + text + "\n"})
+	}
+
+	return applyEdits(src, edits), nil
+}
+
+// edit replaces the bytes of a source file from offset start to end with
+// text.
+type edit struct {
+	start, end int
+	text       string
+}
+
+// applyEdits returns src with edits, which must not overlap, applied.
+func applyEdits(src []byte, edits []edit) []byte {
+	// Apply the edits from the end so earlier offsets stay valid.
+	slices.SortFunc(edits, func(a, b edit) int { return b.start - a.start })
+	out := slices.Clone(src)
+	for _, e := range edits {
+		out = slices.Concat(out[:e.start:e.start], []byte(e.text), out[e.end:])
+	}
+	return out
+}
+
+// writeAdvisories writes f back with an advisory comment on each struct fix
+// would shrink, and none on the others.
+func writeAdvisories(f *FileResult, opts options) error {
+	return replaceFile(f.Path, f.Src, advise(f, opts))
+}
+
+// advise returns the source of f with an advisory comment, as formatted by
+// padding.FormatAdvisory, in the doc comment of each struct type fix would
+// shrink. Existing advisories are updated, and removed from the structs fix
+// would leave alone, along with the empty comment line separating them from
+// the rest of the doc comment. A new advisory becomes the last paragraph of
+// the doc comment, or the doc comment of a struct without one.
+func advise(f *FileResult, opts options) []byte {
+	var edits []edit
+	tf := f.Fset.File(f.Node.Pos())
+	lineStart := func(line int) int {
+		if line > tf.LineCount() {
+			return len(f.Src)
+		}
+		return tf.Offset(tf.LineStart(line))
+	}
+	for i := range f.Structs {
+		s := &f.Structs[i]
+		if s.Anonymous {
+			continue // there is no type declaration to annotate
+		}
+		var text string
+		if opts.fixable(s) {
+			if fixed := opts.fixedLayout(s); fixed.Size < s.Size {
+				text = padding.FormatAdvisory(s.Size, fixed.Size)
+			}
+		}
+		c := padding.FindAdvisory(s.Doc)
+		switch {
+		case c != nil && text == "":
+			first := tf.Line(c.Pos())
+			if j := slices.Index(s.Doc.List, c); j > 0 && s.Doc.List[j-1].Text == "//" {
+				first = tf.Line(s.Doc.List[j-1].Pos())
+			}
+			edits = append(edits, edit{lineStart(first), lineStart(tf.Line(c.End()) + 1), ""})
+		case c != nil && c.Text != text:
+			edits = append(edits, edit{tf.Offset(c.Pos()), tf.Offset(c.End()), text})
+		case c == nil && text != "" && s.Doc != nil:
+			start := lineStart(tf.Line(s.Doc.Pos()))
+			at := lineStart(tf.Line(s.Doc.End()) + 1)
+			indent := lineIndent(f.Src, start)
+			edits = append(edits, edit{at, at, indent + "//\n" + indent + text + "\n"})
+		case c == nil && text != "":
+			start := lineStart(tf.Line(s.Node.Pos()))
+			indent := lineIndent(f.Src, start)
+			edits = append(edits, edit{start, start, indent + text + "\n"})
+		}
+	}
+	return applyEdits(f.Src, edits)
+}
+
+// lineIndent returns the leading whitespace of the line starting at offset
+// start of src.
+func lineIndent(src []byte, start int) string {
+	end := start
+	for end < len(src) && (src[end] == ' ' || src[end] == '\t') {
+		end++
+	}
+	return string(src[start:end])
+}
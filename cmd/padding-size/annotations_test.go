@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// captureReport runs process with stdout redirected and returns the report.
+func captureReport(t *testing.T, process func() error) string {
+	t.Helper()
+	var buf bytes.Buffer
+	saved := stdout
+	stdout = bufio.NewWriter(&buf)
+	defer func() { stdout = saved }()
+
+	if err := process(); err != nil {
+		t.Fatal(err)
+	}
+	stdout.Flush()
+	return buf.String()
+}
+
+func writeFile(t *testing.T, src string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "types.go")
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+const annotatedSrc = `package test
+
+// Matching is annotated with its size.
+//
+//padding-size:ok size=16
+type Matching struct {
+	A int64
+	B int32
+}
+
+//padding-size:ok size=16
+type Drifted struct {
+	A bool
+	B int64
+	C bool
+}
+
+//padding-size:ok
+type Malformed struct {
+	A int64
+}
+`
+
+func TestAnnotationCheck(t *testing.T) {
+	path := writeFile(t, annotatedSrc)
+	report := captureReport(t, func() error { return processFile(path, options{}) })
+
+	if strings.Contains(report, "struct Matching") {
+		t.Errorf("report flags the matching annotation:\n%s", report)
+	}
+	if !strings.Contains(report, "Layout drift: struct Drifted is 24 bytes but annotated size=16\n") {
+		t.Errorf("report lacks the drift of Drifted:\n%s", report)
+	}
+	if !strings.Contains(report, "Invalid annotation: struct Malformed: missing size in //padding-size:ok\n") {
+		t.Errorf("report lacks the malformed annotation:\n%s", report)
+	}
+	if got := readFile(t, path); got != annotatedSrc {
+		t.Errorf("checking modified the file:\n%s", got)
+	}
+}
+
+func TestWriteAnnotations(t *testing.T) {
+	src := `package test
+
+// Doc is documented.
+type Doc struct {
+	A bool
+	B int64
+}
+
+type (
+	Grouped struct {
+		A int32
+	}
+
+	//padding-size:ok size=99
+	Stale struct {
+		A int64
+	}
+)
+
+func f() {
+	type local struct {
+		A bool
+	}
+}
+`
+	want := `package test
+
+// Doc is documented.
+// padding-size:ok size=16
+type Doc struct {
+	A bool
+	B int64
+}
+
+type (
+	// padding-size:ok size=4
+	Grouped struct {
+		A int32
+	}
+
+	// padding-size:ok size=8
+	Stale struct {
+		A int64
+	}
+)
+
+func f() {
+	// padding-size:ok size=1
+	type local struct {
+		A bool
+	}
+}
+`
+	path := writeFile(t, src)
+	captureReport(t, func() error { return processFile(path, options{writeAnnotations: true}) })
+	if got := readFile(t, path); got != want {
+		t.Errorf("annotated file:\n%s\nwant:\n%s", got, want)
+	}
+
+	// The annotations now match, and writing them again changes nothing.
+	report := captureReport(t, func() error { return processFile(path, options{writeAnnotations: true}) })
+	if strings.Contains(report, "Layout drift") {
+		t.Errorf("report after annotating shows drift:\n%s", report)
+	}
+	if got := readFile(t, path); got != want {
+		t.Errorf("annotating again changed the file:\n%s", got)
+	}
+}
+
+func TestFixUpdatesAnnotation(t *testing.T) {
+	src := `package test
+
+//padding-size:ok size=24
+type Wasteful struct {
+	A bool
+	B int64
+	C bool
+}
+`
+	path := writeFile(t, src)
+	captureReport(t, func() error { return processFile(path, options{fix: true}) })
+
+	got := readFile(t, path)
+	if !strings.Contains(got, "// padding-size:ok size=16\ntype Wasteful struct {\n\tB int64\n") {
+		t.Errorf("fixed file:\n%s", got)
+	}
+	report := captureReport(t, func() error { return processFile(path, options{}) })
+	if strings.Contains(report, "Layout drift") {
+		t.Errorf("report after -fix shows drift:\n%s", report)
+	}
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/types"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// benchLen is the number of elements of the slices the generated benchmarks
+// allocate and walk: enough for the slices of most structs to outgrow the
+// first cache levels.
+const benchLen = 1 << 16
+
+// runBench implements the bench subcommand:
+//
+//	padding-size bench [-o file] dir Type
+//
+// It returns the process exit code.
+func runBench(args []string) int {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	output := fs.String("o", "", "Write the benchmark to `file`, relative to the package directory (default type_layout_bench_test.go)")
+	goBuild.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: padding-size bench [options] <package directory> <type>")
+		fs.PrintDefaults()
+	}
+
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return 2
+	}
+	if err := goBuild.resolve(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if len(args) != 2 {
+		fs.Usage()
+		return 2
+	}
+	dir, name := args[0], args[1]
+
+	src, err := generateBench(dir, name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	path := *output
+	if path == "" {
+		path = strings.ToLower(name) + "_layout_bench_test.go"
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	if err := os.WriteFile(path, src, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// benchLayout is a layout of the struct benchmarked: its fields in order,
+// and the name and size of its copy.
+type benchLayout struct {
+	label, typeName string
+	fields          []*types.Var
+	size            int64
+}
+
+// generateBench type-checks the package in dir and returns the source of a
+// test file of that package benchmarking the struct type name as declared
+// against its optimal order: private copies of both, and benchmarks
+// allocating slices of each and walking them to sum their fields.
+func generateBench(dir, name string) ([]byte, error) {
+	cfg := goBuild.config(dir, packages.NeedName|packages.NeedTypes|packages.NeedTypesSizes)
+	pkgs, err := goBuild.load(cfg, ".")
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("%s: expected one package, found %d", dir, len(pkgs))
+	}
+	pkg := pkgs[0]
+	if len(pkg.Errors) > 0 {
+		return nil, fmt.Errorf("cannot load package %s: %v", dir, firstError(pkg))
+	}
+
+	tn, ok := pkg.Types.Scope().Lookup(name).(*types.TypeName)
+	if !ok {
+		return nil, fmt.Errorf("package %s declares no type %s", pkg.PkgPath, name)
+	}
+	if named, ok := tn.Type().(*types.Named); ok && named.TypeParams().Len() > 0 {
+		return nil, fmt.Errorf("%s is generic; its layout depends on its type arguments", name)
+	}
+	st, ok := tn.Type().Underlying().(*types.Struct)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a struct type but %s", name, types.TypeString(tn.Type().Underlying(), types.RelativeTo(pkg.Types)))
+	}
+
+	fields, layout := padding.Layout(st, pkg.TypesSizes)
+	s := padding.StructInfo{Name: name, Fields: make([]padding.FieldInfo, len(fields)), Compiler: goBuild.compiler}
+	for i, f := range fields {
+		s.Fields[i] = padding.FieldInfo{Name: f.Field.Name(), Size: f.Size, Align: f.Align}
+	}
+	order := padding.OptimalPermutation(s)
+	optimal := padding.Optimal(s).Size
+	if optimal >= layout.Size {
+		return nil, fmt.Errorf("%s is already laid out optimally, in %d bytes; there is nothing to compare it with", name, layout.Size)
+	}
+
+	// The copies are declared in the package, so its own types are
+	// written unqualified; those of other packages are imported.
+	imports := map[string]string{"testing": "testing", "unsafe": "unsafe"}
+	var clash error
+	qualifier := func(p *types.Package) string {
+		if p == pkg.Types {
+			return ""
+		}
+		for path, imported := range imports {
+			if imported == p.Name() && path != p.Path() && clash == nil {
+				clash = fmt.Errorf("%s has fields of types from %s and %s, both named %s; declare the benchmark by hand", name, path, p.Path(), p.Name())
+			}
+		}
+		imports[p.Path()] = p.Name()
+		return p.Name()
+	}
+	for _, f := range fields {
+		if obj := uncopyable(f.Field.Type(), pkg.Types); obj != nil {
+			return nil, fmt.Errorf("%s.%s has type %s, which the package of the benchmark cannot name as %s.%s is not visible to it",
+				name, f.Field.Name(), types.TypeString(f.Field.Type(), qualifier), obj.Pkg().Path(), obj.Name())
+		}
+	}
+
+	arch, err := goarch(dir)
+	if err != nil {
+		return nil, err
+	}
+	suffix := exportedName(name)
+	layouts := []benchLayout{
+		{label: "current", typeName: "benchCurrent" + suffix, size: layout.Size},
+		{label: "optimal", typeName: "benchOptimal" + suffix, size: optimal},
+	}
+	for _, i := range order {
+		layouts[1].fields = append(layouts[1].fields, fields[i].Field)
+	}
+	for _, f := range fields {
+		layouts[0].fields = append(layouts[0].fields, f.Field)
+	}
+
+	var body bytes.Buffer
+	for _, l := range layouts {
+		fmt.Fprintf(&body, "\n// %s is %s with its fields in the %s order: %d bytes for %s.\n", l.typeName, name, l.label, l.size, goBuild.target(arch))
+		fmt.Fprintf(&body, "type %s struct {\n", l.typeName)
+		for _, f := range l.fields {
+			typ := types.TypeString(f.Type(), qualifier)
+			if f.Embedded() && embeddedName(typ) == f.Name() {
+				fmt.Fprintf(&body, "%s\n", typ)
+			} else {
+				fmt.Fprintf(&body, "%s %s\n", f.Name(), typ)
+			}
+		}
+		fmt.Fprintf(&body, "}\n")
+	}
+	if clash != nil {
+		return nil, clash
+	}
+	for _, l := range layouts {
+		fmt.Fprintf(&body, "\n// sum%s walks s, summing the fields of its elements it can.\n", strings.TrimPrefix(l.typeName, "bench"))
+		fmt.Fprintf(&body, "func sum%s(s []%s) (n uint64) {\n", strings.TrimPrefix(l.typeName, "bench"), l.typeName)
+		fmt.Fprintf(&body, "for i := range s {\n")
+		for _, f := range l.fields {
+			if f.Name() != "_" {
+				body.WriteString(sumField("s[i]."+f.Name(), f.Type()))
+			}
+		}
+		fmt.Fprintf(&body, "}\nreturn n\n}\n")
+	}
+
+	sink := "bench" + suffix + "Sink"
+	fmt.Fprintf(&body, "\n// %s keeps the results of the benchmarks alive.\n", sink)
+	fmt.Fprintf(&body, "var %s struct {\n", sink)
+	for _, l := range layouts {
+		fmt.Fprintf(&body, "%s []%s\n", l.label, l.typeName)
+	}
+	fmt.Fprintf(&body, "n uint64\n}\n")
+
+	fmt.Fprintf(&body, "\n// Benchmark%sLayout compares %s as declared with its optimal order,\n", suffix, name)
+	fmt.Fprintf(&body, "// allocating slices of %d elements and walking them.\n", benchLen)
+	fmt.Fprintf(&body, "func Benchmark%sLayout(b *testing.B) {\n", suffix)
+	for _, l := range layouts {
+		fmt.Fprintf(&body, "b.Run(%q, func(b *testing.B) {\n", l.label+"/alloc")
+		fmt.Fprintf(&body, "b.ReportAllocs()\nb.SetBytes(int64(unsafe.Sizeof(%s{})) * %d)\n", l.typeName, benchLen)
+		fmt.Fprintf(&body, "for i := 0; i < b.N; i++ {\n%s.%s = make([]%s, %d)\n}\n})\n", sink, l.label, l.typeName, benchLen)
+	}
+	for _, l := range layouts {
+		fmt.Fprintf(&body, "b.Run(%q, func(b *testing.B) {\n", l.label+"/walk")
+		fmt.Fprintf(&body, "s := make([]%s, %d)\n", l.typeName, benchLen)
+		fmt.Fprintf(&body, "b.SetBytes(int64(unsafe.Sizeof(%s{})) * %d)\nb.ResetTimer()\n", l.typeName, benchLen)
+		fmt.Fprintf(&body, "for i := 0; i < b.N; i++ {\n%s.n += sum%s(s)\n}\n})\n", sink, strings.TrimPrefix(l.typeName, "bench"))
+	}
+	fmt.Fprintf(&body, "}\n")
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by padding-size bench; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", pkg.Name)
+	paths := make([]string, 0, len(imports))
+	for path := range imports {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+	fmt.Fprintf(&b, "import (\n")
+	for _, path := range paths {
+		if imports[path] == path[strings.LastIndex(path, "/")+1:] {
+			fmt.Fprintf(&b, "%q\n", path)
+		} else {
+			fmt.Fprintf(&b, "%s %q\n", imports[path], path)
+		}
+	}
+	fmt.Fprintf(&b, ")\n")
+	b.Write(body.Bytes())
+
+	return format.Source(b.Bytes())
+}
+
+// uncopyable returns the type name in t that a file of the package pkg
+// cannot refer to: one unexported by another package, or declared in an
+// internal package pkg may not import. It returns nil if there is none.
+func uncopyable(t types.Type, pkg *types.Package) *types.TypeName {
+	switch t := t.(type) {
+	case *types.Alias:
+		if obj := t.Obj(); obj.Pkg() != nil && obj.Pkg() != pkg && (!obj.Exported() || !importable(obj.Pkg().Path(), pkg.Path())) {
+			return obj
+		}
+		if args := t.TypeArgs(); args != nil {
+			for i := range args.Len() {
+				if obj := uncopyable(args.At(i), pkg); obj != nil {
+					return obj
+				}
+			}
+		}
+	case *types.Named:
+		if obj := t.Obj(); obj.Pkg() != nil && obj.Pkg() != pkg && (!obj.Exported() || !importable(obj.Pkg().Path(), pkg.Path())) {
+			return obj
+		}
+		if args := t.TypeArgs(); args != nil {
+			for i := range args.Len() {
+				if obj := uncopyable(args.At(i), pkg); obj != nil {
+					return obj
+				}
+			}
+		}
+	case *types.Pointer:
+		return uncopyable(t.Elem(), pkg)
+	case *types.Slice:
+		return uncopyable(t.Elem(), pkg)
+	case *types.Array:
+		return uncopyable(t.Elem(), pkg)
+	case *types.Chan:
+		return uncopyable(t.Elem(), pkg)
+	case *types.Map:
+		if obj := uncopyable(t.Key(), pkg); obj != nil {
+			return obj
+		}
+		return uncopyable(t.Elem(), pkg)
+	case *types.Struct:
+		for i := range t.NumFields() {
+			if obj := uncopyable(t.Field(i).Type(), pkg); obj != nil {
+				return obj
+			}
+		}
+	case *types.Signature:
+		for _, tuple := range []*types.Tuple{t.Params(), t.Results()} {
+			for i := range tuple.Len() {
+				if obj := uncopyable(tuple.At(i).Type(), pkg); obj != nil {
+					return obj
+				}
+			}
+		}
+	case *types.Interface:
+		for i := range t.NumEmbeddeds() {
+			if obj := uncopyable(t.EmbeddedType(i), pkg); obj != nil {
+				return obj
+			}
+		}
+		for i := range t.NumExplicitMethods() {
+			if obj := uncopyable(t.ExplicitMethod(i).Type(), pkg); obj != nil {
+				return obj
+			}
+		}
+	}
+	return nil
+}
+
+// importable reports whether the package at path may be imported by the
+// package at from, as far as internal packages go.
+func importable(path, from string) bool {
+	var parent string
+	switch i := strings.LastIndex(path, "/internal/"); {
+	case i >= 0:
+		parent = path[:i]
+	case strings.HasSuffix(path, "/internal"):
+		parent = strings.TrimSuffix(path, "/internal")
+	case path == "internal" || strings.HasPrefix(path, "internal/"):
+		// Only the standard library imports its internal packages.
+		return false
+	default:
+		return true
+	}
+	return from == parent || strings.HasPrefix(from, parent+"/")
+}
+
+// embeddedName returns the field name of an embedded field of the type
+// written typ: the type name, without its package or pointer.
+func embeddedName(typ string) string {
+	typ = strings.TrimPrefix(typ, "*")
+	return typ[strings.LastIndex(typ, ".")+1:]
+}
+
+// sumField returns a statement adding to n what can be summed of the field
+// expression x of type t: its value if it is a number, whether it is set if
+// it is a bool or may be nil, its length if it has one. It returns nothing
+// for the fields of other types.
+func sumField(x string, t types.Type) string {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch info := u.Info(); {
+		case info&types.IsBoolean != 0:
+			return fmt.Sprintf("if %s {\nn++\n}\n", x)
+		case info&(types.IsInteger|types.IsFloat) != 0:
+			return fmt.Sprintf("n += uint64(%s)\n", x)
+		case info&types.IsComplex != 0:
+			return fmt.Sprintf("n += uint64(real(%s))\n", x)
+		case info&types.IsString != 0:
+			return fmt.Sprintf("n += uint64(len(%s))\n", x)
+		case u.Kind() == types.UnsafePointer:
+			return fmt.Sprintf("if %s != nil {\nn++\n}\n", x)
+		}
+	case *types.Slice, *types.Map, *types.Chan:
+		return fmt.Sprintf("n += uint64(len(%s))\n", x)
+	case *types.Pointer, *types.Signature, *types.Interface:
+		return fmt.Sprintf("if %s != nil {\nn++\n}\n", x)
+	}
+	return ""
+}
+
+// exportedName returns name with its first letter in upper case, to follow
+// a lower-case prefix in an identifier.
+func exportedName(name string) string {
+	r, n := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(r)) + name[n:]
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"go/token"
+	"go/types"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// benchFixture copies the module of testdata/bench to a new directory and
+// returns it.
+func benchFixture(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not available")
+	}
+	dir := t.TempDir()
+	if err := os.CopyFS(dir, os.DirFS(filepath.Join("testdata", "bench"))); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestBenchEndToEnd(t *testing.T) {
+	dir := benchFixture(t)
+
+	if code := runBench([]string{dir, "Record"}); code != 0 {
+		t.Fatalf("bench exited with %d", code)
+	}
+	src := readFile(t, filepath.Join(dir, "record_layout_bench_test.go"))
+	for _, want := range []string{
+		"// Code generated by padding-size bench; DO NOT EDIT.\n\npackage bench\n",
+		"\t\"example.com/bench/clock\"\n",
+		"type benchCurrentRecord struct {\n\tActive bool\n\t*Base\n",
+		"\tsync.Mutex\n",
+		"\tWhen  clock.Stamp\n",
+		"\tZone  clock.Zone\n",
+		"type benchOptimalRecord struct {\n\t*Base\n\tName   string\n",
+		"func BenchmarkRecordLayout(b *testing.B) {\n",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("generated file lacks %q:\n%s", want, src)
+		}
+	}
+
+	// Regenerating yields the same file.
+	again, err := generateBench(dir, "Record")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal([]byte(src), again) {
+		t.Errorf("regenerated file differs:\n%s\nfirst:\n%s", again, src)
+	}
+
+	cmd := exec.Command("go", "test", "-count=1", "-run=^$", "-bench=.", "-benchtime=1x", ".")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("go test: %v\n%s", err, out)
+	}
+	for _, want := range []string{"BenchmarkRecordLayout/current/alloc", "BenchmarkRecordLayout/optimal/alloc", "BenchmarkRecordLayout/current/walk", "BenchmarkRecordLayout/optimal/walk"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("benchmark output lacks %s:\n%s", want, out)
+		}
+	}
+}
+
+func TestBenchErrors(t *testing.T) {
+	dir := benchFixture(t)
+	for _, tt := range []struct {
+		name, want string
+	}{
+		{"Tight", "Tight is already laid out optimally, in 16 bytes"},
+		{"Generic", "Generic is generic"},
+		{"Count", "Count is not a struct type but int"},
+		{"Missing", "package example.com/bench declares no type Missing"},
+	} {
+		_, err := generateBench(dir, tt.name)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error %v, want it to contain %q", tt.name, err, tt.want)
+		}
+	}
+	if code := runBench([]string{dir}); code != 2 {
+		t.Errorf("bench without a type exited with %d, want 2", code)
+	}
+}
+
+func TestUncopyable(t *testing.T) {
+	pkg := types.NewPackage("example.com/app/store", "store")
+	other := types.NewPackage("example.com/app/clock", "clock")
+	internal := types.NewPackage("example.com/lib/internal/wire", "wire")
+	named := func(p *types.Package, name string) types.Type {
+		return types.NewNamed(types.NewTypeName(token.NoPos, p, name, nil), types.Typ[types.Int64], nil)
+	}
+	for _, tt := range []struct {
+		typ  types.Type
+		want string // name of the type that can't be named, if any
+	}{
+		{named(pkg, "local"), ""},
+		{named(other, "Stamp"), ""},
+		{types.NewPointer(named(other, "zone")), "zone"},
+		{types.NewMap(types.Typ[types.String], types.NewSlice(named(internal, "Frame"))), "Frame"},
+		{types.NewSignature(nil, types.NewTuple(types.NewVar(token.NoPos, nil, "z", named(other, "zone"))), nil, false), "zone"},
+	} {
+		got := ""
+		if obj := uncopyable(tt.typ, pkg); obj != nil {
+			got = obj.Name()
+		}
+		if got != tt.want {
+			t.Errorf("%s: found %q, want %q", tt.typ, got, tt.want)
+		}
+	}
+
+	for _, tt := range []struct {
+		path, from string
+		want       bool
+	}{
+		{"example.com/lib/internal/wire", "example.com/lib/server", true},
+		{"example.com/lib/internal", "example.com/lib", true},
+		{"example.com/lib/internal/wire", "example.com/app", false},
+		{"internal/abi", "example.com/app", false},
+		{"net/http", "example.com/app", true},
+	} {
+		if got := importable(tt.path, tt.from); got != tt.want {
+			t.Errorf("importable(%s, %s) = %t, want %t", tt.path, tt.from, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// checkResult is the outcome of -check on a report.
+type checkResult struct {
+	// offending lists the structs wasting more than the threshold, in
+	// report order.
+	offending []padding.StructReport
+	wasted    int64 // the bytes the offending structs waste together
+	failed    bool
+}
+
+// checkReport checks r: a struct offends if it wastes more than threshold
+// bytes, and the check fails if any does or, if total is not negative, if
+// the offending structs waste more than total bytes together.
+func checkReport(r padding.Report, threshold, total int64) checkResult {
+	var c checkResult
+	for _, s := range r.Structs {
+		if s.WastedBytes > threshold {
+			c.offending = append(c.offending, s)
+			c.wasted += s.WastedBytes
+		}
+	}
+	c.failed = len(c.offending) > 0 && (total < 0 || c.wasted > total)
+	return c
+}
+
+// write lists the offending structs to w, one per line, with a summary
+// line after them, as -check does in the text format.
+func (c checkResult) write(w io.Writer, threshold, total int64) error {
+	for _, s := range c.offending {
+		if _, err := fmt.Fprintf(w, "%s: %s: current %d bytes, optimal %d bytes, wasted %d\n",
+			s.Location(), s.Name, s.Size, s.OptimalSize, s.WastedBytes); err != nil {
+			return err
+		}
+	}
+	if len(c.offending) == 0 {
+		return nil
+	}
+	summary := fmt.Sprintf("Structs wasting more than %d bytes: %d, wasting %d bytes in total", threshold, len(c.offending), c.wasted)
+	if total >= 0 {
+		if c.failed {
+			summary += fmt.Sprintf(", over the total threshold of %d", total)
+		} else {
+			summary += fmt.Sprintf(", within the total threshold of %d", total)
+		}
+	}
+	_, err := fmt.Fprintln(w, summary)
+	return err
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runCaptured runs padding-size with args and returns its exit status and
+// what it wrote to stdout.
+func runCaptured(t *testing.T, args ...string) (int, string) {
+	t.Helper()
+	var out bytes.Buffer
+	saved := stdout
+	stdout = bufio.NewWriter(&out)
+	defer func() { stdout = saved }()
+	code := run(args)
+	stdout.Flush()
+	return code, out.String()
+}
+
+func TestRunCheck(t *testing.T) {
+	dir := filepath.Join("testdata", "json")
+	tests := []struct {
+		name string
+		args []string
+		code int
+		out  []string // lines of the output, in order
+	}{
+		{
+			name: "any waste",
+			args: []string{"-check", dir},
+			code: 1,
+			out: []string{
+				"testdata/json/fixture.go:4:6: Loose: current 24 bytes, optimal 16 bytes, wasted 8",
+				"testdata/json/other.go:4:6: Pair: current 12 bytes, optimal 8 bytes, wasted 4",
+				"Structs wasting more than 0 bytes: 2, wasting 12 bytes in total",
+			},
+		},
+		{
+			name: "threshold",
+			args: []string{"-check", "-threshold", "4", dir},
+			code: 1,
+			out: []string{
+				"testdata/json/fixture.go:4:6: Loose: current 24 bytes, optimal 16 bytes, wasted 8",
+				"Structs wasting more than 4 bytes: 1, wasting 8 bytes in total",
+			},
+		},
+		{
+			name: "under threshold",
+			args: []string{"-check", "-threshold", "8", dir},
+			code: 0,
+		},
+		{
+			name: "within total",
+			args: []string{"-check", "-threshold-total", "12", dir},
+			code: 0,
+			out: []string{
+				"testdata/json/fixture.go:4:6: Loose: current 24 bytes, optimal 16 bytes, wasted 8",
+				"testdata/json/other.go:4:6: Pair: current 12 bytes, optimal 8 bytes, wasted 4",
+				"Structs wasting more than 0 bytes: 2, wasting 12 bytes in total, within the total threshold of 12",
+			},
+		},
+		{
+			name: "over total",
+			args: []string{"-check", "-threshold", "1", "-threshold-total", "11", dir},
+			code: 1,
+			out: []string{
+				"testdata/json/fixture.go:4:6: Loose: current 24 bytes, optimal 16 bytes, wasted 8",
+				"testdata/json/other.go:4:6: Pair: current 12 bytes, optimal 8 bytes, wasted 4",
+				"Structs wasting more than 1 bytes: 2, wasting 12 bytes in total, over the total threshold of 11",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, out := runCaptured(t, tt.args...)
+			if code != tt.code {
+				t.Errorf("exit status %d, want %d", code, tt.code)
+			}
+			var want string
+			if tt.out != nil {
+				want = strings.Join(tt.out, "\n") + "\n"
+			}
+			if out != want {
+				t.Errorf("output:\n%s\nwant:\n%s", out, want)
+			}
+		})
+	}
+}
+
+func TestRunExitCodes(t *testing.T) {
+	dir := t.TempDir()
+	broken := filepath.Join(dir, "broken.go")
+	if err := os.WriteFile(broken, []byte("package p\ntype T struct {\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{"report", []string{filepath.Join("testdata", "json")}, 0},
+		{"json check", []string{"-check", "-format=json", filepath.Join("testdata", "json")}, 1},
+		{"parse error", []string{"-check", broken}, 2},
+		{"parse error without check", []string{broken}, 2},
+		{"missing path", []string{"-check", filepath.Join(dir, "missing.go")}, 2},
+		{"threshold without check", []string{"-threshold", "8", dir}, 2},
+		{"negative threshold", []string{"-check", "-threshold", "-1", dir}, 2},
+		{"check with fix", []string{"-check", "-fix", dir}, 2},
+		{"unknown flag", []string{"-no-such-flag", dir}, 2},
+	}
+	for _, tt := range tests {
+		if code, _ := runCaptured(t, tt.args...); code != tt.code {
+			t.Errorf("%s: exit status %d, want %d", tt.name, code, tt.code)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// runCompare runs the compare subcommand, which diffs two JSON reports and
+// exits with status 1 if the second one has regressions.
+func runCompare(args []string) int {
+	fs := flag.NewFlagSet("compare", flag.ContinueOnError)
+	format := fs.String("format", "text", "Output `format`: text or markdown")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: padding-size compare [options] <old.json> <new.json>")
+		fs.PrintDefaults()
+	}
+
+	paths, err := parseArgs(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(paths) != 2 || *format != "text" && *format != "markdown" {
+		fs.Usage()
+		return 2
+	}
+	before, err := loadReport(paths[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	after, err := loadReport(paths[1])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	c := compareReports(before, after)
+	if err := writeComparison(os.Stdout, c, *format == "markdown"); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if c.regressed() {
+		return 1
+	}
+	return 0
+}
+
+// writeJSON writes r to w as indented JSON.
+func writeJSON(w io.Writer, r padding.Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// loadReport reads the JSON report at path, which must have the major
+// schema version of this build.
+func loadReport(path string) (padding.Report, error) {
+	var r padding.Report
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return r, err
+	}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return r, fmt.Errorf("%s: %v", path, err)
+	}
+	major, _, _ := strings.Cut(padding.SchemaVersion, ".")
+	if got, _, _ := strings.Cut(r.SchemaVersion, "."); got != major {
+		return r, fmt.Errorf("%s: schema version %q, want %s.x", path, r.SchemaVersion, major)
+	}
+	return r, nil
+}
+
+// structChange is a struct present in either report of a comparison, or in
+// both. Before or After is nil for a struct that was added or removed.
+type structChange struct {
+	Before, After *padding.StructReport
+}
+
+// comparison is the difference between two reports.
+type comparison struct {
+	Worsened []structChange // wasting more bytes or larger
+	Improved []structChange // wasting fewer bytes or smaller
+	Added    []structChange // new structs wasting space
+	Removed  []structChange
+
+	// WastedBefore and WastedAfter are the bytes wasted by all the structs
+	// of each report.
+	WastedBefore, WastedAfter int64
+}
+
+// regressed reports whether a struct got worse or a new struct wastes space.
+func (c comparison) regressed() bool {
+	return len(c.Worsened) > 0 || len(c.Added) > 0
+}
+
+// compareKeys returns the keys structs are matched by: the package and
+// struct name, with the build variants if any, so that a struct is matched
+// wherever its file moved. Structs with the same key are told apart by the
+// order they appear in.
+func compareKeys(r padding.Report) []string {
+	keys := make([]string, len(r.Structs))
+	seen := make(map[string]int)
+	for i, s := range r.Structs {
+		key := s.Package + "." + s.Name
+		if len(s.Variants) > 0 {
+			key += " [" + strings.Join(s.Variants, ", ") + "]"
+		}
+		seen[key]++
+		if n := seen[key]; n > 1 {
+			key = fmt.Sprintf("%s#%d", key, n)
+		}
+		keys[i] = key
+	}
+	return keys
+}
+
+// compareReports compares the structs of before and after.
+func compareReports(before, after padding.Report) comparison {
+	var c comparison
+	old := make(map[string]*padding.StructReport)
+	for i, key := range compareKeys(before) {
+		s := &before.Structs[i]
+		old[key] = s
+		c.WastedBefore += s.WastedBytes
+	}
+	for i, key := range compareKeys(after) {
+		s := &after.Structs[i]
+		c.WastedAfter += s.WastedBytes
+		b, ok := old[key]
+		delete(old, key)
+		switch {
+		case !ok:
+			if s.WastedBytes > 0 {
+				c.Added = append(c.Added, structChange{After: s})
+			}
+		case s.WastedBytes > b.WastedBytes || s.Size > b.Size:
+			c.Worsened = append(c.Worsened, structChange{b, s})
+		case s.WastedBytes < b.WastedBytes || s.Size < b.Size:
+			c.Improved = append(c.Improved, structChange{b, s})
+		}
+	}
+	for i, key := range compareKeys(before) {
+		if _, ok := old[key]; ok {
+			c.Removed = append(c.Removed, structChange{Before: &before.Structs[i]})
+		}
+	}
+	return c
+}
+
+// summary returns a sentence on the change of the total bytes wasted.
+func (c comparison) summary() string {
+	switch delta := c.WastedAfter - c.WastedBefore; {
+	case delta > 0:
+		return fmt.Sprintf("This change adds %d bytes of padding (%d → %d).", delta, c.WastedBefore, c.WastedAfter)
+	case delta < 0:
+		return fmt.Sprintf("This change removes %d bytes of padding (%d → %d).", -delta, c.WastedBefore, c.WastedAfter)
+	}
+	return fmt.Sprintf("This change does not change the padding (%d bytes).", c.WastedAfter)
+}
+
+// writeComparison writes c to w as text, or as Markdown suitable for a pull
+// request comment.
+func writeComparison(w io.Writer, c comparison, markdown bool) error {
+	sections := []struct {
+		title   string
+		changes []structChange
+	}{
+		{"Worsened", c.Worsened},
+		{"New structs with padding", c.Added},
+		{"Improved", c.Improved},
+		{"Removed", c.Removed},
+	}
+
+	var b strings.Builder
+	if markdown {
+		fmt.Fprintf(&b, "### Struct padding\n\n%s\n", c.summary())
+	} else {
+		fmt.Fprintf(&b, "%s\n", c.summary())
+	}
+	for _, section := range sections {
+		if len(section.changes) == 0 {
+			continue
+		}
+		if markdown {
+			fmt.Fprintf(&b, "\n#### %s (%d)\n\n", section.title, len(section.changes))
+			fmt.Fprintf(&b, "| Struct | Size | Wasted |\n|---|---:|---:|\n")
+		} else {
+			fmt.Fprintf(&b, "\n%s (%d):\n", section.title, len(section.changes))
+		}
+		for _, ch := range section.changes {
+			name, size, wasted := ch.describe()
+			if markdown {
+				fmt.Fprintf(&b, "| `%s` | %s | %s |\n", name, size, wasted)
+			} else {
+				fmt.Fprintf(&b, "  %s: size %s, wasted %s\n", name, size, wasted)
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// describe returns the name of the changed struct and its size and bytes
+// wasted, before and after if it is in both reports.
+func (ch structChange) describe() (name, size, wasted string) {
+	s := ch.After
+	if s == nil {
+		s = ch.Before
+	}
+	name = s.Package + "." + s.Name
+	if len(s.Variants) > 0 {
+		name += " [" + strings.Join(s.Variants, ", ") + "]"
+	}
+	if ch.Before == nil || ch.After == nil {
+		return name, fmt.Sprint(s.Size), fmt.Sprint(s.WastedBytes)
+	}
+	return name, change(ch.Before.Size, ch.After.Size), change(ch.Before.WastedBytes, ch.After.WastedBytes)
+}
+
+// change formats the change of a value, as in 24 → 32 (+8).
+func change(before, after int64) string {
+	if before == after {
+		return fmt.Sprint(after)
+	}
+	return fmt.Sprintf("%d → %d (%+d)", before, after, after-before)
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func structReport(file, pkg, name string, size, wasted int64) padding.StructReport {
+	return padding.StructReport{File: file, Package: pkg, Name: name, Size: size, OptimalSize: size - wasted, WastedBytes: wasted}
+}
+
+var (
+	baseReport = padding.NewReport(
+		structReport("a.go", "cache", "Entry", 24, 8),
+		structReport("a.go", "cache", "Stats", 32, 16),
+		structReport("b.go", "cache", "Old", 16, 8),
+		structReport("b.go", "cache", "Same", 16, 0),
+	)
+	headReport = padding.NewReport(
+		structReport("moved/a.go", "cache", "Entry", 32, 16), // worsened
+		structReport("a.go", "cache", "Stats", 16, 0),        // improved
+		structReport("b.go", "cache", "Same", 16, 0),
+		structReport("c.go", "cache", "Fresh", 24, 8),  // added with waste
+		structReport("c.go", "cache", "Packed", 16, 0), // added without waste
+	)
+)
+
+func names(changes []structChange) string {
+	var names []string
+	for _, ch := range changes {
+		name, _, _ := ch.describe()
+		names = append(names, name)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestCompareReports(t *testing.T) {
+	c := compareReports(baseReport, headReport)
+	for _, tt := range []struct {
+		section string
+		changes []structChange
+		want    string
+	}{
+		{"worsened", c.Worsened, "cache.Entry"},
+		{"improved", c.Improved, "cache.Stats"},
+		{"added", c.Added, "cache.Fresh"},
+		{"removed", c.Removed, "cache.Old"},
+	} {
+		if got := names(tt.changes); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.section, got, tt.want)
+		}
+	}
+	if c.WastedBefore != 32 || c.WastedAfter != 24 {
+		t.Errorf("wasted %d → %d, want 32 → 24", c.WastedBefore, c.WastedAfter)
+	}
+	if !c.regressed() {
+		t.Error("regressed = false, want true")
+	}
+	if c := compareReports(headReport, headReport); c.regressed() || len(c.Improved)+len(c.Removed) > 0 {
+		t.Errorf("comparing a report with itself: %+v", c)
+	}
+}
+
+func TestCompareVariants(t *testing.T) {
+	linux := structReport("x_linux.go", "x", "Conn", 24, 8)
+	linux.Variants = []string{"linux"}
+	windows := structReport("x_windows.go", "x", "Conn", 16, 0)
+	windows.Variants = []string{"windows"}
+	worse := windows
+	worse.Size, worse.WastedBytes = 24, 8
+
+	c := compareReports(padding.NewReport(linux, windows), padding.NewReport(linux, worse))
+	if got, want := names(c.Worsened), "x.Conn [windows]"; got != want || len(c.Added)+len(c.Removed) > 0 {
+		t.Errorf("worsened = %q, want %q; comparison %+v", got, want, c)
+	}
+}
+
+func TestWriteComparison(t *testing.T) {
+	c := compareReports(baseReport, headReport)
+	var b strings.Builder
+	if err := writeComparison(&b, c, false); err != nil {
+		t.Fatal(err)
+	}
+	want := `This change removes 8 bytes of padding (32 → 24).
+
+Worsened (1):
+  cache.Entry: size 24 → 32 (+8), wasted 8 → 16 (+8)
+
+New structs with padding (1):
+  cache.Fresh: size 24, wasted 8
+
+Improved (1):
+  cache.Stats: size 32 → 16 (-16), wasted 16 → 0 (-16)
+
+Removed (1):
+  cache.Old: size 16, wasted 8
+`
+	if b.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", b.String(), want)
+	}
+
+	b.Reset()
+	if err := writeComparison(&b, compareReports(baseReport, padding.NewReport(baseReport.Structs[:1]...)), true); err != nil {
+		t.Fatal(err)
+	}
+	want = "### Struct padding\n\n" +
+		"This change removes 24 bytes of padding (32 → 8).\n\n" +
+		"#### Removed (3)\n\n" +
+		"| Struct | Size | Wasted |\n|---|---:|---:|\n" +
+		"| `cache.Stats` | 32 | 16 |\n" +
+		"| `cache.Old` | 16 | 8 |\n" +
+		"| `cache.Same` | 16 | 0 |\n"
+	if b.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestRunCompare(t *testing.T) {
+	dir := t.TempDir()
+	save := func(name string, r padding.Report) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		f, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if err := writeJSON(f, r); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	base, head := save("base.json", baseReport), save("head.json", headReport)
+	future := filepath.Join(dir, "future.json")
+	data, _ := json.Marshal(map[string]any{"schema_version": "2.0", "structs": []any{}})
+	if err := os.WriteFile(future, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = devNull, devNull
+	defer func() { os.Stdout, os.Stderr = stdout, stderr }()
+
+	for _, tt := range []struct {
+		args []string
+		want int
+	}{
+		{[]string{base, head}, 1},
+		{[]string{head, base}, 1}, // Old is back with its waste
+		{[]string{head, head}, 0},
+		{[]string{"-format=markdown", head, head}, 0},
+		{[]string{base, future}, 2},
+		{[]string{base}, 2},
+	} {
+		if got := runCompare(tt.args); got != tt.want {
+			t.Errorf("compare %q = %d, want %d", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestLoadReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := os.WriteFile(path, []byte(`{"schema_version": "1.0", "structs": [{"name": "T", "size": 8}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := loadReport(path)
+	if err != nil || len(r.Structs) != 1 || r.Structs[0].Size != 8 {
+		t.Errorf("loadReport = %+v, %v", r, err)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"schema_version": "2.1", "structs": []}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = loadReport(path)
+	if want := path + `: schema version "2.1", want 1.x`; err == nil || err.Error() != want {
+		t.Errorf("err = %v, want %s", err, want)
+	}
+}
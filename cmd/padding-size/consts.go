@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// constsOptions configures the generated size constants.
+type constsOptions struct {
+	Types []string // struct types to generate constants for; all if empty
+	Arch  string   // GOARCH to compute layouts for; the go command's if empty
+	Tag   bool     // add a //go:build line for Arch
+}
+
+// runGenConsts implements the gen-consts subcommand:
+//
+//	padding-size gen-consts [-o file] [-types T1,T2] [-arch a1,a2] dir
+//
+// It returns the process exit code.
+func runGenConsts(args []string) int {
+	fs := flag.NewFlagSet("gen-consts", flag.ContinueOnError)
+	output := fs.String("o", "sizes_gen.go", "Write the constants to `file`, relative to the package directory")
+	typeList := fs.String("types", "", "Comma-separated `names` of the struct types (default all)")
+	archList := fs.String("arch", "", "Comma-separated `GOARCH` values; several produce one build-tagged file each")
+	goBuild.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: padding-size gen-consts [options] <package directory>")
+		fs.PrintDefaults()
+	}
+
+	dirs, err := parseArgs(fs, args)
+	if err != nil {
+		return 2
+	}
+	if err := goBuild.resolve(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if len(dirs) != 1 {
+		fs.Usage()
+		return 2
+	}
+	dir := dirs[0]
+
+	var opts constsOptions
+	if *typeList != "" {
+		for _, name := range strings.Split(*typeList, ",") {
+			opts.Types = append(opts.Types, strings.TrimSpace(name))
+		}
+	}
+	var arches []string
+	if *archList != "" {
+		for _, arch := range strings.Split(*archList, ",") {
+			arches = append(arches, strings.TrimSpace(arch))
+		}
+	}
+
+	path := *output
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	outputs := map[string]string{"": path}
+	if len(arches) == 1 {
+		outputs = map[string]string{arches[0]: path}
+	}
+	if len(arches) > 1 {
+		// sizes_gen.go becomes sizes_gen_amd64.go, sizes_gen_arm64.go, ...
+		opts.Tag = true
+		outputs = make(map[string]string)
+		ext := filepath.Ext(path)
+		for _, arch := range arches {
+			outputs[arch] = strings.TrimSuffix(path, ext) + "_" + arch + ext
+		}
+	}
+
+	for arch, path := range outputs {
+		opts.Arch = arch
+		src, err := generateConsts(dir, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		if err := os.WriteFile(path, src, 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+	return 0
+}
+
+// generateConsts type-checks the package in dir and returns the source of a
+// file declaring SizeOfT and AlignOfT constants for its struct types T.
+// Unexported types get unexported constants.
+func generateConsts(dir string, opts constsOptions) ([]byte, error) {
+	pkgName, structs, err := loadStructs(dir, opts.Arch, opts.Types)
+	if err != nil {
+		return nil, err
+	}
+	arch := opts.Arch
+	if arch == "" {
+		if arch, err = goarch(dir); err != nil {
+			return nil, err
+		}
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by padding-size gen-consts; DO NOT EDIT.\n\n")
+	if opts.Tag {
+		fmt.Fprintf(&b, "//go:build %s\n\n", arch)
+	}
+	fmt.Fprintf(&b, "package %s\n\n", pkgName)
+	fmt.Fprintf(&b, "// Sizes and alignments of struct types for %s.\n", goBuild.target(arch))
+	fmt.Fprintf(&b, "const (\n")
+	for i, s := range structs {
+		if i > 0 {
+			fmt.Fprintf(&b, "\n")
+		}
+		fmt.Fprintf(&b, "%s = %d\n", constName("SizeOf", s.name), s.size)
+		fmt.Fprintf(&b, "%s = %d\n", constName("AlignOf", s.name), s.align)
+	}
+	fmt.Fprintf(&b, ")\n")
+
+	return format.Source(b.Bytes())
+}
+
+// constName returns prefix followed by the type name, unexported if the
+// type is.
+func constName(prefix, typeName string) string {
+	r, n := utf8.DecodeRuneInString(typeName)
+	if unicode.IsUpper(r) {
+		return prefix + typeName
+	}
+	return strings.ToLower(prefix[:1]) + prefix[1:] + string(unicode.ToUpper(r)) + typeName[n:]
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenConstsGolden(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "sizes_gen.go")
+	fixture := filepath.Join("testdata", "wire")
+
+	if code := runGenConsts([]string{fixture, "-arch", "amd64", "-o", out}); code != 0 {
+		t.Fatalf("gen-consts exited with %d", code)
+	}
+	if code := runGenConsts([]string{"-arch", "386,amd64", "-o", out, fixture}); code != 0 {
+		t.Fatalf("gen-consts exited with %d", code)
+	}
+
+	for _, name := range []string{"sizes_gen.go", "sizes_gen_amd64.go", "sizes_gen_386.go"} {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := os.ReadFile(filepath.Join("testdata", "gen-consts", name+".golden"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("%s:\n%s\nwant:\n%s", name, got, want)
+		}
+	}
+}
+
+func TestGenConstsSelection(t *testing.T) {
+	src, err := generateConsts(filepath.Join("testdata", "wire"), constsOptions{Types: []string{"state"}, Arch: "arm64"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `// Code generated by padding-size gen-consts; DO NOT EDIT.
+
+package wire
+
+// Sizes and alignments of struct types for GOARCH=arm64.
+const (
+	sizeOfState  = 8
+	alignOfState = 4
+)
+`
+	if string(src) != want {
+		t.Errorf("generated:\n%s\nwant:\n%s", src, want)
+	}
+
+	if _, err := generateConsts(filepath.Join("testdata", "wire"), constsOptions{Types: []string{"Count"}}); err == nil {
+		t.Error("generating constants for a non-struct type succeeded")
+	}
+	if _, err := generateConsts(filepath.Join("testdata", "wire"), constsOptions{Arch: "pdp11"}); err == nil {
+		t.Error("generating constants for an unknown architecture succeeded")
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"cmp"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// instanceCounts holds the expected number of instances of structs, given
+// with -count and -counts, keyed by the struct name, optionally qualified by
+// its package name as in cache.Entry. It is safe for concurrent use.
+type instanceCounts struct {
+	mu     sync.Mutex
+	counts map[string]int64
+	order  []string        // keys in the order they were given
+	used   map[string]bool // keys that matched a struct
+}
+
+// String implements flag.Value for -count.
+func (c *instanceCounts) String() string {
+	if c == nil {
+		return ""
+	}
+	var parts []string
+	for _, name := range c.order {
+		parts = append(parts, fmt.Sprintf("%s=%d", name, c.counts[name]))
+	}
+	return strings.Join(parts, ",")
+}
+
+// Set implements flag.Value for -count, parsing Struct=N.
+func (c *instanceCounts) Set(s string) error {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return fmt.Errorf("invalid count %q: want Struct=N", s)
+	}
+	return c.add(name, value)
+}
+
+func (c *instanceCounts) add(name, value string) error {
+	name = strings.TrimSpace(name)
+	n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || n < 0 {
+		return fmt.Errorf("invalid count %q for %s", value, name)
+	}
+	if name == "" {
+		return errors.New("missing struct name")
+	}
+	if c.counts == nil {
+		c.counts = make(map[string]int64)
+		c.used = make(map[string]bool)
+	}
+	if _, ok := c.counts[name]; ok {
+		return fmt.Errorf("duplicate count for %s", name)
+	}
+	c.counts[name] = n
+	c.order = append(c.order, name)
+	return nil
+}
+
+// readCSV adds the counts of the CSV file at path, whose records are
+// type,count. A first record that is not a count, such as type,count, is
+// taken as a header and skipped.
+func (c *instanceCounts) readCSV(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = 2
+	r.Comment = '#'
+	r.TrimLeadingSpace = true
+	for line := 1; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		if line == 1 {
+			if _, err := strconv.ParseInt(strings.TrimSpace(record[1]), 10, 64); err != nil {
+				continue
+			}
+		}
+		if err := c.add(record[0], record[1]); err != nil {
+			row, _ := r.FieldPos(0)
+			return fmt.Errorf("%s:%d: %v", path, row, err)
+		}
+	}
+}
+
+// empty reports whether no counts were given.
+func (c *instanceCounts) empty() bool {
+	return len(c.counts) == 0
+}
+
+// weigh sets the instances and the recoverable bytes of r if it has a
+// count. A count for the qualified name takes precedence.
+func (c *instanceCounts) weigh(r *padding.StructReport) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, name := range []string{r.Package + "." + r.Name, r.Name} {
+		if n, ok := c.counts[name]; ok {
+			c.used[name] = true
+			r.Instances = n
+			r.RecoverableBytes = n * r.WastedBytes
+			return
+		}
+	}
+}
+
+// unknown returns the names that matched no struct, in the order given.
+func (c *instanceCounts) unknown() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var names []string
+	for _, name := range c.order {
+		if !c.used[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// writeRecoverable writes the structs of r that have an instance count to
+// w, with the memory recoverable by reordering their fields, and the total.
+// If any of them has nested waste, inside the elements of array fields, the
+// memory reordering the element types recovers is listed in columns of its
+// own. Structs are listed in the order of r, or by decreasing recoverable
+// memory if byRecoverable is set. If top is positive, only the first top
+// structs are listed; the totals still cover all of them.
+func writeRecoverable(w io.Writer, r padding.Report, byRecoverable bool, top int) error {
+	var structs []padding.StructReport
+	var total, nestedTotal int64
+	for _, s := range r.Structs {
+		if s.Instances > 0 {
+			structs = append(structs, s)
+			total += s.RecoverableBytes
+			nestedTotal += s.Instances * s.NestedWaste
+		}
+	}
+	if byRecoverable {
+		slices.SortStableFunc(structs, func(a, b padding.StructReport) int {
+			return cmp.Compare(b.RecoverableBytes, a.RecoverableBytes)
+		})
+	}
+	if top > 0 && len(structs) > top {
+		structs = structs[:top]
+	}
+
+	fmt.Fprintf(w, "Recoverable memory (instance counts):\n")
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	if nestedTotal == 0 {
+		fmt.Fprintf(tw, "INSTANCES\tWASTED/OBJECT\tRECOVERABLE\t  STRUCT\n")
+		for _, s := range structs {
+			fmt.Fprintf(tw, "%d\t%d\t%s\t  %s.%s (%s)\n", s.Instances, s.WastedBytes, formatBytes(s.RecoverableBytes), s.Package, s.Name, s.File)
+		}
+		fmt.Fprintf(tw, "\t\t%s\t  total\n", formatBytes(total))
+	} else {
+		fmt.Fprintf(tw, "INSTANCES\tWASTED/OBJECT\tRECOVERABLE\tNESTED/OBJECT\tNESTED WASTE\t  STRUCT\n")
+		for _, s := range structs {
+			fmt.Fprintf(tw, "%d\t%d\t%s\t%d\t%s\t  %s.%s (%s)\n", s.Instances, s.WastedBytes, formatBytes(s.RecoverableBytes),
+				s.NestedWaste, formatBytes(s.Instances*s.NestedWaste), s.Package, s.Name, s.File)
+		}
+		fmt.Fprintf(tw, "\t\t%s\t\t%s\t  total\n", formatBytes(total), formatBytes(nestedTotal))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// formatBytes formats n bytes, with a binary unit for large amounts, as in
+// 1536 (1.5 KiB).
+func formatBytes(n int64) string {
+	const units = "KMGTPE"
+	if n < 1024 {
+		return strconv.FormatInt(n, 10)
+	}
+	v, i := float64(n)/1024, 0
+	for v >= 1024 && i < len(units)-1 {
+		v /= 1024
+		i++
+	}
+	return fmt.Sprintf("%d (%.1f %ciB)", n, v, units[i])
+}
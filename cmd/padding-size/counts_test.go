@@ -0,0 +1,175 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestInstanceCounts(t *testing.T) {
+	var counts instanceCounts
+	for _, arg := range []string{"Node=1000", "allocs.Sample=3", "Sample=5", "Missing=7"} {
+		if err := counts.Set(arg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dir := filepath.Join("testdata", "allocs")
+	opts := options{collect: new(reportCollector), counts: &counts}
+	captureReport(t, func() error { return processPath(dir, opts, newFileRegistry()) })
+
+	want := []struct {
+		name                   string
+		instances, recoverable int64
+	}{
+		{"Node", 1000, 8000},
+		{"Sample", 3, 24}, // the qualified name takes precedence
+		{"Buffer", 0, 0},
+		{"Config", 0, 0},
+		{"Node", 0, 0}, // declared in a function
+	}
+	structs := opts.collect.report().Structs
+	if len(structs) != len(want) {
+		t.Fatalf("got %d structs, want %d", len(structs), len(want))
+	}
+	for i, s := range structs {
+		if s.Name != want[i].name || s.Instances != want[i].instances || s.RecoverableBytes != want[i].recoverable {
+			t.Errorf("struct %d: %s with %d instances and %d recoverable bytes, want %s with %d and %d",
+				i, s.Name, s.Instances, s.RecoverableBytes, want[i].name, want[i].instances, want[i].recoverable)
+		}
+	}
+	if got, want := counts.unknown(), []string{"Sample", "Missing"}; !slices.Equal(got, want) {
+		t.Errorf("unknown = %q, want %q", got, want)
+	}
+}
+
+func TestInstanceCountsErrors(t *testing.T) {
+	var counts instanceCounts
+	for _, arg := range []string{"Node", "Node=x", "Node=-1", "=3"} {
+		if err := counts.Set(arg); err == nil {
+			t.Errorf("Set(%q) succeeded", arg)
+		}
+	}
+	if err := counts.Set("Node=1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := counts.Set("Node=2"); err == nil || err.Error() != "duplicate count for Node" {
+		t.Errorf("duplicate count: err = %v", err)
+	}
+}
+
+func TestInstanceCountsCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counts.csv")
+	if err := os.WriteFile(path, []byte("type,count\n# from production\nNode, 1000\nallocs.Sample,3\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var counts instanceCounts
+	if err := counts.readCSV(path); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := counts.String(), "Node=1000,allocs.Sample=3"; got != want {
+		t.Errorf("counts = %s, want %s", got, want)
+	}
+
+	if err := os.WriteFile(path, []byte("Node,1000\nSample,many\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	counts = instanceCounts{}
+	err := counts.readCSV(path)
+	if want := path + `:2: invalid count "many" for Sample`; err == nil || err.Error() != want {
+		t.Errorf("err = %v, want %s", err, want)
+	}
+}
+
+func TestWriteRecoverable(t *testing.T) {
+	var counts instanceCounts
+	for _, arg := range []string{"Sample=3", "Node=1000", "Config=200"} {
+		if err := counts.Set(arg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dir := filepath.Join("testdata", "allocs")
+	opts := options{collect: new(reportCollector), counts: &counts}
+	captureReport(t, func() error { return processPath(dir, opts, newFileRegistry()) })
+	file := filepath.Join(dir, "allocs.go")
+
+	var b strings.Builder
+	if err := writeRecoverable(&b, opts.collect.report(), true, 2); err != nil {
+		t.Fatal(err)
+	}
+	want := "Recoverable memory (instance counts):\n" +
+		"  INSTANCES  WASTED/OBJECT     RECOVERABLE  STRUCT\n" +
+		"       1000              8  8000 (7.8 KiB)  allocs.Node (" + file + ")\n" +
+		"        200              8  1600 (1.6 KiB)  allocs.Config (" + file + ")\n" +
+		"                            9624 (9.4 KiB)  total\n\n"
+	if b.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", b.String(), want)
+	}
+
+	b.Reset()
+	if err := writeRecoverable(&b, opts.collect.report(), false, 0); err != nil {
+		t.Fatal(err)
+	}
+	var order []string
+	for _, line := range strings.Split(b.String(), "\n") {
+		if i := strings.Index(line, "allocs."); i >= 0 {
+			name, _, _ := strings.Cut(line[i:], " ")
+			order = append(order, name)
+		}
+	}
+	if want := []string{"allocs.Node", "allocs.Sample", "allocs.Config"}; !slices.Equal(order, want) {
+		t.Errorf("source order = %q, want %q", order, want)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	for n, want := range map[int64]string{
+		0:       "0",
+		1023:    "1023",
+		1536:    "1536 (1.5 KiB)",
+		3 << 30: "3221225472 (3.0 GiB)",
+	} {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestWriteRecoverableNestedWaste(t *testing.T) {
+	var counts instanceCounts
+	for _, arg := range []string{"Table=10", "Entry=100"} {
+		if err := counts.Set(arg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dir := filepath.Join("testdata", "elements")
+	opts := options{collect: new(reportCollector), counts: &counts}
+	report := captureReport(t, func() error { return processPath(dir, opts, newFileRegistry()) })
+	file := filepath.Join(dir, "elements.go")
+
+	for _, line := range []string{
+		"Struct: Table (size: 24888 bytes (alloc 27264), align: 8, nested waste: 8192 bytes)\n",
+		"  entries [1024]Entry (offset: 0, size: 24576, align: 8, nested waste: 8192 bytes, 8 per element)\n",
+		"  packed [16]Packed (offset: 24576, size: 256, align: 8)\n",
+		"  recent []Entry (offset: 24832, size: 24, align: 8, 8 bytes wasted per element)\n",
+		"  ptrs []*Entry (offset: 24856, size: 24, align: 8)\n",
+	} {
+		if !strings.Contains(report, line) {
+			t.Errorf("report lacks %q:\n%s", line, report)
+		}
+	}
+
+	var b strings.Builder
+	if err := writeRecoverable(&b, opts.collect.report(), false, 0); err != nil {
+		t.Fatal(err)
+	}
+	want := "Recoverable memory (instance counts):\n" +
+		"  INSTANCES  WASTED/OBJECT  RECOVERABLE  NESTED/OBJECT      NESTED WASTE  STRUCT\n" +
+		"        100              8          800              0                 0  elements.Entry (" + file + ")\n" +
+		"         10              0            0           8192  81920 (80.0 KiB)  elements.Table (" + file + ")\n" +
+		"                                    800                 81920 (80.0 KiB)  total\n\n"
+	if b.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
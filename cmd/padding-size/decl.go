@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// declPosition parses a file:line position as given to -decl and -fix-decl.
+// An empty position is taken from the GOFILE and GOLINE variables that go
+// generate sets, so that
+//
+//	//go:generate padding-size -fix-decl=
+//
+// above a struct declaration refers to that declaration.
+func declPosition(pos string) (string, int, error) {
+	if pos == "" {
+		file, line := os.Getenv("GOFILE"), os.Getenv("GOLINE")
+		if file == "" || line == "" {
+			return "", 0, errors.New("no file:line given and GOFILE/GOLINE not set; run from go generate or pass file:line")
+		}
+		pos = file + ":" + line
+	}
+	i := strings.LastIndexByte(pos, ':')
+	if i < 0 {
+		return "", 0, fmt.Errorf("invalid position %q: want file:line", pos)
+	}
+	line, err := strconv.Atoi(pos[i+1:])
+	if err != nil || line < 1 {
+		return "", 0, fmt.Errorf("invalid line in position %q", pos)
+	}
+	return pos[:i], line, nil
+}
+
+// runDecl implements -decl and -fix-decl for the position pos.
+func runDecl(pos string, fix bool) error {
+	path, line, err := declPosition(pos)
+	if err != nil {
+		return err
+	}
+	return processDecl(path, line, fix)
+}
+
+// processDecl reports the struct type declared at line of path and, if fix is
+// set, reorders its fields. Everything outside the declaration is left
+// byte-identical.
+func processDecl(path string, line int, fix bool) error {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments|parser.SkipObjectResolution|parser.AllErrors)
+	if err != nil {
+		return newSourceError(path, src, file, err)
+	}
+	spec, err := typeSpecAt(fset, file, line)
+	if err != nil {
+		return fmt.Errorf("%s:%d: %v", path, line, err)
+	}
+	st, ok := spec.Type.(*ast.StructType)
+	if !ok {
+		return fmt.Errorf("%s:%d: %s is not a struct type", path, line, spec.Name.Name)
+	}
+
+	structs, err := padding.Analyze(fset, file, padding.Options{Compiler: goBuild.compiler, Arch: goBuild.arch})
+	if err != nil {
+		return err
+	}
+	index := -1
+	for i := range structs {
+		if structs[i].Node == st {
+			index = i
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("%s:%d: %s is not a struct type", path, line, spec.Name.Name)
+	}
+	s := structs[index]
+
+	var out bytes.Buffer
+	defer func() { emit(out.Bytes()) }()
+	fmt.Fprintf(&out, "File: %s\n", path)
+	padding.Fprint(&out, s)
+	if drift := checkAnnotation(s); drift != "" {
+		fmt.Fprintf(&out, "%s\n\n", drift)
+	}
+	if !fix {
+		return nil
+	}
+	if s.Ignored() {
+		fmt.Fprintf(&out, "%s: not reordering %s: marked //padding:ignore\n\n", path, s.Name)
+		return nil
+	}
+	if s.Cgo {
+		fmt.Fprintf(&out, "%s: not reordering %s: its file uses cgo, whose C types are sized by guess\n\n", path, s.Name)
+		return nil
+	}
+	if s.TooLarge {
+		fmt.Fprintf(&out, "%s: not reordering %s: type too large\n\n", path, s.Name)
+		return nil
+	}
+	if s.FreeComments {
+		fmt.Fprintf(&out, "%s: not reordering %s: a comment of its body belongs to no field\n\n", path, s.Name)
+		return nil
+	}
+	if s.ParamSized {
+		fmt.Fprintf(&out, "%s: not reordering %s: its size depends on type parameters\n\n", path, s.Name)
+		return nil
+	}
+	structs[index] = padding.Optimal(s)
+	padding.Fprint(&out, structs[index])
+
+	data, err := padding.Rewrite(fset, file, src, structs[index:index+1])
+	if err != nil {
+		return err
+	}
+	return replaceFile(path, src, data)
+}
+
+// typeSpecAt returns the type declaration at line: the one whose source,
+// including its doc comment, spans the line. A //go:generate line directly
+// above a declaration is part of its doc comment.
+func typeSpecAt(fset *token.FileSet, file *ast.File, line int) (*ast.TypeSpec, error) {
+	tf := fset.File(file.Pos())
+	if line > tf.LineCount() {
+		return nil, fmt.Errorf("line out of range (file has %d lines)", tf.LineCount())
+	}
+	spans := func(doc *ast.CommentGroup, n ast.Node) bool {
+		start := n.Pos()
+		if doc != nil {
+			start = doc.Pos()
+		}
+		return tf.Line(start) <= line && line <= tf.Line(n.End())
+	}
+
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok {
+			fn := decl.(*ast.FuncDecl)
+			if spans(fn.Doc, fn) {
+				return nil, fmt.Errorf("position is in func %s, not in a type declaration", fn.Name.Name)
+			}
+			continue
+		}
+		if !spans(gen.Doc, gen) {
+			continue
+		}
+		if gen.Tok != token.TYPE {
+			return nil, fmt.Errorf("position is in a %s declaration, not a type declaration", gen.Tok)
+		}
+		for _, spec := range gen.Specs {
+			spec := spec.(*ast.TypeSpec)
+			if spans(spec.Doc, spec) {
+				return spec, nil
+			}
+		}
+		if len(gen.Specs) == 1 {
+			return gen.Specs[0].(*ast.TypeSpec), nil
+		}
+		return nil, errors.New("position is in a type declaration group but not at one of its types")
+	}
+	return nil, errors.New("no type declaration at this position")
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func declFixture(t *testing.T) (string, string) {
+	t.Helper()
+	src, err := os.ReadFile(filepath.Join("testdata", "decl", "decl.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "decl.go")
+	if err := os.WriteFile(path, src, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path, string(src)
+}
+
+func fieldOrders(t *testing.T, src string) map[string][]string {
+	t.Helper()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "decl.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	structs, err := padding.Analyze(fset, file, padding.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	orders := make(map[string][]string)
+	for _, s := range structs {
+		for _, f := range s.Fields {
+			orders[s.Name] = append(orders[s.Name], f.Name)
+		}
+	}
+	return orders
+}
+
+func TestFixDeclFromGoGenerate(t *testing.T) {
+	path, orig := declFixture(t)
+	t.Setenv("GOFILE", path)
+	t.Setenv("GOLINE", "12") // the //go:generate line above Target
+
+	captureReport(t, func() error { return runDecl("", true) })
+	got := readFile(t, path)
+
+	// Only the annotation and the struct type of Target may change.
+	start := strings.Index(orig, "//padding-size:ok size=24")
+	end := strings.Index(orig, "\ntype (")
+	if !strings.HasPrefix(got, orig[:start]) || !strings.HasSuffix(got, orig[end:]) {
+		t.Errorf("text outside Target changed:\n%s", got)
+	}
+	if !strings.Contains(got, "// padding-size:ok size=16\ntype Target struct {") {
+		t.Errorf("annotation of Target not updated:\n%s", got)
+	}
+
+	want := fieldOrders(t, orig)
+	want["Target"] = []string{"Count", "Ready", "Done"}
+	if orders := fieldOrders(t, got); !reflect.DeepEqual(orders, want) {
+		t.Errorf("field orders = %v, want %v", orders, want)
+	}
+}
+
+func TestFixDeclInGroup(t *testing.T) {
+	path, orig := declFixture(t)
+
+	captureReport(t, func() error { return runDecl(path+":27", true) }) // Second struct {
+	got := readFile(t, path)
+
+	want := fieldOrders(t, orig)
+	want["Second"] = []string{"Y", "X", "Z"}
+	if orders := fieldOrders(t, got); !reflect.DeepEqual(orders, want) {
+		t.Errorf("field orders = %v, want %v", orders, want)
+	}
+	// The unformatted Before struct keeps its formatting.
+	if !strings.Contains(got, "\tA   bool\n\tB   int64 // b\n\tC   bool\n") {
+		t.Errorf("Before was reformatted:\n%s", got)
+	}
+}
+
+func TestDeclErrors(t *testing.T) {
+	path, orig := declFixture(t)
+
+	tests := []struct {
+		pos  string
+		want string
+	}{
+		{path + ":33", path + ":33: no type declaration at this position"},
+		{path + ":34", path + ":34: Count is not a struct type"},
+		{path + ":36", path + ":36: position is in a var declaration, not a type declaration"},
+		{path + ":38", path + ":38: position is in func helper, not in a type declaration"},
+		{path + ":99", path + ":99: line out of range (file has 38 lines)"},
+		{path, `invalid position "` + path + `": want file:line`},
+		{path + ":x", `invalid line in position "` + path + `:x"`},
+	}
+	for _, tt := range tests {
+		err := runDecl(tt.pos, true)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("runDecl(%q) error = %v, want %q", tt.pos, err, tt.want)
+		}
+	}
+
+	t.Setenv("GOFILE", "")
+	t.Setenv("GOLINE", "")
+	if err := runDecl("", true); err == nil {
+		t.Error("runDecl without a position or GOFILE/GOLINE succeeded")
+	}
+
+	if got := readFile(t, path); got != orig {
+		t.Errorf("failed runs modified the file:\n%s", got)
+	}
+}
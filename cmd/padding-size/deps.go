@@ -0,0 +1,57 @@
+package main
+
+import (
+	"maps"
+	"slices"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// dependency is a package of another module imported by the packages being
+// analyzed, whose structs are reported read-only.
+type dependency struct {
+	module string   // module path and version, as in example.com/dep@v1.2.0
+	files  []string // Go files of the package, without tests
+}
+
+// findDependencies loads the package in dir, and with recursive the packages
+// below it as well, and returns the packages of other modules they import,
+// directly with depth 1 and through that many imports with a larger depth.
+// Standard library packages and those of the main module are left out, and
+// their imports are not followed.
+func findDependencies(dir string, recursive bool, depth int) ([]dependency, error) {
+	cfg := goBuild.config(dir, packages.NeedName|packages.NeedFiles|packages.NeedImports|packages.NeedDeps|packages.NeedModule)
+	pattern := "."
+	if recursive {
+		pattern = "./..."
+	}
+	pkgs, err := goBuild.load(cfg, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	for _, pkg := range pkgs {
+		seen[pkg.PkgPath] = true
+	}
+	var deps []dependency
+	for level := pkgs; depth > 0 && len(level) > 0; depth-- {
+		var next []*packages.Package
+		for _, pkg := range level {
+			for _, path := range slices.Sorted(maps.Keys(pkg.Imports)) {
+				imp := pkg.Imports[path]
+				if seen[imp.PkgPath] {
+					continue
+				}
+				seen[imp.PkgPath] = true
+				if imp.Module == nil || imp.Module.Main {
+					continue
+				}
+				deps = append(deps, dependency{imp.Module.Path + "@" + imp.Module.Version, imp.GoFiles})
+				next = append(next, imp)
+			}
+		}
+		level = next
+	}
+	return deps, nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIncludeDeps(t *testing.T) {
+	root := t.TempDir()
+	if err := os.CopyFS(root, os.DirFS(filepath.Join("testdata", "deps"))); err != nil {
+		t.Fatal(err)
+	}
+	app := filepath.Join(root, "app")
+	depFile := filepath.Join(root, "dep", "dep.go")
+	depSrc := readFile(t, depFile)
+
+	out := captureReport(t, func() error {
+		return processPath(app, options{fix: true, includeDeps: true, depsDepth: 1}, newFileRegistry())
+	})
+	if want := "File: " + depFile + " (example.com/dep@v1.2.0, read-only)\nStruct: Message (size: 32 bytes"; !strings.Contains(out, want) {
+		t.Errorf("report lacks %q:\n%s", want, out)
+	}
+	if strings.Contains(out, "example.com/deeper") {
+		t.Errorf("report goes past the direct imports:\n%s", out)
+	}
+	if got := readFile(t, depFile); got != depSrc {
+		t.Errorf("dependency was rewritten:\n%s", got)
+	}
+	if got, want := readFile(t, filepath.Join(app, "app.go")), "\tmsgs []dep.Message\n\topen bool\n"; !strings.Contains(got, want) {
+		t.Errorf("app.go lacks %q:\n%s", want, got)
+	}
+
+	out = captureReport(t, func() error {
+		return processPath(app, options{includeDeps: true, depsDepth: 2}, newFileRegistry())
+	})
+	if want := " (example.com/deeper@v1.0.0, read-only)\nStruct: Header (size: 24 bytes"; !strings.Contains(out, want) {
+		t.Errorf("report lacks %q:\n%s", want, out)
+	}
+}
+
+func TestExternalWaste(t *testing.T) {
+	app := filepath.Join("testdata", "deps", "app")
+	opts := options{collect: new(reportCollector), external: true}
+	out := captureReport(t, func() error { return processPath(app, opts, newFileRegistry()) })
+
+	// Message wastes 8 bytes of its own and 8 inside its deeper.Header.
+	if want := "  field last dep.Message carries 16 bytes of internal padding (not fixable here)\n"; !strings.Contains(out, want) {
+		t.Errorf("report lacks %q:\n%s", want, out)
+	}
+	structs := opts.collect.report().Structs
+	if len(structs) != 1 {
+		t.Fatalf("got %d structs, want 1", len(structs))
+	}
+	// None of it counts as waste this package can recover.
+	if s := structs[0]; s.ExternalWaste != 16 || s.WastedBytes != 0 || s.NestedWaste != 0 {
+		t.Errorf("Server: external waste %d, wasted %d, nested waste %d, want 16, 0, 0", s.ExternalWaste, s.WastedBytes, s.NestedWaste)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/types"
+	"os"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// runDescribe implements the describe subcommand:
+//
+//	padding-size describe [-arch GOARCH] [-format text|json] importpath.Type...
+//
+// It lays out struct types named by import path, such as net/http.Request,
+// from packages of the standard library, the build list of the current
+// module or the module cache, without any of their files being at hand.
+// It returns the process exit code.
+func runDescribe(args []string) int {
+	fs := flag.NewFlagSet("describe", flag.ContinueOnError)
+	arch := fs.String("arch", "", "`GOARCH` to lay out the types for (default the go command's)")
+	format := fs.String("format", "text", "Output `format`: text or json")
+	goBuild.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: padding-size describe [options] <importpath.Type>...")
+		fs.PrintDefaults()
+	}
+
+	names, err := parseArgs(fs, args)
+	if err != nil {
+		return 2
+	}
+	if err := goBuild.resolve(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if len(names) == 0 || *format != "text" && *format != "json" {
+		fs.Usage()
+		return 2
+	}
+
+	var reports []padding.StructReport
+	for _, name := range names {
+		s, pkgPath, err := describeType(name, *arch)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		r := padding.NewStructReport(s)
+		r.Package = pkgPath
+		if *format == "json" {
+			reports = append(reports, r)
+			continue
+		}
+		fmt.Printf("Package: %s\n", pkgPath)
+		if goBuild.gccgo() {
+			fmt.Println("Compiler: gccgo")
+		}
+		padding.FprintStruct(os.Stdout, r)
+		if r.WastedBytes > 0 {
+			fmt.Println("Optimal order:")
+			padding.Fprint(os.Stdout, padding.Optimal(s))
+		}
+	}
+	if *format == "json" {
+		r := padding.NewReport(reports...)
+		r.Compiler = goBuild.compiler
+		if err := writeJSON(os.Stdout, r); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+	return 0
+}
+
+// describeType loads the package of name, an import path and a type name
+// joined by a dot, for arch, or for the go command's default architecture
+// if arch is empty, and returns the layout of the type, which must be a
+// non-generic struct type, with its package path. Unexported types are only
+// described in packages of the main module.
+func describeType(name, arch string) (padding.StructInfo, string, error) {
+	dot := strings.LastIndex(name, ".")
+	if dot <= strings.LastIndex(name, "/") || dot == len(name)-1 {
+		return padding.StructInfo{}, "", fmt.Errorf("%q is not an import path and a type name, as in net/http.Request", name)
+	}
+	pkgPath, typeName := name[:dot], name[dot+1:]
+
+	cfg := goBuild.config("", packages.NeedName|packages.NeedTypes|packages.NeedSyntax|packages.NeedTypesSizes|packages.NeedModule)
+	if arch != "" {
+		if types.SizesFor("gc", arch) == nil {
+			return padding.StructInfo{}, "", fmt.Errorf("unknown architecture %q", arch)
+		}
+		cfg.Env = append(os.Environ(), "GOARCH="+arch)
+	}
+	pkgs, err := goBuild.load(cfg, pkgPath)
+	if err != nil {
+		return padding.StructInfo{}, "", err
+	}
+	if len(pkgs) != 1 {
+		return padding.StructInfo{}, "", fmt.Errorf("%s: expected one package, found %d", pkgPath, len(pkgs))
+	}
+	pkg := pkgs[0]
+	if len(pkg.Errors) > 0 {
+		return padding.StructInfo{}, "", fmt.Errorf("cannot load package %s: %v", pkgPath, firstError(pkg))
+	}
+
+	obj := pkg.Types.Scope().Lookup(typeName)
+	if obj == nil {
+		return padding.StructInfo{}, "", fmt.Errorf("package %s declares no %s", pkgPath, typeName)
+	}
+	tn, ok := obj.(*types.TypeName)
+	if !ok {
+		return padding.StructInfo{}, "", fmt.Errorf("%s is not a type", name)
+	}
+	if !tn.Exported() && (pkg.Module == nil || !pkg.Module.Main) {
+		return padding.StructInfo{}, "", fmt.Errorf("%s is unexported, and %s is not part of the main module", name, pkgPath)
+	}
+	if named, ok := tn.Type().(*types.Named); ok && named.TypeParams().Len() > 0 {
+		return padding.StructInfo{}, "", fmt.Errorf("%s is generic; its layout depends on its type arguments", name)
+	}
+	st, ok := tn.Type().Underlying().(*types.Struct)
+	if !ok {
+		return padding.StructInfo{}, "", fmt.Errorf("%s is not a struct type but %s", name, types.TypeString(tn.Type().Underlying(), types.RelativeTo(pkg.Types)))
+	}
+
+	// Field types are written as in the source of the package.
+	qualifier := func(p *types.Package) string {
+		if p == pkg.Types {
+			return ""
+		}
+		return p.Name()
+	}
+	fields, layout := padding.Layout(st, pkg.TypesSizes)
+	s := padding.StructInfo{
+		Name:   typeName,
+		Fields: make([]padding.FieldInfo, len(fields)),
+		Size:   layout.Size,
+		Align:  layout.Align,
+	}
+	for i, f := range fields {
+		s.Fields[i] = padding.FieldInfo{
+			Name:   f.Field.Name(),
+			Type:   types.TypeString(f.Field.Type(), qualifier),
+			Size:   f.Size,
+			Align:  f.Align,
+			Offset: f.Offset,
+		}
+	}
+	return s, pkg.PkgPath, nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestDescribe(t *testing.T) {
+	s, pkgPath, err := describeType("net/http.Request", "amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkgPath != "net/http" || s.Name != "Request" {
+		t.Errorf("described %s.%s, want net/http.Request", pkgPath, s.Name)
+	}
+	// The layout of Request changes between releases, so only its shape
+	// is checked.
+	var names []string
+	var end int64
+	for _, f := range s.Fields {
+		names = append(names, f.Name)
+		if f.Offset < end {
+			t.Errorf("field %s at offset %d overlaps the previous field, which ends at %d", f.Name, f.Offset, end)
+		}
+		end = f.Offset + f.Size
+	}
+	for _, name := range []string{"Method", "URL", "Header", "Body", "Host"} {
+		if !slices.Contains(names, name) {
+			t.Errorf("fields %v lack %s", names, name)
+		}
+	}
+	if s.Size < end || s.Align != 8 {
+		t.Errorf("size %d, align %d; want at least %d, aligned to 8", s.Size, s.Align, end)
+	}
+	if r := padding.NewStructReport(s); r.OptimalSize > r.Size {
+		t.Errorf("optimal size %d larger than the size %d", r.OptimalSize, r.Size)
+	}
+
+	// Unexported types can be described in the main module.
+	if _, _, err := describeType("github.com/zakon47/padding-size/padding.typeLayout", ""); err != nil {
+		t.Errorf("unexported type of the main module: %v", err)
+	}
+}
+
+func TestDescribeErrors(t *testing.T) {
+	for name, want := range map[string]string{
+		"Request":                   `"Request" is not an import path and a type name`,
+		"net/http.":                 `"net/http." is not an import path and a type name`,
+		"example.invalid/nothing.T": "cannot load package example.invalid/nothing",
+		"net/http.Reqest":           "package net/http declares no Reqest",
+		"net/http.StatusOK":         "net/http.StatusOK is not a type",
+		"net/http.Handler":          "net/http.Handler is not a struct type but interface{",
+		"net/http.conn":             "net/http.conn is unexported, and net/http is not part of the main module",
+		"sync/atomic.Pointer":       "sync/atomic.Pointer is generic",
+	} {
+		_, _, err := describeType(name, "")
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("describeType(%q) = %v, want an error containing %q", name, err, want)
+		}
+	}
+	if _, _, err := describeType("net/http.Request", "z80"); err == nil || err.Error() != `unknown architecture "z80"` {
+		t.Errorf("unknown architecture: %v", err)
+	}
+}
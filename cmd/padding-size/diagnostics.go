@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/scanner"
+	"go/version"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// sourceError is the failure to parse a file, with every error found in it.
+// Its message shows each error with the offending source line and a caret
+// under the column, followed by a hint when the cause is a common one.
+type sourceError struct {
+	path   string // as given, relative to the working directory if below it
+	src    []byte
+	errors scanner.ErrorList
+	hint   string
+}
+
+// newSourceError returns err, as returned by parsing src, the contents of
+// the file at path, into node, as a sourceError. Errors other than parse
+// errors are returned as they are.
+func newSourceError(path string, src []byte, node *ast.File, err error) error {
+	var list scanner.ErrorList
+	if !errors.As(err, &list) || len(list) == 0 {
+		return err
+	}
+	list.Sort()
+	list.RemoveMultiples()
+	return &sourceError{path: displayPath(path), src: src, errors: list, hint: parseHint(path, src, node)}
+}
+
+func (e *sourceError) Error() string {
+	var b strings.Builder
+	lines := bytes.Split(e.src, []byte("\n"))
+	for i, err := range e.errors {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%s:%d:%d: %s", e.path, err.Pos.Line, err.Pos.Column, err.Msg)
+		if err.Pos.Line < 1 || err.Pos.Line > len(lines) {
+			continue
+		}
+		line := strings.TrimRight(string(lines[err.Pos.Line-1]), "\r")
+		// The caret is placed by repeating the tabs before the column,
+		// so that it lines up however tabs are shown.
+		var caret strings.Builder
+		for j, r := range line {
+			if j >= err.Pos.Column-1 {
+				break
+			}
+			if r == '\t' {
+				caret.WriteByte('\t')
+			} else {
+				caret.WriteByte(' ')
+			}
+		}
+		fmt.Fprintf(&b, "\n  %s\n  %s^", line, caret.String())
+	}
+	if e.hint != "" {
+		fmt.Fprintf(&b, "\nhint: %s", e.hint)
+	}
+	return b.String()
+}
+
+// parseHint returns a hint at the likely cause of the parse errors of the
+// file at path with contents src, parsed as far as it goes into node, or
+// the empty string: the file is built only for another platform, its
+// module needs a newer Go than the one padding-size was built with, or it
+// uses cgo.
+func parseHint(path string, src []byte, node *ast.File) string {
+	expr := nameConstraint(filepath.Base(path))
+	if node != nil && node.Package.IsValid() {
+		expr = fileConstraint(path, node)
+	}
+	if expr != nil && !expr.Eval(goBuild.hasTag) {
+		return fmt.Sprintf("the file is only built for %s, not %s/%s; it may rely on another toolchain", expr, cmp.Or(goBuild.goos, runtime.GOOS), cmp.Or(goBuild.arch, runtime.GOARCH))
+	}
+	if v := moduleGoVersion(filepath.Dir(path)); v != "" && version.IsValid(runtime.Version()) && version.Compare(v, runtime.Version()) > 0 {
+		return fmt.Sprintf("the module requires %s, newer than the %s padding-size was built with; the file may use newer syntax", v, runtime.Version())
+	}
+	if node != nil && importsC(node) || node == nil && bytes.Contains(src, []byte(`import "C"`)) {
+		return "the file uses cgo; padding-size parses only its Go code, so the error is there rather than in the C preamble"
+	}
+	return ""
+}
+
+// importsC reports whether node imports "C".
+func importsC(node *ast.File) bool {
+	for _, spec := range node.Imports {
+		if spec.Path.Value == `"C"` {
+			return true
+		}
+	}
+	return false
+}
+
+// moduleGoVersion returns the Go version, as in go1.22, required by the go
+// directive of the go.mod file of the module holding dir, or the empty
+// string if there is none.
+func moduleGoVersion(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		f, err := os.Open(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			defer f.Close()
+			s := bufio.NewScanner(f)
+			for s.Scan() {
+				if v, ok := strings.CutPrefix(strings.TrimSpace(s.Text()), "go "); ok {
+					return "go" + strings.TrimSpace(v)
+				}
+			}
+			return ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// displayPath returns path relative to the working directory if it is below
+// it, and as given otherwise.
+func displayPath(path string) string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	if rel, err := filepath.Rel(cwd, abs); err == nil && filepath.IsLocal(rel) {
+		return rel
+	}
+	return path
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestSourceError(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name:  "several errors",
+			files: map[string]string{"p/bad.go": "package p\n\ntype T struct {\n\tA int y\n\tB string\n}\n\nvar s = \"abc\n"},
+			want: `p/bad.go:4:8: expected ';', found y
+  	A int y
+  	      ^
+p/bad.go:8:1: expected '}', found 'var'
+  var s = "abc
+  ^`,
+		},
+		{
+			name:  "cgo",
+			files: map[string]string{"p/cgo.go": "package p\n\nimport \"C\"\n\ntype T struct {\n\tA C.int,\n}\n"},
+			want: `p/cgo.go:6:9: expected ';', found ','
+  	A C.int,
+  	       ^
+hint: the file uses cgo; padding-size parses only its Go code, so the error is there rather than in the C preamble`,
+		},
+		{
+			name: "newer Go",
+			files: map[string]string{
+				"m/go.mod":    "module example.com/m\n\ngo 1.999\n",
+				"m/p/span.go": "package p\n\ntype T struct { A int ]\n",
+			},
+			want: `m/p/span.go:3:23: expected ';', found ']'
+  type T struct { A int ]
+                        ^
+hint: the module requires go1.999, newer than the ` + runtime.Version() + ` padding-size was built with; the file may use newer syntax`,
+		},
+		{
+			name:  "other platform",
+			files: map[string]string{"p/sys_plan9.go": "package p\n\ntype T struct { A int ]\n"},
+			want: `p/sys_plan9.go:3:23: expected ';', found ']'
+  type T struct { A int ]
+                        ^
+hint: the file is only built for plan9, not ` + runtime.GOOS + "/" + runtime.GOARCH + `; it may rely on another toolchain`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Chdir(dir)
+			var path string
+			for name, src := range tt.files {
+				if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(name, []byte(src), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				if filepath.Ext(name) == ".go" {
+					path = filepath.Join(dir, name)
+				}
+			}
+			_, err := loadFile(path, padding.NewCache())
+			if err == nil {
+				t.Fatal("no error")
+			}
+			if err.Error() != tt.want {
+				t.Errorf("error:\n%s\nwant:\n%s", err, tt.want)
+			}
+		})
+	}
+}
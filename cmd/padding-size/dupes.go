@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// writeDuplicateLayouts writes the groups of structs of r with the same
+// layout to w, each under its fingerprint, with where its members are
+// declared.
+func writeDuplicateLayouts(w io.Writer, r padding.Report) error {
+	fmt.Fprintf(w, "Duplicate layouts:\n")
+	if len(r.DuplicateLayouts) == 0 {
+		fmt.Fprintf(w, "  None; no two structs share a layout.\n\n")
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	for _, g := range r.DuplicateLayouts {
+		fmt.Fprintf(tw, "  %d structs, %s\n", len(g.Structs), g.Fingerprint)
+		for _, s := range g.Structs {
+			name := s.Name
+			if s.Package != "" {
+				name = s.Package + "." + s.Name
+			}
+			fmt.Fprintf(tw, "    %s\t%s\n", name, s.Position)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestWriteDuplicateLayouts(t *testing.T) {
+	path := writeFile(t, "package p\n\ntype A struct {\n\tx int64\n\tb bool\n}\n\n"+
+		"type B struct {\n\ty float64\n\tc bool\n}\n\n"+
+		"type C struct {\n\tc bool\n\ty float64\n}\n")
+	opts := options{dupes: true, collect: new(reportCollector)}
+	captureReport(t, func() error { return processPath(filepath.Dir(path), opts, newFileRegistry()) })
+
+	r := opts.collect.report()
+	var out bytes.Buffer
+	if err := writeDuplicateLayouts(&out, r); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Duplicate layouts:\n  None; no two structs share a layout.\n\n"; out.String() != want {
+		t.Errorf("without grouping:\n%s\nwant\n%s", out.String(), want)
+	}
+
+	r.DuplicateLayouts = padding.DuplicateLayouts(r.Structs, false)
+	out.Reset()
+	if err := writeDuplicateLayouts(&out, r); err != nil {
+		t.Fatal(err)
+	}
+	// C, with the fields of B in another order, is not grouped.
+	want := "Duplicate layouts:\n  2 structs, 16/8: 8/8 1/1\n" +
+		"    p.A  " + path + ":3\n" +
+		"    p.B  " + path + ":8\n\n"
+	if out.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", out.String(), want)
+	}
+}
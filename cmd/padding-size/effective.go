@@ -0,0 +1,61 @@
+package main
+
+import (
+	"go/ast"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// elementTypes returns the names of the types files use as the element type
+// of an array or slice, anywhere: in declarations, conversions or calls to
+// make. Those are the struct types whose every byte saved is multiplied by
+// the length of contiguous storage.
+func elementTypes(files []*FileResult) map[string]bool {
+	elements := make(map[string]bool)
+	for _, f := range files {
+		ast.Inspect(f.Node, func(n ast.Node) bool {
+			if a, ok := n.(*ast.ArrayType); ok {
+				if id, ok := ast.Unparen(a.Elt).(*ast.Ident); ok {
+					elements[id.Name] = true
+				}
+			}
+			return true
+		})
+	}
+	return elements
+}
+
+// shown reports whether r, the report of a struct, is printed under
+// -effective: only structs whose fix changes the heap memory they take are,
+// unless all are requested, in which case the others are noted as such.
+func (o options) shown(r *padding.StructReport) bool {
+	if !o.effective {
+		return true
+	}
+	r.CheckAllocationImpact(o.elements[r.Name])
+	return o.all || r.WastedBytes > 0 && !r.NoAllocationImpact
+}
+
+// listed reports whether r, the report of a struct shown, is listed in the
+// text report, which leaves out the structs there is nothing to note about
+// unless all are requested.
+func (o options) listed(r *padding.StructReport) bool {
+	return o.all || o.effective || noteworthy(r)
+}
+
+// noteworthy reports whether the text report of r notes anything about the
+// struct besides its layout: that reordering shrinks it, a caveat on its
+// sizes, or a finding of one of the analyses.
+func noteworthy(r *padding.StructReport) bool {
+	for _, f := range r.Fields {
+		if f.Estimated || f.NestedWaste > 0 || f.ElementWaste > 0 || f.Stride > 0 || f.ExternalWaste > 0 {
+			return true
+		}
+	}
+	return r.WastedBytes > 0 || r.TooLarge || r.ParamSized || r.Estimated || r.Cgo || r.Skipped != "" || len(r.SkipReasons) > 0 ||
+		r.NestedWaste > 0 || len(r.PromotedFields) > 0 || len(r.Instantiations) > 0 || len(r.RedundantPads) > 0 ||
+		len(r.Holes) > 0 || len(r.Layout) > 0 || r.NearMiss > 0 || r.LineAlignedSize ||
+		len(r.CacheLineCrossings) > 0 || len(r.FalseSharing) > 0 || r.GCOrder != nil || r.KeepFirstCost > 0 ||
+		r.VisibilityCost > 0 || r.FreeTail > 0 || r.PointerBytes > 0 || len(r.NestedPadding) > 0 ||
+		len(r.HotFields) > 0 || r.Split != nil || len(r.BoolPacks) > 0 || len(r.Narrowings) > 0 || r.Indirection != nil
+}
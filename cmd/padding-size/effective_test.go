@@ -0,0 +1,91 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEffective(t *testing.T) {
+	// Same and Elem shrink from 48 to 40 bytes, both in the 48-byte size
+	// class, but Elem is stored in a slice.
+	path := writeFile(t, `package p
+
+type Small struct {
+	a bool
+	n int64
+	b bool
+}
+
+type Same struct {
+	a          bool
+	w, x, y, z int64
+	b          bool
+}
+
+type Elem struct {
+	a          bool
+	w, x, y, z int64
+	b          bool
+}
+
+var table = make([]Elem, 0, 1024)
+`)
+	out := captureReport(t, func() error { return processFile(path, options{effective: true}) })
+	for _, want := range []string{"Struct: Small ", "Struct: Elem "} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Struct: Same ") {
+		t.Errorf("report has Same:\n%s", out)
+	}
+
+	out = captureReport(t, func() error { return processFile(path, options{effective: true, all: true}) })
+	if want := "Struct: Same (size: 48 bytes, align: 8, optimal: 40 bytes (alloc 48), packed minimum: 34 bytes, wasted: 8 bytes, padding: 7 inter-field + 7 trailing)\n"; !strings.Contains(out, want) {
+		t.Errorf("report lacks %q:\n%s", want, out)
+	}
+	if n := strings.Count(out, "  No allocation impact: 48 and 40 bytes both allocate 48 bytes\n"); n != 1 {
+		t.Errorf("report notes %d structs without allocation impact, want 1:\n%s", n, out)
+	}
+}
+
+func TestListed(t *testing.T) {
+	// Tight is optimal and has nothing else to note; Near is optimal too but
+	// ends 4 bytes over a cache line.
+	path := writeFile(t, `package p
+
+type Loose struct {
+	a bool
+	n int64
+	b bool
+}
+
+type Tight struct {
+	n int64
+	a bool
+}
+
+type Near struct {
+	a [68]byte
+}
+`)
+	out := captureReport(t, func() error { return processFile(path, options{nearMiss: 8, cacheLine: 64}) })
+	for _, want := range []string{
+		"Struct: Loose ",
+		"  a bool (offset: 0, size: 1, align: 1, padding after: 7)\n",
+		"  b bool (offset: 16, size: 1, align: 1, trailing padding: 7)\n",
+		"Struct: Near ",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Struct: Tight ") {
+		t.Errorf("report lists Tight:\n%s", out)
+	}
+
+	out = captureReport(t, func() error { return processFile(path, options{all: true}) })
+	if !strings.Contains(out, "Struct: Tight ") {
+		t.Errorf("-all report lacks Tight:\n%s", out)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"go/token"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// maxEstimatedExamples is the number of fields listed as examples of each
+// field type whose size was guessed.
+const maxEstimatedExamples = 3
+
+// fieldPosition is the file and line declaring a field.
+type fieldPosition struct {
+	file string
+	line int
+}
+
+// estimatedFields returns the positions of the fields of the structs of
+// files whose sizes are guesses, by type, once resolveSizes sized those
+// holding the structs of files.
+func estimatedFields(files []*FileResult) map[string][]fieldPosition {
+	named := namedStructs(files)
+	fields := make(map[string][]fieldPosition)
+	for _, f := range files {
+		for _, s := range f.Structs {
+			// The fields of s follow the fields of its declaration, an
+			// embedded one declared by its type.
+			k := 0
+			for _, field := range s.Node.Fields.List {
+				positions := []token.Pos{field.Type.Pos()}
+				if len(field.Names) > 0 {
+					positions = positions[:0]
+					for _, name := range field.Names {
+						positions = append(positions, name.Pos())
+					}
+				}
+				for _, pos := range positions {
+					if k == len(s.Fields) {
+						break
+					}
+					field := s.Fields[k]
+					k++
+					if typ := field.Type; field.Kind == "" && padding.Estimated(typ, named) {
+						fields[typ] = append(fields[typ], fieldPosition{f.Path, f.Fset.Position(pos).Line})
+					}
+				}
+			}
+		}
+	}
+	return fields
+}
+
+// estimatedTypes returns the field types of fields, by decreasing number of
+// fields, then by type, each with the positions of its first fields by file
+// and line.
+func estimatedTypes(fields map[string][]fieldPosition) []padding.EstimatedType {
+	var types []padding.EstimatedType
+	for typ, positions := range fields {
+		positions = slices.Clone(positions)
+		slices.SortFunc(positions, func(a, b fieldPosition) int {
+			return cmp.Or(strings.Compare(a.file, b.file), cmp.Compare(a.line, b.line))
+		})
+		t := padding.EstimatedType{Type: typ, Fields: len(positions), Examples: []string{}}
+		for _, p := range positions[:min(len(positions), maxEstimatedExamples)] {
+			t.Examples = append(t.Examples, fmt.Sprintf("%s:%d", p.file, p.line))
+		}
+		types = append(types, t)
+	}
+	slices.SortFunc(types, func(a, b padding.EstimatedType) int {
+		return cmp.Or(cmp.Compare(b.Fields, a.Fields), strings.Compare(a.Type, b.Type))
+	})
+	return types
+}
+
+// writeEstimatedTypes writes the field types of r whose sizes were guessed
+// to w, with the number of fields of each and the first of them.
+func writeEstimatedTypes(w io.Writer, r padding.Report) error {
+	scalars := ""
+	if slices.ContainsFunc(r.EstimatedTypes, func(t padding.EstimatedType) bool { return padding.CgoType(t.Type) }) {
+		scalars = "; C scalars as on 64-bit Unix"
+	}
+	fmt.Fprintf(w, "Estimated field types (sized as 8 bytes, aligned to 8%s):\n", scalars)
+	if len(r.EstimatedTypes) == 0 {
+		fmt.Fprintf(w, "  None; every field was sized exactly.\n\n")
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "  FIELDS\tTYPE\tEXAMPLES\n")
+	for _, t := range r.EstimatedTypes {
+		fmt.Fprintf(tw, "  %6d\t%s\t%s\n", t.Fields, t.Type, strings.Join(t.Examples, ", "))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
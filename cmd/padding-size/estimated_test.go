@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestEstimatedTypes(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.go": `package p
+
+type A struct {
+	When  time.Time
+	Inner Inner
+	Cells [2]Inner
+	Tags  Labels
+	N     int
+}
+
+type Inner struct {
+	Since time.Time
+	ok    bool
+}
+`,
+		"b.go": `package p
+
+type B struct {
+	Created, Updated time.Time
+	Tags             Labels
+	Next             *B
+}
+`,
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	opts := options{collect: new(reportCollector), verbose: true}
+	captureReport(t, func() error { return processPath(dir, opts, newFileRegistry()) })
+
+	r := opts.collect.report()
+	a, b := filepath.Join(dir, "a.go"), filepath.Join(dir, "b.go")
+	want := []padding.EstimatedType{
+		{Type: "time.Time", Fields: 4, Examples: []string{a + ":4", a + ":12", b + ":4"}},
+		{Type: "Labels", Fields: 2, Examples: []string{a + ":7", b + ":5"}},
+	}
+	if !reflect.DeepEqual(r.EstimatedTypes, want) {
+		t.Errorf("estimated types = %+v, want %+v", r.EstimatedTypes, want)
+	}
+
+	var out strings.Builder
+	if err := writeEstimatedTypes(&out, r); err != nil {
+		t.Fatal(err)
+	}
+	line := "       4  time.Time  " + a + ":4, " + a + ":12, " + b + ":4\n"
+	if !strings.Contains(out.String(), line) {
+		t.Errorf("output lacks %q:\n%s", line, out.String())
+	}
+}
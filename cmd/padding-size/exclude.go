@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// excludePatterns is the flag.Value of the repeatable -exclude flag: glob
+// patterns, as of path.Match, of the files and directories a directory walk
+// skips.
+type excludePatterns []string
+
+func (p *excludePatterns) String() string {
+	return strings.Join(*p, ",")
+}
+
+func (p *excludePatterns) Set(s string) error {
+	if _, err := path.Match(s, ""); err != nil {
+		return fmt.Errorf("invalid pattern %q: %v", s, err)
+	}
+	*p = append(*p, s)
+	return nil
+}
+
+// match reports whether a pattern of p matches rel, a slash-separated path
+// relative to the root of a walk, or its last element.
+func (p excludePatterns) match(rel string) bool {
+	for _, pattern := range p {
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, path.Base(rel)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// skipDir reports whether the walk of root skips dir, a directory below it:
+// vendor and testdata directories, which the go command leaves out of ./...
+// too, and those matching -exclude. Paths named on the command line are
+// never skipped.
+func (o options) skipDir(root, dir string) bool {
+	if dir == root {
+		return false
+	}
+	switch filepath.Base(dir) {
+	case "vendor", "testdata":
+		return true
+	}
+	return o.exclude.match(relSlash(root, dir))
+}
+
+// skipFile reports whether the walk of root skips the Go file at path: test
+// files unless -include-tests, files matching -exclude, unless
+// -all-platforms, files the build constraints of the target leave out, and,
+// unless -include-generated, generated files, those with a // Code
+// generated ... DO NOT EDIT. comment before the package clause.
+func (o options) skipFile(root, path string) (bool, error) {
+	if strings.HasSuffix(path, "_test.go") && !o.includeTests {
+		return true, nil
+	}
+	if o.exclude.match(relSlash(root, path)) {
+		return true, nil
+	}
+	if o.includeGenerated && o.allPlatforms {
+		return false, nil
+	}
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	file, err := parser.ParseFile(token.NewFileSet(), path, src, parser.PackageClauseOnly|parser.ParseComments)
+	if err != nil {
+		// The file is reported with its errors when it is analyzed.
+		return false, nil
+	}
+	if !o.allPlatforms && !goBuild.builds(path, file) {
+		return true, nil
+	}
+	return !o.includeGenerated && ast.IsGenerated(file), nil
+}
+
+// relSlash returns path relative to root, with forward slashes.
+func relSlash(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		rel = path
+	}
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// writeTree writes files, by slash-separated path relative to dir, to dir.
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, src := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWalkExclusions(t *testing.T) {
+	const loose = "\ntype T struct {\n\ta bool\n\tb int64\n\tc bool\n}\n"
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"plain.go":                 "package p\n" + loose,
+		"plain_test.go":            "package p\n" + loose,
+		"types.pb.go":              "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage p\n" + loose,
+		"almost.go":                "// Code generated by hand, do not edit.\n\npackage p\n" + loose,
+		"vendor/v/v.go":            "package v\n" + loose,
+		"testdata/fixture.go":      "package fixture\n" + loose,
+		"internal/gen/gen.go":      "package gen\n" + loose,
+		"internal/kept/kept.go":    "package kept\n" + loose,
+		"internal/kept/skipped.go": "package kept\n" + loose,
+	})
+	files := func(args ...string) []string {
+		t.Helper()
+		code, out := runCaptured(t, append([]string{"-format=json"}, append(args, dir)...)...)
+		if code != 0 {
+			t.Fatalf("%q: exit status %d", args, code)
+		}
+		var r padding.Report
+		if err := json.Unmarshal([]byte(out), &r); err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, f := range r.Files {
+			rel, err := filepath.Rel(dir, f.File)
+			if err != nil {
+				t.Fatal(err)
+			}
+			names = append(names, filepath.ToSlash(rel))
+		}
+		slices.Sort(names)
+		return names
+	}
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{nil, []string{"almost.go", "internal/gen/gen.go", "internal/kept/kept.go", "internal/kept/skipped.go", "plain.go"}},
+		{[]string{"-include-tests"}, []string{"almost.go", "internal/gen/gen.go", "internal/kept/kept.go", "internal/kept/skipped.go", "plain.go", "plain_test.go"}},
+		{[]string{"-include-generated"}, []string{"almost.go", "internal/gen/gen.go", "internal/kept/kept.go", "internal/kept/skipped.go", "plain.go", "types.pb.go"}},
+		{[]string{"-exclude", "internal/gen", "-exclude", "skipped.go"}, []string{"almost.go", "internal/kept/kept.go", "plain.go"}},
+	}
+	for _, tt := range tests {
+		if got := files(tt.args...); !slices.Equal(got, tt.want) {
+			t.Errorf("%q: files %q, want %q", tt.args, got, tt.want)
+		}
+	}
+
+	// Paths named on the command line are analyzed whatever they are.
+	code, out := runCaptured(t, filepath.Join(dir, "testdata"), filepath.Join(dir, "plain_test.go"))
+	if code != 0 || !strings.Contains(out, "fixture.go") || !strings.Contains(out, "plain_test.go") {
+		t.Errorf("named paths: exit status %d, output:\n%s", code, out)
+	}
+
+	if code, _ := runCaptured(t, "-exclude", "[", dir); code != 2 {
+		t.Errorf("invalid pattern: exit status %d, want 2", code)
+	}
+}
+
+func TestIgnoreDirective(t *testing.T) {
+	const src = `package p
+
+// Wire is laid out for a wire format.
+//
+//padding:ignore
+type Wire struct {
+	a bool
+	b int64
+	c bool
+}
+
+// Upper is reordered: the directive is case-sensitive.
+//
+//padding:Ignore
+type Upper struct {
+	a bool
+	b int64
+	c bool
+}
+
+// Suffixed is reordered: the directive must be exact.
+//
+//padding:ignored
+type Suffixed struct {
+	a bool
+	b int64
+	c bool
+}
+`
+	path := filepath.Join(t.TempDir(), "p.go")
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	code, out := runCaptured(t, "-check", path)
+	if code != 1 || strings.Contains(out, "Wire") || !strings.Contains(out, "Upper") || !strings.Contains(out, "Suffixed") {
+		t.Errorf("-check: exit status %d, output:\n%s", code, out)
+	}
+
+	if code, _ := runCaptured(t, "-fix", path); code != 0 {
+		t.Fatalf("-fix: exit status %d", code)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Wire and its directive are left as they were, while the others move
+	// their int64 first.
+	wire := "// Wire is laid out for a wire format.\n//\n//padding:ignore\ntype Wire struct {\n\ta bool\n\tb int64\n\tc bool\n}\n"
+	if !strings.Contains(string(got), wire) {
+		t.Errorf("-fix changed Wire:\n%s", got)
+	}
+	for _, name := range []string{"Upper", "Suffixed"} {
+		if !strings.Contains(string(got), "type "+name+" struct {\n\tb int64\n") {
+			t.Errorf("-fix did not reorder %s:\n%s", name, got)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// writeSkippedStructs writes the structs of r fix left alone to w, for
+// -explain-skip, each at its position with the code and details of every
+// rule excluding it.
+func writeSkippedStructs(w io.Writer, r padding.Report) error {
+	fmt.Fprintf(w, "Skipped structs:\n")
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	skipped := 0
+	for _, s := range r.Structs {
+		if len(s.SkipReasons) == 0 {
+			continue
+		}
+		skipped++
+		position := s.File
+		if s.Line > 0 {
+			position = fmt.Sprintf("%s:%d", s.File, s.Line)
+		}
+		for i, reason := range s.SkipReasons {
+			if i > 0 {
+				position, s.Name = "", ""
+			}
+			fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", position, s.Name, reason.Code, reason.Reason)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if skipped == 0 {
+		fmt.Fprintf(w, "  None; fix left no struct alone.\n")
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// skipCodes returns the codes of the skip reasons of the structs of r, by
+// struct name, leaving out the structs fix reorders.
+func skipCodes(r padding.Report) map[string][]string {
+	codes := map[string][]string{}
+	for _, s := range r.Structs {
+		for _, reason := range s.SkipReasons {
+			codes[s.Name] = append(codes[s.Name], reason.Code)
+		}
+	}
+	return codes
+}
+
+func TestExplainSkip(t *testing.T) {
+	path := writeFile(t, `package p
+
+import "sync"
+
+type Huge struct {
+	A    bool
+	Many [4611686018427387904]Inner
+	B    int32
+}
+
+type Inner struct {
+	A bool
+	N int64
+}
+
+type Guarded struct {
+	ok bool
+	mu sync.Mutex
+	n  int64
+	b  bool
+}
+
+type Loose struct {
+	a bool
+	n int64
+	b bool
+}
+
+func f() {
+	_ = struct {
+		A bool
+		B int64
+		C bool
+	}{true, 1, false}
+}
+`)
+	opts := options{fix: true, explainSkip: true, collect: new(reportCollector),
+		only: parseSelection("Huge, Guarded"), skipTypes: parseSkipTypes("sync.Mutex")}
+	captureReport(t, func() error { return processFile(path, opts) })
+
+	r := opts.collect.report()
+	want := map[string][]string{
+		"Huge":                           {"too_large"},
+		"Guarded":                        {"estimated_sizes", "held_type"},
+		"Inner":                          {"not_selected"},
+		"Loose":                          {"not_selected"},
+		"types.go:30 (anonymous struct)": {"anonymous_struct", "not_selected"},
+	}
+	if got := skipCodes(r); !reflect.DeepEqual(got, want) {
+		t.Errorf("skip codes = %v, want %v", got, want)
+	}
+
+	var out bytes.Buffer
+	if err := writeSkippedStructs(&out, r); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Skipped structs:\n",
+		path + ":16  Guarded",
+		"held_type         has a field of type sync.Mutex, listed by -skip-has-type\n",
+		"not_selected      not named by -only\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("list lacks %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestExplainSkipFixtures(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not available")
+	}
+	for _, tt := range []struct {
+		fixture string
+		opts    options
+		want    map[string][]string
+	}{
+		{"reflection", options{}, map[string][]string{"Record": {"reflect_index"}}},
+		{"generics", options{generics: true}, map[string][]string{"Pair": {"generic_instantiations"}}},
+		{"generics", options{}, map[string][]string{"Entry": {"type_params"}, "Pair": {"type_params"}}},
+		{"literals", options{typeFailures: new(typeFailures)}, map[string][]string{
+			"Config": {"unkeyed_literal"}, "Flags": {"unkeyed_literal"}, "Holder": {"unkeyed_literal"}, "Pair": {"unkeyed_literal"}, "Box": {"unkeyed_literal"},
+		}},
+		{"cgo", options{}, map[string][]string{"Sample": {"cgo_file"}, "Local": {"cgo_file"}}},
+	} {
+		t.Run(tt.fixture, func(t *testing.T) {
+			root := t.TempDir()
+			if err := os.CopyFS(root, os.DirFS(filepath.Join("testdata", tt.fixture))); err != nil {
+				t.Fatal(err)
+			}
+			if tt.fixture == "literals" {
+				root = filepath.Join(root, "types")
+			}
+			opts := tt.opts
+			opts.fix, opts.explainSkip, opts.collect, opts.fixLog = true, true, new(reportCollector), new(fixLog)
+			captureReport(t, func() error { return processPath(root, opts, newFileRegistry()) })
+			if got := skipCodes(opts.collect.report()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("skip codes = %v, want %v", got, tt.want)
+			}
+			for _, r := range opts.fixLog.structs {
+				if codes := tt.want[r.Struct]; codes != nil && r.Code != codes[0] {
+					t.Errorf("fix log records %s with code %q, want %q", r.Struct, r.Code, codes[0])
+				}
+			}
+		})
+	}
+}
+
+func TestCauseCodes(t *testing.T) {
+	for _, cause := range []string{causeUnwritten, causeReflection, causeUnkeyed, causeGeneric, causeAnonymous, causeCgo, causeUnselected, causeHeldType, causeTooLarge, causeTypeParams, causeComments, causeEstimated} {
+		if causeCodes[cause] == "" {
+			t.Errorf("cause %q has no code", cause)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := writeSkippedStructs(&out, padding.Report{}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Skipped structs:\n  None; fix left no struct alone.\n\n"; out.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", out.String(), want)
+	}
+}
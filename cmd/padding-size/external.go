@@ -0,0 +1,69 @@
+package main
+
+import (
+	"go/types"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// externalWastes holds, for package-level struct types, the bytes wasted
+// inside their fields of struct types of other packages, by field name.
+// Types without such fields are left out.
+type externalWastes map[structKey]map[string]int64
+
+// findExternalWaste type-checks the package in dir, and with recursive the
+// packages below it as well, and finds the waste inside the fields of their
+// package-level struct types whose types are structs of other packages.
+// Generic types are left out, since they are laid out only once
+// instantiated.
+func findExternalWaste(dir string, recursive bool) (externalWastes, error) {
+	pkgs, loadErr := loadPackages(dir, recursive, packages.NeedTypes|packages.NeedTypesSizes)
+
+	wastes := make(externalWastes)
+	for _, pkg := range pkgs {
+		if pkg.Types == nil || pkg.TypesSizes == nil {
+			continue
+		}
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			obj, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || obj.IsAlias() {
+				continue
+			}
+			st, ok := obj.Type().Underlying().(*types.Struct)
+			if !ok {
+				continue
+			}
+			if named, ok := obj.Type().(*types.Named); ok && named.TypeParams().Len() > 0 {
+				continue
+			}
+			fields := padding.ExternalWaste(st, pkg.Types, pkg.TypesSizes)
+			if len(fields) == 0 {
+				continue
+			}
+			byName := make(map[string]int64, len(fields))
+			for i, w := range fields {
+				byName[st.Field(i).Name()] = w
+			}
+			wastes[structKey{realDir(pkg.Fset.Position(obj.Pos()).Filename), obj.Name()}] = byName
+		}
+	}
+	return wastes, loadErr
+}
+
+// weigh sets the waste inside the fields of r, a package-level struct, that
+// are structs of other packages.
+func (e externalWastes) weigh(r *padding.StructReport) {
+	fields, ok := e[structKey{realDir(r.File), r.Name}]
+	if !ok {
+		return
+	}
+	r.ExternalWaste = 0
+	for i := range r.Fields {
+		f := &r.Fields[i]
+		f.ExternalWaste = fields[f.Name]
+		r.ExternalWaste += f.ExternalWaste
+	}
+}
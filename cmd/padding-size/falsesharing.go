@@ -0,0 +1,149 @@
+package main
+
+import (
+	"go/ast"
+	"go/types"
+	"slices"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// concurrentFields holds the fields of package-level struct types that are
+// presumably written concurrently, laid out with type information, in
+// offset order.
+type concurrentFields map[structKey][]padding.FieldReport
+
+// findConcurrentFields type-checks the package in dir, and with recursive
+// the packages below it as well, and returns the fields of their struct
+// types that are written concurrently: fields of the types of sync/atomic,
+// sync.Mutex and sync.RWMutex, and fields whose address is passed to a
+// function of sync/atomic, as in atomic.AddInt64(&s.hits, 1). Only fields
+// selected directly, not through embedded structs, are found by their use.
+func findConcurrentFields(dir string, recursive bool) (concurrentFields, error) {
+	pkgs, loadErr := loadPackages(dir, recursive, packages.NeedTypes|packages.NeedSyntax|packages.NeedTypesInfo|packages.NeedTypesSizes)
+
+	fields := make(concurrentFields)
+	for _, pkg := range pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		// The names of the concurrently written fields of each struct.
+		names := make(map[*types.TypeName][]string)
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			obj, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok {
+				continue
+			}
+			st, ok := obj.Type().Underlying().(*types.Struct)
+			if !ok {
+				continue
+			}
+			for i := range st.NumFields() {
+				if f := st.Field(i); concurrentType(f.Type()) {
+					names[obj] = append(names[obj], f.Name())
+				}
+			}
+		}
+		for _, file := range pkg.Syntax {
+			ast.Inspect(file, func(n ast.Node) bool {
+				if call, ok := n.(*ast.CallExpr); ok {
+					// Structs of other packages are laid out with those.
+					if obj, field := atomicOperand(pkg.TypesInfo, call); obj != nil && obj.Pkg() == pkg.Types {
+						names[obj] = append(names[obj], field)
+					}
+				}
+				return true
+			})
+		}
+
+		for obj, names := range names {
+			if named, ok := obj.Type().(*types.Named); ok && named.TypeParams().Len() > 0 {
+				continue // laid out only once instantiated
+			}
+			st := obj.Type().Underlying().(*types.Struct)
+			layout, _ := padding.Layout(st, pkg.TypesSizes)
+			var concurrent []padding.FieldReport
+			for _, f := range layout {
+				if slices.Contains(names, f.Field.Name()) {
+					concurrent = append(concurrent, padding.FieldReport{
+						Name:   f.Field.Name(),
+						Type:   types.TypeString(f.Field.Type(), types.RelativeTo(pkg.Types)),
+						Offset: f.Offset,
+						Size:   f.Size,
+						Align:  f.Align,
+					})
+				}
+			}
+			key := structKey{realDir(pkg.Fset.Position(obj.Pos()).Filename), obj.Name()}
+			fields[key] = concurrent
+		}
+	}
+	return fields, loadErr
+}
+
+// concurrentType reports whether t is a type of sync/atomic, sync.Mutex or
+// sync.RWMutex.
+func concurrentType(t types.Type) bool {
+	named, ok := types.Unalias(t).(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return false
+	}
+	switch obj := named.Obj(); obj.Pkg().Path() {
+	case "sync/atomic":
+		return true
+	case "sync":
+		return obj.Name() == "Mutex" || obj.Name() == "RWMutex"
+	}
+	return false
+}
+
+// atomicOperand returns the struct type and the name of the field whose
+// address call passes to a function of sync/atomic as its first argument,
+// or nil if it passes none.
+func atomicOperand(info *types.Info, call *ast.CallExpr) (*types.TypeName, string) {
+	var id *ast.Ident
+	switch fun := ast.Unparen(call.Fun).(type) {
+	case *ast.SelectorExpr:
+		id = fun.Sel
+	case *ast.Ident:
+		id = fun
+	default:
+		return nil, ""
+	}
+	fn, ok := info.Uses[id].(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != "sync/atomic" || len(call.Args) == 0 {
+		return nil, ""
+	}
+	addr, ok := ast.Unparen(call.Args[0]).(*ast.UnaryExpr)
+	if !ok {
+		return nil, ""
+	}
+	sel, ok := ast.Unparen(addr.X).(*ast.SelectorExpr)
+	if !ok {
+		return nil, ""
+	}
+	selection := info.Selections[sel]
+	if selection == nil || selection.Kind() != types.FieldVal || len(selection.Index()) != 1 {
+		return nil, ""
+	}
+	recv := selection.Recv()
+	if ptr, ok := types.Unalias(recv).(*types.Pointer); ok {
+		recv = ptr.Elem()
+	}
+	obj := structTypeName(recv)
+	if obj == nil {
+		return nil, ""
+	}
+	return obj, sel.Sel.Name
+}
+
+// weigh sets the false sharing of r, a package-level struct, for cache
+// lines of the given size.
+func (c concurrentFields) weigh(r *padding.StructReport, line int64) {
+	if fields := c[structKey{realDir(r.File), r.Name}]; len(fields) > 1 {
+		r.CheckFalseSharing(fields, line)
+	}
+}
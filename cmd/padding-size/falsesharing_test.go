@@ -0,0 +1,60 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestFalseSharing(t *testing.T) {
+	dir := filepath.Join("testdata", "sharing")
+	opts := options{collect: new(reportCollector), falseSharing: true, cacheLine: 64}
+	report := captureReport(t, func() error { return processPath(dir, opts, newFileRegistry()) })
+
+	want := map[string][]padding.FalseSharing{
+		"Counters": {{First: "hits", FirstOffset: 0, Second: "misses", SecondOffset: 8, Line: 0}},
+		"Stats": {
+			{First: "mu", FirstOffset: 0, Second: "requests", SecondOffset: 8, Line: 0},
+			{First: "mu", FirstOffset: 0, Second: "errors", SecondOffset: 16, Line: 0},
+			{First: "requests", FirstOffset: 8, Second: "errors", SecondOffset: 16, Line: 0},
+		},
+	}
+	for _, s := range opts.collect.report().Structs {
+		got := s.FalseSharing
+		if len(got) != len(want[s.Name]) {
+			t.Errorf("%s: false sharing %+v, want %+v", s.Name, got, want[s.Name])
+			continue
+		}
+		for i := range got {
+			if got[i] != want[s.Name][i] {
+				t.Errorf("%s: false sharing %+v, want %+v", s.Name, got[i], want[s.Name][i])
+			}
+		}
+	}
+
+	line := "  Possible false sharing: hits (offset: 0) and misses (offset: 8) share the 64-byte cache line at 0;" +
+		" separate them with 64 bytes of padding, as cpu.CacheLinePad does, or split the struct\n"
+	if !strings.Contains(report, line) {
+		t.Errorf("report lacks %q:\n%s", line, report)
+	}
+}
+
+func TestCheckFalseSharing(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		fields []padding.FieldReport
+		want   int
+	}{
+		{"same line", []padding.FieldReport{{Name: "a", Offset: 0, Size: 8}, {Name: "b", Offset: 56, Size: 8}}, 1},
+		{"next line", []padding.FieldReport{{Name: "a", Offset: 0, Size: 8}, {Name: "b", Offset: 64, Size: 8}}, 0},
+		{"straddling", []padding.FieldReport{{Name: "a", Offset: 60, Size: 8}, {Name: "b", Offset: 68, Size: 8}}, 1},
+	} {
+		var r padding.StructReport
+		r.CheckFalseSharing(tt.fields, 64)
+		if len(r.FalseSharing) != tt.want {
+			t.Errorf("%s: false sharing %+v, want %d pairs", tt.name, r.FalseSharing, tt.want)
+		}
+	}
+}
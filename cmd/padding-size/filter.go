@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// structFilter selects the structs analyzed at all, with -structs and
+// -exported-only. The zero structFilter selects every struct.
+type structFilter struct {
+	pattern  *regexp.Regexp // matched against the name, or pkg.Name
+	exported bool           // only exported types
+}
+
+// newStructFilter returns the filter of the -structs regular expression
+// pattern, empty for any name, and of -exported-only.
+func newStructFilter(pattern string, exported bool) (structFilter, error) {
+	sf := structFilter{exported: exported}
+	if pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return structFilter{}, fmt.Errorf("invalid -structs: %v", err)
+		}
+		sf.pattern = re
+	}
+	return sf, nil
+}
+
+// selects reports whether sf selects s, declared in package pkg. An
+// anonymous struct goes with the type or package-level variable whose
+// declaration it is part of; the others are selected only without a
+// filter.
+func (sf structFilter) selects(pkg string, s *padding.StructInfo) bool {
+	if sf.pattern == nil && !sf.exported {
+		return true
+	}
+	name := ownerName(s)
+	if name == "" || sf.exported && !token.IsExported(name) {
+		return false
+	}
+	return sf.pattern == nil || sf.pattern.MatchString(name) || sf.pattern.MatchString(pkg+"."+name)
+}
+
+// apply drops the structs of files sf doesn't select.
+func (sf structFilter) apply(files []*FileResult) {
+	for _, f := range files {
+		f.Structs = slices.DeleteFunc(f.Structs, func(s padding.StructInfo) bool {
+			return !sf.selects(f.Package, &s)
+		})
+	}
+}
+
+// ownerName returns the name of the struct type s or, for an anonymous
+// struct, that of the type or first package-level variable it is declared
+// in, as in Config for Config.limits, or empty if it is declared elsewhere.
+func ownerName(s *padding.StructInfo) string {
+	if !s.Anonymous {
+		return s.Name
+	}
+	name := strings.TrimSuffix(s.Name, " (anonymous struct)")
+	if vars, ok := strings.CutPrefix(name, "var "); ok {
+		name, _, _ = strings.Cut(vars, ",")
+		return name
+	}
+	if strings.Contains(name, ":") {
+		return "" // file:line
+	}
+	name, _, _ = strings.Cut(name, ".")
+	return name
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const filterSource = `package demo
+
+type Conn struct {
+	open bool
+	id   int64
+	busy bool
+}
+
+type ConnPool struct {
+	full  bool
+	size  int64
+	limit struct {
+		soft bool
+		hard int64
+		set  bool
+	}
+	idle bool
+}
+
+type conn struct {
+	open bool
+	id   int64
+	busy bool
+}
+
+type Request struct {
+	done bool
+	n    int64
+	sent bool
+}
+`
+
+func TestStructFilter(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		want  []string // the structs reported
+		fixed []string // the structs -fix reorders
+	}{
+		{
+			name:  "no filter",
+			want:  []string{"Conn", "ConnPool", "ConnPool.limit (anonymous struct)", "conn", "Request"},
+			fixed: []string{"Conn", "ConnPool", "ConnPool.limit", "conn", "Request"},
+		},
+		{
+			name:  "alternatives",
+			args:  []string{"-structs", "^(Conn|Request)$"},
+			want:  []string{"Conn", "Request"},
+			fixed: []string{"Conn", "Request"},
+		},
+		{
+			name:  "case-insensitive with its anonymous structs",
+			args:  []string{"-structs", "(?i)^conn"},
+			want:  []string{"Conn", "ConnPool", "ConnPool.limit (anonymous struct)", "conn"},
+			fixed: []string{"Conn", "ConnPool", "ConnPool.limit", "conn"},
+		},
+		{
+			name:  "qualified",
+			args:  []string{"-structs", `^demo\.(ConnPool|conn)$`},
+			want:  []string{"ConnPool", "ConnPool.limit (anonymous struct)", "conn"},
+			fixed: []string{"ConnPool", "ConnPool.limit", "conn"},
+		},
+		{
+			name:  "exported only",
+			args:  []string{"-exported-only", "-structs", "Conn"},
+			want:  []string{"Conn", "ConnPool", "ConnPool.limit (anonymous struct)"},
+			fixed: []string{"Conn", "ConnPool", "ConnPool.limit"},
+		},
+	}
+	fixedNames := map[string]string{
+		"Conn":           "type Conn struct {\n\tid   int64\n",
+		"ConnPool":       "type ConnPool struct {\n\tsize  int64\n",
+		"ConnPool.limit": "\tlimit struct {\n\t\thard int64\n",
+		"conn":           "type conn struct {\n\tid   int64\n",
+		"Request":        "type Request struct {\n\tn    int64\n",
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, out := runStdin(t, filterSource, append(tt.args, "-all", "-")...)
+			if code != 0 {
+				t.Fatalf("exit status %d, want 0; output:\n%s", code, out)
+			}
+			var got []string
+			for line := range strings.Lines(out) {
+				if name, ok := strings.CutPrefix(line, "Struct: "); ok {
+					name, _, _ = strings.Cut(name, " (size")
+					got = append(got, name)
+				}
+			}
+			if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+				t.Errorf("report lists %v, want %v", got, tt.want)
+			}
+			if want := fmt.Sprintf("Summary: 1 file scanned, %d structs,", len(tt.want)); !strings.Contains(out, want) {
+				t.Errorf("report lacks %q:\n%s", want, out)
+			}
+
+			code, fixed := runStdin(t, filterSource, append(tt.args, "-fix", "-")...)
+			if code != 0 {
+				t.Fatalf("-fix: exit status %d, want 0; output:\n%s", code, fixed)
+			}
+			for name, reordered := range fixedNames {
+				want := contains(tt.fixed, name)
+				if got := strings.Contains(fixed, reordered); got != want {
+					t.Errorf("-fix reordered %s: %v, want %v:\n%s", name, got, want, fixed)
+				}
+			}
+		})
+	}
+}
+
+func TestStructFilterInvalid(t *testing.T) {
+	code, out := runStdin(t, filterSource, "-structs", "Conn(", "-")
+	if code != 2 {
+		t.Errorf("exit status %d, want 2", code)
+	}
+	if out != "" {
+		t.Errorf("stdout holds %q, want nothing", out)
+	}
+}
+
+func TestStructFilterSizes(t *testing.T) {
+	const src = "package demo\n\ntype inner struct {\n\ta bool\n\tb int64\n}\n\ntype Outer struct {\n\tok bool\n\tin inner\n}\n"
+	code, out := runStdin(t, src, "-exported-only", "-all", "-")
+	if code != 0 {
+		t.Fatalf("exit status %d, want 0; output:\n%s", code, out)
+	}
+	// inner is left out, but still lays out the field holding it.
+	if want := "Struct: Outer (size: 24 bytes"; !strings.Contains(out, want) || strings.Contains(out, "Struct: inner") {
+		t.Errorf("report lacks %q, or lists inner:\n%s", want, out)
+	}
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// Statuses of a struct in the fix log.
+const (
+	fixFixed   = "fixed"   // its fields were reordered
+	fixOptimal = "optimal" // its field order was kept
+	fixSkipped = "skipped" // its file was not rewritten
+)
+
+// Causes of skipping a struct, by which the fix summary counts them.
+const (
+	causeUnwritten  = "file not rewritten"
+	causeReflection = "fields indexed by reflection"
+	causeUnkeyed    = "built with unkeyed literals"
+	causeGeneric    = "no order fits all instantiations"
+	causeAnonymous  = "anonymous struct"
+	causeCgo        = "cgo file"
+	causeUnselected = "not selected by -only"
+	causeHeldType   = "holds a -skip-has-type type"
+	causeTooLarge   = "type too large"
+	causeTypeParams = "sized by type parameters"
+	causeComments   = "comment of no field"
+	causeEstimated  = "sizes estimated"
+)
+
+// causeCodes are the machine-readable codes of the causes, as -explain-skip
+// and the fix log report them.
+var causeCodes = map[string]string{
+	causeUnwritten:  "file_not_rewritten",
+	causeReflection: "reflect_index",
+	causeUnkeyed:    "unkeyed_literal",
+	causeGeneric:    "generic_instantiations",
+	causeAnonymous:  "anonymous_struct",
+	causeCgo:        "cgo_file",
+	causeUnselected: "not_selected",
+	causeHeldType:   "held_type",
+	causeTooLarge:   "too_large",
+	causeTypeParams: "type_params",
+	causeComments:   "free_comment",
+	causeEstimated:  "estimated_sizes",
+}
+
+// skipReason is why fix left a struct alone: the cause, and the details.
+type skipReason struct {
+	cause, text string
+}
+
+// code returns the code of the cause of r.
+func (r skipReason) code() string {
+	return causeCodes[r.cause]
+}
+
+// fixLog records what -fix changed, for -fix-log and the summary printed
+// after fixing. It is safe for concurrent use.
+type fixLog struct {
+	mu      sync.Mutex
+	structs []fixRecord
+	skipped []skippedFile
+
+	// pad is set when fix pads the structs rather than reordering them,
+	// which leaves their sizes as they were.
+	pad bool
+}
+
+// fixRecord is the entry of a struct in the fix log.
+type fixRecord struct {
+	File     string   `json:"file"`
+	Struct   string   `json:"struct"`
+	Status   string   `json:"status"`
+	Reason   string   `json:"reason,omitempty"` // why a skipped struct was left alone
+	Cause    string   `json:"cause,omitempty"`  // the kind of Reason, such as "anonymous struct"
+	Code     string   `json:"code,omitempty"`   // the code of Cause, such as "anonymous_struct"
+	OldOrder []string `json:"old_order"`
+	NewOrder []string `json:"new_order"`
+	Moved    []string `json:"moved,omitempty"` // fields moved, the others keeping their relative order
+	OldSize  int64    `json:"old_size"`
+	NewSize  int64    `json:"new_size"`
+	Saved    int64    `json:"saved"` // OldSize - NewSize
+}
+
+// skippedFile is a file that was not rewritten at all.
+type skippedFile struct {
+	File   string `json:"file"`
+	Reason string `json:"reason"`
+}
+
+// fixSummary sums up the fix log.
+type fixSummary struct {
+	Structs      int   `json:"structs"`
+	Fixed        int   `json:"fixed"`
+	Optimal      int   `json:"optimal"`
+	Skipped      int   `json:"skipped"`
+	SkippedFiles int   `json:"skipped_files"`
+	Moved        int   `json:"moved"` // fields moved in all
+	Saved        int64 `json:"saved"` // bytes saved per instance of each struct
+}
+
+// skipCause is the number of structs skipped for a cause.
+type skipCause struct {
+	Cause   string `json:"cause"`
+	Structs int    `json:"structs"`
+}
+
+// addFile records the structs of the file at path, with their layout before
+// and after fixing. If err is not nil, the file could not be rewritten and
+// its structs are recorded as skipped for that reason; otherwise a struct
+// with reasons in reasons, by index, was left alone for the first.
+func (l *fixLog) addFile(path string, before, after []padding.StructInfo, reasons [][]skipReason, err error) {
+	records := make([]fixRecord, len(before))
+	for i, s := range before {
+		r := fixRecord{
+			File:     path,
+			Struct:   s.Name,
+			Status:   fixOptimal,
+			OldOrder: fieldNames(s),
+			NewOrder: fieldNames(after[i]),
+			OldSize:  s.Size,
+			NewSize:  after[i].Size,
+		}
+		switch all := fixSkipReasons(s, reasons[i]); {
+		case err != nil:
+			r.Status, r.Reason, r.Cause, r.Code = fixSkipped, err.Error(), causeUnwritten, causeCodes[causeUnwritten]
+			r.NewOrder, r.NewSize = r.OldOrder, r.OldSize
+		case len(all) > 0:
+			r.Status, r.Reason, r.Cause, r.Code = fixSkipped, all[0].text, all[0].cause, all[0].code()
+		case !slices.Equal(r.OldOrder, r.NewOrder) || !slices.EqualFunc(s.Fields, after[i].Fields, sameType):
+			r.Status = fixFixed
+			r.Moved = movedFields(r.OldOrder, r.NewOrder)
+		}
+		if s.ParamSized {
+			// Its sizes depend on the instantiation.
+			r.OldSize, r.NewSize = 0, 0
+		}
+		r.Saved = r.OldSize - r.NewSize
+		records[i] = r
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.structs = append(l.structs, records...)
+}
+
+// fixSkipReasons returns why fix left s, as declared, alone: the reasons
+// it never reorders such a struct, followed by found, as found while
+// reporting it. It returns nil if fix didn't leave s alone.
+func fixSkipReasons(s padding.StructInfo, found []skipReason) []skipReason {
+	var reasons []skipReason
+	if s.TooLarge {
+		reasons = append(reasons, skipReason{causeTooLarge, "its size overflows, as the compiler rejects it"})
+	}
+	if s.Cgo {
+		reasons = append(reasons, skipReason{causeCgo, "declared in a file using cgo, whose C types are sized by guess"})
+	}
+	if !s.Cgo && guessedSizes(s) {
+		reasons = append(reasons, skipReason{causeEstimated, "the sizes of some of its fields are estimated, as their types failed to type-check"})
+	}
+	if s.FreeComments {
+		reasons = append(reasons, skipReason{causeComments, "a comment of its body belongs to no field, and would end up above another"})
+	}
+	if s.ReportOnly {
+		reasons = append(reasons, skipReason{causeAnonymous, "anonymous struct other than the type of a package-level variable or inline field"})
+	}
+	return append(reasons, found...)
+}
+
+// sameType reports whether a and b, a field before and after fixing, have
+// the same type, as they don't if -pad resized a padding field.
+func sameType(a, b padding.FieldInfo) bool {
+	return a.Type == b.Type
+}
+
+// movedFields returns the fields of the old order that the new order of the
+// same fields moves, in the old order. Blank fields, such as the padding
+// fields -pad adds and drops, are left out.
+func movedFields(old, new []string) []string {
+	blank := func(name string) bool { return name == "_" }
+	old, new = slices.DeleteFunc(slices.Clone(old), blank), slices.DeleteFunc(slices.Clone(new), blank)
+	index := make(map[string]int, len(old))
+	for i, name := range old {
+		index[name] = i
+	}
+	order := make([]int, len(new))
+	for i, name := range new {
+		order[i] = index[name]
+	}
+	var moved []string
+	for _, i := range padding.Moved(order) {
+		moved = append(moved, old[i])
+	}
+	return moved
+}
+
+// skipFile records that the file at path was skipped for reason.
+func (l *fixLog) skipFile(path, reason string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.skipped = append(l.skipped, skippedFile{path, reason})
+}
+
+func fieldNames(s padding.StructInfo) []string {
+	names := make([]string, len(s.Fields))
+	for i, f := range s.Fields {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// summary sums up the log.
+func (l *fixLog) summary() fixSummary {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	sum := fixSummary{Structs: len(l.structs), SkippedFiles: len(l.skipped)}
+	for _, r := range l.structs {
+		switch r.Status {
+		case fixFixed:
+			sum.Fixed++
+		case fixOptimal:
+			sum.Optimal++
+		case fixSkipped:
+			sum.Skipped++
+		}
+		sum.Moved += len(r.Moved)
+		sum.Saved += r.Saved
+	}
+	return sum
+}
+
+// skipCauses counts the skipped structs by cause, most frequent first.
+func (l *fixLog) skipCauses() []skipCause {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	causes := []skipCause{}
+	for _, r := range l.structs {
+		if r.Status != fixSkipped {
+			continue
+		}
+		i := slices.IndexFunc(causes, func(c skipCause) bool { return c.Cause == r.Cause })
+		if i < 0 {
+			i = len(causes)
+			causes = append(causes, skipCause{Cause: r.Cause})
+		}
+		causes[i].Structs++
+	}
+	slices.SortFunc(causes, func(a, b skipCause) int {
+		return cmp.Or(cmp.Compare(b.Structs, a.Structs), cmp.Compare(a.Cause, b.Cause))
+	})
+	return causes
+}
+
+// sort sorts the structs and files of the log by file, so that its output
+// does not depend on the order files were processed in.
+func (l *fixLog) sort() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	slices.SortStableFunc(l.structs, func(a, b fixRecord) int { return cmp.Compare(a.File, b.File) })
+	slices.SortStableFunc(l.skipped, func(a, b skippedFile) int { return cmp.Compare(a.File, b.File) })
+}
+
+// write writes the log to the file at path as JSON.
+func (l *fixLog) write(path string) error {
+	l.sort()
+	summary, causes := l.summary(), l.skipCauses()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	doc := struct {
+		Summary      fixSummary    `json:"summary"`
+		SkipCauses   []skipCause   `json:"skip_causes"`
+		Structs      []fixRecord   `json:"structs"`
+		SkippedFiles []skippedFile `json:"skipped_files"`
+	}{summary, causes, l.structs, l.skipped}
+	if doc.Structs == nil {
+		doc.Structs = []fixRecord{}
+	}
+	if doc.SkippedFiles == nil {
+		doc.SkippedFiles = []skippedFile{}
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// writeSummary writes a table of the structs fix shrank or reordered to w,
+// with their sizes before and after and the bytes saved, followed by the
+// totals and the number of structs skipped for each cause.
+func (l *fixLog) writeSummary(w io.Writer) error {
+	l.sort()
+	summary, causes := l.summary(), l.skipCauses()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	count := func(n int, noun string) string {
+		if n == 1 {
+			return fmt.Sprintf("%d %s", n, noun)
+		}
+		return fmt.Sprintf("%d %ss", n, noun)
+	}
+	fmt.Fprintf(w, "Fix summary:\n")
+	if l.pad {
+		fmt.Fprintf(w, "Padded %s; %d without padding, %d skipped.\n", count(summary.Fixed, "struct"), summary.Optimal, summary.Skipped)
+		for _, c := range causes {
+			fmt.Fprintf(w, "  skipped %d: %s\n", c.Structs, c.Cause)
+		}
+		if summary.SkippedFiles > 0 {
+			fmt.Fprintf(w, "  %s not rewritten at all\n", count(summary.SkippedFiles, "file"))
+		}
+		_, err := fmt.Fprintln(w)
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "OLD SIZE\tNEW SIZE\tSAVED\t  STRUCT\n")
+	for _, r := range l.structs {
+		if r.Status == fixFixed {
+			fmt.Fprintf(tw, "%d\t%d\t%d\t  %s (%s)\n", r.OldSize, r.NewSize, r.Saved, r.Struct, r.File)
+		}
+	}
+	fmt.Fprintf(tw, "\t\t%d\t  total\n", summary.Saved)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Fixed %s, saved %d bytes per instance; %d already optimal, %d skipped.\n",
+		count(summary.Fixed, "struct"), summary.Saved, summary.Optimal, summary.Skipped)
+	for _, c := range causes {
+		fmt.Fprintf(w, "  skipped %d: %s\n", c.Structs, c.Cause)
+	}
+	if summary.SkippedFiles > 0 {
+		fmt.Fprintf(w, "  %s not rewritten at all\n", count(summary.SkippedFiles, "file"))
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// log logs the structs fix shrank or reordered and those it skipped, with
+// the reason, then the files it skipped and the totals, as writeSummary
+// writes them as text.
+func (l *fixLog) log(logger *slog.Logger) {
+	l.sort()
+	summary := l.summary()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, r := range l.structs {
+		switch r.Status {
+		case fixFixed:
+			logger.Info("fixed", "file", r.File, "struct", r.Struct,
+				"old_size", r.OldSize, "new_size", r.NewSize, "saved", r.Saved)
+		case fixSkipped:
+			logger.Warn("skipped", "file", r.File, "struct", r.Struct, "reason", r.Reason, "cause", r.Cause)
+		}
+	}
+	for _, f := range l.skipped {
+		logger.Warn("skipped file", "file", f.File, "reason", f.Reason)
+	}
+	logger.Info("fix summary", "fixed", summary.Fixed, "optimal", summary.Optimal,
+		"skipped", summary.Skipped, "skipped_files", summary.SkippedFiles, "saved", summary.Saved)
+}
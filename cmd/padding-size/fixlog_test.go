@@ -0,0 +1,264 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestFixLog(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, src string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	a := write("a.go", `package p
+
+type Loose struct {
+	A bool
+	B int64
+	C bool
+}
+
+type Tight struct {
+	B int64
+	A bool
+}
+`)
+	b := write("b.go", `package p
+
+type Busy struct {
+	A bool
+	B int64
+	C bool
+}
+`)
+
+	l := new(fixLog)
+	opts := options{fix: true, fixLog: l}
+	cache := padding.NewCache()
+	var files []*FileResult
+	for _, path := range []string{b, a} {
+		f, err := loadFile(path, cache)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, f)
+	}
+	// b.go is edited while it is analyzed, so it is not rewritten.
+	write("b.go", "package p\n\ntype Busy struct{}\n")
+	captureReport(t, func() error {
+		if err := reportFile(files[0], nil, opts); err == nil {
+			t.Error("rewriting b.go succeeded")
+		}
+		return reportFile(files[1], nil, opts)
+	})
+	l.skipFile(filepath.Join(dir, "c.go"), "already processed under another name")
+
+	logPath := filepath.Join(dir, "fix.json")
+	if err := l.write(logPath); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Summary      fixSummary
+		SkipCauses   []skipCause `json:"skip_causes"`
+		Structs      []fixRecord
+		SkippedFiles []skippedFile `json:"skipped_files"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []fixRecord{
+		{File: a, Struct: "Loose", Status: fixFixed, OldOrder: []string{"A", "B", "C"}, NewOrder: []string{"B", "A", "C"}, Moved: []string{"B"}, OldSize: 24, NewSize: 16, Saved: 8},
+		{File: a, Struct: "Tight", Status: fixOptimal, OldOrder: []string{"B", "A"}, NewOrder: []string{"B", "A"}, OldSize: 16, NewSize: 16},
+		{File: b, Struct: "Busy", Status: fixSkipped, Reason: b + " changed while it was being analyzed; not rewriting it", Cause: causeUnwritten, Code: "file_not_rewritten",
+			OldOrder: []string{"A", "B", "C"}, NewOrder: []string{"A", "B", "C"}, OldSize: 24, NewSize: 24},
+	}
+	if !reflect.DeepEqual(got.Structs, want) {
+		t.Errorf("structs:\n%+v\nwant:\n%+v", got.Structs, want)
+	}
+	if want := (fixSummary{Structs: 3, Fixed: 1, Optimal: 1, Skipped: 1, SkippedFiles: 1, Saved: 8, Moved: 1}); got.Summary != want {
+		t.Errorf("summary = %+v, want %+v", got.Summary, want)
+	}
+	if want := []skipCause{{causeUnwritten, 1}}; !reflect.DeepEqual(got.SkipCauses, want) {
+		t.Errorf("skip causes = %+v, want %+v", got.SkipCauses, want)
+	}
+	if len(got.SkippedFiles) != 1 || got.SkippedFiles[0].Reason != "already processed under another name" {
+		t.Errorf("skipped files = %+v", got.SkippedFiles)
+	}
+	if src := readFile(t, a); !strings.Contains(src, "type Loose struct {\n\tB int64\n\tA bool\n\tC bool\n}") {
+		t.Errorf("a.go was not fixed:\n%s", src)
+	}
+}
+
+func TestFixLogDuplicateFile(t *testing.T) {
+	path := writeFile(t, "package p\n\ntype T struct {\n\tA bool\n\tB int64\n\tC bool\n}\n")
+	link := filepath.Join(filepath.Dir(path), "link.go")
+	if err := os.Symlink(path, link); err != nil {
+		t.Skip(err)
+	}
+
+	l := new(fixLog)
+	opts := options{fix: true, fixLog: l}
+	captureReport(t, func() error { return processFiles([]string{path, link}, opts, newFileRegistry()) })
+	if want := []skippedFile{{link, "already processed under another name"}}; !reflect.DeepEqual(l.skipped, want) {
+		t.Errorf("skipped files = %+v, want %+v", l.skipped, want)
+	}
+	if sum := l.summary(); sum.Structs != 1 || sum.Fixed != 1 {
+		t.Errorf("summary = %+v, want 1 struct fixed", sum)
+	}
+}
+
+func TestFixSummary(t *testing.T) {
+	dir := t.TempDir()
+	for name, src := range map[string]string{
+		"a.go": `package p
+
+type Loose struct {
+	A bool
+	B int64
+	C bool
+}
+
+type Tight struct {
+	B int64
+	A bool
+}
+
+var limits = struct {
+	on   bool
+	rate int64
+	off  bool
+}{}
+`,
+		"b.go": `package p
+
+type Wide struct {
+	A bool
+	B int64
+	C bool
+	D int32
+	E bool
+	F int64
+}
+`,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	l := new(fixLog)
+	opts := options{fix: true, fixLog: l}
+	captureReport(t, func() error { return processPath(dir, opts, newFileRegistry()) })
+
+	var b strings.Builder
+	if err := l.writeSummary(&b); err != nil {
+		t.Fatal(err)
+	}
+	a, w := filepath.Join(dir, "a.go"), filepath.Join(dir, "b.go")
+	want := `Fix summary:
+  OLD SIZE  NEW SIZE  SAVED  STRUCT
+        24        16      8  Loose (` + a + `)
+        40        24     16  Wide (` + w + `)
+                         24  total
+Fixed 2 structs, saved 24 bytes per instance; 1 already optimal, 1 skipped.
+  skipped 1: anonymous struct
+
+`
+	if b.String() != want {
+		t.Errorf("summary:\n%s\nwant:\n%s", b.String(), want)
+	}
+
+	// The total is the sum of the rows.
+	var rows int64
+	for _, r := range l.structs {
+		rows += r.Saved
+	}
+	if sum := l.summary(); sum.Saved != rows || sum.Saved != 24 {
+		t.Errorf("saved %d in all, rows sum to %d", sum.Saved, rows)
+	}
+}
+
+func TestFixSkipsCgoFiles(t *testing.T) {
+	src := readFile(t, filepath.Join("testdata", "cgo", "cgo.go"))
+	path := writeFile(t, src)
+
+	l := new(fixLog)
+	report := captureReport(t, func() error { return processFile(path, options{fix: true, fixLog: l}) })
+	if got := readFile(t, path); got != src {
+		t.Errorf("fix rewrote the cgo file:\n%s", got)
+	}
+	for _, r := range l.structs {
+		if r.Status != fixSkipped || r.Cause != causeCgo {
+			t.Errorf("%s: %s (%s), want skipped for %s", r.Struct, r.Status, r.Cause, causeCgo)
+		}
+	}
+	if len(l.structs) != 2 {
+		t.Errorf("logged %d structs, want 2", len(l.structs))
+	}
+	// Only the struct with C fields has approximate sizes.
+	if !strings.Contains(report, "Struct: Sample (size: 40 bytes (alloc 48), align: 8, optimal: 32 bytes, packed minimum: 30 bytes, wasted: 8 bytes, padding: 10 inter-field + 0 trailing) (cgo — sizes approximate)\n") {
+		t.Errorf("report lacks the approximate Sample:\n%s", report)
+	}
+	if strings.Count(report, "cgo — sizes approximate") != 1 {
+		t.Errorf("want only Sample marked approximate:\n%s", report)
+	}
+
+	// -fix-decl leaves the file alone too.
+	report = captureReport(t, func() error { return processDecl(path, 14, true) })
+	if got := readFile(t, path); got != src {
+		t.Errorf("-fix-decl rewrote the cgo file:\n%s", got)
+	}
+	if !strings.Contains(report, "not reordering Sample: its file uses cgo") {
+		t.Errorf("-fix-decl doesn't say why it left Sample:\n%s", report)
+	}
+}
+
+func TestFixSkipsTypesTooLarge(t *testing.T) {
+	src := `package p
+
+type Huge struct {
+	A    bool
+	Many [4611686018427387904]Inner
+	B    int32
+}
+
+type Inner struct {
+	A bool
+	N int64
+	B bool
+}
+`
+	path := writeFile(t, src)
+	l := new(fixLog)
+	report := captureReport(t, func() error { return processFile(path, options{fix: true, fixLog: l}) })
+	if want := path + ":3: type Huge too large\n"; !strings.Contains(report, want) {
+		t.Errorf("report lacks %q:\n%s", want, report)
+	}
+	if want := "Struct: Huge (type too large: 1125899906842624 bytes or more)\n  A bool\n  Many [4611686018427387904]Inner\n  B int32\n\n"; !strings.Contains(report, want) {
+		t.Errorf("report lacks %q:\n%s", want, report)
+	}
+	if got := readFile(t, path); !strings.Contains(got, "type Huge struct {\n\tA    bool\n") || !strings.Contains(got, "type Inner struct {\n\tN int64\n") {
+		t.Errorf("want only Inner reordered:\n%s", got)
+	}
+	for _, r := range l.structs {
+		if r.Struct == "Huge" && (r.Status != fixSkipped || r.Cause != causeTooLarge) {
+			t.Errorf("Huge: %s (%s), want skipped for %s", r.Status, r.Cause, causeTooLarge)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"cmp"
+	"go/types"
+	"slices"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// genericLayouts holds, for package-level generic struct types, the field
+// order suiting best all their instantiations in the packages processed.
+// Types never instantiated with concrete type arguments are left out.
+type genericLayouts map[structKey]genericLayout
+
+// genericLayout is the common order of the fields of a generic struct, as a
+// permutation of its declared fields, with the bytes it wastes in the worst
+// of its instantiations and their sizes in it.
+type genericLayout struct {
+	order     []int
+	waste     int64
+	fields    []string
+	instances []padding.InstantiationReport
+}
+
+// findGenericLayouts type-checks the package in dir, and with recursive the
+// packages below it as well, collects the instantiations of their generic
+// struct types with concrete type arguments and finds for each type the
+// common order of padding.CommonOrder. Types embedding fields are left out,
+// since the common order would not keep an embedded lock first.
+func findGenericLayouts(dir string, recursive bool) (genericLayouts, error) {
+	pkgs, loadErr := loadPackages(dir, recursive, packages.NeedTypes|packages.NeedTypesInfo|packages.NeedTypesSizes)
+
+	type instantiated struct {
+		names   []string
+		structs []*types.Struct
+		sizes   types.Sizes
+	}
+	found := make(map[structKey]*instantiated)
+	for _, pkg := range pkgs {
+		if pkg.TypesInfo == nil || pkg.TypesSizes == nil {
+			continue
+		}
+		for _, inst := range pkg.TypesInfo.Instances {
+			named, ok := inst.Type.(*types.Named)
+			if !ok || named.Origin() == named || anyTypeParams(inst.TypeArgs) {
+				continue
+			}
+			st, ok := named.Underlying().(*types.Struct)
+			if !ok || embeds(st) {
+				continue
+			}
+			obj := named.Origin().Obj()
+			key := structKey{realDir(pkg.Fset.Position(obj.Pos()).Filename), obj.Name()}
+			in := found[key]
+			if in == nil {
+				in = &instantiated{sizes: pkg.TypesSizes}
+				found[key] = in
+			}
+			name := types.TypeString(named, types.RelativeTo(obj.Pkg()))
+			if !slices.Contains(in.names, name) {
+				in.names = append(in.names, name)
+				in.structs = append(in.structs, st)
+			}
+		}
+	}
+
+	layouts := make(genericLayouts)
+	for key, in := range found {
+		order, waste := padding.CommonOrder(in.structs, in.sizes)
+		l := genericLayout{order: order, waste: waste}
+		for _, i := range order {
+			l.fields = append(l.fields, in.structs[0].Field(i).Name())
+		}
+		for i, st := range in.structs {
+			l.instances = append(l.instances, padding.InstantiationReport{
+				Type:        in.names[i],
+				Size:        padding.OrderedSize(st, in.sizes, order),
+				OptimalSize: padding.OrderedSize(st, in.sizes, padding.OptimalOrder(st, in.sizes)),
+			})
+		}
+		slices.SortFunc(l.instances, func(a, b padding.InstantiationReport) int {
+			return cmp.Compare(a.Type, b.Type)
+		})
+		layouts[key] = l
+	}
+	return layouts, loadErr
+}
+
+// hasTypeParams reports whether t mentions a type parameter, as the type
+// arguments of the instantiations inside generic code do.
+func hasTypeParams(t types.Type) bool {
+	switch t := t.(type) {
+	case *types.TypeParam:
+		return true
+	case *types.Pointer:
+		return hasTypeParams(t.Elem())
+	case *types.Slice:
+		return hasTypeParams(t.Elem())
+	case *types.Array:
+		return hasTypeParams(t.Elem())
+	case *types.Chan:
+		return hasTypeParams(t.Elem())
+	case *types.Map:
+		return hasTypeParams(t.Key()) || hasTypeParams(t.Elem())
+	case *types.Named:
+		return anyTypeParams(t.TypeArgs())
+	case *types.Struct:
+		for i := range t.NumFields() {
+			if hasTypeParams(t.Field(i).Type()) {
+				return true
+			}
+		}
+	case *types.Tuple:
+		for i := range t.Len() {
+			if hasTypeParams(t.At(i).Type()) {
+				return true
+			}
+		}
+	case *types.Signature:
+		return hasTypeParams(t.Params()) || hasTypeParams(t.Results())
+	}
+	return false
+}
+
+// anyTypeParams reports whether a type of list mentions a type parameter.
+func anyTypeParams(list *types.TypeList) bool {
+	for i := range list.Len() {
+		if hasTypeParams(list.At(i)) {
+			return true
+		}
+	}
+	return false
+}
+
+// embeds reports whether st has embedded fields.
+func embeds(st *types.Struct) bool {
+	for i := range st.NumFields() {
+		if st.Field(i).Embedded() {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns the layouts of the generic structs of files by struct.
+func (g genericLayouts) resolve(files []*FileResult) map[*padding.StructInfo]genericLayout {
+	resolved := make(map[*padding.StructInfo]genericLayout)
+	for _, f := range files {
+		topLevel := topLevelStructs(f.Node)
+		for i := range f.Structs {
+			s := &f.Structs[i]
+			if l, ok := g[structKey{realDir(f.Path), s.Name}]; ok && topLevel[s.Node] && len(l.order) == len(s.Fields) {
+				resolved[s] = l
+			}
+		}
+	}
+	return resolved
+}
+
+// apply returns s with its fields in the common order.
+func (l genericLayout) apply(s padding.StructInfo) padding.StructInfo {
+	fields := s.Fields
+	s.Fields = make([]padding.FieldInfo, len(fields))
+	for i, j := range l.order {
+		s.Fields[i] = fields[j]
+	}
+	padding.AnalyzeStruct(&s)
+	return s
+}
+
+// weigh sets the instantiations of r, a generic struct, and their common
+// order.
+func (l genericLayout) weigh(r *padding.StructReport) {
+	r.Instantiations, r.CommonOrder, r.CommonOrderWaste = l.instances, l.fields, l.waste
+}
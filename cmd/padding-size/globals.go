@@ -0,0 +1,107 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"go/types"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"text/tabwriter"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// findStaticFootprint type-checks the package in dir, and with recursive
+// the packages below it as well, and returns their package-level variables
+// of struct types and arrays of them holding padding. The type checker
+// evaluates the constant expressions giving the lengths of the arrays.
+func findStaticFootprint(dir string, recursive bool) ([]padding.GlobalReport, error) {
+	pkgs, loadErr := loadPackages(dir, recursive, packages.NeedTypes|packages.NeedSyntax|packages.NeedTypesSizes)
+
+	cwd, _ := os.Getwd()
+	var globals []padding.GlobalReport
+	for _, pkg := range pkgs {
+		if pkg.Types == nil || pkg.TypesSizes == nil {
+			continue
+		}
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			obj, ok := scope.Lookup(name).(*types.Var)
+			if !ok {
+				continue
+			}
+			elements, bytes, recoverable, ok := padding.StaticFootprint(obj.Type(), pkg.TypesSizes)
+			if !ok || bytes == 0 {
+				continue
+			}
+			pos := pkg.Fset.Position(obj.Pos())
+			file := pos.Filename
+			if rel, err := filepath.Rel(cwd, file); err == nil && filepath.IsLocal(rel) {
+				file = rel
+			}
+			globals = append(globals, padding.GlobalReport{
+				Package:          pkg.Name,
+				Name:             name,
+				Type:             types.TypeString(obj.Type(), types.RelativeTo(pkg.Types)),
+				Position:         fmt.Sprintf("%s:%d", filepath.ToSlash(file), pos.Line),
+				Size:             pkg.TypesSizes.Sizeof(obj.Type()),
+				Elements:         elements,
+				PaddingBytes:     bytes,
+				RecoverableBytes: recoverable,
+			})
+		}
+	}
+	return globals, loadErr
+}
+
+// sortGlobals returns a copy of globals by decreasing padding, then by
+// position, or nil if there are none.
+func sortGlobals(globals []padding.GlobalReport) []padding.GlobalReport {
+	if len(globals) == 0 {
+		return nil
+	}
+	globals = slices.Clone(globals)
+	slices.SortFunc(globals, func(a, b padding.GlobalReport) int {
+		return cmp.Or(cmp.Compare(b.PaddingBytes, a.PaddingBytes), cmp.Compare(a.Position, b.Position))
+	})
+	return globals
+}
+
+// writeStaticFootprint writes the package-level variables of r holding
+// padded structs to w, with their sizes, padding and the bytes reordering
+// the fields of their element types recovers, followed by the totals. If
+// top is positive, only the first top variables are listed; the totals
+// still cover all of them.
+func writeStaticFootprint(w io.Writer, r padding.Report, top int) error {
+	globals := r.StaticFootprint
+	var total, recoverable int64
+	for _, g := range globals {
+		total += g.PaddingBytes
+		recoverable += g.RecoverableBytes
+	}
+	if top > 0 && len(globals) > top {
+		globals = globals[:top]
+	}
+
+	fmt.Fprintf(w, "Static footprint (package-level variables):\n")
+	if len(globals) == 0 {
+		fmt.Fprintf(w, "  No variables hold padded structs.\n\n")
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "SIZE\tPADDING\tRECOVERABLE\t  VARIABLE\n")
+	for _, g := range globals {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t  %s.%s %s (%s)\n", formatBytes(g.Size), formatBytes(g.PaddingBytes),
+			formatBytes(g.RecoverableBytes), g.Package, g.Name, g.Type, g.Position)
+	}
+	fmt.Fprintf(tw, "\t%s\t%s\t  total\n", formatBytes(total), formatBytes(recoverable))
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
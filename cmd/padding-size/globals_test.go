@@ -0,0 +1,44 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestStaticFootprint(t *testing.T) {
+	dir := filepath.Join("testdata", "globals")
+	opts := options{collect: new(reportCollector), globals: true}
+	captureReport(t, func() error { return processPath(dir, opts, newFileRegistry()) })
+	// The same package given again adds nothing.
+	captureReport(t, func() error { return processPath(dir, opts, newFileRegistry()) })
+
+	r := opts.collect.report()
+	want := []padding.GlobalReport{
+		{Package: "globals", Name: "table", Type: "[32]entry", Position: "testdata/globals/globals.go:17",
+			Size: 768, Elements: 32, PaddingBytes: 448, RecoverableBytes: 256},
+		{Package: "globals", Name: "fallback", Type: "entry", Position: "testdata/globals/globals.go:20",
+			Size: 24, Elements: 1, PaddingBytes: 14, RecoverableBytes: 8},
+	}
+	if !reflect.DeepEqual(r.StaticFootprint, want) {
+		t.Errorf("static footprint = %+v, want %+v", r.StaticFootprint, want)
+	}
+
+	var b strings.Builder
+	if err := writeStaticFootprint(&b, r, 0); err != nil {
+		t.Fatal(err)
+	}
+	wantText := `Static footprint (package-level variables):
+  SIZE  PADDING  RECOVERABLE  VARIABLE
+   768      448          256  globals.table [32]entry (testdata/globals/globals.go:17)
+    24       14            8  globals.fallback entry (testdata/globals/globals.go:20)
+            462          264  total
+
+`
+	if b.String() != wantText {
+		t.Errorf("static footprint:\n%s\nwant:\n%s", b.String(), wantText)
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"cmp"
+	"flag"
+	"fmt"
+	"go/types"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// goBuild holds the go command settings of the run. Every package load and
+// go command padding-size runs uses them, so the analysis sees the same
+// dependency versions as the build. GOFLAGS is honored as by the go command
+// itself, with -mod and -modfile taking precedence over it.
+var goBuild goSettings
+
+// goSettings are the go command flags padding-size passes on, and whether
+// to trace what it runs.
+type goSettings struct {
+	mod     string // -mod, such as vendor or readonly
+	modfile string // -modfile, absolute once resolved
+
+	// compiler is -compiler, gc or gccgo, whose layout rules structs are
+	// laid out by. Package loads stay with gc, which needs no gccgo
+	// installed, and only their sizes follow the compiler.
+	compiler string
+
+	// arch is -arch, the GOARCH structs are laid out for and packages
+	// loaded for; the go command's if empty. Only the report itself sets
+	// it, the commands having -arch flags of their own.
+	arch string
+
+	// goos and tags are -goos and -tags, the GOOS and build tags that
+	// select the files analyzed and the packages loaded; the go
+	// command's GOOS if goos is empty. Only the report sets them.
+	goos string
+	tags []string
+
+	// debug, if set, receives a line for each package load and go
+	// command, and the go list invocations of the loads.
+	debug io.Writer
+}
+
+// register defines the -mod, -modfile, -compiler and -debug flags setting
+// s on fs.
+func (s *goSettings) register(fs *flag.FlagSet) {
+	fs.StringVar(&s.compiler, "compiler", padding.CompilerGC, "Compiler whose size and alignment rules to lay out structs by: gc or gccgo")
+	fs.StringVar(&s.mod, "mod", "", "Module download `mode` for loading packages, as go build -mod: readonly, vendor or mod")
+	fs.StringVar(&s.modfile, "modfile", "", "Read and write this go.mod `file` instead of the module's, as go build -modfile")
+	fs.BoolFunc("debug", "Print the package loads and go commands run, to diagnose mismatches with the build", func(string) error {
+		s.debug = os.Stderr
+		return nil
+	})
+}
+
+// resolve checks the flags, and makes the -modfile path absolute, since the
+// go command resolves it in the directory of each package loaded.
+func (s *goSettings) resolve() error {
+	switch s.mod {
+	case "", "readonly", "vendor", "mod":
+	default:
+		return fmt.Errorf("invalid -mod=%s: want readonly, vendor or mod", s.mod)
+	}
+	switch s.compiler {
+	case "", padding.CompilerGC, padding.CompilerGccgo:
+	default:
+		return fmt.Errorf("invalid -compiler=%s: want gc or gccgo", s.compiler)
+	}
+	if s.goos != "" && !knownOS[s.goos] {
+		return fmt.Errorf("invalid -goos=%s: not an operating system the go command knows", s.goos)
+	}
+	if s.arch != "" && types.SizesFor(cmp.Or(s.compiler, padding.CompilerGC), s.arch) == nil {
+		return fmt.Errorf("invalid -arch=%s: not an architecture %s supports", s.arch, cmp.Or(s.compiler, padding.CompilerGC))
+	}
+	if s.modfile != "" {
+		abs, err := filepath.Abs(s.modfile)
+		if err != nil {
+			return err
+		}
+		s.modfile = abs
+	}
+	return nil
+}
+
+// buildFlags returns the go command flags of s.
+func (s goSettings) buildFlags() []string {
+	var flags []string
+	if s.mod != "" {
+		flags = append(flags, "-mod="+s.mod)
+	}
+	if s.modfile != "" {
+		flags = append(flags, "-modfile="+s.modfile)
+	}
+	if len(s.tags) > 0 {
+		flags = append(flags, "-tags="+strings.Join(s.tags, ","))
+	}
+	return flags
+}
+
+// env returns the environment of the go commands run with s, setting GOOS
+// and GOARCH, or nil for that of the process.
+func (s goSettings) env() []string {
+	if s.goos == "" && s.arch == "" {
+		return nil
+	}
+	env := os.Environ()
+	if s.goos != "" {
+		env = append(env, "GOOS="+s.goos)
+	}
+	if s.arch != "" {
+		env = append(env, "GOARCH="+s.arch)
+	}
+	return env
+}
+
+// config returns the configuration loading the packages of dir with mode.
+// The go command runs in dir, or in the working directory if it is empty.
+func (s goSettings) config(dir string, mode packages.LoadMode) *packages.Config {
+	cfg := &packages.Config{
+		Mode:       mode,
+		Dir:        dir,
+		BuildFlags: s.buildFlags(),
+		Env:        s.env(),
+	}
+	if s.debug != nil {
+		cfg.Logf = func(format string, args ...any) {
+			line := fmt.Sprintf(strings.TrimSuffix(format, "\n"), args...)
+			s.trace("debug: "+line+"\n", "go/packages", "reason", line)
+		}
+	}
+	return cfg
+}
+
+// gccgo reports whether structs are laid out by the rules of gccgo.
+func (s goSettings) gccgo() bool {
+	return s.compiler == padding.CompilerGccgo
+}
+
+// target describes the platform layouts are computed for, GOARCH=arch and,
+// for gccgo, the compiler, as recorded in generated files.
+func (s goSettings) target(arch string) string {
+	if s.gccgo() {
+		return "GOARCH=" + arch + " and the gccgo compiler"
+	}
+	return "GOARCH=" + arch
+}
+
+// load runs packages.Load with cfg, as returned by config, tracing it with
+// -debug. With -compiler=gccgo, the packages get the sizes of gccgo for the
+// architecture loaded; the type-checker itself still evaluated
+// unsafe.Sizeof and its kin as gc would.
+func (s goSettings) load(cfg *packages.Config, patterns ...string) ([]*packages.Package, error) {
+	if s.debug != nil {
+		dir := cfg.Dir
+		if dir == "" {
+			dir = "."
+		}
+		patterns := strings.Join(patterns, " ")
+		s.trace(fmt.Sprintf("debug: loading %s in %s with build flags %q, GOFLAGS=%q\n", patterns, dir, cfg.BuildFlags, goflags(cfg.Env)),
+			"loading packages", "file", dir, "patterns", patterns, "build_flags", cfg.BuildFlags, "goflags", goflags(cfg.Env))
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil || !s.gccgo() {
+		return pkgs, err
+	}
+	arch := ""
+	for _, kv := range cfg.Env {
+		if v, ok := strings.CutPrefix(kv, "GOARCH="); ok {
+			arch = v
+		}
+	}
+	if arch == "" {
+		if arch, err = goarch(cfg.Dir); err != nil {
+			return nil, err
+		}
+	}
+	sizes := types.SizesFor(padding.CompilerGccgo, arch)
+	if sizes == nil {
+		return nil, fmt.Errorf("gccgo does not support GOARCH=%s", arch)
+	}
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		if pkg.TypesSizes != nil {
+			pkg.TypesSizes = sizes
+		}
+	})
+	return pkgs, nil
+}
+
+// command returns the go command running the go subcommand sub with args
+// in dir, with the build flags of s and, unlike package loads, its
+// compiler, so that -verify checks layouts against gccgo itself.
+func (s goSettings) command(dir, sub string, args ...string) *exec.Cmd {
+	flags := s.buildFlags()
+	if s.gccgo() {
+		flags = append(flags, "-compiler="+s.compiler)
+	}
+	cmd := exec.Command("go", append(append([]string{sub}, flags...), args...)...)
+	cmd.Dir = dir
+	cmd.Env = s.env()
+	if s.debug != nil {
+		command := strings.Join(cmd.Args, " ")
+		s.trace(fmt.Sprintf("debug: running %s in %s, GOFLAGS=%q\n", command, dir, goflags(nil)),
+			"running go command", "file", dir, "command", command, "goflags", goflags(nil))
+	}
+	return cmd
+}
+
+// trace writes the -debug line text to s.debug, or logs it at the debug
+// level as msg with attrs with -log-format=json.
+func (s goSettings) trace(text, msg string, attrs ...any) {
+	diagnose(func(p []byte) { s.debug.Write(p) }, text, slog.LevelDebug, msg, attrs...)
+}
+
+// goflags returns the value of GOFLAGS in env, or in the environment of the
+// process if env is nil.
+func goflags(env []string) string {
+	if env == nil {
+		return os.Getenv("GOFLAGS")
+	}
+	value := ""
+	for _, kv := range env {
+		if v, ok := strings.CutPrefix(kv, "GOFLAGS="); ok {
+			value = v
+		}
+	}
+	return value
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestGoSettingsConfig(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		s       goSettings
+		want    []string
+		wantErr bool
+	}{
+		{name: "none"},
+		{name: "mod", s: goSettings{mod: "vendor"}, want: []string{"-mod=vendor"}},
+		{name: "modfile", s: goSettings{modfile: "ci.mod"}, want: []string{"-modfile=" + filepath.Join(cwd, "ci.mod")}},
+		{
+			name: "both",
+			s:    goSettings{mod: "readonly", modfile: "/ci/go.mod"},
+			want: []string{"-mod=readonly", "-modfile=/ci/go.mod"},
+		},
+		{name: "invalid mod", s: goSettings{mod: "vendored"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := tt.s.resolve(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: resolve = %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		cfg := tt.s.config("pkg", 0)
+		if cfg.Dir != "pkg" || !reflect.DeepEqual(cfg.BuildFlags, tt.want) {
+			t.Errorf("%s: config in %q with build flags %q, want pkg and %q", tt.name, cfg.Dir, cfg.BuildFlags, tt.want)
+		}
+		if cfg.Logf != nil {
+			t.Errorf("%s: config logs without -debug", tt.name)
+		}
+	}
+
+	var debug bytes.Buffer
+	s := goSettings{mod: "vendor", debug: &debug}
+	cfg := s.config("", 0)
+	cfg.Logf("starting %s", "go list")
+	cmd := s.command("pkg", "test", "-run", "X", ".")
+	if want := []string{"go", "test", "-mod=vendor", "-run", "X", "."}; !reflect.DeepEqual(cmd.Args, want) || cmd.Dir != "pkg" {
+		t.Errorf("command = %q in %q, want %q in pkg", cmd.Args, cmd.Dir, want)
+	}
+	if got := debug.String(); !strings.HasPrefix(got, "debug: starting go list\ndebug: running go test -mod=vendor -run X . in pkg, GOFLAGS=") {
+		t.Errorf("debug output:\n%s", got)
+	}
+}
+
+func TestGoSettingsVendoredLoad(t *testing.T) {
+	// The module needs its dependency only in the go.mod given with
+	// -modfile, and has it only in its vendor directory.
+	dir := t.TempDir()
+	for name, src := range map[string]string{
+		"go.mod":                        "module example.com/app\n\ngo 1.26\n",
+		"ci.mod":                        "module example.com/app\n\ngo 1.26\n\nrequire example.com/dep v1.0.0\n",
+		"vendor/modules.txt":            "# example.com/dep v1.0.0\n## explicit\nexample.com/dep\n",
+		"vendor/example.com/dep/dep.go": "package dep\n\ntype Header struct {\n\tA bool\n\tB int64\n\tC bool\n}\n",
+		"app.go":                        "package app\n\nimport \"example.com/dep\"\n\ntype Frame struct {\n\tok bool\n\th  dep.Header\n}\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("GOFLAGS", "")
+	t.Setenv("GOPROXY", "off")
+	saved := goBuild
+	t.Cleanup(func() { goBuild = saved })
+
+	goBuild = goSettings{}
+	if _, err := findExternalWaste(dir, false); err == nil {
+		t.Error("loading without -modfile found the vendored dependency")
+	}
+
+	goBuild = goSettings{mod: "vendor", modfile: filepath.Join(dir, "ci.mod")}
+	wastes, err := findExternalWaste(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := structKey{realDir(filepath.Join(dir, "app.go")), "Frame"}
+	if got := wastes[key]["h"]; got != 8 {
+		t.Errorf("waste inside Frame.h = %d, want 8 (%v)", got, wastes)
+	}
+}
+
+func TestGoSettingsCompiler(t *testing.T) {
+	if err := (&goSettings{compiler: "tinygo"}).resolve(); err == nil {
+		t.Error("resolve accepted -compiler=tinygo")
+	}
+	cmd := goSettings{compiler: "gccgo"}.command("pkg", "test", ".")
+	if want := []string{"go", "test", "-compiler=gccgo", "."}; !reflect.DeepEqual(cmd.Args, want) {
+		t.Errorf("command = %q, want %q", cmd.Args, want)
+	}
+
+	// On arm, gc aligns 64-bit values to 4 bytes and gccgo to 8, so
+	// time.Time, a uint64, an int64 and a pointer, grows from 20 to 24.
+	saved := goBuild
+	defer func() { goBuild = saved }()
+	for _, tt := range []struct {
+		compiler string
+		size     int64
+		offsets  []int64
+	}{
+		{"gc", 20, []int64{0, 8, 16}},
+		{"gccgo", 24, []int64{0, 8, 16}},
+	} {
+		goBuild = goSettings{compiler: tt.compiler}
+		s, _, err := describeType("time.Time", "arm")
+		if err != nil {
+			t.Fatalf("%s: %v", tt.compiler, err)
+		}
+		var offsets []int64
+		for _, f := range s.Fields {
+			offsets = append(offsets, f.Offset)
+		}
+		if s.Size != tt.size || !reflect.DeepEqual(offsets, tt.offsets) {
+			t.Errorf("%s: time.Time has size %d and offsets %v, want %d and %v", tt.compiler, s.Size, offsets, tt.size, tt.offsets)
+		}
+	}
+	if got := goBuild.target("arm"); got != "GOARCH=arm and the gccgo compiler" {
+		t.Errorf("target = %q", got)
+	}
+}
+
+func TestGoSettingsArch(t *testing.T) {
+	if err := (&goSettings{arch: "pdp11"}).resolve(); err == nil {
+		t.Error("resolve accepted -arch=pdp11")
+	}
+	s := goSettings{arch: "386"}
+	if err := s.resolve(); err != nil {
+		t.Fatal(err)
+	}
+	if env := s.config("pkg", 0).Env; len(env) == 0 || env[len(env)-1] != "GOARCH=386" {
+		t.Errorf("config does not load for GOARCH=386")
+	}
+	if env := s.command("pkg", "test", ".").Env; len(env) == 0 || env[len(env)-1] != "GOARCH=386" {
+		t.Errorf("command does not run for GOARCH=386")
+	}
+
+	// The fixture's Loose, a bool, an int64 and a bool, is 24 bytes on
+	// amd64 and 16 on 386, where the int64 is 4-aligned.
+	saved := goBuild
+	defer func() { goBuild = saved }()
+	for _, tt := range []struct {
+		arch string
+		size int64
+	}{
+		{"amd64", 24},
+		{"386", 16},
+	} {
+		goBuild = goSettings{arch: tt.arch}
+		f, err := loadFile(filepath.Join("testdata", "json", "fixture.go"), padding.NewCache())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := f.Structs[0]; s.Name != "Loose" || s.Size != tt.size {
+			t.Errorf("%s: %s has size %d, want Loose of %d", tt.arch, s.Name, s.Size, tt.size)
+		}
+	}
+}
+
+func TestGoSettingsReset(t *testing.T) {
+	saved := goBuild
+	t.Cleanup(func() { goBuild = saved })
+	path := writeFile(t, "package p\n\ntype T struct {\n\tn int64\n}\n")
+
+	runCaptured(t, "-debug", "-tags", "x", path)
+	if goBuild.debug == nil || len(goBuild.tags) == 0 {
+		t.Fatalf("-debug -tags x left %+v", goBuild)
+	}
+	runCaptured(t, path)
+	if goBuild.debug != nil || goBuild.tags != nil {
+		t.Errorf("a run without -debug and -tags kept them: %+v", goBuild)
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/google/pprof/profile"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// heapProfile holds the live objects of a pprof heap profile, attributed to
+// the struct types they are presumed to be.
+type heapProfile struct {
+	// live maps a package and type name, as in main.Event, to the sizes
+	// and numbers of the live objects allocated as that type.
+	live map[string][]heapObjects
+}
+
+// heapObjects is a number of live objects of the same allocation size. A
+// size of 0 means the profile does not record it.
+type heapObjects struct {
+	size, count int64
+}
+
+// loadHeapProfile reads the heap profile at path. The type of each sample is
+// taken from its "type" label, such as main.Event, if it has one. Otherwise,
+// since Go heap profiles don't record types, it is guessed from the
+// allocating function: T for methods of T and for constructors named NewT or
+// newT.
+func loadHeapProfile(path string) (*heapProfile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	p, err := profile.Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	index := -1
+	for i, st := range p.SampleType {
+		if st.Type == "inuse_objects" {
+			index = i
+		}
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("%s: not a heap profile (no inuse_objects samples)", path)
+	}
+
+	h := &heapProfile{live: make(map[string][]heapObjects)}
+	for _, s := range p.Sample {
+		count := s.Value[index]
+		if count == 0 {
+			continue
+		}
+		typ := ""
+		if labels := s.Label["type"]; len(labels) > 0 {
+			if pkg, name, ok := strings.Cut(labels[0], "."); ok && name != "" {
+				typ = heapKey(pkg, name)
+			}
+		} else {
+			typ = allocatedType(s)
+		}
+		if typ == "" {
+			continue
+		}
+		var size int64
+		if sizes := s.NumLabel["bytes"]; len(sizes) > 0 {
+			size = sizes[0]
+		}
+		h.live[typ] = append(h.live[typ], heapObjects{size, count})
+	}
+	return h, nil
+}
+
+// allocatedType guesses the type allocated by the sample s from the name of
+// the innermost function outside the runtime, returning it qualified by the
+// package name, or the empty string.
+func allocatedType(s *profile.Sample) string {
+	for _, loc := range s.Location {
+		for _, line := range loc.Line {
+			if line.Function == nil {
+				continue
+			}
+			name := line.Function.Name
+			if strings.HasPrefix(name, "runtime.") {
+				continue
+			}
+			return typeOfFunc(name)
+		}
+	}
+	return ""
+}
+
+// typeOfFunc returns the type a function with the given fully qualified
+// name presumably allocates: pkg.T for pkg.(*T).m, pkg.T.m, pkg.NewT and
+// pkg.newT, or the empty string.
+func typeOfFunc(name string) string {
+	name = name[strings.LastIndexByte(name, '/')+1:]
+	pkg, fn, ok := strings.Cut(name, ".")
+	if !ok {
+		return ""
+	}
+	fn = strings.ReplaceAll(fn, "[...]", "") // instantiations of generic code
+	var typ string
+	if recv, ok := strings.CutPrefix(fn, "(*"); ok {
+		typ, _, _ = strings.Cut(recv, ")")
+	} else if recv, _, ok := strings.Cut(fn, "."); ok {
+		typ = recv
+	} else if t, ok := strings.CutPrefix(fn, "New"); ok {
+		typ = t
+	} else if t, ok := strings.CutPrefix(fn, "new"); ok {
+		typ = t
+	}
+	if typ == "" {
+		return ""
+	}
+	return heapKey(pkg, typ)
+}
+
+// heapKey returns the key of the type pkg.name in heapProfile.live. The case
+// of the first letter of the name is ignored, so that newEvent is taken to
+// allocate an event as well as an Event.
+func heapKey(pkg, name string) string {
+	return pkg + "." + strings.ToLower(name[:1]) + name[1:]
+}
+
+// weigh sets the live objects and the live bytes wasted of r from the
+// profile. Objects recorded with an allocation size count only if it is
+// that of a single r, allowing for rounding up to a size class.
+func (h *heapProfile) weigh(r *padding.StructReport) {
+	var live int64
+	for _, objs := range h.live[heapKey(r.Package, r.Name)] {
+		if objs.size == 0 || objs.size >= r.Size && objs.size < 2*r.Size {
+			live += objs.count
+		}
+	}
+	r.LiveObjects = live
+	r.LiveWastedBytes = live * r.WastedBytes
+}
+
+// writeHeapRanking writes the structs of r that waste space to w, ranked by
+// the bytes their live instances waste. Structs not found in the heap
+// profile follow, ranked by the bytes a single instance wastes, and are
+// marked with an asterisk. If top is positive, only the first top structs
+// are written.
+func writeHeapRanking(w io.Writer, r padding.Report, top int) error {
+	var structs []padding.StructReport
+	for _, s := range r.Structs {
+		if s.WastedBytes > 0 {
+			structs = append(structs, s)
+		}
+	}
+	slices.SortStableFunc(structs, func(a, b padding.StructReport) int {
+		if (a.LiveObjects > 0) != (b.LiveObjects > 0) {
+			if a.LiveObjects > 0 {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Or(
+			cmp.Compare(b.LiveWastedBytes, a.LiveWastedBytes),
+			cmp.Compare(b.WastedBytes, a.WastedBytes),
+		)
+	})
+	if top > 0 && len(structs) > top {
+		structs = structs[:top]
+	}
+
+	fmt.Fprintf(w, "Estimated live bytes wasted (heap profile):\n")
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "LIVE WASTED\tLIVE OBJECTS\tWASTED/OBJECT\tSTRUCT\n")
+	unmatched := false
+	for _, s := range structs {
+		name := fmt.Sprintf("%s.%s (%s)", s.Package, s.Name, s.File)
+		if s.LiveObjects == 0 {
+			unmatched = true
+			fmt.Fprintf(tw, "%d*\t-\t%d\t%s\n", s.WastedBytes, s.WastedBytes, name)
+			continue
+		}
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%s\n", s.LiveWastedBytes, s.LiveObjects, s.WastedBytes, name)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if unmatched {
+		fmt.Fprintf(w, "* not found in the heap profile; waste of a single instance\n")
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestHeapProfile(t *testing.T) {
+	heap, err := loadHeapProfile(filepath.Join("testdata", "heap", "heap.pb.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := options{collect: new(reportCollector), heap: heap}
+	captureReport(t, func() error { return processPath(filepath.Join("testdata", "heap"), opts, newFileRegistry()) })
+
+	want := map[string][2]int64{ // live objects, live wasted bytes
+		"Event":  {1000, 8000},
+		"Entry":  {9, 72},
+		"Config": {0, 0}, // never allocated
+		"Packed": {0, 0}, // allocated by main, not by a constructor
+	}
+	report := opts.collect.report()
+	if len(report.Structs) != len(want) {
+		t.Fatalf("got %d structs, want %d", len(report.Structs), len(want))
+	}
+	for _, s := range report.Structs {
+		if got := [2]int64{s.LiveObjects, s.LiveWastedBytes}; got != want[s.Name] {
+			t.Errorf("%s: live objects and wasted bytes = %v, want %v", s.Name, got, want[s.Name])
+		}
+	}
+}
+
+func TestHeapProfileNotHeap(t *testing.T) {
+	_, err := loadHeapProfile(filepath.Join("testdata", "heap", "main.go"))
+	if err == nil {
+		t.Error("loading a Go source file as a heap profile succeeded")
+	}
+}
+
+func TestTypeOfFunc(t *testing.T) {
+	tests := map[string]string{
+		"main.newEvent":                        "main.event",
+		"github.com/a/b/cache.NewEntry":        "cache.entry",
+		"github.com/a/b/cache.(*Entry).grow":   "cache.entry",
+		"github.com/a/b/cache.Entry.Clone":     "cache.entry",
+		"github.com/a/b/cache.New[...]":        "",
+		"github.com/a/b/cache.newSet[...]":     "cache.set",
+		"github.com/a/b/cache.(*Set[...]).Add": "cache.set",
+		"main.main":                            "",
+	}
+	for name, want := range tests {
+		if got := typeOfFunc(name); got != want {
+			t.Errorf("typeOfFunc(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestHeapRanking(t *testing.T) {
+	r := padding.NewReport(
+		padding.StructReport{Package: "p", Name: "Single", File: "a.go", WastedBytes: 16},
+		padding.StructReport{Package: "p", Name: "Optimal", File: "a.go", LiveObjects: 50},
+		padding.StructReport{Package: "p", Name: "Few", File: "a.go", WastedBytes: 8, LiveObjects: 2, LiveWastedBytes: 16},
+		padding.StructReport{Package: "p", Name: "Many", File: "b.go", WastedBytes: 4, LiveObjects: 1000, LiveWastedBytes: 4000},
+		padding.StructReport{Package: "p", Name: "Small", File: "b.go", WastedBytes: 2},
+	)
+
+	var b strings.Builder
+	if err := writeHeapRanking(&b, r, 3); err != nil {
+		t.Fatal(err)
+	}
+	want := `Estimated live bytes wasted (heap profile):
+LIVE WASTED  LIVE OBJECTS  WASTED/OBJECT  STRUCT
+4000         1000          4              p.Many (b.go)
+16           2             8              p.Few (a.go)
+16*          -             16             p.Single (a.go)
+* not found in the heap profile; waste of a single instance
+
+`
+	if b.String() != want {
+		t.Errorf("ranking:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// jsonEncoder writes reports as the document of -format=json: the report,
+// indented, with the files declaring its structs and its summary filled in,
+// the summary ranking the first top structs wasting the most bytes, or
+// defaultOffendersTop.
+type jsonEncoder struct {
+	w   io.Writer
+	top int
+}
+
+// encode writes r to the encoder. The number of files scanned is kept from
+// the summary of r, if it has one.
+func (e jsonEncoder) encode(r padding.Report) error {
+	files, summary := padding.Summarize(r.Structs)
+	summary.TopOffenders = padding.TopOffenders(r.Structs, offendersTop(e.top))
+	if r.Summary != nil {
+		summary.ScannedFiles = r.Summary.ScannedFiles
+	}
+	r.Files, r.Summary = files, &summary
+	enc := json.NewEncoder(e.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestJSONEncoder(t *testing.T) {
+	dir := filepath.Join("testdata", "json")
+	opts := options{format: "json", collect: new(reportCollector)}
+	if out := captureReport(t, func() error { return processPath(dir, opts, newFileRegistry()) }); out != "" {
+		t.Errorf("-format=json writes text:\n%s", out)
+	}
+	// The compiler is known once a package was loaded, perhaps by
+	// another test.
+	r := opts.collect.report()
+	r.Compiler, r.Arch = "gc", "amd64"
+	var got bytes.Buffer
+	if err := (jsonEncoder{w: &got}).encode(r); err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile(filepath.Join(dir, "report.golden.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Errorf("got:\n%s\nwant:\n%s", got.Bytes(), want)
+	}
+
+	// The document reads back as a report, with its summary.
+	r = padding.Report{}
+	if err := json.Unmarshal(got.Bytes(), &r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Summary == nil || r.Summary.Structs != 3 || r.Summary.Files != 2 || r.Summary.WastedBytes != 12 || r.Summary.WastefulStructs != 2 {
+		t.Fatalf("summary %+v, want 3 structs in 2 files, 2 wasting 12 bytes", r.Summary)
+	}
+	offenders := []padding.OffenderReport{
+		{Position: "testdata/json/fixture.go:4", Name: "Loose", Size: 24, OptimalSize: 16, WastedBytes: 8},
+		{Position: "testdata/json/other.go:4", Name: "Pair", Size: 12, OptimalSize: 8, WastedBytes: 4},
+	}
+	if !slices.Equal(r.Summary.TopOffenders, offenders) {
+		t.Errorf("top offenders %+v, want %+v", r.Summary.TopOffenders, offenders)
+	}
+}
+
+func TestJSONEncoderEmpty(t *testing.T) {
+	var got bytes.Buffer
+	if err := (jsonEncoder{w: &got}).encode(padding.NewReport()); err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "schema_version": "` + padding.SchemaVersion + `",
+  "structs": [],
+  "summary": {
+    "structs": 0,
+    "files": 0,
+    "wasted_bytes": 0,
+    "wasteful_structs": 0
+  }
+}
+`
+	if got.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got.String(), want)
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// runLayoutDiff implements the layout-diff subcommand, which compares the
+// layouts of the structs of a file at a git revision with those of its
+// working copy. It exits with status 1 if a struct grew.
+func runLayoutDiff(args []string) int {
+	fs := flag.NewFlagSet("layout-diff", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: padding-size layout-diff <ref> <file.go>")
+		fs.PrintDefaults()
+	}
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 2 {
+		fs.Usage()
+		return 2
+	}
+	ref, path := positional[0], positional[1]
+	old, err := gitShow(ref, path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	before, err := fileStructs(path, old)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s at %s: %v\n", path, ref, err)
+		return 2
+	}
+	src, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	after, err := fileStructs(path, src)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	diffs := diffLayouts(before, after)
+	if err := writeLayoutDiff(os.Stdout, diffs); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	for _, d := range diffs {
+		if d.Before != nil && d.After != nil && d.After.Size > d.Before.Size {
+			return 1
+		}
+	}
+	return 0
+}
+
+// gitShow returns the contents of the file at path as of the git revision
+// ref, read with git show from the repository holding path.
+func gitShow(ref, path string) ([]byte, error) {
+	cmd := exec.Command("git", "show", ref+":./"+filepath.Base(path))
+	cmd.Dir = filepath.Dir(path)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git show %s:%s: %s", ref, path, msg)
+		}
+		return nil, fmt.Errorf("git show %s:%s: %v", ref, path, err)
+	}
+	return out, nil
+}
+
+// fileStructs analyzes src, the contents of the file at path, sizing the
+// fields holding other structs of the file by value with their layouts.
+func fileStructs(path string, src []byte) ([]padding.StructInfo, error) {
+	fset := token.NewFileSet()
+	node, err := parser.ParseFile(fset, path, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	structs, err := padding.Analyze(fset, node, padding.Options{})
+	if err != nil {
+		return nil, err
+	}
+	resolveSizes([]*FileResult{{Path: path, Fset: fset, Node: node, Structs: structs}})
+	return structs, nil
+}
+
+// layoutDiff is the change of the layout of a struct. Before or After is nil
+// for a struct that was added or removed.
+type layoutDiff struct {
+	Name          string
+	Before, After *padding.StructInfo
+	Fields        []fieldDiff
+}
+
+// fieldDiff is the change of a field, matched by name. Before or After is
+// nil for a field that was added or removed.
+type fieldDiff struct {
+	Before, After *padding.FieldInfo
+}
+
+// kind returns how the field changed: "added", "removed", "grew", "shrank",
+// "moved" or "retyped", or "" if it is unchanged.
+func (d fieldDiff) kind() string {
+	switch {
+	case d.Before == nil:
+		return "added"
+	case d.After == nil:
+		return "removed"
+	case d.After.Size > d.Before.Size:
+		return "grew"
+	case d.After.Size < d.Before.Size:
+		return "shrank"
+	case d.After.Offset != d.Before.Offset:
+		return "moved"
+	case d.After.Type != d.Before.Type:
+		return "retyped"
+	}
+	return ""
+}
+
+// diffLayouts returns the structs whose layouts differ between before and
+// after, matched by name, in the order of after followed by those removed.
+func diffLayouts(before, after []padding.StructInfo) []layoutDiff {
+	old := make(map[string]*padding.StructInfo)
+	for i := range before {
+		if _, ok := old[before[i].Name]; !ok {
+			old[before[i].Name] = &before[i]
+		}
+	}
+	var diffs []layoutDiff
+	seen := make(map[string]bool)
+	for i := range after {
+		s := &after[i]
+		if seen[s.Name] {
+			continue
+		}
+		seen[s.Name] = true
+		b := old[s.Name]
+		if b == nil {
+			diffs = append(diffs, layoutDiff{Name: s.Name, After: s})
+			continue
+		}
+		d := layoutDiff{Name: s.Name, Before: b, After: s, Fields: diffFields(b.Fields, s.Fields)}
+		if len(d.Fields) > 0 || b.Size != s.Size || b.Align != s.Align {
+			diffs = append(diffs, d)
+		}
+	}
+	for i := range before {
+		if s := &before[i]; !seen[s.Name] {
+			seen[s.Name] = true
+			diffs = append(diffs, layoutDiff{Name: s.Name, Before: s})
+		}
+	}
+	return diffs
+}
+
+// diffFields returns the fields that changed between before and after, in
+// the order of after followed by those removed. Blank fields are matched by
+// their position among the blank fields.
+func diffFields(before, after []padding.FieldInfo) []fieldDiff {
+	key := func(fields []padding.FieldInfo) []string {
+		keys := make([]string, len(fields))
+		blank := 0
+		for i, f := range fields {
+			keys[i] = f.Name
+			if f.Name == "_" {
+				blank++
+				keys[i] = fmt.Sprintf("_#%d", blank)
+			}
+		}
+		return keys
+	}
+	old := make(map[string]*padding.FieldInfo)
+	for i, k := range key(before) {
+		old[k] = &before[i]
+	}
+	var diffs []fieldDiff
+	for i, k := range key(after) {
+		d := fieldDiff{old[k], &after[i]}
+		delete(old, k)
+		if d.kind() != "" {
+			diffs = append(diffs, d)
+		}
+	}
+	for i, k := range key(before) {
+		if _, ok := old[k]; ok {
+			diffs = append(diffs, fieldDiff{Before: &before[i]})
+		}
+	}
+	return diffs
+}
+
+// writeLayoutDiff writes diffs to w: for each struct its size, before and
+// after, and the fields that were added, removed, resized or moved.
+func writeLayoutDiff(w io.Writer, diffs []layoutDiff) error {
+	var b strings.Builder
+	if len(diffs) == 0 {
+		b.WriteString("No layout changes.\n")
+	}
+	for _, d := range diffs {
+		switch {
+		case d.Before == nil:
+			fmt.Fprintf(&b, "Struct: %s (added, size: %d bytes)\n", d.Name, d.After.Size)
+		case d.After == nil:
+			fmt.Fprintf(&b, "Struct: %s (removed, size: %d bytes)\n", d.Name, d.Before.Size)
+		default:
+			fmt.Fprintf(&b, "Struct: %s (size: %s bytes, wasted: %s bytes)\n", d.Name,
+				change(d.Before.Size, d.After.Size),
+				change(d.Before.Size-padding.Optimal(*d.Before).Size, d.After.Size-padding.Optimal(*d.After).Size))
+		}
+		for _, f := range d.Fields {
+			switch kind := f.kind(); kind {
+			case "added":
+				fmt.Fprintf(&b, "  %-7s %s %s (offset: %d, size: %d)\n", kind, f.After.Name, f.After.Type, f.After.Offset, f.After.Size)
+			case "removed":
+				fmt.Fprintf(&b, "  %-7s %s %s (offset: %d, size: %d)\n", kind, f.Before.Name, f.Before.Type, f.Before.Offset, f.Before.Size)
+			default:
+				typ := f.After.Type
+				if f.Before.Type != f.After.Type {
+					typ = f.Before.Type + " → " + f.After.Type
+				}
+				fmt.Fprintf(&b, "  %-7s %s %s (offset: %s, size: %s)\n", kind, f.After.Name, typ,
+					change(f.Before.Offset, f.After.Offset), change(f.Before.Size, f.After.Size))
+			}
+		}
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
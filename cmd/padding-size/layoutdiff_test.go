@@ -0,0 +1,148 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// gitRepo returns a new git repository with the file types.go committed with
+// src, and the path of that file.
+func gitRepo(t *testing.T, src string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "types.go")
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "types.go"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "types"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+	return path
+}
+
+func TestLayoutDiff(t *testing.T) {
+	path := gitRepo(t, `package types
+
+type Config struct {
+	name string
+	port int32
+	on   bool
+}
+
+type Counter struct {
+	n  int32
+	ok bool
+}
+
+type Same struct {
+	a int64
+	b int32
+}
+
+type Legacy struct {
+	id int64
+}
+`)
+	src := `package types
+
+type Config struct {
+	name  string
+	debug bool
+	port  int32
+	on    bool
+}
+
+type Counter struct {
+	n  int64
+	ok bool
+}
+
+type Same struct {
+	a int64
+	b int32
+}
+
+type Fresh struct {
+	id int64
+}
+`
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	old, err := gitShow("HEAD", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, err := fileStructs(path, old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := fileStructs(path, []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := writeLayoutDiff(&b, diffLayouts(before, after)); err != nil {
+		t.Fatal(err)
+	}
+	want := `Struct: Config (size: 24 → 32 (+8) bytes, wasted: 0 → 8 (+8) bytes)
+  added   debug bool (offset: 16, size: 1)
+  moved   port int32 (offset: 16 → 20 (+4), size: 4)
+  moved   on bool (offset: 20 → 24 (+4), size: 1)
+
+Struct: Counter (size: 8 → 16 (+8) bytes, wasted: 0 bytes)
+  grew    n int32 → int64 (offset: 0, size: 4 → 8 (+4))
+  moved   ok bool (offset: 4 → 8 (+4), size: 1)
+
+Struct: Fresh (added, size: 8 bytes)
+
+Struct: Legacy (removed, size: 8 bytes)
+
+`
+	if got := b.String(); got != want {
+		t.Errorf("layout diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestLayoutDiffUnchanged(t *testing.T) {
+	path := gitRepo(t, "package types\n\ntype T struct {\n\ta int64\n\tb bool\n}\n")
+	old, err := gitShow("HEAD", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, err := fileStructs(path, old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := writeLayoutDiff(&b, diffLayouts(before, before)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := b.String(), "No layout changes.\n"; got != want {
+		t.Errorf("layout diff = %q, want %q", got, want)
+	}
+}
+
+func TestGitShowMissingFile(t *testing.T) {
+	path := gitRepo(t, "package types\n")
+	_, err := gitShow("HEAD", filepath.Join(filepath.Dir(path), "new.go"))
+	if err == nil || !strings.Contains(err.Error(), "git show HEAD:") {
+		t.Errorf("gitShow of a file missing at HEAD = %v, want a git show error", err)
+	}
+}
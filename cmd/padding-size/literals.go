@@ -0,0 +1,279 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/types"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// unkeyedLiterals holds, for package-level struct types, the composite
+// literals giving their fields without naming them, such as T{1, "a"},
+// which reordering the fields breaks: at compile time, or silently when the
+// fields swapped have the same type.
+type unkeyedLiterals map[structKey]*unkeyedType
+
+// unkeyedType is a struct type built with unkeyed composite literals.
+type unkeyedType struct {
+	literals []unkeyedLiteral
+	// moves tells whether the type checker's optimal order moves fields.
+	moves bool
+}
+
+// unkeyedLiteral is a composite literal giving the fields of a struct
+// without naming them.
+type unkeyedLiteral struct {
+	path string // absolute path of its file
+	pos  string // its file and line, as displayed
+	// offsets are those of its elements in the file, nil for a literal
+	// found without the type checker, and keys the names of the fields
+	// they set, nil if some can't be named, as blank ones.
+	offsets []int
+	keys    []string
+	// inScope tells whether its file is among those being fixed.
+	inScope bool
+}
+
+// keyable reports whether the literal can be rewritten in keyed form.
+func (l unkeyedLiteral) keyable() bool {
+	return l.inScope && l.keys != nil
+}
+
+// findUnkeyedLiterals type-checks the packages of the main module holding
+// path, the file or the directory being fixed, with their tests, and finds
+// the unkeyed composite literals of their struct types, nested ones
+// included, telling those in the files being fixed from the others. Out of
+// a module, only the packages of path are searched.
+func findUnkeyedLiterals(path string, isDir bool) (unkeyedLiterals, error) {
+	dir := path
+	if !isDir {
+		dir = filepath.Dir(path)
+	}
+	mode := packages.NeedTypes | packages.NeedTypesSizes | packages.NeedSyntax | packages.NeedTypesInfo | packages.NeedModule
+	cfg := goBuild.config(dir, mode|packages.NeedName|packages.NeedFiles)
+	cfg.Tests = true
+	pkgs, loadErr := loadSound(cfg, isDir)
+	if len(pkgs) > 0 && pkgs[0].Module != nil && pkgs[0].Module.Dir != "" {
+		cfg.Dir = pkgs[0].Module.Dir
+		var err error
+		if pkgs, err = loadSound(cfg, true); err != nil {
+			loadErr = err
+		}
+	}
+
+	root := realDir(filepath.Join(dir, "doc.go")) // any file in dir
+	file, _ := resolvePath(path)
+	inScope := func(name string) bool {
+		if !isDir {
+			real, _ := resolvePath(name)
+			return real == file
+		}
+		rel, err := filepath.Rel(root, realDir(name))
+		return err == nil && filepath.IsLocal(rel)
+	}
+
+	cwd, _ := os.Getwd()
+	literals := make(unkeyedLiterals)
+	seen := make(map[string]bool)
+	for _, pkg := range pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, f := range pkg.Syntax {
+			ast.Inspect(f, func(n ast.Node) bool {
+				lit, ok := n.(*ast.CompositeLit)
+				if !ok || len(lit.Elts) == 0 {
+					return true
+				}
+				if _, keyed := lit.Elts[0].(*ast.KeyValueExpr); keyed {
+					return true
+				}
+				named, st := literalStruct(pkg.TypesInfo.TypeOf(lit))
+				if named == nil || st.NumFields() != len(lit.Elts) {
+					return true
+				}
+				pos := pkg.Fset.Position(lit.Lbrace)
+				// The test variants of a package parse its files again.
+				id := fmt.Sprintf("%s:%d", pos.Filename, pos.Offset)
+				if seen[id] {
+					return true
+				}
+				seen[id] = true
+				l := unkeyedLiteral{path: pos.Filename, inScope: inScope(pos.Filename)}
+				display := pos.Filename
+				if rel, err := filepath.Rel(cwd, display); err == nil && filepath.IsLocal(rel) {
+					display = rel
+				}
+				l.pos = fmt.Sprintf("%s:%d", filepath.ToSlash(display), pos.Line)
+				for i, elt := range lit.Elts {
+					l.offsets = append(l.offsets, pkg.Fset.Position(elt.Pos()).Offset)
+					if name := st.Field(i).Name(); name != "_" {
+						l.keys = append(l.keys, name)
+					}
+				}
+				if len(l.keys) != len(l.offsets) {
+					l.keys = nil
+				}
+
+				obj := named.Obj()
+				key := structKey{realDir(pkg.Fset.Position(obj.Pos()).Filename), obj.Name()}
+				t := literals[key]
+				if t == nil {
+					t = &unkeyedType{moves: !slices.IsSorted(padding.OptimalOrder(st, pkg.TypesSizes))}
+					literals[key] = t
+				}
+				t.literals = append(t.literals, l)
+				return true
+			})
+		}
+	}
+	return literals, loadErr
+}
+
+// literalStruct returns the package-level named struct type, and its
+// struct, that a composite literal of type t builds, through a pointer for
+// the elided types of &T literals, or nil if there is none.
+func literalStruct(t types.Type) (*types.Named, *types.Struct) {
+	if ptr, ok := types.Unalias(t).(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := types.Unalias(t).(*types.Named)
+	if !ok {
+		return nil, nil
+	}
+	obj := named.Origin().Obj()
+	if obj.Pkg() == nil || obj.Parent() != obj.Pkg().Scope() {
+		return nil, nil
+	}
+	st, ok := named.Underlying().(*types.Struct)
+	if !ok {
+		return nil, nil
+	}
+	return named.Origin(), st
+}
+
+// key rewrites the unkeyed literals of the types fix may reorder in keyed
+// form, provided every literal of the type can be, and removes these types
+// from l, leaving those whose literals still pin them. reorders tells,
+// from the key of a type and whether the type checker's optimal order
+// moves its fields, whether fix may reorder it. key reports each type
+// keyed to write.
+func (l unkeyedLiterals) key(reorders func(key structKey, moves bool) bool, write func([]byte)) error {
+	type insertion struct {
+		offset int
+		key    string
+	}
+	edits := make(map[string][]insertion)
+	var keys []structKey
+	for key, t := range l {
+		if !reorders(key, t.moves) || slices.ContainsFunc(t.literals, func(lit unkeyedLiteral) bool { return !lit.keyable() }) {
+			continue
+		}
+		for _, lit := range t.literals {
+			for i, offset := range lit.offsets {
+				edits[lit.path] = append(edits[lit.path], insertion{offset, lit.keys[i] + ": "})
+			}
+		}
+		keys = append(keys, key)
+	}
+
+	paths := make([]string, 0, len(edits))
+	for path := range edits {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+	for _, path := range paths {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		inserts := edits[path]
+		slices.SortFunc(inserts, func(a, b insertion) int { return b.offset - a.offset })
+		data := slices.Clone(src)
+		for _, ins := range inserts {
+			if ins.offset > len(data) {
+				return fmt.Errorf("%s changed while it was being analyzed; not rewriting it", path)
+			}
+			data = slices.Insert(data, ins.offset, []byte(ins.key)...)
+		}
+		// Keyed elements on lines of their own are aligned by gofmt.
+		if formatted, err := format.Source(data); err == nil {
+			data = formatted
+		}
+		if err := replaceFile(path, src, data); err != nil {
+			return err
+		}
+	}
+
+	slices.SortFunc(keys, func(a, b structKey) int {
+		return strings.Compare(a.dir+"/"+a.name, b.dir+"/"+b.name)
+	})
+	for _, key := range keys {
+		var positions []string
+		for _, lit := range l[key].literals {
+			positions = append(positions, lit.pos)
+		}
+		positions = slices.Compact(positions)
+		diagnose(write, fmt.Sprintf("Keyed the composite literals of %s at %s\n", key.name, strings.Join(positions, ", ")),
+			slog.LevelInfo, "keyed composite literals", "struct", key.name, "positions", positions)
+		delete(l, key)
+	}
+	return nil
+}
+
+// pinned returns the structs of files built with unkeyed composite
+// literals that reordered would change the order of, with why fix leaves
+// them alone: where the literals are, and why -fix-literals can't key those
+// it can't.
+func (l unkeyedLiterals) pinned(files []*FileResult, fixLiterals bool, reordered func(padding.StructInfo) padding.StructInfo) map[*padding.StructInfo]string {
+	pinned := make(map[*padding.StructInfo]string)
+	for _, f := range files {
+		topLevel := topLevelStructs(f.Node)
+		for i := range f.Structs {
+			s := &f.Structs[i]
+			t, ok := l[structKey{realDir(f.Path), s.Name}]
+			if !ok || !topLevel[s.Node] || slices.Equal(fieldNames(*s), fieldNames(reordered(*s))) {
+				continue
+			}
+			var positions []string
+			keyable := true
+			for _, lit := range t.literals {
+				switch {
+				case !lit.inScope:
+					positions = append(positions, lit.pos+" (outside the files being fixed)")
+					keyable = false
+				case lit.offsets == nil:
+					positions = append(positions, lit.pos)
+					keyable = false
+				case lit.keys == nil:
+					positions = append(positions, lit.pos+" (setting blank fields)")
+					keyable = false
+				case !fixLiterals:
+					positions = append(positions, lit.pos)
+				}
+			}
+			if keyable && fixLiterals {
+				// Every literal could be keyed, but the type checker
+				// found no field to move.
+				for _, lit := range t.literals {
+					positions = append(positions, lit.pos)
+				}
+			}
+			reason := "built with unkeyed composite literals at " + strings.Join(slices.Compact(positions), ", ")
+			if keyable && !fixLiterals {
+				reason += "; -fix-literals rewrites them in keyed form"
+			}
+			pinned[s] = reason
+		}
+	}
+	return pinned
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// copyLiterals copies the module of testdata/literals to a new directory
+// and returns it.
+func copyLiterals(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not available")
+	}
+	root := t.TempDir()
+	if err := os.CopyFS(root, os.DirFS(filepath.Join("testdata", "literals"))); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+// testModule builds and tests the module in root.
+func testModule(t *testing.T, root string) {
+	t.Helper()
+	cmd := exec.Command("go", "test", "./...")
+	cmd.Dir = root
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("go test: %v\n%s", err, out)
+	}
+}
+
+func TestFixUnkeyedLiterals(t *testing.T) {
+	root := copyLiterals(t)
+	types := filepath.Join(root, "types")
+	log := new(fixLog)
+	opts := options{fix: true, fixLog: log, typeFailures: new(typeFailures)}
+	out := captureReport(t, func() error { return processPath(types, opts, newFileRegistry()) })
+	if opts.typeFailures.degraded() {
+		t.Fatal(opts.typeFailures.warning())
+	}
+
+	use := filepath.Join(types, "use.go")
+	for _, want := range []string{
+		"not reordering Config: built with unkeyed composite literals at " + use + ":3, " + use + ":7, " +
+			filepath.Join(types, "use_test.go") + ":6; -fix-literals rewrites them in keyed form\n",
+		"not reordering Holder: built with unkeyed composite literals at " + use + ":5;",
+		"not reordering Pair: built with unkeyed composite literals at " + use + ":11;",
+		"not reordering Box: built with unkeyed composite literals at " + filepath.Join(root, "user", "user.go") + ":5 (outside the files being fixed)\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "not reordering Point") {
+		t.Errorf("report warns about Point, which keeps its order:\n%s", out)
+	}
+	for _, r := range log.structs {
+		if r.Struct != "Point" && (r.Status != fixSkipped || r.Cause != causeUnkeyed) {
+			t.Errorf("%s: %s (%s), want skipped for %s", r.Struct, r.Status, r.Cause, causeUnkeyed)
+		}
+	}
+	testModule(t, root)
+}
+
+func TestFixLiterals(t *testing.T) {
+	root := copyLiterals(t)
+	types := filepath.Join(root, "types")
+	opts := options{fix: true, fixLiterals: true, typeFailures: new(typeFailures)}
+	out := captureReport(t, func() error { return processPath(types, opts, newFileRegistry()) })
+	if opts.typeFailures.degraded() {
+		t.Fatal(opts.typeFailures.warning())
+	}
+
+	use := filepath.Join(types, "use.go")
+	for _, want := range []string{
+		"Keyed the composite literals of Config at " + use + ":3, " + use + ":7, " + filepath.Join(types, "use_test.go") + ":6\n",
+		"Keyed the composite literals of Holder at " + use + ":5\n",
+		"Keyed the composite literals of Pair at " + use + ":11\n",
+		"not reordering Box: built with unkeyed composite literals at " + filepath.Join(root, "user", "user.go") + ":5 (outside the files being fixed)\n",
+		"not reordering Flags: built with unkeyed composite literals at " + use + ":15 (setting blank fields)\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report lacks %q:\n%s", want, out)
+		}
+	}
+	for _, want := range []string{
+		"var defaults = Config{OK: true, ID: 1, On: false}\n",
+		"var held = &Holder{\n\tSet:    true,\n\tConfig: Config{OK: false, ID: 2, On: true},\n\tN:      3,\n}\n",
+		"var pairs = []Pair{{a: true, b: 2, c: false}, {a: false, b: 3, c: true}}\n",
+		"var origin = Point{0, 0}\n",
+		"var none = Flags{false, 0, 0, false}\n",
+	} {
+		if src := readFile(t, use); !strings.Contains(src, want) {
+			t.Errorf("use.go lacks %q:\n%s", want, src)
+		}
+	}
+	if src := readFile(t, filepath.Join(types, "use_test.go")); !strings.Contains(src, "(Config{OK: true, ID: 1, On: false})") {
+		t.Errorf("the literal of the test was not keyed:\n%s", src)
+	}
+	src := readFile(t, filepath.Join(types, "types.go"))
+	for _, want := range []string{
+		"type Config struct {\n\tID int64\n",
+		"type Holder struct {\n\tConfig Config\n",
+		"type Box struct {\n\tOpen bool\n",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("types.go lacks %q:\n%s", want, src)
+		}
+	}
+	testModule(t, root)
+
+	// From the module root, the literal of user is among the files fixed.
+	opts.typeFailures = new(typeFailures)
+	out = captureReport(t, func() error { return processPath(root, opts, newFileRegistry()) })
+	if want := "Keyed the composite literals of Box at " + filepath.Join(root, "user", "user.go") + ":5\n"; !strings.Contains(out, want) {
+		t.Errorf("report lacks %q:\n%s", want, out)
+	}
+	if src := readFile(t, filepath.Join(root, "user", "user.go")); !strings.Contains(src, "types.Box{Open: true, Size: 0, Full: false}") {
+		t.Errorf("the literal of Box was not keyed:\n%s", src)
+	}
+	if src := readFile(t, filepath.Join(types, "types.go")); !strings.Contains(src, "type Box struct {\n\tSize int64\n") {
+		t.Errorf("Box was not reordered:\n%s", src)
+	}
+	testModule(t, root)
+}
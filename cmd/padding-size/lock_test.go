@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// lockFixture copies testdata/wire into a module in a temporary directory
+// and returns its path.
+func lockFixture(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not available")
+	}
+	dir := t.TempDir()
+	src, err := os.ReadFile(filepath.Join("testdata", "wire", "wire.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{
+		"go.mod":  []byte("module example.com/wire\n\ngo 1.22\n"),
+		"wire.go": src,
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func goTest(dir string) (string, error) {
+	cmd := exec.Command("go", "test", "-count=1", ".")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+func TestLockEndToEnd(t *testing.T) {
+	dir := lockFixture(t)
+
+	if code := runLock([]string{dir, "-o", "layout_lock_test.go"}); code != 0 {
+		t.Fatalf("lock exited with %d", code)
+	}
+	src, err := os.ReadFile(filepath.Join(dir, "layout_lock_test.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"// Code generated by padding-size lock; DO NOT EDIT.",
+		`"unsafe.Sizeof(Frame{})"`,
+		`"unsafe.Offsetof(Frame{}.Mutex)"`,
+		`"unsafe.Offsetof(Header{}.Length)"`,
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("generated file lacks %s:\n%s", want, src)
+		}
+	}
+	for _, unwanted := range []string{"Generic", "Alias", "Count", "Header{}._"} {
+		if strings.Contains(string(src), unwanted) {
+			t.Errorf("generated file mentions %s:\n%s", unwanted, src)
+		}
+	}
+
+	if out, err := goTest(dir); err != nil {
+		t.Fatalf("generated test fails on the unchanged package: %v\n%s", err, out)
+	}
+
+	// Regenerating yields the same file.
+	again, err := generateLock(dir, lockOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, again) {
+		t.Errorf("regenerated file differs:\n%s\nfirst:\n%s", again, src)
+	}
+
+	// Moving a field must fail the generated test.
+	wire := filepath.Join(dir, "wire.go")
+	orig, err := os.ReadFile(wire)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed := strings.Replace(string(orig), "\tMagic   uint32\n\tFlags   uint16\n", "\tFlags   uint16\n\tMagic   uint32\n", 1)
+	if changed == string(orig) {
+		t.Fatal("fixture does not have the expected field order")
+	}
+	if err := os.WriteFile(wire, []byte(changed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := goTest(dir)
+	if err == nil {
+		t.Fatalf("generated test passes after the layout changed:\n%s", out)
+	}
+	if !strings.Contains(out, "unsafe.Offsetof(Header{}.Magic) = 4, want 0") {
+		t.Errorf("unexpected failure output:\n%s", out)
+	}
+}
+
+func TestLockOptions(t *testing.T) {
+	dir := lockFixture(t)
+
+	src, err := generateLock(dir, lockOptions{Types: []string{"Header"}, Tags: "amd64 || arm64"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(src), "//go:build amd64 || arm64\n") {
+		t.Errorf("generated file lacks the build constraint:\n%s", src)
+	}
+	if strings.Contains(string(src), "Frame") {
+		t.Errorf("generated file locks unselected type Frame:\n%s", src)
+	}
+
+	if _, err := generateLock(dir, lockOptions{Types: []string{"Missing"}}); err == nil {
+		t.Error("locking an unknown type succeeded")
+	}
+	if _, err := generateLock(dir, lockOptions{Tags: "amd64 ||"}); err == nil {
+		t.Error("an invalid build constraint was accepted")
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// logger receives the diagnostics of the run as structured records with
+// -log-format=json: errors, warnings, the reasons structs were skipped and
+// timings. Their keys are level, msg and, where they apply, file, struct
+// and reason. When logger is nil, diagnostics are written as text instead.
+var logger *slog.Logger
+
+// newLogger returns the logger of -log-format=format writing to w, or nil
+// for the text format. Debug records are only kept with -debug.
+func newLogger(format string, w io.Writer, debug bool) (*slog.Logger, error) {
+	switch format {
+	case "text":
+		return nil, nil
+	case "json":
+		level := slog.LevelInfo
+		if debug {
+			level = slog.LevelDebug
+		}
+		return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})), nil
+	}
+	return nil, fmt.Errorf("unknown log format %q", format)
+}
+
+// diagnose reports a diagnostic: to logger, as a record with msg and attrs
+// at level, or else as text, written with write.
+func diagnose(write func([]byte), text string, level slog.Level, msg string, attrs ...any) {
+	if logger != nil {
+		logger.Log(context.Background(), level, msg, attrs...)
+		return
+	}
+	write([]byte(text))
+}
+
+// logError reports an error the run stops at: "Error: msg" on stderr as
+// text.
+func logError(msg string, attrs ...any) {
+	diagnose(writeStderr, "Error: "+msg+"\n", slog.LevelError, msg, attrs...)
+}
+
+func writeStderr(p []byte) {
+	os.Stderr.Write(p)
+}
+
+// logTiming logs how long msg took since start, as duration_ms. Timings are
+// only reported in structured logs.
+func logTiming(start time.Time, msg string, attrs ...any) {
+	if logger != nil {
+		logger.Info(msg, append(attrs, "duration_ms", time.Since(start).Milliseconds())...)
+	}
+}
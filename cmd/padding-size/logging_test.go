@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestJSONLog(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := newLogger("json", &buf, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := logger
+	logger = l
+	defer func() { logger = saved }()
+
+	// Reordering a struct with json tags warns, and a directory outside
+	// any module fails to type-check.
+	path := writeFile(t, "package types\n\ntype Loose struct {\n\tA bool  `json:\"a\"`\n\tB int64 `json:\"b\"`\n\tC bool  `json:\"c\"`\n}\n")
+	opts := options{fix: true, nested: true, fixLog: new(fixLog), typeFailures: new(typeFailures)}
+	report := captureReport(t, func() error { return processPath(filepath.Dir(path), opts, newFileRegistry()) })
+	opts.fixLog.log(logger)
+	opts.typeFailures.log(logger)
+
+	if strings.Contains(report, "marshaled") {
+		t.Errorf("the marshal warning went to the report:\n%s", report)
+	}
+	var records []map[string]any
+	for line := range strings.Lines(buf.String()) {
+		var r map[string]any
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("%v: %s", err, line)
+		}
+		if r["level"] == nil || r["msg"] == nil {
+			t.Errorf("record lacks level or msg: %s", line)
+		}
+		records = append(records, r)
+	}
+	find := func(msg string) map[string]any {
+		t.Helper()
+		i := slices.IndexFunc(records, func(r map[string]any) bool { return r["msg"] == msg })
+		if i < 0 {
+			t.Fatalf("no %q record in:\n%s", msg, buf.String())
+		}
+		return records[i]
+	}
+
+	r := find("reordering changes the marshaled order")
+	if r["level"] != "WARN" || r["file"] != path || r["struct"] != "Loose" || !strings.Contains(r["reason"].(string), "(json tags)") {
+		t.Errorf("marshal warning: %v", r)
+	}
+	r = find("fixed")
+	if r["struct"] != "Loose" || r["saved"] != 8.0 {
+		t.Errorf("fixed record: %v", r)
+	}
+	if r = find("fix summary"); r["fixed"] != 1.0 {
+		t.Errorf("fix summary: %v", r)
+	}
+	r = find("type-checking failed; results are estimated from the source alone")
+	options, _ := r["options"].([]any)
+	if r["level"] != "WARN" || r["file"] != filepath.Dir(path) || r["reason"] == "" || !slices.Contains(options, any("-nested")) {
+		t.Errorf("type-checking failure: %v", r)
+	}
+}
+
+func TestLoggerFormats(t *testing.T) {
+	if l, err := newLogger("text", nil, false); l != nil || err != nil {
+		t.Errorf("text: %v, %v; want no logger", l, err)
+	}
+	if _, err := newLogger("yaml", nil, false); err == nil {
+		t.Error("yaml accepted")
+	}
+}
@@ -0,0 +1,1849 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// FileResult holds the structs collected from a single parsed file
+type FileResult struct {
+	Path    string
+	Src     []byte // contents the file was parsed from
+	Package string
+	Variant string // build constraint the file is restricted to, if any
+	Fset    *token.FileSet
+	Node    *ast.File
+	Structs []padding.StructInfo
+}
+
+// stdout buffers the report, which is written in many small pieces. It is
+// flushed before main returns.
+var (
+	stdout   = bufio.NewWriter(os.Stdout)
+	stdoutMu sync.Mutex
+)
+
+// emit writes a piece of the report to stdout. It may be called concurrently.
+func emit(p []byte) {
+	stdoutMu.Lock()
+	defer stdoutMu.Unlock()
+	stdout.Write(p)
+}
+
+// options are the command-line settings that control how files are
+// processed.
+type options struct {
+	fix              bool // rewrite files with optimized layouts
+	writeAnnotations bool // insert or update //padding-size:ok annotations
+	annotate         bool // comment on structs fix would shrink instead of fixing them
+	verify           bool // cross-check computed layouts against the compiler
+
+	// only, if not nil, limits fix to the structs it names.
+	only structSelection
+
+	// filter limits the structs analyzed at all, with -structs and
+	// -exported-only.
+	filter structFilter
+
+	// exclude lists the -exclude patterns of the paths directory walks
+	// skip. Generated and test files are skipped too, unless
+	// includeGenerated or includeTests, and so are the files not built
+	// for the target of goBuild, unless allPlatforms.
+	exclude                        excludePatterns
+	includeGenerated, includeTests bool
+	allPlatforms                   bool
+
+	// mixedPackages is set while reporting a directory whose files
+	// declare several packages, which the file headers then name.
+	mixedPackages bool
+
+	// jobs is the number of directories a walk processes at once. write,
+	// if set, takes the report of the directory being processed in place
+	// of stdout, so that the reports come out in walk order.
+	jobs  int
+	write func([]byte)
+
+	// skipTypes lists the types whose holders fix leaves alone. held holds
+	// what processPath found of them by type-checking, and holding, set by
+	// processFiles, the structs of the package being reported that hold
+	// one, with the name listed.
+	skipTypes skipTypes
+	held      heldTypes
+	holding   map[*padding.StructInfo]string
+
+	// format is the output format, text if empty. Other formats are
+	// rendered from collect once the run is complete, and the findings
+	// that are not part of them go to stderr.
+	format string
+
+	// collect, if set, gathers the report of every struct.
+	collect *reportCollector
+
+	// heap, if set, weighs the reports with the live objects of a heap
+	// profile.
+	heap *heapProfile
+
+	// allocSites requests counting the allocation sites of the structs of
+	// each path; processPath sets sites to the counts.
+	allocSites bool
+	sites      allocSites
+
+	// counts, if not empty, holds the expected instances of structs.
+	counts *instanceCounts
+
+	// fixLog, if not nil, records what fix changed, for -fix-log and the
+	// summary printed after fixing.
+	fixLog *fixLog
+
+	// typeFailures, if not nil, gathers the packages the type-checked
+	// options could not type-check; the structs of these packages are
+	// marked estimated.
+	typeFailures *typeFailures
+
+	// cacheLine is the size of a cache line. With cacheLineReport, the
+	// lines of fields and structs, and fields crossing line boundaries, are
+	// reported; with cacheLineOrder, fix prefers an order of the optimal
+	// size in which no field crosses one.
+	cacheLine       int64
+	cacheLineReport bool
+	cacheLineOrder  bool
+
+	// stats requests attributing the padding of each struct to the field
+	// types causing it, which main sums across the run.
+	stats bool
+
+	// globals requests listing the package-level variables holding padded
+	// structs, which processPath adds to collect.
+	globals bool
+
+	// verbose requests listing the field types whose sizes were guessed
+	// after the report.
+	verbose bool
+
+	// positions requests starting the header line of each struct of the
+	// text report with its position, and with verbose, each field line too.
+	positions bool
+
+	// dupes requests grouping the structs of the run by layout, and with
+	// dupesByType by field types as well, after the report.
+	dupes, dupesByType bool
+
+	// nearMiss is the number of bytes over a multiple of the cache line up
+	// to which a struct is reported as a near miss.
+	nearMiss int64
+
+	// falseSharing enables reporting concurrently written fields sharing a
+	// cache line; sharing holds these fields.
+	falseSharing bool
+	sharing      concurrentFields
+
+	// pad makes fix write explicit padding fields, keeping the field
+	// order, in place of a new order.
+	pad bool
+
+	// order is the field order fix writes: "visibility" keeps exported
+	// fields before unexported ones, and reports what that costs; any
+	// other value orders by size alone.
+	order string
+
+	// keepFirst is the number of leading fields the optimal orders keep in
+	// place in the structs without a //padding:keep-first directive.
+	keepFirst int
+	// moveLocks lets the optimal orders move a leading embedded lock,
+	// which they otherwise keep first.
+	moveLocks bool
+
+	// tieBreak orders the fields of the same size and alignment in the
+	// layouts fix writes: "alpha" sorts them by name, any other value
+	// keeps their source order.
+	tieBreak string
+
+	// includeDeps requests reporting the structs of the packages of other
+	// modules imported, through depsDepth imports, by those processed.
+	// module is set to the module and version of the dependency being
+	// reported, whose files are never written.
+	includeDeps bool
+	depsDepth   int
+	module      string
+
+	// gcOrder reports the pointer prefix of each struct and makes fix
+	// order pointer fields first among orders of the optimal size.
+	gcOrder bool
+
+	// pointers requests the pointer words of each struct; processPath
+	// sets masks to their layouts.
+	pointers bool
+	masks    pointerMasks
+
+	// wastes holds the bytes each package-level struct type of the
+	// package being reported wastes, by name, for the array and slice
+	// fields of the others; processFiles sets it.
+	wastes map[string]int64
+
+	// strides holds the stride of each package-level struct type of the
+	// package being reported, by name, for the array and slice fields of
+	// the others; processFiles sets it.
+	strides map[string]padding.Stride
+
+	// fixed holds the layouts fix gives the structs of the package being
+	// reported, found by fixedLayouts; processFiles sets it.
+	fixed map[*padding.StructInfo]padding.StructInfo
+
+	// reflected holds the reflect calls indexing the fields of the structs
+	// being fixed by position; processPath sets it when fixing, and
+	// processFiles sets pinned to the structs among them, which fix leaves
+	// alone.
+	reflected reflectIndexes
+	pinned    map[*padding.StructInfo][]string
+
+	// fixLiterals requests rewriting the unkeyed composite literals of the
+	// structs being fixed in keyed form before reordering them, when all
+	// of them are in the files being fixed. processPath sets literals to
+	// the unkeyed literals it leaves, and processFiles sets unkeyed to the
+	// structs they build that fix would reorder, which it leaves alone.
+	fixLiterals bool
+	literals    unkeyedLiterals
+	unkeyed     map[*padding.StructInfo]string
+
+	// fixOut, if set with fix, receives the fixed source of each file in
+	// place of the file being rewritten; processStdin sets it.
+	fixOut io.Writer
+
+	// printFixed, set with fix, collects the declaration fix would write
+	// for the structs of a name instead of reporting and fixing anything.
+	printFixed *fixedPrinter
+
+	// explainSkip, set with fix, requests the reasons fix leaves each
+	// struct alone in its report, and their list after the report.
+	explainSkip bool
+
+	// explain requests an explanation of each run of padding.
+	explain bool
+
+	// types requests the sizes of fields as the type checker gives them;
+	// processPath sets typed to the struct types it found.
+	types bool
+	typed typedStructs
+
+	// external requests the waste inside fields of struct types of other
+	// packages; processPath sets externals to it.
+	external  bool
+	externals externalWastes
+
+	// promoted requests the fields promoted from embedded structs, with
+	// their offsets; processPath sets promotions to them.
+	promoted   bool
+	promotions promotedFields
+
+	// effective hides the structs whose fix leaves the heap memory they
+	// take unchanged, unless all is set, which shows them with a note;
+	// processFiles sets elements to the element types of arrays and slices
+	// of the package being reported, which are never hidden.
+	effective bool
+	all       bool
+	elements  map[string]bool
+
+	// freeTail requests the trailing padding of each struct, where fields
+	// fit without growing it.
+	freeTail bool
+
+	// nested requests tracing padding to the nested struct types leaving
+	// it; processPath sets sources to their padding.
+	nested  bool
+	sources nestedPadding
+
+	// splitThreshold, if positive, requests hot/cold splits of the structs
+	// larger than it.
+	splitThreshold int64
+
+	// suggest requests advisory suggestions, such as packing bools and
+	// narrowing counters, and moving a field taking more than
+	// indirectFraction of a struct behind a pointer.
+	suggest          bool
+	indirectFraction float64
+
+	// generics requests laying out the generic structs for each of their
+	// instantiations; processPath sets instantiations to the common order
+	// of each, and processFiles sets generic to those of the structs of the
+	// package being reported. Fix writes the common order if it wastes no
+	// more than genericSlack bytes in any instantiation, and otherwise
+	// leaves the struct alone.
+	generics       bool
+	genericSlack   int64
+	instantiations genericLayouts
+	generic        map[*padding.StructInfo]genericLayout
+}
+
+// optimal returns s with the field order fix writes, or with -pad, with
+// its padding fields.
+func (o options) optimal(s padding.StructInfo) padding.StructInfo {
+	switch {
+	case o.pad:
+		return padding.Pad(s)
+	case o.order == "visibility":
+		s = padding.VisibilityOrder(s)
+	case o.gcOrder:
+		s = padding.GCOrder(s)
+	case o.cacheLineOrder:
+		s = padding.CrossingFree(padding.Optimal(s), o.cacheLine)
+	default:
+		s = padding.Optimal(s)
+	}
+	if o.tieBreak == "alpha" {
+		s = padding.SortTies(s)
+	}
+	return s
+}
+
+// fixing returns what fix does to a struct: padding with -pad, and
+// otherwise reordering.
+func (o options) fixing() string {
+	if o.pad {
+		return "padding"
+	}
+	return "reordering"
+}
+
+// fixable reports whether fix may reorder s.
+func (o options) fixable(s *padding.StructInfo) bool {
+	l, generic := o.generic[s]
+	if generic && l.waste > o.genericSlack {
+		return false
+	}
+	// The order of a struct sized by its type parameters is only known
+	// to be better from its instantiations, and its padding not at all.
+	if s.ParamSized && (!generic || o.pad) {
+		return false
+	}
+	// Sizes that are guesses could make any order worse.
+	return !s.ReportOnly && !s.Cgo && !s.TooLarge && !s.FreeComments && !guessedSizes(*s) && o.pinned[s] == nil && o.unkeyed[s] == "" && o.holding[s] == "" && o.only.contains(s)
+}
+
+// fixedLayout returns the layout fix gives s: that found by fixedLayouts if
+// there is one, and otherwise its optimal layout on its own.
+func (o options) fixedLayout(s *padding.StructInfo) padding.StructInfo {
+	if l, ok := o.fixed[s]; ok {
+		return l
+	}
+	return o.optimal(*s)
+}
+
+// text reports whether structs are printed as text while they are processed.
+func (o options) text() bool {
+	return o.format == "" || o.format == "text"
+}
+
+// diagnostics returns the function writing findings that are not part of
+// the report: stdout for the text format, stderr otherwise, or when stdout
+// carries fixed source.
+func (o options) diagnostics() func([]byte) {
+	if !o.text() || o.printFixed != nil || o.fixOut != nil {
+		return func(p []byte) { os.Stderr.Write(p) }
+	}
+	return o.emit
+}
+
+// emit writes a piece of the report with write if set, and otherwise to
+// stdout.
+func (o options) emit(p []byte) {
+	if o.write != nil {
+		o.write(p)
+		return
+	}
+	emit(p)
+}
+
+// replace writes data, the fixed source of the file at path, which was read
+// as orig, to fixOut if set, and otherwise back to the file.
+func (o options) replace(path string, orig, data []byte) error {
+	if o.fixOut != nil {
+		_, err := o.fixOut.Write(data)
+		return err
+	}
+	return replaceFile(path, orig, data)
+}
+
+func main() {
+	if vetTool(os.Args[1:]) {
+		runVetTool()
+	}
+	os.Exit(run(os.Args[1:]))
+}
+
+// run runs padding-size with the command-line arguments args, the command
+// name excluded, and returns its exit status: 0 on success, 1 if a check
+// failed, and 2 if the arguments are invalid or a file could not be
+// processed; some of the commands and options keep 1 for their errors.
+func run(args []string) int {
+	// The go command settings of an earlier run in the same process, such
+	// as -debug, which no flag turns off, don't carry over.
+	goBuild = goSettings{}
+	if len(args) > 0 {
+		switch args[0] {
+		case "lock":
+			return runLock(args[1:])
+		case "gen-consts":
+			return runGenConsts(args[1:])
+		case "serve":
+			return runServe(args[1:])
+		case "compare":
+			return runCompare(args[1:])
+		case "layout-diff":
+			return runLayoutDiff(args[1:])
+		case "snapshot":
+			return runSnapshot(args[1:])
+		case "describe":
+			return runDescribe(args[1:])
+		case "render":
+			return runRender(args[1:])
+		case "bench":
+			return runBench(args[1:])
+		}
+	}
+
+	fs := flag.NewFlagSet("padding-size", flag.ContinueOnError)
+	fix := fs.Bool("fix", false, "Apply fixes to optimize struct layout")
+	pad := fs.Bool("pad", false, "Keep the field order, writing explicit _ [N]byte fields in place of the padding instead of reordering")
+	writeAnnotations := fs.Bool("write-annotations", false, "Insert or update //padding-size:ok size annotations")
+	annotate := fs.Bool("annotate", false, "Comment on the structs -fix would shrink instead of reordering them")
+	only := fs.String("only", "", "With -fix, reorder only the structs of the comma-separated `names`")
+	structs := fs.String("structs", "", "Analyze only the struct types whose name, or package-qualified name, matches the `regexp`")
+	exportedOnly := fs.Bool("exported-only", false, "Analyze only exported struct types")
+	verify := fs.Bool("verify", false, "Cross-check computed layouts against the compiler (runs go test)")
+	fixLiterals := fs.Bool("fix-literals", false, "With -fix, rewrite the unkeyed composite literals of the structs being reordered in keyed form")
+	printFixed := fs.String("print-fixed", "", "Print the declaration -fix would write for the struct type `name`, without changing any file")
+	explainSkip := fs.Bool("explain-skip", false, "With -fix, list the structs left alone after the report, with the rules excluding each")
+	skipHasType := fs.String("skip-has-type", "", "With -fix, leave alone the structs with a field of one of the comma-separated `types`, such as sync.Mutex")
+	decl := fs.String("decl", "", "Analyze only the struct declared at `file:line`")
+	fixDecl := fs.String("fix-decl", "", "Fix only the struct declared at `file:line`; empty for $GOFILE:$GOLINE")
+	format := fs.String("format", "text", "Output `format`: text, json, metrics (OpenMetrics) or sarif")
+	check := fs.Bool("check", false, "Exit with status 1 if a struct wastes more than -threshold bytes, listing only those structs")
+	threshold := fs.Int64("threshold", 0, "With -check, the `bytes` of padding a struct may waste without failing the check")
+	thresholdTotal := fs.Int64("threshold-total", -1, "With -check, fail only if the structs over -threshold waste more than this many `bytes` in total; negative for any")
+	var exclude excludePatterns
+	fs.Var(&exclude, "exclude", "Skip the files and directories matching the glob `pattern` when walking directories (repeatable)")
+	includeGenerated := fs.Bool("include-generated", false, "Analyze generated files, marked // Code generated ... DO NOT EDIT., too")
+	includeTests := fs.Bool("include-tests", false, "Analyze _test.go files too")
+	jobs := fs.Int("jobs", runtime.NumCPU(), "Number of directories to process at once")
+	sarifLevel := fs.String("sarif-level", "warning", "`Level` of the results of -format=sarif: note or warning")
+	var labels metricLabels
+	fs.Var(&labels, "metrics-label", "Add the label `name=value` to every metric (repeatable)")
+	heapProfilePath := fs.String("heap-profile", "", "Rank structs by the bytes their live instances in the pprof heap profile `file` waste")
+	allocSites := fs.Bool("alloc-sites", false, "Count allocation sites of structs and rank structs by them (type-checks the packages)")
+	cacheLineReport := fs.Bool("cacheline-report", false, "Report the cache line each field starts in, the lines each struct occupies, and fields crossing cache line boundaries")
+	cacheLineOrder := fs.Bool("cacheline-order", false, "With -fix, prefer an order of the optimal size in which no field crosses a cache line boundary")
+	cacheLineSize := fs.Int64("cacheline-size", 64, "Size of a cache line in `bytes` for -cacheline-report, -cacheline-order, -false-sharing and -near-miss")
+	nearMiss := fs.Int64("near-miss", 8, "Report structs at most `n` bytes over a multiple of the cache line; 0 to disable")
+	falseSharing := fs.Bool("false-sharing", false, "Report concurrently written fields sharing a cache line (type-checks the packages)")
+	explain := fs.Bool("explain", false, "Explain the cause of each run of padding and what removes it, and narrate the layout")
+	order := fs.String("order", "size", "Field `order` -fix writes: size, or visibility to keep exported fields first")
+	includeDeps := fs.Bool("include-deps", false, "Also report, read-only, the structs of packages of other modules the analyzed packages import")
+	depsDepth := fs.Int("deps-depth", 1, "Follow imports `n` levels deep for -include-deps")
+	keepFirst := fs.Int("keep-first", 0, "Keep the first `n` fields of each struct in place, optimizing only the rest")
+	moveLocks := fs.Bool("move-locks", false, "Let -fix move a leading embedded sync.Mutex, sync.RWMutex or noCopy like any other field")
+	tieBreak := fs.String("tie-break", "source", "Order of fields of the same size and alignment -fix writes: `source` or alpha")
+	typeSizes := fs.Bool("types", true, "Size fields with the type checker, falling back to their type expressions where packages fail to load")
+	external := fs.Bool("external-waste", false, "Report the padding inside fields whose types are structs of other packages (type-checks the packages)")
+	freeTail := fs.Bool("free-tail", false, "Report the trailing padding of structs, where fields can be added for free")
+	gcOrder := fs.Bool("gc-order", false, "Report the pointer prefix the garbage collector scans, and with -fix order pointer fields first")
+	nested := fs.Bool("nested", false, "Trace the padding of structs to the nested struct types it comes from (type-checks the packages)")
+	pointers := fs.Bool("pointers", false, "Report the pointer bytes and GC scan length of structs (type-checks the packages)")
+	promoted := fs.Bool("promoted", false, "List the fields promoted from embedded structs with their offsets (type-checks the packages)")
+	suggest := fs.Bool("suggest", false, "Suggest changes reordering can't make, such as packing bool fields into bit flags")
+	generics := fs.Bool("generics", false, "Lay out generic structs for each instantiation and, with -fix, write one order suiting all (type-checks the packages)")
+	genericSlack := fs.Int64("generic-slack", 0, "Bytes the order -generics writes may waste in an instantiation")
+	indirectFraction := fs.Float64("indirect-fraction", 0.5, "Share of a struct above which -suggest proposes moving a field behind a pointer")
+	suggestSplit := fs.Bool("suggest-split", false, "Suggest moving cold fields of large structs behind a pointer")
+	splitThreshold := fs.Int64("split-threshold", 0, "Size in `bytes` above which -suggest-split proposes splits; 0 for two cache lines")
+	effective := fs.Bool("effective", false, "Report only structs whose fix changes the heap memory they take")
+	all := fs.Bool("all", false, "List every struct in the text report, including those already optimal; with -effective, also report the other structs, noting that fixing them saves no heap memory")
+	top := fs.Int("top", 0, "Rank only the first `n` structs; 0 for all, or 10 for the top offenders of the summary and -stats")
+	verbose := fs.Bool("verbose", false, "After the report, list the field types whose sizes were guessed")
+	positions := fs.Bool("positions", false, "Start each struct of the text report with its file:line:column position, and with -verbose each field")
+	strict := fs.Bool("strict", false, "Exit with status 1 if a package fails to type-check and results are estimated")
+	globals := fs.Bool("globals", false, "After the report, list the package-level variables holding padded structs")
+	stats := fs.Bool("stats", false, "After the report, list the field types causing the most padding across all structs")
+	dupes := fs.Bool("dupes", false, "After the report, list the groups of structs with identical layouts")
+	dupesByType := fs.Bool("dupes-types", false, "With -dupes, group only structs whose field types are identical too")
+	counts := new(instanceCounts)
+	fs.Var(counts, "count", "Expect `Struct=N` instances of a struct (repeatable)")
+	countsFile := fs.String("counts", "", "Read expected instances from the CSV `file` of type,count records")
+	logFormat := fs.String("log-format", "text", "Diagnostics `format` on stderr: text, or json for one structured record per event")
+	fixLogPath := fs.String("fix-log", "", "With -fix, write a JSON record of the rewritten structs to `file`")
+	sortBy := fs.String("sort", "source", "Order of the recoverable memory table: source or recoverable")
+	schema := fs.Bool("schema", false, "Print the JSON Schema of the report and exit")
+	help := fs.Bool("help", false, "Display help information")
+	cpuProfile := fs.String("cpuprofile", "", "Write a CPU profile to `file`")
+	memProfile := fs.String("memprofile", "", "Write a heap profile to `file`")
+	traceFile := fs.String("trace", "", "Write an execution trace to `file`")
+	goBuild.register(fs)
+	fs.StringVar(&goBuild.arch, "arch", runtime.GOARCH, "`GOARCH` whose word size and alignment to lay out structs for: amd64, 386, arm, arm64, wasm, ...")
+	fs.StringVar(&goBuild.goos, "goos", runtime.GOOS, "`GOOS` whose files to analyze, as selected by file name suffixes and build constraints")
+	fs.Func("tags", "Comma-separated build `tags` to consider satisfied, as go build -tags", func(v string) error {
+		goBuild.tags = strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+		return nil
+	})
+	allPlatforms := fs.Bool("all-platforms", false, "Analyze the files of every GOOS, GOARCH and build tag, labeling each struct with the constraint of its file")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	if *help || len(args) == 0 {
+		printHelp()
+		return 0
+	}
+	if *schema {
+		os.Stdout.Write(padding.Schema())
+		return 0
+	}
+	var err error
+	if logger, err = newLogger(*logFormat, os.Stderr, goBuild.debug != nil); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	start := time.Now()
+
+	// -decl and -fix-decl may be given an empty position, so check
+	// whether they were set rather than their values.
+	declMode := ""
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "decl" || f.Name == "fix-decl" {
+			declMode = f.Name
+		}
+	})
+	if declMode != "" {
+		pos := *decl
+		if declMode == "fix-decl" {
+			pos = *fixDecl
+		}
+		err := runDecl(pos, *fix || declMode == "fix-decl")
+		stdout.Flush()
+		if err != nil {
+			logError(err.Error())
+			return 1
+		}
+		return 0
+	}
+
+	if *format != "text" && *format != "json" && *format != "metrics" && *format != "sarif" {
+		logError(fmt.Sprintf("unknown format %q", *format))
+		return 2
+	}
+	if *sarifLevel != "note" && *sarifLevel != "warning" {
+		logError(fmt.Sprintf("unknown SARIF level %q", *sarifLevel))
+		return 2
+	}
+	if *sortBy != "source" && *sortBy != "recoverable" {
+		logError(fmt.Sprintf("unknown sort order %q", *sortBy))
+		return 2
+	}
+	if *order != "size" && *order != "visibility" {
+		logError(fmt.Sprintf("unknown field order %q", *order))
+		return 2
+	}
+	if *indirectFraction <= 0 || *indirectFraction >= 1 {
+		logError(fmt.Sprintf("invalid indirection fraction %v", *indirectFraction))
+		return 2
+	}
+	if *genericSlack < 0 {
+		logError(fmt.Sprintf("invalid generic slack %d", *genericSlack))
+		return 2
+	}
+	if *depsDepth < 1 {
+		logError(fmt.Sprintf("invalid dependency depth %d", *depsDepth))
+		return 2
+	}
+	if *keepFirst < 0 {
+		logError(fmt.Sprintf("invalid number of fields to keep first %d", *keepFirst))
+		return 2
+	}
+	if *tieBreak != "source" && *tieBreak != "alpha" {
+		logError(fmt.Sprintf("unknown tie-break %q", *tieBreak))
+		return 2
+	}
+	if *order == "visibility" && *gcOrder {
+		logError("-gc-order can't be combined with -order=visibility")
+		return 2
+	}
+	if *cacheLineOrder && (*order == "visibility" || *gcOrder) {
+		logError("-cacheline-order can't be combined with -order=visibility or -gc-order")
+		return 2
+	}
+	if *cacheLineSize <= 0 {
+		logError(fmt.Sprintf("invalid cache line size %d", *cacheLineSize))
+		return 2
+	}
+	if *nearMiss < 0 {
+		logError(fmt.Sprintf("invalid near-miss threshold %d", *nearMiss))
+		return 2
+	}
+	if *jobs < 1 {
+		logError(fmt.Sprintf("invalid number of jobs %d", *jobs))
+		return 2
+	}
+	if *splitThreshold < 0 {
+		logError(fmt.Sprintf("invalid split threshold %d", *splitThreshold))
+		return 2
+	}
+	if *annotate && (*fix || *writeAnnotations) {
+		logError("-annotate can't be combined with -fix or -write-annotations")
+		return 2
+	}
+	if *pad && (*fix || *annotate || *writeAnnotations || *printFixed != "" || *check || *fixDecl != "") {
+		logError("-pad can't be combined with -fix, -annotate, -write-annotations, -print-fixed, -check or -fix-decl")
+		return 2
+	}
+	if *pad && (*order == "visibility" || *gcOrder || *cacheLineOrder || *tieBreak == "alpha") {
+		logError("-pad keeps the field order and can't be combined with -order=visibility, -gc-order, -cacheline-order or -tie-break=alpha")
+		return 2
+	}
+	// -pad fixes structs by padding them, with the options of -fix.
+	*fix = *fix || *pad
+	if *only != "" && !*fix {
+		logError("-only requires -fix")
+		return 2
+	}
+	if *fixLiterals && !*fix {
+		logError("-fix-literals requires -fix")
+		return 2
+	}
+	if *explainSkip && !*fix {
+		logError("-explain-skip requires -fix")
+		return 2
+	}
+	if *printFixed != "" && (*fix || *annotate || *writeAnnotations || *format != "text") {
+		logError("-print-fixed can't be combined with -fix, -annotate, -write-annotations or -format")
+		return 2
+	}
+	if *skipHasType != "" && !*fix && !*annotate && *printFixed == "" {
+		logError("-skip-has-type requires -fix, -annotate or -print-fixed")
+		return 2
+	}
+	if *dupesByType && !*dupes {
+		logError("-dupes-types requires -dupes")
+		return 2
+	}
+	if *fixLogPath != "" && !*fix {
+		logError("-fix-log requires -fix")
+		return 2
+	}
+	if (*threshold != 0 || *thresholdTotal >= 0) && !*check {
+		logError("-threshold and -threshold-total require -check")
+		return 2
+	}
+	if *threshold < 0 {
+		logError(fmt.Sprintf("invalid threshold %d", *threshold))
+		return 2
+	}
+	if *check && (*fix || *annotate || *writeAnnotations || *printFixed != "") {
+		logError("-check can't be combined with -fix, -annotate, -write-annotations or -print-fixed")
+		return 2
+	}
+	if err := goBuild.resolve(); err != nil {
+		logError(err.Error())
+		return 2
+	}
+	filter, err := newStructFilter(*structs, *exportedOnly)
+	if err != nil {
+		logError(err.Error())
+		return 2
+	}
+	if *countsFile != "" {
+		if err := counts.readCSV(*countsFile); err != nil {
+			logError(err.Error())
+			return 1
+		}
+	}
+
+	args = fs.Args()
+	if len(args) == 0 {
+		fmt.Println("Error: No input files or directories specified.")
+		fmt.Println("Run 'padding-size -help' for usage information.")
+		return 1
+	}
+
+	fromStdin := slices.Contains(args, stdinPath)
+	if fromStdin && len(args) > 1 {
+		logError("- (standard input) can't be combined with other paths")
+		return 2
+	}
+	if fromStdin {
+		var conflicts []string
+		fs.Visit(func(f *flag.Flag) {
+			if slices.Contains(stdinConflicts, f.Name) {
+				conflicts = append(conflicts, "-"+f.Name)
+			}
+		})
+		if len(conflicts) > 0 {
+			logError(fmt.Sprintf("- (standard input) can't be combined with %s, which need the file or its package on disk", strings.Join(conflicts, ", ")))
+			return 2
+		}
+	}
+
+	stopProfiling, err := startProfiling(*cpuProfile, *memProfile, *traceFile)
+	if err != nil {
+		logError(err.Error())
+		return 1
+	}
+	// An interrupted run still leaves complete profiles behind.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		<-signals
+		if err := stopProfiling(); err != nil {
+			logError(err.Error())
+		}
+		os.Exit(1)
+	}()
+
+	opts := options{fix: *fix, writeAnnotations: *writeAnnotations, annotate: *annotate, verify: *verify, format: *format, allocSites: *allocSites}
+	if *check && opts.text() {
+		// The offending structs are listed in place of the report.
+		opts.format = "check"
+	}
+	if *heapProfilePath != "" {
+		if opts.heap, err = loadHeapProfile(*heapProfilePath); err != nil {
+			logError(err.Error())
+			return 1
+		}
+	}
+	if !counts.empty() {
+		opts.counts = counts
+	}
+	if *only != "" {
+		opts.only = parseSelection(*only)
+	}
+	// Standard input can be read only once, by processStdin, so the names
+	// of -only aren't checked against it.
+	if opts.only != nil && !fromStdin {
+		if err := opts.only.check(args); err != nil {
+			logError(err.Error())
+			return 1
+		}
+	}
+	opts.exclude, opts.includeGenerated, opts.includeTests = exclude, *includeGenerated, *includeTests
+	opts.allPlatforms = *allPlatforms
+	opts.filter = filter
+	opts.jobs = *jobs
+	opts.skipTypes = parseSkipTypes(*skipHasType)
+	opts.fixLiterals = *fixLiterals
+	opts.explainSkip = *explainSkip
+	opts.pad = *pad
+	if *fix {
+		opts.fixLog = &fixLog{pad: *pad}
+	}
+	opts.typeFailures = new(typeFailures)
+	opts.cacheLine, opts.cacheLineReport, opts.falseSharing = *cacheLineSize, *cacheLineReport, *falseSharing
+	opts.cacheLineOrder = *cacheLineOrder
+	opts.nearMiss = *nearMiss
+	opts.types = *typeSizes
+	opts.gcOrder, opts.pointers, opts.promoted, opts.suggest = *gcOrder, *pointers, *promoted, *suggest
+	opts.explain, opts.external, opts.freeTail, opts.nested = *explain, *external, *freeTail, *nested
+	opts.order, opts.tieBreak, opts.keepFirst = *order, *tieBreak, *keepFirst
+	opts.moveLocks = *moveLocks
+	opts.includeDeps, opts.depsDepth = *includeDeps, *depsDepth
+	opts.effective, opts.all = *effective, *all
+	opts.indirectFraction = *indirectFraction
+	opts.generics, opts.genericSlack = *generics, *genericSlack
+	if *suggestSplit {
+		opts.splitThreshold = *splitThreshold
+		if opts.splitThreshold == 0 {
+			opts.splitThreshold = 2 * opts.cacheLine
+		}
+	}
+	opts.stats, opts.globals, opts.verbose, opts.positions = *stats, *globals, *verbose, *positions
+	opts.dupes, opts.dupesByType = *dupes, *dupesByType
+	opts.collect = &reportCollector{allPlatforms: *allPlatforms}
+	if fromStdin && opts.fix {
+		// The fixed source is written in place of the report.
+		opts.fixOut = stdout
+	}
+	if *printFixed != "" {
+		opts.fix, opts.printFixed, opts.collect = true, &fixedPrinter{name: *printFixed}, nil
+		err := runPrintFixed(args, opts)
+		stdout.Flush()
+		if logger != nil {
+			opts.typeFailures.log(logger)
+		} else {
+			fmt.Fprint(os.Stderr, opts.typeFailures.warning())
+		}
+		if err != nil {
+			logError(err.Error())
+			return 1
+		}
+		return 0
+	}
+	if goBuild.gccgo() && opts.text() && opts.fixOut == nil {
+		emit([]byte("Compiler: gccgo\n\n"))
+	}
+	if goBuild.goos != runtime.GOOS && opts.text() && opts.fixOut == nil {
+		emit([]byte("Operating system: " + goBuild.goos + "\n\n"))
+	}
+	if goBuild.arch != runtime.GOARCH && opts.text() && opts.fixOut == nil {
+		emit([]byte("Architecture: " + goBuild.arch + "\n\n"))
+	}
+	reg := newFileRegistry()
+	processingFailed := false
+	for _, path := range args {
+		pathStart := time.Now()
+		err := processPath(path, opts, reg)
+		if err != nil {
+			processingFailed = true
+			text := fmt.Sprintf("Error processing %s: %v\n", path, err)
+			var se *sourceError
+			if errors.As(err, &se) {
+				text = fmt.Sprintf("Error processing %s:\n%v\n", path, err)
+			}
+			write := opts.diagnostics()
+			if path == stdinPath {
+				// The output of an editor's filter is its stdout alone.
+				write = writeStderr
+			}
+			diagnose(write, text, slog.LevelError, "error processing", "file", path, "reason", err.Error())
+		}
+		logTiming(pathStart, "processed", "file", path)
+	}
+	switch {
+	case opts.fixOut != nil && opts.explainSkip:
+		// Stdout carries the fixed source.
+		err = writeSkippedStructs(os.Stderr, opts.collect.report())
+	case opts.fixOut != nil:
+	case opts.format == "json":
+		r := opts.collect.report()
+		if opts.stats {
+			r.PaddingByType = padding.AggregatePaddingByType(r.Structs)
+		}
+		if opts.dupes {
+			r.DuplicateLayouts = padding.DuplicateLayouts(r.Structs, opts.dupesByType)
+		}
+		r.Summary = &padding.ReportSummary{ScannedFiles: reg.count()}
+		err = jsonEncoder{stdout, *top}.encode(r)
+	case opts.format == "metrics":
+		err = writeMetrics(stdout, opts.collect.report(), labels)
+	case opts.format == "sarif":
+		err = writeSARIF(stdout, opts.collect.report(), *sarifLevel)
+	case opts.format == "check":
+		err = checkReport(opts.collect.report(), *threshold, *thresholdTotal).write(stdout, *threshold, *thresholdTotal)
+	default:
+		r := opts.collect.report()
+		if opts.dupes {
+			r.DuplicateLayouts = padding.DuplicateLayouts(r.Structs, opts.dupesByType)
+		}
+		r.Summary = &padding.ReportSummary{ScannedFiles: reg.count()}
+		t := tables{opts.heap != nil, opts.allocSites, opts.counts != nil, opts.stats, opts.globals, opts.verbose, opts.dupes, opts.explainSkip, true}
+		err = writeTables(stdout, r, t, *sortBy == "recoverable", *top)
+	}
+	if opts.counts != nil {
+		for _, name := range opts.counts.unknown() {
+			diagnose(writeStderr, "Warning: instance count given for unknown struct "+name+"\n",
+				slog.LevelWarn, "instance count given for unknown struct", "struct", name)
+		}
+	}
+	if *fixLogPath != "" && err == nil {
+		err = opts.fixLog.write(*fixLogPath)
+	}
+	if err != nil {
+		logError(err.Error())
+	}
+	stdout.Flush()
+	switch {
+	case opts.fixLog != nil && logger != nil:
+		opts.fixLog.log(logger)
+	case opts.fixLog != nil && opts.text() && opts.fixOut == nil:
+		if err := opts.fixLog.writeSummary(os.Stderr); err != nil {
+			logError(err.Error())
+		}
+	}
+	if logger != nil {
+		opts.typeFailures.log(logger)
+	} else {
+		fmt.Fprint(os.Stderr, opts.typeFailures.warning())
+	}
+	logTiming(start, "run complete")
+
+	if err := stopProfiling(); err != nil {
+		logError(err.Error())
+		return 1
+	}
+	if processingFailed {
+		return 2
+	}
+	if *strict && opts.typeFailures.degraded() {
+		return 1
+	}
+	if *check && checkReport(opts.collect.report(), *threshold, *thresholdTotal).failed {
+		return 1
+	}
+	return 0
+}
+
+func printHelp() {
+	fmt.Println("padding-size - Analyze and optimize struct field alignment in Go")
+	fmt.Println("\nUsage:")
+	fmt.Println("  padding-size [options] <file or directory paths>")
+	fmt.Println("  padding-size [options] -")
+	fmt.Println("  padding-size lock [-o file] [-types T1,T2] [-tags expr] <package directory>")
+	fmt.Println("  padding-size gen-consts [-o file] [-types T1,T2] [-arch a1,a2] <package directory>")
+	fmt.Println("  padding-size serve [-listen addr] [-max-bytes n] [-timeout d]")
+	fmt.Println("  padding-size compare [-format text|markdown] <old.json> <new.json>")
+	fmt.Println("  padding-size layout-diff <ref> <file.go>")
+	fmt.Println("  padding-size snapshot [-o file] [-arch GOARCH] [-check-snapshot file] <packages>")
+	fmt.Println("  padding-size describe [-arch GOARCH] [-format text|json] <importpath.Type>...")
+	fmt.Println("  padding-size render [-format text|json|metrics|sarif] [-all] [-min-waste n] [-top n] <report.json>")
+	fmt.Println("  padding-size bench [-o file] <package directory> <type>")
+	fmt.Println("\nOptions:")
+	fmt.Println("  -fix        Apply fixes to optimize struct layout; given the path -, read")
+	fmt.Println("              the source from stdin and print it fixed to stdout instead")
+	fmt.Println("  -pad        Keep the field order, and fix structs by writing explicit blank")
+	fmt.Println("              _ [N]byte fields marked // padding where the layout pads, and")
+	fmt.Println("              last for tail padding; those an edit left stale are replaced,")
+	fmt.Println("              so padding again changes nothing. Takes the options of -fix")
+	fmt.Println("  -only names With -fix, reorder only the structs of the comma-separated names,")
+	fmt.Println("              leaving everything else in the files byte-identical")
+	fmt.Println("  -structs regexp")
+	fmt.Println("              Analyze only the struct types whose name, or name qualified by its")
+	fmt.Println("              package as pkg.Type, matches regexp; the others are neither reported,")
+	fmt.Println("              counted nor fixed")
+	fmt.Println("  -exported-only")
+	fmt.Println("              Analyze only exported struct types")
+	fmt.Println("  -fix-literals")
+	fmt.Println("              With -fix, rewrite the unkeyed composite literals, such as")
+	fmt.Println("              T{1, \"a\"}, of the structs being reordered in keyed form first;")
+	fmt.Println("              without it, fix leaves these structs alone, as it does those")
+	fmt.Println("              with literals outside the files being fixed")
+	fmt.Println("  -print-fixed name")
+	fmt.Println("              Print the declaration -fix would write for the struct type name,")
+	fmt.Println("              with its comments and tags, changing no file; fails, telling why,")
+	fmt.Println("              for a struct -fix would leave alone")
+	fmt.Println("  -explain-skip")
+	fmt.Println("              With -fix, list after the report every struct fix left alone, with")
+	fmt.Println("              its position and the code and details of each rule excluding it")
+	fmt.Println("  -exclude pattern")
+	fmt.Println("              Skip the files and directories matching the glob pattern when")
+	fmt.Println("              walking directories (repeatable); vendor and testdata directories,")
+	fmt.Println("              test files and generated files are skipped unless named")
+	fmt.Println("  -include-generated")
+	fmt.Println("              Also analyze generated files, marked // Code generated ... DO NOT EDIT.")
+	fmt.Println("  -include-tests")
+	fmt.Println("              Also analyze _test.go files")
+	fmt.Println("  -jobs n     Number of directories to process at once when walking directories")
+	fmt.Println("              (default the number of CPUs); the report stays in walk order")
+	fmt.Println("  -skip-has-type types")
+	fmt.Println("              With -fix, -annotate or -print-fixed, leave alone the structs with a field,")
+	fmt.Println("              embedded or not, of one of the comma-separated types, such as")
+	fmt.Println("              sync.Mutex, qualified by package name or import path; they are")
+	fmt.Println("              still reported, marked skipped")
+	fmt.Println("  -annotate   Instead of reordering, comment on each struct -fix would shrink")
+	fmt.Println("              with the bytes it wastes, and remove stale comments")
+	fmt.Println("  -write-annotations")
+	fmt.Println("              Insert or update //padding-size:ok size=N annotations, which")
+	fmt.Println("              later runs check for layout drift")
+	fmt.Println("  -verify     Cross-check the computed layouts against the compiler by")
+	fmt.Println("              running generated probes with go test")
+	fmt.Println("  -decl file:line")
+	fmt.Println("              Analyze only the struct declared at file:line")
+	fmt.Println("  -fix-decl file:line")
+	fmt.Println("              Fix only the struct declared at file:line, leaving the rest of")
+	fmt.Println("              the file unchanged; with an empty value (-fix-decl=), the")
+	fmt.Println("              position is taken from $GOFILE and $GOLINE under go generate")
+	fmt.Println("  -fix-log file")
+	fmt.Println("              With -fix, write a JSON record of each struct's old and new")
+	fmt.Println("              field order and size, and of the files left alone, to file")
+	fmt.Println("  -order size|visibility")
+	fmt.Println("              Field order -fix writes: by size alone (default), or keeping")
+	fmt.Println("              exported fields before unexported ones, reporting what it costs")
+	fmt.Println("  -keep-first n")
+	fmt.Println("              Keep the first n fields of each struct in place, optimizing and")
+	fmt.Println("              fixing only the rest, and report the bytes this costs; the")
+	fmt.Println("              //padding:keep-first=n directive sets n for one struct")
+	fmt.Println("  -move-locks")
+	fmt.Println("              Let -fix move a leading embedded sync.Mutex, sync.RWMutex or")
+	fmt.Println("              noCopy like any other field, rather than keeping it first")
+	fmt.Println("  -tie-break source|alpha")
+	fmt.Println("              Order -fix gives fields of the same size and alignment: their")
+	fmt.Println("              source order (default) or alphabetical")
+	fmt.Println("  -include-deps")
+	fmt.Println("              Also report the structs of the packages of other modules the")
+	fmt.Println("              analyzed packages import, labeled with their module and version;")
+	fmt.Println("              they are never fixed")
+	fmt.Println("  -deps-depth n")
+	fmt.Println("              Follow imports n levels deep for -include-deps (default 1,")
+	fmt.Println("              direct imports only)")
+	fmt.Println("  -format text|json|metrics|sarif")
+	fmt.Println("              Output format; json writes the report (see -schema) as one")
+	fmt.Println("              document: each struct with its position, sizes, wasted bytes")
+	fmt.Println("              and fields with the padding after each, the files declaring")
+	fmt.Println("              them and a summary of the totals; metrics OpenMetrics gauges of")
+	fmt.Println("              struct sizes and wasted bytes; sarif a SARIF 2.1.0 log with a")
+	fmt.Println("              struct-padding result for each struct reordering would shrink;")
+	fmt.Println("              all with other findings on stderr")
+	fmt.Println("  -sarif-level note|warning")
+	fmt.Println("              Level of the results of -format=sarif (default warning)")
+	fmt.Println("  -check      List only the structs wasting more than -threshold bytes, and exit")
+	fmt.Println("              with status 1 if there is any; 2 means a file couldn't be processed")
+	fmt.Println("  -threshold n")
+	fmt.Println("              With -check, the bytes a struct may waste without failing (default 0)")
+	fmt.Println("  -threshold-total n")
+	fmt.Println("              With -check, fail only if the offending structs waste more than n")
+	fmt.Println("              bytes in total")
+	fmt.Println("  -metrics-label name=value")
+	fmt.Println("              Add a static label to every metric (repeatable)")
+	fmt.Println("  -heap-profile file")
+	fmt.Println("              Rank the structs by the bytes wasted by their live instances in")
+	fmt.Println("              the pprof heap profile file")
+	fmt.Println("  -alloc-sites")
+	fmt.Println("              Count the allocation sites of each struct (&T{}, new(T),")
+	fmt.Println("              make([]T, n)) in the type-checked packages and rank by them")
+	fmt.Println("  -count Struct=N")
+	fmt.Println("              Expect N instances of Struct (or pkg.Struct) and show the memory")
+	fmt.Println("              reordering recovers across them; repeatable")
+	fmt.Println("  -counts file")
+	fmt.Println("              Read instance counts from a CSV file of type,count records")
+	fmt.Println("  -sort source|recoverable")
+	fmt.Println("              Order of the recoverable memory table")
+	fmt.Println("  -cacheline-report")
+	fmt.Println("              Report the cache line each field starts in, the lines each")
+	fmt.Println("              struct occupies now and once reordered, and fields crossing a")
+	fmt.Println("              cache line boundary, with a field order or padding avoiding it")
+	fmt.Println("  -cacheline-order")
+	fmt.Println("              With -fix, among the orders of the optimal size prefer one in")
+	fmt.Println("              which no field crosses a cache line boundary")
+	fmt.Println("  -cacheline-size n")
+	fmt.Println("              Size of a cache line in bytes (default 64)")
+	fmt.Println("  -near-miss n")
+	fmt.Println("              Report structs at most n bytes over a multiple of the cache line,")
+	fmt.Println("              and whether reordering brings them down to it (default 8; 0")
+	fmt.Println("              disables)")
+	fmt.Println("  -false-sharing")
+	fmt.Println("              Report pairs of concurrently written fields (atomics, mutexes and")
+	fmt.Println("              fields passed to sync/atomic) sharing a cache line")
+	fmt.Println("  -explain    Explain each run of padding: the alignment of the field after it,")
+	fmt.Println("              or that of the struct for trailing padding, and what removes it,")
+	fmt.Println("              and walk through the layout and the optimal one step by step")
+	fmt.Println("  -types      Size fields with the type checker, as the compiler does, so that")
+	fmt.Println("              named types, aliases and types of other packages, such as")
+	fmt.Println("              time.Time, get their true size (default true); fields of packages")
+	fmt.Println("              that fail to load keep a guess, marked estimated, and -fix leaves")
+	fmt.Println("              their structs alone")
+	fmt.Println("  -external-waste")
+	fmt.Println("              Report the bytes wasted inside fields whose types are structs of")
+	fmt.Println("              other packages, which only those packages can recover")
+	fmt.Println("  -free-tail  Report the padding after the last field of each struct, where")
+	fmt.Println("              new fields fit without growing it, now and in the optimal order")
+	fmt.Println("  -gc-order   Report the bytes of each struct the garbage collector scans, up")
+	fmt.Println("              to its last pointer, and with -fix move pointer fields first")
+	fmt.Println("              among the field orders of the optimal size")
+	fmt.Println("  -nested     Report how much of the padding of each struct comes from the")
+	fmt.Println("              struct types nested in it, and where they are declared")
+	fmt.Println("  -pointers   Report how many bytes of each struct are pointer words and")
+	fmt.Println("              where the last one ends, the length the garbage collector scans")
+	fmt.Println("  -promoted   List the fields promoted from structs embedded by value, however")
+	fmt.Println("              deeply, with their offsets in the outer struct")
+	fmt.Println("  -generics   Lay out generic structs for each instantiation in the packages")
+	fmt.Println("              and find one order suiting all; with -fix, write it if it is")
+	fmt.Println("              optimal for every instantiation, or else leave the struct alone")
+	fmt.Println("  -generic-slack n")
+	fmt.Println("              Bytes the order -generics writes may waste in an instantiation")
+	fmt.Println("              (default 0)")
+	fmt.Println("  -suggest    Suggest changes reordering can't make: packing runs of four or")
+	fmt.Println("              more bool fields into a flags field of bit constants, and")
+	fmt.Println("              narrowing counters wider than their siblings (heuristic), and")
+	fmt.Println("              moving a field taking most of a struct behind a pointer when")
+	fmt.Println("              the rest then fits a cache line of -cacheline-size")
+	fmt.Println("  -indirect-fraction f")
+	fmt.Println("              Share of a struct above which a single field is suggested to")
+	fmt.Println("              move behind a pointer (default 0.5)")
+	fmt.Println("  -suggest-split")
+	fmt.Println("              Suggest moving the fields marked //padding:cold, or else the")
+	fmt.Println("              largest ones, of structs over -split-threshold into a side")
+	fmt.Println("              struct behind a pointer")
+	fmt.Println("  -split-threshold n")
+	fmt.Println("              Size in bytes above which splits are suggested (default two")
+	fmt.Println("              cache lines of -cacheline-size)")
+	fmt.Println("  -effective  Report only the structs whose fix changes their allocation size")
+	fmt.Println("              class, or that are stored in arrays or slices of their package")
+	fmt.Println("  -all        List every struct in the text report, including those already")
+	fmt.Println("              optimal; with -effective, report the other structs too, with a note")
+	fmt.Println("  -top n      Rank only the first n structs; the top offenders of the summary")
+	fmt.Println("              and -stats list 10 unless given")
+	fmt.Println("  -stats      After the report, list the field types whose alignment causes")
+	fmt.Println("              the most padding across all structs (the first 10, or -top n)")
+	fmt.Println("  -dupes      After the report, list the groups of structs whose fields have")
+	fmt.Println("              the same sizes and alignments in the same order, with where each")
+	fmt.Println("              is declared")
+	fmt.Println("  -dupes-types")
+	fmt.Println("              With -dupes, group only structs whose field types are identical too")
+	fmt.Println("  -globals    After the report, list the package-level variables of struct")
+	fmt.Println("              types, or arrays of them, with the padding they hold")
+	fmt.Println("  -verbose    After the report, list the field types whose sizes were guessed,")
+	fmt.Println("              with the number of fields of each and where the first are")
+	fmt.Println("  -positions  Start each struct of the text report with the file:line:column of")
+	fmt.Println("              its name, as compilers do, and with -verbose each field with that")
+	fmt.Println("              of the field's name")
+	fmt.Println("  -strict     Exit with status 1 if an option needing type information found a")
+	fmt.Println("              package that fails to type-check, instead of only warning")
+	fmt.Println("  -mod mode   Load packages with go build -mod=mode: readonly, vendor or mod")
+	fmt.Println("  -modfile f  Load packages with go build -modfile=f, as the build does")
+	fmt.Println("  -compiler gc|gccgo")
+	fmt.Println("              Lay out structs by the size and alignment rules of this compiler")
+	fmt.Println("  -arch GOARCH")
+	fmt.Println("              Lay out structs for the word size and alignment of this")
+	fmt.Println("              architecture, such as 386 or arm, instead of the running one")
+	fmt.Println("  -goos GOOS  Analyze the files built for this operating system, as selected by")
+	fmt.Println("              file name suffixes and //go:build lines, instead of the running one")
+	fmt.Println("  -tags list  Consider the comma-separated build tags satisfied, as go build -tags")
+	fmt.Println("  -all-platforms")
+	fmt.Println("              Analyze the files of every platform, labeling each struct with the")
+	fmt.Println("              build constraint of its file")
+	fmt.Println("  -debug      Print each package load and go command run, with its build flags")
+	fmt.Println("  -log-format format")
+	fmt.Println("              Write errors, warnings, skip reasons and timings to stderr as text,")
+	fmt.Println("              or as json with one structured record per event")
+	fmt.Println("  -schema     Print the JSON Schema of the report, version " + padding.SchemaVersion)
+	fmt.Println("  -help       Display this help information")
+	fmt.Println("\nCommands:")
+	fmt.Println("  lock        Generate a test asserting the current layout of the package's structs")
+	fmt.Println("  gen-consts  Generate SizeOfT and AlignOfT constants for the package's structs")
+	fmt.Println("  serve       Serve a JSON API analyzing posted Go source (POST /analyze)")
+	fmt.Println("  compare     Diff two JSON reports, exiting with status 1 on regressions")
+	fmt.Println("  layout-diff Diff the struct layouts of a file at a git revision and in the")
+	fmt.Println("              working copy, exiting with status 1 if a struct grew")
+	fmt.Println("  snapshot    Record the exact layouts of the packages' structs, or with")
+	fmt.Println("              -check-snapshot exit with status 1 listing every deviation")
+	fmt.Println("  describe    Lay out struct types named by import path, from the standard")
+	fmt.Println("              library, the build list or the module cache, with their optimal order")
+	fmt.Println("  render      Write a saved JSON report in another format, filtered and ranked,")
+	fmt.Println("              without analyzing anything again")
+	fmt.Println("  bench       Generate a benchmark allocating and walking slices of a struct as")
+	fmt.Println("              declared and in its optimal order")
+	fmt.Println("\nStarted by go vet -vettool, padding-size runs the paddingcheck analyzer on")
+	fmt.Println("the package go vet passes, with its flags prefixed paddingcheck.")
+	fmt.Println("\nProfiling:")
+	fmt.Println("  -cpuprofile file   Write a CPU profile of the run to file")
+	fmt.Println("  -memprofile file   Write a heap profile taken at the end of the run to file")
+	fmt.Println("  -trace file        Write a runtime execution trace of the run to file")
+	fmt.Println("\nExamples:")
+	fmt.Println("  padding-size main.go")
+	fmt.Println("  padding-size -fix .")
+	fmt.Println("  padding-size -fix /path/to/project")
+	fmt.Println("  padding-size -fix - < types.go")
+	fmt.Println("  padding-size -check -threshold 8 ./...")
+	fmt.Println("  padding-size -format=metrics -metrics-label repo=api .")
+	fmt.Println("  //go:generate padding-size -fix-decl=")
+	fmt.Println("  padding-size lock ./wire -types Header,Frame")
+	fmt.Println("  padding-size gen-consts ./wire -arch amd64,arm")
+	fmt.Println("  padding-size compare -format=markdown base.json head.json")
+	fmt.Println("  padding-size layout-diff origin/main pkg/types.go")
+	fmt.Println("  padding-size snapshot -check-snapshot layouts.json ./shm")
+	fmt.Println("  padding-size describe -arch amd64 net/http.Request")
+	fmt.Println("  padding-size render -format=metrics -min-waste 8 report.json")
+	fmt.Println("  padding-size bench ./store Record")
+	fmt.Println("  go vet -vettool=$(which padding-size) ./...")
+}
+
+func processPath(path string, opts options, reg *fileRegistry) error {
+	if path == stdinPath {
+		reg.claimStdin()
+		return processStdin(stdin, opts)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if opts.allocSites {
+		dir := path
+		if !info.IsDir() {
+			dir = filepath.Dir(path)
+		}
+		opts.sites, err = countAllocSites(dir, info.IsDir())
+		if err := opts.typeFailures.add("-alloc-sites", err); err != nil {
+			cannot(opts, "count allocation sites", "in", dir, err)
+		}
+	}
+
+	if opts.falseSharing {
+		dir := path
+		if !info.IsDir() {
+			dir = filepath.Dir(path)
+		}
+		opts.sharing, err = findConcurrentFields(dir, info.IsDir())
+		if err := opts.typeFailures.add("-false-sharing", err); err != nil {
+			cannot(opts, "find concurrently written fields", "in", dir, err)
+		}
+	}
+
+	if opts.pointers {
+		dir := path
+		if !info.IsDir() {
+			dir = filepath.Dir(path)
+		}
+		opts.masks, err = findPointerMasks(dir, info.IsDir())
+		if err := opts.typeFailures.add("-pointers", err); err != nil {
+			cannot(opts, "lay out pointers", "in", dir, err)
+		}
+	}
+
+	if opts.types {
+		dir := path
+		if !info.IsDir() {
+			dir = filepath.Dir(path)
+		}
+		opts.typed, err = findTypedStructs(dir, info.IsDir())
+		if err := opts.typeFailures.add("-types", err); err != nil {
+			cannot(opts, "size fields with the type checker", "in", dir, err)
+		}
+	}
+
+	if opts.external {
+		dir := path
+		if !info.IsDir() {
+			dir = filepath.Dir(path)
+		}
+		opts.externals, err = findExternalWaste(dir, info.IsDir())
+		if err := opts.typeFailures.add("-external-waste", err); err != nil {
+			cannot(opts, "weigh the fields of other packages", "in", dir, err)
+		}
+	}
+
+	if opts.promoted {
+		dir := path
+		if !info.IsDir() {
+			dir = filepath.Dir(path)
+		}
+		opts.promotions, err = findPromotedFields(dir, info.IsDir())
+		if err := opts.typeFailures.add("-promoted", err); err != nil {
+			cannot(opts, "place promoted fields", "in", dir, err)
+		}
+	}
+
+	if opts.nested {
+		dir := path
+		if !info.IsDir() {
+			dir = filepath.Dir(path)
+		}
+		opts.sources, err = findNestedPadding(dir, info.IsDir())
+		if err := opts.typeFailures.add("-nested", err); err != nil {
+			cannot(opts, "trace nested padding", "in", dir, err)
+		}
+	}
+
+	if opts.generics {
+		dir := path
+		if !info.IsDir() {
+			dir = filepath.Dir(path)
+		}
+		opts.instantiations, err = findGenericLayouts(dir, info.IsDir())
+		if err := opts.typeFailures.add("-generics", err); err != nil {
+			cannot(opts, "lay out the instantiations of generic structs", "in", dir, err)
+		}
+	}
+
+	if opts.globals {
+		dir := path
+		if !info.IsDir() {
+			dir = filepath.Dir(path)
+		}
+		globals, err := findStaticFootprint(dir, info.IsDir())
+		if err := opts.typeFailures.add("-globals", err); err != nil {
+			cannot(opts, "weigh the package-level variables", "in", dir, err)
+		}
+		opts.collect.addGlobals(globals)
+	}
+
+	if opts.fix || opts.annotate {
+		dir := path
+		if !info.IsDir() {
+			dir = filepath.Dir(path)
+		}
+		option := "-fix"
+		if opts.annotate {
+			option = "-annotate"
+		}
+		opts.reflected, err = findReflectIndexes(dir, info.IsDir())
+		if err := opts.typeFailures.add(option, err); err != nil {
+			cannot(opts, "look for reflection indexing fields", "in", dir, err)
+		}
+		if opts.skipTypes != nil {
+			opts.held, err = findHeldTypes(dir, info.IsDir(), opts.skipTypes)
+			if err := opts.typeFailures.add("-skip-has-type", err); err != nil {
+				cannot(opts, "look for the types of -skip-has-type", "in", dir, err)
+			}
+		}
+		if opts.fix {
+			opts.literals, err = findUnkeyedLiterals(path, info.IsDir())
+			if err := opts.typeFailures.add("-fix", err); err != nil {
+				cannot(opts, "look for unkeyed composite literals", "in", dir, err)
+			}
+		}
+		if opts.fixLiterals {
+			reorders := func(key structKey, moves bool) bool {
+				return (moves || opts.order == "visibility" || opts.gcOrder) &&
+					(opts.only == nil || opts.only[key.name]) && opts.held[key] == ""
+			}
+			if err := opts.literals.key(reorders, opts.diagnostics()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !info.IsDir() {
+		if err := processFiles([]string{path}, opts, reg); err != nil {
+			return err
+		}
+		return processDependencies(filepath.Dir(path), false, opts, reg)
+	}
+
+	// Files are processed a directory at a time so that build variants of
+	// the same package can be compared with each other.
+	var dirs []string
+	filesByDir := make(map[string][]string)
+	err = filepath.Walk(path, func(filePath string, fileInfo os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fileInfo.IsDir() && opts.skipDir(path, filePath) {
+			return filepath.SkipDir
+		}
+		if !fileInfo.IsDir() && strings.HasSuffix(filePath, ".go") {
+			if skip, err := opts.skipFile(path, filePath); skip || err != nil {
+				return err
+			}
+			dir := filepath.Dir(filePath)
+			if _, ok := filesByDir[dir]; !ok {
+				dirs = append(dirs, dir)
+			}
+			filesByDir[dir] = append(filesByDir[dir], filePath)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = processDirs(dirs, filesByDir, opts, reg)
+	return errors.Join(err, processDependencies(path, true, opts, reg))
+}
+
+// cannot reports that an option could not do action in, or of, the package
+// in dir, as told by prep, because of err; the rest of the run goes on.
+func cannot(opts options, action, prep, dir string, err error) {
+	diagnose(opts.diagnostics(), fmt.Sprintf("Cannot %s %s %s: %v\n", action, prep, dir, err),
+		slog.LevelWarn, "cannot "+action, "file", dir, "reason", err.Error())
+}
+
+// structWarning is a warning about a struct that fix reorders, or leaves
+// alone: its text, and its message and reason as a log record.
+type structWarning struct {
+	text, msg, reason string
+}
+
+// report reports w, if there is one, about struct s of the file at path,
+// writing its text with write unless it is logged.
+func (w structWarning) report(write func([]byte), path string, s *padding.StructInfo) {
+	if w.text != "" {
+		diagnose(write, w.text+"\n", slog.LevelWarn, w.msg, "file", path, "struct", s.Name, "reason", w.reason)
+	}
+}
+
+// writeParagraph returns a function writing to b followed by a blank line.
+func writeParagraph(b *bytes.Buffer) func([]byte) {
+	return func(p []byte) {
+		b.Write(p)
+		b.WriteString("\n")
+	}
+}
+
+// processDependencies reports the structs of the packages of other modules
+// the package in dir imports, and with recursive those below it, if
+// requested. They are never fixed or annotated.
+func processDependencies(dir string, recursive bool, opts options, reg *fileRegistry) error {
+	if !opts.includeDeps {
+		return nil
+	}
+	deps, err := findDependencies(dir, recursive, opts.depsDepth)
+	if err != nil {
+		cannot(opts, "find the dependencies", "of", dir, err)
+		return nil
+	}
+	opts.fix, opts.writeAnnotations, opts.annotate, opts.verify, opts.fixLog = false, false, false, false, nil
+	for _, dep := range deps {
+		opts.module = dep.module
+		if err := processFiles(dep.files, opts, reg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func processFile(filePath string, opts options) error {
+	return processFiles([]string{filePath}, opts, newFileRegistry())
+}
+
+// processFiles analyzes files from a single directory. Structs declared
+// identically by several build variants of the package are reported once.
+// Files already claimed in reg, possibly under another name, are skipped.
+// A file that can't be loaded or written doesn't stop the others; the
+// errors of all are returned joined.
+func processFiles(paths []string, opts options, reg *fileRegistry) error {
+	var files []*FileResult
+	var errs []error
+	cache := padding.NewCache()
+	for _, path := range paths {
+		first, err := reg.claim(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !first {
+			if opts.fixLog != nil {
+				opts.fixLog.skipFile(path, "already processed under another name")
+			}
+			continue
+		}
+		f, err := loadFile(path, cache)
+		if err != nil {
+			if opts.fixLog != nil {
+				opts.fixLog.skipFile(path, err.Error())
+			}
+			errs = append(errs, err)
+			continue
+		}
+		if f != nil {
+			files = append(files, f)
+		}
+	}
+	// The packages of a directory, such as a package and its external
+	// tests, or several commands, are reported apart: their types don't
+	// refer to each other by name.
+	packages := splitPackages(files)
+	opts.mixedPackages = len(packages) > 1
+	for _, files := range packages {
+		errs = append(errs, processLoaded(files, opts))
+	}
+	return errors.Join(errs...)
+}
+
+// splitPackages groups files by the package they declare, in the order the
+// packages first appear.
+func splitPackages(files []*FileResult) [][]*FileResult {
+	var packages [][]*FileResult
+	index := make(map[string]int)
+	for _, f := range files {
+		i, ok := index[f.Package]
+		if !ok {
+			i = len(packages)
+			index[f.Package] = i
+			packages = append(packages, nil)
+		}
+		packages[i] = append(packages[i], f)
+	}
+	return packages
+}
+
+// processLoaded reports, and fixes as requested, files, loaded from a single
+// directory.
+func processLoaded(files []*FileResult, opts options) error {
+	var errs []error
+	if opts.typed != nil {
+		opts.typed.size(files)
+	}
+	resolveSizes(files)
+	markEstimated(files)
+	keepFirst(files, opts.keepFirst, opts.moveLocks)
+	opts.wastes = elementWastes(files)
+	opts.strides = elementStrides(files)
+	if opts.effective {
+		opts.elements = elementTypes(files)
+	}
+	// The structs -structs and -exported-only leave out have sized the
+	// fields holding them, and go no further.
+	opts.filter.apply(files)
+	if opts.collect != nil {
+		opts.collect.addEstimated(estimatedFields(files))
+	}
+	folded := foldVariants(files, opts.allPlatforms)
+	if opts.instantiations != nil {
+		opts.generic = opts.instantiations.resolve(files)
+	}
+	if opts.fix || opts.annotate {
+		opts.pinned = opts.reflected.pinned(files)
+		opts.unkeyed = opts.literals.pinned(files, opts.fixLiterals, opts.optimal)
+		opts.holding = opts.skipTypes.holders(files, opts.held)
+		opts.fixed = fixedLayouts(files, opts)
+	}
+	for _, f := range files {
+		if err := reportFile(f, folded, opts); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if opts.verify {
+		errs = append(errs, verifyLayouts(files, opts.diagnostics()))
+	}
+	return errors.Join(errs...)
+}
+
+// keepFirst makes the optimal orders of the structs declared in files
+// keep their first n fields in place, and a leading embedded lock unless
+// moveLocks is set, unless a //padding:keep-first directive says otherwise.
+func keepFirst(files []*FileResult, n int, moveLocks bool) {
+	for _, f := range files {
+		for i := range f.Structs {
+			s := &f.Structs[i]
+			if s.HasDirective("keep-first") {
+				continue
+			}
+			s.KeepFirst = n
+			if !moveLocks && s.LeadingLock() {
+				s.KeepFirst = max(n, 1)
+			}
+		}
+	}
+}
+
+// elementWastes returns the bytes each package-level struct type declared in
+// files wastes, by name.
+func elementWastes(files []*FileResult) map[string]int64 {
+	wastes := make(map[string]int64)
+	for name, s := range namedStructs(files) {
+		wastes[name] = s.Size - padding.Optimal(*s).Size
+	}
+	return wastes
+}
+
+// elementStrides returns the stride of each package-level struct type
+// declared in files, by name.
+func elementStrides(files []*FileResult) map[string]padding.Stride {
+	strides := make(map[string]padding.Stride)
+	for name, s := range namedStructs(files) {
+		strides[name] = padding.StrideOf(*s)
+	}
+	return strides
+}
+
+// loadFile parses and analyzes a single file. It returns nil if the file
+// declares no struct types.
+func loadFile(filePath string, cache *padding.Cache) (*FileResult, error) {
+	src, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if !padding.MayContainStruct(src) {
+		return nil, nil
+	}
+	return loadSource(filePath, src, cache)
+}
+
+// loadSource parses and analyzes src, the contents of the file at filePath.
+// It returns nil if src declares no struct types.
+func loadSource(filePath string, src []byte, cache *padding.Cache) (*FileResult, error) {
+	fset := token.NewFileSet()
+	node, err := parser.ParseFile(fset, filePath, src, parser.ParseComments|parser.SkipObjectResolution|parser.AllErrors)
+	if err != nil {
+		return nil, newSourceError(filePath, src, node, err)
+	}
+
+	structs, err := padding.Analyze(fset, node, padding.Options{Cache: cache, Compiler: goBuild.compiler, Arch: goBuild.arch})
+	if err != nil {
+		return nil, err
+	}
+	// Structs marked //padding:ignore are neither reported nor fixed.
+	structs = slices.DeleteFunc(structs, padding.StructInfo.Ignored)
+	if len(structs) == 0 {
+		return nil, nil
+	}
+
+	f := &FileResult{
+		Path:    filePath,
+		Src:     src,
+		Package: node.Name.Name,
+		Fset:    fset,
+		Node:    node,
+		Structs: structs,
+	}
+	if expr := fileConstraint(filePath, node); expr != nil {
+		f.Variant = expr.String()
+	}
+	return f, nil
+}
+
+// reportFile prints the structs of f, skipping those folded into an identical
+// build variant, along with any layout drift from their annotations, and
+// applies fixes and annotations when requested.
+func reportFile(f *FileResult, folded map[*padding.StructInfo]bool, opts options) error {
+	// The report of a file is written in one piece, so reports of files
+	// processed concurrently never interleave.
+	var out bytes.Buffer
+	if opts.printFixed == nil {
+		defer func() { opts.emit(out.Bytes()) }()
+	}
+
+	var before []padding.StructInfo
+	var reasons [][]skipReason
+	if opts.fix && (opts.fixLog != nil || opts.printFixed != nil || opts.explainSkip) {
+		before = slices.Clone(f.Structs)
+		reasons = make([][]skipReason, len(f.Structs))
+	}
+	var topLevel map[*ast.StructType]bool
+	if opts.sites != nil || opts.counts != nil || opts.sharing != nil || opts.masks != nil ||
+		opts.sources != nil || opts.externals != nil || opts.promotions != nil {
+		topLevel = topLevelStructs(f.Node)
+	}
+	header := false
+	for i := range f.Structs {
+		s := &f.Structs[i]
+		drift := checkAnnotation(*s)
+		r := padding.NewStructReport(*s)
+		r.File, r.Package, r.Module = f.Path, f.Package, opts.module
+		if s.Pos.IsValid() {
+			r.Line, r.Column = s.Pos.Line, s.Pos.Column
+			r.Position = fmt.Sprintf("%s:%d", f.Path, r.Line)
+		}
+		r.Estimated = opts.typeFailures.affects(f.Path)
+		var tooLargeWarning structWarning
+		switch {
+		case s.TooLarge:
+			// Its sizes overflow, so there is nothing to check, and the
+			// compiler rejects it anyway.
+			tooLargeWarning = structWarning{fmt.Sprintf("%s:%d: type %s too large", f.Path, r.Line, s.Name), "type too large", "its size overflows"}
+		case s.ParamSized:
+			// Its sizes are unknown but for its instantiations.
+			if l, ok := opts.generic[s]; ok {
+				l.weigh(&r)
+			}
+		default:
+			checkStruct(&r, s, topLevel[s.Node], opts)
+		}
+		var marshalWarning, reflectWarning, literalWarning, genericWarning, commentWarning structWarning
+		if opts.fix && s.FreeComments && !slices.EqualFunc(s.Fields, opts.fixedLayout(s).Fields, sameType) {
+			reason := "a comment of its body belongs to no field, and would end up above another"
+			commentWarning = structWarning{fmt.Sprintf("%s: not %s %s: %s", f.Path, opts.fixing(), s.Name, reason), "not " + opts.fixing(), reason}
+		}
+		var estimatedWarning structWarning
+		if opts.fix && !s.Cgo && guessedSizes(*s) && !slices.EqualFunc(s.Fields, opts.fixedLayout(s).Fields, sameType) {
+			reason := "the sizes of some of its fields are estimated, as their types failed to type-check"
+			estimatedWarning = structWarning{fmt.Sprintf("%s: not %s %s: %s", f.Path, opts.fixing(), s.Name, reason), "not " + opts.fixing(), reason}
+		}
+		if _, ok := opts.generic[s]; opts.fix && s.ParamSized && !ok {
+			reason := "its size depends on type parameters; -generics lays it out for each instantiation"
+			genericWarning = structWarning{fmt.Sprintf("%s: not %s %s: %s", f.Path, opts.fixing(), s.Name, reason), "not " + opts.fixing(), reason}
+			if reasons != nil {
+				reasons[i] = append(reasons[i], skipReason{causeTypeParams, reason})
+			}
+		}
+		if tags := padding.MarshalTags(*s); len(tags) > 0 && opts.fixable(s) {
+			r.MarshalOrderChanges = padding.MarshalOrderChanges(*s, opts.fixedLayout(s))
+			if opts.fix && r.MarshalOrderChanges {
+				reason := fmt.Sprintf("changes the order its fields are marshaled in (%s tags)", strings.Join(tags, ", "))
+				marshalWarning = structWarning{fmt.Sprintf("%s: reordering %s %s", f.Path, s.Name, reason), "reordering changes the marshaled order", reason}
+			}
+		}
+		if opts.fix && !opts.only.contains(s) && reasons != nil {
+			reasons[i] = append(reasons[i], skipReason{causeUnselected, "not named by -only"})
+		}
+		if listed := opts.holding[s]; listed != "" {
+			r.Skipped = "contains " + listed
+			if reasons != nil {
+				reasons[i] = append(reasons[i], skipReason{causeHeldType, "has a field of type " + listed + ", listed by -skip-has-type"})
+			}
+		}
+		if calls := opts.pinned[s]; opts.fix && calls != nil {
+			reason := "reflection indexes its fields by position at " + strings.Join(calls, ", ")
+			reflectWarning = structWarning{fmt.Sprintf("%s: not %s %s: %s", f.Path, opts.fixing(), s.Name, reason), "not " + opts.fixing(), reason}
+			if reasons != nil {
+				reasons[i] = append(reasons[i], skipReason{causeReflection, reason})
+			}
+		}
+		if reason := opts.unkeyed[s]; opts.fix && reason != "" {
+			literalWarning = structWarning{fmt.Sprintf("%s: not %s %s: %s", f.Path, opts.fixing(), s.Name, reason), "not " + opts.fixing(), reason}
+			if reasons != nil {
+				reasons[i] = append(reasons[i], skipReason{causeUnkeyed, reason})
+			}
+		}
+		if l, ok := opts.generic[s]; opts.fix && ok && l.waste > opts.genericSlack {
+			reason := fmt.Sprintf("no order is optimal for all its instantiations; the best wastes %d bytes in one", l.waste)
+			genericWarning = structWarning{fmt.Sprintf("%s: not %s %s: %s", f.Path, opts.fixing(), s.Name, reason), "not " + opts.fixing(), reason}
+			if reasons != nil {
+				reasons[i] = append(reasons[i], skipReason{causeGeneric, reason})
+			}
+		}
+		if opts.explainSkip {
+			for _, reason := range fixSkipReasons(*s, reasons[i]) {
+				r.SkipReasons = append(r.SkipReasons, padding.SkipReason{Code: reason.code(), Reason: reason.text})
+			}
+		}
+		hidden := folded[s] || !opts.shown(&r)
+		listed := !hidden && opts.listed(&r)
+		if opts.collect != nil && !hidden {
+			opts.collect.add(r)
+		}
+		if !opts.text() {
+			if drift != "" {
+				diagnose(opts.diagnostics(), fmt.Sprintf("%s: %s\n", f.Path, drift),
+					slog.LevelWarn, "layout drift", "file", f.Path, "struct", s.Name, "reason", drift)
+			}
+			if opts.fix && opts.fixable(s) {
+				*s = opts.fixedLayout(s)
+			}
+			for _, warning := range []structWarning{tooLargeWarning, marshalWarning, reflectWarning, literalWarning, genericWarning, commentWarning, estimatedWarning} {
+				warning.report(opts.diagnostics(), f.Path, s)
+			}
+			continue
+		}
+		if (listed || drift != "") && !header {
+			if opts.module != "" {
+				fmt.Fprintf(&out, "File: %s (%s, read-only)\n", f.Path, opts.module)
+			} else if opts.mixedPackages {
+				fmt.Fprintf(&out, "File: %s (package %s)\n", f.Path, f.Package)
+			} else {
+				fmt.Fprintf(&out, "File: %s\n", f.Path)
+			}
+			header = true
+		}
+		if listed && opts.positions {
+			padding.FprintStructAt(&out, r, opts.verbose)
+		} else if listed {
+			padding.FprintStruct(&out, r)
+		}
+		if drift != "" {
+			fmt.Fprintf(&out, "%s\n\n", drift)
+		}
+		if opts.fix && opts.fixable(s) {
+			*s = opts.fixedLayout(s)
+			if listed {
+				padding.Fprint(&out, *s)
+			}
+			marshalWarning.report(writeParagraph(&out), f.Path, s)
+		}
+		for _, warning := range []structWarning{tooLargeWarning, reflectWarning, literalWarning, genericWarning, commentWarning, estimatedWarning} {
+			warning.report(writeParagraph(&out), f.Path, s)
+		}
+	}
+
+	// The structs of a cgo file are never reordered, and formatting it
+	// anew could only disturb its preamble, so fix leaves it as it is.
+	cgo := importsC(f.Node)
+	var err error
+	switch {
+	case opts.printFixed != nil:
+		return opts.printFixed.add(f, before, reasons)
+	case opts.writeAnnotations:
+		err = writeAnnotations(f, opts.fix && !cgo)
+	case opts.annotate:
+		err = writeAdvisories(f, opts)
+	case opts.fix && cgo:
+	case opts.fix && opts.only != nil:
+		err = applySelectedFixes(f, opts.only, opts.holding, opts.replace)
+	case opts.fix:
+		err = applyFixes(f, unheld(f, opts.holding), opts.replace)
+	}
+	if opts.fixLog != nil && before != nil {
+		opts.fixLog.addFile(f.Path, before, f.Structs, reasons, err)
+	}
+	return err
+}
+
+// checkStruct runs on r, the report of s, the checks opts requests.
+// topLevel tells whether s is declared at package level.
+func checkStruct(r *padding.StructReport, s *padding.StructInfo, topLevel bool, opts options) {
+	r.CheckNestedWaste(opts.wastes)
+	r.CheckStrides(opts.strides)
+	if opts.heap != nil {
+		opts.heap.weigh(r)
+	}
+	if opts.cacheLineReport {
+		r.CheckCacheLines(*s, opts.cacheLine)
+	}
+	if opts.stats {
+		r.CheckPaddingByType(*s)
+	}
+	if opts.cacheLine > 0 {
+		r.CheckLineFit(opts.cacheLine, opts.nearMiss)
+	}
+	if opts.explain {
+		r.CheckHoles(*s)
+		r.CheckLayout(*s)
+	}
+	if !opts.fix {
+		r.CheckHint(*s)
+	}
+	if slices.ContainsFunc(s.Fields, padding.IsCacheLinePad) {
+		fixed := *s
+		if opts.fixable(s) {
+			fixed = opts.fixedLayout(s)
+		}
+		r.CheckCacheLinePads(*s, fixed, opts.cacheLine)
+	}
+	if opts.order == "visibility" {
+		r.CheckVisibility(*s)
+	}
+	if opts.freeTail {
+		r.CheckFreeTail(*s)
+	}
+	if opts.gcOrder {
+		r.CheckGCOrder(*s)
+	}
+	if opts.splitThreshold > 0 {
+		r.CheckSplit(*s, opts.splitThreshold)
+	}
+	if opts.suggest {
+		r.CheckSuggestions(*s)
+		r.CheckIndirection(*s, opts.indirectFraction, opts.cacheLine)
+	}
+	if topLevel {
+		opts.sites.weigh(r)
+		if opts.sharing != nil {
+			opts.sharing.weigh(r, opts.cacheLine)
+		}
+		if opts.masks != nil {
+			opts.masks.weigh(r)
+		}
+		if opts.sources != nil {
+			opts.sources.weigh(r)
+		}
+		if opts.externals != nil {
+			opts.externals.weigh(r)
+		}
+		if opts.promotions != nil {
+			opts.promotions.weigh(r)
+		}
+		if opts.counts != nil {
+			opts.counts.weigh(r)
+		}
+	}
+	if l, ok := opts.generic[s]; ok {
+		l.weigh(r)
+	}
+}
+
+// applyFixes writes f back, with replace, with structs reordered as in
+// f.Structs.
+func applyFixes(f *FileResult, structs []padding.StructInfo, replace func(path string, orig, data []byte) error) error {
+	src, err := padding.Rewrite(f.Fset, f.Node, f.Src, structs)
+	if err != nil {
+		return err
+	}
+	return replace(f.Path, f.Src, src)
+}
@@ -0,0 +1,690 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// sizeModel holds a field of each type the syntactic size model knows, in
+// an order leaving padding between some of them.
+type sizeModel struct {
+	b    bool
+	s    []int64
+	c64  complex64
+	i8   int8
+	m    map[string]int
+	ch   chan int
+	r    <-chan struct{}
+	fn   func(int) error
+	u16  uint16
+	any  any
+	e    error
+	in   interface{ Close() error }
+	str  string
+	up   uintptr
+	p    unsafe.Pointer
+	c128 complex128
+	f32  float32
+	bs   []byte
+	done struct{}
+	pt   struct{ x, y int32 }
+	n    int
+}
+
+// TestSizeModel checks the sizes Analyze gives the fields of sizeModel,
+// from this file's source, against those of the compiler.
+func TestSizeModel(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main_test.go", nil, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	structs, err := padding.Analyze(fset, file, padding.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := slices.IndexFunc(structs, func(s padding.StructInfo) bool { return s.Name == "sizeModel" })
+	if i < 0 {
+		t.Fatal("sizeModel not found")
+	}
+	s := structs[i]
+	typ := reflect.TypeFor[sizeModel]()
+	if len(s.Fields) != typ.NumField() {
+		t.Fatalf("analyzed %d fields, want %d", len(s.Fields), typ.NumField())
+	}
+	for i, f := range s.Fields {
+		want := typ.Field(i)
+		if f.Size != int64(want.Type.Size()) || f.Align != int64(want.Type.Align()) || f.Offset != int64(want.Offset) {
+			t.Errorf("%s %s: size %d, align %d, offset %d; compiler: %d, %d, %d",
+				f.Name, f.Type, f.Size, f.Align, f.Offset, want.Type.Size(), want.Type.Align(), want.Offset)
+		}
+		if padding.Estimated(f.Type, nil) {
+			t.Errorf("%s %s is estimated", f.Name, f.Type)
+		}
+	}
+	var v sizeModel
+	if s.Size != int64(unsafe.Sizeof(v)) || s.Align != int64(unsafe.Alignof(v)) {
+		t.Errorf("size %d, align %d; compiler: %d, %d", s.Size, s.Align, unsafe.Sizeof(v), unsafe.Alignof(v))
+	}
+}
+
+func TestApplyFixesDuplicateNames(t *testing.T) {
+	src := `package test
+
+func a() {
+	type T struct {
+		A bool
+		B int64
+		C bool
+	}
+}
+
+func b() {
+	type T struct {
+		X int8
+		Y int32
+	}
+}
+`
+	path := filepath.Join(t.TempDir(), "dup.go")
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := processFile(path, options{fix: true}); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+
+	fixed, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, fixed, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse fixed file: %v", err)
+	}
+	structs, err := padding.Analyze(fset, f, padding.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got [][]string
+	for _, s := range structs {
+		var names []string
+		for _, field := range s.Fields {
+			names = append(names, field.Name)
+		}
+		got = append(got, names)
+	}
+	want := [][]string{{"B", "A", "C"}, {"X", "Y"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Fixed field orders = %v, want %v", got, want)
+	}
+}
+
+func TestFixGCOrder(t *testing.T) {
+	path := writeFile(t, "package p\n\ntype T struct {\n\tN int64\n\tB bool\n\tP *T\n}\n")
+	out := captureReport(t, func() error { return processFile(path, options{fix: true, gcOrder: true}) })
+	if want := "Pointer prefix: 24 bytes, 8 with the pointer-first order P, N, B"; !strings.Contains(out, want) {
+		t.Errorf("report lacks %q:\n%s", want, out)
+	}
+	if src := readFile(t, path); !strings.Contains(src, "type T struct {\n\tP *T\n\tN int64\n\tB bool\n}") {
+		t.Errorf("fields not ordered pointer first:\n%s", src)
+	}
+}
+
+func TestFixAnonymous(t *testing.T) {
+	path := writeFile(t, `package p
+
+var state struct {
+	A bool
+	B int64
+	C bool
+}
+
+func f() {
+	_ = struct {
+		A bool
+		B int64
+		C bool
+	}{true, 1, false}
+}
+`)
+	l := new(fixLog)
+	out := captureReport(t, func() error { return processFile(path, options{fix: true, fixLog: l}) })
+	if !strings.Contains(out, "Struct: types.go:10 (anonymous struct) (size: 24 bytes") {
+		t.Errorf("report lacks the composite literal:\n%s", out)
+	}
+	src := readFile(t, path)
+	if !strings.Contains(src, "var state struct {\n\tB int64\n\tA bool\n\tC bool\n}") {
+		t.Errorf("package-level variable not fixed:\n%s", src)
+	}
+	if !strings.Contains(src, "_ = struct {\n\t\tA bool\n\t\tB int64\n\t\tC bool\n\t}{true, 1, false}") {
+		t.Errorf("composite literal type reordered:\n%s", src)
+	}
+	if sum := l.summary(); sum.Fixed != 1 || sum.Skipped != 1 {
+		t.Errorf("fix log summary = %+v, want 1 fixed and 1 skipped", sum)
+	}
+}
+
+func TestFixNested(t *testing.T) {
+	// Only once A is reordered does B get smaller than Big, which C holds
+	// first already; B is then of its optimal size as it stands.
+	path := writeFile(t, `package p
+
+type A struct {
+	x bool
+	n int64
+	y bool
+}
+
+type B struct {
+	a [2]A
+	k bool
+	m int64
+}
+
+type Big struct {
+	a, b, c, d, e, f, g int64
+}
+
+type C struct {
+	big Big
+	b   B
+}
+`)
+	out := captureReport(t, func() error { return processFile(path, options{fix: true, all: true}) })
+	if want := "Struct: C (size: 104 bytes (alloc 112), align: 8)\n  big Big (offset: 0, size: 56, align: 8)\n  b B (offset: 56, size: 48, align: 8)\n"; !strings.Contains(out, want) {
+		t.Errorf("report lacks the fixed layout of C:\n%s", out)
+	}
+	src := readFile(t, path)
+	for _, want := range []string{
+		"type A struct {\n\tn int64\n\tx bool\n\ty bool\n}",
+		"type B struct {\n\ta [2]A\n\tk bool\n\tm int64\n}",
+		"type C struct {\n\tbig Big\n\tb   B\n}",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("fixed source lacks %q:\n%s", want, src)
+		}
+	}
+}
+
+func TestFixVisibility(t *testing.T) {
+	path := writeFile(t, "package p\n\ntype T struct {\n\tFlag  bool\n\tcount int64\n\tID    int64\n\tok    bool\n}\n")
+	out := captureReport(t, func() error { return processFile(path, options{fix: true, order: "visibility"}) })
+	if strings.Contains(out, "Exported fields first") {
+		t.Errorf("report gives a cost of ordering exported fields first:\n%s", out)
+	}
+	if want := "type T struct {\n\tID    int64\n\tFlag  bool\n\tok    bool\n\tcount int64\n}"; !strings.Contains(readFile(t, path), want) {
+		t.Errorf("fixed source lacks %q:\n%s", want, readFile(t, path))
+	}
+}
+
+func TestFixKeepFirst(t *testing.T) {
+	path := writeFile(t, "package p\n\ntype T struct {\n\thdr bool\n\ta   int64\n\tb   bool\n\tc   int64\n}\n\n"+
+		"//padding:keep-first=0\ntype U struct {\n\thdr bool\n\ta   int64\n\tb   bool\n\tc   int64\n}\n\n"+
+		"type V struct {\n\thdr bool\n\ta   int64\n\tb   bool\n}\n")
+	out := captureReport(t, func() error { return processFile(path, options{fix: true, keepFirst: 2}) })
+	if want := "Keeping the first 2 fields in place: 8 bytes more than the optimal order"; !strings.Contains(out, want) {
+		t.Errorf("report lacks %q:\n%s", want, out)
+	}
+	src := readFile(t, path)
+	for _, want := range []string{
+		// The directive of U sets the flag aside.
+		"type T struct {\n\thdr bool\n\ta   int64\n\tb   bool\n\tc   int64\n}",
+		"type U struct {\n\ta   int64\n\thdr bool\n\tb   bool\n\tc   int64\n}",
+		"type V struct {\n\thdr bool\n\ta   int64\n\tb   bool\n}",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("fixed source lacks %q:\n%s", want, src)
+		}
+	}
+}
+
+func TestFixEmbedded(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not available")
+	}
+	const src = "package p\n\nimport \"sync\"\n\ntype Base struct{ id int64 }\n\n" +
+		"type T struct {\n\tsync.Mutex\n\tok bool\n\t*Base\n\tn  int32\n\tbuf [2]int64\n}\n"
+	for _, tt := range []struct {
+		moveLocks bool
+		want      string
+	}{
+		// The lock stays first, and no embedded field is lost.
+		{false, "type T struct {\n\tsync.Mutex\n\t*Base\n\tok  bool\n\tn   int32\n\tbuf [2]int64\n}"},
+		// Free to move, the lock, 4-byte aligned, ties with no word
+		// and stays first, where it costs nothing.
+		{true, "type T struct {\n\tsync.Mutex\n\t*Base\n\tok  bool\n\tn   int32\n\tbuf [2]int64\n}"},
+	} {
+		// Only the type checker sizes the lock, so the package is a module.
+		path := writeFile(t, src)
+		writeTree(t, filepath.Dir(path), map[string]string{"go.mod": "module example.com/p\n\ngo 1.22\n"})
+		opts := options{fix: true, tieBreak: "alpha", moveLocks: tt.moveLocks, types: true}
+		captureReport(t, func() error { return processPath(filepath.Dir(path), opts, newFileRegistry()) })
+		if got := readFile(t, path); !strings.Contains(got, tt.want) {
+			t.Errorf("-move-locks=%t: fixed source lacks %q:\n%s", tt.moveLocks, tt.want, got)
+		}
+	}
+}
+
+func TestFixTieBreak(t *testing.T) {
+	const src = "package p\n\ntype T struct {\n\tz bool\n\ty int64\n\ta bool\n\tb int64\n}\n"
+	for _, tt := range []struct {
+		tieBreak string
+		want     string
+	}{
+		{"source", "type T struct {\n\ty int64\n\tz bool\n\ta bool\n\tb int64\n}"},
+		{"alpha", "type T struct {\n\tb int64\n\ta bool\n\tz bool\n\ty int64\n}"},
+	} {
+		path := writeFile(t, src)
+		// A second run leaves the result alone.
+		for range 2 {
+			captureReport(t, func() error { return processFile(path, options{fix: true, tieBreak: tt.tieBreak}) })
+			if got := readFile(t, path); !strings.Contains(got, tt.want) {
+				t.Errorf("-tie-break=%s: fixed source lacks %q:\n%s", tt.tieBreak, tt.want, got)
+			}
+		}
+	}
+}
+
+func TestExplain(t *testing.T) {
+	path := writeFile(t, "package p\n\ntype T struct {\n\tflag  bool\n\tcount int64\n}\n")
+	out := captureReport(t, func() error { return processFile(path, options{explain: true}) })
+	for _, want := range []string{
+		"  7 bytes of padding between `flag bool` and `count int64`, because count requires 8-byte alignment;",
+		"  Layout:\n    offset 0: flag bool (size 1)\n    offset 1–7: padding to align count int64\n",
+		"    offset 8: count int64 (size 8)\n    total 16, a multiple of alignment 8\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestFreeTail(t *testing.T) {
+	path := writeFile(t, "package p\n\ntype T struct {\n\tflag  bool\n\tcount int64\n\tport  int16\n}\n")
+	out := captureReport(t, func() error { return processFile(path, options{freeTail: true}) })
+	if want := "  Free tail: 6 bytes, room for a 4-byte field without growing the struct; 5 with the optimal order\n"; !strings.Contains(out, want) {
+		t.Errorf("report lacks %q:\n%s", want, out)
+	}
+}
+
+func BenchmarkApplyFixes(b *testing.B) {
+	var src strings.Builder
+	src.WriteString("package bench\n\n")
+	for i := 0; i < 3000; i++ {
+		fmt.Fprintf(&src, "type S%d struct {\n\tA bool\n\tB int64\n\tC int16\n}\n\n", i)
+	}
+	path := filepath.Join(b.TempDir(), "bench.go")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		if err := os.WriteFile(path, []byte(src.String()), 0644); err != nil {
+			b.Fatal(err)
+		}
+		f, err := loadFile(path, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for j := range f.Structs {
+			f.Structs[j] = padding.Optimal(f.Structs[j])
+		}
+		b.StartTimer()
+
+		if err := applyFixes(f, f.Structs, replaceFile); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkProcessFile(b *testing.B) {
+	var src strings.Builder
+	src.WriteString("package bench\n\nimport \"time\"\n\n")
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&src, "// S%d is a struct.\ntype S%d struct {\n\tA bool `json:\"a\"`\n\tB map[string][]*time.Time\n\tC, D int16 // c and d\n\tE []byte\n\tF *S%d\n\tG string\n\tH float64\n}\n\n", i, i, i)
+	}
+	path := filepath.Join(b.TempDir(), "bench.go")
+	if err := os.WriteFile(path, []byte(src.String()), 0644); err != nil {
+		b.Fatal(err)
+	}
+
+	devNull, err := os.Create(os.DevNull)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer devNull.Close()
+	saved := stdout
+	stdout = bufio.NewWriter(devNull)
+	defer func() { stdout = saved }()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := processFile(path, options{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestFixMarshalOrderWarning(t *testing.T) {
+	path := writeFile(t, "package p\n\n"+
+		"type Tagged struct {\n\tOK bool `json:\"ok\"`\n\tID int64 `json:\"id\"`\n\tOn bool `json:\"on\"`\n}\n\n"+
+		"type Optimal struct {\n\tID int64 `json:\"id\"`\n\tOK bool  `json:\"ok\"`\n}\n\n"+
+		"type Untagged struct {\n\tOK bool\n\tID int64\n\tOn bool\n}\n")
+	out := captureReport(t, func() error { return processFile(path, options{fix: true}) })
+	if n := strings.Count(out, "changes the order its fields are marshaled in"); n != 1 {
+		t.Errorf("report warns %d times, want once:\n%s", n, out)
+	}
+	if want := path + ": reordering Tagged changes the order its fields are marshaled in (json tags)\n"; !strings.Contains(out, want) {
+		t.Errorf("report lacks %q:\n%s", want, out)
+	}
+}
+
+func TestFixFreeComments(t *testing.T) {
+	const sections = "type Sections struct {\n\tok bool\n\n\t// Counters.\n\n\tn int64\n\tb bool\n}\n"
+	path := writeFile(t, "package p\n\n"+sections+"\n"+
+		"type Trailing struct {\n\tok bool\n\tn  int64\n\tb  bool\n\n\t// More to come.\n}\n")
+	log := new(fixLog)
+	out := captureReport(t, func() error { return processFile(path, options{fix: true, fixLog: log}) })
+
+	if want := path + ": not reordering Sections: a comment of its body belongs to no field, and would end up above another\n"; !strings.Contains(out, want) {
+		t.Errorf("report lacks %q:\n%s", want, out)
+	}
+	if strings.Contains(out, "not reordering Trailing") {
+		t.Errorf("report warns about Trailing, whose comment stays last:\n%s", out)
+	}
+	src := readFile(t, path)
+	if !strings.Contains(src, sections) {
+		t.Errorf("Sections was reordered:\n%s", src)
+	}
+	if want := "type Trailing struct {\n\tn  int64\n\tok bool\n\tb  bool\n\n\t// More to come.\n}"; !strings.Contains(src, want) {
+		t.Errorf("Trailing was not reordered:\n%s", src)
+	}
+	i := slices.IndexFunc(log.structs, func(r fixRecord) bool { return r.Struct == "Sections" })
+	if i < 0 || log.structs[i].Status != fixSkipped || log.structs[i].Code != "free_comment" {
+		t.Errorf("fix log records Sections as %+v, want skipped as free_comment", log.structs)
+	}
+}
+
+func TestFixReflectionByPosition(t *testing.T) {
+	root := t.TempDir()
+	if err := os.CopyFS(root, os.DirFS(filepath.Join("testdata", "reflection"))); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(root, "reflection.go")
+	log := new(fixLog)
+	out := captureReport(t, func() error {
+		return processPath(root, options{fix: true, fixLog: log}, newFileRegistry())
+	})
+
+	want := path + ": not reordering Record: reflection indexes its fields by position at " +
+		path + ":22, " + path + ":26\n"
+	if !strings.Contains(out, want) {
+		t.Errorf("report lacks %q:\n%s", want, out)
+	}
+	if strings.Contains(out, "not reordering Entry") {
+		t.Errorf("report warns about Entry, read by name only:\n%s", out)
+	}
+	src := readFile(t, path)
+	if want := "type Record struct {\n\tok bool\n\tid int64\n\ton bool\n}"; !strings.Contains(src, want) {
+		t.Errorf("Record was reordered:\n%s", src)
+	}
+	if want := "type Entry struct {\n\tid int64\n"; !strings.Contains(src, want) {
+		t.Errorf("Entry was not reordered:\n%s", src)
+	}
+	for _, r := range log.structs {
+		if r.Struct == "Record" && (r.Status != fixSkipped || !strings.HasPrefix(r.Reason, "reflection indexes its fields by position")) {
+			t.Errorf("fix log records Record as %s (%q), want skipped", r.Status, r.Reason)
+		}
+	}
+}
+
+func TestFixGenerics(t *testing.T) {
+	root := t.TempDir()
+	if err := os.CopyFS(root, os.DirFS(filepath.Join("testdata", "generics"))); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(root, "generics.go")
+	log := new(fixLog)
+	out := captureReport(t, func() error {
+		return processPath(root, options{fix: true, generics: true, fixLog: log}, newFileRegistry())
+	})
+
+	for _, want := range []string{
+		"  One order suits all 3 instantiations: hot, val, key\n" +
+			"    Entry[[3]int64]: 48 bytes\n    Entry[bool]: 24 bytes\n    Entry[int64]: 32 bytes\n",
+		"  No order is optimal for all 2 instantiations; the best wastes up to 8 bytes: n, flag, key, val\n" +
+			"    Pair[bool, int64]: 16 bytes\n    Pair[int64, bool]: 24 bytes, optimal 16\n",
+		path + ": not reordering Pair: no order is optimal for all its instantiations; the best wastes 8 bytes in one\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report lacks %q:\n%s", want, out)
+		}
+	}
+	src := readFile(t, path)
+	if want := "type Entry[V any] struct {\n\thot bool\n\tval V\n\tkey string\n}"; !strings.Contains(src, want) {
+		t.Errorf("Entry was not given the common order:\n%s", src)
+	}
+	if want := "type Pair[K, V any] struct {\n\tn    int32\n\tflag bool\n\tkey  K\n\tval  V\n}"; !strings.Contains(src, want) {
+		t.Errorf("Pair was reordered:\n%s", src)
+	}
+	for _, r := range log.structs {
+		if r.Struct == "Pair" && r.Status != fixSkipped {
+			t.Errorf("fix log records Pair as %s, want skipped", r.Status)
+		}
+	}
+
+	// With slack for the trade-off, Pair is fixed too.
+	out = captureReport(t, func() error {
+		return processPath(root, options{fix: true, generics: true, genericSlack: 8}, newFileRegistry())
+	})
+	if strings.Contains(out, "not reordering Pair") {
+		t.Errorf("report warns about Pair within the slack:\n%s", out)
+	}
+}
+
+func TestFixIdempotent(t *testing.T) {
+	root := t.TempDir()
+	if err := os.CopyFS(root, os.DirFS(filepath.Join("testdata", "json"))); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(root, "fixture.go")
+	fix := func() *fixLog {
+		log := new(fixLog)
+		captureReport(t, func() error { return processPath(root, options{fix: true, fixLog: log}, newFileRegistry()) })
+		return log
+	}
+	if sum := fix().summary(); sum.Fixed == 0 {
+		t.Fatalf("first fix fixed no struct: %+v", sum)
+	}
+	fixed := readFile(t, path)
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The second fix finds every struct optimal and writes nothing.
+	if sum := fix().summary(); sum.Fixed != 0 || sum.Moved != 0 {
+		t.Errorf("second fix changed structs: %+v", sum)
+	}
+	if again := readFile(t, path); again != fixed {
+		t.Errorf("second fix changed the source:\n%s", again)
+	}
+	if again, err := os.Stat(path); err != nil || !again.ModTime().Equal(info.ModTime()) {
+		t.Errorf("second fix rewrote the file (%v)", err)
+	}
+}
+
+func TestPad(t *testing.T) {
+	const src = "package demo\n\ntype H struct {\n\tmagic uint8\n\tsize  uint32\n\tkind  byte\n}\n\nvar h = H{1, 2, 3}\n"
+	const want = "package demo\n\ntype H struct {\n\tmagic uint8\n\t_     [3]byte // padding\n\tsize  uint32\n\tkind  byte\n\t_     [3]byte // padding\n}\n\nvar h = H{1, 2, 3}\n"
+
+	// Its unkeyed literal would break, as it does reordering.
+	if code, out := runStdin(t, src, "-pad", "-"); code != 0 || out != src {
+		t.Errorf("exit status %d, padded source:\n%s\nwant it unchanged", code, out)
+	}
+	keyed := strings.Replace(src, "H{1, 2, 3}", "H{magic: 1, size: 2, kind: 3}", 1)
+	code, out := runStdin(t, keyed, "-pad", "-")
+	if want := strings.Replace(want, "H{1, 2, 3}", "H{magic: 1, size: 2, kind: 3}", 1); code != 0 || out != want {
+		t.Fatalf("exit status %d, padded source:\n%s\nwant:\n%s", code, out, want)
+	}
+	if code, again := runStdin(t, out, "-pad", "-"); code != 0 || again != out {
+		t.Errorf("padding again gives:\n%s", again)
+	}
+
+	for _, args := range [][]string{{"-pad", "-fix"}, {"-pad", "-check"}, {"-pad", "-tie-break=alpha"}, {"-pad", "-gc-order"}} {
+		if code, _ := runStdin(t, src, append(args, "-")...); code != 2 {
+			t.Errorf("%v: exit status %d, want 2", args, code)
+		}
+	}
+}
+
+func TestFixMultiName(t *testing.T) {
+	const src = "package demo\n\ntype T struct {\n\tok   bool\n\tz, a int64 `json:\"v\"` // pair\n\tb    bool\n}\n"
+	const want = "package demo\n\ntype T struct {\n\ta, z int64 `json:\"v\"` // pair\n\tb    bool\n\tok   bool\n}\n"
+	code, out := runStdin(t, src, "-fix", "-tie-break=alpha", "-")
+	if code != 0 || out != want {
+		t.Fatalf("exit status %d, fixed source:\n%s\nwant:\n%s", code, out, want)
+	}
+	if code, again := runStdin(t, out, "-fix", "-tie-break=alpha", "-"); code != 0 || again != out {
+		t.Errorf("fixing again gives:\n%s", again)
+	}
+}
+
+func TestFixInline(t *testing.T) {
+	const src = "package demo\n\ntype Record struct {\n\ta    bool\n\tMeta struct{ Flag bool; Created int64; Seen bool }\n" +
+		"\tnext *struct {\n\t\tok bool\n\t\tn  int64\n\t\tz  bool\n\t}\n\tb bool\n}\n"
+	// Meta is laid out in 16 bytes once fixed, which fix sizes Record
+	// with; the struct behind the pointer is only reported.
+	const want = "package demo\n\ntype Record struct {\n\tMeta struct {\n\t\tCreated int64\n\t\tFlag    bool\n\t\tSeen    bool\n\t}\n" +
+		"\tnext *struct {\n\t\tok bool\n\t\tn  int64\n\t\tz  bool\n\t}\n\ta bool\n\tb bool\n}\n"
+	code, out := runStdin(t, src, "-fix", "-")
+	if code != 0 || out != want {
+		t.Fatalf("exit status %d, fixed source:\n%s\nwant:\n%s", code, out, want)
+	}
+	if code, again := runStdin(t, out, "-fix", "-"); code != 0 || again != out {
+		t.Errorf("fixing again gives:\n%s", again)
+	}
+}
+
+func TestFixTypeParams(t *testing.T) {
+	const src = "package demo\n\ntype Pair[K comparable, V any] struct {\n\tok  bool\n\tkey K\n\tn   int64\n\tval V\n}\n"
+	code, out := runStdin(t, src, "-all", "-")
+	if code != 0 {
+		t.Fatalf("exit status %d, want 0", code)
+	}
+	for _, want := range []string{
+		"Struct: Pair (size depends on type parameters)\n",
+		"  key K (size: ?)\n",
+		"  n int64 (size: 8, align: 8)\n",
+		"Summary: 1 file scanned, 1 struct, 0 wasting padding",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report lacks %q:\n%s", want, out)
+		}
+	}
+	if code, out := runStdin(t, src, "-fix", "-"); code != 0 || out != src {
+		t.Errorf("exit status %d, fixed source:\n%s\nwant it unchanged", code, out)
+	}
+}
+
+func TestFixCacheLineOrder(t *testing.T) {
+	const src = "package demo\n\ntype W struct {\n\tflag bool\n\tb    int64\n\ta    [12]byte\n\tc    [3]byte\n}\n"
+	for _, tt := range []struct {
+		args   []string
+		fields string
+	}{
+		// The optimal order puts a across the line boundary at 16.
+		{nil, "\tb    int64\n\tflag bool\n\ta    [12]byte\n\tc    [3]byte\n"},
+		{[]string{"-cacheline-order"}, "\tflag bool\n\ta    [12]byte\n\tc    [3]byte\n\tb    int64\n"},
+	} {
+		code, out := runStdin(t, src, append(append([]string{"-fix", "-cacheline-size", "16"}, tt.args...), "-")...)
+		if code != 0 || !strings.Contains(out, tt.fields) {
+			t.Errorf("%v: exit status %d, fixed source:\n%s\nwant fields:\n%s", tt.args, code, out, tt.fields)
+		}
+	}
+}
+
+func TestFixCacheLinePads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counters.go")
+	if err := os.WriteFile(path, []byte(readFile(t, filepath.Join("testdata", "cachepad", "counters.go"))), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out := captureReport(t, func() error { return processFile(path, options{fix: true, cacheLine: 64}) })
+
+	if want := "  _ cpu.CacheLinePad (offset: 16, size: 64, align: 1, cache-line pad)\n"; !strings.Contains(out, want) {
+		t.Errorf("report lacks %q:\n%s", want, out)
+	}
+	want := "  Cache-line pad _ [64]byte at offset 58 is redundant once reordered: last and next are on different 64-byte cache lines without it\n"
+	if !strings.Contains(out, want) {
+		t.Errorf("report lacks %q:\n%s", want, out)
+	}
+	if n := strings.Count(out, "Cache-line pad _"); n != 1 {
+		t.Errorf("report finds %d redundant pads, want 1:\n%s", n, out)
+	}
+
+	// Each pad keeps separating the fields it was placed between.
+	src := readFile(t, path)
+	for _, want := range []string{
+		"\treads   int64\n\t_       cpu.CacheLinePad\n\twrites  int64\n\tflag    bool\n\tother   bool\n\t_       [64]byte\n\terrs    int64\n",
+		// The counters declared together stay so.
+		"\ta, b, c, d, e, f, g int64\n\tfirst               bool\n\tlast                bool\n\t_                   [64]byte\n\tnext                int64\n",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("fixed source lacks %q:\n%s", want, src)
+		}
+	}
+}
+
+func TestRunPositions(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"a/config.go": "package a\n\ntype Config struct {\n\tok   bool\n\tsize int64\n\tdone bool\n}\n",
+		"b/config.go": "package b\n\n// Config is padded too.\ntype Config struct {\n\tok bool\n\tn  int32\n\tx  bool\n}\n",
+	})
+	t.Chdir(dir)
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"-positions", "."}, []string{
+			"a/config.go:3:6: struct Config (size: 24 bytes",
+			"\n  ok bool (offset: 0",
+			"b/config.go:4:6: struct Config (size: 12 bytes",
+		}},
+		{[]string{"-positions", "-verbose", "."}, []string{
+			"a/config.go:3:6: struct Config (size: 24 bytes",
+			"\n  a/config.go:5:2: size int64 (offset: 8",
+			"\n  b/config.go:6:2: n int32 (offset: 4",
+		}},
+		{[]string{"-positions", filepath.Join(dir, "b")}, []string{
+			filepath.Join(dir, "b", "config.go") + ":4:6: struct Config",
+		}},
+	}
+	for _, tt := range tests {
+		code, out := runCaptured(t, tt.args...)
+		if code != 0 {
+			t.Fatalf("%q: exit code %d:\n%s", tt.args, code, out)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(out, want) {
+				t.Errorf("%q: report lacks %q:\n%s", tt.args, want, out)
+			}
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// reportCollector gathers the reports of the structs of a run for the
+// formats that are rendered once the run is complete. It is safe for
+// concurrent use.
+type reportCollector struct {
+	mu      sync.Mutex
+	structs []padding.StructReport
+	globals []padding.GlobalReport
+
+	// estimated holds the fields whose sizes were guessed, by type.
+	estimated map[string][]fieldPosition
+
+	// allPlatforms is set when the files of every platform were
+	// analyzed, with -all-platforms, rather than those of one GOOS.
+	allPlatforms bool
+}
+
+func (c *reportCollector) add(r padding.StructReport) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.structs = append(c.structs, r)
+}
+
+// merge adds everything o collected, after what c holds.
+func (c *reportCollector) merge(o *reportCollector) {
+	c.mu.Lock()
+	c.structs = append(c.structs, o.structs...)
+	c.mu.Unlock()
+	c.addGlobals(o.globals)
+	c.addEstimated(o.estimated)
+}
+
+// addGlobals adds the package-level variables of a path; those of a
+// package given twice are kept once.
+func (c *reportCollector) addGlobals(globals []padding.GlobalReport) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, g := range globals {
+		if !slices.Contains(c.globals, g) {
+			c.globals = append(c.globals, g)
+		}
+	}
+}
+
+// addEstimated adds the fields of a package whose sizes were guessed.
+func (c *reportCollector) addEstimated(fields map[string][]fieldPosition) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.estimated == nil {
+		c.estimated = make(map[string][]fieldPosition)
+	}
+	for typ, positions := range fields {
+		c.estimated[typ] = append(c.estimated[typ], positions...)
+	}
+}
+
+func (c *reportCollector) report() padding.Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r := padding.NewReport(slices.Clone(c.structs)...)
+	r.StaticFootprint = sortGlobals(c.globals)
+	r.EstimatedTypes = estimatedTypes(c.estimated)
+	r.Compiler = goBuild.compiler
+	r.Arch = goBuild.arch
+	if !c.allPlatforms {
+		r.OS = goBuild.goos
+	}
+	return r
+}
+
+// metricLabel is a label added to every metric, given with -metrics-label.
+type metricLabel struct {
+	name, value string
+}
+
+// labelNameRE matches the label names OpenMetrics allows.
+var labelNameRE = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// metricLabels is the flag.Value of the repeatable -metrics-label flag.
+type metricLabels []metricLabel
+
+func (l *metricLabels) String() string {
+	var parts []string
+	for _, label := range *l {
+		parts = append(parts, label.name+"="+label.value)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (l *metricLabels) Set(s string) error {
+	name, value, ok := strings.Cut(s, "=")
+	switch {
+	case !ok:
+		return fmt.Errorf("invalid label %q: want name=value", s)
+	case !labelNameRE.MatchString(name) || strings.HasPrefix(name, "__"):
+		return fmt.Errorf("invalid label name %q", name)
+	case name == "package" || name == "struct" || name == "variant":
+		return fmt.Errorf("label name %q is reserved", name)
+	}
+	for _, label := range *l {
+		if label.name == name {
+			return fmt.Errorf("duplicate label %q", name)
+		}
+	}
+	*l = append(*l, metricLabel{name, value})
+	return nil
+}
+
+// writeMetrics writes r to w in the OpenMetrics text format: the size, the
+// wasted bytes and the padding between fields and after the last one of
+// each struct, and totals for the run. Structs are
+// labeled with their package directory, which unlike the package name is
+// unique, their name, and their build variants if any. Of several structs
+// with the same labels, such as types of the same name declared in
+// different functions, only the first is reported on its own, but all of
+// them count towards the totals. The static labels are added to every
+// sample.
+func writeMetrics(w io.Writer, r padding.Report, static []metricLabel) error {
+	type sample struct {
+		labels                       string
+		size, optimal, packed, waste int64
+		interField, trailing         int64
+	}
+	var samples []sample
+	seen := make(map[string]bool)
+	var totalWaste, totalInterField, totalTrailing, suboptimal int64
+	for _, s := range r.Structs {
+		totalWaste += s.WastedBytes
+		totalInterField += s.InterFieldPadding
+		totalTrailing += s.TrailingPadding
+		if s.WastedBytes > 0 {
+			suboptimal++
+		}
+		labels := []metricLabel{
+			{"package", path.Dir(filepath.ToSlash(s.File))},
+			{"struct", s.Name},
+		}
+		if len(s.Variants) > 0 {
+			labels = append(labels, metricLabel{"variant", strings.Join(s.Variants, ", ")})
+		}
+		text := formatLabels(append(labels, static...))
+		if seen[text] {
+			continue
+		}
+		seen[text] = true
+		samples = append(samples, sample{text, s.Size, s.OptimalSize, s.PackedSize, s.WastedBytes, s.InterFieldPadding, s.TrailingPadding})
+	}
+	slices.SortFunc(samples, func(a, b sample) int { return cmp.Compare(a.labels, b.labels) })
+	runLabels := formatLabels(static)
+
+	var b strings.Builder
+	family := func(name, unit, help string) {
+		fmt.Fprintf(&b, "# TYPE %s gauge\n", name)
+		if unit != "" {
+			fmt.Fprintf(&b, "# UNIT %s %s\n", name, unit)
+		}
+		fmt.Fprintf(&b, "# HELP %s %s\n", name, help)
+	}
+	family("padding_size_struct_bytes", "bytes", "Size of the struct.")
+	for _, s := range samples {
+		fmt.Fprintf(&b, "padding_size_struct_bytes%s %d\n", s.labels, s.size)
+	}
+	family("padding_size_optimal_bytes", "bytes", "Size of the struct with the optimal field order.")
+	for _, s := range samples {
+		fmt.Fprintf(&b, "padding_size_optimal_bytes%s %d\n", s.labels, s.optimal)
+	}
+	family("padding_size_packed_bytes", "bytes", "Sum of the field sizes of the struct, which no field order gets below.")
+	for _, s := range samples {
+		fmt.Fprintf(&b, "padding_size_packed_bytes%s %d\n", s.labels, s.packed)
+	}
+	family("padding_size_wasted_bytes", "bytes", "Bytes of the struct that the optimal field order saves.")
+	for _, s := range samples {
+		fmt.Fprintf(&b, "padding_size_wasted_bytes%s %d\n", s.labels, s.waste)
+	}
+	family("padding_size_inter_field_padding_bytes", "bytes", "Padding between the fields of the struct.")
+	for _, s := range samples {
+		fmt.Fprintf(&b, "padding_size_inter_field_padding_bytes%s %d\n", s.labels, s.interField)
+	}
+	family("padding_size_trailing_padding_bytes", "bytes", "Padding after the last field of the struct.")
+	for _, s := range samples {
+		fmt.Fprintf(&b, "padding_size_trailing_padding_bytes%s %d\n", s.labels, s.trailing)
+	}
+	family("padding_size_total_wasted_bytes", "bytes", "Bytes the optimal field order saves across all analyzed structs.")
+	fmt.Fprintf(&b, "padding_size_total_wasted_bytes%s %d\n", runLabels, totalWaste)
+	family("padding_size_total_inter_field_padding_bytes", "bytes", "Padding between fields across all analyzed structs.")
+	fmt.Fprintf(&b, "padding_size_total_inter_field_padding_bytes%s %d\n", runLabels, totalInterField)
+	family("padding_size_total_trailing_padding_bytes", "bytes", "Padding after the last field across all analyzed structs.")
+	fmt.Fprintf(&b, "padding_size_total_trailing_padding_bytes%s %d\n", runLabels, totalTrailing)
+	family("padding_size_structs", "", "Number of analyzed structs.")
+	fmt.Fprintf(&b, "padding_size_structs%s %d\n", runLabels, len(r.Structs))
+	family("padding_size_suboptimal_structs", "", "Number of analyzed structs whose fields could be reordered to save space.")
+	fmt.Fprintf(&b, "padding_size_suboptimal_structs%s %d\n", runLabels, suboptimal)
+	b.WriteString("# EOF\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// formatLabels returns the label set of a sample, or the empty string if
+// there are no labels.
+func formatLabels(labels []metricLabel) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	parts := make([]string, len(labels))
+	for i, label := range labels {
+		parts[i] = label.name + `="` + escapeLabelValue(label.value) + `"`
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// escapeLabelValue escapes backslashes, double quotes and newlines, the
+// characters a label value cannot hold literally.
+func escapeLabelValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
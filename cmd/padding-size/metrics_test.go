@@ -0,0 +1,255 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestMetrics(t *testing.T) {
+	dir := filepath.Dir(writeFile(t, `package a
+
+type Bad struct {
+	A bool
+	B int64
+	C bool
+}
+
+type Good struct {
+	B int64
+	A bool
+}
+`))
+	err := os.WriteFile(filepath.Join(dir, "b.go"), []byte(`package a
+
+type Worse struct {
+	A bool
+	B int32
+	C bool
+	D int64
+	E bool
+}
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := options{format: "metrics", collect: new(reportCollector)}
+	captureReport(t, func() error { return processPath(dir, opts, newFileRegistry()) })
+	var b strings.Builder
+	static := []metricLabel{{"repo", `a "quoted" \ repo`}, {"branch", "line\nbreak"}}
+	if err := writeMetrics(&b, opts.collect.report(), static); err != nil {
+		t.Fatal(err)
+	}
+	samples := parseOpenMetrics(t, b.String())
+
+	branch, repo := `branch=line`+"\n"+`break`, `repo=a "quoted" \ repo`
+	labels := func(name string) string {
+		return "{" + branch + ",package=" + filepath.ToSlash(dir) + "," + repo + ",struct=" + name + "}"
+	}
+	run := "{" + branch + "," + repo + "}"
+	want := map[string]float64{
+		"padding_size_struct_bytes" + labels("Bad"):                24,
+		"padding_size_struct_bytes" + labels("Good"):               16,
+		"padding_size_struct_bytes" + labels("Worse"):              32,
+		"padding_size_optimal_bytes" + labels("Bad"):               16,
+		"padding_size_optimal_bytes" + labels("Good"):              16,
+		"padding_size_optimal_bytes" + labels("Worse"):             16,
+		"padding_size_packed_bytes" + labels("Bad"):                10,
+		"padding_size_packed_bytes" + labels("Good"):               9,
+		"padding_size_packed_bytes" + labels("Worse"):              15,
+		"padding_size_wasted_bytes" + labels("Bad"):                8,
+		"padding_size_wasted_bytes" + labels("Good"):               0,
+		"padding_size_wasted_bytes" + labels("Worse"):              16,
+		"padding_size_inter_field_padding_bytes" + labels("Bad"):   7,
+		"padding_size_inter_field_padding_bytes" + labels("Good"):  0,
+		"padding_size_inter_field_padding_bytes" + labels("Worse"): 10,
+		"padding_size_trailing_padding_bytes" + labels("Bad"):      7,
+		"padding_size_trailing_padding_bytes" + labels("Good"):     7,
+		"padding_size_trailing_padding_bytes" + labels("Worse"):    7,
+		"padding_size_total_wasted_bytes" + run:                    24,
+		"padding_size_total_inter_field_padding_bytes" + run:       17,
+		"padding_size_total_trailing_padding_bytes" + run:          21,
+		"padding_size_structs" + run:                               3,
+		"padding_size_suboptimal_structs" + run:                    2,
+	}
+	if len(samples) != len(want) {
+		t.Errorf("got %d samples, want %d:\n%s", len(samples), len(want), b.String())
+	}
+	for key, v := range want {
+		if got, ok := samples[key]; !ok || got != v {
+			t.Errorf("%s = %v (present %v), want %v", key, got, ok, v)
+		}
+	}
+}
+
+func TestMetricsDuplicateLabels(t *testing.T) {
+	// Types of the same name declared in different functions.
+	r := padding.NewReport(
+		padding.StructReport{File: "p/a.go", Name: "T", Size: 24, WastedBytes: 8},
+		padding.StructReport{File: "p/b.go", Name: "T", Size: 8},
+		padding.StructReport{File: "p/b.go", Name: "T", Size: 16, Variants: []string{"linux"}},
+	)
+	var b strings.Builder
+	if err := writeMetrics(&b, r, nil); err != nil {
+		t.Fatal(err)
+	}
+	samples := parseOpenMetrics(t, b.String())
+	if got := samples["padding_size_struct_bytes{package=p,struct=T}"]; got != 24 {
+		t.Errorf("size of first T = %v, want 24", got)
+	}
+	if got := samples["padding_size_struct_bytes{package=p,struct=T,variant=linux}"]; got != 16 {
+		t.Errorf("size of linux T = %v, want 16", got)
+	}
+	if got := samples["padding_size_structs"]; got != 3 {
+		t.Errorf("padding_size_structs = %v, want 3", got)
+	}
+}
+
+func TestMetricLabelsFlag(t *testing.T) {
+	var labels metricLabels
+	for _, arg := range []string{"repo=api", "branch=main=x"} {
+		if err := labels.Set(arg); err != nil {
+			t.Errorf("Set(%q) failed: %v", arg, err)
+		}
+	}
+	if want := (metricLabels{{"repo", "api"}, {"branch", "main=x"}}); !slices.Equal(labels, want) {
+		t.Errorf("labels = %v, want %v", labels, want)
+	}
+	for _, arg := range []string{"repo", "1x=a", "__name=a", "a-b=c", "struct=x", "repo=again"} {
+		if err := labels.Set(arg); err == nil {
+			t.Errorf("Set(%q) succeeded", arg)
+		}
+	}
+}
+
+var (
+	metricNameRE = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*`)
+	metadataRE   = regexp.MustCompile(`^# (TYPE|UNIT|HELP) ([a-zA-Z_:][a-zA-Z0-9_:]*) (.*)$`)
+)
+
+// parseOpenMetrics strictly checks that text is a valid OpenMetrics
+// exposition of gauges and returns its samples, keyed by the metric name
+// followed by the unescaped labels sorted by name, as in name{a=x,b=y}.
+func parseOpenMetrics(t *testing.T, text string) map[string]float64 {
+	t.Helper()
+	body, ok := strings.CutSuffix(text, "# EOF\n")
+	if !ok {
+		t.Fatalf("exposition does not end with # EOF:\n%s", text)
+	}
+	samples := make(map[string]float64)
+	families := make(map[string]bool)
+	family := ""
+	for i, line := range strings.Split(strings.TrimSuffix(body, "\n"), "\n") {
+		fail := func(format string, args ...any) {
+			t.Helper()
+			t.Fatalf("line %d: %q: %s\n%s", i+1, line, fmt.Sprintf(format, args...), text)
+		}
+		if strings.HasPrefix(line, "#") {
+			m := metadataRE.FindStringSubmatch(line)
+			if m == nil {
+				fail("invalid metadata line")
+			}
+			switch m[1] {
+			case "TYPE":
+				if families[m[2]] {
+					fail("family declared twice")
+				}
+				if m[3] != "gauge" {
+					fail("type %s, want gauge", m[3])
+				}
+				families[m[2]] = true
+				family = m[2]
+			case "UNIT":
+				if m[2] != family || !strings.HasSuffix(family, "_"+m[3]) {
+					fail("unit does not match the family")
+				}
+			case "HELP":
+				if m[2] != family {
+					fail("help for another family")
+				}
+			}
+			continue
+		}
+
+		name := metricNameRE.FindString(line)
+		if name == "" || name != family {
+			fail("sample outside its family %q", family)
+		}
+		rest := line[len(name):]
+		var labels []string
+		if strings.HasPrefix(rest, "{") {
+			rest = rest[1:]
+			for {
+				label := labelNameRE.FindString(strings.SplitN(rest, "=", 2)[0])
+				if label == "" || !strings.HasPrefix(rest[len(label):], `="`) {
+					fail("invalid label at %q", rest)
+				}
+				rest = rest[len(label)+2:]
+				var value strings.Builder
+				for {
+					if rest == "" || rest[0] == '\n' {
+						fail("unterminated label value")
+					}
+					c := rest[0]
+					rest = rest[1:]
+					if c == '"' {
+						break
+					}
+					if c == '\\' {
+						switch {
+						case strings.HasPrefix(rest, `\`), strings.HasPrefix(rest, `"`):
+							c = rest[0]
+						case strings.HasPrefix(rest, "n"):
+							c = '\n'
+						default:
+							fail("invalid escape in label value")
+						}
+						rest = rest[1:]
+					}
+					value.WriteByte(c)
+				}
+				for _, l := range labels {
+					if strings.HasPrefix(l, label+"=") {
+						fail("duplicate label %s", label)
+					}
+				}
+				labels = append(labels, label+"="+value.String())
+				if strings.HasPrefix(rest, "}") {
+					rest = rest[1:]
+					break
+				}
+				if !strings.HasPrefix(rest, ",") {
+					fail("invalid label separator at %q", rest)
+				}
+				rest = rest[1:]
+			}
+		}
+		value, ok := strings.CutPrefix(rest, " ")
+		if !ok {
+			fail("missing value")
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			fail("invalid value: %v", err)
+		}
+
+		slices.Sort(labels)
+		key := name
+		if len(labels) > 0 {
+			key += "{" + strings.Join(labels, ",") + "}"
+		}
+		if _, ok := samples[key]; ok {
+			fail("duplicate sample")
+		}
+		samples[key] = v
+	}
+	return samples
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"go/types"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// nestedPadding holds the padding of package-level struct types and its
+// sources among the struct types nested in them, laid out with type
+// information. Types without nested padding are left out.
+type nestedPadding map[structKey]paddingSources
+
+// paddingSources is the padding of a struct, in all, and the share of each
+// nested struct type.
+type paddingSources struct {
+	total  int64
+	nested []padding.NestedPaddingReport
+}
+
+// findNestedPadding type-checks the package in dir, and with recursive the
+// packages below it as well, and traces the padding of their package-level
+// struct types to the nested struct types leaving it. Generic types are left
+// out, since they are laid out only once instantiated.
+func findNestedPadding(dir string, recursive bool) (nestedPadding, error) {
+	pkgs, loadErr := loadPackages(dir, recursive, packages.NeedTypes|packages.NeedTypesSizes)
+
+	cwd, _ := os.Getwd()
+	sources := make(nestedPadding)
+	for _, pkg := range pkgs {
+		if pkg.Types == nil || pkg.TypesSizes == nil {
+			continue
+		}
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			obj, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || obj.IsAlias() {
+				continue
+			}
+			st, ok := obj.Type().Underlying().(*types.Struct)
+			if !ok {
+				continue
+			}
+			if named, ok := obj.Type().(*types.Named); ok && named.TypeParams().Len() > 0 {
+				continue
+			}
+			total, nested := padding.PaddingSources(st, pkg.TypesSizes)
+			if len(nested) == 0 {
+				continue
+			}
+			s := paddingSources{total: total}
+			for _, n := range nested {
+				pos := pkg.Fset.Position(n.Type.Pos())
+				file := pos.Filename
+				if rel, err := filepath.Rel(cwd, file); err == nil && filepath.IsLocal(rel) {
+					file = rel
+				}
+				s.nested = append(s.nested, padding.NestedPaddingReport{
+					Type:     types.TypeString(n.Type.Type(), types.RelativeTo(pkg.Types)),
+					Position: fmt.Sprintf("%s:%d", filepath.ToSlash(file), pos.Line),
+					Bytes:    n.Bytes,
+				})
+			}
+			key := structKey{realDir(pkg.Fset.Position(obj.Pos()).Filename), obj.Name()}
+			sources[key] = s
+		}
+	}
+	return sources, loadErr
+}
+
+// weigh sets the padding sources of r, a package-level struct.
+func (n nestedPadding) weigh(r *padding.StructReport) {
+	if s, ok := n[structKey{realDir(r.File), r.Name}]; ok {
+		r.PaddingBytes, r.NestedPadding = s.total, s.nested
+	}
+}
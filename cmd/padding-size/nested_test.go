@@ -0,0 +1,40 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestNestedPadding(t *testing.T) {
+	dir := filepath.Join("testdata", "nested")
+	opts := options{collect: new(reportCollector), nested: true}
+	report := captureReport(t, func() error { return processPath(dir, opts, newFileRegistry()) })
+
+	inner := "testdata/nested/inner.go:4"
+	flags := "testdata/nested/inner.go:11"
+	type sources struct {
+		total  int64
+		nested []padding.NestedPaddingReport
+	}
+	want := map[string]sources{
+		"Outer": {31, []padding.NestedPaddingReport{{Type: "Inner", Position: inner, Bytes: 28}}},
+		"Grid": {50, []padding.NestedPaddingReport{
+			{Type: "Inner", Position: inner, Bytes: 28},
+			{Type: "Flags", Position: flags, Bytes: 15},
+		}},
+		"Inner": {},
+		"Flags": {},
+	}
+	for _, s := range opts.collect.report().Structs {
+		if got := (sources{s.PaddingBytes, s.NestedPadding}); !reflect.DeepEqual(got, want[s.Name]) {
+			t.Errorf("%s: %+v, want %+v", s.Name, got, want[s.Name])
+		}
+	}
+	if line := "  28 of 31 wasted bytes come from Inner (" + inner + ")\n"; !strings.Contains(report, line) {
+		t.Errorf("report lacks %q:\n%s", line, report)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// structSelection is the set of struct type names given to -only, to which
+// fix is limited. A nil selection holds every struct.
+type structSelection map[string]bool
+
+// parseSelection parses the comma-separated names of -only.
+func parseSelection(list string) structSelection {
+	sel := make(structSelection)
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			sel[name] = true
+		}
+	}
+	return sel
+}
+
+// contains reports whether s is selected: always with a nil selection, and
+// otherwise if s is a named struct type listed in it.
+func (sel structSelection) contains(s *padding.StructInfo) bool {
+	return sel == nil || !s.Anonymous && sel[s.Name]
+}
+
+// check returns an error if a name of sel is not the name of a struct type
+// declared in the Go files of paths, files or directories walked like
+// processPath does, before anything is fixed.
+func (sel structSelection) check(paths []string) error {
+	kinds := make(map[string]string) // "struct", or another kind of type
+	fset := token.NewFileSet()
+	parse := func(path string) error {
+		file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return err
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			spec, ok := n.(*ast.TypeSpec)
+			if !ok || !sel[spec.Name.Name] {
+				return true
+			}
+			if _, ok := spec.Type.(*ast.StructType); ok {
+				kinds[spec.Name.Name] = "struct"
+			} else if kinds[spec.Name.Name] == "" {
+				kinds[spec.Name.Name] = "other"
+			}
+			return true
+		})
+		return nil
+	}
+	for _, path := range paths {
+		err := filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() || !strings.HasSuffix(filePath, ".go") && filePath != path {
+				return nil
+			}
+			return parse(filePath)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	var names []string
+	for name := range sel {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		switch kinds[name] {
+		case "":
+			return fmt.Errorf("-only: no type %s declared in the given files", name)
+		case "other":
+			return fmt.Errorf("-only: %s is not a struct type", name)
+		}
+	}
+	return nil
+}
+
+// applySelectedFixes writes f back, with replace, with the structs of sel
+// reordered as in f.Structs, leaving everything outside their declarations,
+// and those of the structs in holding, byte-identical.
+func applySelectedFixes(f *FileResult, sel structSelection, holding map[*padding.StructInfo]string, replace func(path string, orig, data []byte) error) error {
+	var selected []padding.StructInfo
+	for i := range f.Structs {
+		if s := &f.Structs[i]; sel.contains(s) && !s.ReportOnly && holding[s] == "" {
+			selected = append(selected, *s)
+		}
+	}
+	if len(selected) == 0 {
+		return nil
+	}
+	data, err := padding.Rewrite(f.Fset, f.Node, f.Src, selected)
+	if err != nil {
+		return err
+	}
+	return replace(f.Path, f.Src, data)
+}
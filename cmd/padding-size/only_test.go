@@ -0,0 +1,80 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFixOnly(t *testing.T) {
+	// Other has formatting gofmt would change, so rewriting the whole file
+	// would show.
+	src := `package p
+
+type ConnState struct {
+	open  bool
+	id    int64
+	ready bool
+}
+
+type Other struct {
+	a   bool // first
+	b  int64
+	c bool
+}
+
+type bufferPool struct {
+	size int32
+	free []byte
+	n    int32
+}
+`
+	path := writeFile(t, src)
+	opts := options{fix: true, only: parseSelection("ConnState, bufferPool")}
+	captureReport(t, func() error { return processFile(path, opts) })
+
+	want := `package p
+
+type ConnState struct {
+	id    int64
+	open  bool
+	ready bool
+}
+
+type Other struct {
+	a   bool // first
+	b  int64
+	c bool
+}
+
+type bufferPool struct {
+	free []byte
+	size int32
+	n    int32
+}
+`
+	if got := readFile(t, path); got != want {
+		t.Errorf("fixed:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestCheckSelection(t *testing.T) {
+	path := writeFile(t, "package p\n\ntype A struct{ x int }\n\ntype B int\n")
+	dir := filepath.Dir(path)
+	tests := []struct {
+		only string
+		err  string
+	}{
+		{"A", ""},
+		{"A,B", "-only: B is not a struct type"},
+		{"A,C", "-only: no type C declared in the given files"},
+	}
+	for _, tt := range tests {
+		var got string
+		if err := parseSelection(tt.only).check([]string{dir}); err != nil {
+			got = err.Error()
+		}
+		if got != tt.err {
+			t.Errorf("-only=%s: error %q, want %q", tt.only, got, tt.err)
+		}
+	}
+}
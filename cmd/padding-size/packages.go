@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// typedStruct is a package-level struct type with its layout as computed by
+// the type checker.
+type typedStruct struct {
+	name   string
+	size   int64
+	align  int64
+	fields []padding.FieldLayout
+}
+
+// loadStructs type-checks the package in dir for arch, or for the go
+// command's default architecture if arch is empty, and returns its name and
+// the layouts of its non-generic package-level struct types, sorted by name.
+// If names is not empty, only those types are returned; naming a type that
+// is not a struct is an error.
+func loadStructs(dir, arch string, names []string) (string, []typedStruct, error) {
+	cfg := goBuild.config(dir, packages.NeedName|packages.NeedTypes|packages.NeedSyntax|packages.NeedTypesInfo|packages.NeedTypesSizes)
+	if arch != "" {
+		if types.SizesFor("gc", arch) == nil {
+			return "", nil, fmt.Errorf("unknown architecture %q", arch)
+		}
+		cfg.Env = append(os.Environ(), "GOARCH="+arch)
+	}
+	pkgs, err := goBuild.load(cfg, ".")
+	if err != nil {
+		return "", nil, err
+	}
+	if len(pkgs) != 1 {
+		return "", nil, fmt.Errorf("%s: expected one package, found %d", dir, len(pkgs))
+	}
+	pkg := pkgs[0]
+	if len(pkg.Errors) > 0 {
+		return "", nil, pkg.Errors[0]
+	}
+
+	structs := packageStructs(pkg)
+	if len(names) > 0 {
+		byName := make(map[string]typedStruct)
+		for _, s := range structs {
+			byName[s.name] = s
+		}
+		structs = structs[:0]
+		for _, name := range names {
+			s, ok := byName[name]
+			if !ok {
+				return "", nil, fmt.Errorf("%s: no struct type %s", dir, name)
+			}
+			structs = append(structs, s)
+		}
+	}
+	slices.SortFunc(structs, func(a, b typedStruct) int {
+		return strings.Compare(a.name, b.name)
+	})
+	structs = slices.CompactFunc(structs, func(a, b typedStruct) bool {
+		return a.name == b.name
+	})
+	if len(structs) == 0 {
+		return "", nil, fmt.Errorf("%s: no struct types", dir)
+	}
+	return pkg.Name, structs, nil
+}
+
+// packageStructs returns the non-generic struct types declared at package
+// level in pkg, with their layouts.
+func packageStructs(pkg *packages.Package) []typedStruct {
+	var structs []typedStruct
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				spec, ok := spec.(*ast.TypeSpec)
+				if !ok || spec.TypeParams != nil || spec.Assign.IsValid() {
+					continue
+				}
+				obj := pkg.TypesInfo.Defs[spec.Name]
+				if obj == nil || spec.Name.Name == "_" {
+					continue
+				}
+				st, ok := obj.Type().Underlying().(*types.Struct)
+				if !ok {
+					continue
+				}
+				fields, layout := padding.Layout(st, pkg.TypesSizes)
+				structs = append(structs, typedStruct{
+					name:   spec.Name.Name,
+					size:   layout.Size,
+					align:  layout.Align,
+					fields: fields,
+				})
+			}
+		}
+	}
+	return structs
+}
+
+// goarch returns the architecture the go command builds for in dir.
+func goarch(dir string) (string, error) {
+	cmd := exec.Command("go", "env", "GOARCH")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("go env GOARCH: %v", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+)
+
+// processDirs processes the files of each of dirs, listed in filesByDir,
+// opts.jobs directories at a time. Every directory gets its own file sets,
+// so the only state they share is safe for concurrent use. The reports are
+// written in the order of dirs, each as soon as those before it are done,
+// and an error in one directory doesn't stop the others: the errors of all
+// are returned joined, in the same order. The structs collected for the
+// formats rendered at the end are kept in that order too.
+func processDirs(dirs []string, filesByDir map[string][]string, opts options, reg *fileRegistry) error {
+	out := newOrderedOutput(len(dirs), opts.emit)
+	errs := make([]error, len(dirs))
+	var collected []*reportCollector
+	if opts.collect != nil {
+		collected = make([]*reportCollector, len(dirs))
+	}
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(max(opts.jobs, 1), len(dirs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				dirOpts := opts
+				dirOpts.write = out.writer(i)
+				if collected != nil {
+					collected[i] = &reportCollector{}
+					dirOpts.collect = collected[i]
+				}
+				errs[i] = processFiles(filesByDir[dirs[i]], dirOpts, reg)
+				out.finish(i)
+			}
+		}()
+	}
+	for i := range dirs {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	for _, c := range collected {
+		opts.collect.merge(c)
+	}
+	return errors.Join(errs...)
+}
+
+// orderedOutput writes the output of numbered pieces of work done
+// concurrently in their order: the output of the first piece not yet done
+// goes straight through, and that of the later ones is held back until
+// those before them are done.
+type orderedOutput struct {
+	mu       sync.Mutex
+	write    func([]byte)
+	pending  []bytes.Buffer
+	finished []bool
+	current  int // the first piece not yet done
+}
+
+func newOrderedOutput(n int, write func([]byte)) *orderedOutput {
+	return &orderedOutput{write: write, pending: make([]bytes.Buffer, n), finished: make([]bool, n)}
+}
+
+// writer returns the function writing the output of piece i.
+func (o *orderedOutput) writer(i int) func([]byte) {
+	return func(p []byte) {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		if i == o.current {
+			o.write(p)
+			return
+		}
+		o.pending[i].Write(p)
+	}
+}
+
+// finish records that piece i is done, writing the output held back of the
+// pieces after it up to the first one not done yet.
+func (o *orderedOutput) finish(i int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.finished[i] = true
+	for o.current < len(o.finished) && o.finished[o.current] {
+		o.current++
+		if o.current < len(o.pending) && o.pending[o.current].Len() > 0 {
+			o.write(o.pending[o.current].Bytes())
+			o.pending[o.current] = bytes.Buffer{}
+		}
+	}
+}
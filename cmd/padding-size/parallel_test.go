@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProcessDirsOrder(t *testing.T) {
+	dir := t.TempDir()
+	files := make(map[string]string)
+	for i := range 12 {
+		files[fmt.Sprintf("p%02d/types.go", i)] = fmt.Sprintf("package p%02d\n\ntype T%02d struct {\n\ta bool\n\tn int64\n\tb bool\n}\n", i, i)
+	}
+	writeTree(t, dir, files)
+
+	sequential := captureReport(t, func() error { return processPath(dir, options{jobs: 1}, newFileRegistry()) })
+	for range 5 {
+		concurrent := captureReport(t, func() error { return processPath(dir, options{jobs: 4}, newFileRegistry()) })
+		if concurrent != sequential {
+			t.Fatalf("-jobs 4 report:\n%s\nwant, as with -jobs 1:\n%s", concurrent, sequential)
+		}
+	}
+	if got := strings.Count(sequential, "Struct: T"); got != 12 {
+		t.Errorf("report lists %d structs, want 12:\n%s", got, sequential)
+	}
+
+	// The formats rendered once the run is done, and the summary ranking
+	// structs wasting as much, list the structs in the walk order too.
+	for _, format := range []string{"json", "sarif", "metrics", "text"} {
+		_, sequential := runCaptured(t, "-format", format, "-jobs", "1", dir)
+		for range 5 {
+			_, concurrent := runCaptured(t, "-format", format, "-jobs", "4", dir)
+			if concurrent != sequential {
+				t.Fatalf("-format %s -jobs 4 output:\n%s\nwant, as with -jobs 1:\n%s", format, concurrent, sequential)
+			}
+		}
+	}
+}
+
+func TestProcessDirsErrors(t *testing.T) {
+	// Errors in one directory, or one file, leave the others reported and
+	// fixed, and come back together.
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"a/types.go":  "package a\n\ntype A struct {\n\tx bool\n\tn int64\n\ty bool\n}\n",
+		"b/broken.go": "package b\n\ntype Broken struct {\n",
+		"b/types.go":  "package b\n\ntype B struct {\n\tx bool\n\tn int64\n\ty bool\n}\n",
+		"c/broken.go": "package c\n\nfunc f( {}\n\ntype C struct{}\n",
+		"d/types.go":  "package d\n\ntype D struct {\n\tx bool\n\tn int64\n\ty bool\n}\n",
+	})
+	var err error
+	out := captureReport(t, func() error {
+		err = processPath(dir, options{fix: true, jobs: 3}, newFileRegistry())
+		return nil
+	})
+	if err == nil {
+		t.Fatal("no error for the broken files")
+	}
+	for _, want := range []string{"b/broken.go", "c/broken.go"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error lacks %s: %v", want, err)
+		}
+	}
+	for _, name := range []string{"A", "B", "D"} {
+		if !strings.Contains(out, "Struct: "+name+" ") {
+			t.Errorf("report lacks %s:\n%s", name, out)
+		}
+	}
+	if src := readFile(t, filepath.Join(dir, "d", "types.go")); !strings.Contains(src, "\tn int64\n\tx bool\n") {
+		t.Errorf("d/types.go not fixed:\n%s", src)
+	}
+}
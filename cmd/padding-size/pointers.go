@@ -0,0 +1,59 @@
+package main
+
+import (
+	"go/types"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// pointerMasks holds the pointer masks of package-level struct types, laid
+// out with type information.
+type pointerMasks map[structKey]pointerMask
+
+// pointerMask is the mask of PointerMask, the size of its words and that of
+// the struct.
+type pointerMask struct {
+	words      []bool
+	word, size int64
+}
+
+// findPointerMasks type-checks the package in dir, and with recursive the
+// packages below it as well, and returns the pointer masks of their
+// package-level struct types. Generic types are left out, since they are
+// laid out only once instantiated.
+func findPointerMasks(dir string, recursive bool) (pointerMasks, error) {
+	pkgs, loadErr := loadPackages(dir, recursive, packages.NeedTypes|packages.NeedTypesSizes)
+
+	masks := make(pointerMasks)
+	for _, pkg := range pkgs {
+		if pkg.Types == nil || pkg.TypesSizes == nil {
+			continue
+		}
+		word := pkg.TypesSizes.Sizeof(types.Typ[types.Uintptr])
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			obj, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || obj.IsAlias() {
+				continue
+			}
+			if _, ok := obj.Type().Underlying().(*types.Struct); !ok {
+				continue
+			}
+			if named, ok := obj.Type().(*types.Named); ok && named.TypeParams().Len() > 0 {
+				continue
+			}
+			key := structKey{realDir(pkg.Fset.Position(obj.Pos()).Filename), obj.Name()}
+			masks[key] = pointerMask{padding.PointerMask(obj.Type(), pkg.TypesSizes), word, pkg.TypesSizes.Sizeof(obj.Type())}
+		}
+	}
+	return masks, loadErr
+}
+
+// weigh sets the pointer bytes and scan length of r, a package-level struct.
+func (m pointerMasks) weigh(r *padding.StructReport) {
+	if mask, ok := m[structKey{realDir(r.File), r.Name}]; ok {
+		r.CheckPointers(mask.words, mask.word, mask.size)
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPointers(t *testing.T) {
+	dir := filepath.Join("testdata", "pointers")
+	opts := options{collect: new(reportCollector), pointers: true}
+	report := captureReport(t, func() error { return processPath(dir, opts, newFileRegistry()) })
+
+	type stats struct{ pointers, scan, size int64 }
+	want := map[string]stats{
+		"Node":  {16, 24, 64},
+		"Table": {32, 56, 56},
+		"Plain": {},
+	}
+	for _, s := range opts.collect.report().Structs {
+		if got := (stats{s.PointerBytes, s.ScanLength, s.TypedSize}); got != want[s.Name] {
+			t.Errorf("%s: %+v, want %+v", s.Name, got, want[s.Name])
+		}
+	}
+	if line := "  Pointers: 16B of 64B, scan length 24B\n"; !strings.Contains(report, line) {
+		t.Errorf("report lacks %q:\n%s", line, report)
+	}
+}
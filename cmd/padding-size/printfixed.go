@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// runPrintFixed implements -print-fixed for the files and directories of
+// paths, with opts set up for it: it processes them as fix does, and writes
+// the declaration found to stdout.
+func runPrintFixed(paths []string, opts options) error {
+	reg := newFileRegistry()
+	for _, path := range paths {
+		if err := processPath(path, opts, reg); err != nil {
+			return fmt.Errorf("processing %s: %v", path, err)
+		}
+	}
+	return opts.printFixed.write(stdout, os.Stderr)
+}
+
+// fixedPrinter collects, for -print-fixed, the declarations fix would write
+// for the struct types of a name, or why it would leave them alone. It is
+// safe for concurrent use.
+type fixedPrinter struct {
+	name  string
+	mu    sync.Mutex
+	decls []fixedDecl
+}
+
+// fixedDecl is a declaration found by a fixedPrinter.
+type fixedDecl struct {
+	pos string // file and line of the declaration
+	// source is the declaration with the order fix writes, or reason why
+	// fix leaves it alone.
+	source, reason string
+	optimal        bool // whether fix keeps its order
+}
+
+// add records the structs of f of the name of p. before holds them as they
+// are declared, and reasons why fix leaves them alone, as reportFile finds
+// them, the first being given; f.Structs holds them as fix lays them out.
+func (p *fixedPrinter) add(f *FileResult, before []padding.StructInfo, reasons [][]skipReason) error {
+	for i, s := range f.Structs {
+		if s.Anonymous || s.Name != p.name {
+			continue
+		}
+		d := fixedDecl{pos: fmt.Sprintf("%s:%d", f.Path, f.Fset.Position(s.Node.Pos()).Line)}
+		if all := fixSkipReasons(before[i], reasons[i]); len(all) > 0 {
+			d.reason = all[0].text
+		} else {
+			source, err := fixedSource(f, s)
+			if err != nil {
+				return err
+			}
+			d.source = source
+			d.optimal = slices.Equal(fieldNames(before[i]), fieldNames(s))
+		}
+		p.mu.Lock()
+		p.decls = append(p.decls, d)
+		p.mu.Unlock()
+	}
+	return nil
+}
+
+// fixedSource returns the declaration of s, a struct of f laid out as fix
+// lays it out, as fix writes it, with its doc comment, formatted on its
+// own.
+func fixedSource(f *FileResult, s padding.StructInfo) (string, error) {
+	rewritten, err := padding.Rewrite(f.Fset, f.Node, f.Src, []padding.StructInfo{s})
+	if err != nil {
+		return "", err
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, f.Path, rewritten, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return "", err
+	}
+	k := slices.IndexFunc(namedTypes(f.Node, s.Name), func(n namedType) bool { return n.spec.Type == s.Node })
+	specs := namedTypes(file, s.Name)
+	if k >= 0 && k < len(specs) {
+		gen, spec := specs[k].gen, specs[k].spec
+		doc, start := spec.Doc, spec.Pos()
+		if len(gen.Specs) == 1 {
+			doc = gen.Doc
+		}
+		var b strings.Builder
+		if doc != nil {
+			b.Write(rewritten[fset.Position(doc.Pos()).Offset:fset.Position(doc.End()).Offset])
+			b.WriteString("\n")
+		}
+		b.WriteString("type ")
+		b.Write(rewritten[fset.Position(start).Offset:fset.Position(spec.End()).Offset])
+		// A declaration of a group is indented; formatting it anew on
+		// its own takes that away.
+		src, err := format.Source([]byte("package p\n\n" + b.String()))
+		if err != nil {
+			return "", err
+		}
+		return string(bytes.TrimPrefix(src, []byte("package p\n\n"))), nil
+	}
+	return "", fmt.Errorf("%s: %s not found once rewritten", f.Path, s.Name)
+}
+
+// write writes the declaration found to w, and a note to notes if fix keeps
+// its order. It fails if there is no declaration of the name, or more than
+// one, or if fix leaves it alone.
+func (p *fixedPrinter) write(w, notes io.Writer) error {
+	switch len(p.decls) {
+	case 0:
+		return fmt.Errorf("-print-fixed: no struct type %s declared in the given files", p.name)
+	case 1:
+	default:
+		positions := make([]string, len(p.decls))
+		for i, d := range p.decls {
+			positions[i] = d.pos
+		}
+		slices.Sort(positions)
+		return fmt.Errorf("-print-fixed: %s is declared at %s; pass only the file of the one wanted", p.name, strings.Join(positions, ", "))
+	}
+	d := p.decls[0]
+	if d.reason != "" {
+		return errors.New(d.pos + ": not printing " + p.name + ", which fix leaves alone: " + d.reason)
+	}
+	if d.optimal {
+		fmt.Fprintf(notes, "%s: %s is already laid out as fix would\n", d.pos, p.name)
+	}
+	_, err := io.WriteString(w, d.source)
+	return err
+}
+
+// namedType is the declaration of a type, in the declaration gen.
+type namedType struct {
+	gen  *ast.GenDecl
+	spec *ast.TypeSpec
+}
+
+// namedTypes returns the declarations of the types of the name in file,
+// those inside functions included, in source order.
+func namedTypes(file *ast.File, name string) []namedType {
+	var types []namedType
+	var gen *ast.GenDecl
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.GenDecl:
+			gen = n
+		case *ast.TypeSpec:
+			if n.Name.Name == name {
+				types = append(types, namedType{gen, n})
+			}
+		}
+		return true
+	})
+	return types
+}
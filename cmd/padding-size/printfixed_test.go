@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// printFixed runs -print-fixed for name on dir, returning what it writes
+// to stdout and stderr.
+func printFixed(t *testing.T, dir, name string) (string, string, error) {
+	t.Helper()
+	opts := options{fix: true, printFixed: &fixedPrinter{name: name}, typeFailures: new(typeFailures)}
+	if err := processPath(dir, opts, newFileRegistry()); err != nil {
+		t.Fatal(err)
+	}
+	if opts.typeFailures.degraded() {
+		t.Fatal(opts.typeFailures.warning())
+	}
+	var out, notes bytes.Buffer
+	err := opts.printFixed.write(&out, &notes)
+	return out.String(), notes.String(), err
+}
+
+func TestPrintFixed(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not available")
+	}
+	dir := filepath.Join("testdata", "printfixed")
+	src := readFile(t, filepath.Join(dir, "printfixed.go"))
+
+	for _, name := range []string{"Event", "Pair"} {
+		got, notes, err := printFixed(t, dir, name)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		want, err := os.ReadFile(filepath.Join(dir, strings.ToLower(name)+".golden"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != string(want) || notes != "" {
+			t.Errorf("%s: got\n%s\nwant\n%s\nnotes: %q", name, got, want, notes)
+		}
+	}
+
+	got, notes, err := printFixed(t, dir, "Tight")
+	if err != nil || !strings.Contains(got, "Tight struct {\n\tn int64\n\tb bool\n}") || !strings.Contains(notes, "Tight is already laid out as fix would") {
+		t.Errorf("Tight: %v, got\n%s\nnotes: %q", err, got, notes)
+	}
+
+	for name, want := range map[string]string{
+		"Record": "not printing Record, which fix leaves alone: reflection indexes its fields by position at ",
+		"Nope":   "-print-fixed: no struct type Nope declared in the given files",
+	} {
+		if got, _, err := printFixed(t, dir, name); err == nil || !strings.Contains(err.Error(), want) || got != "" {
+			t.Errorf("%s: %v, want %q; printed\n%s", name, err, want, got)
+		}
+	}
+
+	if got := readFile(t, filepath.Join(dir, "printfixed.go")); got != src {
+		t.Errorf("printfixed.go was changed:\n%s", got)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"sync"
+)
+
+// startProfiling starts a CPU profile and an execution trace and arranges for
+// a heap profile to be written, for each of the non-empty paths. The returned
+// stop function finishes all of them; it is safe to call more than once, so it
+// can be used both on the normal exit path and from a signal handler.
+func startProfiling(cpuProfile, memProfile, traceFile string) (stop func() error, err error) {
+	var stops []func() error
+	stopAll := func() error {
+		var errs []error
+		for i := len(stops) - 1; i >= 0; i-- {
+			errs = append(errs, stops[i]())
+		}
+		return errors.Join(errs...)
+	}
+
+	if cpuProfile != "" {
+		f, err := os.Create(cpuProfile)
+		if err != nil {
+			return nil, fmt.Errorf("creating CPU profile: %w", err)
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("starting CPU profile: %w", err)
+		}
+		stops = append(stops, func() error {
+			pprof.StopCPUProfile()
+			return f.Close()
+		})
+	}
+
+	if traceFile != "" {
+		f, err := os.Create(traceFile)
+		if err != nil {
+			stopAll()
+			return nil, fmt.Errorf("creating trace: %w", err)
+		}
+		if err := trace.Start(f); err != nil {
+			f.Close()
+			stopAll()
+			return nil, fmt.Errorf("starting trace: %w", err)
+		}
+		stops = append(stops, func() error {
+			trace.Stop()
+			return f.Close()
+		})
+	}
+
+	if memProfile != "" {
+		f, err := os.Create(memProfile)
+		if err != nil {
+			stopAll()
+			return nil, fmt.Errorf("creating memory profile: %w", err)
+		}
+		stops = append(stops, func() error {
+			runtime.GC() // materialize up-to-date allocation statistics
+			if err := pprof.WriteHeapProfile(f); err != nil {
+				f.Close()
+				return fmt.Errorf("writing memory profile: %w", err)
+			}
+			return f.Close()
+		})
+	}
+
+	var once sync.Once
+	var stopErr error
+	return func() error {
+		once.Do(func() { stopErr = stopAll() })
+		return stopErr
+	}, nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStartProfiling(t *testing.T) {
+	dir := t.TempDir()
+	cpu := filepath.Join(dir, "cpu.pprof")
+	mem := filepath.Join(dir, "mem.pprof")
+	trc := filepath.Join(dir, "trace.out")
+
+	stop, err := startProfiling(cpu, mem, trc)
+	if err != nil {
+		t.Fatalf("startProfiling failed: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		if _, err := loadFile(filepath.Join("testdata", "variants", "types_darwin.go"), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := stop(); err != nil {
+		t.Fatalf("stop failed: %v", err)
+	}
+	if err := stop(); err != nil {
+		t.Fatalf("second stop failed: %v", err)
+	}
+
+	for _, path := range []string{cpu, mem, trc} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("profile not written: %v", err)
+		}
+		if info.Size() == 0 {
+			t.Errorf("%s is empty", filepath.Base(path))
+		}
+	}
+}
+
+func TestStartProfilingCleansUpOnError(t *testing.T) {
+	cpu := filepath.Join(t.TempDir(), "cpu.pprof")
+	if _, err := startProfiling(cpu, "", filepath.Join(t.TempDir(), "missing", "trace.out")); err == nil {
+		t.Fatal("expected an error for an unwritable trace path")
+	}
+
+	// The CPU profile started before the failure must have been stopped,
+	// otherwise starting a new one fails.
+	stop, err := startProfiling(cpu, "", "")
+	if err != nil {
+		t.Fatalf("CPU profiling was left running: %v", err)
+	}
+	stop()
+}
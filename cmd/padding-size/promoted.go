@@ -0,0 +1,65 @@
+package main
+
+import (
+	"go/types"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// promotedFields holds the fields package-level struct types promote from
+// the structs they embed. Types embedding no struct are left out.
+type promotedFields map[structKey][]padding.PromotedFieldReport
+
+// findPromotedFields type-checks the package in dir, and with recursive the
+// packages below it as well, and places the fields their package-level
+// struct types promote from embedded structs. Generic types are left out,
+// since they are laid out only once instantiated.
+func findPromotedFields(dir string, recursive bool) (promotedFields, error) {
+	pkgs, loadErr := loadPackages(dir, recursive, packages.NeedTypes|packages.NeedTypesSizes)
+
+	promoted := make(promotedFields)
+	for _, pkg := range pkgs {
+		if pkg.Types == nil || pkg.TypesSizes == nil {
+			continue
+		}
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			obj, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || obj.IsAlias() {
+				continue
+			}
+			st, ok := obj.Type().Underlying().(*types.Struct)
+			if !ok {
+				continue
+			}
+			if named, ok := obj.Type().(*types.Named); ok && named.TypeParams().Len() > 0 {
+				continue
+			}
+			fields := padding.PromotedFields(st, pkg.TypesSizes)
+			if len(fields) == 0 {
+				continue
+			}
+			reports := make([]padding.PromotedFieldReport, len(fields))
+			for i, f := range fields {
+				reports[i] = padding.PromotedFieldReport{
+					Path:    f.Path,
+					Type:    types.TypeString(f.Field.Type(), types.RelativeTo(pkg.Types)),
+					Offset:  f.Offset,
+					Size:    f.Size,
+					Pointer: f.Pointer,
+				}
+			}
+			promoted[structKey{realDir(pkg.Fset.Position(obj.Pos()).Filename), obj.Name()}] = reports
+		}
+	}
+	return promoted, loadErr
+}
+
+// weigh sets the promoted fields of r, a package-level struct.
+func (p promotedFields) weigh(r *padding.StructReport) {
+	if fields, ok := p[structKey{realDir(r.File), r.Name}]; ok {
+		r.PromotedFields = fields
+	}
+}
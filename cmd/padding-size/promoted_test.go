@@ -0,0 +1,26 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPromotedFields(t *testing.T) {
+	dir := filepath.Join("testdata", "promoted")
+	report := captureReport(t, func() error { return processPath(dir, options{promoted: true}, newFileRegistry()) })
+
+	want := "  Promoted: Base Base (offset: 8, size: 32)\n" +
+		"  Promoted: Base.Name string (offset: 8, size: 16)\n" +
+		"  Promoted: Base.Meta Meta (offset: 24, size: 16)\n" +
+		"  Promoted: Base.Meta.Version uint16 (offset: 24, size: 2)\n" +
+		"  Promoted: Base.Meta.ID uint64 (offset: 32, size: 8)\n" +
+		"  Promoted: Conn *Conn (offset: 40, size: 8, embedded by pointer, not flattened)\n"
+	if !strings.Contains(report, want) {
+		t.Errorf("report lacks the promoted fields of Server:\n%s", report)
+	}
+	// Base promotes the fields of Meta as well; Meta and Conn embed nothing.
+	if n := strings.Count(report, "  Promoted: "); n != 9 {
+		t.Errorf("report lists %d promoted fields, want 9:\n%s", n, report)
+	}
+}
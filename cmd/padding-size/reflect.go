@@ -0,0 +1,230 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// reflectIndexes holds, for package-level struct types, the positions of the
+// reflect calls indexing their fields by position, such as v.Field(3) or
+// reflect.VisibleFields(t)[3], which reordering the fields breaks at run
+// time.
+type reflectIndexes map[structKey][]string
+
+// findReflectIndexes type-checks the package in dir, and with recursive the
+// packages below it as well, and finds the calls of the Field method of
+// reflect.Value and reflect.Type with a constant index, and the elements of
+// reflect.VisibleFields taken at a constant index, on the struct types of
+// these packages. A receiver is tied to a struct type through reflect.ValueOf,
+// reflect.TypeOf, reflect.TypeFor, reflect.Indirect, the Elem and Type
+// methods and the variables they are assigned to.
+func findReflectIndexes(dir string, recursive bool) (reflectIndexes, error) {
+	pkgs, loadErr := loadPackages(dir, recursive, packages.NeedTypes|packages.NeedSyntax|packages.NeedTypesInfo)
+
+	cwd, _ := os.Getwd()
+	indexes := make(reflectIndexes)
+	for _, pkg := range pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		t := reflectTracer{info: pkg.TypesInfo, values: make(map[types.Object]ast.Expr)}
+		for _, file := range pkg.Syntax {
+			t.collectValues(file)
+		}
+		for _, file := range pkg.Syntax {
+			ast.Inspect(file, func(n ast.Node) bool {
+				recv := t.indexedReceiver(n)
+				if recv == nil {
+					return true
+				}
+				named := t.structOf(recv, 0)
+				if named == nil || named.Obj().Pkg() == nil {
+					return true
+				}
+				pos := pkg.Fset.Position(n.Pos())
+				file := pos.Filename
+				if rel, err := filepath.Rel(cwd, file); err == nil && filepath.IsLocal(rel) {
+					file = rel
+				}
+				obj := named.Obj()
+				key := structKey{realDir(pkg.Fset.Position(obj.Pos()).Filename), obj.Name()}
+				indexes[key] = append(indexes[key], fmt.Sprintf("%s:%d", filepath.ToSlash(file), pos.Line))
+				return true
+			})
+		}
+	}
+	return indexes, loadErr
+}
+
+// pinned returns the structs of files whose fields reflection indexes by
+// position, with the positions of the calls doing so.
+func (r reflectIndexes) pinned(files []*FileResult) map[*padding.StructInfo][]string {
+	pinned := make(map[*padding.StructInfo][]string)
+	for _, f := range files {
+		topLevel := topLevelStructs(f.Node)
+		for i := range f.Structs {
+			s := &f.Structs[i]
+			if calls, ok := r[structKey{realDir(f.Path), s.Name}]; ok && topLevel[s.Node] {
+				pinned[s] = calls
+			}
+		}
+	}
+	return pinned
+}
+
+// reflectTracer ties reflect values and types to the struct types they
+// describe.
+type reflectTracer struct {
+	info *types.Info
+	// values holds the expression each variable is set to, for those set
+	// to a single one.
+	values map[types.Object]ast.Expr
+}
+
+// collectValues records the expressions the variables of file are set to.
+// A variable set more than once is recorded with its last value, which is
+// good enough for the reflect values of a function.
+func (t *reflectTracer) collectValues(file *ast.File) {
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if len(n.Lhs) != len(n.Rhs) {
+				return true
+			}
+			for i, lhs := range n.Lhs {
+				if id, ok := lhs.(*ast.Ident); ok {
+					if obj := t.info.ObjectOf(id); obj != nil {
+						t.values[obj] = n.Rhs[i]
+					}
+				}
+			}
+		case *ast.ValueSpec:
+			if len(n.Names) != len(n.Values) {
+				return true
+			}
+			for i, id := range n.Names {
+				if obj := t.info.ObjectOf(id); obj != nil {
+					t.values[obj] = n.Values[i]
+				}
+			}
+		}
+		return true
+	})
+}
+
+// indexedReceiver returns the reflect.Value or reflect.Type whose fields n
+// indexes by a constant position, or nil if n doesn't.
+func (t *reflectTracer) indexedReceiver(n ast.Node) ast.Expr {
+	switch n := n.(type) {
+	case *ast.CallExpr:
+		sel, ok := n.Fun.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "Field" || len(n.Args) != 1 || !t.constant(n.Args[0]) {
+			return nil
+		}
+		if s := t.info.Selections[sel]; s == nil || !isReflect(s.Recv(), "Value", "Type") {
+			return nil
+		}
+		return sel.X
+	case *ast.IndexExpr:
+		call, ok := ast.Unparen(n.X).(*ast.CallExpr)
+		if !ok || !t.reflectFunc(call.Fun, "VisibleFields") || len(call.Args) != 1 || !t.constant(n.Index) {
+			return nil
+		}
+		return call.Args[0]
+	}
+	return nil
+}
+
+// structOf returns the named struct type the reflect.Value or reflect.Type
+// expression e describes, or nil if it can't be told, following variables
+// up to a few levels deep.
+func (t *reflectTracer) structOf(e ast.Expr, depth int) *types.Named {
+	if depth > 8 {
+		return nil
+	}
+	switch e := ast.Unparen(e).(type) {
+	case *ast.Ident:
+		if v, ok := t.values[t.info.ObjectOf(e)]; ok {
+			return t.structOf(v, depth+1)
+		}
+	case *ast.CallExpr:
+		switch {
+		case t.reflectFunc(e.Fun, "ValueOf", "TypeOf") && len(e.Args) == 1:
+			return namedStruct(t.info.TypeOf(e.Args[0]))
+		case t.reflectFunc(e.Fun, "Indirect") && len(e.Args) == 1:
+			return t.structOf(e.Args[0], depth+1)
+		}
+		if ix, ok := e.Fun.(*ast.IndexExpr); ok && t.reflectFunc(ix.X, "TypeFor") {
+			return namedStruct(t.info.TypeOf(ix.Index))
+		}
+		if sel, ok := e.Fun.(*ast.SelectorExpr); ok && (sel.Sel.Name == "Elem" || sel.Sel.Name == "Type") {
+			if s := t.info.Selections[sel]; s != nil && isReflect(s.Recv(), "Value", "Type") {
+				return t.structOf(sel.X, depth+1)
+			}
+		}
+	}
+	return nil
+}
+
+// constant reports whether e is a constant expression.
+func (t *reflectTracer) constant(e ast.Expr) bool {
+	tv, ok := t.info.Types[e]
+	return ok && tv.Value != nil
+}
+
+// reflectFunc reports whether e names one of the functions of package reflect.
+func (t *reflectTracer) reflectFunc(e ast.Expr, names ...string) bool {
+	sel, ok := ast.Unparen(e).(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	fn, ok := t.info.Uses[sel.Sel].(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != "reflect" {
+		return false
+	}
+	for _, name := range names {
+		if fn.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isReflect reports whether typ is one of the named types of package reflect.
+func isReflect(typ types.Type, names ...string) bool {
+	named, ok := types.Unalias(typ).(*types.Named)
+	if !ok || named.Obj().Pkg() == nil || named.Obj().Pkg().Path() != "reflect" {
+		return false
+	}
+	for _, name := range names {
+		if named.Obj().Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// namedStruct returns the named struct type typ is, or points to.
+func namedStruct(typ types.Type) *types.Named {
+	if typ == nil {
+		return nil
+	}
+	if p, ok := typ.Underlying().(*types.Pointer); ok {
+		typ = p.Elem()
+	}
+	named, ok := types.Unalias(typ).(*types.Named)
+	if !ok {
+		return nil
+	}
+	if _, ok := named.Underlying().(*types.Struct); !ok {
+		return nil
+	}
+	return named.Origin()
+}
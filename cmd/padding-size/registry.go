@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// fileRegistry records which files a run has already taken on. Files are keyed
+// by their resolved path, so a file reached through several arguments or
+// symlinks is analyzed, reported and rewritten by exactly one caller. It is
+// safe for concurrent use.
+type fileRegistry struct {
+	mu      sync.Mutex
+	claimed map[string]bool
+}
+
+func newFileRegistry() *fileRegistry {
+	return &fileRegistry{claimed: make(map[string]bool)}
+}
+
+// claim reports whether path is seen for the first time in this run. Only the
+// caller that receives true may process the file.
+func (r *fileRegistry) claim(path string) (bool, error) {
+	key, err := resolvePath(path)
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.claimed[key] {
+		return false, nil
+	}
+	r.claimed[key] = true
+	return true, nil
+}
+
+// claimStdin records standard input as taken on, under stdinName, which no
+// resolved path can clash with.
+func (r *fileRegistry) claimStdin() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.claimed[stdinName] = true
+}
+
+// count returns the number of files claimed so far.
+func (r *fileRegistry) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.claimed)
+}
+
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(abs)
+}
+
+// replaceFile writes data to path, provided the file still holds orig. The new
+// contents are written to a temporary file in the same directory and renamed
+// over the original, so readers never observe a partially written file.
+// Symlinks are replaced at their target.
+func replaceFile(path string, orig, data []byte) error {
+	target, err := resolvePath(path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		return err
+	}
+
+	current, err := os.ReadFile(target)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(current, orig) {
+		return fmt.Errorf("%s changed while it was being analyzed; not rewriting it", path)
+	}
+	if bytes.Equal(current, data) {
+		return nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
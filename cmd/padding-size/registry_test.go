@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const registryFixture = `package tree
+
+type T struct {
+	A bool
+	B int64
+	C bool
+}
+`
+
+func TestConcurrentFixOverlappingPaths(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "tree")
+	files := []string{
+		filepath.Join(root, "a.go"),
+		filepath.Join(root, "b.go"),
+		filepath.Join(root, "sub", "c.go"),
+	}
+	for _, path := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(registryFixture), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	inner := filepath.Join(root, "link.go")
+	outer := filepath.Join(filepath.Dir(root), "outer.go")
+	if err := os.Symlink("a.go", inner); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	if err := os.Symlink(files[2], outer); err != nil {
+		t.Fatal(err)
+	}
+
+	want := fixedCopy(t, registryFixture)
+
+	var out bytes.Buffer
+	saved := stdout
+	stdout = bufio.NewWriter(&out)
+	defer func() { stdout = saved }()
+
+	args := []string{root, files[0], outer, filepath.Join(root, "sub"), root, inner, files[1]}
+	reg := newFileRegistry()
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		for _, arg := range args {
+			wg.Add(1)
+			go func(arg string) {
+				defer wg.Done()
+				if err := processPath(arg, options{fix: true}, reg); err != nil {
+					t.Errorf("processPath(%s) failed: %v", arg, err)
+				}
+			}(arg)
+		}
+	}
+	wg.Wait()
+	stdout.Flush()
+
+	for _, path := range files {
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%s was not fixed correctly:\n%s", path, got)
+		}
+	}
+	if target, err := os.Readlink(inner); err != nil || target != "a.go" {
+		t.Errorf("symlink was replaced by a regular file")
+	}
+	if n := strings.Count(out.String(), "File: "); n != len(files) {
+		t.Errorf("Expected %d file reports, got %d:\n%s", len(files), n, out.String())
+	}
+}
+
+func TestReplaceFileRefusesChangedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.go")
+	if err := os.WriteFile(path, []byte("package a\n// edited\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := replaceFile(path, []byte("package a\n"), []byte("package b\n")); err == nil {
+		t.Fatal("expected an error for a file modified after it was read")
+	}
+	got, _ := os.ReadFile(path)
+	if string(got) != "package a\n// edited\n" {
+		t.Errorf("file was overwritten: %q", got)
+	}
+}
+
+// fixedCopy returns what -fix turns src into, computed on a private copy.
+func fixedCopy(t *testing.T, src string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "copy.go")
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := processFile(path, options{fix: true}); err != nil {
+		t.Fatal(err)
+	}
+	fixed, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(fixed)
+}
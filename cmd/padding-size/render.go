@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// tables selects the tables the text format writes after the structs.
+type tables struct {
+	heap, allocSites, recoverable, stats, globals, verbose, dupes, skips, summary bool
+}
+
+// writeTables writes the tables t selects of r to w, in the order of the
+// text format: the heap ranking, the allocation site ranking, the
+// recoverable memory, the padding by type, the static footprint, the
+// estimated field types, the duplicate layouts, the skipped structs and the
+// summary of the run. The rankings list the first top entries, if top is
+// positive, and the recoverable memory is sorted by it if byRecoverable is
+// set.
+func writeTables(w io.Writer, r padding.Report, t tables, byRecoverable bool, top int) error {
+	var err error
+	if t.heap {
+		err = writeHeapRanking(w, r, top)
+	}
+	if t.allocSites && err == nil {
+		err = writeAllocRanking(w, r, top)
+	}
+	if t.recoverable && err == nil {
+		err = writeRecoverable(w, r, byRecoverable, top)
+	}
+	if t.stats && err == nil {
+		err = writePaddingByType(w, r, top)
+	}
+	if t.globals && err == nil {
+		err = writeStaticFootprint(w, r, top)
+	}
+	if t.verbose && err == nil {
+		err = writeEstimatedTypes(w, r)
+	}
+	if t.dupes && err == nil {
+		err = writeDuplicateLayouts(w, r)
+	}
+	if t.skips && err == nil {
+		err = writeSkippedStructs(w, r)
+	}
+	if t.summary && err == nil {
+		err = writeRunSummary(w, r, top)
+	}
+	return err
+}
+
+// reportTables returns the tables of the text format r has the data of.
+func reportTables(r padding.Report) tables {
+	return tables{
+		heap:        slices.ContainsFunc(r.Structs, func(s padding.StructReport) bool { return s.LiveObjects > 0 }),
+		allocSites:  slices.ContainsFunc(r.Structs, func(s padding.StructReport) bool { return s.AllocSites > 0 }),
+		recoverable: slices.ContainsFunc(r.Structs, func(s padding.StructReport) bool { return s.Instances > 0 }),
+		stats:       len(r.PaddingByType) > 0,
+		globals:     len(r.StaticFootprint) > 0,
+		verbose:     len(r.EstimatedTypes) > 0,
+		dupes:       len(r.DuplicateLayouts) > 0,
+		skips:       slices.ContainsFunc(r.Structs, func(s padding.StructReport) bool { return len(s.SkipReasons) > 0 }),
+		summary:     r.Summary != nil,
+	}
+}
+
+// runRender implements the render subcommand:
+//
+//	padding-size render [-format text|json|metrics|sarif] [-all] [-min-waste n] [-top n] [-sort order] report.json
+//
+// It writes a JSON report saved by an earlier run in another format, as
+// that run would have with the same options, without analyzing anything
+// again. It returns the process exit code.
+func runRender(args []string) int {
+	fs := flag.NewFlagSet("render", flag.ContinueOnError)
+	format := fs.String("format", "text", "Output `format`: text, json, metrics (OpenMetrics) or sarif")
+	all := fs.Bool("all", false, "With -format=text, also list the structs that waste no padding")
+	minWaste := fs.Int64("min-waste", 0, "Leave out the structs wasting fewer than `n` bytes")
+	top := fs.Int("top", 0, "Rank only the first `n` structs; 0 for all")
+	sortBy := fs.String("sort", "source", "Order of the recoverable memory table: source or recoverable")
+	sarifLevel := fs.String("sarif-level", "warning", "`Level` of the results of -format=sarif: note or warning")
+	var labels metricLabels
+	fs.Var(&labels, "metrics-label", "Add the label `name=value` to every metric (repeatable)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: padding-size render [options] <report.json>")
+		fs.PrintDefaults()
+	}
+
+	paths, err := parseArgs(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(paths) != 1 || *format != "text" && *format != "json" && *format != "metrics" && *format != "sarif" ||
+		*sarifLevel != "note" && *sarifLevel != "warning" ||
+		*sortBy != "source" && *sortBy != "recoverable" || *minWaste < 0 {
+		fs.Usage()
+		return 2
+	}
+	r, err := loadRenderable(paths[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	settings := renderSettings{*format, *all, *minWaste, *top, *sortBy == "recoverable", labels, *sarifLevel}
+	if err := renderReport(os.Stdout, r, settings); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// renderSettings are the options of the render subcommand.
+type renderSettings struct {
+	format        string
+	all           bool
+	minWaste      int64
+	top           int
+	byRecoverable bool
+	labels        metricLabels
+	sarifLevel    string
+}
+
+// renderReport writes r to w in the format of s, leaving out the structs
+// wasting fewer than s.minWaste bytes, and from the text format those the
+// text format of a live run leaves out unless s.all.
+func renderReport(w io.Writer, r padding.Report, s renderSettings) error {
+	if s.minWaste > 0 {
+		r.Structs = slices.DeleteFunc(slices.Clone(r.Structs), func(sr padding.StructReport) bool { return sr.WastedBytes < s.minWaste })
+		if r.PaddingByType != nil {
+			r.PaddingByType = padding.AggregatePaddingByType(r.Structs)
+		}
+	}
+	switch s.format {
+	case "json":
+		r.SchemaVersion = padding.SchemaVersion
+		return jsonEncoder{w, s.top}.encode(r)
+	case "metrics":
+		return writeMetrics(w, r, s.labels)
+	case "sarif":
+		return writeSARIF(w, r, s.sarifLevel)
+	}
+	writeStructs(w, r, s.all)
+	return writeTables(w, r, reportTables(r), s.byRecoverable, s.top)
+}
+
+// loadRenderable reads the JSON report at path, which must have a schema
+// version this build knows every field of: the same major version, and a
+// minor version no later than its own.
+func loadRenderable(path string) (padding.Report, error) {
+	var r padding.Report
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return r, err
+	}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return r, fmt.Errorf("%s: %v", path, err)
+	}
+	major, minor := schemaVersion(padding.SchemaVersion)
+	gotMajor, gotMinor := schemaVersion(r.SchemaVersion)
+	switch {
+	case gotMajor != major:
+		return r, fmt.Errorf("%s: schema version %q can't be rendered by this build, which writes %s; analyze again with this build, or render with the release of padding-size that wrote the report", path, r.SchemaVersion, padding.SchemaVersion)
+	case gotMinor > minor:
+		return r, fmt.Errorf("%s: schema version %q is newer than this build's %s, whose output would lack its new fields; render with the release of padding-size that wrote the report, or a later one", path, r.SchemaVersion, padding.SchemaVersion)
+	}
+	return r, nil
+}
+
+// schemaVersion returns the major and minor numbers of version, -1 for
+// those that are missing or malformed.
+func schemaVersion(version string) (major, minor int) {
+	ma, mi, _ := strings.Cut(version, ".")
+	major, err := strconv.Atoi(ma)
+	if err != nil {
+		major = -1
+	}
+	minor, err = strconv.Atoi(mi)
+	if err != nil {
+		minor = -1
+	}
+	return major, minor
+}
+
+// writeStructs writes the structs of r to w as the text format does while
+// processing files: under a header naming their file, and their package in
+// directories of several, with the compiler first if it is not gc, and only
+// those it lists unless all.
+func writeStructs(w io.Writer, r padding.Report, all bool) {
+	if r.Compiler == padding.CompilerGccgo {
+		fmt.Fprintf(w, "Compiler: %s\n\n", r.Compiler)
+	}
+	packages := make(map[string]map[string]bool) // by directory
+	for _, s := range r.Structs {
+		dir := filepath.Dir(s.File)
+		if packages[dir] == nil {
+			packages[dir] = make(map[string]bool)
+		}
+		packages[dir][s.Package] = true
+	}
+	file, header := "", false
+	for _, s := range r.Structs {
+		if !all && !noteworthy(&s) {
+			continue
+		}
+		if !header || s.File != file {
+			file, header = s.File, true
+			if s.Module != "" {
+				fmt.Fprintf(w, "File: %s (%s, read-only)\n", s.File, s.Module)
+			} else if len(packages[filepath.Dir(s.File)]) > 1 {
+				fmt.Fprintf(w, "File: %s (package %s)\n", s.File, s.Package)
+			} else {
+				fmt.Fprintf(w, "File: %s\n", s.File)
+			}
+		}
+		padding.FprintStruct(w, s)
+	}
+}
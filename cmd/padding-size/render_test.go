@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestRenderRoundTrip(t *testing.T) {
+	path := writeFile(t, "package p\n\ntype Loose struct {\n\ta bool\n\tb int64\n\tc bool\n}\n\ntype Tight struct {\n\tb int64\n\ta bool\n}\n\ntype Slack struct {\n\ta bool\n\tb int32\n\tc bool\n}\n")
+	dir := filepath.Dir(path)
+
+	// The live run, in text and then in JSON, saved.
+	live := options{stats: true, collect: new(reportCollector)}
+	text := captureReport(t, func() error { return processPath(dir, live, newFileRegistry()) })
+	var ranked bytes.Buffer
+	if err := writeTables(&ranked, live.collect.report(), tables{stats: true, summary: true}, false, 0); err != nil {
+		t.Fatal(err)
+	}
+	text += ranked.String()
+
+	saved := options{format: "json", stats: true, collect: new(reportCollector)}
+	captureReport(t, func() error { return processPath(dir, saved, newFileRegistry()) })
+	r := saved.collect.report()
+	r.PaddingByType = padding.AggregatePaddingByType(r.Structs)
+	var js bytes.Buffer
+	if err := (jsonEncoder{w: &js}).encode(r); err != nil {
+		t.Fatal(err)
+	}
+	report := filepath.Join(t.TempDir(), "report.json")
+	if err := os.WriteFile(report, js.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := loadRenderable(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var metrics bytes.Buffer
+	if err := writeMetrics(&metrics, r, nil); err != nil {
+		t.Fatal(err)
+	}
+	for format, want := range map[string]string{"text": text, "json": js.String(), "metrics": metrics.String()} {
+		var got bytes.Buffer
+		if err := renderReport(&got, loaded, renderSettings{format: format}); err != nil {
+			t.Fatal(err)
+		}
+		if got.String() != want {
+			t.Errorf("-format=%s rendered\n%s\nwant\n%s", format, got.String(), want)
+		}
+	}
+
+	// Only Loose wastes 8 bytes; Slack wastes 4.
+	var got bytes.Buffer
+	if err := renderReport(&got, loaded, renderSettings{format: "text", minWaste: 8}); err != nil {
+		t.Fatal(err)
+	}
+	if out := got.String(); !strings.Contains(out, "Struct: Loose") || strings.Contains(out, "Struct: Slack") || strings.Contains(out, "Struct: Tight") {
+		t.Errorf("-min-waste=8 rendered:\n%s", out)
+	}
+
+	// Tight, optimal, is listed only with -all.
+	got.Reset()
+	if err := renderReport(&got, loaded, renderSettings{format: "text", all: true}); err != nil {
+		t.Fatal(err)
+	}
+	if out := got.String(); !strings.Contains(out, "Struct: Tight") {
+		t.Errorf("-all rendered:\n%s", out)
+	}
+}
+
+func TestLoadRenderable(t *testing.T) {
+	if major, minor := schemaVersion(padding.SchemaVersion); major != 1 || minor >= 999 {
+		t.Fatalf("schema version %s: update the test", padding.SchemaVersion)
+	}
+	for version, want := range map[string]string{
+		padding.SchemaVersion: "",
+		"1.0":                 "",
+		"2.1":                 `schema version "2.1" can't be rendered by this build`,
+		"1.999":               `schema version "1.999" is newer than this build's`,
+		"":                    `schema version "" can't be rendered by this build`,
+	} {
+		path := filepath.Join(t.TempDir(), "report.json")
+		if err := os.WriteFile(path, []byte(`{"schema_version": "`+version+`", "structs": []}`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := loadRenderable(path)
+		switch {
+		case want == "" && err != nil:
+			t.Errorf("%q: %v", version, err)
+		case want != "" && (err == nil || !strings.Contains(err.Error(), want)):
+			t.Errorf("%q: %v, want %s", version, err, want)
+		}
+	}
+}
+
+func TestRenderSARIF(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"a.go": "package p\n\ntype Loose struct {\n\ta bool\n\tb int64\n\tc bool\n}\n\ntype Tight struct {\n\tb int64\n\ta bool\n}\n",
+		"b.go": "package p\n\ntype Slack struct {\n\ta bool\n\tb int32\n\tc bool\n}\n",
+	})
+	_, js := runCaptured(t, "-format", "json", dir)
+	report := filepath.Join(t.TempDir(), "report.json")
+	if err := os.WriteFile(report, []byte(js), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadRenderable(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, level := range []string{"warning", "note"} {
+		_, want := runCaptured(t, "-format", "sarif", "-sarif-level", level, dir)
+		if !strings.Contains(want, `"level": "`+level+`"`) || !strings.Contains(want, "Loose") {
+			t.Fatalf("live -format=sarif -sarif-level=%s:\n%s", level, want)
+		}
+		var got bytes.Buffer
+		if err := renderReport(&got, loaded, renderSettings{format: "sarif", sarifLevel: level}); err != nil {
+			t.Fatal(err)
+		}
+		if got.String() != want {
+			t.Errorf("-format=sarif -sarif-level=%s rendered\n%s\nwant, as live:\n%s", level, got.String(), want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"go/ast"
+	"go/types"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// namedStructs returns the package-level struct types declared in files by
+// name. Of build variants declaring the same name, the first one counts.
+func namedStructs(files []*FileResult) map[string]*padding.StructInfo {
+	named := make(map[string]*padding.StructInfo)
+	for _, f := range files {
+		topLevel := topLevelStructs(f.Node)
+		for i := range f.Structs {
+			s := &f.Structs[i]
+			if _, ok := named[s.Name]; !ok && topLevel[s.Node] {
+				named[s.Name] = s
+			}
+		}
+	}
+	return named
+}
+
+// resolveSizes sizes the fields of the structs of files holding other
+// package-level structs of files by value with the layout of those, however
+// deeply they nest.
+func resolveSizes(files []*FileResult) {
+	var structs []*padding.StructInfo
+	for _, f := range files {
+		for i := range f.Structs {
+			structs = append(structs, &f.Structs[i])
+		}
+	}
+	named := namedStructs(files)
+	for padding.ResolveSizes(structs, named) {
+	}
+}
+
+// fixedLayouts returns the layouts fix gives the structs of files it may
+// reorder. Reordering a struct can shrink the structs holding it by value
+// and change their optimal order, so the structs are reordered again with
+// the new sizes of the structs they hold until no size changes: the layout
+// of a struct nested three levels deep reaches the outermost one in three
+// rounds.
+func fixedLayouts(files []*FileResult, opts options) map[*padding.StructInfo]padding.StructInfo {
+	fixed := make(map[*padding.StructInfo]*padding.StructInfo)
+	var structs []*padding.StructInfo
+	for _, f := range files {
+		for i := range f.Structs {
+			if s := &f.Structs[i]; opts.fixable(s) {
+				if l, ok := opts.generic[s]; ok {
+					// Generic structs are only held by value once
+					// instantiated, which sizes never follow.
+					o := l.apply(*s)
+					fixed[s] = &o
+					continue
+				}
+				o := opts.optimal(*s)
+				fixed[s] = &o
+				structs = append(structs, &o)
+			}
+		}
+	}
+	named := make(map[string]*padding.StructInfo)
+	for name, s := range namedStructs(files) {
+		if o, ok := fixed[s]; ok {
+			named[name] = o
+		}
+	}
+	// The fields of inline struct types are sized by the reordered
+	// types, which they spell out.
+	for _, f := range files {
+		inline := inlineStructs(f.Node)
+		for i := range f.Structs {
+			if s := &f.Structs[i]; inline[s.Node] && fixed[s] != nil {
+				named[types.ExprString(s.Node)] = fixed[s]
+			}
+		}
+	}
+	for padding.ResolveSizes(structs, named) {
+		for _, s := range structs {
+			*s = opts.optimal(*s)
+		}
+	}
+
+	layouts := make(map[*padding.StructInfo]padding.StructInfo, len(fixed))
+	for s, o := range fixed {
+		layouts[s] = *o
+	}
+	return layouts
+}
+
+// inlineStructs returns the struct type literals of file that are the types
+// of fields.
+func inlineStructs(file *ast.File) map[*ast.StructType]bool {
+	inline := make(map[*ast.StructType]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if f, ok := n.(*ast.Field); ok {
+			if st, ok := f.Type.(*ast.StructType); ok {
+				inline[st] = true
+			}
+		}
+		return true
+	})
+	return inline
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// sarifRuleID is the id of the rule the results of -format=sarif follow.
+const sarifRuleID = "struct-padding"
+
+// sarifLog is a SARIF 2.1.0 log, of the parts -format=sarif writes.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	Name                 string             `json:"name"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	FullDescription      sarifMessage       `json:"fullDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// writeSARIF writes r to w as a SARIF 2.1.0 log of one run, with a result
+// of the given level, note or warning, for each struct reordering would
+// shrink.
+func writeSARIF(w io.Writer, r padding.Report, level string) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "padding-size",
+			InformationURI: "https://github.com/zakon47/padding-size",
+			Rules: []sarifRule{{
+				ID:               sarifRuleID,
+				Name:             "StructPadding",
+				ShortDescription: sarifMessage{"Struct wastes bytes in padding that reordering its fields removes"},
+				FullDescription: sarifMessage{"The fields of the struct are laid out with padding between them that another order " +
+					"avoids; padding-size -fix reorders them."},
+				DefaultConfiguration: sarifConfiguration{level},
+			}},
+		}},
+		Results: []sarifResult{},
+	}
+	for _, s := range r.Structs {
+		if s.WastedBytes <= 0 || s.File == "" || s.Line == 0 {
+			continue
+		}
+		run.Results = append(run.Results, sarifResult{
+			RuleID: sarifRuleID,
+			Level:  level,
+			Message: sarifMessage{fmt.Sprintf("struct %s is %d bytes but could be %d (%d bytes of padding)",
+				s.Name, s.Size, s.OptimalSize, s.WastedBytes)},
+			Locations: []sarifLocation{{sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{sarifURI(s.File)},
+				Region:           sarifRegion{s.Line, s.Column},
+			}}},
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
+
+// sarifURI returns the URI of the file at path: relative paths stay relative,
+// with forward slashes, as code scanning resolves them against the checkout;
+// absolute ones become file URIs.
+func sarifURI(path string) string {
+	if !filepath.IsAbs(path) {
+		return (&url.URL{Path: filepath.ToSlash(path)}).String()
+	}
+	slashed := filepath.ToSlash(path)
+	if !strings.HasPrefix(slashed, "/") {
+		slashed = "/" + slashed // a volume name, as in C:/src
+	}
+	return (&url.URL{Scheme: "file", Path: slashed}).String()
+}
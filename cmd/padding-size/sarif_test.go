@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestWriteSARIF(t *testing.T) {
+	opts := options{format: "sarif", collect: new(reportCollector)}
+	captureReport(t, func() error { return processPath(filepath.Join("testdata", "json"), opts, newFileRegistry()) })
+	var out bytes.Buffer
+	if err := writeSARIF(&out, opts.collect.report(), "note"); err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(out.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("version %q with %d runs, want 2.1.0 with 1", log.Version, len(log.Runs))
+	}
+	run := log.Runs[0]
+	if rules := run.Tool.Driver.Rules; len(rules) != 1 || rules[0].ID != "struct-padding" || rules[0].DefaultConfiguration.Level != "note" {
+		t.Errorf("rules %+v, want struct-padding at level note", rules)
+	}
+
+	// Tight is optimal already, and gives no result.
+	want := []struct {
+		message, uri string
+		line, column int
+	}{
+		{"struct Loose is 24 bytes but could be 16 (8 bytes of padding)", "testdata/json/fixture.go", 4, 6},
+		{"struct Pair is 12 bytes but could be 8 (4 bytes of padding)", "testdata/json/other.go", 4, 6},
+	}
+	if len(run.Results) != len(want) {
+		t.Fatalf("%d results, want %d:\n%s", len(run.Results), len(want), out.String())
+	}
+	for i, r := range run.Results {
+		loc := r.Locations[0].PhysicalLocation
+		if w := want[i]; r.Message.Text != w.message || r.Level != "note" || r.RuleID != "struct-padding" ||
+			loc.ArtifactLocation.URI != w.uri || loc.Region.StartLine != w.line || loc.Region.StartColumn != w.column {
+			t.Errorf("result %d = %+v at %+v, want %q at %s:%d:%d", i, r, loc, w.message, w.uri, w.line, w.column)
+		}
+	}
+}
+
+func TestWriteSARIFNoResults(t *testing.T) {
+	opts := options{format: "sarif", collect: new(reportCollector)}
+	path := writeFile(t, "package p\n\ntype T struct {\n\tn  int64\n\tok bool\n}\n")
+	captureReport(t, func() error { return processFile(path, opts) })
+	var out bytes.Buffer
+	if err := writeSARIF(&out, opts.collect.report(), "warning"); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(out.Bytes(), []byte(`"results": []`)) {
+		t.Errorf("an optimal struct gives results:\n%s", out.String())
+	}
+}
+
+func TestSARIFURI(t *testing.T) {
+	tests := map[string]string{
+		"p.go":              "p.go",
+		"pkg/a b/p.go":      "pkg/a%20b/p.go",
+		"/src/repo/p.go":    "file:///src/repo/p.go",
+		"./internal/x/y.go": "./internal/x/y.go",
+	}
+	if runtime.GOOS == "windows" {
+		t.Skip("paths are Unix paths")
+	}
+	for path, want := range tests {
+		if got := sarifURI(path); got != want {
+			t.Errorf("sarifURI(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// serveOptions configures the analysis server.
+type serveOptions struct {
+	MaxBytes int64         // largest accepted request body
+	Timeout  time.Duration // limit on handling a single request
+}
+
+// runServe implements the serve subcommand:
+//
+//	padding-size serve [-listen addr] [-max-bytes n] [-timeout d]
+//
+// It returns the process exit code.
+func runServe(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	listen := fs.String("listen", ":8080", "Listen on `address`")
+	maxBytes := fs.Int64("max-bytes", 1<<20, "Reject sources larger than `n` bytes")
+	timeout := fs.Duration("timeout", 10*time.Second, "Abort requests taking longer than `duration`")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: padding-size serve [options]")
+		fs.PrintDefaults()
+	}
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(rest) != 0 {
+		fs.Usage()
+		return 2
+	}
+
+	opts := serveOptions{MaxBytes: *maxBytes, Timeout: *timeout}
+	srv := &http.Server{
+		Addr:              *listen,
+		Handler:           newServer(opts),
+		ReadHeaderTimeout: opts.Timeout,
+		ReadTimeout:       opts.Timeout,
+		WriteTimeout:      2 * opts.Timeout,
+	}
+	fmt.Fprintf(os.Stderr, "padding-size: listening on %s\n", *listen)
+	if err := srv.ListenAndServe(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// newServer returns the handler of the analysis API:
+//
+//	POST /analyze[?arch=GOARCH]  analyze the Go source file in the body
+//	GET  /healthz                report that the server is alive
+//
+// /analyze responds with a padding.Report, or with {"error": "..."} and a
+// client error status. The source is only parsed and type-checked: it is
+// never written to disk or executed, and its imports are not loaded.
+func newServer(opts serveOptions) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok\n")
+	})
+	mux.HandleFunc("POST /analyze", func(w http.ResponseWriter, r *http.Request) {
+		src, err := io.ReadAll(http.MaxBytesReader(w, r.Body, opts.MaxBytes))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("source larger than %d bytes", opts.MaxBytes))
+				return
+			}
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		report, err := analyzeSource(src, r.URL.Query().Get("arch"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+	})
+	return http.TimeoutHandler(mux, opts.Timeout, `{"error":"request timed out"}`)
+}
+
+// writeError responds with status and err as a JSON object.
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+// analyzeSource returns the report of the struct types declared in src, a
+// single Go source file. Without arch, layouts come from the source model
+// used for files. With arch, the file is type-checked and laid out for that
+// GOARCH; since no imports are loaded, imported types are sized as a word.
+func analyzeSource(src []byte, arch string) (padding.Report, error) {
+	var sizes types.Sizes
+	if arch != "" {
+		if sizes = types.SizesFor("gc", arch); sizes == nil {
+			return padding.Report{}, fmt.Errorf("unknown architecture %q", arch)
+		}
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "input.go", src, parser.SkipObjectResolution)
+	if err != nil {
+		return padding.Report{}, err
+	}
+	structs, err := padding.Analyze(fset, file, padding.Options{})
+	if err != nil {
+		return padding.Report{}, err
+	}
+
+	var info *types.Info
+	if sizes != nil {
+		info = &types.Info{Types: make(map[ast.Expr]types.TypeAndValue)}
+		conf := types.Config{
+			Importer: refuseImports{},
+			Sizes:    sizes,
+			Error:    func(error) {}, // unresolved imports are expected
+		}
+		conf.Check(file.Name.Name, fset, []*ast.File{file}, info)
+	}
+
+	reports := make([]padding.StructReport, 0, len(structs))
+	for _, s := range structs {
+		if info != nil {
+			// The type checker can't size type parameters, so
+			// structs using them keep their syntactic layout.
+			if st, ok := info.Types[s.Node].Type.(*types.Struct); ok && !s.ParamSized && !hasTypeParams(st) {
+				s = typedStructInfo(s, st, sizes)
+			}
+		}
+		r := padding.NewStructReport(s)
+		r.Package = file.Name.Name
+		reports = append(reports, r)
+	}
+	return padding.NewReport(reports...), nil
+}
+
+// typedStructInfo returns the layout of s as the type st, with its field
+// types sized by sizes. Field types are shown as written in the source.
+func typedStructInfo(s padding.StructInfo, st *types.Struct, sizes types.Sizes) padding.StructInfo {
+	var exprs []string
+	for _, field := range s.Node.Fields.List {
+		for range max(1, len(field.Names)) {
+			exprs = append(exprs, types.ExprString(field.Type))
+		}
+	}
+
+	fields, layout := padding.Layout(st, sizes)
+	typed := padding.StructInfo{
+		Name:   s.Name,
+		Fields: make([]padding.FieldInfo, len(fields)),
+		Size:   layout.Size,
+		Align:  layout.Align,
+	}
+	for i, f := range fields {
+		typed.Fields[i] = padding.FieldInfo{
+			Name:     f.Field.Name(),
+			Type:     exprs[i],
+			Size:     f.Size,
+			Align:    f.Align,
+			Offset:   f.Offset,
+			Embedded: f.Field.Embedded(),
+		}
+	}
+	return typed
+}
+
+// refuseImports is a types.Importer that loads nothing, so analyzing a
+// source never reads other packages from disk.
+type refuseImports struct{}
+
+func (refuseImports) Import(path string) (*types.Package, error) {
+	return nil, fmt.Errorf("import %q not loaded", path)
+}
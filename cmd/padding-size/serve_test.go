@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+const serveSrc = `package snippet
+
+import "time"
+
+type Event struct {
+	Done bool
+	At   int64
+	Kind bool
+}
+
+type Stamp struct {
+	Ok bool
+	time.Time
+	N  int32
+}
+`
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(newServer(serveOptions{MaxBytes: 1 << 10, Timeout: 5 * time.Second}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func post(t *testing.T, url, body string) (*http.Response, map[string]any) {
+	t.Helper()
+	resp, err := http.Post(url, "text/plain", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var v map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	return resp, v
+}
+
+func TestServeAnalyze(t *testing.T) {
+	srv := newTestServer(t)
+
+	resp, err := http.Post(srv.URL+"/analyze", "text/plain", strings.NewReader(serveSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	var report padding.Report
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	if report.SchemaVersion != padding.SchemaVersion || len(report.Structs) != 2 {
+		t.Fatalf("report = %+v, want version %s and 2 structs", report, padding.SchemaVersion)
+	}
+	event := report.Structs[0]
+	if event.Name != "Event" || event.Package != "snippet" || event.Size != 24 || event.OptimalSize != 16 || event.WastedBytes != 8 {
+		t.Errorf("Event = %+v, want size 24, optimal 16", event)
+	}
+}
+
+func TestServeAnalyzeArch(t *testing.T) {
+	srv := newTestServer(t)
+
+	_, v := post(t, srv.URL+"/analyze?arch=386", serveSrc)
+	data, _ := json.Marshal(v)
+	var report padding.Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	// On 386, int64 is 4-byte aligned.
+	if event := report.Structs[0]; event.Size != 16 || event.Align != 4 || event.Fields[1].Offset != 4 {
+		t.Errorf("Event on 386 = %+v, want size 16, align 4, At at offset 4", event)
+	}
+	// The embedded time.Time is not loaded and counts as a word.
+	stamp := report.Structs[1]
+	var names []string
+	for _, f := range stamp.Fields {
+		names = append(names, f.Name+" "+f.Type)
+	}
+	if got, want := strings.Join(names, ", "), "Ok bool, Time time.Time, N int32"; got != want || stamp.Size != 12 {
+		t.Errorf("Stamp on 386 has fields %q and size %d, want %q and 12", got, stamp.Size, want)
+	}
+
+	resp, v := post(t, srv.URL+"/analyze?arch=pdp11", serveSrc)
+	if resp.StatusCode != http.StatusBadRequest || v["error"] != `unknown architecture "pdp11"` {
+		t.Errorf("unknown arch: status %d, body %v", resp.StatusCode, v)
+	}
+}
+
+func TestServeAnalyzeGenericArch(t *testing.T) {
+	srv := newTestServer(t)
+
+	const src = "package p\n\ntype P[T any] struct {\n\ta bool\n\tv T\n\tb int64\n}\n"
+	resp, v := post(t, srv.URL+"/analyze?arch=amd64", src)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, body %v", resp.StatusCode, v)
+	}
+	data, _ := json.Marshal(v)
+	var report padding.Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	// Sized by its type parameter, P keeps its fields as written.
+	if got := report.Structs; len(got) != 1 || got[0].Name != "P" || len(got[0].Fields) != 3 || got[0].Fields[1].Type != "T" {
+		t.Errorf("Structs = %+v, want P with fields a, v T and b", got)
+	}
+}
+
+func TestServeErrors(t *testing.T) {
+	srv := newTestServer(t)
+
+	resp, v := post(t, srv.URL+"/analyze", "package p\n\ntype T struct {\n\tA bool\n")
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("syntax error: status = %d, want 400", resp.StatusCode)
+	}
+	if v["error"] != "input.go:4:9: expected '}', found 'EOF'" {
+		t.Errorf("syntax error: error = %v", v["error"])
+	}
+
+	resp, v = post(t, srv.URL+"/analyze", "package p\n"+strings.Repeat("// padding\n", 200))
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized body: status = %d, want 413", resp.StatusCode)
+	}
+	if v["error"] != "source larger than 1024 bytes" {
+		t.Errorf("oversized body: error = %v", v["error"])
+	}
+
+	resp, err := http.Get(srv.URL + "/analyze")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET /analyze: status = %d, want 405", resp.StatusCode)
+	}
+}
+
+func TestServeHealthz(t *testing.T) {
+	srv := newTestServer(t)
+	resp, err := http.Get(srv.URL + "/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"go/ast"
+	"go/types"
+	"path"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// skipTypes are the type names given to -skip-has-type: structs with a
+// field of one of them, embedded or not, are never reordered. A name is
+// qualified by the import path or the name of its package, as sync.Mutex,
+// or unqualified for a type of the package of the struct.
+type skipTypes []string
+
+// parseSkipTypes parses the comma-separated names of -skip-has-type.
+func parseSkipTypes(list string) skipTypes {
+	var names skipTypes
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// match returns the name of l naming the type name declared in the package
+// with the given path and name, or "" if there is none. local tells whether
+// the type is declared in the package of the struct holding it.
+func (l skipTypes) match(pkgPath, pkgName, name string, local bool) string {
+	for _, listed := range l {
+		dot := strings.LastIndex(listed, ".")
+		if dot < 0 {
+			if local && listed == name {
+				return listed
+			}
+			continue
+		}
+		if qual := listed[:dot]; listed[dot+1:] == name && (qual == pkgPath || qual == pkgName) {
+			return listed
+		}
+	}
+	return ""
+}
+
+// heldTypes holds, for the package-level struct types that type-checked,
+// the name of l that one of their fields has the type of, or "" if none
+// does.
+type heldTypes map[structKey]string
+
+// findHeldTypes type-checks the package in dir, and with recursive the
+// packages below it as well, and matches the field types of their
+// package-level struct types against l, through aliases, and arrays of
+// the types listed.
+func findHeldTypes(dir string, recursive bool, l skipTypes) (heldTypes, error) {
+	pkgs, loadErr := loadPackages(dir, recursive, packages.NeedTypes)
+
+	held := make(heldTypes)
+	for _, pkg := range pkgs {
+		if pkg.Types == nil {
+			continue
+		}
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			obj, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || obj.IsAlias() {
+				continue
+			}
+			st, ok := obj.Type().Underlying().(*types.Struct)
+			if !ok {
+				continue
+			}
+			key := structKey{realDir(pkg.Fset.Position(obj.Pos()).Filename), obj.Name()}
+			held[key] = ""
+			for field := range st.Fields() {
+				if listed := l.matchType(field.Type(), pkg.Types); listed != "" {
+					held[key] = listed
+					break
+				}
+			}
+		}
+	}
+	return held, loadErr
+}
+
+// matchType returns the name of l naming t, or the element type of t if t
+// is an array, for a field of a struct of pkg, or "" if there is none.
+func (l skipTypes) matchType(t types.Type, pkg *types.Package) string {
+	for {
+		array, ok := types.Unalias(t).(*types.Array)
+		if !ok {
+			break
+		}
+		t = array.Elem()
+	}
+	named, ok := types.Unalias(t).(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return ""
+	}
+	obj := named.Origin().Obj()
+	return l.match(obj.Pkg().Path(), obj.Pkg().Name(), obj.Name(), obj.Pkg() == pkg)
+}
+
+// holders returns the structs of files with a field of a type of l, with
+// the name of l matched. The package-level structs found in held, which
+// come from the type checker, are taken from it; the others are matched by
+// the selectors their fields are written with, resolved through the
+// imports of their file.
+func (l skipTypes) holders(files []*FileResult, held heldTypes) map[*padding.StructInfo]string {
+	holding := make(map[*padding.StructInfo]string)
+	for _, f := range files {
+		topLevel := topLevelStructs(f.Node)
+		imports := fileImports(f.Node)
+		for i := range f.Structs {
+			s := &f.Structs[i]
+			listed, ok := held[structKey{realDir(f.Path), s.Name}]
+			if !ok || !topLevel[s.Node] {
+				listed = l.matchSource(s.Node, imports)
+			}
+			if listed != "" {
+				holding[s] = listed
+			}
+		}
+	}
+	return holding
+}
+
+// unheld returns the structs of f that are not in holding, which fix
+// rewrites; the declarations of the others are left as they are written.
+func unheld(f *FileResult, holding map[*padding.StructInfo]string) []padding.StructInfo {
+	if len(holding) == 0 {
+		return f.Structs
+	}
+	var structs []padding.StructInfo
+	for i := range f.Structs {
+		if holding[&f.Structs[i]] == "" {
+			structs = append(structs, f.Structs[i])
+		}
+	}
+	return structs
+}
+
+// matchSource returns the name of l that a field of st, embedded or not,
+// is written with, or "" if there is none. imports maps the names of the
+// packages the file imports to their paths.
+func (l skipTypes) matchSource(st *ast.StructType, imports map[string]string) string {
+	if st == nil {
+		return ""
+	}
+	for _, field := range st.Fields.List {
+		t := ast.Unparen(field.Type)
+		for {
+			array, ok := t.(*ast.ArrayType)
+			if !ok || array.Len == nil {
+				break
+			}
+			t = ast.Unparen(array.Elt)
+		}
+		switch x := t.(type) {
+		case *ast.IndexExpr:
+			t = x.X
+		case *ast.IndexListExpr:
+			t = x.X
+		}
+		switch t := t.(type) {
+		case *ast.Ident:
+			if listed := l.match("", "", t.Name, true); listed != "" {
+				return listed
+			}
+		case *ast.SelectorExpr:
+			pkg, ok := t.X.(*ast.Ident)
+			if !ok {
+				continue
+			}
+			pkgPath := imports[pkg.Name]
+			if listed := l.match(pkgPath, pkg.Name, t.Sel.Name, false); listed != "" {
+				return listed
+			}
+		}
+	}
+	return ""
+}
+
+// fileImports maps the names file imports packages under to their paths,
+// taking the last element of the path as the name of a package imported
+// without one.
+func fileImports(file *ast.File) map[string]string {
+	imports := make(map[string]string)
+	for _, spec := range file.Imports {
+		p, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := path.Base(p)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imports[name] = p
+	}
+	return imports
+}
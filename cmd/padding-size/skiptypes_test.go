@@ -0,0 +1,126 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// skipSource declares a struct embedding sync.Mutex, one with a named field
+// of it under another import name, one with an array of a local lock type,
+// and one to fix.
+const skipSource = `package p
+
+import (
+	"sync"
+	s "sync"
+)
+
+type spinlock struct{ state uint32 }
+
+type Embedded struct {
+	ok bool
+	sync.Mutex
+	n int64
+	b bool
+}
+
+type Named struct {
+	ok bool
+	mu s.Mutex
+	n  int64
+	b  bool
+}
+
+type Array struct {
+	ok    bool
+	locks [2]spinlock
+	n     int64
+	b     bool
+}
+
+type Plain struct {
+	ok bool
+	n  int64
+	b  bool
+}
+`
+
+func TestSkipHasType(t *testing.T) {
+	// Outside any module, the package fails to type-check and the field
+	// types are matched as written.
+	path := writeFile(t, skipSource)
+	opts := options{fix: true, skipTypes: parseSkipTypes("sync.Mutex, spinlock"), fixLog: new(fixLog)}
+	out := captureReport(t, func() error { return processPath(filepath.Dir(path), opts, newFileRegistry()) })
+
+	markers := make(map[string]string) // by struct, from the first header
+	for line := range strings.Lines(out) {
+		if rest, ok := strings.CutPrefix(line, "Struct: "); ok {
+			name, _, _ := strings.Cut(rest, " ")
+			if _, ok := markers[name]; !ok {
+				_, markers[name], _ = strings.Cut(strings.TrimSpace(rest), ") (skipped: ")
+			}
+		}
+	}
+	for name, want := range map[string]string{
+		"Embedded": "contains sync.Mutex)",
+		"Named":    "contains sync.Mutex)",
+		"Array":    "contains spinlock)",
+		"Plain":    "",
+	} {
+		if markers[name] != want {
+			t.Errorf("%s is marked %q, want %q:\n%s", name, markers[name], want, out)
+		}
+	}
+	src := readFile(t, path)
+	for _, want := range []string{
+		"type Embedded struct {\n\tok bool\n\tsync.Mutex\n\tn int64\n\tb bool\n}",
+		"type Named struct {\n\tok bool\n\tmu s.Mutex\n\tn  int64\n\tb  bool\n}",
+		"type Array struct {\n\tok    bool\n\tlocks [2]spinlock\n\tn     int64\n\tb     bool\n}",
+		"type Plain struct {\n\tn  int64\n\tok bool\n\tb  bool\n}",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("fixed source lacks %q:\n%s", want, src)
+		}
+	}
+	// The size of sync.Mutex is a guess, which alone keeps the structs
+	// holding it in place.
+	for _, r := range opts.fixLog.structs {
+		want := causeHeldType
+		if r.Struct == "Embedded" || r.Struct == "Named" {
+			want = causeEstimated
+		}
+		if r.Struct != "Plain" && r.Struct != "spinlock" && (r.Status != fixSkipped || r.Cause != want) {
+			t.Errorf("%s: %s (%s), want skipped for %s", r.Struct, r.Status, r.Cause, want)
+		}
+	}
+}
+
+func TestSkipHasTypeChecked(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not available")
+	}
+	// Only the type checker sees through the alias.
+	dir := t.TempDir()
+	for name, data := range map[string]string{
+		"go.mod":   "module example.com/p\n\ngo 1.22\n",
+		"types.go": "package p\n\nimport \"sync\"\n\ntype Lock = sync.Mutex\n\ntype Guarded struct {\n\tok bool\n\tmu Lock\n\tn  int64\n\tb  bool\n}\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	opts := options{fix: true, skipTypes: parseSkipTypes("sync.Mutex"), typeFailures: new(typeFailures)}
+	out := captureReport(t, func() error { return processPath(dir, opts, newFileRegistry()) })
+	if opts.typeFailures.degraded() {
+		t.Fatal(opts.typeFailures.warning())
+	}
+	if !strings.Contains(out, "(skipped: contains sync.Mutex)") {
+		t.Errorf("Guarded is not marked skipped:\n%s", out)
+	}
+	if src := readFile(t, filepath.Join(dir, "types.go")); !strings.Contains(src, "\tok bool\n\tmu Lock\n") {
+		t.Errorf("Guarded was reordered:\n%s", src)
+	}
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/types"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// snapshot is the exact layout of the struct types of some packages for one
+// architecture and compiler, as written by the snapshot subcommand. Structs are sorted by
+// package path and name so that the file diffs cleanly under version
+// control.
+type snapshot struct {
+	Arch     string           `json:"arch"`
+	Compiler string           `json:"compiler,omitempty"` // gccgo, or empty for gc
+	Structs  []structSnapshot `json:"structs"`
+}
+
+// structSnapshot is the layout of a struct type.
+type structSnapshot struct {
+	Package string          `json:"package"` // import path
+	Name    string          `json:"name"`
+	Size    int64           `json:"size"`
+	Align   int64           `json:"align"`
+	Fields  []fieldSnapshot `json:"fields"`
+}
+
+// fieldSnapshot is the placement of a field.
+type fieldSnapshot struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Offset int64  `json:"offset"`
+	Size   int64  `json:"size"`
+	Align  int64  `json:"align"`
+}
+
+// runSnapshot implements the snapshot subcommand:
+//
+//	padding-size snapshot [-o file] [-arch GOARCH] packages
+//	padding-size snapshot -check-snapshot file packages
+//
+// It records the layouts of the struct types of the packages, or checks
+// them against a recorded snapshot, exiting with status 1 if any deviates.
+// It returns the process exit code.
+func runSnapshot(args []string) int {
+	fs := flag.NewFlagSet("snapshot", flag.ContinueOnError)
+	output := fs.String("o", "layouts.json", "Write the snapshot to `file`")
+	arch := fs.String("arch", "", "`GOARCH` to lay out the structs for (default the go command's)")
+	check := fs.String("check-snapshot", "", "Check the layouts against the snapshot `file` instead of writing one")
+	goBuild.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: padding-size snapshot [options] <packages>")
+		fs.PrintDefaults()
+	}
+
+	patterns, err := parseArgs(fs, args)
+	if err != nil {
+		return 2
+	}
+	if err := goBuild.resolve(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if len(patterns) == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	if *check != "" {
+		want, err := readSnapshot(*check)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		// The check lays out the structs as they were recorded.
+		goBuild.compiler = cmp.Or(want.Compiler, padding.CompilerGC)
+		got, err := takeSnapshot(patterns, want.Arch)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		deviations, notes := compareSnapshots(want, got)
+		for _, line := range notes {
+			fmt.Fprintln(os.Stderr, line)
+		}
+		for _, line := range deviations {
+			fmt.Println(line)
+		}
+		if len(deviations) > 0 {
+			return 1
+		}
+		return 0
+	}
+
+	s, err := takeSnapshot(patterns, *arch)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	f, err := os.Create(*output)
+	if err == nil {
+		err = writeSnapshot(f, s)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// takeSnapshot type-checks the packages matching patterns for arch, or for
+// the go command's default architecture if arch is empty, and records the
+// layouts of their non-generic package-level struct types.
+func takeSnapshot(patterns []string, arch string) (snapshot, error) {
+	cfg := goBuild.config("", packages.NeedName|packages.NeedTypes|packages.NeedSyntax|packages.NeedTypesInfo|packages.NeedTypesSizes)
+	if arch == "" {
+		var err error
+		if arch, err = goarch("."); err != nil {
+			return snapshot{}, err
+		}
+	}
+	if types.SizesFor("gc", arch) == nil {
+		return snapshot{}, fmt.Errorf("unknown architecture %q", arch)
+	}
+	cfg.Env = append(os.Environ(), "GOARCH="+arch)
+	pkgs, err := goBuild.load(cfg, patterns...)
+	if err != nil {
+		return snapshot{}, err
+	}
+
+	s := snapshot{Arch: arch, Structs: []structSnapshot{}}
+	if goBuild.gccgo() {
+		s.Compiler = goBuild.compiler
+	}
+	for _, pkg := range pkgs {
+		if len(pkg.Errors) > 0 {
+			return snapshot{}, pkg.Errors[0]
+		}
+		for _, ts := range packageStructs(pkg) {
+			ss := structSnapshot{Package: pkg.PkgPath, Name: ts.name, Size: ts.size, Align: ts.align, Fields: []fieldSnapshot{}}
+			for _, f := range ts.fields {
+				ss.Fields = append(ss.Fields, fieldSnapshot{
+					Name:   f.Field.Name(),
+					Type:   types.TypeString(f.Field.Type(), types.RelativeTo(pkg.Types)),
+					Offset: f.Offset,
+					Size:   f.Size,
+					Align:  f.Align,
+				})
+			}
+			s.Structs = append(s.Structs, ss)
+		}
+	}
+	slices.SortFunc(s.Structs, func(a, b structSnapshot) int {
+		if c := strings.Compare(a.Package, b.Package); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	// Build variants of a package declare the same type in different files.
+	s.Structs = slices.CompactFunc(s.Structs, func(a, b structSnapshot) bool {
+		return a.Package == b.Package && a.Name == b.Name
+	})
+	return s, nil
+}
+
+// writeSnapshot writes s to w as indented JSON.
+func writeSnapshot(w io.Writer, s snapshot) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// readSnapshot reads the snapshot at path.
+func readSnapshot(path string) (snapshot, error) {
+	var s snapshot
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, fmt.Errorf("%s: %v", path, err)
+	}
+	if s.Arch == "" {
+		return s, fmt.Errorf("%s: no architecture recorded", path)
+	}
+	return s, nil
+}
+
+// compareSnapshots returns the deviations of the layouts of got from those
+// recorded in want, one line each, for the packages got covers, and notes
+// on the structs only got has, which nothing can depend on yet. Fields are
+// matched by name, blank fields by their position among the blank ones.
+func compareSnapshots(want, got snapshot) (deviations, notes []string) {
+	checked := make(map[string]bool)
+	current := make(map[string]structSnapshot)
+	for _, s := range got.Structs {
+		checked[s.Package] = true
+		current[s.Package+"."+s.Name] = s
+	}
+	for _, w := range want.Structs {
+		key := w.Package + "." + w.Name
+		if !checked[w.Package] {
+			continue
+		}
+		g, ok := current[key]
+		if !ok {
+			deviations = append(deviations, fmt.Sprintf("%s: removed", key))
+			continue
+		}
+		delete(current, key)
+		if g.Size != w.Size {
+			deviations = append(deviations, fmt.Sprintf("%s: size %d → %d", key, w.Size, g.Size))
+		}
+		if g.Align != w.Align {
+			deviations = append(deviations, fmt.Sprintf("%s: align %d → %d", key, w.Align, g.Align))
+		}
+		deviations = append(deviations, compareFields(key, w.Fields, g.Fields)...)
+	}
+	for _, s := range got.Structs {
+		if key := s.Package + "." + s.Name; current[key].Name != "" {
+			notes = append(notes, fmt.Sprintf("%s: not in the snapshot", key))
+		}
+	}
+	return deviations, notes
+}
+
+// compareFields returns the deviations of the fields of the struct key.
+func compareFields(key string, want, got []fieldSnapshot) []string {
+	names := func(fields []fieldSnapshot) []string {
+		keys := make([]string, len(fields))
+		blank := 0
+		for i, f := range fields {
+			keys[i] = f.Name
+			if f.Name == "_" {
+				blank++
+				keys[i] = fmt.Sprintf("_#%d", blank)
+			}
+		}
+		return keys
+	}
+	current := make(map[string]fieldSnapshot)
+	for i, name := range names(got) {
+		current[name] = got[i]
+	}
+	var deviations []string
+	for i, name := range names(want) {
+		w := want[i]
+		g, ok := current[name]
+		if !ok {
+			deviations = append(deviations, fmt.Sprintf("%s.%s: removed (offset %d, size %d)", key, name, w.Offset, w.Size))
+			continue
+		}
+		delete(current, name)
+		var changes []string
+		if g.Type != w.Type {
+			changes = append(changes, fmt.Sprintf("type %s → %s", w.Type, g.Type))
+		}
+		if g.Offset != w.Offset {
+			changes = append(changes, fmt.Sprintf("offset %d → %d", w.Offset, g.Offset))
+		}
+		if g.Size != w.Size {
+			changes = append(changes, fmt.Sprintf("size %d → %d", w.Size, g.Size))
+		}
+		if g.Align != w.Align {
+			changes = append(changes, fmt.Sprintf("align %d → %d", w.Align, g.Align))
+		}
+		if len(changes) > 0 {
+			deviations = append(deviations, fmt.Sprintf("%s.%s: %s", key, name, strings.Join(changes, ", ")))
+		}
+	}
+	for i, name := range names(got) {
+		if _, ok := current[name]; ok {
+			g := got[i]
+			deviations = append(deviations, fmt.Sprintf("%s.%s: added (offset %d, size %d, align %d)", key, name, g.Offset, g.Size, g.Align))
+		}
+	}
+	return deviations
+}
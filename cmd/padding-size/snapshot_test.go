@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	dir := lockFixture(t)
+	t.Chdir(dir)
+
+	if code := runSnapshot([]string{"-arch", "amd64", "-o", "layouts.json", "."}); code != 0 {
+		t.Fatalf("snapshot exited with %d", code)
+	}
+	s, err := readSnapshot("layouts.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, st := range s.Structs {
+		names = append(names, st.Package+"."+st.Name)
+	}
+	if want := []string{"example.com/wire.Frame", "example.com/wire.Header", "example.com/wire.state"}; !slices.Equal(names, want) {
+		t.Errorf("snapshot structs = %v, want %v", names, want)
+	}
+
+	// The same layouts written again give the same file.
+	first, err := os.ReadFile("layouts.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again bytes.Buffer
+	if err := writeSnapshot(&again, s); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first, again.Bytes()) {
+		t.Errorf("snapshot not written deterministically:\n%s\nthen:\n%s", first, again.Bytes())
+	}
+
+	if code := runSnapshot([]string{"-check-snapshot", "layouts.json", "."}); code != 0 {
+		t.Errorf("check of unchanged layouts exited with %d", code)
+	}
+}
+
+func TestCompareSnapshots(t *testing.T) {
+	dir := lockFixture(t)
+	t.Chdir(dir)
+	want, err := takeSnapshot([]string{"."}, "amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := os.ReadFile("wire.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed := strings.NewReplacer(
+		"\tFlags   uint16\n", "\tFlags   uint32\n",
+		"\tn  int32\n\tok bool\n", "\tn  int32\n\tok bool\n\tid int64\n",
+		"\tlast bool\n", "",
+	).Replace(string(src))
+	if err := os.WriteFile(filepath.Join(dir, "wire.go"), []byte(changed+"\ntype Fresh struct{ a int64 }\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := takeSnapshot([]string{"."}, "amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deviations, notes := compareSnapshots(want, got)
+	wantDeviations := []string{
+		"example.com/wire.Frame.Header: size 16 → 24",
+		"example.com/wire.Frame.Payload: offset 16 → 24",
+		"example.com/wire.Frame.Mutex: offset 40 → 48",
+		"example.com/wire.Frame.last: removed (offset 48, size 1)",
+		"example.com/wire.Header: size 16 → 24",
+		"example.com/wire.Header.Flags: type uint16 → uint32, size 2 → 4, align 2 → 4",
+		"example.com/wire.Header.Version: offset 6 → 8",
+		"example.com/wire.Header._#1: offset 7 → 9",
+		"example.com/wire.Header.Length: offset 8 → 16",
+		"example.com/wire.state: size 8 → 16",
+		"example.com/wire.state: align 4 → 8",
+		"example.com/wire.state.id: added (offset 8, size 8, align 8)",
+	}
+	if !slices.Equal(deviations, wantDeviations) {
+		t.Errorf("deviations:\n%s\nwant:\n%s", strings.Join(deviations, "\n"), strings.Join(wantDeviations, "\n"))
+	}
+	if want := []string{"example.com/wire.Fresh: not in the snapshot"}; !slices.Equal(notes, want) {
+		t.Errorf("notes = %v, want %v", notes, want)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// defaultStatsTop is the number of field types -stats lists unless -top
+// says otherwise.
+const defaultStatsTop = 10
+
+// writePaddingByType writes the field types causing the most padding across
+// the structs of r to w, with the bytes attributed to each and the number
+// of structs they pad, followed by the total. If top is positive, only the
+// first top types are listed, and otherwise defaultStatsTop; the total still
+// covers all of them.
+func writePaddingByType(w io.Writer, r padding.Report, top int) error {
+	types := padding.AggregatePaddingByType(r.Structs)
+	var total int64
+	for _, t := range types {
+		total += t.Bytes
+	}
+	if top <= 0 {
+		top = defaultStatsTop
+	}
+	if len(types) > top {
+		types = types[:top]
+	}
+
+	fmt.Fprintf(w, "Padding by field type:\n")
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "PADDING\tSTRUCTS\t  TYPE\n")
+	for _, t := range types {
+		fmt.Fprintf(tw, "%s\t%d\t  %s\n", formatBytes(t.Bytes), t.Structs, t.Type)
+	}
+	fmt.Fprintf(tw, "%s\t\t  total\n", formatBytes(total))
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
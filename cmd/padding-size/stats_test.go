@@ -0,0 +1,29 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestWritePaddingByType(t *testing.T) {
+	r := padding.NewReport(
+		padding.StructReport{Name: "A", PaddingByType: map[string]int64{"int64": 1500, "bool": 3}},
+		padding.StructReport{Name: "B", PaddingByType: map[string]int64{"time.Time": 700, "int64": 500}},
+	)
+	var b strings.Builder
+	if err := writePaddingByType(&b, r, 2); err != nil {
+		t.Fatal(err)
+	}
+	want := `Padding by field type:
+         PADDING  STRUCTS  TYPE
+  2000 (2.0 KiB)        2  int64
+             700        1  time.Time
+  2703 (2.6 KiB)           total
+
+`
+	if got := b.String(); got != want {
+		t.Errorf("table:\n%s\nwant:\n%s", got, want)
+	}
+}
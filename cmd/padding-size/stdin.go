@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io"
+	"os"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// stdinPath is the path argument standing for standard input, whose source
+// is reported as the file stdinName.
+const (
+	stdinPath = "-"
+	stdinName = "<stdin>"
+)
+
+// stdin is where the source of stdinPath is read from.
+var stdin io.Reader = os.Stdin
+
+// stdinConflicts are the options that need the package of the file on disk,
+// or the file itself, and so can't be used with stdinPath.
+var stdinConflicts = []string{
+	"annotate", "write-annotations", "print-fixed", "verify", "fix-literals", "skip-has-type",
+	"alloc-sites", "false-sharing", "pointers", "types", "external-waste", "promoted", "nested",
+	"generics", "globals", "include-deps",
+}
+
+// processStdin analyzes the source read from r as a file of its own, named
+// stdinName. With fixOut set, the fixed source, or the source as it was if
+// fix leaves it alone, is written to it in place of the report. Only the
+// source itself is checked for what stops fix from reordering a struct, as
+// its unkeyed composite literals: there is no package on disk to
+// type-check.
+func processStdin(r io.Reader, opts options) error {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	f, err := loadSource(stdinName, src, padding.NewCache())
+	if err != nil {
+		return err
+	}
+	var files []*FileResult
+	if f != nil {
+		files = append(files, f)
+	}
+	if opts.fixOut == nil {
+		return processLoaded(files, opts)
+	}
+	if f != nil {
+		opts.literals = fileUnkeyedLiterals(f)
+	}
+
+	out := opts.fixOut
+	var fixed bytes.Buffer
+	opts.fixOut = &fixed
+	opts.write = func([]byte) {} // out carries the source, not the report
+	if err := processLoaded(files, opts); err != nil {
+		return err
+	}
+	if fixed.Len() > 0 {
+		src = fixed.Bytes()
+	}
+	_, err = out.Write(src)
+	return err
+}
+
+// fileUnkeyedLiterals finds, without the type checker, the unkeyed composite
+// literals in f of the struct types f declares at package level: those
+// naming the type, and those eliding it in the elements of arrays, slices
+// and maps.
+func fileUnkeyedLiterals(f *FileResult) unkeyedLiterals {
+	structs := make(map[string]bool)
+	for _, decl := range f.Node.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			spec := spec.(*ast.TypeSpec)
+			if _, ok := spec.Type.(*ast.StructType); ok && !spec.Assign.IsValid() {
+				structs[spec.Name.Name] = true
+			}
+		}
+	}
+
+	literals := make(unkeyedLiterals)
+	elided := make(map[*ast.CompositeLit]ast.Expr) // the types of elided literals
+	ast.Inspect(f.Node, func(n ast.Node) bool {
+		lit, ok := n.(*ast.CompositeLit)
+		if !ok {
+			return true
+		}
+		typ := lit.Type
+		if typ == nil {
+			typ = elided[lit]
+		}
+		if elem := elementType(typ); elem != nil {
+			for _, elt := range lit.Elts {
+				if kv, ok := elt.(*ast.KeyValueExpr); ok {
+					elt = kv.Value
+				}
+				if c, ok := elt.(*ast.CompositeLit); ok && c.Type == nil {
+					elided[c] = elem
+				}
+			}
+		}
+
+		name := literalTypeName(typ, lit.Type == nil)
+		if !structs[name] || len(lit.Elts) == 0 {
+			return true
+		}
+		if _, keyed := lit.Elts[0].(*ast.KeyValueExpr); keyed {
+			return true
+		}
+		key := structKey{realDir(f.Path), name}
+		t := literals[key]
+		if t == nil {
+			t = &unkeyedType{moves: true}
+			literals[key] = t
+		}
+		t.literals = append(t.literals, unkeyedLiteral{
+			path:    f.Path,
+			pos:     fmt.Sprintf("%s:%d", f.Path, f.Fset.Position(lit.Lbrace).Line),
+			inScope: true,
+		})
+		return true
+	})
+	return literals
+}
+
+// elementType returns the type of the elements of the array, slice or map
+// type typ, or nil.
+func elementType(typ ast.Expr) ast.Expr {
+	switch typ := typ.(type) {
+	case *ast.ArrayType:
+		return typ.Elt
+	case *ast.MapType:
+		return typ.Value
+	}
+	return nil
+}
+
+// literalTypeName returns the name of the type typ of a composite literal,
+// through a pointer if the type is elided, as for the elements of a []*T,
+// or empty if it isn't named.
+func literalTypeName(typ ast.Expr, elided bool) string {
+	switch typ := typ.(type) {
+	case *ast.Ident:
+		return typ.Name
+	case *ast.IndexExpr:
+		return literalTypeName(typ.X, false)
+	case *ast.IndexListExpr:
+		return literalTypeName(typ.X, false)
+	case *ast.StarExpr:
+		if elided {
+			return literalTypeName(typ.X, false)
+		}
+	}
+	return ""
+}
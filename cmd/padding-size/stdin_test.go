@@ -0,0 +1,134 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// runStdin runs padding-size with args, reading input as standard input,
+// and returns its exit status and what it wrote to stdout.
+func runStdin(t *testing.T, input string, args ...string) (int, string) {
+	t.Helper()
+	saved := stdin
+	stdin = strings.NewReader(input)
+	defer func() { stdin = saved }()
+	return runCaptured(t, args...)
+}
+
+const stdinSource = `package demo
+
+type Loose struct {
+	a bool
+	b int64 // the count
+	c bool
+}
+
+// Built unkeyed, so never reordered.
+type Pinned struct {
+	a bool
+	b int64
+	c bool
+}
+
+var pinned = []*Pinned{{true, 1, false}}
+`
+
+func TestStdinFix(t *testing.T) {
+	code, out := runStdin(t, stdinSource, "-fix", "-")
+	if code != 0 {
+		t.Fatalf("exit status %d, want 0; output:\n%s", code, out)
+	}
+	want := strings.Replace(stdinSource, "\ta bool\n\tb int64 // the count\n", "\tb int64 // the count\n\ta bool\n", 1)
+	if out != want {
+		t.Errorf("fixed source:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestStdinFixUnchanged(t *testing.T) {
+	for name, input := range map[string]string{
+		"optimal":    "package demo\n\ntype Tight struct {\n\tb   int64\n\ta,c bool\n}\n",
+		"no structs": "package demo\n\nvar  x = 1 // not gofmt'd\n",
+		"empty":      "package demo",
+	} {
+		t.Run(name, func(t *testing.T) {
+			code, out := runStdin(t, input, "-fix", "-")
+			if code != 0 || out != input {
+				t.Errorf("exit status %d, output %q; want 0 and the input %q", code, out, input)
+			}
+		})
+	}
+}
+
+func TestStdinReport(t *testing.T) {
+	code, out := runStdin(t, stdinSource, "-")
+	if code != 0 {
+		t.Fatalf("exit status %d, want 0", code)
+	}
+	for _, want := range []string{
+		"File: " + stdinName + "\n",
+		"Struct: Loose (size: 24 bytes",
+		"Summary: 1 file scanned, 2 structs, 2 wasting padding",
+		"Loose (" + stdinName + ":3)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestStdinErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		args  []string
+	}{
+		{"parse error", "package demo\n\ntype T struct {\n", []string{"-fix", "-"}},
+		{"parse error without structs", "package demo; var", []string{"-fix", "-"}},
+		{"other paths", stdinSource, []string{"-", "testdata/json"}},
+		{"needs the package", stdinSource, []string{"-fix", "-types", "-"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, out := runStdin(t, tt.input, tt.args...)
+			if code != 2 {
+				t.Errorf("exit status %d, want 2", code)
+			}
+			if out != "" {
+				t.Errorf("stdout holds %q, want nothing", out)
+			}
+		})
+	}
+}
+
+func TestFileUnkeyedLiterals(t *testing.T) {
+	src := `package demo
+
+type A struct{ x, y int }
+type B struct{ x, y int }
+type C struct{ x, y int }
+type D[T any] struct{ x, y T }
+type E struct{ x, y int }
+
+var (
+	a = A{1, 2}
+	b = map[string][]*B{"b": {{1, 2}}}
+	c = &C{x: 1, y: 2}
+	d = D[int]{1, 2}
+	e = []E{}
+)
+`
+	f, err := loadSource(stdinName, []byte(src), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	literals := fileUnkeyedLiterals(f)
+	for name, want := range map[string]string{"A": stdinName + ":10", "B": stdinName + ":11", "D": stdinName + ":13"} {
+		lit, ok := literals[structKey{realDir(stdinName), name}]
+		if !ok || len(lit.literals) != 1 || lit.literals[0].pos != want {
+			t.Errorf("unkeyed literals of %s: %+v, want one at %s", name, lit, want)
+		}
+	}
+	if len(literals) != 3 {
+		t.Errorf("found unkeyed literals of %d types, want 3", len(literals))
+	}
+}
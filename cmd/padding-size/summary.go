@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// defaultOffendersTop is the number of structs the top offenders of the
+// summary list unless -top says otherwise.
+const defaultOffendersTop = 10
+
+// offendersTop returns the number of top offenders to list for -top n.
+func offendersTop(n int) int {
+	if n <= 0 {
+		return defaultOffendersTop
+	}
+	return n
+}
+
+// writeRunSummary writes the totals of r to w, with the number of files
+// scanned if its summary has it, followed by the first top structs wasting
+// the most bytes, or defaultOffendersTop, from the most wasteful.
+func writeRunSummary(w io.Writer, r padding.Report, top int) error {
+	count := func(n int, noun string) string {
+		if n == 1 {
+			return fmt.Sprintf("%d %s", n, noun)
+		}
+		return fmt.Sprintf("%d %ss", n, noun)
+	}
+	_, summary := padding.Summarize(r.Structs)
+	fmt.Fprint(w, "Summary: ")
+	if r.Summary != nil && r.Summary.ScannedFiles > 0 {
+		fmt.Fprintf(w, "%s scanned, ", count(r.Summary.ScannedFiles, "file"))
+	}
+	fmt.Fprintf(w, "%s, %d wasting padding, %d bytes wasted in total\n",
+		count(summary.Structs, "struct"), summary.WastefulStructs, summary.WastedBytes)
+
+	offenders := padding.TopOffenders(r.Structs, offendersTop(top))
+	if len(offenders) == 0 {
+		_, err := fmt.Fprintln(w)
+		return err
+	}
+	fmt.Fprintf(w, "Top offenders:\n")
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "WASTED\tSIZE\tOPTIMAL\t  STRUCT\n")
+	for _, o := range offenders {
+		fmt.Fprintf(tw, "%d\t%d\t%d\t  %s (%s)\n", o.WastedBytes, o.Size, o.OptimalSize, o.Name, o.Position)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
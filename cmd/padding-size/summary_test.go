@@ -0,0 +1,37 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunSummary(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"a.go":    "package p\n\ntype Small struct {\n\ta bool\n\tb int32\n\tc bool\n}\n\ntype Tight struct {\n\tn int64\n}\n",
+		"b.go":    "package p\n\ntype Big struct {\n\ta bool\n\tn int64\n\tb bool\n}\n",
+		"none.go": "package p\n\nfunc f() {}\n",
+	})
+
+	code, out := runCaptured(t, dir)
+	if code != 0 {
+		t.Fatalf("exit code %d:\n%s", code, out)
+	}
+	summary := out[strings.Index(out, "Summary: "):]
+	want := "Summary: 3 files scanned, 3 structs, 2 wasting padding, 12 bytes wasted in total\n" +
+		"Top offenders:\n" +
+		"  WASTED  SIZE  OPTIMAL  STRUCT\n" +
+		"       8    24       16  Big (" + filepath.Join(dir, "b.go") + ":3)\n" +
+		"       4    12        8  Small (" + filepath.Join(dir, "a.go") + ":3)\n\n"
+	if summary != want {
+		t.Errorf("summary:\n%s\nwant:\n%s", summary, want)
+	}
+
+	// -top limits the offenders, not the totals.
+	_, out = runCaptured(t, "-top", "1", dir)
+	summary = out[strings.Index(out, "Summary: "):]
+	if !strings.Contains(summary, "12 bytes wasted in total") || !strings.Contains(summary, "Big (") || strings.Contains(summary, "Small (") {
+		t.Errorf("-top 1 summary:\n%s", summary)
+	}
+}
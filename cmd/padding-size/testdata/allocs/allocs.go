@@ -0,0 +1,71 @@
+package allocs
+
+import "sync"
+
+// Node is allocated through pointers: 2 sites.
+type Node struct {
+	Leaf   bool
+	Weight int64
+	Red    bool
+	Next   *Node
+}
+
+// Sample is allocated in slices: 64 + 1 + 16 + 2 sites.
+type Sample struct {
+	Ok  bool
+	At  int64
+	Tag bool
+}
+
+// Measurement is another name for Sample.
+type Measurement = Sample
+
+// Buffer is allocated by a pool: 1 site.
+type Buffer struct {
+	Dirty   bool
+	N       int64
+	Flushed bool
+}
+
+// Config is never allocated.
+type Config struct {
+	Debug bool
+	Limit int64
+	Trace bool
+}
+
+var pool = sync.Pool{New: func() any { return &Buffer{} }}
+
+func newNode() *Node {
+	return &Node{}
+}
+
+func grow(n *Node) {
+	n.Next = new(Node)
+}
+
+func samples(n int) [][]Sample {
+	return [][]Sample{
+		make([]Sample, 64),
+		make([]Sample, n),
+		make([]Measurement, 0, 16),
+		{{Ok: true}, {Tag: true}},
+	}
+}
+
+// nodes allocates pointers, not Nodes.
+func nodes() []*Node {
+	return make([]*Node, 100)
+}
+
+// local allocates a Node of its own.
+func local() any {
+	type Node struct {
+		A bool
+	}
+	return &Node{}
+}
+
+func config() Config {
+	return Config{Debug: true}
+}
@@ -0,0 +1,56 @@
+package annotate
+
+type Bare struct {
+	a bool
+	b int64
+	c bool
+}
+
+// Documented keeps its doc comment, the advisory following it.
+type Documented struct {
+	on    bool
+	count int64
+	off   bool
+	n     int32
+}
+
+// Stale grew a field since it was annotated.
+//
+// padding-size: 8 bytes wasted (24 -> 16); run padding-size -fix to reorder
+type Stale struct {
+	a bool
+	b int64
+	c bool
+	d bool
+	e int64
+	f bool
+}
+
+// Fixed has been reordered since.
+//
+// padding-size: 8 bytes wasted (24 -> 16); run padding-size -fix to reorder
+type Fixed struct {
+	b int64
+	a bool
+	c bool
+}
+
+// padding-size: 8 bytes wasted (24 -> 16); run padding-size -fix to reorder
+type Alone struct {
+	b int64
+	a bool
+}
+
+type (
+	// Grouped is declared in a group.
+	Grouped struct {
+		a bool
+		b int64
+		c bool
+	}
+
+	Optimal struct {
+		b int64
+		a bool
+	}
+)
@@ -0,0 +1,41 @@
+// Package bench is the fixture of the bench subcommand tests.
+package bench
+
+import (
+	"sync"
+
+	"example.com/bench/clock"
+)
+
+type Kind uint8
+
+type Base struct {
+	ID int64
+}
+
+type Record struct {
+	Active bool
+	*Base
+	Name  string
+	Kind  Kind
+	When  clock.Stamp
+	Zone  clock.Zone
+	Ratio float64
+	Tags  []string
+	sync.Mutex
+	Done  func()
+	Ready bool
+	_     [0]func()
+}
+
+type Tight struct {
+	N  int64
+	OK bool
+}
+
+type Generic[T any] struct {
+	OK    bool
+	Value T
+}
+
+type Count int
@@ -0,0 +1,16 @@
+// Package clock holds the field types of the bench fixture from another
+// package.
+package clock
+
+// Stamp is a point in time.
+type Stamp struct {
+	Wall int64
+	Mono int32
+}
+
+// Zone names a type the fixture cannot name itself.
+type Zone = zone
+
+type zone struct {
+	offset int32
+}
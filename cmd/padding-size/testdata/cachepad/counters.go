@@ -0,0 +1,25 @@
+package counters
+
+import "golang.org/x/sys/cpu"
+
+// Sharded keeps the counters each CPU updates on cache lines of their own.
+type Sharded struct {
+	enabled bool
+	reads   int64
+	_       cpu.CacheLinePad
+	flag    bool
+	writes  int64
+	other   bool
+	_       [64]byte
+	errs    int64
+}
+
+// Window has its pad separate nothing once its bools move after the
+// counters: next then starts on the second cache line even without it.
+type Window struct {
+	first               bool
+	a, b, c, d, e, f, g int64
+	last                bool
+	_                   [64]byte
+	next                int64
+}
@@ -0,0 +1,28 @@
+package cgo
+
+/*
+#include <stdint.h>
+
+struct point {
+	int32_t x, y;
+};
+*/
+import "C"
+
+// Sample mixes C and Go fields: reordering it would pay off if the guessed
+// sizes were right, but they are only guesses.
+type Sample struct {
+	ok    bool
+	count C.int
+	flag  C.char
+	at    C.struct_point
+	name  *C.char
+	total int64
+}
+
+// Local has no C fields, yet is left alone like the rest of the file.
+type Local struct {
+	a bool
+	b int64
+	c bool
+}
@@ -0,0 +1,38 @@
+package decl
+
+// Before is wasteful too, and its odd formatting must survive.
+type Before struct {
+	A   bool
+	B   int64 // b
+	C   bool
+}
+
+// Target is the declaration the go:generate line refers to.
+//
+//go:generate padding-size -fix-decl=
+//padding-size:ok size=24
+type Target struct {
+	Ready bool   `json:"ready"`
+	Count int64  // number of items
+	Done  bool
+}
+
+type (
+	First struct {
+		A bool
+		B int64
+		C bool
+	}
+
+	Second struct {
+		X int8
+		Y int32
+		Z int8
+	}
+)
+
+type Count int
+
+var global = Before{}
+
+func helper() {}
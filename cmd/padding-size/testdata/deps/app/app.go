@@ -0,0 +1,9 @@
+package app
+
+import "example.com/dep"
+
+type Server struct {
+	msgs []dep.Message
+	open bool
+	last dep.Message
+}
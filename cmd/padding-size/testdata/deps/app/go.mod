@@ -0,0 +1,13 @@
+module example.com/app
+
+go 1.21
+
+require (
+	example.com/deeper v1.0.0
+	example.com/dep v1.2.0
+)
+
+replace (
+	example.com/deeper => ../deeper
+	example.com/dep => ../dep
+)
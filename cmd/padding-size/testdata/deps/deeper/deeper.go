@@ -0,0 +1,7 @@
+package deeper
+
+type Header struct {
+	a bool
+	n int64
+	b bool
+}
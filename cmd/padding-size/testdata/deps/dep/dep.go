@@ -0,0 +1,11 @@
+package dep
+
+import "example.com/deeper"
+
+// Message wastes 8 bytes.
+type Message struct {
+	ok   bool
+	id   int64
+	last bool
+	h    deeper.Header
+}
@@ -0,0 +1,23 @@
+package elements
+
+// Entry wastes 8 bytes per instance.
+type Entry struct {
+	a bool
+	n int64
+	b bool
+}
+
+// Packed wastes nothing.
+type Packed struct {
+	n    int64
+	a, b bool
+}
+
+// Table holds arrays and slices of both.
+type Table struct {
+	entries [1024]Entry
+	packed  [16]Packed
+	recent  []Entry
+	ptrs    []*Entry
+	n       int
+}
@@ -0,0 +1,25 @@
+package generics
+
+// Entry has an order optimal for all its instantiations below.
+type Entry[V any] struct {
+	hot bool
+	key string
+	val V
+}
+
+// Pair has none: key and val trade sizes between its instantiations.
+type Pair[K, V any] struct {
+	n    int32
+	flag bool
+	key  K
+	val  V
+}
+
+var (
+	flags  []Entry[bool]
+	counts []Entry[int64]
+	blocks []Entry[[3]int64]
+
+	byKey   []Pair[int64, bool]
+	byValue []Pair[bool, int64]
+)
@@ -0,0 +1,23 @@
+package globals
+
+// entry is 24 bytes, 14 of them padding; ordered optimally it takes 16.
+type entry struct {
+	ok   bool
+	next int64
+	hit  bool
+}
+
+// packed has no padding.
+type packed struct {
+	a, b int32
+}
+
+const buckets = 1 << 4
+
+var table [buckets * 2]entry
+
+var (
+	fallback entry
+	counters [8]packed
+	limit    = 10
+)
@@ -0,0 +1,89 @@
+// Command heap writes the heap profile heap.pb.gz used by the heap profile
+// tests. Regenerate it with
+//
+//	go run ./testdata/heap
+//
+// from cmd/padding-size. The sizes below are for 64-bit platforms.
+package main
+
+import (
+	"log"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+// Event is 24 bytes, 16 with its fields reordered. 1000 are live.
+type Event struct {
+	Done bool
+	At   int64
+	Kind bool
+}
+
+// Entry is 32 bytes, 24 reordered. 9 are live; head is allocated statically.
+type Entry struct {
+	Key   bool
+	Value int64
+	Hits  int32
+	Ok    bool
+	Next  *Entry
+}
+
+// Config is 24 bytes, 16 reordered, and never allocated on the heap.
+type Config struct {
+	Debug bool
+	Limit int64
+	Trace bool
+}
+
+// Packed wastes nothing. 500 are live.
+type Packed struct {
+	A int64
+	B int64
+}
+
+func newEvent(kind bool) *Event {
+	return &Event{Kind: kind}
+}
+
+func (e *Entry) grow() *Entry {
+	e.Next = &Entry{Key: true}
+	return e.Next
+}
+
+var (
+	events []*Event
+	head   = &Entry{}
+	packed []*Packed
+)
+
+func main() {
+	runtime.MemProfileRate = 1
+
+	events = make([]*Event, 1000)
+	for i := range events {
+		events[i] = newEvent(i%2 == 0)
+	}
+	e := head
+	for range 9 {
+		e = e.grow()
+	}
+	packed = make([]*Packed, 500)
+	for i := range packed {
+		packed[i] = new(Packed)
+	}
+	runtime.GC()
+
+	f, err := os.Create("testdata/heap/heap.pb.gz")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := pprof.Lookup("heap").WriteTo(f, 0); err != nil {
+		log.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatal(err)
+	}
+	runtime.KeepAlive(events)
+	runtime.KeepAlive(packed)
+}
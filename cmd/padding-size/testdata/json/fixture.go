@@ -0,0 +1,15 @@
+package fixture
+
+// Loose wastes 8 bytes.
+type Loose struct {
+	ok    bool
+	count int64
+	done  bool
+}
+
+// Tight is laid out well already.
+type Tight struct {
+	count int64
+	flags uint16
+	ok    bool
+}
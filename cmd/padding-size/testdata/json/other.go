@@ -0,0 +1,8 @@
+package fixture
+
+// Pair is declared in a file of its own.
+type Pair struct {
+	a bool
+	b int32
+	c bool
+}
@@ -0,0 +1,42 @@
+package types
+
+// Config is built positionally in this package and its tests.
+type Config struct {
+	OK bool
+	ID int64
+	On bool
+}
+
+// Holder holds a Config, built positionally within its literals.
+type Holder struct {
+	Set    bool
+	Config Config
+	N      int32
+}
+
+// Pair is built in a slice, with elided types.
+type Pair struct {
+	a bool
+	b int64
+	c bool
+}
+
+// Box is built positionally in another package.
+type Box struct {
+	Open bool
+	Size int64
+	Full bool
+}
+
+// Point is built positionally, but already laid out well.
+type Point struct {
+	X, Y int64
+}
+
+// Flags has a blank field, which a keyed literal can't set.
+type Flags struct {
+	Set  bool
+	_    int32
+	Mask int64
+	On   bool
+}
@@ -0,0 +1,15 @@
+package types
+
+var defaults = Config{true, 1, false}
+
+var held = &Holder{
+	true,
+	Config{false, 2, true},
+	3,
+}
+
+var pairs = []Pair{{true, 2, false}, {false, 3, true}}
+
+var origin = Point{0, 0}
+
+var none = Flags{false, 0, 0, false}
@@ -0,0 +1,9 @@
+package types
+
+import "testing"
+
+func TestDefaults(t *testing.T) {
+	if defaults != (Config{true, 1, false}) {
+		t.Fatal(defaults)
+	}
+}
@@ -0,0 +1,5 @@
+package user
+
+import "example.com/literals/types"
+
+var Empty = types.Box{true, 0, false}
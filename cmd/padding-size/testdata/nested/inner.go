@@ -0,0 +1,14 @@
+package nested
+
+// Inner leaves 14 bytes of padding, after A and after C.
+type Inner struct {
+	A bool
+	B int64
+	C bool
+}
+
+// Flags leaves 3 bytes of padding after Y.
+type Flags struct {
+	X int32
+	Y bool
+}
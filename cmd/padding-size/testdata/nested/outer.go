@@ -0,0 +1,20 @@
+package nested
+
+// Outer embeds an Inner and holds another in a field.
+type Outer struct {
+	Inner
+	Other Inner
+	N     int32
+	Ok    bool
+}
+
+// Grid holds arrays of padded structs, and an anonymous struct whose own
+// padding counts as that of Grid.
+type Grid struct {
+	Cells [2]Inner
+	Flags [4]Flags
+	Wrap  struct {
+		F Flags
+		B bool
+	}
+}
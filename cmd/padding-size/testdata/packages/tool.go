@@ -0,0 +1,9 @@
+//go:build ignore
+
+package main
+
+type Options struct {
+	verbose bool
+	depth   int64
+	dry     bool
+}
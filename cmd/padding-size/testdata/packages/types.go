@@ -0,0 +1,7 @@
+package packages
+
+type Pair struct {
+	ok   bool
+	n    int64
+	done bool
+}
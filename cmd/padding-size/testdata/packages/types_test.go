@@ -0,0 +1,14 @@
+package packages_test
+
+// Pair is another type than that of package packages, which the fields of
+// Holder are not sized by.
+type Pair struct {
+	ok bool
+	n  int32
+}
+
+type Holder struct {
+	ready bool
+	pair  Pair
+	more  bool
+}
@@ -0,0 +1,24 @@
+package pointers
+
+// Node holds pointers up to its Next field; the counters after it are not
+// scanned.
+type Node struct {
+	Name  string
+	Next  *Node
+	Hits  int64
+	Total [4]int64
+}
+
+// Table nests pointers in an array of structs.
+type Table struct {
+	Size  int64
+	Slots [2]struct {
+		Key   int64
+		Value any
+	}
+}
+
+// Plain holds no pointers at all.
+type Plain struct {
+	A, B int64
+}
@@ -0,0 +1,3 @@
+module example.com/printfixed
+
+go 1.21
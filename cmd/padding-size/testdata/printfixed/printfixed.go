@@ -0,0 +1,39 @@
+package printfixed
+
+import "reflect"
+
+// Event is sent to subscribers.
+//
+// padding-size:ok size=40
+type Event struct {
+	Seen bool   `json:"seen"`
+	ID   int64  `json:"id"` // unique per stream
+	Done bool   `json:"done,omitempty"`
+	Name string `json:"name"` // display name
+}
+
+type (
+	// Pair is declared in a group.
+	Pair struct {
+		a bool
+		b int64
+		c bool
+	}
+
+	// Tight is laid out well already.
+	Tight struct {
+		n int64
+		b bool
+	}
+)
+
+// Record is read by the position of its fields.
+type Record struct {
+	ok bool
+	id int64
+	on bool
+}
+
+func recordID(r *Record) int64 {
+	return reflect.ValueOf(r).Elem().Field(1).Int()
+}
@@ -0,0 +1,19 @@
+package promoted
+
+type Meta struct {
+	Version uint16
+	ID      uint64
+}
+
+type Base struct {
+	Name string
+	Meta
+}
+
+type Conn struct{ fd int }
+
+type Server struct {
+	open bool
+	Base
+	*Conn
+}
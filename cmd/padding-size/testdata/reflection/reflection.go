@@ -0,0 +1,36 @@
+package reflection
+
+import "reflect"
+
+// Record is read by the position of its fields.
+type Record struct {
+	ok bool
+	id int64
+	on bool
+}
+
+// Entry is read by the name of its fields, and by positions only known at
+// run time.
+type Entry struct {
+	ok bool
+	id int64
+	on bool
+}
+
+func recordID(r *Record) int64 {
+	v := reflect.ValueOf(r).Elem()
+	return v.Field(1).Int()
+}
+
+func recordOn() reflect.StructField {
+	return reflect.VisibleFields(reflect.TypeOf(Record{}))[2]
+}
+
+func entryFields(e Entry) []any {
+	v := reflect.ValueOf(e)
+	fields := []any{v.FieldByName("id").Int()}
+	for i := 0; i < v.NumField(); i++ {
+		fields = append(fields, v.Field(i).Interface())
+	}
+	return fields
+}
@@ -0,0 +1,59 @@
+package sharing
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Counters has two atomic counters on the same cache line.
+type Counters struct {
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// Padded keeps its counters on separate cache lines.
+type Padded struct {
+	hits   atomic.Int64
+	_      [56]byte
+	misses atomic.Int64
+}
+
+// Stats has plain integers updated with the functions of sync/atomic, next
+// to a mutex.
+type Stats struct {
+	mu       sync.Mutex
+	requests int64
+	errors   int64
+	name     string
+}
+
+// Quiet has a single concurrently written field.
+type Quiet struct {
+	mu    sync.Mutex
+	count int64
+	total int64
+}
+
+// Local has an atomically updated field that only one goroutine reads.
+type Local struct {
+	n int64
+	m int64
+}
+
+func (s *Stats) record(failed bool) {
+	atomic.AddInt64(&s.requests, 1)
+	if failed {
+		atomic.AddInt64(&(s.errors), 1)
+	}
+}
+
+func (q *Quiet) add(n int64) {
+	q.mu.Lock()
+	q.count++
+	q.total += n
+	q.mu.Unlock()
+}
+
+func (l *Local) load() int64 {
+	return atomic.LoadInt64(&l.n) + l.m
+}
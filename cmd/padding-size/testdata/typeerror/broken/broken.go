@@ -0,0 +1,19 @@
+package broken
+
+// Handle refers to a type that is declared nowhere, so the package fails to
+// type-check.
+type Handle struct {
+	ok   bool
+	id   int64
+	conn Conn
+}
+
+type Inner struct {
+	a bool
+	b int64
+}
+
+type Outer struct {
+	in Inner
+	c  bool
+}
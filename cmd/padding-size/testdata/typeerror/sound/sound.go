@@ -0,0 +1,11 @@
+package sound
+
+type Inner struct {
+	a bool
+	b int64
+}
+
+type Outer struct {
+	in Inner
+	c  bool
+}
@@ -0,0 +1,6 @@
+package variants
+
+type Common struct {
+	A bool
+	B int64
+}
@@ -0,0 +1,8 @@
+//go:build freebsd || netbsd
+
+package variants
+
+type Handle struct {
+	Fd     uintptr
+	Closed bool
+}
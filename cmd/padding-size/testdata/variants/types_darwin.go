@@ -0,0 +1,7 @@
+package variants
+
+type Handle struct {
+	Closed bool
+	Fd     uintptr
+	Kq     int32
+}
@@ -0,0 +1,6 @@
+package variants
+
+type Handle struct {
+	Fd     uintptr
+	Closed bool
+}
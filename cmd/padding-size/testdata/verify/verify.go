@@ -0,0 +1,28 @@
+// Package verify is the fixture of the -verify tests. The analyzer sizes
+// Exact correctly and Inexact wrongly.
+package verify
+
+import "time"
+
+type Exact struct {
+	A int64
+	B int32
+	C bool
+}
+
+type Inexact struct {
+	A bool
+	B time.Time
+	C int32
+}
+
+type Generic[T any] struct {
+	V T
+}
+
+func f() {
+	type local struct {
+		A bool
+	}
+	_ = local{}
+}
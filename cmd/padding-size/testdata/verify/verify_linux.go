@@ -0,0 +1,5 @@
+package verify
+
+type OnlyLinux struct {
+	A int64
+}
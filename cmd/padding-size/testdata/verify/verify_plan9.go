@@ -0,0 +1,5 @@
+package verify
+
+type OnlyPlan9 struct {
+	A int64
+}
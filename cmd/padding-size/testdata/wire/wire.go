@@ -0,0 +1,32 @@
+// Package wire is the fixture of the lock and gen-consts subcommand tests.
+package wire
+
+import "sync"
+
+type Header struct {
+	Magic   uint32
+	Flags   uint16
+	Version uint8
+	_       uint8
+	Length  uint64
+}
+
+type Frame struct {
+	Header
+	Payload []byte
+	sync.Mutex
+	last bool
+}
+
+type Generic[T any] struct {
+	Value T
+}
+
+type Alias = Header
+
+type Count int
+
+type state struct {
+	n  int32
+	ok bool
+}
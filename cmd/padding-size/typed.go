@@ -0,0 +1,92 @@
+package main
+
+import (
+	"go/ast"
+	"go/types"
+	"path/filepath"
+	"slices"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// structPos is the position of a struct type in its source: the directory
+// of its file, resolved as by realDir, the name of the file and the offset
+// of the struct keyword.
+type structPos struct {
+	dir, file string
+	offset    int
+}
+
+// typedStructType is a struct type as the type checker sees it, with the
+// sizes laying out its field types.
+type typedStructType struct {
+	st    *types.Struct
+	sizes types.Sizes
+}
+
+// typedStructs holds the struct types of type-checked packages by position.
+type typedStructs map[structPos]typedStructType
+
+// findTypedStructs type-checks the package in dir, and with recursive the
+// packages below it as well, and finds every struct type written in their
+// files, named or not, with the sizes of its package.
+func findTypedStructs(dir string, recursive bool) (typedStructs, error) {
+	pkgs, loadErr := loadPackages(dir, recursive, packages.NeedTypes|packages.NeedSyntax|packages.NeedTypesInfo|packages.NeedTypesSizes)
+
+	typed := make(typedStructs)
+	for _, pkg := range pkgs {
+		if pkg.TypesInfo == nil || pkg.TypesSizes == nil {
+			continue
+		}
+		for _, file := range pkg.Syntax {
+			ast.Inspect(file, func(n ast.Node) bool {
+				node, ok := n.(*ast.StructType)
+				if !ok {
+					return true
+				}
+				if st, ok := pkg.TypesInfo.TypeOf(node).(*types.Struct); ok {
+					pos := pkg.Fset.Position(node.Pos())
+					typed[structPos{realDir(pos.Filename), filepath.Base(pos.Filename), pos.Offset}] = typedStructType{st, pkg.TypesSizes}
+				}
+				return true
+			})
+		}
+	}
+	return typed, loadErr
+}
+
+// size sizes the fields of the structs of files with the type checker, as
+// padding.TypeSizes does, for those it found.
+func (t typedStructs) size(files []*FileResult) {
+	for _, f := range files {
+		dir, file := realDir(f.Path), filepath.Base(f.Path)
+		for i := range f.Structs {
+			s := &f.Structs[i]
+			if typed, ok := t[structPos{dir, file, f.Fset.Position(s.Node.Pos()).Offset}]; ok {
+				padding.TypeSizes(s, typed.st, typed.sizes)
+			}
+		}
+	}
+}
+
+// guessedSizes reports whether some field of s is marked estimated, other
+// than those sized by type parameters, which only instantiations size.
+func guessedSizes(s padding.StructInfo) bool {
+	return slices.ContainsFunc(s.Fields, func(f padding.FieldInfo) bool { return f.Estimated && !f.ParamSized })
+}
+
+// markEstimated marks the fields of the structs of files whose sizes are
+// still guesses once the type checker and resolveSizes sized them.
+func markEstimated(files []*FileResult) {
+	named := namedStructs(files)
+	for _, f := range files {
+		for i := range f.Structs {
+			for j := range f.Structs[i].Fields {
+				field := &f.Structs[i].Fields[j]
+				field.Estimated = field.Kind == "" && !padding.IsCacheLinePad(*field) && padding.Estimated(field.Type, named)
+			}
+		}
+	}
+}
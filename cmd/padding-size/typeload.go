@@ -0,0 +1,228 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// typeLoadError is the failure to type-check some or all of the packages the
+// type-checked options look at. The structs of these packages are still
+// reported, from their source alone, and marked estimated.
+type typeLoadError struct {
+	// dir is the directory loaded, and recursive whether the packages
+	// below it were too. Only the packages in dirs failed, unless all is
+	// set, when the whole load did.
+	dir       string
+	recursive bool
+	all       bool
+	dirs      []string
+	err       error // the first error
+}
+
+func (e *typeLoadError) Error() string {
+	return e.err.Error()
+}
+
+// affects reports whether the file at path belongs to a package that could
+// not be type-checked.
+func (e *typeLoadError) affects(path string) bool {
+	dir := realDir(path)
+	if !e.all {
+		return slices.Contains(e.dirs, dir)
+	}
+	root := realDir(filepath.Join(e.dir, "doc.go")) // any file in dir
+	if !e.recursive {
+		return dir == root
+	}
+	rel, err := filepath.Rel(root, dir)
+	return err == nil && filepath.IsLocal(rel)
+}
+
+// packageDirs returns the directories of the packages that failed, as
+// displayed.
+func (e *typeLoadError) packageDirs() []string {
+	if e.all {
+		return []string{displayPath(e.dir)}
+	}
+	dirs := make([]string, len(e.dirs))
+	for i, dir := range e.dirs {
+		dirs[i] = displayPath(dir)
+	}
+	return dirs
+}
+
+// loadPackages type-checks the package in dir, and with recursive the
+// packages below it as well, loading what mode asks for. Packages with
+// errors are left out, and a *typeLoadError describing them is returned
+// along with the others, so the type-checked options still cover the
+// packages that are sound.
+func loadPackages(dir string, recursive bool, mode packages.LoadMode) ([]*packages.Package, error) {
+	return loadSound(goBuild.config(dir, mode|packages.NeedName|packages.NeedFiles), recursive)
+}
+
+// loadSound loads the package in the directory of cfg, and with recursive
+// the packages below it as well, as loadPackages does, for callers that
+// need more of cfg, such as the test variants of the packages.
+func loadSound(cfg *packages.Config, recursive bool) ([]*packages.Package, error) {
+	dir := cfg.Dir
+	pattern := "."
+	if recursive {
+		pattern = "./..."
+	}
+	pkgs, err := goBuild.load(cfg, pattern)
+	if err != nil {
+		return nil, &typeLoadError{dir: dir, recursive: recursive, all: true, err: err}
+	}
+
+	var loadErr *typeLoadError
+	sound := pkgs[:0]
+	for _, pkg := range pkgs {
+		if len(pkg.Errors) == 0 {
+			sound = append(sound, pkg)
+			continue
+		}
+		if loadErr == nil {
+			loadErr = &typeLoadError{dir: dir, recursive: recursive, err: firstError(pkg)}
+		}
+		if len(pkg.GoFiles) == 0 {
+			// A package without files, such as a pattern matching
+			// nothing, leaves no way to tell which files it covers.
+			loadErr.all = true
+			continue
+		}
+		loadErr.dirs = append(loadErr.dirs, realDir(pkg.GoFiles[0]))
+	}
+	if loadErr != nil {
+		return sound, loadErr
+	}
+	return sound, nil
+}
+
+// firstError returns the first error of pkg, preferring type errors, whose
+// positions are more precise than those of the build errors reported for
+// the same mistakes, and leaving out the "# package" lines of the latter.
+func firstError(pkg *packages.Package) error {
+	i := slices.IndexFunc(pkg.Errors, func(e packages.Error) bool {
+		return e.Kind == packages.TypeError
+	})
+	e := pkg.Errors[max(i, 0)]
+	var lines []string
+	for line := range strings.Lines(e.Msg) {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "# ") {
+			lines = append(lines, line)
+		}
+	}
+	msg := strings.Join(lines, "; ")
+	if e.Pos != "" && e.Pos != "-" {
+		pos := e.Pos
+		if file, line, ok := strings.Cut(pos, ".go:"); ok {
+			pos = displayPath(file+".go") + ":" + line
+		}
+		msg = pos + ": " + msg
+	}
+	return errors.New(msg)
+}
+
+// typeFailures gathers the type-checking failures of a run, with the
+// options each of them degraded, so main warns about them once at the end
+// rather than once per option. A nil *typeFailures ignores them.
+type typeFailures struct {
+	mu       sync.Mutex
+	failures []*typeLoadError
+	options  [][]string // the options each failure degraded
+}
+
+// add records that err, as returned by one of the type-checked options'
+// loaders, degraded option. Other errors are returned for the caller to
+// report.
+func (t *typeFailures) add(option string, err error) error {
+	le, ok := err.(*typeLoadError)
+	if !ok {
+		return err
+	}
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, f := range t.failures {
+		if f.dir == le.dir && f.Error() == le.Error() {
+			if !slices.Contains(t.options[i], option) {
+				t.options[i] = append(t.options[i], option)
+			}
+			return nil
+		}
+	}
+	t.failures = append(t.failures, le)
+	t.options = append(t.options, []string{option})
+	return nil
+}
+
+// affects reports whether the results for the file at path lack type
+// information because of a recorded failure.
+func (t *typeFailures) affects(path string) bool {
+	if t == nil {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, f := range t.failures {
+		if f.affects(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// degraded reports whether any failure was recorded.
+func (t *typeFailures) degraded() bool {
+	if t == nil {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.failures) > 0
+}
+
+// warning returns the warning describing the recorded failures, or "" if
+// there are none.
+func (t *typeFailures) warning() string {
+	if !t.degraded() {
+		return ""
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var b strings.Builder
+	b.WriteString("Warning: type-checking failed, so some results are estimated from the source alone:\n")
+	for i, f := range t.failures {
+		what := "packages in " + strings.Join(f.packageDirs(), ", ")
+		if f.all && f.recursive {
+			what = "packages in and below " + displayPath(f.dir)
+		}
+		fmt.Fprintf(&b, "  %s: %v\n", what, f.err)
+		fmt.Fprintf(&b, "    affected: %s; their structs are marked estimated\n", strings.Join(t.options[i], ", "))
+	}
+	return b.String()
+}
+
+// log logs a warning for each recorded failure, with the directories of
+// the packages that failed as file and the options it degraded.
+func (t *typeFailures) log(logger *slog.Logger) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, f := range t.failures {
+		logger.Warn("type-checking failed; results are estimated from the source alone",
+			"file", strings.Join(f.packageDirs(), ", "), "recursive", f.all && f.recursive,
+			"reason", f.err.Error(), "options", t.options[i])
+	}
+}
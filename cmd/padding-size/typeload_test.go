@@ -0,0 +1,74 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTypeCheckFallback(t *testing.T) {
+	dir := filepath.Join("testdata", "typeerror")
+	opts := options{collect: new(reportCollector), nested: true, pointers: true, typeFailures: new(typeFailures)}
+	report := captureReport(t, func() error { return processPath(dir, opts, newFileRegistry()) })
+
+	// The sound package is type-checked as usual, while the structs of
+	// the broken one are still reported, from their source, and labeled.
+	structs := opts.collect.report().Structs
+	if len(structs) != 5 {
+		t.Fatalf("got %d structs, want 5:\n%s", len(structs), report)
+	}
+	for _, s := range structs {
+		broken := strings.Contains(s.File, "broken")
+		if s.Estimated != broken {
+			t.Errorf("%s %s: estimated = %v, want %v", s.File, s.Name, s.Estimated, broken)
+		}
+		if s.Name == "Outer" && (s.NestedPadding != nil) == broken {
+			t.Errorf("%s Outer: nested padding %+v", s.File, s.NestedPadding)
+		}
+		if s.Name == "Handle" && s.Size != 24 {
+			t.Errorf("Handle: size %d, want the estimated 24", s.Size)
+		}
+	}
+	if !strings.Contains(report, "Struct: Handle (size: 24 bytes, align: 8, packed minimum: 17 bytes, padding: 7 inter-field + 0 trailing) (estimated: package failed to type-check)\n") {
+		t.Errorf("report lacks the estimated Handle:\n%s", report)
+	}
+
+	if !opts.typeFailures.degraded() {
+		t.Fatal("no failure recorded")
+	}
+	warning := opts.typeFailures.warning()
+	for _, want := range []string{
+		"packages in " + filepath.Join(dir, "broken") + ": ",
+		filepath.Join(dir, "broken", "broken.go") + ":8:7: undefined: Conn\n",
+		"affected: -pointers, -nested;",
+	} {
+		if !strings.Contains(warning, want) {
+			t.Errorf("warning lacks %q:\n%s", want, warning)
+		}
+	}
+	if strings.Count(warning, "Warning:") != 1 {
+		t.Errorf("want a single warning:\n%s", warning)
+	}
+}
+
+func TestTypeCheckFallbackWholeLoad(t *testing.T) {
+	// A directory outside any module fails to load altogether, leaving
+	// every file in it estimated.
+	path := writeFile(t, "package types\n\ntype T struct {\n\ta bool\n\tb int64\n}\n")
+	dir := filepath.Dir(path)
+
+	failures := new(typeFailures)
+	_, err := findNestedPadding(dir, true)
+	if err == nil {
+		t.Fatal("loading outside a module succeeded")
+	}
+	if err := failures.add("-nested", err); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if !failures.affects(path) || !failures.affects(filepath.Join(dir, "sub", "x.go")) {
+		t.Error("files of the failed load not affected")
+	}
+	if failures.affects(filepath.Join(filepath.Dir(dir), "other.go")) {
+		t.Error("file outside the failed load affected")
+	}
+}
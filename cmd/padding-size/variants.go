@@ -0,0 +1,234 @@
+package main
+
+import (
+	"cmp"
+	"go/ast"
+	"go/build"
+	"go/build/constraint"
+	"go/version"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// knownOS and knownArch mirror the GOOS and GOARCH values the go command
+// recognizes in file name suffixes.
+var knownOS = map[string]bool{
+	"aix": true, "android": true, "darwin": true, "dragonfly": true,
+	"freebsd": true, "hurd": true, "illumos": true, "ios": true,
+	"js": true, "linux": true, "nacl": true, "netbsd": true,
+	"openbsd": true, "plan9": true, "solaris": true, "wasip1": true,
+	"windows": true, "zos": true,
+}
+
+var knownArch = map[string]bool{
+	"386": true, "amd64": true, "amd64p32": true, "arm": true,
+	"armbe": true, "arm64": true, "arm64be": true, "loong64": true,
+	"mips": true, "mipsle": true, "mips64": true, "mips64le": true,
+	"mips64p32": true, "mips64p32le": true, "ppc": true, "ppc64": true,
+	"ppc64le": true, "riscv": true, "riscv64": true, "s390": true,
+	"s390x": true, "sparc": true, "sparc64": true, "wasm": true,
+}
+
+// unixOS lists the GOOS values satisfying the unix build tag.
+var unixOS = map[string]bool{
+	"aix": true, "android": true, "darwin": true, "dragonfly": true,
+	"freebsd": true, "hurd": true, "illumos": true, "ios": true,
+	"linux": true, "netbsd": true, "openbsd": true, "solaris": true,
+}
+
+// hasTag reports whether a build tag is satisfied for the target of s: its
+// GOOS, GOARCH and compiler, the running ones by default, the tags they
+// imply, the -tags given, and the Go versions up to the running one. cgo
+// counts only for the running platform, where the go command enables it by
+// default.
+func (s goSettings) hasTag(tag string) bool {
+	goos, arch := cmp.Or(s.goos, runtime.GOOS), cmp.Or(s.arch, runtime.GOARCH)
+	switch tag {
+	case goos, arch, cmp.Or(s.compiler, padding.CompilerGC):
+		return true
+	case "unix":
+		return unixOS[goos]
+	case "linux":
+		return goos == "android"
+	case "darwin":
+		return goos == "ios"
+	case "solaris":
+		return goos == "illumos"
+	case "cgo":
+		return goos == runtime.GOOS && arch == runtime.GOARCH && build.Default.CgoEnabled
+	}
+	if slices.Contains(s.tags, tag) {
+		return true
+	}
+	if v := constraint.GoVersion(&constraint.TagExpr{Tag: tag}); v != "" && version.IsValid(runtime.Version()) {
+		return version.Compare(v, runtime.Version()) <= 0
+	}
+	return false
+}
+
+// builds reports whether the file at path, parsed at least up to its
+// package clause into node, is built for the target of s. Files whose
+// constraints can't be parsed count as built.
+func (s goSettings) builds(path string, node *ast.File) bool {
+	expr := fileConstraint(path, node)
+	return expr == nil || expr.Eval(s.hasTag)
+}
+
+// fileConstraint returns the build constraint a file is restricted to,
+// combining its _GOOS/_GOARCH file name suffix with its //go:build (or
+// legacy // +build) lines. It returns nil for files built on every platform
+// and for constraints it cannot parse.
+func fileConstraint(path string, node *ast.File) constraint.Expr {
+	expr := nameConstraint(filepath.Base(path))
+
+	var lineExpr constraint.Expr
+	var plusExprs []constraint.Expr
+	for _, group := range node.Comments {
+		if group.Pos() >= node.Package {
+			break
+		}
+		for _, c := range group.List {
+			switch {
+			case constraint.IsGoBuild(c.Text):
+				x, err := constraint.Parse(c.Text)
+				if err != nil {
+					return nil
+				}
+				lineExpr = x
+			case constraint.IsPlusBuild(c.Text):
+				x, err := constraint.Parse(c.Text)
+				if err != nil {
+					return nil
+				}
+				plusExprs = append(plusExprs, x)
+			}
+		}
+	}
+	if lineExpr == nil {
+		for _, x := range plusExprs {
+			lineExpr = and(lineExpr, x)
+		}
+	}
+
+	return and(expr, lineExpr)
+}
+
+// nameConstraint derives the implicit constraint of a file name such as
+// foo_linux.go or foo_windows_amd64_test.go.
+func nameConstraint(name string) constraint.Expr {
+	name = strings.TrimSuffix(name, ".go")
+	name = strings.TrimSuffix(name, "_test")
+
+	// As with the go command, the part before the first underscore never
+	// counts, so linux.go is an ordinary file.
+	i := strings.Index(name, "_")
+	if i < 0 {
+		return nil
+	}
+	parts := strings.Split(name[i:], "_")
+
+	n := len(parts)
+	if n >= 2 && knownOS[parts[n-2]] && knownArch[parts[n-1]] {
+		return and(&constraint.TagExpr{Tag: parts[n-2]}, &constraint.TagExpr{Tag: parts[n-1]})
+	}
+	if knownOS[parts[n-1]] || knownArch[parts[n-1]] {
+		return &constraint.TagExpr{Tag: parts[n-1]}
+	}
+	return nil
+}
+
+func and(x, y constraint.Expr) constraint.Expr {
+	if x == nil {
+		return y
+	}
+	if y == nil {
+		return x
+	}
+	return &constraint.AndExpr{X: x, Y: y}
+}
+
+// foldVariants finds structs that several build variants of a package declare
+// with the same layout. The first declaration is kept and lists every variant
+// it stands for in Variants; the others are returned so they can be left out of
+// the report. Variant-specific layouts are labeled with their own constraint,
+// and with all, so are the structs only one variant declares.
+func foldVariants(files []*FileResult, all bool) map[*padding.StructInfo]bool {
+	type key struct {
+		dir, pkg, name string
+	}
+	type member struct {
+		s       *padding.StructInfo
+		variant string
+	}
+
+	groups := make(map[key][]member)
+	var keys []key
+	for _, f := range files {
+		if f.Variant == "" {
+			continue
+		}
+		for i := range f.Structs {
+			k := key{filepath.Dir(f.Path), f.Package, f.Structs[i].Name}
+			if _, ok := groups[k]; !ok {
+				keys = append(keys, k)
+			}
+			groups[k] = append(groups[k], member{&f.Structs[i], f.Variant})
+		}
+	}
+
+	folded := make(map[*padding.StructInfo]bool)
+	for _, k := range keys {
+		members := groups[k]
+		if len(members) < 2 {
+			if all {
+				members[0].s.Variants = []string{members[0].variant}
+			}
+			continue
+		}
+
+		var kept []*padding.StructInfo
+		for _, m := range members {
+			var same *padding.StructInfo
+			for _, s := range kept {
+				if sameLayout(*s, *m.s) && !contains(s.Variants, m.variant) {
+					same = s
+					break
+				}
+			}
+			if same != nil {
+				same.Variants = append(same.Variants, m.variant)
+				folded[m.s] = true
+				continue
+			}
+			m.s.Variants = []string{m.variant}
+			kept = append(kept, m.s)
+		}
+	}
+	return folded
+}
+
+func sameLayout(a, b padding.StructInfo) bool {
+	if a.Size != b.Size || a.Align != b.Align || len(a.Fields) != len(b.Fields) {
+		return false
+	}
+	for i := range a.Fields {
+		fa, fb := a.Fields[i], b.Fields[i]
+		if fa.Name != fb.Name || fa.Type != fb.Type || fa.Tag != fb.Tag || fa.Offset != fb.Offset {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
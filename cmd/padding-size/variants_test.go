@@ -0,0 +1,197 @@
+package main
+
+import (
+	"go/ast"
+	"go/build/constraint"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNameConstraint(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"foo.go", ""},
+		{"linux.go", ""},
+		{"foo_linux.go", "linux"},
+		{"foo_amd64.go", "amd64"},
+		{"foo_linux_amd64.go", "linux && amd64"},
+		{"foo_windows_test.go", "windows"},
+		{"foo_test.go", ""},
+		{"foo_bar.go", ""},
+	}
+
+	for _, tt := range tests {
+		got := ""
+		if expr := nameConstraint(tt.name); expr != nil {
+			got = expr.String()
+		}
+		if got != tt.want {
+			t.Errorf("nameConstraint(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFoldVariants(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "variants", "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var files []*FileResult
+	for _, path := range paths {
+		f, err := loadFile(path, nil)
+		if err != nil {
+			t.Fatalf("loadFile(%s) failed: %v", path, err)
+		}
+		files = append(files, f)
+	}
+
+	folded := foldVariants(files, false)
+
+	type reported struct {
+		File     string
+		Struct   string
+		Variants []string
+	}
+	var got []reported
+	for _, f := range files {
+		for i := range f.Structs {
+			if !folded[&f.Structs[i]] {
+				got = append(got, reported{filepath.Base(f.Path), f.Structs[i].Name, f.Structs[i].Variants})
+			}
+		}
+	}
+
+	want := []reported{
+		{"types.go", "Common", nil},
+		{"types_bsd.go", "Handle", []string{"freebsd || netbsd", "linux", "windows"}},
+		{"types_darwin.go", "Handle", []string{"darwin"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Reported structs = %+v, want %+v", got, want)
+	}
+}
+
+func TestFileConstraintPlusBuild(t *testing.T) {
+	node := &ast.File{
+		Package: 100,
+		Comments: []*ast.CommentGroup{{List: []*ast.Comment{
+			{Slash: 1, Text: "// +build linux darwin"},
+			{Slash: 30, Text: "// +build amd64"},
+		}}},
+	}
+	expr := fileConstraint("foo.go", node)
+	if expr == nil || expr.String() != "(linux || darwin) && amd64" {
+		t.Errorf("fileConstraint() = %v, want (linux || darwin) && amd64", expr)
+	}
+}
+
+func TestHasTag(t *testing.T) {
+	linux := goSettings{goos: "linux", arch: "amd64", tags: []string{"integration"}}
+	tests := []struct {
+		s    goSettings
+		expr string
+		want bool
+	}{
+		{linux, "linux && amd64", true},
+		{linux, "unix && gc", true},
+		{linux, "windows || arm64", false},
+		{linux, "integration && !purego", true},
+		{linux, "ignore", false},
+		{linux, "go1.1", true},
+		{linux, "go1.999", false},
+		{goSettings{goos: "android", arch: "arm64"}, "linux && unix", true},
+		{goSettings{goos: "ios", arch: "arm64"}, "darwin", true},
+		{goSettings{goos: "plan9", arch: "386"}, "unix", false},
+		{goSettings{goos: "linux", arch: "amd64", compiler: "gccgo"}, "gccgo && !gc", true},
+	}
+	for _, tt := range tests {
+		expr, err := constraint.Parse("//go:build " + tt.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := expr.Eval(tt.s.hasTag); got != tt.want {
+			t.Errorf("%s for %s/%s with tags %v = %v, want %v", tt.expr, tt.s.goos, tt.s.arch, tt.s.tags, got, tt.want)
+		}
+	}
+}
+
+func TestRunPlatforms(t *testing.T) {
+	dir := filepath.Join("testdata", "variants")
+	tests := []struct {
+		args []string
+		want []string // the file and struct lines of the report
+	}{
+		{
+			args: []string{"-all", "-goos", "linux", "-arch", "amd64"},
+			want: []string{"File: testdata/variants/types.go", "Struct: Common", "File: testdata/variants/types_linux.go", "Struct: Handle"},
+		},
+		{
+			args: []string{"-all", "-goos", "darwin", "-arch", "amd64"},
+			want: []string{"File: testdata/variants/types.go", "Struct: Common", "File: testdata/variants/types_darwin.go", "Struct: Handle"},
+		},
+		{
+			args: []string{"-all", "-goos", "netbsd", "-arch", "amd64"},
+			want: []string{"File: testdata/variants/types.go", "Struct: Common", "File: testdata/variants/types_bsd.go", "Struct: Handle"},
+		},
+		{
+			args: []string{"-all", "-goos", "plan9", "-arch", "amd64"},
+			want: []string{"File: testdata/variants/types.go", "Struct: Common"},
+		},
+		{
+			args: []string{"-all", "-all-platforms"},
+			want: []string{
+				"File: testdata/variants/types.go", "Struct: Common",
+				"File: testdata/variants/types_bsd.go", "Struct: Handle [freebsd || netbsd, linux, windows]",
+				"File: testdata/variants/types_darwin.go", "Struct: Handle [darwin]",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			code, out := runCaptured(t, append(tt.args, dir)...)
+			if code != 0 {
+				t.Fatalf("exit status %d, want 0; output:\n%s", code, out)
+			}
+			var got []string
+			for line := range strings.Lines(out) {
+				if strings.HasPrefix(line, "File: ") || strings.HasPrefix(line, "Struct: ") {
+					// Keep the name and the variants.
+					name, layout, _ := strings.Cut(strings.TrimSpace(line), " (size")
+					if i := strings.LastIndex(layout, ") ["); i >= 0 {
+						name += layout[i+1:]
+					}
+					got = append(got, name)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("report lists:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestRunPackages(t *testing.T) {
+	code, out := runCaptured(t, "-all", "-include-tests", filepath.Join("testdata", "packages"))
+	if code != 0 {
+		t.Fatalf("exit status %d, want 0; output:\n%s", code, out)
+	}
+	for _, want := range []string{
+		"File: testdata/packages/types.go (package packages)\nStruct: Pair (size: 24 bytes",
+		"File: testdata/packages/types_test.go (package packages_test)\nStruct: Pair (size: 8 bytes",
+		// Holder holds the Pair of its own package.
+		"Struct: Holder (size: 16 bytes",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Options") {
+		t.Errorf("report lists the struct of a file built with the ignore tag:\n%s", out)
+	}
+}
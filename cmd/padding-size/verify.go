@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// verifyPrefix marks the lines printed by the verification probes.
+const verifyPrefix = "padding-size-verify:"
+
+// verifyLayouts cross-checks the computed layouts of the structs of files,
+// which come from a single package directory, against the compiler. It adds
+// a test file printing unsafe.Sizeof, Alignof and Offsetof for each struct to
+// the package through a go build overlay, without touching the directory,
+// runs it with go test, and reports every difference as an analyzer bug
+// through diag.
+//
+// Only package-level, non-generic structs of files that are part of the
+// current build can be probed; the others are skipped.
+func verifyLayouts(files []*FileResult, diag func([]byte)) error {
+	targets := verifyTargets(files)
+	if len(targets) == 0 {
+		return nil
+	}
+	dir, err := filepath.Abs(filepath.Dir(files[0].Path))
+	if err != nil {
+		return err
+	}
+	display := filepath.Dir(files[0].Path)
+
+	probe, err := probeSource(files[0].Package, targets)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.MkdirTemp("", "padding-size-verify")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	probePath := filepath.Join(tmp, "probe_test.go")
+	if err := os.WriteFile(probePath, probe, 0o644); err != nil {
+		return err
+	}
+	overlay, err := json.Marshal(map[string]map[string]string{
+		"Replace": {filepath.Join(dir, "padding_size_verify_test.go"): probePath},
+	})
+	if err != nil {
+		return err
+	}
+	overlayPath := filepath.Join(tmp, "overlay.json")
+	if err := os.WriteFile(overlayPath, overlay, 0o644); err != nil {
+		return err
+	}
+
+	cmd := goBuild.command(dir, "test", "-count=1", "-run", "^TestPaddingSizeVerify$", "-v", "-overlay", overlayPath, ".")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("verify: go test failed: %v\n%s", err, out)
+	}
+
+	actual := parseProbeOutput(out)
+	var report bytes.Buffer
+	for _, s := range targets {
+		for _, d := range layoutDiffs(s, actual) {
+			diagnose(func(p []byte) { report.Write(p) }, fmt.Sprintf("Analyzer bug: %s: %s\n", display, d),
+				slog.LevelError, "analyzer bug", "file", display, "struct", s.Name, "reason", d)
+		}
+	}
+	if report.Len() > 0 {
+		report.WriteString("\n")
+		diag(report.Bytes())
+	}
+	return nil
+}
+
+// verifyTargets returns the structs of files that the probes can refer to.
+func verifyTargets(files []*FileResult) []*padding.StructInfo {
+	if len(files) == 0 {
+		return nil
+	}
+	pkg := files[0].Package
+	seen := make(map[string]bool)
+	var targets []*padding.StructInfo
+	for _, f := range files {
+		// External test packages and files excluded from the build
+		// cannot be compiled together with the probes.
+		if f.Package != pkg {
+			continue
+		}
+		if ok, err := build.Default.MatchFile(filepath.Dir(f.Path), filepath.Base(f.Path)); err != nil || !ok {
+			continue
+		}
+
+		probeable := make(map[*ast.StructType]bool)
+		for _, decl := range f.Node.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				if spec, ok := spec.(*ast.TypeSpec); ok && spec.TypeParams == nil && !spec.Assign.IsValid() && spec.Name.Name != "_" {
+					if st, ok := spec.Type.(*ast.StructType); ok {
+						probeable[st] = true
+					}
+				}
+			}
+		}
+		for i := range f.Structs {
+			s := &f.Structs[i]
+			if probeable[s.Node] && !seen[s.Name] {
+				seen[s.Name] = true
+				targets = append(targets, s)
+			}
+		}
+	}
+	return targets
+}
+
+// probeSource returns a test file for package pkg printing the layout of the
+// targets as seen by the compiler.
+func probeSource(pkg string, targets []*padding.StructInfo) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+	fmt.Fprintf(&b, "import (\n\t\"fmt\"\n\t\"testing\"\n\t\"unsafe\"\n)\n\n")
+	fmt.Fprintf(&b, "func TestPaddingSizeVerify(t *testing.T) {\n")
+	for _, s := range targets {
+		fmt.Fprintf(&b, "fmt.Println(%q, %q, unsafe.Sizeof(%s{}))\n", verifyPrefix, s.Name+" size", s.Name)
+		fmt.Fprintf(&b, "fmt.Println(%q, %q, unsafe.Alignof(%s{}))\n", verifyPrefix, s.Name+" align", s.Name)
+		for _, f := range s.Fields {
+			if f.Name == "_" {
+				continue
+			}
+			fmt.Fprintf(&b, "fmt.Println(%q, %q, unsafe.Offsetof(%s{}.%s))\n", verifyPrefix, s.Name+"."+f.Name+" offset", s.Name, f.Name)
+		}
+	}
+	fmt.Fprintf(&b, "}\n")
+	return format.Source(b.Bytes())
+}
+
+// parseProbeOutput returns the values printed by the probes, keyed by what
+// they measure, such as "T size" or "T.f offset".
+func parseProbeOutput(out []byte) map[string]int64 {
+	values := make(map[string]int64)
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		rest, ok := strings.CutPrefix(sc.Text(), verifyPrefix+" ")
+		if !ok {
+			continue
+		}
+		i := strings.LastIndexByte(rest, ' ')
+		if i < 0 {
+			continue
+		}
+		var v int64
+		if _, err := fmt.Sscan(rest[i+1:], &v); err == nil {
+			values[rest[:i]] = v
+		}
+	}
+	return values
+}
+
+// layoutDiffs describes the differences between the computed layout of s and
+// the actual values measured by the probes.
+func layoutDiffs(s *padding.StructInfo, actual map[string]int64) []string {
+	var diffs []string
+	check := func(key, what string, computed int64) {
+		if v, ok := actual[key]; ok && v != computed {
+			diffs = append(diffs, fmt.Sprintf("%s computed as %d, compiler says %d", what, computed, v))
+		}
+	}
+	check(s.Name+" size", "size of struct "+s.Name, s.Size)
+	check(s.Name+" align", "alignment of struct "+s.Name, s.Align)
+	for _, f := range s.Fields {
+		check(s.Name+"."+f.Name+" offset", fmt.Sprintf("offset of %s.%s (%s)", s.Name, f.Name, f.Type), f.Offset)
+	}
+	return diffs
+}
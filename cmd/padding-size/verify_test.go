@@ -0,0 +1,36 @@
+package main
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not available")
+	}
+	if testing.Short() {
+		t.Skip("runs go test")
+	}
+
+	dir := filepath.Join("testdata", "verify")
+	report := captureReport(t, func() error {
+		return processPath(dir, options{verify: true}, newFileRegistry())
+	})
+
+	var bugs []string
+	for _, line := range strings.Split(report, "\n") {
+		if strings.HasPrefix(line, "Analyzer bug: ") {
+			bugs = append(bugs, line)
+		}
+	}
+	want := []string{
+		"Analyzer bug: " + dir + ": size of struct Inexact computed as 24, compiler says 40",
+		"Analyzer bug: " + dir + ": offset of Inexact.C (int32) computed as 16, compiler says 32",
+	}
+	if strings.Join(bugs, "\n") != strings.Join(want, "\n") {
+		t.Errorf("reported bugs:\n%s\nwant:\n%s\nfull report:\n%s", strings.Join(bugs, "\n"), strings.Join(want, "\n"), report)
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"strings"
+
+	"golang.org/x/tools/go/analysis/unitchecker"
+
+	"github.com/zakon47/padding-size/paddingcheck"
+)
+
+// vetTool reports whether padding-size was started by go vet as its
+// -vettool, with the arguments args: asked to describe itself with -V=full
+// or -flags, or to analyze the package of a vet configuration file, which
+// go vet passes last.
+func vetTool(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	switch args[0] {
+	case "-V=full", "-flags":
+		return true
+	}
+	return strings.HasSuffix(args[len(args)-1], ".cfg")
+}
+
+// runVetTool runs the paddingcheck analyzer on the package go vet describes
+// in os.Args, with its flags, and exits.
+func runVetTool() {
+	unitchecker.Main(paddingcheck.Analyzer)
+}
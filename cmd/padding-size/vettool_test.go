@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+func TestVetTool(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{nil, false},
+		{[]string{"-V=full"}, true},
+		{[]string{"-flags"}, true},
+		{[]string{"-paddingcheck.min-waste=8", "/tmp/go-build1/b001/vet.cfg"}, true},
+		{[]string{"/tmp/go-build1/b001/vet.cfg"}, true},
+		{[]string{"-fix", "."}, false},
+		{[]string{"-format=json", "./..."}, false},
+		{[]string{"describe", "time.Time"}, false},
+	}
+	for _, tt := range tests {
+		if got := vetTool(tt.args); got != tt.want {
+			t.Errorf("vetTool(%q) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
@@ -1,3 +1,14 @@
 module github.com/zakon47/padding-size
 
-go 1.21.4
+go 1.26.0
+
+require (
+	github.com/golangci/plugin-module-register v0.1.2
+	github.com/google/pprof v0.0.0-20260115054156-294ebfa9ad83
+	golang.org/x/tools v0.50.0
+)
+
+require (
+	golang.org/x/mod v0.41.0 // indirect
+	golang.org/x/sync v0.23.0 // indirect
+)
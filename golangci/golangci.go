@@ -0,0 +1,93 @@
+// Package golangci registers paddingcheck as a golangci-lint module plugin.
+//
+// To build it into a custom golangci-lint binary, list the module in
+// .custom-gcl.yml:
+//
+//	version: v2.1.0
+//	plugins:
+//	  - module: github.com/zakon47/padding-size
+//	    import: github.com/zakon47/padding-size/golangci
+//	    version: latest
+//
+// run "golangci-lint custom", and enable the linter in .golangci.yml:
+//
+//	linters:
+//	  enable:
+//	    - paddingcheck
+//	  settings:
+//	    custom:
+//	      paddingcheck:
+//	        type: module
+//	        settings:
+//	          min-waste: 8
+//	          skip-generated: true
+//	          preserve-marshal-order: true
+//	          arch: arm64
+//
+// Each setting sets the analyzer flag of the same name; unset settings keep
+// the flag's default.
+package golangci
+
+import (
+	"fmt"
+	"go/types"
+	"strconv"
+
+	"github.com/golangci/plugin-module-register/register"
+	"golang.org/x/tools/go/analysis"
+
+	"github.com/zakon47/padding-size/paddingcheck"
+)
+
+func init() {
+	register.Plugin("paddingcheck", New)
+}
+
+// Settings are the plugin settings from .golangci.yml.
+type Settings struct {
+	MinWaste             *int64  `json:"min-waste"`
+	SkipGenerated        *bool   `json:"skip-generated"`
+	PreserveMarshalOrder *bool   `json:"preserve-marshal-order"`
+	Arch                 *string `json:"arch"`
+}
+
+// New decodes the plugin settings and applies them to the analyzer flags.
+func New(settings any) (register.LinterPlugin, error) {
+	s, err := register.DecodeSettings[Settings](settings)
+	if err != nil {
+		return nil, err
+	}
+
+	flags := make(map[string]string)
+	if s.MinWaste != nil {
+		flags["min-waste"] = strconv.FormatInt(*s.MinWaste, 10)
+	}
+	if s.SkipGenerated != nil {
+		flags["skip-generated"] = strconv.FormatBool(*s.SkipGenerated)
+	}
+	if s.PreserveMarshalOrder != nil {
+		flags["preserve-marshal-order"] = strconv.FormatBool(*s.PreserveMarshalOrder)
+	}
+	if s.Arch != nil {
+		if *s.Arch != "" && types.SizesFor("gc", *s.Arch) == nil {
+			return nil, fmt.Errorf("unknown architecture %q", *s.Arch)
+		}
+		flags["arch"] = *s.Arch
+	}
+	for name, value := range flags {
+		if err := paddingcheck.Analyzer.Flags.Set(name, value); err != nil {
+			return nil, err
+		}
+	}
+	return plugin{}, nil
+}
+
+type plugin struct{}
+
+func (plugin) BuildAnalyzers() ([]*analysis.Analyzer, error) {
+	return []*analysis.Analyzer{paddingcheck.Analyzer}, nil
+}
+
+func (plugin) GetLoadMode() string {
+	return register.LoadModeTypesInfo
+}
@@ -0,0 +1,90 @@
+package golangci_test
+
+import (
+	"testing"
+
+	"github.com/golangci/plugin-module-register/register"
+
+	"github.com/zakon47/padding-size/golangci"
+	"github.com/zakon47/padding-size/paddingcheck"
+)
+
+// restoreFlags resets the analyzer flags changed by a test.
+func restoreFlags(t *testing.T) {
+	t.Helper()
+	old := make(map[string]string)
+	for _, name := range []string{"min-waste", "skip-generated", "preserve-marshal-order", "arch"} {
+		old[name] = paddingcheck.Analyzer.Flags.Lookup(name).Value.String()
+	}
+	t.Cleanup(func() {
+		for name, value := range old {
+			paddingcheck.Analyzer.Flags.Set(name, value)
+		}
+	})
+}
+
+func TestNewAppliesSettings(t *testing.T) {
+	restoreFlags(t)
+
+	newPlugin, err := register.GetPlugin("paddingcheck")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := newPlugin(map[string]any{
+		"min-waste":              8,
+		"skip-generated":         true,
+		"preserve-marshal-order": true,
+		"arch":                   "arm64",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"min-waste":              "8",
+		"skip-generated":         "true",
+		"preserve-marshal-order": "true",
+		"arch":                   "arm64",
+	}
+	for name, value := range want {
+		if got := paddingcheck.Analyzer.Flags.Lookup(name).Value.String(); got != value {
+			t.Errorf("flag -%s = %q, want %q", name, got, value)
+		}
+	}
+
+	analyzers, err := p.BuildAnalyzers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(analyzers) != 1 || analyzers[0] != paddingcheck.Analyzer {
+		t.Errorf("BuildAnalyzers() = %v, want [paddingcheck]", analyzers)
+	}
+	if got := p.GetLoadMode(); got != register.LoadModeTypesInfo {
+		t.Errorf("GetLoadMode() = %q, want %q", got, register.LoadModeTypesInfo)
+	}
+}
+
+func TestNewKeepsDefaults(t *testing.T) {
+	restoreFlags(t)
+
+	if _, err := golangci.New(map[string]any{"arch": "386"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := paddingcheck.Analyzer.Flags.Lookup("min-waste").Value.String(); got != "1" {
+		t.Errorf("flag -min-waste = %q, want the default 1", got)
+	}
+}
+
+func TestNewRejectsBadSettings(t *testing.T) {
+	restoreFlags(t)
+
+	for _, settings := range []map[string]any{
+		{"min_waste": 8},
+		{"min-waste": "eight"},
+		{"arch": "pdp11"},
+	} {
+		if _, err := golangci.New(settings); err == nil {
+			t.Errorf("New(%v) succeeded, want an error", settings)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package padding
+
+import (
+	"fmt"
+	"go/ast"
+	"strings"
+)
+
+// advisoryMarker starts the text of an advisory comment in the doc comment
+// of a struct type declaration. Unlike annotationMarker, it is followed by a
+// space.
+const advisoryMarker = "padding-size: "
+
+// FindAdvisory returns the advisory comment in doc, such as
+//
+//	// padding-size: 16 bytes wasted (56 -> 40); run padding-size -fix to reorder
+//
+// or nil if doc has none. The comment is recognized by its marker followed
+// by a number, whatever the rest of its text.
+func FindAdvisory(doc *ast.CommentGroup) *ast.Comment {
+	if doc == nil {
+		return nil
+	}
+	for _, c := range doc.List {
+		text, ok := strings.CutPrefix(c.Text, "//")
+		if !ok {
+			continue
+		}
+		rest, ok := strings.CutPrefix(strings.TrimPrefix(text, " "), advisoryMarker)
+		if ok && rest != "" && rest[0] >= '0' && rest[0] <= '9' {
+			return c
+		}
+	}
+	return nil
+}
+
+// FormatAdvisory returns the text of the advisory comment for a struct of
+// size bytes that fix would shrink to optimal bytes.
+func FormatAdvisory(size, optimal int64) string {
+	return fmt.Sprintf("// %s%d bytes wasted (%d -> %d); run padding-size -fix to reorder", advisoryMarker, size-optimal, size, optimal)
+}
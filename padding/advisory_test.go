@@ -0,0 +1,48 @@
+package padding_test
+
+import (
+	"go/ast"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestFindAdvisory(t *testing.T) {
+	advisory := padding.FormatAdvisory(56, 40)
+	if want := "// padding-size: 16 bytes wasted (56 -> 40); run padding-size -fix to reorder"; advisory != want {
+		t.Errorf("FormatAdvisory(56, 40) = %q, want %q", advisory, want)
+	}
+
+	tests := []struct {
+		name string
+		doc  []string
+		want int // index of the advisory, or -1
+	}{
+		{"none", []string{"// Config is the configuration."}, -1},
+		{"alone", []string{advisory}, 0},
+		{"own paragraph", []string{"// Config is the configuration.", "//", advisory}, 2},
+		{"stale", []string{"// padding-size: 8 bytes wasted (24 -> 16); run padding-size -fix to reorder"}, 0},
+		{"without space", []string{"//padding-size: 8 bytes wasted"}, 0},
+		{"drift guard", []string{"// padding-size:ok size=16"}, -1},
+		{"prose", []string{"// padding-size: see the README."}, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := &ast.CommentGroup{}
+			for _, text := range tt.doc {
+				doc.List = append(doc.List, &ast.Comment{Text: text})
+			}
+			got := padding.FindAdvisory(doc)
+			var want *ast.Comment
+			if tt.want >= 0 {
+				want = doc.List[tt.want]
+			}
+			if got != want {
+				t.Errorf("FindAdvisory = %v, want %v", got, want)
+			}
+		})
+	}
+	if padding.FindAdvisory(nil) != nil {
+		t.Error("FindAdvisory(nil) != nil")
+	}
+}
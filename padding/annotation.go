@@ -0,0 +1,66 @@
+package padding
+
+import (
+	"fmt"
+	"go/ast"
+	"strconv"
+	"strings"
+)
+
+// annotationMarker starts the text of a drift-guard comment in the doc
+// comment of a struct type declaration.
+const annotationMarker = "padding-size:ok"
+
+// Annotation is a drift-guard comment such as
+//
+//	// padding-size:ok size=48
+//
+// recording the size a struct is expected to have. The marker may also
+// follow the slashes directly, as in //padding-size:ok; gofmt inserts the
+// space in doc comments of package-level declarations, since the marker is
+// not a //tool:directive.
+type Annotation struct {
+	Size    int64        // expected size of the struct in bytes
+	Comment *ast.Comment // the comment holding the annotation
+}
+
+// ParseAnnotation returns the drift-guard annotation in doc, or nil if doc
+// has none. An annotation without a valid size is an error.
+func ParseAnnotation(doc *ast.CommentGroup) (*Annotation, error) {
+	if doc == nil {
+		return nil, nil
+	}
+	for _, c := range doc.List {
+		text, ok := strings.CutPrefix(c.Text, "//")
+		if !ok {
+			continue
+		}
+		rest, ok := strings.CutPrefix(strings.TrimPrefix(text, " "), annotationMarker)
+		if !ok || rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+			continue
+		}
+		a := &Annotation{Size: -1, Comment: c}
+		for _, field := range strings.Fields(rest) {
+			key, value, _ := strings.Cut(field, "=")
+			if key != "size" {
+				continue
+			}
+			size, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || size < 0 {
+				return nil, fmt.Errorf("invalid size %q in %s", value, c.Text)
+			}
+			a.Size = size
+		}
+		if a.Size < 0 {
+			return nil, fmt.Errorf("missing size in %s", c.Text)
+		}
+		return a, nil
+	}
+	return nil, nil
+}
+
+// FormatAnnotation returns the text of a drift-guard comment recording size,
+// in the form gofmt leaves unchanged.
+func FormatAnnotation(size int64) string {
+	return fmt.Sprintf("// %s size=%d", annotationMarker, size)
+}
@@ -0,0 +1,54 @@
+package padding_test
+
+import (
+	"go/ast"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestParseAnnotation(t *testing.T) {
+	tests := []struct {
+		comments []string
+		size     int64 // -1 for no annotation
+		wantErr  bool
+	}{
+		{[]string{"// Plain doc."}, -1, false},
+		{[]string{"// Doc.", "//padding-size:ok size=48"}, 48, false},
+		{[]string{"//padding-size:ok size=0 reason=wire"}, 0, false},
+		{[]string{"// padding-size:ok size=48"}, 48, false},
+		{[]string{"//  padding-size:ok size=48"}, -1, false},
+		{[]string{"/* padding-size:ok size=48 */"}, -1, false},
+		{[]string{"//padding-size:okay size=48"}, -1, false},
+		{[]string{"//padding-size:ok"}, 0, true},
+		{[]string{"//padding-size:ok size=big"}, 0, true},
+		{[]string{"//padding-size:ok size=-8"}, 0, true},
+	}
+	for _, tt := range tests {
+		doc := &ast.CommentGroup{}
+		for _, text := range tt.comments {
+			doc.List = append(doc.List, &ast.Comment{Text: text})
+		}
+		a, err := padding.ParseAnnotation(doc)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseAnnotation(%q) error = %v, want error %v", tt.comments, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		switch {
+		case tt.size < 0 && a != nil:
+			t.Errorf("ParseAnnotation(%q) = size %d, want none", tt.comments, a.Size)
+		case tt.size >= 0 && (a == nil || a.Size != tt.size):
+			t.Errorf("ParseAnnotation(%q) = %+v, want size %d", tt.comments, a, tt.size)
+		}
+	}
+
+	if a, err := padding.ParseAnnotation(nil); a != nil || err != nil {
+		t.Errorf("ParseAnnotation(nil) = %v, %v", a, err)
+	}
+	if got := padding.FormatAnnotation(48); got != "// padding-size:ok size=48" {
+		t.Errorf("FormatAnnotation(48) = %q", got)
+	}
+}
@@ -0,0 +1,121 @@
+package padding_test
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestAnalyzeAnonymous(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filepath.Join("testdata", "anonymous.go"), nil, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	structs, err := padding.Analyze(fset, file, padding.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type found struct {
+		name       string
+		anonymous  bool
+		reportOnly bool
+		size       int64
+		// line and column of the name of a named type, or of the
+		// struct keyword
+		line, column int
+	}
+	want := []found{
+		{"var cache (anonymous struct)", true, false, 40, 6, 11},
+		{"Config", false, false, 40, 15, 6},
+		{"Config.limits (anonymous struct)", true, false, 24, 17, 9},
+		{"anonymous.go:25 (anonymous struct)", true, true, 16, 25, 17},
+		{"anonymous.go:33 (anonymous struct)", true, true, 24, 33, 12},
+		{"anonymous.go:41 (anonymous struct)", true, true, 16, 41, 8},
+		{"Server", false, false, 56, 53, 6},
+		{"Server.tls (anonymous struct)", true, false, 40, 55, 7},
+		{"Server.tls.session (anonymous struct)", true, false, 24, 57, 11},
+		{"Entry", false, false, 24, 67, 6},
+		{"Entry.meta (anonymous struct)", true, true, 24, 68, 7},
+		{"anonymous.go:75 (anonymous struct)", true, true, 24, 75, 25},
+	}
+	var got []found
+	for _, s := range structs {
+		got = append(got, found{s.Name, s.Anonymous, s.ReportOnly, s.Size, s.Pos.Line, s.Pos.Column})
+	}
+	if len(got) != len(want) {
+		t.Fatalf("found %d structs, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("struct %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestRewriteAnonymous(t *testing.T) {
+	fset := token.NewFileSet()
+	orig, err := os.ReadFile(filepath.Join("testdata", "anonymous.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := parser.ParseFile(fset, "anonymous.go", orig, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	structs, err := padding.Analyze(fset, file, padding.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range structs {
+		structs[i] = padding.Optimal(structs[i])
+	}
+	src, err := padding.Rewrite(fset, file, orig, structs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err = parser.ParseFile(fset, "anonymous.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixed, err := padding.Analyze(fset, file, padding.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The package-level variable, the named types and the inline field
+	// types not spelled out again are reordered, the innermost along
+	// with the struct holding it.
+	want := [][]string{
+		{"m", "mu", "hits", "ready", "done"},
+		{"Name", "limits"},
+		{"rate", "max", "burst"},
+		{"verbose", "level"},
+		{"done", "count", "ok"},
+		{"in", "want"},
+		{"addr", "tls"},
+		{"session", "enabled", "strict"},
+		{"ttl", "ticket", "reuse"},
+		{"meta"},
+		{"seen", "at", "ok"},
+		{"seen", "at", "ok"},
+	}
+	if len(fixed) != len(want) {
+		t.Fatalf("found %d structs after Rewrite, want %d", len(fixed), len(want))
+	}
+	for i, s := range fixed {
+		var names []string
+		for _, f := range s.Fields {
+			names = append(names, f.Name)
+		}
+		if !slices.Equal(names, want[i]) {
+			t.Errorf("%s: fields %v after Rewrite, want %v", s.Name, names, want[i])
+		}
+	}
+}
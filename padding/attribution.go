@@ -0,0 +1,65 @@
+package padding
+
+import (
+	"cmp"
+	"slices"
+)
+
+// PaddingByType attributes the padding of s to the types of its fields: a
+// hole between two fields to the type of the second one, whose alignment
+// opened it, and the trailing padding to the type of the first field of the
+// alignment of s, which rounds its size up, or to that of a zero-size last
+// field, which gc pads. Types causing no padding are left out.
+func PaddingByType(s StructInfo) map[string]int64 {
+	byType := make(map[string]int64)
+	for _, h := range Holes(s) {
+		cause := s.Fields[h.After]
+		switch {
+		case h.Before >= 0:
+			cause = s.Fields[h.Before]
+		case !s.padsZeroTail():
+			for _, f := range s.Fields {
+				if f.Align == s.Align {
+					cause = f
+					break
+				}
+			}
+		}
+		byType[cause.Type] += h.Size
+	}
+	return byType
+}
+
+// TypePadding is the padding attributed to a field type across structs.
+type TypePadding struct {
+	Type    string `json:"type"`
+	Bytes   int64  `json:"bytes"`
+	Structs int    `json:"structs"` // structs with padding attributed to it
+}
+
+// AggregatePaddingByType sums the padding attributed to each field type by
+// the reports of structs, as set by CheckPaddingByType, and returns the
+// types by decreasing padding, then by name.
+func AggregatePaddingByType(structs []StructReport) []TypePadding {
+	index := make(map[string]int)
+	var types []TypePadding
+	for _, s := range structs {
+		for typ, bytes := range s.PaddingByType {
+			i, ok := index[typ]
+			if !ok {
+				i = len(types)
+				index[typ] = i
+				types = append(types, TypePadding{Type: typ})
+			}
+			types[i].Bytes += bytes
+			types[i].Structs++
+		}
+	}
+	slices.SortFunc(types, func(a, b TypePadding) int {
+		if c := cmp.Compare(b.Bytes, a.Bytes); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Type, b.Type)
+	})
+	return types
+}
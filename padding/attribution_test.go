@@ -0,0 +1,95 @@
+package padding_test
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// laidOut returns a struct with the given fields, sized as given, laid out in
+// that order.
+func laidOut(fields ...padding.FieldInfo) padding.StructInfo {
+	s := padding.StructInfo{Name: "T", Fields: fields}
+	padding.AnalyzeStruct(&s)
+	return s
+}
+
+func TestPaddingByType(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		s    padding.StructInfo
+		want map[string]int64
+	}{
+		{
+			// flag at 0, 7 bytes before count at 8, ok at 16, 3 bytes
+			// before n at 20, size 24.
+			name: "holes",
+			s: laidOut(
+				padding.FieldInfo{Name: "flag", Type: "bool", Size: 1, Align: 1},
+				padding.FieldInfo{Name: "count", Type: "int64", Size: 8, Align: 8},
+				padding.FieldInfo{Name: "ok", Type: "bool", Size: 1, Align: 1},
+				padding.FieldInfo{Name: "n", Type: "int32", Size: 4, Align: 4},
+			),
+			want: map[string]int64{"int64": 7, "int32": 3},
+		},
+		{
+			// ok at 0, 7 bytes before at at 8, n at 32, 4 trailing bytes
+			// rounding 36 up to the 8-byte alignment of time.Time.
+			name: "trailing",
+			s: laidOut(
+				padding.FieldInfo{Name: "ok", Type: "bool", Size: 1, Align: 1},
+				padding.FieldInfo{Name: "at", Type: "time.Time", Size: 24, Align: 8},
+				padding.FieldInfo{Name: "n", Type: "int32", Size: 4, Align: 4},
+			),
+			want: map[string]int64{"time.Time": 11},
+		},
+		{
+			// n at 0, done at 4 padded to 5, rounded up to 8.
+			name: "zero-size last field",
+			s: laidOut(
+				padding.FieldInfo{Name: "n", Type: "int32", Size: 4, Align: 4},
+				padding.FieldInfo{Name: "done", Type: "struct{}", Size: 0, Align: 1},
+			),
+			want: map[string]int64{"struct{}": 4},
+		},
+		{
+			name: "no padding",
+			s: laidOut(
+				padding.FieldInfo{Name: "count", Type: "int64", Size: 8, Align: 8},
+				padding.FieldInfo{Name: "n", Type: "int32", Size: 4, Align: 4},
+				padding.FieldInfo{Name: "m", Type: "int32", Size: 4, Align: 4},
+			),
+			want: map[string]int64{},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := padding.PaddingByType(tt.s); !maps.Equal(got, tt.want) {
+				t.Errorf("PaddingByType = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAggregatePaddingByType(t *testing.T) {
+	structs := []padding.StructReport{
+		{Name: "A", PaddingByType: map[string]int64{"int64": 7, "int32": 3}},
+		{Name: "B", PaddingByType: map[string]int64{"time.Time": 11}},
+		{Name: "C", PaddingByType: map[string]int64{"int64": 7}},
+		{Name: "D"},
+	}
+	want := []padding.TypePadding{
+		{Type: "int64", Bytes: 14, Structs: 2},
+		{Type: "time.Time", Bytes: 11, Structs: 1},
+		{Type: "int32", Bytes: 3, Structs: 1},
+	}
+	got := padding.AggregatePaddingByType(structs)
+	if len(got) != len(want) {
+		t.Fatalf("AggregatePaddingByType = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("AggregatePaddingByType[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
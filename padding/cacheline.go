@@ -0,0 +1,139 @@
+package padding
+
+import "slices"
+
+// Crossing is a field whose bytes span one or more cache line boundaries.
+type Crossing struct {
+	Field      FieldInfo
+	Boundaries []int64 // offsets of the boundaries inside the field
+}
+
+// CacheLineCrossings returns the fields of s, in their current order, whose
+// bytes span a boundary between cache lines of the given size. A field
+// starting exactly on a boundary does not cross it.
+func CacheLineCrossings(s StructInfo, line int64) []Crossing {
+	var crossings []Crossing
+	for _, f := range s.Fields {
+		if bs := boundaries(f.Offset, f.Size, line); len(bs) > 0 {
+			crossings = append(crossings, Crossing{f, bs})
+		}
+	}
+	return crossings
+}
+
+// LineFit returns by how many bytes size exceeds the largest multiple of
+// line below it, the bytes a struct of that size must shave to fit one cache
+// line fewer, and whether size is a multiple of line itself. A size of less
+// than a line is neither.
+func LineFit(size, line int64) (over int64, aligned bool) {
+	if line <= 0 || size < line {
+		return 0, false
+	}
+	over = size % line
+	return over, over == 0
+}
+
+// CacheLines returns the number of cache lines of the given size a struct
+// of size bytes occupies, starting on a line as the elements of a
+// line-aligned allocation do.
+func CacheLines(size, line int64) int64 {
+	if line <= 0 {
+		return 0
+	}
+	return (size + line - 1) / line
+}
+
+// boundaries returns the offsets of the line boundaries inside the range
+// [offset, offset+size), excluding offset itself.
+func boundaries(offset, size, line int64) []int64 {
+	var bs []int64
+	for b := (offset/line + 1) * line; b < offset+size; b += line {
+		bs = append(bs, b)
+	}
+	return bs
+}
+
+// maxCrossingSearch bounds the number of partial orders CrossingFreeOrder
+// tries, so that structs with many fields of many shapes stay cheap.
+const maxCrossingSearch = 100000
+
+// CrossingFreeOrder returns a field order of s in which no field crosses a
+// boundary between cache lines of the given size and the struct is no larger
+// than it is now: the i-th field of that layout is s.Fields[order[i]]. It
+// prefers orders close to the current one, and reports false if it finds
+// none, such as when a field is larger than a line.
+func CrossingFreeOrder(s StructInfo, line int64) (order []int, ok bool) {
+	n := len(s.Fields)
+	for _, f := range s.Fields {
+		if f.Size > line {
+			return nil, false
+		}
+	}
+	used := make([]bool, n)
+	order = make([]int, 0, n)
+	budget := maxCrossingSearch
+	var search func(p packer) bool
+	search = func(p packer) bool {
+		if len(order) == n {
+			size, _ := p.size()
+			return size <= s.Size
+		}
+		if budget--; budget < 0 {
+			return false
+		}
+		// Fields of the same size and alignment are interchangeable, so
+		// only the first unused one of each shape is tried.
+		tried := make(map[slot]bool)
+		for i, f := range s.Fields {
+			shape := slot{f.Size, f.Align}
+			if used[i] || tried[shape] {
+				continue
+			}
+			tried[shape] = true
+			next := p
+			offset := next.add(f.Size, f.Align)
+			if offset+f.Size > s.Size || len(boundaries(offset, f.Size, line)) > 0 {
+				continue
+			}
+			used[i] = true
+			order = append(order, i)
+			if search(next) {
+				return true
+			}
+			used[i] = false
+			order = order[:len(order)-1]
+		}
+		return false
+	}
+	if !search(packer{}) {
+		return nil, false
+	}
+	return order, true
+}
+
+// CrossingFree returns a copy of s, laid out as it is, with its fields
+// reordered so that none crosses a boundary between cache lines of the
+// given size, in the order of CrossingFreeOrder, if that doesn't grow it.
+// Otherwise, and for structs keeping their first fields in place or with
+// cache-line pads or hot fields, whose placement comes first, s is returned
+// as it is. s itself is not modified.
+func CrossingFree(s StructInfo, line int64) StructInfo {
+	if len(CacheLineCrossings(s, line)) == 0 || s.kept() > 0 ||
+		slices.ContainsFunc(s.Fields, func(f FieldInfo) bool { return IsCacheLinePad(f) || f.HasDirective("hot") }) {
+		return s
+	}
+	order, ok := CrossingFreeOrder(s, line)
+	if !ok {
+		return s
+	}
+	free := s
+	free.Fields = make([]FieldInfo, len(order))
+	for i, j := range order {
+		free.Fields[i] = s.Fields[j]
+	}
+	layoutFields(&free)
+	if free.Size > s.Size {
+		return s
+	}
+	return free
+}
@@ -0,0 +1,215 @@
+package padding_test
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// cacheLineStruct returns a struct with the fields of the given types, named
+// A, B and so on. Types [N]byte are sized here, since AnalyzeStruct doesn't
+// size arrays.
+func cacheLineStruct(types ...string) padding.StructInfo {
+	s := padding.StructInfo{Name: "T"}
+	for i, typ := range types {
+		f := padding.FieldInfo{Name: string(rune('A' + i)), Type: typ}
+		if n, ok := strings.CutPrefix(typ, "["); ok {
+			n, _, _ = strings.Cut(n, "]")
+			f.Size, _ = strconv.ParseInt(n, 10, 64)
+			f.Align = 1
+		}
+		s.Fields = append(s.Fields, f)
+	}
+	padding.AnalyzeStruct(&s)
+	return s
+}
+
+func TestCacheLineCrossings(t *testing.T) {
+	const line = 16
+	for _, tt := range []struct {
+		name  string
+		types []string
+		want  map[string][]int64
+	}{
+		{"on boundary", []string{"[8]byte", "int64", "int64"}, nil},
+		{"ends on boundary", []string{"[12]byte", "int32"}, nil},
+		{"crosses one", []string{"[4]byte", "[16]byte"}, map[string][]int64{"B": {16}}},
+		{"crosses two", []string{"[4]byte", "[30]byte"}, map[string][]int64{"B": {16, 32}}},
+	} {
+		var got map[string][]int64
+		for _, c := range padding.CacheLineCrossings(cacheLineStruct(tt.types...), line) {
+			if got == nil {
+				got = make(map[string][]int64)
+			}
+			got[c.Field.Name] = c.Boundaries
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: crossings = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCrossingFreeOrder(t *testing.T) {
+	const line = 16
+	s := cacheLineStruct("[12]byte", "[8]byte", "[4]byte")
+	order, ok := padding.CrossingFreeOrder(s, line)
+	if !ok || !reflect.DeepEqual(order, []int{0, 2, 1}) {
+		t.Errorf("CrossingFreeOrder = %v, %v, want [0 2 1]", order, ok)
+	}
+
+	// Either order of the two fields crosses, and padding would grow it.
+	if order, ok := padding.CrossingFreeOrder(cacheLineStruct("[12]byte", "[8]byte"), line); ok {
+		t.Errorf("CrossingFreeOrder = %v, want none", order)
+	}
+	if order, ok := padding.CrossingFreeOrder(cacheLineStruct("[4]byte", "[30]byte"), line); ok {
+		t.Errorf("CrossingFreeOrder of a field larger than a line = %v, want none", order)
+	}
+}
+
+func TestFprintCacheLines(t *testing.T) {
+	const line = 16
+	for _, tt := range []struct {
+		types []string
+		want  string
+	}{
+		{[]string{"[12]byte", "[8]byte", "[4]byte"}, `
+  Field B (offset: 12, size: 8) crosses the 16-byte cache line boundary at 16
+  Ordering the fields as A, C, B avoids crossing cache lines
+`},
+		{[]string{"[12]byte", "[8]byte"}, `
+  Field B (offset: 12, size: 8) crosses the 16-byte cache line boundary at 16; 4 bytes of padding before it would start it on the boundary at 16
+`},
+		{[]string{"[4]byte", "[30]byte"}, `
+  Field B (offset: 4, size: 30) crosses the 16-byte cache line boundaries at 16, 32
+`},
+	} {
+		s := cacheLineStruct(tt.types...)
+		r := padding.NewStructReport(s)
+		r.CheckCacheLines(s, line)
+		var b strings.Builder
+		padding.FprintStruct(&b, r)
+		if !strings.Contains(b.String(), tt.want) {
+			t.Errorf("%v: report lacks %q:\n%s", tt.types, tt.want, b.String())
+		}
+	}
+}
+
+func TestCacheLines(t *testing.T) {
+	for _, tt := range []struct{ size, want int64 }{{0, 0}, {1, 1}, {64, 1}, {65, 2}, {128, 2}} {
+		if got := padding.CacheLines(tt.size, 64); got != tt.want {
+			t.Errorf("CacheLines(%d, 64) = %d, want %d", tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestCheckCacheLines(t *testing.T) {
+	const line = 16
+	s := cacheLineStruct("bool", "int64", "[12]byte", "[8]byte")
+	r := padding.NewStructReport(s)
+	r.CheckCacheLines(s, line)
+	if r.CacheLines != 3 || r.OptimalCacheLines != 2 {
+		t.Errorf("cache lines = %d, %d once reordered, want 3, 2", r.CacheLines, r.OptimalCacheLines)
+	}
+	var got []int64
+	for _, f := range r.Fields {
+		if f.CacheLine == nil {
+			t.Fatalf("field %s has no cache line", f.Name)
+		}
+		got = append(got, *f.CacheLine)
+	}
+	if want := []int64{0, 0, 1, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("field cache lines = %v, want %v", got, want)
+	}
+
+	var b strings.Builder
+	padding.FprintStruct(&b, r)
+	for _, want := range []string{
+		"  B int64 (offset: 8, size: 8, align: 8, cache line: 0)\n",
+		"  D [8]byte (offset: 28, size: 8, align: 1, trailing padding: 4, cache lines: 1-2)\n",
+		"  Cache lines: 3 of 16 bytes, 2 once reordered\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("report lacks %q:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestCrossingFree(t *testing.T) {
+	const line = 16
+	// The optimal order, B A C D, puts C across the boundary at 16.
+	s := padding.Optimal(cacheLineStruct("bool", "int64", "[12]byte", "[3]byte"))
+	free := padding.CrossingFree(s, line)
+	var names []string
+	for _, f := range free.Fields {
+		names = append(names, f.Name)
+	}
+	if free.Size != s.Size || len(padding.CacheLineCrossings(free, line)) > 0 {
+		t.Errorf("CrossingFree = %v (size %d), want no crossing at size %d", names, free.Size, s.Size)
+	}
+	if want := []string{"A", "C", "D", "B"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("CrossingFree order = %v, want %v", names, want)
+	}
+
+	// A struct keeping its first field stays as it is.
+	s.KeepFirst = 1
+	if kept := padding.CrossingFree(s, line); !reflect.DeepEqual(kept, s) {
+		t.Errorf("CrossingFree reordered a struct keeping its first field: %+v", kept.Fields)
+	}
+}
+
+func TestLineFit(t *testing.T) {
+	for _, tt := range []struct {
+		size    int64
+		over    int64
+		aligned bool
+	}{
+		{32, 0, false},
+		{63, 0, false},
+		{64, 0, true},
+		{65, 1, false},
+		{72, 8, false},
+		{73, 9, false},
+		{127, 63, false},
+		{128, 0, true},
+		{129, 1, false},
+	} {
+		over, aligned := padding.LineFit(tt.size, 64)
+		if over != tt.over || aligned != tt.aligned {
+			t.Errorf("LineFit(%d, 64) = %d, %v, want %d, %v", tt.size, over, aligned, tt.over, tt.aligned)
+		}
+	}
+}
+
+func TestFprintLineFit(t *testing.T) {
+	const line, threshold = 64, 8
+	for _, tt := range []struct {
+		types []string
+		want  string // "" if nothing is reported
+	}{
+		{[]string{"[65]byte"}, "  Near miss: 1 byte over 64 (1 64-byte cache line); reordering alone doesn't (optimal: 65 bytes)\n"},
+		{[]string{"bool", "int64", "int64", "int64", "int64", "int64", "int64", "int64", "bool", "[6]byte"},
+			"  Near miss: 8 bytes over 64 (1 64-byte cache line); reordering alone fits it (optimal: 64 bytes)\n"},
+		{[]string{"[73]byte"}, ""},
+		{[]string{"[63]byte"}, ""},
+		{[]string{"[64]byte"}, "  Cache-line aligned size: 64 bytes fill 1 64-byte cache line exactly\n"},
+		{[]string{"[128]byte"}, "  Cache-line aligned size: 128 bytes fill 2 64-byte cache lines exactly\n"},
+		{[]string{"[130]byte"}, "  Near miss: 2 bytes over 128 (2 64-byte cache lines); reordering alone doesn't (optimal: 130 bytes)\n"},
+	} {
+		s := cacheLineStruct(tt.types...)
+		r := padding.NewStructReport(s)
+		r.CheckLineFit(line, threshold)
+		var b strings.Builder
+		padding.FprintStruct(&b, r)
+		got := b.String()
+		if tt.want == "" {
+			if strings.Contains(got, "Near miss") || strings.Contains(got, "aligned size") {
+				t.Errorf("%v: report notes the fit of the size:\n%s", tt.types, got)
+			}
+		} else if !strings.Contains(got, tt.want) {
+			t.Errorf("%v: report lacks %q:\n%s", tt.types, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package padding
+
+import "slices"
+
+// cacheLinePadTypes are the types of the blank fields code places between
+// contended fields to keep them on separate cache lines, with their sizes.
+// cpu.CacheLinePad, of golang.org/x/sys/cpu, is 64 bytes on amd64 and sized
+// by cacheLinePadSize elsewhere.
+var cacheLinePadTypes = map[string]int64{
+	"cpu.CacheLinePad": 64,
+	"[64]byte":         64,
+	"[64]uint8":        64,
+	"[128]byte":        128,
+	"[128]uint8":       128,
+}
+
+// cpuCacheLineSizes are the sizes of cpu.CacheLinePad on the architectures
+// where golang.org/x/sys/cpu does not make it 64 bytes.
+var cpuCacheLineSizes = map[string]int64{
+	"arm":      32,
+	"arm64":    128,
+	"mips":     32,
+	"mipsle":   32,
+	"mips64":   32,
+	"mips64le": 32,
+	"ppc64":    128,
+	"ppc64le":  128,
+	"s390x":    256,
+}
+
+// cacheLinePadSize returns the size of a cache-line pad of type typ on arch.
+func cacheLinePadSize(typ, arch string) int64 {
+	if n, ok := cpuCacheLineSizes[arch]; ok && typ == "cpu.CacheLinePad" {
+		return n
+	}
+	return cacheLinePadTypes[typ]
+}
+
+// IsCacheLinePad reports whether f is a manual cache-line pad: a blank field
+// of type cpu.CacheLinePad or a 64- or 128-byte array of bytes. Reordering
+// keeps pads in place, so that they go on separating the fields they were
+// placed between.
+func IsCacheLinePad(f FieldInfo) bool {
+	return f.Name == "_" && cacheLinePadTypes[f.Type] > 0
+}
+
+// bySegments returns the permutation permute gives each run of fields of s
+// between cache-line pads, the pads keeping their places, so that no field
+// crosses a pad. It reports false if s has no pads.
+func bySegments(s StructInfo, permute func(StructInfo) []int) ([]int, bool) {
+	if !slices.ContainsFunc(s.Fields, IsCacheLinePad) {
+		return nil, false
+	}
+	order := make([]int, 0, len(s.Fields))
+	start := 0
+	flush := func(end int) {
+		if end > start {
+			for _, j := range permute(StructInfo{Fields: s.Fields[start:end]}) {
+				order = append(order, start+j)
+			}
+		}
+	}
+	for i, f := range s.Fields {
+		if IsCacheLinePad(f) {
+			flush(i)
+			order = append(order, i)
+			start = i + 1
+		}
+	}
+	flush(len(s.Fields))
+	return order, true
+}
+
+// RedundantPad is a cache-line pad whose neighbours are on different cache
+// lines without it.
+type RedundantPad struct {
+	Field  int    // index of the pad among the fields
+	Type   string // type of the pad
+	Offset int64  // offset of the pad
+	After  string // name of the field before it
+	Before string // name of the field after it
+}
+
+// RedundantPads returns the cache-line pads of s between two fields that
+// would be on different cache lines of line bytes without them: the field
+// before the pad ends on one line and the one after it would start on the
+// next anyway. Pads at either end of s, which keep neighbouring objects in
+// memory apart, are never redundant.
+func RedundantPads(s StructInfo, line int64) []RedundantPad {
+	var pads []RedundantPad
+	for i, f := range s.Fields {
+		if !IsCacheLinePad(f) || i == 0 || IsCacheLinePad(s.Fields[i-1]) {
+			continue
+		}
+		next := i + 1
+		for next < len(s.Fields) && IsCacheLinePad(s.Fields[next]) {
+			next++
+		}
+		if next == len(s.Fields) {
+			continue
+		}
+		a, b := s.Fields[i-1], s.Fields[next]
+		last := a.Offset + max(a.Size, 1) - 1
+		if start := align(a.Offset+a.Size, b.Align); last/line < start/line {
+			pads = append(pads, RedundantPad{i, f.Type, f.Offset, a.Name, b.Name})
+		}
+	}
+	return pads
+}
@@ -0,0 +1,110 @@
+package padding_test
+
+import (
+	"go/parser"
+	"go/token"
+	"slices"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestIsCacheLinePad(t *testing.T) {
+	for _, tt := range []struct {
+		name, typ string
+		want      bool
+	}{
+		{"_", "cpu.CacheLinePad", true},
+		{"_", "[64]byte", true},
+		{"_", "[128]uint8", true},
+		{"_", "[32]byte", false},
+		{"pad", "[64]byte", false},
+		{"_", "int64", false},
+	} {
+		if got := padding.IsCacheLinePad(padding.FieldInfo{Name: tt.name, Type: tt.typ}); got != tt.want {
+			t.Errorf("IsCacheLinePad(%s %s) = %v, want %v", tt.name, tt.typ, got, tt.want)
+		}
+	}
+}
+
+func TestOptimalKeepsCacheLinePads(t *testing.T) {
+	s := analyzeOne(t, `type Sharded struct {
+		enabled bool
+		reads   int64
+		_       cpu.CacheLinePad
+		flag    bool
+		writes  int64
+		other   bool
+		_       [128]byte
+		errs    int64
+	}`)
+	if s.Fields[2].Size != 64 || s.Fields[6].Size != 128 {
+		t.Errorf("pads sized %d and %d, want 64 and 128", s.Fields[2].Size, s.Fields[6].Size)
+	}
+	// The fields between two pads are ordered among themselves: enabled
+	// and reads take 16 bytes in either order, and writes moves first.
+	want := []int{0, 1, 2, 4, 3, 5, 6, 7}
+	for name, permute := range map[string]func(padding.StructInfo) []int{
+		"OptimalPermutation":    padding.OptimalPermutation,
+		"GCPermutation":         padding.GCPermutation,
+		"VisibilityPermutation": padding.VisibilityPermutation,
+	} {
+		if got := permute(s); !slices.Equal(got, want) {
+			t.Errorf("%s = %v, want %v", name, got, want)
+		}
+	}
+
+	// Equal fields on either side of a pad don't trade places.
+	ties := analyzeOne(t, "type T struct { b int64; _ [64]byte; a int64 }")
+	if got := padding.SortTies(ties); got.Fields[0].Name != "b" || got.Fields[2].Name != "a" {
+		t.Errorf("SortTies moved fields across the pad: %s, %s, %s", got.Fields[0].Name, got.Fields[1].Name, got.Fields[2].Name)
+	}
+}
+
+func TestCacheLinePadSizeByArch(t *testing.T) {
+	const src = "package p\ntype T struct { a int64; _ cpu.CacheLinePad; b int64; _ [64]byte }"
+	for arch, want := range map[string]int64{"amd64": 64, "arm": 32, "arm64": 128, "s390x": 256} {
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, "p.go", src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		structs, err := padding.Analyze(fset, file, padding.Options{Arch: arch})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Byte arrays are the same size everywhere.
+		if got := structs[0].Fields[1].Size; got != want || structs[0].Fields[3].Size != 64 {
+			t.Errorf("%s: pads sized %d and %d, want %d and 64", arch, got, structs[0].Fields[3].Size, want)
+		}
+	}
+}
+
+func TestRedundantPads(t *testing.T) {
+	// The counters fill the first cache line, so the next field starts on
+	// the second one with or without the pad.
+	full := analyzeOne(t, "type T struct { a, b, c, d, e, f, g, h int64; _ [64]byte; next int64 }")
+	want := []padding.RedundantPad{{Field: 8, Type: "[64]byte", Offset: 64, After: "h", Before: "next"}}
+	if got := padding.RedundantPads(full, 64); !slices.Equal(got, want) {
+		t.Errorf("RedundantPads = %+v, want %+v", got, want)
+	}
+	for _, src := range []string{
+		"type T struct { a bool; _ [64]byte; b int64 }",
+		// Pads at either end guard the neighbouring objects.
+		"type T struct { _ [64]byte; a, b, c, d, e, f, g, h int64; _ [64]byte }",
+	} {
+		if got := padding.RedundantPads(analyzeOne(t, src), 64); got != nil {
+			t.Errorf("%s: RedundantPads = %+v, want none", src, got)
+		}
+	}
+
+	// Once the bools move after the counters, the run before the pad
+	// ends on the first cache line and next starts on the second.
+	s := analyzeOne(t, "type Window struct { first bool; a, b, c, d, e, f, g int64; last bool; _ [64]byte; next int64 }")
+	r := padding.NewStructReport(s)
+	r.CheckCacheLinePads(s, padding.Optimal(s), 64)
+	wantReport := []padding.RedundantPadReport{{Type: "[64]byte", Offset: 58, After: "last", Before: "next", LineSize: 64, Reordered: true}}
+	if !slices.Equal(r.RedundantPads, wantReport) {
+		t.Errorf("RedundantPads = %+v, want %+v", r.RedundantPads, wantReport)
+	}
+}
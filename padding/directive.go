@@ -0,0 +1,81 @@
+package padding
+
+import (
+	"go/ast"
+	"slices"
+	"strings"
+)
+
+// directivePrefix starts a comment directing how a field is laid out, such as
+// //padding:cold. Unlike drift-guard annotations, directives follow the
+// slashes directly, as gofmt leaves //tool:directive comments alone.
+const directivePrefix = "//padding:"
+
+// directives returns the directives in the comment groups, without their
+// prefix: "cold" for //padding:cold and "keep-first=2" for
+// //padding:keep-first=2.
+func directives(groups ...*ast.CommentGroup) []string {
+	var ds []string
+	for _, g := range groups {
+		if g == nil {
+			continue
+		}
+		for _, c := range g.List {
+			if rest, ok := strings.CutPrefix(c.Text, directivePrefix); ok {
+				if d, _, _ := strings.Cut(rest, " "); d != "" {
+					ds = append(ds, d)
+				}
+			}
+		}
+	}
+	return ds
+}
+
+// HasDirective reports whether the declaration of f carries the directive
+// name, as in //padding:name or //padding:name=value.
+func (f FieldInfo) HasDirective(name string) bool {
+	return hasDirective(f.Directives, name)
+}
+
+// HasDirective reports whether the doc comment of the type declaration of s
+// carries the directive name, as in //padding:name or //padding:name=value.
+func (s StructInfo) HasDirective(name string) bool {
+	return hasDirective(s.Directives, name)
+}
+
+// Ignored reports whether the doc comment of the type declaration of s
+// carries the directive //padding:ignore, spelled exactly so, which opts s
+// out of reports and fixes.
+func (s StructInfo) Ignored() bool {
+	return slices.Contains(s.Directives, "ignore")
+}
+
+// IgnoreDirective reports whether doc, the doc comment of a type
+// declaration, carries //padding:ignore, for tools finding struct types
+// without Analyze.
+func IgnoreDirective(doc *ast.CommentGroup) bool {
+	return slices.Contains(directives(doc), "ignore")
+}
+
+func hasDirective(ds []string, name string) bool {
+	for _, d := range ds {
+		if d == name || strings.HasPrefix(d, name+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+// directiveValue returns the value of the directive name=value in ds, and
+// whether ds holds the directive.
+func directiveValue(ds []string, name string) (string, bool) {
+	for _, d := range ds {
+		if v, ok := strings.CutPrefix(d, name+"="); ok {
+			return v, true
+		}
+		if d == name {
+			return "", true
+		}
+	}
+	return "", false
+}
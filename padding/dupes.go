@@ -0,0 +1,80 @@
+package padding
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DuplicateLayout is a group of structs with the same layout, as told by
+// their Fingerprint.
+type DuplicateLayout struct {
+	Fingerprint string            `json:"fingerprint"`
+	Size        int64             `json:"size"`
+	Structs     []DuplicateStruct `json:"structs"`
+}
+
+// DuplicateStruct is a member of a DuplicateLayout.
+type DuplicateStruct struct {
+	Package  string `json:"package,omitempty"` // name of the package
+	Name     string `json:"name"`
+	Position string `json:"position"` // file and line of its declaration
+}
+
+// Fingerprint returns the layout of r in a canonical form equal for the
+// structs whose fields, in order, have the same sizes and alignments, and
+// with byType the same types as well, and of the same size and alignment,
+// which tells apart embedded fields: "24/8: 8/8 1/1 1/1" for the size and
+// alignment of the struct and of each field, or with byType
+// "24/8: int64 8/8, bool 1/1, bool 1/1".
+func Fingerprint(r StructReport, byType bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d/%d:", r.Size, r.Align)
+	for i, f := range r.Fields {
+		if byType && i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString(" ")
+		if byType {
+			b.WriteString(f.Type + " ")
+		}
+		fmt.Fprintf(&b, "%d/%d", f.Size, f.Align)
+	}
+	return b.String()
+}
+
+// DuplicateLayouts groups structs by their Fingerprint and returns the
+// groups of two or more, largest first, then by size and fingerprint, their
+// members in the order of structs. Structs with fewer than two fields are
+// left out: few layouts are simpler, so sharing one says little.
+func DuplicateLayouts(structs []StructReport, byType bool) []DuplicateLayout {
+	index := make(map[string]int)
+	var groups []DuplicateLayout
+	for _, s := range structs {
+		if len(s.Fields) < 2 {
+			continue
+		}
+		fp := Fingerprint(s, byType)
+		i, ok := index[fp]
+		if !ok {
+			i = len(groups)
+			index[fp] = i
+			groups = append(groups, DuplicateLayout{Fingerprint: fp, Size: s.Size})
+		}
+		position := s.File
+		if s.Line > 0 {
+			position = fmt.Sprintf("%s:%d", s.File, s.Line)
+		}
+		groups[i].Structs = append(groups[i].Structs, DuplicateStruct{s.Package, s.Name, position})
+	}
+	groups = slices.DeleteFunc(groups, func(g DuplicateLayout) bool { return len(g.Structs) < 2 })
+	slices.SortStableFunc(groups, func(a, b DuplicateLayout) int {
+		return cmp.Or(
+			cmp.Compare(len(b.Structs), len(a.Structs)),
+			cmp.Compare(b.Size, a.Size),
+			cmp.Compare(a.Fingerprint, b.Fingerprint),
+		)
+	})
+	return groups
+}
@@ -0,0 +1,57 @@
+package padding_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// dupe returns the report of a struct of the given name declared at line 1
+// of file, with fields of the given types, sized as written.
+func dupe(file, name string, fields ...padding.FieldInfo) padding.StructReport {
+	r := padding.NewStructReport(laidOut(fields...))
+	r.File, r.Line, r.Package, r.Name = file, 1, "p", name
+	return r
+}
+
+func TestDuplicateLayouts(t *testing.T) {
+	var (
+		i64 = padding.FieldInfo{Name: "n", Type: "int64", Size: 8, Align: 8}
+		f64 = padding.FieldInfo{Name: "f", Type: "float64", Size: 8, Align: 8}
+		b   = padding.FieldInfo{Name: "b", Type: "bool", Size: 1, Align: 1}
+	)
+	structs := []padding.StructReport{
+		dupe("a.go", "A", i64, b, b),
+		dupe("b.go", "B", i64, b, b),
+		dupe("c.go", "C", f64, b, b), // the same layout, other types
+		dupe("d.go", "D", b, i64, b), // the same fields in another order
+		dupe("e.go", "E", i64, b),    // a field fewer
+		dupe("f.go", "F", i64),       // a single field
+		dupe("g.go", "G", i64),
+	}
+	// An embedded field, which Fields leave out, makes H larger.
+	h := dupe("h.go", "H", i64, b, b)
+	h.Size += 8
+	structs = append(structs, h)
+
+	for _, tt := range []struct {
+		byType bool
+		want   []padding.DuplicateLayout
+	}{
+		{false, []padding.DuplicateLayout{{
+			Fingerprint: "16/8: 8/8 1/1 1/1",
+			Size:        16,
+			Structs:     []padding.DuplicateStruct{{"p", "A", "a.go:1"}, {"p", "B", "b.go:1"}, {"p", "C", "c.go:1"}},
+		}}},
+		{true, []padding.DuplicateLayout{{
+			Fingerprint: "16/8: int64 8/8, bool 1/1, bool 1/1",
+			Size:        16,
+			Structs:     []padding.DuplicateStruct{{"p", "A", "a.go:1"}, {"p", "B", "b.go:1"}},
+		}}},
+	} {
+		if got := padding.DuplicateLayouts(structs, tt.byType); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("byType %v: %+v, want %+v", tt.byType, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package padding
+
+import (
+	"go/ast"
+	"go/parser"
+)
+
+// arrayElement returns the element type name of typ, an array or slice type
+// expression such as [16]Entry, []Entry or [4][8]Entry, and the number of
+// elements of that type in an array, or in each element of a slice. It
+// reports false for other types, for element types other than names of the
+// same package, and for array lengths other than constant expressions of
+// literals, which can't be resolved without type information.
+func arrayElement(typ string) (elem string, count int64, slice, ok bool) {
+	expr, err := parser.ParseExpr(typ)
+	if err != nil {
+		return "", 0, false, false
+	}
+	count = 1
+	for outer := true; ; outer = false {
+		switch e := ast.Unparen(expr).(type) {
+		case *ast.ArrayType:
+			if e.Len == nil {
+				// The elements of an inner slice are allocated apart.
+				if !outer {
+					return "", 0, false, false
+				}
+				slice = true
+			} else {
+				n, ok := arrayLen(e.Len)
+				if !ok {
+					return "", 0, false, false
+				}
+				count = mulSize(count, n)
+			}
+			expr = e.Elt
+		case *ast.Ident:
+			return e.Name, count, slice, !outer
+		default:
+			return "", 0, false, false
+		}
+	}
+}
+
+// CheckNestedWaste sets the waste of the array and slice fields of r whose
+// elements are structs, given the bytes each struct type of the package
+// wastes by name: the waste of each element, and for arrays the waste of all
+// of them, which NestedWaste sums over the fields. The waste of a slice
+// depends on its length, so it counts only per element.
+func (r *StructReport) CheckNestedWaste(wastes map[string]int64) {
+	r.NestedWaste = 0
+	for i := range r.Fields {
+		f := &r.Fields[i]
+		f.ElementWaste, f.NestedWaste = 0, 0
+		elem, count, slice, ok := arrayElement(f.Type)
+		if !ok || wastes[elem] == 0 {
+			continue
+		}
+		if slice {
+			f.ElementWaste = count * wastes[elem]
+			continue
+		}
+		f.ElementWaste, f.NestedWaste = wastes[elem], count*wastes[elem]
+		r.NestedWaste += f.NestedWaste
+	}
+}
+
+// Stride is the distance between the elements of an array or slice of a
+// struct type: its size, trailing padding included, which every element
+// repeats.
+type Stride struct {
+	Size     int64 // size of the struct
+	Trailing int64 // padding after its last field
+	Optimal  int64 // size with the optimal field order
+}
+
+// StrideOf returns the stride of the elements of arrays of s.
+func StrideOf(s StructInfo) Stride {
+	_, trailing := SplitPadding(s)
+	return Stride{Size: s.Size, Trailing: trailing, Optimal: Optimal(s).Size}
+}
+
+// CheckStrides sets the stride of the array and slice fields of r whose
+// elements are structs, given the strides of the struct types of the
+// package by name, and the stride with the optimal order of the element type
+// if that is smaller.
+func (r *StructReport) CheckStrides(strides map[string]Stride) {
+	for i := range r.Fields {
+		f := &r.Fields[i]
+		f.Stride, f.StrideTrailing, f.OptimalStride = 0, 0, 0
+		elem, _, _, ok := arrayElement(f.Type)
+		if !ok {
+			continue
+		}
+		st, ok := strides[elem]
+		if !ok {
+			continue
+		}
+		f.Stride, f.StrideTrailing = st.Size, st.Trailing
+		if st.Optimal < st.Size {
+			f.OptimalStride = st.Optimal
+		}
+	}
+}
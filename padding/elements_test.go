@@ -0,0 +1,82 @@
+package padding_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestCheckNestedWaste(t *testing.T) {
+	s := analyzeOne(t, `type T struct {
+		entries [1024]Entry
+		packed  [16]Packed
+		grid    [2][0x4]Entry
+		recent  []Entry
+		rows    [][3]Entry
+		ptrs    []*Entry
+		sized   [N]Entry
+		inner   [2][]Entry
+		other   [8]pkg.Entry
+		one     Entry
+	}`)
+	r := padding.NewStructReport(s)
+	r.CheckNestedWaste(map[string]int64{"Entry": 7, "Packed": 0})
+
+	type waste struct{ element, nested int64 }
+	want := map[string]waste{
+		"entries": {7, 7168},
+		"grid":    {7, 56},
+		"recent":  {7, 0},
+		"rows":    {21, 0},
+	}
+	for _, f := range r.Fields {
+		if got := (waste{f.ElementWaste, f.NestedWaste}); got != want[f.Name] {
+			t.Errorf("%s %s: waste %+v, want %+v", f.Name, f.Type, got, want[f.Name])
+		}
+	}
+	if r.NestedWaste != 7224 {
+		t.Errorf("NestedWaste = %d, want 7224", r.NestedWaste)
+	}
+}
+
+func TestCheckStrides(t *testing.T) {
+	// Entry is optimal, but its 7 bytes of trailing padding repeat in every
+	// element of an array; Record shrinks from 24 to 16 bytes once fixed.
+	entry := analyzeOne(t, "type Entry struct { n int64; ok bool }")
+	record := analyzeOne(t, "type Record struct { a bool; n int64; b bool }")
+	strides := map[string]padding.Stride{"Entry": padding.StrideOf(entry), "Record": padding.StrideOf(record)}
+	if want := (padding.Stride{Size: 16, Trailing: 7, Optimal: 16}); strides["Entry"] != want {
+		t.Errorf("StrideOf(Entry) = %+v, want %+v", strides["Entry"], want)
+	}
+
+	s := analyzeOne(t, `type T struct {
+		entries [64]Entry
+		records []Record
+		ptrs    []*Record
+		one     Record
+	}`)
+	r := padding.NewStructReport(s)
+	r.CheckStrides(strides)
+	type stride struct{ size, trailing, optimal int64 }
+	want := map[string]stride{
+		"entries": {16, 7, 0},
+		"records": {24, 7, 16},
+	}
+	for _, f := range r.Fields {
+		if got := (stride{f.Stride, f.StrideTrailing, f.OptimalStride}); got != want[f.Name] {
+			t.Errorf("%s %s: stride %+v, want %+v", f.Name, f.Type, got, want[f.Name])
+		}
+	}
+
+	var b strings.Builder
+	padding.FprintStruct(&b, r)
+	for _, line := range []string{
+		"  entries [64]Entry — stride 16B (7B trailing padding)\n",
+		"  records []Record — stride 24B (7B trailing padding), 16B if Record is optimized\n",
+	} {
+		if !strings.Contains(b.String(), line) {
+			t.Errorf("output lacks %q:\n%s", line, b.String())
+		}
+	}
+}
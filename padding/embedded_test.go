@@ -0,0 +1,96 @@
+package padding_test
+
+import (
+	"go/parser"
+	"go/token"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestEmbedded(t *testing.T) {
+	src := `package p
+
+type Base struct{ id int64 }
+
+type G[T any] struct{ v T }
+
+type T struct {
+	ok bool
+	*Base
+	G[int]
+	n  int32
+	Inner
+}
+
+type Inner struct{ a, b int64 }
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	structs, err := padding.Analyze(fset, file, padding.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := structs[2]
+	var got []string
+	for _, f := range s.Fields {
+		if f.Embedded {
+			got = append(got, f.Name+"="+f.Type)
+		} else {
+			got = append(got, f.Name)
+		}
+	}
+	if want := []string{"ok", "Base=*Base", "G=G[int]", "n", "Inner=Inner"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("fields %v, want %v", got, want)
+	}
+
+	// The embedded fields are written back unnamed, in their new order.
+	slices.Reverse(s.Fields)
+	out, err := padding.Rewrite(fset, file, []byte(src), []padding.StructInfo{s})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "type T struct {\n\tInner\n\tn int32\n\tG[int]\n\t*Base\n\tok bool\n}"; !strings.Contains(string(out), want) {
+		t.Errorf("rewritten source lacks %q:\n%s", want, out)
+	}
+}
+
+func TestLeadingLock(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		src  string
+		keep int
+	}{
+		{"mutex", "type T struct {\n\tsync.Mutex\n\tok bool\n}", 1},
+		{"rwmutex", "type T struct {\n\tsync.RWMutex\n\tok bool\n}", 1},
+		{"nocopy", "type T struct {\n\tnoCopy\n\tok bool\n}", 1},
+		// A named lock, one embedded by pointer or further down, moves
+		// as any field.
+		{"named", "type T struct {\n\tmu sync.Mutex\n\tok bool\n}", 0},
+		{"pointer", "type T struct {\n\t*sync.Mutex\n\tok bool\n}", 0},
+		{"second", "type T struct {\n\tok bool\n\tsync.Mutex\n}", 0},
+		// The directive sets the policy aside.
+		{"directive", "//padding:keep-first=0\ntype T struct {\n\tsync.Mutex\n\tok bool\n}", 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			fset := token.NewFileSet()
+			file, err := parser.ParseFile(fset, "p.go", "package p\n\n"+tt.src+"\n", parser.ParseComments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			structs, err := padding.Analyze(fset, file, padding.Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s := structs[0]; s.KeepFirst != tt.keep {
+				t.Errorf("keeps %d fields first, want %d", s.KeepFirst, tt.keep)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package padding
+
+import (
+	"go/types"
+	"strings"
+)
+
+// EstimatedType is a field type whose layout Analyze could only guess,
+// sizing it as a word, or a C scalar type as on 64-bit Unix, with the
+// number of fields of that type and the positions of the first few of them.
+type EstimatedType struct {
+	Type     string   `json:"type"` // type expression as written in the source
+	Fields   int      `json:"fields"`
+	Examples []string `json:"examples"` // file:line of the first fields, in order
+}
+
+// Estimated reports whether the size of a field of type typ, a type
+// expression as written in the source, is a guess: Analyze knows neither the
+// type nor its layout, and it is not the name of a struct in named, which
+// ResolveSizes sizes, an array of known elements with a length Analyze can
+// evaluate, or a struct type literal of known fields, such as struct{}.
+// Types known to take a word, such as int, maps, channels and functions,
+// are not guesses, nor are slices and interfaces; C types, such as C.int,
+// always are.
+func Estimated(typ string, named map[string]*StructInfo) bool {
+	// An array is a guess if its elements are.
+	if _, elem, ok := arrayType(typ); ok {
+		return Estimated(elem, named)
+	}
+	// So is a struct type literal if one of its fields is.
+	if st, ok := structType(typ); ok {
+		for _, field := range st.Fields.List {
+			if Estimated(types.ExprString(field.Type), named) {
+				return true
+			}
+		}
+		return false
+	}
+	if _, ok := named[typ]; ok {
+		return false
+	}
+	switch typ {
+	case "bool", "int8", "uint8", "byte", "int16", "uint16", "int32", "uint32", "float32",
+		"int64", "uint64", "float64", "complex64", "complex128", "string", "error", "any",
+		"int", "uint", "uintptr", "unsafe.Pointer":
+		return false
+	}
+	return !strings.HasPrefix(typ, "[]") && !strings.HasPrefix(typ, "interface{") && !wordType(typ)
+}
+
+// CgoType reports whether typ, a type expression as written in the source,
+// is a C type from cgo, such as C.int or C.struct_point, or an array of one.
+// Pointers to C types take a word, so they are not.
+func CgoType(typ string) bool {
+	for strings.HasPrefix(typ, "[") && !strings.HasPrefix(typ, "[]") {
+		_, typ, _ = strings.Cut(typ, "]")
+	}
+	return strings.HasPrefix(typ, "C.")
+}
@@ -0,0 +1,120 @@
+package padding_test
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestEstimated(t *testing.T) {
+	named := map[string]*padding.StructInfo{"Inner": {Name: "Inner", Size: 16, Align: 8}}
+	tests := []struct {
+		typ  string
+		want bool
+	}{
+		{"int32", false},
+		{"string", false},
+		{"int", false},
+		{"*Outer", false},
+		{"map[string]int", false},
+		{"chan struct{}", false},
+		{"func(int) error", false},
+		{"chan<- int", false},
+		{"[]Inner", false},
+		{"any", false},
+		{"interface{ Close() error }", false},
+		{"Inner", false},
+		{"[4]Inner", false},
+		{"time.Time", true},
+		{"Outer", true},
+		{"[4]byte", false},
+		{"[2][1 << 3]uint32", false},
+		{"[4]time.Time", true},
+		{"[n]Inner", true},
+		{"rune", true},
+	}
+	for _, tt := range tests {
+		if got := padding.Estimated(tt.typ, named); got != tt.want {
+			t.Errorf("Estimated(%q) = %v, want %v", tt.typ, got, tt.want)
+		}
+	}
+}
+
+func TestCgo(t *testing.T) {
+	const src = `package p
+
+/*
+#include <stdint.h>
+struct point { int32_t x, y; };
+*/
+import "C"
+
+type Sample struct {
+	ok    bool
+	count C.int
+	at    C.struct_point
+	name  *C.char
+	total int64
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	structs, err := padding.Analyze(fset, file, padding.Options{})
+	if err != nil || len(structs) != 1 {
+		t.Fatalf("Analyze = %d structs, %v", len(structs), err)
+	}
+	s := structs[0]
+	if !s.Cgo {
+		t.Error("struct of a cgo file not marked Cgo")
+	}
+	// C scalars are sized as on 64-bit Unix, other C types as a word.
+	for _, f := range s.Fields {
+		want := map[string]int64{"ok": 1, "count": 4, "at": 8, "name": 8, "total": 8}[f.Name]
+		if f.Size != want {
+			t.Errorf("%s %s: size %d, want %d", f.Name, f.Type, f.Size, want)
+		}
+	}
+
+	r := padding.NewStructReport(s)
+	if !r.Cgo {
+		t.Error("report of a struct with C fields not marked Cgo")
+	}
+	var buf bytes.Buffer
+	padding.FprintStruct(&buf, r)
+	if !strings.Contains(buf.String(), "(cgo — sizes approximate)\n") {
+		t.Errorf("header lacks the cgo note:\n%s", buf.String())
+	}
+
+	// Rewrite leaves the struct, and the preamble, as they are.
+	structs[0] = padding.Optimal(s)
+	out, err := padding.Rewrite(fset, file, []byte(src), structs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != src {
+		t.Errorf("Rewrite changed the cgo file:\n%s", out)
+	}
+}
+
+func TestCgoType(t *testing.T) {
+	for typ, want := range map[string]bool{
+		"C.int":          true,
+		"C.struct_point": true,
+		"[4]C.char":      true,
+		"*C.char":        false,
+		"[]C.int":        false,
+		"int":            false,
+		"Config":         false,
+	} {
+		if got := padding.CgoType(typ); got != want {
+			t.Errorf("CgoType(%q) = %v, want %v", typ, got, want)
+		}
+	}
+}
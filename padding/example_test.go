@@ -0,0 +1,51 @@
+package padding_test
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// A code generator can lay out the structs it generates before writing them.
+func ExampleOptimize() {
+	src := []byte(`package wire
+
+type Header struct {
+	Flags   uint8
+	Length  uint64
+	Version uint8
+}
+`)
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "wire.go", src, parser.ParseComments)
+	if err != nil {
+		panic(err)
+	}
+	structs, err := padding.Analyze(fset, file, padding.Options{})
+	if err != nil {
+		panic(err)
+	}
+	for i := range structs {
+		before := structs[i].Size
+		moves, changed := padding.Optimize(&structs[i])
+		if changed {
+			fmt.Printf("%s: %d -> %d bytes, moved %v\n", structs[i].Name, before, structs[i].Size, moves)
+		}
+	}
+	out, err := padding.Rewrite(fset, file, src, structs)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Print(string(out))
+	// Output:
+	// Header: 24 -> 16 bytes, moved [{Length 1 0}]
+	// package wire
+	//
+	// type Header struct {
+	// 	Length  uint64
+	// 	Flags   uint8
+	// 	Version uint8
+	// }
+}
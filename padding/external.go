@@ -0,0 +1,56 @@
+package padding
+
+import "go/types"
+
+// InternalWaste returns the bytes a value of type t laid out by sizes wastes
+// inside the struct types it is made of, however deeply they nest: for each
+// struct, its size less that of its optimal field order. Only code declaring
+// these types can recover them.
+func InternalWaste(t types.Type, sizes types.Sizes) int64 {
+	switch u := t.Underlying().(type) {
+	case *types.Array:
+		return u.Len() * InternalWaste(u.Elem(), sizes)
+	case *types.Struct:
+		_, current := Layout(u, sizes)
+		p := packerFor(sizes)
+		for _, i := range OptimalOrder(u, sizes) {
+			ft := u.Field(i).Type()
+			p.add(sizes.Sizeof(ft), sizes.Alignof(ft))
+		}
+		optimal, _ := p.size()
+		waste := current.Size - optimal
+		for i := range u.NumFields() {
+			waste += InternalWaste(u.Field(i).Type(), sizes)
+		}
+		return waste
+	}
+	return 0
+}
+
+// ExternalWaste returns the InternalWaste of the fields of st, a struct type
+// of pkg, whose type is a struct type declared in another package or an
+// array of them, by field index. Fields wasting nothing are left out.
+func ExternalWaste(st *types.Struct, pkg *types.Package, sizes types.Sizes) map[int]int64 {
+	wastes := make(map[int]int64)
+	for i := range st.NumFields() {
+		t := st.Field(i).Type()
+		for {
+			a, ok := t.Underlying().(*types.Array)
+			if !ok {
+				break
+			}
+			t = a.Elem()
+		}
+		named, ok := types.Unalias(t).(*types.Named)
+		if !ok || named.Obj().Pkg() == nil || named.Obj().Pkg() == pkg {
+			continue
+		}
+		if _, ok := named.Underlying().(*types.Struct); !ok {
+			continue
+		}
+		if w := InternalWaste(st.Field(i).Type(), sizes); w > 0 {
+			wastes[i] = w
+		}
+	}
+	return wastes
+}
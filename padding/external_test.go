@@ -0,0 +1,58 @@
+package padding_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// importerFunc imports the packages of a test.
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) { return f(path) }
+
+func TestExternalWaste(t *testing.T) {
+	fset := token.NewFileSet()
+	check := func(path, src string, imp types.Importer) *types.Package {
+		t.Helper()
+		file, err := parser.ParseFile(fset, path+".go", src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkg, err := (&types.Config{Importer: imp}).Check(path, fset, []*ast.File{file}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pkg
+	}
+	dep := check("dep", `package dep
+type Header struct { a bool; n int64; b bool }
+type Message struct { ok bool; id int64; last bool; h Header }
+`, nil)
+	app := check("app", `package app
+import "dep"
+type Local struct { a bool; n int64; b bool }
+type Server struct {
+	msg   dep.Message
+	ring  [4]dep.Header
+	local Local
+	ptr   *dep.Message
+}
+`, importerFunc(func(string) (*types.Package, error) { return dep, nil }))
+
+	sizes := types.SizesFor("gc", "amd64")
+	// Message wastes 8 bytes of its own and 8 inside its Header.
+	if got := padding.InternalWaste(dep.Scope().Lookup("Message").Type(), sizes); got != 16 {
+		t.Errorf("InternalWaste(Message) = %d, want 16", got)
+	}
+	st := app.Scope().Lookup("Server").Type().Underlying().(*types.Struct)
+	got := padding.ExternalWaste(st, app, sizes)
+	want := map[int]int64{0: 16, 1: 32}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("ExternalWaste = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,423 @@
+package padding
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Fprint writes the layout of s to w: a header line with the struct's size
+// and alignment, and the optimal size and bytes wasted if reordering its
+// fields would make it smaller, its Variants if any, and one line per field
+// in source order. The header also gives the packed minimum, the sum of the
+// field sizes, if no order reaches it; a struct that is optimal nonetheless
+// is followed by the fields whose alignment causes its padding, and a struct
+// with fields marked //padding:hot by whether they fit in the first
+// HotLineSize bytes of the optimal layout.
+func Fprint(w io.Writer, s StructInfo) {
+	FprintStruct(w, NewStructReport(s))
+}
+
+// FprintStruct writes r to w in the text format of Fprint, adding the
+// allocated sizes to the header line where the runtime rounds them up, its
+// allocation sites if it has any, whether it is Estimated or Cgo and why it
+// is Skipped, the fields, noting the padding after each and those whose
+// sizes are guesses, and after them, the cheapest manual edit reaching the
+// optimal size, the stride of the array and slice fields of structs, whether
+// fixing it leaves its allocation size unchanged, the promoted fields, the
+// instantiations of a generic struct, the padding inside fields of other
+// packages, the redundant cache-line pads, the explained padding, the
+// narrated layouts, how the size fits cache lines, the fields crossing cache
+// lines and those sharing one while written concurrently, the pointer
+// prefix, the cost of keeping the first fields in place and of ordering
+// exported fields first, the free tail, the pointer words, a hot/cold split
+// and suggestions, if they were checked. Of a struct too large, it prints
+// only the names and types of the fields, and of a generic struct sized by
+// its type parameters, the sizes known, "?" for the others, and its
+// instantiations.
+func FprintStruct(w io.Writer, r StructReport) {
+	fprintStruct(w, r, false, false)
+}
+
+// FprintStructAt writes r to w as FprintStruct does, but with the header
+// line starting with the position of the struct's name, in the
+// file:line:column form of compiler messages, as "p.go:12:6: struct T (size:
+// ...", and with fields set, each field line with that of the field's name.
+// A struct or field without a position is printed as FprintStruct does.
+func FprintStructAt(w io.Writer, r StructReport, fields bool) {
+	fprintStruct(w, r, true, fields)
+}
+
+func fprintStruct(w io.Writer, r StructReport, at, fields bool) {
+	header := func() {
+		if at && r.File != "" && r.Line > 0 {
+			fmt.Fprintf(w, "%s: struct %s (", position(r.File, r.Line, r.Column), r.Name)
+		} else {
+			fmt.Fprintf(w, "Struct: %s (", r.Name)
+		}
+	}
+	// declare starts the line of the field f, up to its declaration.
+	declare := func(f FieldReport) {
+		if fields && r.File != "" && f.Line > 0 {
+			fmt.Fprintf(w, "  %s: %s", position(r.File, f.Line, f.Column), f.decl())
+		} else {
+			fmt.Fprintf(w, "  %s", f.decl())
+		}
+	}
+	if r.TooLarge {
+		header()
+		fmt.Fprintf(w, "type too large: %d bytes or more)", MaxSize)
+		if len(r.Variants) > 0 {
+			fmt.Fprintf(w, " [%s]", strings.Join(r.Variants, ", "))
+		}
+		fmt.Fprintln(w)
+		for _, field := range r.Fields {
+			declare(field)
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w)
+		return
+	}
+	if r.ParamSized {
+		header()
+		fmt.Fprint(w, "size depends on type parameters)")
+		if len(r.Variants) > 0 {
+			fmt.Fprintf(w, " [%s]", strings.Join(r.Variants, ", "))
+		}
+		fmt.Fprintln(w)
+		for _, field := range r.Fields {
+			declare(field)
+			if field.ParamSized {
+				fmt.Fprint(w, " (size: ?)\n")
+			} else {
+				fmt.Fprintf(w, " (size: %d, align: %d)\n", field.Size, field.Align)
+			}
+		}
+		fprintInstantiations(w, r)
+		fmt.Fprintln(w)
+		return
+	}
+	header()
+	fmt.Fprintf(w, "size: %d bytes%s, align: %d", r.Size, allocated(r.Size, r.AllocSize), r.Align)
+	if r.WastedBytes > 0 {
+		fmt.Fprintf(w, ", optimal: %d bytes%s", r.OptimalSize, allocated(r.OptimalSize, r.OptimalAllocSize))
+	}
+	if r.PackedSize < r.OptimalSize {
+		fmt.Fprintf(w, ", packed minimum: %d bytes", r.PackedSize)
+	}
+	if r.WastedBytes > 0 {
+		fmt.Fprintf(w, ", wasted: %d bytes", r.WastedBytes)
+	}
+	if r.InterFieldPadding > 0 || r.TrailingPadding > 0 {
+		fmt.Fprintf(w, ", padding: %d inter-field + %d trailing", r.InterFieldPadding, r.TrailingPadding)
+	}
+	if r.NestedWaste > 0 {
+		fmt.Fprintf(w, ", nested waste: %d bytes", r.NestedWaste)
+	}
+	switch {
+	case r.AllocSites == 1:
+		fmt.Fprint(w, ", ≈1 allocation site")
+	case r.AllocSites > 1:
+		fmt.Fprintf(w, ", ≈%d allocation sites", r.AllocSites)
+	}
+	fmt.Fprint(w, ")")
+	if len(r.Variants) > 0 {
+		fmt.Fprintf(w, " [%s]", strings.Join(r.Variants, ", "))
+	}
+	if r.Estimated {
+		fmt.Fprint(w, " (estimated: package failed to type-check)")
+	}
+	if r.Cgo {
+		fmt.Fprint(w, " (cgo — sizes approximate)")
+	}
+	if r.Skipped != "" {
+		fmt.Fprintf(w, " (skipped: %s)", r.Skipped)
+	}
+	fmt.Fprintln(w)
+	for i, field := range r.Fields {
+		declare(field)
+		fmt.Fprintf(w, " (offset: %d, size: %d, align: %d", field.Offset, field.Size, field.Align)
+		switch {
+		case field.PaddingAfter > 0 && i == len(r.Fields)-1:
+			fmt.Fprintf(w, ", trailing padding: %d", field.PaddingAfter)
+		case field.PaddingAfter > 0:
+			fmt.Fprintf(w, ", padding after: %d", field.PaddingAfter)
+		}
+		if field.CacheLinePad {
+			fmt.Fprint(w, ", cache-line pad")
+		}
+		if field.CacheLine != nil {
+			if last := (field.Offset + max(field.Size, 1) - 1) / r.CacheLineSize; last > *field.CacheLine {
+				fmt.Fprintf(w, ", cache lines: %d-%d", *field.CacheLine, last)
+			} else {
+				fmt.Fprintf(w, ", cache line: %d", *field.CacheLine)
+			}
+		}
+		if field.Estimated {
+			fmt.Fprint(w, ", estimated")
+		}
+		switch {
+		case field.NestedWaste > 0:
+			fmt.Fprintf(w, ", nested waste: %d bytes, %d per element", field.NestedWaste, field.ElementWaste)
+		case field.ElementWaste > 0:
+			fmt.Fprintf(w, ", %d bytes wasted per element", field.ElementWaste)
+		}
+		fmt.Fprintln(w, ")")
+	}
+	if h := r.EditHint; h != nil {
+		switch {
+		case h.Kind == "move" && h.After == "":
+			fmt.Fprintf(w, "  hint: move `%s` first to save %d bytes\n", h.Field, h.Saved)
+		case h.Kind == "move":
+			fmt.Fprintf(w, "  hint: move `%s` after `%s` to save %d bytes\n", h.Field, h.After, h.Saved)
+		case h.Kind == "swap":
+			fmt.Fprintf(w, "  hint: swap `%s` and `%s` to save %d bytes\n", h.Field, h.With, h.Saved)
+		default:
+			fmt.Fprintf(w, "  hint: no single move or swap saves the %d bytes; the optimal layout moves %d fields\n", h.Saved, h.Moves)
+		}
+	}
+	for _, field := range r.Fields {
+		if field.Stride == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "  %s — stride %dB", field.decl(), field.Stride)
+		if field.StrideTrailing > 0 {
+			fmt.Fprintf(w, " (%dB trailing padding)", field.StrideTrailing)
+		}
+		if field.OptimalStride > 0 {
+			elem, _, _, _ := arrayElement(field.Type)
+			fmt.Fprintf(w, ", %dB if %s is optimized", field.OptimalStride, elem)
+		}
+		fmt.Fprintln(w)
+	}
+	if r.NoAllocationImpact {
+		fmt.Fprintf(w, "  No allocation impact: %d and %d bytes both allocate %d bytes\n", r.Size, r.OptimalSize, r.AllocSize)
+	}
+	for _, p := range r.PromotedFields {
+		fmt.Fprintf(w, "  Promoted: %s %s (offset: %d, size: %d", p.Path, p.Type, p.Offset, p.Size)
+		if p.Pointer {
+			fmt.Fprint(w, ", embedded by pointer, not flattened")
+		}
+		fmt.Fprintln(w, ")")
+	}
+	fprintInstantiations(w, r)
+	for _, field := range r.Fields {
+		if field.ExternalWaste > 0 {
+			fmt.Fprintf(w, "  field %s carries %d bytes of internal padding (not fixable here)\n",
+				field.decl(), field.ExternalWaste)
+		}
+	}
+	for _, p := range r.RedundantPads {
+		fmt.Fprintf(w, "  Cache-line pad _ %s at offset %d is redundant", p.Type, p.Offset)
+		if p.Reordered {
+			fmt.Fprint(w, " once reordered")
+		}
+		fmt.Fprintf(w, ": %s and %s are on different %d-byte cache lines without it\n", p.After, p.Before, p.LineSize)
+	}
+	for _, h := range r.Holes {
+		fmt.Fprintf(w, "  %s\n", h.Explanation)
+	}
+	if len(r.Layout) > 0 {
+		fmt.Fprintln(w, "  Layout:")
+		for _, l := range r.Layout {
+			fmt.Fprintf(w, "    %s\n", l)
+		}
+	}
+	if len(r.OptimalLayout) > 0 {
+		fmt.Fprintln(w, "  Optimal layout:")
+		for _, l := range r.OptimalLayout {
+			fmt.Fprintf(w, "    %s\n", l)
+		}
+	}
+	if r.NearMiss > 0 {
+		lines := r.Size / r.CacheLineSize
+		fmt.Fprintf(w, "  Near miss: %d %s over %d (%d %d-byte cache %s)", r.NearMiss, plural(int(r.NearMiss), "byte", "bytes"),
+			lines*r.CacheLineSize, lines, r.CacheLineSize, plural(int(lines), "line", "lines"))
+		if r.NearMissReordered {
+			fmt.Fprintf(w, "; reordering alone fits it (optimal: %d bytes)\n", r.OptimalSize)
+		} else {
+			fmt.Fprintf(w, "; reordering alone doesn't (optimal: %d bytes)\n", r.OptimalSize)
+		}
+	}
+	if r.LineAlignedSize {
+		lines := r.Size / r.CacheLineSize
+		fmt.Fprintf(w, "  Cache-line aligned size: %d bytes fill %d %d-byte cache %s exactly\n",
+			r.Size, lines, r.CacheLineSize, plural(int(lines), "line", "lines"))
+	}
+	if r.CacheLines > 0 {
+		fmt.Fprintf(w, "  Cache lines: %d of %d bytes", r.CacheLines, r.CacheLineSize)
+		if r.OptimalCacheLines != r.CacheLines {
+			fmt.Fprintf(w, ", %d once reordered", r.OptimalCacheLines)
+		}
+		fmt.Fprintln(w)
+	}
+	for _, c := range r.CacheLineCrossings {
+		fmt.Fprintf(w, "  Field %s (offset: %d, size: %d) crosses the %d-byte cache line %s at %s",
+			c.Field, c.Offset, c.Size, r.CacheLineSize, plural(len(c.Boundaries), "boundary", "boundaries"), joinInts(c.Boundaries))
+		if r.CacheLineOrder == nil && c.Size <= r.CacheLineSize {
+			b := c.Boundaries[0]
+			fmt.Fprintf(w, "; %d bytes of padding before it would start it on the boundary at %d", b-c.Offset, b)
+		}
+		fmt.Fprintln(w)
+	}
+	if r.CacheLineOrder != nil {
+		fmt.Fprintf(w, "  Ordering the fields as %s avoids crossing cache lines\n", strings.Join(r.CacheLineOrder, ", "))
+	}
+	for _, fs := range r.FalseSharing {
+		fmt.Fprintf(w, "  Possible false sharing: %s (offset: %d) and %s (offset: %d) share the %d-byte cache line at %d;"+
+			" separate them with %d bytes of padding, as cpu.CacheLinePad does, or split the struct\n",
+			fs.First, fs.FirstOffset, fs.Second, fs.SecondOffset, r.CacheLineSize, fs.Line, r.CacheLineSize)
+	}
+	if r.GCOrder != nil {
+		if r.GCOrderPointerPrefix < r.PointerPrefix {
+			fmt.Fprintf(w, "  Pointer prefix: %d bytes, %d with the pointer-first order %s\n",
+				r.PointerPrefix, r.GCOrderPointerPrefix, strings.Join(r.GCOrder, ", "))
+		} else {
+			fmt.Fprintf(w, "  Pointer prefix: %d bytes, which no order of the optimal size shortens\n", r.PointerPrefix)
+		}
+	}
+	if r.KeepFirstCost > 0 {
+		fmt.Fprintf(w, "  Keeping the first %d %s in place: %d bytes more than the optimal order\n",
+			r.KeepFirst, plural(r.KeepFirst, "field", "fields"), r.KeepFirstCost)
+	}
+	if r.VisibilityCost > 0 {
+		fmt.Fprintf(w, "  Exported fields first: %d bytes, %d more than the optimal order\n", r.VisibilitySize, r.VisibilityCost)
+	}
+	if r.FreeTail > 0 || r.OptimalFreeTail > 0 {
+		fmt.Fprintf(w, "  Free tail: %d %s", r.FreeTail, plural(int(r.FreeTail), "byte", "bytes"))
+		if r.FreeTailRoom > 0 {
+			fmt.Fprintf(w, ", room for a %d-byte field without growing the struct", r.FreeTailRoom)
+		}
+		if r.WastedBytes > 0 {
+			fmt.Fprintf(w, "; %d with the optimal order", r.OptimalFreeTail)
+		}
+		fmt.Fprintln(w)
+	}
+	if r.PointerBytes > 0 {
+		fmt.Fprintf(w, "  Pointers: %dB of %dB, scan length %dB\n", r.PointerBytes, r.TypedSize, r.ScanLength)
+	}
+	for _, n := range r.NestedPadding {
+		fmt.Fprintf(w, "  %d of %d wasted bytes come from %s (%s)\n", n.Bytes, r.PaddingBytes, n.Type, n.Position)
+	}
+	if len(r.HotFields) > 0 {
+		fields := plural(len(r.HotFields), "field", "fields") + " " + strings.Join(r.HotFields, ", ")
+		if r.HotOverflow == 0 {
+			fmt.Fprintf(w, "  Hot %s %s in the first %d bytes\n", fields, plural(len(r.HotFields), "fits", "fit"), HotLineSize)
+		} else {
+			fmt.Fprintf(w, "  Hot %s %s in the first %d bytes, ending %d bytes past them\n",
+				fields, plural(len(r.HotFields), "doesn't fit", "don't fit"), HotLineSize, r.HotOverflow)
+		}
+	}
+	if sp := r.Split; sp != nil {
+		chosen := "the largest fields"
+		if sp.Marked {
+			chosen = "marked //padding:cold"
+		}
+		fmt.Fprintf(w, "  Suggested split: moving %s (%s) into a %d-byte side struct behind a pointer shrinks %s from %d to %d bytes",
+			strings.Join(sp.Fields, ", "), chosen, sp.ColdSize, r.Name, r.OptimalSize, sp.HotSize)
+		if sp.HotSize > sp.Threshold {
+			fmt.Fprintf(w, ", still over %d", sp.Threshold)
+		}
+		fmt.Fprintln(w)
+	}
+	for _, p := range r.BoolPacks {
+		fmt.Fprintf(w, "  Suggestion: pack the bools %s into one %s field of bit flags, saving %d bytes (size %d → %d):\n",
+			strings.Join(p.Fields, ", "), p.Type, p.Saved, r.OptimalSize, r.OptimalSize-p.Saved)
+		fmt.Fprintln(w, "    const (")
+		for i, f := range p.Fields {
+			if i == 0 {
+				fmt.Fprintf(w, "    \t%s %s = 1 << iota\n", FlagConst(f), p.Type)
+			} else {
+				fmt.Fprintf(w, "    \t%s\n", FlagConst(f))
+			}
+		}
+		fmt.Fprintln(w, "    )")
+	}
+	for _, n := range r.Narrowings {
+		fmt.Fprintf(w, "  Suggestion (heuristic, check the range of its values): %s %s could be %s like the other counters, saving %d bytes (size %d → %d); silence with //padding:keep-type\n",
+			n.Field, n.Type, n.Suggested, n.Saved, r.OptimalSize, r.OptimalSize-n.Saved)
+	}
+	if in := r.Indirection; in != nil {
+		fmt.Fprintf(w, "  Suggestion: %s %s takes %d of %d bytes; as %s the struct would fit a %d-byte cache line (size %d → %d),"+
+			" at the cost of allocating %s separately and following the pointer on every access\n",
+			in.Field, in.Type, in.Size, r.Size, in.Pointer, in.LineSize, r.Size, in.NewSize, in.Field)
+	}
+	if len(r.AlignmentPadding) > 0 {
+		fmt.Fprintf(w, "  Reordering won't help: the alignment of %s leaves %d bytes of padding\n",
+			strings.Join(r.AlignmentPadding, ", "), r.Size-r.PackedSize)
+	}
+	fmt.Fprintln(w)
+}
+
+// allocated returns a note on the heap allocation of an object of the given
+// size if the runtime rounds it up to a larger size class.
+func allocated(size, alloc int64) string {
+	if alloc <= size {
+		return ""
+	}
+	return fmt.Sprintf(" (alloc %d)", alloc)
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+func joinInts(ns []int64) string {
+	parts := make([]string, len(ns))
+	for i, n := range ns {
+		parts[i] = strconv.FormatInt(n, 10)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// position returns the position of line and column in file as compilers
+// print it, leaving the column out if it is unknown.
+func position(file string, line, column int) string {
+	if column > 0 {
+		return fmt.Sprintf("%s:%d:%d", file, line, column)
+	}
+	return fmt.Sprintf("%s:%d", file, line)
+}
+
+// Location returns the position of the declaration of r as compilers print
+// it, file:line:column, or File if its line is unknown.
+func (r StructReport) Location() string {
+	if r.Line <= 0 {
+		return r.File
+	}
+	return position(r.File, r.Line, r.Column)
+}
+
+// decl returns f as it is declared, as in "flags byte", or "sync.Mutex" for
+// an embedded field.
+func (f FieldReport) decl() string {
+	if f.Embedded {
+		return f.Type
+	}
+	return f.Name + " " + f.Type
+}
+
+// fprintInstantiations writes the instantiations of r, a generic struct, and
+// their common order, if they were checked.
+func fprintInstantiations(w io.Writer, r StructReport) {
+	if len(r.Instantiations) == 0 {
+		return
+	}
+	if r.CommonOrderWaste == 0 {
+		fmt.Fprintf(w, "  One order suits all %d instantiations: %s\n", len(r.Instantiations), strings.Join(r.CommonOrder, ", "))
+	} else {
+		fmt.Fprintf(w, "  No order is optimal for all %d instantiations; the best wastes up to %d bytes: %s\n",
+			len(r.Instantiations), r.CommonOrderWaste, strings.Join(r.CommonOrder, ", "))
+	}
+	for _, in := range r.Instantiations {
+		fmt.Fprintf(w, "    %s: %d bytes", in.Type, in.Size)
+		if in.Size > in.OptimalSize {
+			fmt.Fprintf(w, ", optimal %d", in.OptimalSize)
+		}
+		fmt.Fprintln(w)
+	}
+}
@@ -0,0 +1,198 @@
+package padding
+
+import (
+	"cmp"
+	"go/ast"
+	"go/parser"
+	"slices"
+)
+
+// HasPointers reports whether a field of the type expression typ holds
+// pointers the garbage collector scans: pointers, slices, strings, maps,
+// channels, functions and interfaces, and arrays and struct literals of
+// them. Named types declared elsewhere cannot be looked into, so they are
+// assumed to hold pointers unless they are predeclared.
+func HasPointers(typ string) bool {
+	return pointerBytes(typ, 2*amd64Sizes.word, amd64Sizes.word) > 0
+}
+
+// PointerPrefix returns the length of the prefix of s, in its current order,
+// that holds its pointers: the garbage collector scans a struct only up to
+// its last pointer word. It is zero if s holds no pointers.
+func PointerPrefix(s StructInfo) int64 {
+	var prefix int64
+	word := s.sizes().word
+	for _, f := range s.Fields {
+		if n := pointerBytes(f.Type, f.Size, word); n > 0 {
+			prefix = max(prefix, f.Offset+n)
+		}
+	}
+	return prefix
+}
+
+// GCOrder returns a copy of s with its fields reordered to minimize padding
+// and, among the orders of that size, the pointer prefix. s itself is not
+// modified.
+func GCOrder(s StructInfo) StructInfo {
+	fields := slices.Clone(s.Fields)
+	s.Fields = fields
+	AnalyzeStruct(&s)
+
+	s.Fields = make([]FieldInfo, len(fields))
+	for i, j := range GCPermutation(StructInfo{Fields: fields, KeepFirst: s.KeepFirst}) {
+		s.Fields[i] = fields[j]
+	}
+
+	layoutFields(&s)
+	return s
+}
+
+// GCPermutation returns the field order of GCOrder as a permutation: the i-th
+// field of that layout is s.Fields[order[i]]. The pointer fields come first
+// within each alignment, or before all others if that doesn't grow the
+// struct, and the one with the most scalar bytes at its end comes last among
+// them. An order doesn't change unless it shortens the prefix, so structs
+// without pointers get the order of OptimalPermutation, as do structs with
+// fields marked //padding:hot, whose placement comes first. The first
+// KeepFirst fields and cache-line pads keep their places. s itself is not
+// modified.
+func GCPermutation(s StructInfo) (order []int) {
+	if order, ok := byPrefix(s, GCPermutation); ok {
+		return order
+	}
+	if order, ok := bySegments(s, GCPermutation); ok {
+		return order
+	}
+	if slices.ContainsFunc(s.Fields, func(f FieldInfo) bool { return f.HasDirective("hot") }) {
+		return OptimalPermutation(s)
+	}
+	fields := slices.Clone(s.Fields)
+	slots := make([]slot, len(fields))
+	ptrs := make([]int64, len(fields))
+	sizes := s.sizes()
+	for i := range fields {
+		f := &fields[i]
+		if f.Align == 0 {
+			f.Size, f.Align = sizes.getFieldSize(f.Type), sizes.getFieldAlign(f.Type)
+		}
+		slots[i] = slot{f.Size, f.Align}
+		ptrs[i] = pointerBytes(f.Type, f.Size, sizes.word)
+	}
+	layout := func(order []int) (size, prefix int64) {
+		var p packer
+		for _, i := range order {
+			offset := p.add(slots[i].size, slots[i].align)
+			if ptrs[i] > 0 {
+				prefix = max(prefix, offset+ptrs[i])
+			}
+		}
+		size, _ = p.size()
+		return size, prefix
+	}
+
+	order = minimalMoveOrder(slots)
+	size, prefix := layout(order)
+	zeroFirst := func(i, j int) int {
+		return trueFirst(slots[i].size == 0, slots[j].size == 0)
+	}
+	pointersFirst := func(i, j int) int {
+		return trueFirst(ptrs[i] > 0, ptrs[j] > 0)
+	}
+	byAlign := func(i, j int) int {
+		return cmp.Compare(slots[j].align, slots[i].align)
+	}
+	// The pointer field with the longest scalar tail ends the prefix.
+	byTail := func(i, j int) int {
+		if ptrs[i] == 0 || ptrs[j] == 0 {
+			return 0
+		}
+		return cmp.Compare(slots[i].size-ptrs[i], slots[j].size-ptrs[j])
+	}
+	for _, compare := range []func(i, j int) int{
+		// Within each alignment, keeping the padding of the optimal order.
+		func(i, j int) int {
+			return cmp.Or(zeroFirst(i, j), byAlign(i, j), pointersFirst(i, j), byTail(i, j))
+		},
+		// Before all other fields.
+		func(i, j int) int {
+			return cmp.Or(zeroFirst(i, j), pointersFirst(i, j), byAlign(i, j), byTail(i, j))
+		},
+	} {
+		candidate := slices.Clone(order)
+		slices.SortStableFunc(candidate, compare)
+		if sz, p := layout(candidate); sz <= size && p < prefix {
+			order, prefix = candidate, p
+		}
+	}
+	return order
+}
+
+// trueFirst compares a and b so that true sorts before false.
+func trueFirst(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return -1
+	}
+	return 1
+}
+
+// pointerBytes returns the length of the prefix of a field of the type
+// expression typ and the given size that holds pointers, or zero if it holds
+// none, for pointers of word bytes.
+func pointerBytes(typ string, size, word int64) int64 {
+	expr, err := parser.ParseExpr(typ)
+	if err != nil {
+		return size
+	}
+	return min(exprPointerBytes(expr, size, word), size)
+}
+
+func exprPointerBytes(expr ast.Expr, size, word int64) int64 {
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		return exprPointerBytes(e.X, size, word)
+	case *ast.Ident:
+		switch e.Name {
+		case "bool", "byte", "rune", "uintptr",
+			"int", "int8", "int16", "int32", "int64",
+			"uint", "uint8", "uint16", "uint32", "uint64",
+			"float32", "float64", "complex64", "complex128":
+			return 0
+		case "string":
+			return word
+		case "error", "any":
+			return 2 * word
+		}
+		return size
+	case *ast.SelectorExpr:
+		if x, ok := e.X.(*ast.Ident); ok && x.Name == "unsafe" && e.Sel.Name == "Pointer" {
+			return word
+		}
+		return size
+	case *ast.StarExpr, *ast.MapType, *ast.ChanType, *ast.FuncType:
+		return word
+	case *ast.InterfaceType:
+		return 2 * word
+	case *ast.ArrayType:
+		if e.Len == nil {
+			return word
+		}
+		if lit, ok := e.Len.(*ast.BasicLit); ok && lit.Value == "0" {
+			return 0
+		}
+		if exprPointerBytes(e.Elt, 2*word, word) == 0 {
+			return 0
+		}
+		return size
+	case *ast.StructType:
+		for _, f := range e.Fields.List {
+			if exprPointerBytes(f.Type, 2*word, word) > 0 {
+				return size
+			}
+		}
+		return 0
+	}
+	return size
+}
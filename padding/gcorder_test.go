@@ -0,0 +1,132 @@
+package padding_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestHasPointers(t *testing.T) {
+	for typ, want := range map[string]bool{
+		"bool":                     false,
+		"int64":                    false,
+		"uintptr":                  false,
+		"complex128":               false,
+		"[4]int32":                 false,
+		"[0]*T":                    false,
+		"struct{ a, b int }":       false,
+		"*T":                       true,
+		"[]byte":                   true,
+		"string":                   true,
+		"map[string]int":           true,
+		"chan int":                 true,
+		"func()":                   true,
+		"error":                    true,
+		"any":                      true,
+		"interface{ M() }":         true,
+		"unsafe.Pointer":           true,
+		"[2]string":                true,
+		"struct{ a int; b *byte }": true,
+		"T":                        true, // named types may hold pointers
+		"time.Time":                true,
+	} {
+		if got := padding.HasPointers(typ); got != want {
+			t.Errorf("HasPointers(%q) = %v, want %v", typ, got, want)
+		}
+	}
+}
+
+// fieldNames returns the names of the fields of s in the given order.
+func fieldNames(s padding.StructInfo, order []int) []string {
+	names := make([]string, len(order))
+	for i, j := range order {
+		names[i] = s.Fields[j].Name
+	}
+	return names
+}
+
+func TestGCPermutation(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		fields []padding.FieldInfo
+		want   []string
+		prefix int64
+	}{
+		{
+			"pointers first within an alignment",
+			[]padding.FieldInfo{{Name: "N", Type: "int64"}, {Name: "P", Type: "*T"}, {Name: "B", Type: "bool"}},
+			[]string{"P", "N", "B"}, 8,
+		},
+		{
+			// The string's length word is scalar, so it ends the prefix
+			// rather than the pointer.
+			"longest scalar tail last",
+			[]padding.FieldInfo{{Name: "S", Type: "string"}, {Name: "P", Type: "*T"}},
+			[]string{"P", "S"}, 16,
+		},
+		{
+			"no pointers",
+			[]padding.FieldInfo{{Name: "B", Type: "bool"}, {Name: "N", Type: "int64"}, {Name: "C", Type: "bool"}},
+			[]string{"N", "B", "C"}, 0,
+		},
+		{
+			// 4-byte pointers may move before 8-byte fields as long as
+			// the struct doesn't grow.
+			"pointers before wider fields",
+			[]padding.FieldInfo{
+				{Name: "N", Type: "int64", Size: 8, Align: 8},
+				{Name: "P", Type: "*T", Size: 4, Align: 4},
+				{Name: "Q", Type: "*T", Size: 4, Align: 4},
+			},
+			[]string{"P", "Q", "N"}, 8,
+		},
+		{
+			"not at the cost of size",
+			[]padding.FieldInfo{
+				{Name: "N", Type: "int64", Size: 8, Align: 8},
+				{Name: "M", Type: "int32", Size: 4, Align: 4},
+				{Name: "P", Type: "*T", Size: 4, Align: 4},
+			},
+			[]string{"N", "P", "M"}, 12,
+		},
+	} {
+		s := padding.StructInfo{Name: "T", Fields: tt.fields}
+		padding.AnalyzeStruct(&s)
+		if got := fieldNames(s, padding.GCPermutation(s)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: GCPermutation = %v, want %v", tt.name, got, tt.want)
+		}
+		o := padding.GCOrder(s)
+		if o.Size != padding.Optimal(s).Size {
+			t.Errorf("%s: GCOrder size = %d, want the optimal %d", tt.name, o.Size, padding.Optimal(s).Size)
+		}
+		if got := padding.PointerPrefix(o); got != tt.prefix {
+			t.Errorf("%s: pointer prefix = %d, want %d", tt.name, got, tt.prefix)
+		}
+	}
+}
+
+func TestFprintGCOrder(t *testing.T) {
+	for _, tt := range []struct {
+		types []string
+		want  string
+	}{
+		{[]string{"int64", "string", "*T"}, "  Pointer prefix: 32 bytes, 16 with the pointer-first order C, B, A\n"},
+		{[]string{"*T", "int64"}, "  Pointer prefix: 8 bytes, which no order of the optimal size shortens\n"},
+		{[]string{"int64", "bool"}, ""},
+	} {
+		s := padding.StructInfo{Name: "T"}
+		for i, typ := range tt.types {
+			s.Fields = append(s.Fields, padding.FieldInfo{Name: string(rune('A' + i)), Type: typ})
+		}
+		padding.AnalyzeStruct(&s)
+		r := padding.NewStructReport(s)
+		r.CheckGCOrder(s)
+		var b strings.Builder
+		padding.FprintStruct(&b, r)
+		if got := b.String(); tt.want == "" && strings.Contains(got, "Pointer prefix") || !strings.Contains(got, tt.want) {
+			t.Errorf("%v: report lacks %q:\n%s", tt.types, tt.want, got)
+		}
+	}
+}
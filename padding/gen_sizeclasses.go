@@ -0,0 +1,104 @@
+//go:build ignore
+
+// gen_sizeclasses writes sizeclasses.go, the table of the runtime's
+// allocation size classes, from the sources of the Go installation running
+// it. Run it with go generate after a Go release changes the classes.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// sources lists where Go releases keep the size class table, newest first,
+// with the name of the variable holding the size of each class.
+var sources = []struct{ path, name string }{
+	{"src/internal/runtime/gc/sizeclasses.go", "SizeClassToSize"},
+	{"src/runtime/sizeclasses.go", "class_to_size"},
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("gen_sizeclasses: ")
+
+	out, err := exec.Command("go", "env", "GOROOT").Output()
+	if err != nil {
+		log.Fatalf("go env GOROOT: %v", err)
+	}
+	goroot := strings.TrimSpace(string(out))
+
+	var sizes []string
+	for _, src := range sources {
+		path := filepath.Join(goroot, src.path)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		var err error
+		if sizes, err = classSizes(path, src.name); err != nil {
+			log.Fatal(err)
+		}
+		break
+	}
+	if sizes == nil {
+		log.Fatalf("no size class table in %s", goroot)
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by gen_sizeclasses.go from %s; DO NOT EDIT.\n\n", runtime.Version())
+	fmt.Fprintf(&b, "package padding\n\n")
+	fmt.Fprintf(&b, "// sizeClasses holds the object sizes of the runtime's size classes in\n")
+	fmt.Fprintf(&b, "// increasing order, starting with the unused class 0.\n")
+	fmt.Fprintf(&b, "var sizeClasses = [...]int64{%s}\n", strings.Join(sizes, ", "))
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("sizeclasses.go", src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// classSizes returns the elements of the composite literal assigned to the
+// package-level variable name in the file at path.
+func classSizes(path, name string) ([]string, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			spec := spec.(*ast.ValueSpec)
+			if len(spec.Names) != 1 || spec.Names[0].Name != name || len(spec.Values) != 1 {
+				continue
+			}
+			lit, ok := spec.Values[0].(*ast.CompositeLit)
+			if !ok {
+				return nil, fmt.Errorf("%s: %s is not a composite literal", path, name)
+			}
+			var sizes []string
+			for _, elt := range lit.Elts {
+				v, ok := elt.(*ast.BasicLit)
+				if !ok || v.Kind != token.INT {
+					return nil, fmt.Errorf("%s: %s has a non-constant element", path, name)
+				}
+				sizes = append(sizes, v.Value)
+			}
+			return sizes, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: no variable %s", path, name)
+}
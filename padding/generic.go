@@ -0,0 +1,228 @@
+package padding
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"slices"
+)
+
+// maxCommonSearch is the number of fields up to which CommonOrder tries
+// every order. Larger structs choose among the optimal orders of the
+// instantiations and the declared order.
+const maxCommonSearch = 8
+
+// CommonOrder returns the field order for the instantiations insts of one
+// generic struct, sized by sizes, that minimizes the largest waste among
+// them: the bytes an instantiation laid out in that order takes over its
+// optimal size. The order is a permutation as for OptimalOrder; a waste of
+// zero means it is optimal for every instantiation. Among orders of the
+// same waste, the one moving the fewest fields from the declared order,
+// then the one with the smallest sizes in all, wins. The instantiations
+// must have the same number of fields.
+func CommonOrder(insts []*types.Struct, sizes types.Sizes) (order []int, waste int64) {
+	if len(insts) == 0 {
+		return nil, 0
+	}
+	n := insts[0].NumFields()
+	slots := make([][]slot, len(insts))
+	optimal := make([]int64, len(insts))
+	for i, st := range insts {
+		slots[i] = make([]slot, n)
+		for j := range slots[i] {
+			t := st.Field(j).Type()
+			slots[i][j] = slot{sizes.Sizeof(t), sizes.Alignof(t)}
+		}
+		optimal[i] = slotsSize(slots[i], optimalOrder(slots[i]))
+	}
+
+	var bestTotal int64
+	bestMoved := 0
+	try := func(candidate []int) {
+		var worst, total int64
+		for i := range slots {
+			size := slotsSize(slots[i], candidate)
+			worst = max(worst, size-optimal[i])
+			total += size
+		}
+		moved := len(Moved(candidate))
+		if order != nil {
+			if worst != waste {
+				if worst > waste {
+					return
+				}
+			} else if moved != bestMoved {
+				if moved > bestMoved {
+					return
+				}
+			} else if total >= bestTotal {
+				return
+			}
+		}
+		order, waste, bestMoved, bestTotal = slices.Clone(candidate), worst, moved, total
+	}
+
+	declared := make([]int, n)
+	for i := range declared {
+		declared[i] = i
+	}
+	if n > maxCommonSearch {
+		try(declared)
+		for i := range slots {
+			try(minimalMoveOrder(slots[i]))
+		}
+		return order, waste
+	}
+	permutations(declared, 0, try)
+	return order, waste
+}
+
+// permutations calls visit with every order of p[k:], keeping p[:k].
+func permutations(p []int, k int, visit func([]int)) {
+	if k == len(p) {
+		visit(p)
+		return
+	}
+	for i := k; i < len(p); i++ {
+		p[k], p[i] = p[i], p[k]
+		permutations(p, k+1, visit)
+		p[k], p[i] = p[i], p[k]
+	}
+}
+
+// OrderedSize returns the size of st, sized by sizes, with its fields in
+// order, a permutation as returned by OptimalOrder.
+func OrderedSize(st *types.Struct, sizes types.Sizes, order []int) int64 {
+	p := packerFor(sizes)
+	for _, i := range order {
+		t := st.Field(i).Type()
+		p.add(sizes.Sizeof(t), sizes.Alignof(t))
+	}
+	size, _ := p.size()
+	return size
+}
+
+// typeParams returns the type parameters of list, such as the [K comparable,
+// V any] of a generic type, by name, with the type each is bound to if its
+// constraint allows only one of the predeclared types, as ~int64 does:
+// every instantiation then lays the parameter out as that type. The others
+// map to the empty string.
+func typeParams(list *ast.FieldList) map[string]string {
+	params := make(map[string]string)
+	if list == nil {
+		return params
+	}
+	for _, field := range list.List {
+		core := coreType(field.Type)
+		for _, name := range field.Names {
+			params[name.Name] = core
+		}
+	}
+	return params
+}
+
+// receiverParams returns the type parameters of the receiver of a method of
+// a generic type, such as the T of func (l *List[T]), as typeParams does,
+// their constraints unknown.
+func receiverParams(recv *ast.FieldList) map[string]string {
+	params := make(map[string]string)
+	if recv == nil || len(recv.List) != 1 {
+		return params
+	}
+	typ := recv.List[0].Type
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ = star.X
+	}
+	var indices []ast.Expr
+	switch typ := typ.(type) {
+	case *ast.IndexExpr:
+		indices = []ast.Expr{typ.Index}
+	case *ast.IndexListExpr:
+		indices = typ.Indices
+	}
+	for _, index := range indices {
+		if ident, ok := index.(*ast.Ident); ok && ident.Name != "_" {
+			params[ident.Name] = ""
+		}
+	}
+	return params
+}
+
+// coreType returns the predeclared type constraint allows alone, written as
+// int64, ~int64 or interface{ ~int64 }, or the empty string.
+func coreType(constraint ast.Expr) string {
+	switch c := constraint.(type) {
+	case *ast.ParenExpr:
+		return coreType(c.X)
+	case *ast.Ident:
+		if obj, ok := types.Universe.Lookup(c.Name).(*types.TypeName); ok {
+			if _, basic := obj.Type().(*types.Basic); basic {
+				return c.Name
+			}
+		}
+	case *ast.UnaryExpr:
+		if c.Op == token.TILDE {
+			return coreType(c.X)
+		}
+	case *ast.InterfaceType:
+		if list := c.Methods.List; len(list) == 1 && len(list[0].Names) == 0 {
+			return coreType(list[0].Type)
+		}
+	}
+	return ""
+}
+
+// paramSized reports whether the size of the type expr depends on the type
+// parameters params not bound to a single type, held by value in expr,
+// directly or through arrays, struct types and the type arguments of other
+// generic types. Pointers, slices, maps, channels, functions and interfaces
+// take the same space whatever they refer to.
+func paramSized(expr ast.Expr, params map[string]string) bool {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		core, ok := params[e.Name]
+		return ok && core == ""
+	case *ast.ParenExpr:
+		return paramSized(e.X, params)
+	case *ast.ArrayType:
+		return e.Len != nil && paramSized(e.Elt, params)
+	case *ast.StructType:
+		for _, field := range e.Fields.List {
+			if paramSized(field.Type, params) {
+				return true
+			}
+		}
+	case *ast.IndexExpr:
+		return paramSized(e.Index, params)
+	case *ast.IndexListExpr:
+		for _, index := range e.Indices {
+			if paramSized(index, params) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// bindParams returns fieldType, a type expression, with the type parameters
+// of params bound to a single type replaced by it, so that it can be sized,
+// and whether it mentioned any.
+func bindParams(fieldType string, params map[string]string) (string, bool) {
+	expr, err := parser.ParseExpr(fieldType)
+	if err != nil {
+		return fieldType, false
+	}
+	bound := false
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok && params[ident.Name] != "" {
+			ident.Name = params[ident.Name]
+			bound = true
+		}
+		return true
+	})
+	if !bound {
+		return fieldType, false
+	}
+	return types.ExprString(expr), true
+}
@@ -0,0 +1,183 @@
+package padding_test
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestCommonOrder(t *testing.T) {
+	sizes := types.SizesFor("gc", "amd64")
+	tests := []struct {
+		name  string
+		decl  string     // struct type with the type arguments as %s
+		args  [][]string // type arguments of each instantiation
+		order []int
+		waste int64
+		sizes []int64
+	}{
+		{
+			name:  "declared order optimal for all",
+			decl:  "struct { key string; hot bool; val %s }",
+			args:  [][]string{{"bool"}, {"int64"}, {"[3]int64"}},
+			order: []int{0, 1, 2},
+			sizes: []int64{24, 32, 48},
+		},
+		{
+			name:  "one move optimal for all",
+			decl:  "struct { hot bool; key string; val %s }",
+			args:  [][]string{{"bool"}, {"int64"}, {"[3]int64"}},
+			order: []int{0, 2, 1},
+			sizes: []int64{24, 32, 48},
+		},
+		{
+			name:  "byte arrays",
+			decl:  "struct { hot bool; key string; val %s }",
+			args:  [][]string{{"[1]byte"}, {"[8]byte"}, {"[24]byte"}},
+			order: []int{0, 2, 1},
+			sizes: []int64{24, 32, 48},
+		},
+		{
+			name:  "trade-off",
+			decl:  "struct { n int32; flag bool; key %s; val %s }",
+			args:  [][]string{{"int64", "bool"}, {"bool", "int64"}},
+			order: []int{0, 1, 2, 3},
+			waste: 8,
+			sizes: []int64{24, 16},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var insts []*types.Struct
+			for _, args := range tt.args {
+				var a []any
+				for _, arg := range args {
+					a = append(a, arg)
+				}
+				insts = append(insts, checkStruct(t, "type T "+fmt.Sprintf(tt.decl, a...)))
+			}
+			order, waste := padding.CommonOrder(insts, sizes)
+			if !slices.Equal(order, tt.order) || waste != tt.waste {
+				t.Errorf("CommonOrder = %v, %d, want %v, %d", order, waste, tt.order, tt.waste)
+			}
+			for i, st := range insts {
+				if got := padding.OrderedSize(st, sizes, order); got != tt.sizes[i] {
+					t.Errorf("%v: size %d in the common order, want %d", tt.args[i], got, tt.sizes[i])
+				}
+			}
+		})
+	}
+}
+
+const typeParamsSource = `package p
+
+type Pair[K comparable, V any] struct {
+	ok   bool
+	Key  K
+	n    int64
+	vals [2]V
+	next *Pair[K, V]
+	all  []V
+	box  Box[V]
+	ptr  Box[*V]
+}
+
+type Box[T any] struct{ v T }
+
+type Num[T ~int64, U interface{ ~int32 }, W int8] struct {
+	a bool
+	v T
+	u [2]U
+	w W
+}
+
+func (p *Pair[K, V]) entry() {
+	var e struct {
+		key K
+		ok  bool
+	}
+	_ = e
+}
+
+func Keyed[T any](v T) {
+	var kv struct {
+		k string
+		v T
+	}
+	_ = kv
+}
+`
+
+func TestAnalyzeTypeParams(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", typeParamsSource, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	structs, err := padding.Analyze(fset, file, padding.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	paramSized := map[string][]string{
+		"Pair":                       {"Key", "vals", "box"},
+		"Box":                        {"v"},
+		"Num":                        nil,
+		"p.go:24 (anonymous struct)": {"key"},
+		"p.go:32 (anonymous struct)": {"v"},
+	}
+	if len(structs) != len(paramSized) {
+		t.Fatalf("Analyze found %d structs, want %d", len(structs), len(paramSized))
+	}
+	for _, s := range structs {
+		want, ok := paramSized[s.Name]
+		if !ok {
+			t.Errorf("unexpected struct %s", s.Name)
+			continue
+		}
+		var got []string
+		for _, f := range s.Fields {
+			if f.ParamSized {
+				got = append(got, f.Name)
+			}
+		}
+		if !slices.Equal(got, want) || s.ParamSized != (want != nil) {
+			t.Errorf("%s: fields sized by type parameters %v (ParamSized %v), want %v", s.Name, got, s.ParamSized, want)
+		}
+	}
+
+	// Type parameters constrained to a single type are laid out as it.
+	num := structs[2]
+	if num.Size != 32 || num.Fields[1].Size != 8 || num.Fields[2].Size != 8 || num.Fields[2].Align != 4 || num.Fields[3].Size != 1 {
+		t.Errorf("Num laid out as %+v, size %d, want the type parameters sized as int64, int32 and int8", num.Fields, num.Size)
+	}
+
+	r := padding.NewStructReport(structs[0])
+	if !r.ParamSized || r.Size != 0 || r.WastedBytes != 0 || r.OptimalSize != 0 {
+		t.Errorf("report of Pair: ParamSized %v, size %d, optimal %d, wasted %d; want sizes left out",
+			r.ParamSized, r.Size, r.OptimalSize, r.WastedBytes)
+	}
+	if f := r.Fields[1]; !f.ParamSized || f.Size != 0 || f.Align != 0 {
+		t.Errorf("report of Pair.Key: %+v, want its size left out", f)
+	}
+	if f := r.Fields[2]; f.ParamSized || f.Size != 8 || f.Align != 8 {
+		t.Errorf("report of Pair.n: %+v, want its size kept", f)
+	}
+	var buf strings.Builder
+	padding.FprintStruct(&buf, r)
+	for _, want := range []string{
+		"Struct: Pair (size depends on type parameters)\n",
+		"  Key K (size: ?)\n",
+		"  n int64 (size: 8, align: 8)\n",
+		"  ptr Box[*V] (size: 8, align: 8)\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("report lacks %q:\n%s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package padding
+
+import "go/types"
+
+// GlobalReport is the padding held by a package-level variable of a struct
+// type, or of an array of one, however deeply nested. Such a variable lives
+// for the whole run of a program, so its padding never shows in the
+// instances of the struct type.
+type GlobalReport struct {
+	Package  string `json:"package"` // name of the package
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Position string `json:"position"` // file and line of its declaration
+	Size     int64  `json:"size"`
+
+	// Elements is the number of structs in the variable, the product of
+	// the lengths of its arrays, or 1 for a struct.
+	Elements int64 `json:"elements"`
+
+	// PaddingBytes is all the padding of the variable, that of the
+	// structs nested in the elements included, and RecoverableBytes the
+	// bytes reordering the fields of the element type saves across them.
+	PaddingBytes     int64 `json:"padding_bytes"`
+	RecoverableBytes int64 `json:"recoverable_bytes"`
+}
+
+// StaticFootprint returns the structs in a value of type t, a struct or an
+// array of structs, the padding bytes they hold, theirs and that of the
+// structs nested in them, and the bytes the optimal order of the fields of
+// the element struct type saves across them. It reports false for other
+// types.
+func StaticFootprint(t types.Type, sizes types.Sizes) (elements, padding, recoverable int64, ok bool) {
+	elements = 1
+	for {
+		arr, isArray := t.Underlying().(*types.Array)
+		if !isArray {
+			break
+		}
+		elements *= arr.Len()
+		t = arr.Elem()
+	}
+	st, ok := t.Underlying().(*types.Struct)
+	if !ok {
+		return 0, 0, 0, false
+	}
+	total, _ := PaddingSources(st, sizes)
+	saved := sizes.Sizeof(st) - OrderedSize(st, sizes, OptimalOrder(st, sizes))
+	return elements, elements * total, elements * saved, true
+}
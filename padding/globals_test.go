@@ -0,0 +1,74 @@
+package padding_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestStaticFootprint(t *testing.T) {
+	const src = `package p
+
+type entry struct {
+	ok   bool
+	next int64
+	hit  bool
+}
+
+type wrap struct {
+	e    entry
+	tag  int32
+}
+
+const buckets = 1 << 4
+
+var (
+	table [buckets * 2]entry
+	grid  [2][3]wrap
+	one   entry
+	none  [0]entry
+	ptrs  [4]*entry
+	n     int64
+)
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := new(types.Config).Check("p", fset, []*ast.File{file}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sizes := types.SizesFor("gc", "amd64")
+
+	tests := []struct {
+		name                           string
+		elements, padding, recoverable int64
+		ok                             bool
+	}{
+		// entry is 24 bytes with 14 of padding, 16 in the optimal order.
+		{"table", 32, 32 * 14, 32 * 8, true},
+		// wrap holds an entry and 4 bytes of its own padding; reordering
+		// its two fields saves nothing.
+		{"grid", 6, 6 * 18, 0, true},
+		{"one", 1, 14, 8, true},
+		{"none", 0, 0, 0, true},
+		{"ptrs", 0, 0, 0, false},
+		{"n", 0, 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			typ := pkg.Scope().Lookup(tt.name).Type()
+			elements, pad, recoverable, ok := padding.StaticFootprint(typ, sizes)
+			if elements != tt.elements || pad != tt.padding || recoverable != tt.recoverable || ok != tt.ok {
+				t.Errorf("StaticFootprint = %d, %d, %d, %v; want %d, %d, %d, %v",
+					elements, pad, recoverable, ok, tt.elements, tt.padding, tt.recoverable, tt.ok)
+			}
+		})
+	}
+}
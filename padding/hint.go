@@ -0,0 +1,69 @@
+package padding
+
+import "slices"
+
+// EditHint is the cheapest manual edit reaching the optimal size of a
+// struct: moving a single field, swapping two, or else the number of fields
+// the optimal layout moves.
+type EditHint struct {
+	Kind  string // "move", "swap" or "reorder"
+	Field string // field to move, or the first of two to swap, as "name type"
+	After string // field to move it after, empty to move it first
+	With  string // field to swap it with
+	Moves int    // fields the optimal layout moves, for "reorder"
+	Saved int64  // bytes the edit saves
+}
+
+// Hint returns the cheapest manual edit that reaches the optimal size of s,
+// or false if s is optimal already. A single move is taken from the order
+// of OptimalPermutation, which moves as few fields as it can; failing that,
+// swapping two fields other than cache-line pads is tried.
+func Hint(s StructInfo) (EditHint, bool) {
+	optimal := Optimal(s).Size
+	if s.Size <= optimal {
+		return EditHint{}, false
+	}
+	saved := s.Size - optimal
+	order := OptimalPermutation(s)
+	moved := Moved(order)
+	if len(moved) == 1 {
+		h := EditHint{Kind: "move", Field: fieldString(s.Fields[moved[0]]), Saved: saved}
+		if k := slices.Index(order, moved[0]); k > 0 {
+			h.After = fieldString(s.Fields[order[k-1]])
+		}
+		return h, true
+	}
+
+	slots := make([]slot, len(s.Fields))
+	for i, f := range s.Fields {
+		slots[i] = slot{f.Size, f.Align}
+	}
+	if len(slots) <= maxMoveSearch {
+		swapped := make([]int, len(slots))
+		for i := range swapped {
+			swapped[i] = i
+		}
+		for i := range slots {
+			for j := i + 1; j < len(slots); j++ {
+				if IsCacheLinePad(s.Fields[i]) || IsCacheLinePad(s.Fields[j]) {
+					continue
+				}
+				swapped[i], swapped[j] = j, i
+				if slotsSize(slots, swapped) == optimal {
+					return EditHint{Kind: "swap", Field: fieldString(s.Fields[i]), With: fieldString(s.Fields[j]), Saved: saved}, true
+				}
+				swapped[i], swapped[j] = i, j
+			}
+		}
+	}
+	return EditHint{Kind: "reorder", Moves: len(moved), Saved: saved}, true
+}
+
+// fieldString returns f as it is declared, as in "flags byte", or
+// "sync.Mutex" for an embedded field.
+func fieldString(f FieldInfo) string {
+	if f.Embedded {
+		return f.Type
+	}
+	return f.Name + " " + f.Type
+}
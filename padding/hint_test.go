@@ -0,0 +1,69 @@
+package padding_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestHint(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		src  string
+		want padding.EditHint
+		ok   bool
+	}{
+		{
+			"one move",
+			"type T struct { flags byte; data []byte; n int32 }",
+			padding.EditHint{Kind: "move", Field: "flags byte", After: "n int32", Saved: 8},
+			true,
+		},
+		{
+			"move first",
+			"type T struct { a bool; b int32; c bool; d int64 }",
+			padding.EditHint{Kind: "move", Field: "b int32", Saved: 8},
+			true,
+		},
+		{
+			"swap",
+			"type T struct { a bool; x int64; b bool; y int64; c bool }",
+			padding.EditHint{Kind: "swap", Field: "a bool", With: "y int64", Saved: 16},
+			true,
+		},
+		{
+			"two moves",
+			"type T struct { a bool; b int16; c int64; d int32; e bool }",
+			padding.EditHint{Kind: "reorder", Moves: 2, Saved: 8},
+			true,
+		},
+		{"optimal", "type T struct { x int64; a, b bool }", padding.EditHint{}, false},
+	} {
+		got, ok := padding.Hint(analyzeOne(t, tt.src))
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("%s: Hint = %+v, %v, want %+v, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFprintHint(t *testing.T) {
+	for _, tt := range []struct {
+		src, want string
+	}{
+		{"type T struct { flags byte; data []byte; n int32 }", "  hint: move `flags byte` after `n int32` to save 8 bytes\n"},
+		{"type T struct { a bool; b int32; c bool; d int64 }", "  hint: move `b int32` first to save 8 bytes\n"},
+		{"type T struct { a bool; x int64; b bool; y int64; c bool }", "  hint: swap `a bool` and `y int64` to save 16 bytes\n"},
+		{"type T struct { a bool; b int16; c int64; d int32; e bool }",
+			"  hint: no single move or swap saves the 8 bytes; the optimal layout moves 2 fields\n"},
+	} {
+		s := analyzeOne(t, tt.src)
+		r := padding.NewStructReport(s)
+		r.CheckHint(s)
+		var b strings.Builder
+		padding.FprintStruct(&b, r)
+		if !strings.Contains(b.String(), tt.want) {
+			t.Errorf("%s: report lacks %q:\n%s", tt.src, tt.want, b.String())
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package padding
+
+import "fmt"
+
+// Hole is a run of padding bytes in the layout of a struct, between two
+// fields or after the last one.
+type Hole struct {
+	Offset int64 // offset of the first padding byte
+	Size   int64 // number of padding bytes
+	After  int   // index of the field the hole follows
+	Before int   // index of the field the hole precedes, -1 at the end
+}
+
+// Holes returns the padding of s, in offset order.
+func Holes(s StructInfo) []Hole {
+	var holes []Hole
+	for i, f := range s.Fields {
+		end := f.Offset + f.Size
+		next, before := s.Size, -1
+		if i+1 < len(s.Fields) {
+			next, before = s.Fields[i+1].Offset, i+1
+		}
+		if next > end {
+			holes = append(holes, Hole{end, next - end, i, before})
+		}
+	}
+	return holes
+}
+
+// SplitPadding returns the padding of s between its fields, and that after
+// its last field.
+func SplitPadding(s StructInfo) (interField, trailing int64) {
+	for _, h := range Holes(s) {
+		if h.Before >= 0 {
+			interField += h.Size
+		} else {
+			trailing += h.Size
+		}
+	}
+	return interField, trailing
+}
+
+// Explain returns why h, a hole of s, exists and what removes it, such as
+// "7 bytes of padding between `flag bool` and `count int64`, because count
+// requires 8-byte alignment".
+func (h Hole) Explain(s StructInfo) string {
+	after := s.Fields[h.After]
+	bytes := plural(int(h.Size), "byte", "bytes")
+	if h.Before >= 0 {
+		before := s.Fields[h.Before]
+		return fmt.Sprintf("%d %s of padding between `%s` and `%s`, because %s requires %d-byte alignment;"+
+			" smaller fields filling the gap, or ordering the fields by decreasing alignment, remove it",
+			h.Size, bytes, fieldString(after), fieldString(before), before.Name, before.Align)
+	}
+	if s.padsZeroTail() {
+		return fmt.Sprintf("%d %s of padding after `%s`, because a zero-size last field is padded so that its address"+
+			" stays inside %s; moving it before the other fields removes it",
+			h.Size, bytes, fieldString(after), s.Name)
+	}
+	widest := after
+	for _, f := range s.Fields {
+		if f.Align == s.Align {
+			widest = f
+			break
+		}
+	}
+	return fmt.Sprintf("%d %s of trailing padding after `%s`, because the size of %s is rounded up to a multiple of"+
+		" its %d-byte alignment, that of %s, so that the elements of arrays stay aligned;"+
+		" it goes away only if the fields and the padding between them add up to a multiple of %d bytes",
+		h.Size, bytes, fieldString(after), s.Name, s.Align, widest.Name, s.Align)
+}
@@ -0,0 +1,99 @@
+package padding_test
+
+import (
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestHoles(t *testing.T) {
+	s := analyzeOne(t, "type T struct { flag bool; count int64; id int32; ok bool }")
+	want := []padding.Hole{
+		{Offset: 1, Size: 7, After: 0, Before: 1},
+		{Offset: 21, Size: 3, After: 3, Before: -1},
+	}
+	got := padding.Holes(s)
+	if len(got) != len(want) {
+		t.Fatalf("Holes = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("hole %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestHoleExplain(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{
+			"between fields",
+			"type T struct { flag bool; count int64 }",
+			[]string{
+				"7 bytes of padding between `flag bool` and `count int64`, because count requires 8-byte alignment;" +
+					" smaller fields filling the gap, or ordering the fields by decreasing alignment, remove it",
+			},
+		},
+		{
+			"trailing",
+			"type T struct { n int32; b bool }",
+			[]string{
+				"3 bytes of trailing padding after `b bool`, because the size of T is rounded up to a multiple of its" +
+					" 4-byte alignment, that of n, so that the elements of arrays stay aligned;" +
+					" it goes away only if the fields and the padding between them add up to a multiple of 4 bytes",
+			},
+		},
+		{"no padding", "type T struct { a, b int64 }", nil},
+	} {
+		s := analyzeOne(t, tt.src)
+		var got []string
+		for _, h := range padding.Holes(s) {
+			got = append(got, h.Explain(s))
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: explanations %q, want %q", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: explanation\n%s\nwant\n%s", tt.name, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestHoleExplainZeroSize(t *testing.T) {
+	// The syntactic sizes don't know struct{} is empty.
+	s := padding.StructInfo{Name: "T", Fields: []padding.FieldInfo{
+		{Name: "b", Type: "bool", Size: 1, Align: 1},
+		{Name: "end", Type: "struct{}", Size: 0, Align: 1},
+	}}
+	padding.AnalyzeStruct(&s)
+	holes := padding.Holes(s)
+	want := "1 byte of padding after `end struct{}`, because a zero-size last field is padded so that its address" +
+		" stays inside T; moving it before the other fields removes it"
+	if len(holes) != 1 || holes[0].Explain(s) != want {
+		t.Errorf("Holes = %+v, want one explained as %q", holes, want)
+	}
+}
+
+func TestSplitPadding(t *testing.T) {
+	for _, tt := range []struct {
+		src                  string
+		interField, trailing int64
+	}{
+		{"type T struct { flag bool; count int64; id int32; ok bool }", 7, 3},
+		// Purely trailing: no order of the fields removes it.
+		{"type T struct { count int64; ok bool }", 0, 7},
+		{"type T struct { ok bool; count int64 }", 7, 0},
+		{"type T struct { a, b int64 }", 0, 0},
+	} {
+		s := analyzeOne(t, tt.src)
+		if interField, trailing := padding.SplitPadding(s); interField != tt.interField || trailing != tt.trailing {
+			t.Errorf("%s: SplitPadding = %d, %d, want %d, %d", tt.src, interField, trailing, tt.interField, tt.trailing)
+		}
+	}
+}
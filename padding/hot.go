@@ -0,0 +1,92 @@
+package padding
+
+import (
+	"cmp"
+	"slices"
+)
+
+// HotLineSize is the size of the prefix of a struct that Optimal keeps the
+// fields marked //padding:hot in, a typical cache line.
+const HotLineSize = 64
+
+// hotPermutation returns the field order of the fields with the given slots
+// that keeps those marked hot within the first HotLineSize bytes if it can,
+// and among such orders the smallest. It is a heuristic rather than a search
+// of all orders: it tries
+//
+//   - the order of optimalOrder, which costs nothing if the hot fields
+//     happen to fit;
+//   - that order with the hot fields first within each alignment, which
+//     keeps its size;
+//   - the hot fields first, in the order of optimalOrder, followed by the
+//     others in that order;
+//   - the hot fields first followed by the others in increasing alignment,
+//     so that small fields fill the padding after the hot ones.
+//
+// The smallest order keeping the hot fields in the line wins, the earliest
+// one on ties. If none does, the order ending the hot fields soonest does.
+func hotPermutation(slots []slot, hot []bool) []int {
+	base := optimalOrder(slots)
+	hotFirst := func(i, j int) int { return trueFirst(hot[i], hot[j]) }
+	sorted := func(compare func(i, j int) int) []int {
+		order := slices.Clone(base)
+		slices.SortStableFunc(order, compare)
+		return order
+	}
+	candidates := [][]int{
+		base,
+		sorted(func(i, j int) int {
+			return cmp.Or(trueFirst(slots[i].size == 0, slots[j].size == 0),
+				cmp.Compare(slots[j].align, slots[i].align), hotFirst(i, j))
+		}),
+		sorted(hotFirst),
+		sorted(func(i, j int) int {
+			if c := hotFirst(i, j); c != 0 || hot[i] {
+				return c
+			}
+			return cmp.Or(cmp.Compare(slots[i].align, slots[j].align), cmp.Compare(slots[i].size, slots[j].size))
+		}),
+	}
+
+	var best []int
+	var bestSize, bestEnd int64
+	for _, order := range candidates {
+		size, end := hotLayout(slots, hot, order)
+		fits, bestFits := end <= HotLineSize, bestEnd <= HotLineSize
+		switch {
+		case best == nil,
+			fits && (!bestFits || size < bestSize),
+			!fits && !bestFits && (end < bestEnd || end == bestEnd && size < bestSize):
+			best, bestSize, bestEnd = order, size, end
+		}
+	}
+	return best
+}
+
+// hotLayout returns the size of the struct with the fields in the given order
+// and the end of the last hot field.
+func hotLayout(slots []slot, hot []bool, order []int) (size, end int64) {
+	var p packer
+	for _, i := range order {
+		offset := p.add(slots[i].size, slots[i].align)
+		if hot[i] {
+			end = max(end, offset+slots[i].size)
+		}
+	}
+	size, _ = p.size()
+	return size, end
+}
+
+// HotFields returns the names of the fields of s marked //padding:hot, in
+// the order of its optimal layout, and the bytes by which the last of them
+// ends past the first HotLineSize bytes of that layout, zero if they fit.
+func HotFields(s StructInfo) (names []string, overflow int64) {
+	var end int64
+	for _, f := range Optimal(s).Fields {
+		if f.HasDirective("hot") {
+			names = append(names, f.Name)
+			end = max(end, f.Offset+f.Size)
+		}
+	}
+	return names, max(end-HotLineSize, 0)
+}
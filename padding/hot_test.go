@@ -0,0 +1,84 @@
+package padding_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestOptimalHotFields(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		src      string
+		order    []string
+		size     int64
+		overflow int64
+	}{
+		{
+			// The optimal order keeps the hot field in the line anyway.
+			name:  "fits",
+			src:   "type T struct {\n\tB bool\n\tS string\n\tH int64 //padding:hot\n}",
+			order: []string{"S", "H", "B"},
+			size:  32,
+		},
+		{
+			// Sorted by size, H would start at 80; moving it first costs
+			// nothing.
+			name:  "fits when moved first",
+			src:   "type T struct {\n\tA, B, C, D, E string\n\tH int64 //padding:hot\n}",
+			order: []string{"H", "A", "B", "C", "D", "E"},
+			size:  88,
+		},
+		{
+			// H first would leave padding before the strings, which the
+			// cold int32 fills.
+			name:  "small fields fill the gap",
+			src:   "type T struct {\n\tA, B, C, D string\n\tI int32\n\tH int32 //padding:hot\n}",
+			order: []string{"H", "I", "A", "B", "C", "D"},
+			size:  72,
+		},
+		{
+			// The hot fields take 72 bytes, 8 more than the line.
+			name:     "almost fits",
+			src:      "type T struct {\n\tN int64\n\t//padding:hot\n\tA, B, C, D string\n\tH int64 //padding:hot\n}",
+			order:    []string{"A", "B", "C", "D", "H", "N"},
+			size:     80,
+			overflow: 8,
+		},
+		{
+			name:     "cannot fit",
+			src:      "type T struct {\n\tN int32\n\t//padding:hot\n\tA, B, C, D, E, F, G string\n}",
+			order:    []string{"A", "B", "C", "D", "E", "F", "G", "N"},
+			size:     120,
+			overflow: 48,
+		},
+	} {
+		s := analyzeOne(t, tt.src)
+		o := padding.Optimal(s)
+		var order []string
+		for _, f := range o.Fields {
+			order = append(order, f.Name)
+		}
+		if !reflect.DeepEqual(order, tt.order) || o.Size != tt.size {
+			t.Errorf("%s: Optimal = %v (%d bytes), want %v (%d bytes)", tt.name, order, o.Size, tt.order, tt.size)
+		}
+		if _, overflow := padding.HotFields(s); overflow != tt.overflow {
+			t.Errorf("%s: overflow = %d, want %d", tt.name, overflow, tt.overflow)
+		}
+	}
+}
+
+func TestFprintHotFields(t *testing.T) {
+	for src, want := range map[string]string{
+		"type T struct {\n\tA, B, C, D, E string\n\tH int64 //padding:hot\n}":          "  Hot field H fits in the first 64 bytes\n",
+		"type T struct {\n\tN int32\n\t//padding:hot\n\tA, B, C, D, E, F, G string\n}": "  Hot fields A, B, C, D, E, F, G don't fit in the first 64 bytes, ending 48 bytes past them\n",
+	} {
+		var b strings.Builder
+		padding.Fprint(&b, analyzeOne(t, src))
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("report lacks %q:\n%s", want, b.String())
+		}
+	}
+}
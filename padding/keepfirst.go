@@ -0,0 +1,102 @@
+package padding
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// keepFirst returns the number of fields the //padding:keep-first=N
+// directive in ds keeps in place, zero without one.
+func keepFirst(ds []string) (int, error) {
+	v, ok := directiveValue(ds, "keep-first")
+	if !ok {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid //padding:keep-first=%s: want the number of leading fields to keep in place", v)
+	}
+	return n, nil
+}
+
+// kept returns the number of leading fields of s the optimal orders keep in
+// place: KeepFirst, or all of them if s has fewer.
+func (s StructInfo) kept() int {
+	return min(max(s.KeepFirst, 0), len(s.Fields))
+}
+
+// byPrefix returns the permutation that keeps the first fields of s in
+// place, as s.KeepFirst says, and orders the others with permute. It
+// reports false if s keeps no fields.
+func byPrefix(s StructInfo, permute func(StructInfo) []int) ([]int, bool) {
+	n := s.kept()
+	if n == 0 {
+		return nil, false
+	}
+	rest := s
+	rest.Fields, rest.KeepFirst = s.Fields[n:], 0
+	order := make([]int, n, len(s.Fields))
+	for i := range order {
+		order[i] = i
+	}
+	for _, j := range permute(rest) {
+		order = append(order, n+j)
+	}
+	return order, true
+}
+
+// optimalAfterPrefix returns the permutation of s that keeps its first
+// fields in place and minimizes the padding of the rest. Laid out after the
+// prefix rather than at offset 0, the rest may do better by increasing
+// alignment, its small fields filling the padding the prefix leaves, so the
+// orders of blockOrders are tried as well, unless hot fields or cache-line
+// pads have their own placement.
+func optimalAfterPrefix(s StructInfo) ([]int, bool) {
+	order, ok := byPrefix(s, OptimalPermutation)
+	if !ok || slices.ContainsFunc(s.Fields, func(f FieldInfo) bool { return f.HasDirective("hot") || IsCacheLinePad(f) }) {
+		return order, ok
+	}
+	n := s.kept()
+	slots := make([]slot, len(s.Fields))
+	sizes := s.sizes()
+	for i, f := range s.Fields {
+		if f.Align == 0 {
+			f.Size, f.Align = sizes.getFieldSize(f.Type), sizes.getFieldAlign(f.Type)
+		}
+		slots[i] = slot{f.Size, f.Align}
+	}
+	prefix, rest := order[:n], make([]int, 0, len(s.Fields)-n)
+	for i := n; i < len(s.Fields); i++ {
+		rest = append(rest, i)
+	}
+	size, moved := slotsSize(slots, order), len(Moved(order))
+	for _, o := range blockOrders(slots, rest) {
+		candidate := slices.Concat(prefix, o)
+		if sz, m := slotsSize(slots, candidate), len(Moved(candidate)); sz < size || sz == size && m < moved {
+			order, size, moved = candidate, sz, m
+		}
+	}
+	return order, true
+}
+
+// KeepFirstCost returns the bytes keeping the first fields of s in place
+// costs: the optimal size of s less that of s free to move all its fields.
+func KeepFirstCost(s StructInfo) int64 {
+	if s.kept() == 0 {
+		return 0
+	}
+	free := s
+	free.KeepFirst = 0
+	return Optimal(s).Size - Optimal(free).Size
+}
+
+// lockTypes are the types of the embedded fields that lock a struct or
+// mark it not to be copied, which code often expects first.
+var lockTypes = map[string]bool{"sync.Mutex": true, "sync.RWMutex": true, "noCopy": true}
+
+// LeadingLock reports whether the first field of s embeds a sync.Mutex,
+// a sync.RWMutex or a noCopy marker, which Analyze keeps in place.
+func (s StructInfo) LeadingLock() bool {
+	return len(s.Fields) > 0 && s.Fields[0].Embedded && lockTypes[s.Fields[0].Type]
+}
@@ -0,0 +1,114 @@
+package padding_test
+
+import (
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestKeepFirst(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		src     string
+		keep    int
+		want    []string
+		offsets []int64
+		size    int64
+		cost    int64
+	}{
+		{
+			name:    "none",
+			src:     "type T struct {\n\tA bool\n\tB int64\n\tC bool\n}",
+			want:    []string{"B", "A", "C"},
+			offsets: []int64{0, 8, 9},
+			size:    16,
+		},
+		{
+			// Keeping more fields than there are keeps them all.
+			name:    "all",
+			src:     "type T struct {\n\tA bool\n\tB int64\n\tC bool\n}",
+			keep:    5,
+			want:    []string{"A", "B", "C"},
+			offsets: []int64{0, 8, 16},
+			size:    24,
+			cost:    8,
+		},
+		{
+			// B can't leave the padding after A, which C no longer
+			// fills.
+			name:    "padded prefix",
+			src:     "type T struct {\n\tA bool\n\tB int64\n\tC bool\n}",
+			keep:    2,
+			want:    []string{"A", "B", "C"},
+			offsets: []int64{0, 8, 16},
+			size:    24,
+			cost:    8,
+		},
+		{
+			// Laid out after V, the small fields fill its padding
+			// rather than follow X, as they would at offset 0.
+			name:    "filled prefix",
+			src:     "type T struct {\n\tV uint8\n\tX int64\n\tY uint8\n\tZ uint16\n}",
+			keep:    1,
+			want:    []string{"V", "Y", "Z", "X"},
+			offsets: []int64{0, 1, 2, 8},
+			size:    16,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			s := analyzeOne(t, tt.src)
+			s.KeepFirst = tt.keep
+			o := padding.Optimal(s)
+			var names []string
+			var offsets []int64
+			for _, f := range o.Fields {
+				names = append(names, f.Name)
+				offsets = append(offsets, f.Offset)
+			}
+			if !reflect.DeepEqual(names, tt.want) || !reflect.DeepEqual(offsets, tt.offsets) || o.Size != tt.size {
+				t.Errorf("Optimal = %v at %v, %d bytes; want %v at %v, %d bytes", names, offsets, o.Size, tt.want, tt.offsets, tt.size)
+			}
+			if cost := padding.KeepFirstCost(s); cost != tt.cost {
+				t.Errorf("KeepFirstCost = %d, want %d", cost, tt.cost)
+			}
+			r := padding.NewStructReport(s)
+			if r.OptimalSize != tt.size || r.KeepFirstCost != tt.cost {
+				t.Errorf("report: optimal %d, cost %d; want %d, %d", r.OptimalSize, r.KeepFirstCost, tt.size, tt.cost)
+			}
+		})
+	}
+}
+
+func TestKeepFirstOrders(t *testing.T) {
+	s := analyzeOne(t, "type T struct {\n\tb bool\n\tP *int\n\tx int64\n\tN int32\n\ta int32\n}")
+	s.KeepFirst = 1
+	for name, o := range map[string]padding.StructInfo{
+		"GCOrder":         padding.GCOrder(s),
+		"VisibilityOrder": padding.VisibilityOrder(s),
+		"SortTies":        padding.SortTies(padding.Optimal(s)),
+	} {
+		if o.Fields[0].Name != "b" {
+			t.Errorf("%s moved the kept field: %v", name, fieldNames(o, []int{0, 1, 2, 3, 4}))
+		}
+	}
+}
+
+func TestKeepFirstDirective(t *testing.T) {
+	s := analyzeOne(t, "//padding:keep-first=2\ntype T struct {\n\tA bool\n\tB int64\n\tC bool\n}")
+	if s.KeepFirst != 2 || padding.Optimal(s).Fields[2].Name != "C" {
+		t.Errorf("KeepFirst = %d, optimal order %v", s.KeepFirst, padding.Optimal(s).Fields)
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", "package p\n\n//padding:keep-first=two\ntype T struct {\n\tA bool\n}\n", parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := padding.Analyze(fset, file, padding.Options{}); err == nil || !strings.Contains(err.Error(), "p.go:4:6: T: invalid //padding:keep-first=two") {
+		t.Errorf("Analyze = %v, want an invalid directive error", err)
+	}
+}
@@ -0,0 +1,160 @@
+package padding
+
+import (
+	"cmp"
+	"go/token"
+	"go/types"
+	"slices"
+)
+
+// FieldLayout is the placement of one field of a struct.
+type FieldLayout struct {
+	Field  *types.Var // the struct field
+	Offset int64      // offset of the field from the start of the struct
+	Size   int64      // size of the field in bytes
+	Align  int64      // required alignment of the field in bytes
+}
+
+// StructLayout summarizes the layout of a struct.
+type StructLayout struct {
+	Size    int64 // total size including trailing padding
+	Align   int64 // alignment of the struct, the largest field alignment
+	Padding int64 // bytes of Size between and after the fields
+
+	// TooLarge is set for a struct of MaxSize bytes or more, which gc
+	// rejects; its size and offsets are then meaningless.
+	TooLarge bool
+}
+
+// MaxSize bounds the size of a type: gc rejects types of MaxSize bytes or
+// more as too large on 64-bit targets. The sizes and offsets computed here
+// stop at it, so their arithmetic never overflows.
+const MaxSize int64 = 1 << 50
+
+// clampSize returns size, or MaxSize if it is larger or negative, as
+// types.Sizes returns it for a type too large.
+func clampSize(size int64) int64 {
+	if size < 0 || size > MaxSize {
+		return MaxSize
+	}
+	return size
+}
+
+// mulSize returns the size of n elements of size bytes, or MaxSize if that
+// is larger.
+func mulSize(n, size int64) int64 {
+	if n < 0 || size < 0 || size > 0 && n > MaxSize/size {
+		return MaxSize
+	}
+	return n * size
+}
+
+// Layout returns the placement of the fields of st in declaration order and
+// the resulting size of st, with field types sized by sizes.
+func Layout(st *types.Struct, sizes types.Sizes) ([]FieldLayout, StructLayout) {
+	fields := make([]FieldLayout, st.NumFields())
+	p := packerFor(sizes)
+	var used int64
+	for i := range fields {
+		f := st.Field(i)
+		size, alignment := clampSize(sizes.Sizeof(f.Type())), sizes.Alignof(f.Type())
+		fields[i] = FieldLayout{
+			Field:  f,
+			Offset: p.add(size, alignment),
+			Size:   size,
+			Align:  alignment,
+		}
+		used = min(used+size, MaxSize)
+	}
+	size, alignment := p.size()
+	return fields, StructLayout{Size: size, Align: alignment, Padding: max(size-used, 0), TooLarge: size >= MaxSize}
+}
+
+// OptimalOrder returns the order of the fields of st that minimizes its size,
+// as a permutation: the i-th field of the optimal layout is st.Field(order[i]).
+// Fields of equal alignment and size keep their relative order.
+func OptimalOrder(st *types.Struct, sizes types.Sizes) []int {
+	slots := make([]slot, st.NumFields())
+	for i := range slots {
+		t := st.Field(i).Type()
+		slots[i] = slot{clampSize(sizes.Sizeof(t)), sizes.Alignof(t)}
+	}
+	return minimalMoveOrder(slots)
+}
+
+// slot is the size and alignment of a field.
+type slot struct {
+	size, align int64
+}
+
+// optimalOrder returns the permutation of slots that minimizes padding.
+// Zero-size fields come first, since a trailing zero-size field is padded;
+// the rest are sorted by decreasing alignment alone. A size being a multiple
+// of the alignment, no field is then preceded by padding, and the fields of
+// one alignment keep their relative order, whatever their sizes.
+func optimalOrder(slots []slot) []int {
+	order := make([]int, len(slots))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(i, j int) int {
+		a, b := slots[i], slots[j]
+		if (a.size == 0) != (b.size == 0) {
+			if a.size == 0 {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(b.align, a.align)
+	})
+	return order
+}
+
+// packer assigns offsets to fields added one at a time, following the gc
+// compiler's layout rules, or gccgo's with zeroTail.
+type packer struct {
+	offset   int64 // end of the last field
+	align    int64 // largest alignment so far
+	lastSize int64 // size of the last field
+	zeroTail bool  // leave a zero-size last field unpadded, as gccgo does
+}
+
+// zeroTailProbe is a struct type ending in a zero-size field, which gc pads
+// and gccgo does not.
+var zeroTailProbe = types.NewStruct([]*types.Var{
+	types.NewField(token.NoPos, nil, "a", types.Typ[types.Uint8], false),
+	types.NewField(token.NoPos, nil, "b", types.NewStruct(nil, nil), false),
+}, nil)
+
+// packerFor returns a packer following the layout rules of the compiler
+// sizes describes, as returned by types.SizesFor.
+func packerFor(sizes types.Sizes) packer {
+	return packer{zeroTail: sizes.Sizeof(zeroTailProbe) == 1}
+}
+
+// add places a field after the previous ones and returns its offset. The
+// end of the fields stops at MaxSize.
+func (p *packer) add(size, alignment int64) int64 {
+	if alignment < 1 {
+		alignment = 1
+	}
+	size = clampSize(size)
+	p.align = max(p.align, alignment)
+	offset := align(p.offset, alignment)
+	p.offset = min(offset+size, MaxSize)
+	p.lastSize = size
+	return offset
+}
+
+// size returns the size and alignment of the struct holding the fields added
+// so far.
+func (p *packer) size() (size, alignment int64) {
+	alignment = max(p.align, 1)
+	end := p.offset
+	// gc: the last field of a non-zero-sized struct is not allowed to have
+	// size 0, so that its address does not point past the struct.
+	if p.lastSize == 0 && end > 0 && !p.zeroTail {
+		end++
+	}
+	return align(end, alignment), alignment
+}
@@ -0,0 +1,300 @@
+package padding_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// checkStruct type-checks src and returns the underlying struct of type T.
+func checkStruct(t *testing.T, src string) *types.Struct {
+	t.Helper()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", "package p\n"+src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := new(types.Config).Check("p", fset, []*ast.File{file}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pkg.Scope().Lookup("T").Type().Underlying().(*types.Struct)
+}
+
+func TestLayout(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		offsets []int64
+		size    int64
+		align   int64
+		padding int64
+	}{
+		{
+			name:    "scalars",
+			src:     "type T struct { a bool; b int64; c bool }",
+			offsets: []int64{0, 8, 16},
+			size:    24, align: 8, padding: 14,
+		},
+		{
+			name:    "nested struct",
+			src:     "type T struct { a bool; in struct { x int32; y bool }; c bool }",
+			offsets: []int64{0, 4, 12},
+			size:    16, align: 4, padding: 6,
+		},
+		{
+			name:    "arrays",
+			src:     "type T struct { a bool; b [3]int16; c [2]struct{ x int64 } }",
+			offsets: []int64{0, 2, 8},
+			size:    24, align: 8, padding: 1,
+		},
+		{
+			name:    "trailing zero-size field",
+			src:     "type T struct { a int64; z struct{} }",
+			offsets: []int64{0, 8},
+			size:    16, align: 8, padding: 8,
+		},
+		{
+			name:    "leading zero-size field",
+			src:     "type T struct { z [0]int64; a int32 }",
+			offsets: []int64{0, 0},
+			size:    8, align: 8, padding: 4,
+		},
+		{
+			name:    "only zero-size fields",
+			src:     "type T struct { a struct{}; b [0]byte }",
+			offsets: []int64{0, 0},
+			size:    0, align: 1, padding: 0,
+		},
+		{
+			name:    "empty",
+			src:     "type T struct{}",
+			offsets: []int64{},
+			size:    0, align: 1, padding: 0,
+		},
+	}
+
+	sizes := types.SizesFor("gc", "amd64")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := checkStruct(t, tt.src)
+			fields, layout := padding.Layout(st, sizes)
+
+			offsets := make([]int64, len(fields))
+			for i, f := range fields {
+				offsets[i] = f.Offset
+				if f.Field != st.Field(i) {
+					t.Errorf("field %d is %s, want %s", i, f.Field.Name(), st.Field(i).Name())
+				}
+			}
+			if !slices.Equal(offsets, tt.offsets) {
+				t.Errorf("offsets = %v, want %v", offsets, tt.offsets)
+			}
+			want := padding.StructLayout{Size: tt.size, Align: tt.align, Padding: tt.padding}
+			if layout != want {
+				t.Errorf("layout = %+v, want %+v", layout, want)
+			}
+
+			// The layout must agree with the compiler's.
+			var vars []*types.Var
+			for i := 0; i < st.NumFields(); i++ {
+				vars = append(vars, st.Field(i))
+			}
+			if gc := sizes.Offsetsof(vars); !slices.Equal(offsets, gc) {
+				t.Errorf("offsets = %v, gc has %v", offsets, gc)
+			}
+			if gc := sizes.Sizeof(st); layout.Size != gc {
+				t.Errorf("size = %d, gc has %d", layout.Size, gc)
+			}
+		})
+	}
+}
+
+func TestOptimalOrder(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   string
+		order []int
+		size  int64
+	}{
+		{
+			name:  "scalars",
+			src:   "type T struct { a bool; b int64; c bool }",
+			order: []int{1, 0, 2},
+			size:  16,
+		},
+		{
+			name:  "nested struct",
+			src:   "type T struct { a bool; in struct { x int64; y bool }; c bool }",
+			order: []int{1, 0, 2},
+			size:  24,
+		},
+		{
+			name:  "arrays",
+			src:   "type T struct { a bool; b [3]int64; c [5]byte; d int32 }",
+			order: []int{1, 0, 2, 3},
+			size:  40,
+		},
+		{
+			name:  "inner zero-size field stays",
+			src:   "type T struct { a int64; z struct{}; b int64 }",
+			order: []int{0, 1, 2},
+			size:  16,
+		},
+		{
+			name:  "trailing zero-size field",
+			src:   "type T struct { a int64; z struct{} }",
+			order: []int{1, 0},
+			size:  8,
+		},
+		{
+			name:  "already optimal",
+			src:   "type T struct { a int64; b int32; c bool }",
+			order: []int{0, 1, 2},
+			size:  16,
+		},
+	}
+
+	sizes := types.SizesFor("gc", "amd64")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := checkStruct(t, tt.src)
+			order := padding.OptimalOrder(st, sizes)
+			if !slices.Equal(order, tt.order) {
+				t.Fatalf("OptimalOrder = %v, want %v", order, tt.order)
+			}
+
+			fields := make([]*types.Var, len(order))
+			for i, j := range order {
+				fields[i] = st.Field(j)
+			}
+			if got := sizes.Sizeof(types.NewStruct(fields, nil)); got != tt.size {
+				t.Errorf("reordered size = %d, want %d", got, tt.size)
+			}
+		})
+	}
+}
+
+func TestLayoutCompilers(t *testing.T) {
+	tests := []struct {
+		name      string
+		src       string
+		arch      string
+		gc, gccgo int64 // sizes
+	}{
+		// gccgo leaves a zero-size last field unpadded on every platform.
+		{"zero-size last field", "type T struct { a int64; b struct{} }", "amd64", 16, 8},
+		// On arm, gc aligns 64-bit values to 4 bytes, gccgo to 8.
+		{"64-bit on arm", "type T struct { a int32; b int64 }", "arm", 12, 16},
+		{"same", "type T struct { a bool; b int64 }", "amd64", 16, 16},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := checkStruct(t, tt.src)
+			for _, c := range []struct {
+				compiler string
+				want     int64
+			}{{"gc", tt.gc}, {"gccgo", tt.gccgo}} {
+				sizes := types.SizesFor(c.compiler, tt.arch)
+				_, layout := padding.Layout(st, sizes)
+				if layout.Size != c.want || layout.Size != sizes.Sizeof(st) {
+					t.Errorf("%s: size %d, want %d (types.Sizes says %d)", c.compiler, layout.Size, c.want, sizes.Sizeof(st))
+				}
+			}
+		})
+	}
+}
+
+func TestGccgoZeroSizeLastField(t *testing.T) {
+	// The syntactic sizes don't know struct{} is empty.
+	fields := []padding.FieldInfo{
+		{Name: "a", Type: "int64", Size: 8, Align: 8},
+		{Name: "done", Type: "struct{}", Size: 0, Align: 1},
+	}
+	for _, tt := range []struct {
+		compiler string
+		size     int64
+		last     string           // the last line of the narration
+		byType   map[string]int64 // the padding attributed to field types
+	}{
+		{"", 16, "offset 8–15: padding keeping the address of the zero-size last field inside the struct; total rounded up to 16 for alignment 8", map[string]int64{"struct{}": 8}},
+		{padding.CompilerGccgo, 8, "total 8, a multiple of alignment 8", map[string]int64{}},
+	} {
+		s := padding.StructInfo{Name: "T", Fields: slices.Clone(fields), Compiler: tt.compiler}
+		padding.AnalyzeStruct(&s)
+		if s.Size != tt.size {
+			t.Errorf("%q: size %d, want %d", tt.compiler, s.Size, tt.size)
+		}
+		if lines := padding.Narrate(s); lines[len(lines)-1] != tt.last {
+			t.Errorf("%q: narration ends in %q, want %q", tt.compiler, lines[len(lines)-1], tt.last)
+		}
+		if byType := padding.PaddingByType(s); !maps.Equal(byType, tt.byType) {
+			t.Errorf("%q: padding by type %v, want %v", tt.compiler, byType, tt.byType)
+		}
+	}
+
+	// A zero-size last field after rounding padding is not to blame for
+	// it under gccgo.
+	s := padding.StructInfo{Name: "T", Compiler: padding.CompilerGccgo, Fields: []padding.FieldInfo{
+		{Name: "a", Type: "int64", Size: 8, Align: 8},
+		{Name: "b", Type: "bool", Size: 1, Align: 1},
+		{Name: "done", Type: "struct{}", Size: 0, Align: 1},
+	}}
+	padding.AnalyzeStruct(&s)
+	if byType := padding.PaddingByType(s); !maps.Equal(byType, map[string]int64{"int64": 7}) {
+		t.Errorf("gccgo: padding by type %v, want 7 bytes to int64", byType)
+	}
+}
+
+func TestAnalyzeCompiler(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", "package p\n\ntype T struct {\n\ta bool\n\tb int64\n}\n", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	structs, err := padding.Analyze(fset, file, padding.Options{Compiler: padding.CompilerGccgo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := structs[0]; s.Compiler != padding.CompilerGccgo || s.Size != 16 {
+		t.Errorf("T: compiler %q, size %d; want gccgo, 16", s.Compiler, s.Size)
+	}
+}
+
+func TestLayoutTooLarge(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want bool // whether the struct is too large
+	}{
+		// 1<<50 - 16 bytes of array, and the bool padded to 8.
+		{"just under", "type T struct { a [1<<47 - 2]int64; b bool }", false},
+		{"at the limit", "type T struct { a [1<<47 - 1]int64; b bool }", true},
+		{"overflowing", "type T struct { a bool; b [1<<62]int64; c [1<<62]int64 }", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, layout := padding.Layout(checkStruct(t, tt.src), types.SizesFor("gc", "amd64"))
+			if layout.TooLarge != tt.want {
+				t.Errorf("TooLarge = %t, want %t (size %d)", layout.TooLarge, tt.want, layout.Size)
+			}
+			if layout.Size < 0 || layout.Size > padding.MaxSize || layout.Padding < 0 {
+				t.Errorf("size %d, padding %d, want them within [0, MaxSize]", layout.Size, layout.Padding)
+			}
+			for _, f := range fields {
+				if f.Offset < 0 || f.Size < 0 {
+					t.Errorf("%s: offset %d, size %d", f.Field.Name(), f.Offset, f.Size)
+				}
+			}
+			if !tt.want && layout.Size != padding.MaxSize-8 {
+				t.Errorf("size %d, want %d", layout.Size, padding.MaxSize-8)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package padding_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestIncomparableMarker(t *testing.T) {
+	tests := []struct {
+		name          string
+		src           string
+		size, optimal int64
+	}{
+		{"start", "type T struct { _ [0]func(); a bool; b int64; c bool }", 24, 16},
+		{"middle", "type T struct { a bool; _ [0]func(); b int64; c bool }", 24, 16},
+		// Last, the marker is padded so that its address stays inside T.
+		{"end", "type T struct { a bool; b int64; c bool; _ [0]func() }", 32, 16},
+		{"after a word", "type T struct { n int64; _ [0]func() }", 16, 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fset := token.NewFileSet()
+			src := "package p\n" + tt.src + "\n"
+			file, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			structs, err := padding.Analyze(fset, file, padding.Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := structs[0]
+			marker := s.Fields[slices.IndexFunc(s.Fields, func(f padding.FieldInfo) bool { return f.Name == "_" })]
+			if marker.Size != 0 || marker.Align != 8 {
+				t.Errorf("marker: size %d, align %d, want 0 and 8", marker.Size, marker.Align)
+			}
+			if padding.Estimated(marker.Type, nil) {
+				t.Errorf("%s is estimated", marker.Type)
+			}
+
+			// The syntactic layout agrees with the type checker's.
+			pkg, err := new(types.Config).Check("p", fset, []*ast.File{file}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gc := types.SizesFor("gc", "amd64").Sizeof(pkg.Scope().Lookup("T").Type()); s.Size != tt.size || gc != tt.size {
+				t.Errorf("size %d (gc %d), want %d", s.Size, gc, tt.size)
+			}
+
+			o := padding.Optimal(s)
+			if o.Size != tt.optimal {
+				t.Errorf("optimal size %d, want %d", o.Size, tt.optimal)
+			}
+			if last := o.Fields[len(o.Fields)-1]; last.Name == "_" {
+				t.Errorf("the optimal order leaves the marker last: %v", fieldNames(s, padding.OptimalPermutation(s)))
+			}
+			out, err := padding.Rewrite(fset, file, []byte(src), []padding.StructInfo{o})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(out), "_ [0]func()") {
+				t.Errorf("the fixed declaration lost the marker:\n%s", out)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package padding
+
+import (
+	"go/ast"
+	"reflect"
+	"slices"
+	"strconv"
+)
+
+// marshalTags are the struct tag keys of the encoders whose output follows
+// the order of the fields.
+var marshalTags = []string{"json", "xml", "yaml", "csv"}
+
+// MarshalTags returns the keys of marshalTags that the fields of s carry, in
+// the order of marshalTags.
+func MarshalTags(s StructInfo) []string {
+	var keys []string
+	for _, key := range marshalTags {
+		for _, f := range s.Fields {
+			if _, ok := fieldTag(f, key); ok {
+				keys = append(keys, key)
+				break
+			}
+		}
+	}
+	return keys
+}
+
+// MarshalOrderChanges reports whether laying out s as fixed, the same fields
+// in another order, changes the order encoders marshal them in: whether s
+// carries marshalTags and its marshaled fields, the exported ones not tagged
+// "-", come in another order.
+func MarshalOrderChanges(s, fixed StructInfo) bool {
+	if len(MarshalTags(s)) == 0 {
+		return false
+	}
+	return !slices.Equal(marshaledFields(s), marshaledFields(fixed))
+}
+
+// marshaledFields returns the names of the fields of s encoders marshal.
+func marshaledFields(s StructInfo) []string {
+	var names []string
+	for _, f := range s.Fields {
+		if !ast.IsExported(f.Name) {
+			continue
+		}
+		skipped := false
+		for _, key := range marshalTags {
+			if v, ok := fieldTag(f, key); ok && v == "-" {
+				skipped = true
+			}
+		}
+		if !skipped {
+			names = append(names, f.Name)
+		}
+	}
+	return names
+}
+
+// fieldTag returns the value of the tag key of f.
+func fieldTag(f FieldInfo, key string) (string, bool) {
+	if f.Tag == "" {
+		return "", false
+	}
+	tag, err := strconv.Unquote(f.Tag)
+	if err != nil {
+		return "", false
+	}
+	return reflect.StructTag(tag).Lookup(key)
+}
@@ -0,0 +1,39 @@
+package padding_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestMarshalOrderChanges(t *testing.T) {
+	fields := []padding.FieldInfo{
+		{Name: "OK", Type: "bool", Tag: "`json:\"ok\" yaml:\"ok\"`", Size: 1, Align: 1},
+		{Name: "ID", Type: "int64", Tag: "`json:\"id\"`", Size: 8, Align: 8},
+		{Name: "cache", Type: "bool", Size: 1, Align: 1},
+	}
+	s := padding.StructInfo{Name: "T", Fields: fields}
+	padding.AnalyzeStruct(&s)
+	if got, want := padding.MarshalTags(s), []string{"json", "yaml"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MarshalTags = %v, want %v", got, want)
+	}
+	if !padding.MarshalOrderChanges(s, padding.Optimal(s)) {
+		t.Error("moving ID before OK doesn't change the marshal order")
+	}
+
+	// Only the unexported field moves.
+	kept := padding.StructInfo{Name: "T", Fields: []padding.FieldInfo{fields[2], fields[0], fields[1]}}
+	if padding.MarshalOrderChanges(kept, s) {
+		t.Error("moving an unexported field changes the marshal order")
+	}
+
+	untagged := padding.StructInfo{Name: "U", Fields: []padding.FieldInfo{
+		{Name: "OK", Type: "bool", Size: 1, Align: 1},
+		{Name: "ID", Type: "int64", Size: 8, Align: 8},
+	}}
+	padding.AnalyzeStruct(&untagged)
+	if padding.MarshalOrderChanges(untagged, padding.Optimal(untagged)) {
+		t.Error("untagged struct changes the marshal order")
+	}
+}
@@ -0,0 +1,166 @@
+package padding
+
+import "slices"
+
+// maxMoveSearch is the number of fields up to which minimalMoveOrder
+// searches for an order moving few fields. Larger structs get the order of
+// optimalOrder.
+const maxMoveSearch = 64
+
+// minimalMoveOrder returns an order of slots of the size optimalOrder
+// reaches that moves as few fields as it can find from their current order,
+// so that fixing a struct keeps the grouping of its fields where it costs
+// nothing. Starting from the current order, it repeatedly takes the step,
+// moving a single field to another position or swapping two fields, that
+// shrinks the struct the most, moving the fewest fields among equals, until
+// the optimal size is reached. If no step shrinks the struct on the way, or
+// the result moves no fewer fields than optimalOrder, the latter wins.
+func minimalMoveOrder(slots []slot) []int {
+	sorted := optimalOrder(slots)
+	target := slotsSize(slots, sorted)
+	order := make([]int, len(slots))
+	for i := range order {
+		order[i] = i
+	}
+	size := slotsSize(slots, order)
+	if size == target {
+		return order
+	}
+	if len(slots) > maxMoveSearch {
+		return sorted
+	}
+
+	candidate := make([]int, len(order))
+	for size > target {
+		var best []int
+		bestSize, bestMoved := size, 0
+		try := func() {
+			s := slotsSize(slots, candidate)
+			if s > bestSize || s == bestSize && best == nil {
+				return
+			}
+			if moved := len(Moved(candidate)); best == nil || s < bestSize || moved < bestMoved {
+				best, bestSize, bestMoved = slices.Clone(candidate), s, moved
+			}
+		}
+		for i := range order {
+			for to := 0; to <= len(order); to++ {
+				// Moving a field next to its neighbour is the same as
+				// moving the neighbour.
+				if to != i && to != i+1 {
+					moveTo(candidate, order, i, to)
+					try()
+				}
+			}
+			for j := i + 2; j < len(order); j++ {
+				copy(candidate, order)
+				candidate[i], candidate[j] = candidate[j], candidate[i]
+				try()
+			}
+		}
+		if best == nil {
+			return sorted
+		}
+		order, size = best, bestSize
+	}
+	if len(Moved(order)) >= len(Moved(sorted)) {
+		return sorted
+	}
+	return order
+}
+
+// moveTo sets dst to order with the element at from moved before the one at
+// position to, or to the end if to is len(order), the others keeping their
+// relative order.
+func moveTo(dst, order []int, from, to int) {
+	dst = dst[:0]
+	for i, j := range order {
+		if i == to {
+			dst = append(dst, order[from])
+		}
+		if i != from {
+			dst = append(dst, j)
+		}
+	}
+	if to == len(order) {
+		dst = append(dst, order[from])
+	}
+}
+
+// slotsSize returns the size of the struct with the fields of slots in the
+// given order.
+func slotsSize(slots []slot, order []int) int64 {
+	var p packer
+	for _, i := range order {
+		p.add(slots[i].size, slots[i].align)
+	}
+	size, _ := p.size()
+	return size
+}
+
+// Moved returns the fields a permutation of a struct's fields, such as that
+// of OptimalPermutation, moves, in increasing order: those outside a longest
+// run of fields keeping their relative order. Reordering the declaration
+// only needs to move these.
+func Moved(order []int) []int {
+	// Longest increasing subsequence by patience sorting: tails[k] is the
+	// index in order of the smallest tail of a subsequence of length k+1.
+	var tails []int
+	prev := make([]int, len(order))
+	for i, v := range order {
+		k, _ := slices.BinarySearchFunc(tails, v, func(t, v int) int { return order[t] - v })
+		if k > 0 {
+			prev[i] = tails[k-1]
+		} else {
+			prev[i] = -1
+		}
+		if k == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[k] = i
+		}
+	}
+	kept := make([]bool, len(order))
+	if len(tails) > 0 {
+		for i := tails[len(tails)-1]; i >= 0; i = prev[i] {
+			kept[order[i]] = true
+		}
+	}
+	var moved []int
+	for field, k := range kept {
+		if !k {
+			moved = append(moved, field)
+		}
+	}
+	return moved
+}
+
+// FieldMove is a field Optimize moved: its name, and its index in the
+// struct's fields before and after.
+type FieldMove struct {
+	Name     string
+	From, To int
+}
+
+// Optimize reorders the fields of s in place into the order of Optimal and
+// lays them out again. It returns the fields that moved, those of Moved in
+// their source order, and whether the order changed at all; if not, s is
+// only laid out. Passing the reordered structs to Rewrite writes their new
+// declarations.
+func Optimize(s *StructInfo) ([]FieldMove, bool) {
+	AnalyzeStruct(s)
+	order := OptimalPermutation(*s)
+	if slices.IsSorted(order) {
+		return nil, false
+	}
+	fields := slices.Clone(s.Fields)
+	for i, j := range order {
+		s.Fields[i] = fields[j]
+	}
+	layoutFields(s)
+	var moves []FieldMove
+	for _, j := range Moved(order) {
+		moves = append(moves, FieldMove{fields[j].Name, j, slices.Index(order, j)})
+	}
+	return moves, true
+}
@@ -0,0 +1,133 @@
+package padding_test
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestOptimalPermutationFewMoves(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", `package p
+type T struct {
+	id    int32
+	ok    bool
+	port  int16
+	n     int32
+	total int64
+	done  bool
+	code  int32
+}
+`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	structs, err := padding.Analyze(fset, file, padding.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := structs[0]
+
+	// Swapping id and done is enough; sorting by alignment would move four
+	// fields.
+	order := padding.OptimalPermutation(s)
+	if want := []int{5, 1, 2, 3, 4, 0, 6}; !reflect.DeepEqual(order, want) {
+		t.Errorf("OptimalPermutation = %v, want %v", order, want)
+	}
+	if got, want := padding.Moved(order), []int{0, 5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Moved = %v, want %v", got, want)
+	}
+	if got := padding.Optimal(s).Size; got != 24 {
+		t.Errorf("optimal size = %d, want 24", got)
+	}
+}
+
+func TestOptimalPermutationLargeStable(t *testing.T) {
+	// Too many fields to search for few moves: they are sorted by
+	// alignment, those of one alignment keeping their order.
+	var s padding.StructInfo
+	for i := range 20 {
+		for _, typ := range []string{"bool", "string", "int64", "[3]int64"} {
+			s.Fields = append(s.Fields, padding.FieldInfo{Name: fmt.Sprintf("%s%d", typ, i), Type: typ})
+		}
+	}
+	order := padding.OptimalPermutation(s)
+	var words, bytes []int
+	for _, i := range order {
+		if s.Fields[i].Type == "bool" {
+			bytes = append(bytes, i)
+		} else {
+			words = append(words, i)
+		}
+	}
+	if !slices.IsSorted(words) || !slices.IsSorted(bytes) || !slices.Equal(order, append(words, bytes...)) {
+		t.Errorf("OptimalPermutation = %v, want the word-aligned fields, then the bools, each in declared order", order)
+	}
+}
+
+func TestMoved(t *testing.T) {
+	tests := []struct {
+		order []int
+		want  []int
+	}{
+		{nil, nil},
+		{[]int{0, 1, 2}, nil},
+		{[]int{2, 0, 1}, []int{2}},
+		{[]int{1, 2, 3, 0}, []int{0}},
+		{[]int{3, 2, 1, 0}, []int{1, 2, 3}},
+		{[]int{0, 4, 2, 3, 1}, []int{1, 4}},
+	}
+	for _, tt := range tests {
+		if got := padding.Moved(tt.order); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Moved(%v) = %v, want %v", tt.order, got, tt.want)
+		}
+	}
+}
+
+func TestOptimize(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", `package p
+type Loose struct {
+	a bool
+	b int64
+	c bool
+	d int32
+}
+type Tight struct {
+	n int64
+	ok bool
+}
+`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	structs, err := padding.Analyze(fset, file, padding.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Optimize reorders the fields in place, those of structs[0] too.
+	loose, source, want := structs[0], slices.Clone(structs[0].Fields), padding.Optimal(structs[0])
+	moves, changed := padding.Optimize(&loose)
+	if !changed || loose.Size != want.Size || !reflect.DeepEqual(loose.Fields, want.Fields) {
+		t.Errorf("Optimize = %v, size %d, want true and the layout of Optimal, size %d", changed, loose.Size, want.Size)
+	}
+	for _, m := range moves {
+		if source[m.From].Name != m.Name || loose.Fields[m.To].Name != m.Name {
+			t.Errorf("move %+v does not match the orders", m)
+		}
+	}
+	if len(moves) == 0 {
+		t.Error("no moves")
+	}
+
+	tight := structs[1]
+	if moves, changed := padding.Optimize(&tight); changed || moves != nil || !reflect.DeepEqual(tight, structs[1]) {
+		t.Errorf("Optimize of a tight struct = %v, %v", moves, changed)
+	}
+}
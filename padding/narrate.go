@@ -0,0 +1,49 @@
+package padding
+
+import "fmt"
+
+// Narrate walks through the layout of s in the order of its fields, one
+// line per step, as in
+//
+//	offset 0: a bool (size 1)
+//	offset 1–7: padding to align b int64
+//	offset 8: b int64 (size 8)
+//	offset 16: c bool (size 1)
+//	offset 17–23: padding; total rounded up to 24 for alignment 8
+//
+// The steps are those of the layout computation of Analyze, not offsets read
+// back from s.
+func Narrate(s StructInfo) []string {
+	var lines []string
+	var end int64
+	size, alignment := placeFields(s, func(i int, offset, padding int64) {
+		f := s.Fields[i]
+		if padding > 0 {
+			lines = append(lines, fmt.Sprintf("offset %s: padding to align %s", span(offset-padding, offset), fieldString(f)))
+		}
+		if IsCacheLinePad(f) {
+			lines = append(lines, fmt.Sprintf("offset %d: %s (size %d, cache-line pad)", offset, fieldString(f), f.Size))
+		} else {
+			lines = append(lines, fmt.Sprintf("offset %d: %s (size %d)", offset, fieldString(f), f.Size))
+		}
+		end = offset + f.Size
+	})
+	switch {
+	case size == end:
+		lines = append(lines, fmt.Sprintf("total %d, a multiple of alignment %d", size, alignment))
+	case s.padsZeroTail():
+		lines = append(lines, fmt.Sprintf("offset %s: padding keeping the address of the zero-size last field inside the struct;"+
+			" total rounded up to %d for alignment %d", span(end, size), size, alignment))
+	default:
+		lines = append(lines, fmt.Sprintf("offset %s: padding; total rounded up to %d for alignment %d", span(end, size), size, alignment))
+	}
+	return lines
+}
+
+// span formats the bytes from start up to end, exclusive.
+func span(start, end int64) string {
+	if end-start == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d–%d", start, end-1)
+}
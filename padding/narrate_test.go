@@ -0,0 +1,64 @@
+package padding_test
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestNarrateGolden(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", `package p
+type Conn struct {
+	open    bool
+	id      int64
+	retries int16
+	port    uint16
+	last    byte
+	name    string
+	closed  bool
+}
+`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	structs, err := padding.Analyze(fset, file, padding.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := padding.NewStructReport(structs[0])
+	r.CheckHoles(structs[0])
+	r.CheckLayout(structs[0])
+	var got bytes.Buffer
+	padding.FprintStruct(&got, r)
+
+	golden := filepath.Join("testdata", "narrate.golden")
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Errorf("got:\n%s\nwant:\n%s", got.Bytes(), want)
+	}
+}
+
+func TestNarrateZeroSizeLast(t *testing.T) {
+	s := padding.StructInfo{
+		Name: "T",
+		Fields: []padding.FieldInfo{
+			{Name: "n", Type: "int32", Size: 4, Align: 4},
+			{Name: "end", Type: "struct{}", Size: 0, Align: 1},
+		},
+	}
+	padding.AnalyzeStruct(&s)
+	got := padding.Narrate(s)
+	want := "offset 4–7: padding keeping the address of the zero-size last field inside the struct; total rounded up to 8 for alignment 4"
+	if len(got) != 3 || got[2] != want {
+		t.Errorf("Narrate = %q, want last line %q", got, want)
+	}
+}
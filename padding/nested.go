@@ -0,0 +1,58 @@
+package padding
+
+import (
+	"cmp"
+	"go/types"
+	"slices"
+)
+
+// NestedPadding is the padding inside a struct that comes from the layout of
+// a named struct type nested in it, as a field, an embedded field or the
+// elements of an array, however deeply.
+type NestedPadding struct {
+	Type  *types.TypeName // struct type whose declaration leaves the padding
+	Bytes int64           // padding bytes, counted once per nested value
+}
+
+// PaddingSources returns all the padding bytes of a value of type st laid
+// out by sizes, its own and those inside the struct values nested in it, and
+// the share of the named struct types the latter come from, largest first.
+// The padding of a nested struct comes from the innermost named struct type
+// declaring it, so that an anonymous struct counts with the type holding it,
+// and st itself is never a source.
+func PaddingSources(st *types.Struct, sizes types.Sizes) (total int64, nested []NestedPadding) {
+	bytes := make(map[*types.TypeName]int64)
+	addPadding(bytes, st, nil, 1, sizes)
+	for tn, n := range bytes {
+		total += n
+		if tn != nil && n > 0 {
+			nested = append(nested, NestedPadding{tn, n})
+		}
+	}
+	slices.SortFunc(nested, func(a, b NestedPadding) int {
+		return cmp.Or(cmp.Compare(b.Bytes, a.Bytes), cmp.Compare(a.Type.Name(), b.Type.Name()))
+	})
+	return total, nested
+}
+
+// addPadding adds to bytes the padding of count values of type t, counting
+// the padding of structs declared by no named type to owner.
+func addPadding(bytes map[*types.TypeName]int64, t types.Type, owner *types.TypeName, count int64, sizes types.Sizes) {
+	if named, ok := types.Unalias(t).(*types.Named); ok {
+		if _, ok := named.Underlying().(*types.Struct); ok {
+			owner = named.Origin().Obj()
+		}
+	}
+	switch t := t.Underlying().(type) {
+	case *types.Array:
+		// Elements follow each other without padding: a struct is padded
+		// to a multiple of its alignment.
+		addPadding(bytes, t.Elem(), owner, count*t.Len(), sizes)
+	case *types.Struct:
+		fields, layout := Layout(t, sizes)
+		bytes[owner] += count * layout.Padding
+		for _, f := range fields {
+			addPadding(bytes, f.Field.Type(), owner, count, sizes)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package padding_test
+
+import (
+	"go/types"
+	"maps"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestPaddingSources(t *testing.T) {
+	sizes := types.SizesFor("gc", "amd64")
+	for _, tt := range []struct {
+		name  string
+		src   string
+		total int64
+		want  map[string]int64
+	}{
+		{"own padding only", "type T struct { a bool; b int64 }", 7, map[string]int64{}},
+		{"embedded", "type I struct { a bool; b int64 }; type T struct { I; n int64 }", 7, map[string]int64{"I": 7}},
+		{"named field", "type I struct { a int32; b int64 }; type T struct { i I; c bool }", 11, map[string]int64{"I": 4}},
+		{"array", "type I struct { a int64; b bool }; type T struct { is [3]I }", 21, map[string]int64{"I": 21}},
+		{
+			"innermost named type",
+			"type J struct { a bool; b int32 }; type I struct { j J; n int64; c bool }; type T struct { i I }",
+			10, map[string]int64{"I": 7, "J": 3},
+		},
+		{"anonymous struct", "type T struct { in struct { a bool; b int64 } }", 7, map[string]int64{}},
+	} {
+		total, nested := padding.PaddingSources(checkStruct(t, tt.src), sizes)
+		got := make(map[string]int64)
+		for _, n := range nested {
+			got[n.Type.Name()] = n.Bytes
+		}
+		if total != tt.total || !maps.Equal(got, tt.want) {
+			t.Errorf("%s: PaddingSources = %d, %v; want %d, %v", tt.name, total, got, tt.total, tt.want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package padding
+
+import (
+	"fmt"
+	"go/ast"
+	"strings"
+)
+
+// PadComment is the line comment of the padding fields Pad writes.
+const PadComment = "// padding"
+
+// IsPaddingField reports whether f is an explicit padding field as Pad
+// writes them: a blank field of a byte array type, such as [3]byte, with
+// PadComment as its line comment. Blank fields without it, such as the
+// reserved bytes of an on-disk format, are left alone.
+func IsPaddingField(f FieldInfo) bool {
+	if f.Name != "_" || f.Comment == nil || strings.TrimSpace(f.Comment.Text()) != strings.TrimPrefix(PadComment, "// ") {
+		return false
+	}
+	_, elem, ok := arrayType(f.Type)
+	return ok && (elem == "byte" || elem == "uint8")
+}
+
+// Pad returns s with its fields in their current order and explicit padding
+// fields, blank [N]byte fields marked with PadComment, in place of the
+// padding of its layout: before each field the layout pads, and last if the
+// struct is padded at its end. The padding fields s already has are laid out
+// anew first, so that those still matching the layout are kept as they are,
+// and the others dropped: padding s again changes nothing. The size of s
+// and the offsets of its other fields are those it has without padding
+// fields. s itself is not modified.
+func Pad(s StructInfo) StructInfo {
+	// The padding fields of s, by the number of other fields before them.
+	old := make(map[int][]FieldInfo)
+	var fields []FieldInfo
+	for _, f := range s.Fields {
+		if IsPaddingField(f) {
+			old[len(fields)] = append(old[len(fields)], f)
+		} else {
+			fields = append(fields, f)
+		}
+	}
+	s.Fields = fields
+	layoutFields(&s)
+
+	pad := func(k int, n int64) FieldInfo {
+		if kept := old[k]; len(kept) == 1 && kept[0].Size == n {
+			return kept[0]
+		}
+		return FieldInfo{
+			Name:    "_",
+			Type:    fmt.Sprintf("[%d]byte", n),
+			Size:    n,
+			Align:   1,
+			Comment: &ast.CommentGroup{List: []*ast.Comment{{Text: PadComment}}},
+		}
+	}
+	padded := make([]FieldInfo, 0, len(fields))
+	var end int64
+	for k, f := range fields {
+		if f.Offset > end {
+			padded = append(padded, pad(k, f.Offset-end))
+		}
+		padded = append(padded, f)
+		end = f.Offset + f.Size
+	}
+	if s.Size > end {
+		padded = append(padded, pad(len(fields), s.Size-end))
+	}
+	s.Fields = padded
+	layoutFields(&s)
+	return s
+}
@@ -0,0 +1,121 @@
+package padding_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"slices"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// padSource returns src with the structs it declares padded by Pad.
+func padSource(t *testing.T, src string) string {
+	t.Helper()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	structs, err := padding.Analyze(fset, file, padding.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, s := range structs {
+		structs[i] = padding.Pad(s)
+		if padded := structs[i]; padded.Size != s.Size && !slices.ContainsFunc(s.Fields, padding.IsPaddingField) {
+			t.Errorf("%s: padded to %d bytes, want %d", s.Name, padded.Size, s.Size)
+		}
+	}
+	out, err := padding.Rewrite(fset, file, []byte(src), structs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestPad(t *testing.T) {
+	const src = `package p
+
+// Header mirrors a C struct.
+type Header struct {
+	Magic   uint8
+	Version uint32 // format version
+	_       [2]byte
+	// Length of the body.
+	Length uint64
+	Kind   byte
+}
+
+type Empty struct {
+	a int64
+	b struct{}
+}
+`
+	const want = `package p
+
+// Header mirrors a C struct.
+type Header struct {
+	Magic   uint8
+	_       [3]byte // padding
+	Version uint32  // format version
+	_       [2]byte
+	_       [6]byte // padding
+	// Length of the body.
+	Length uint64
+	Kind   byte
+	_      [7]byte // padding
+}
+
+type Empty struct {
+	a int64
+	b struct{}
+	_ [8]byte // padding
+}
+`
+	got := padSource(t, src)
+	if got != want {
+		t.Fatalf("padded:\n%s\nwant:\n%s", got, want)
+	}
+	if again := padSource(t, got); again != got {
+		t.Errorf("padding again changed the source:\n%s", again)
+	}
+
+	// Padding fields an edit left stale are replaced.
+	edited := padSource(t, `package p
+
+type T struct {
+	a bool
+	_ [7]byte // padding
+	b int32
+	_ [4]byte // padding
+	c int16
+	_ [2]byte // padding
+}
+`)
+	if want := "type T struct {\n\ta bool\n\t_ [3]byte // padding\n\tb int32\n\tc int16\n\t_ [2]byte // padding\n}\n"; edited != "package p\n\n"+want {
+		t.Errorf("padded after an edit:\n%s\nwant:\n%s", edited, want)
+	}
+}
+
+func TestIsPaddingField(t *testing.T) {
+	comment := func(text string) *ast.CommentGroup {
+		return &ast.CommentGroup{List: []*ast.Comment{{Text: text}}}
+	}
+	for _, tt := range []struct {
+		f    padding.FieldInfo
+		want bool
+	}{
+		{padding.FieldInfo{Name: "_", Type: "[3]byte", Comment: comment("// padding")}, true},
+		{padding.FieldInfo{Name: "_", Type: "[8]uint8", Comment: comment("//padding")}, true},
+		{padding.FieldInfo{Name: "_", Type: "[3]byte"}, false},
+		{padding.FieldInfo{Name: "_", Type: "[3]byte", Comment: comment("// reserved")}, false},
+		{padding.FieldInfo{Name: "_", Type: "[3]int8", Comment: comment("// padding")}, false},
+		{padding.FieldInfo{Name: "pad", Type: "[3]byte", Comment: comment("// padding")}, false},
+	} {
+		if got := padding.IsPaddingField(tt.f); got != tt.want {
+			t.Errorf("IsPaddingField(%s %s %v) = %v, want %v", tt.f.Name, tt.f.Type, tt.f.Comment, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,566 @@
+// Package padding analyzes the memory layout of Go struct types declared in
+// source files and computes field orders that minimize padding.
+package padding
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// FieldInfo represents information about a struct field
+type FieldInfo struct {
+	Name   string // field name, the type name for an embedded field
+	Type   string // type expression as written in the source
+	Tag    string // raw tag literal including its quotes, or empty
+	Size   int64  // size of the field in bytes
+	Align  int64  // required alignment of the field in bytes
+	Offset int64  // offset of the field from the start of the struct
+
+	// Embedded is set for a field declared with a type and no name, such
+	// as sync.Mutex; Rewrite writes it back so.
+	Embedded bool
+
+	// Doc and Comment are the doc and line comments of the declaration
+	// the field belongs to; Rewrite moves both with the field.
+	Doc     *ast.CommentGroup
+	Comment *ast.CommentGroup
+
+	// Directives lists the //padding: directives in the doc and line
+	// comments of the declaration, such as "cold" for //padding:cold.
+	Directives []string
+
+	// Kind is the kind of the underlying type of the field, such as
+	// uint32 or struct, once TypeSizes sized it with the type checker;
+	// empty for a field sized from its type expression alone. Estimated is
+	// set by callers for a field of those whose size is a guess.
+	Kind      string
+	Estimated bool
+
+	// ParamSized is set for a field of a generic struct whose size depends
+	// on the type parameters, such as one of type T or [4]T, known only
+	// once the struct is instantiated. Its Size and Align are then a guess.
+	// A type parameter whose constraint allows a single predeclared type,
+	// as ~int64 does, is sized as that type instead.
+	ParamSized bool
+
+	// Pos is the position of the field's name, or of the type of an
+	// embedded field. It is zero for the padding fields Pad adds.
+	Pos token.Position
+}
+
+// StructInfo represents information about a struct
+type StructInfo struct {
+	Name   string      // name of the declared type
+	Fields []FieldInfo // fields in layout order
+	Size   int64       // total size including trailing padding
+	Align  int64       // alignment of the struct, the largest field alignment
+
+	// Node is the declaration the struct was collected from. Rewrite
+	// rewrites the source of its field list.
+	Node *ast.StructType
+
+	// Pos is the position of the declaration: that of the name of the
+	// declared type, or of the struct keyword of an anonymous struct.
+	Pos token.Position
+
+	// Doc is the doc comment of the type declaration, which may hold a
+	// drift-guard Annotation.
+	Doc *ast.CommentGroup
+
+	// Variants lists the build constraints of the files declaring this
+	// layout when a package has several variants of the struct. Analyze
+	// leaves it empty.
+	Variants []string
+
+	// Anonymous is set for struct types declared without a name, as the
+	// type of a variable, field or composite literal. ReportOnly is set
+	// for those whose fields cannot be reordered without breaking code
+	// that uses them, such as positional literals or the identical types
+	// of other declarations; Rewrite leaves them alone.
+	Anonymous  bool
+	ReportOnly bool
+
+	// Cgo is set for the structs of files importing "C". The sizes of
+	// their fields of C types are guesses, so Rewrite leaves them alone,
+	// along with the rest of the file's cgo preamble.
+	Cgo bool
+
+	// FreeComments is set for a struct whose body holds a comment that
+	// belongs to no field, such as a section heading, above a field.
+	// Reordering would leave it above another field, so Rewrite leaves
+	// the struct alone.
+	FreeComments bool
+
+	// Compiler is the compiler whose layout rules Size and the offsets
+	// follow, CompilerGC if empty.
+	Compiler string
+
+	// Arch is the GOARCH whose word size and alignment the fields are
+	// sized for, amd64 if empty.
+	Arch string
+
+	// Directives lists the //padding: directives in Doc, such as
+	// "keep-first=2" for //padding:keep-first=2.
+	Directives []string
+
+	// KeepFirst is the number of leading fields the optimal orders keep in
+	// place, such as a version field that must come first. Analyze sets
+	// it from a //padding:keep-first=N directive.
+	KeepFirst int
+
+	// TooLarge is set, with the layout, for a struct of MaxSize bytes or
+	// more, which gc rejects as too large; its size and offsets are then
+	// meaningless, and Rewrite leaves it alone.
+	TooLarge bool
+
+	// ParamSized is set for a generic struct with fields that are
+	// ParamSized: its size and offsets, and so its padding, are unknown
+	// until it is instantiated.
+	ParamSized bool
+}
+
+// Compilers whose layout rules Analyze can follow.
+const (
+	CompilerGC    = "gc"
+	CompilerGccgo = "gccgo"
+)
+
+// Options configures Analyze. The zero value is ready to use.
+type Options struct {
+	// Cache memoizes the layout of type expressions between calls.
+	// Sharing one Cache between the files of a package avoids sizing the
+	// same types again. If nil, each call uses a fresh one.
+	Cache *Cache
+
+	// Compiler selects the layout rules, CompilerGC if empty. gccgo
+	// differs from gc in not padding a zero-size last field and, on some
+	// 32-bit platforms, in aligning 64-bit values to 8 bytes.
+	Compiler string
+
+	// Arch is the GOARCH, such as amd64, 386 or arm, whose word size and
+	// alignment of 64-bit values the field types are sized for, amd64 if
+	// empty. A Cache used for another architecture before is emptied.
+	Arch string
+}
+
+// Analyze returns the layout of every struct type declared in file, in source
+// order, including types declared inside function bodies and anonymous
+// struct types with at least two named fields: the types of variables,
+// fields, parameters and composite literals. Anonymous structs are named
+// by anonymousName, and only those declaring package-level variables, or
+// the fields of rewritable structs inline, may be rewritten; the others are
+// marked ReportOnly.
+func Analyze(fset *token.FileSet, file *ast.File, opts Options) ([]StructInfo, error) {
+	if file == nil {
+		return nil, errors.New("padding: nil file")
+	}
+	sizes, ok := sizesFor(opts.Compiler, opts.Arch)
+	if !ok {
+		return nil, fmt.Errorf("padding: unknown architecture %q", opts.Arch)
+	}
+	cache := opts.Cache
+	if cache == nil {
+		cache = NewCache()
+	}
+	cache.use(sizes)
+
+	var structs []StructInfo
+	var buf bytes.Buffer
+
+	// The doc comment of an unparenthesized declaration belongs to the
+	// GenDecl rather than to its only TypeSpec.
+	var declSpec ast.Spec
+	var declDoc *ast.CommentGroup
+
+	// err is the first invalid directive, which stops the walk.
+	var err error
+
+	cgo := false
+	for _, spec := range file.Imports {
+		cgo = cgo || spec.Path.Value == `"C"`
+	}
+
+	// The struct types naming a type, found before the walk reaches them.
+	named := make(map[*ast.StructType]*ast.TypeSpec)
+	// The names of the package-level variables declared with anonymous
+	// struct types.
+	packageVars := make(map[*ast.StructType][]string)
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.VAR {
+			for _, spec := range gen.Specs {
+				spec := spec.(*ast.ValueSpec)
+				if st, ok := spec.Type.(*ast.StructType); ok {
+					for _, name := range spec.Names {
+						packageVars[st] = append(packageVars[st], name.Name)
+					}
+				}
+			}
+		}
+	}
+	// The dotted paths of the anonymous structs in the field types of
+	// named types and package-level variables, such as Config.limits,
+	// set as the walk reaches the struct declaring the field.
+	paths := make(map[*ast.StructType]string)
+	// Those of them that are the field types themselves, not held through
+	// a pointer, slice or the like.
+	inline := make(map[*ast.StructType]bool)
+	// How often each struct type literal of file is spelled, by its text.
+	spelled := make(map[string]int)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if st, ok := n.(*ast.StructType); ok {
+			spelled[types.ExprString(st)]++
+		}
+		return true
+	})
+	// The type parameters in scope of the struct types of generic types
+	// and functions, and of the methods of generic types.
+	params := make(map[*ast.StructType]map[string]string)
+	inScope := func(n ast.Node, ps map[string]string) {
+		if len(ps) == 0 {
+			return
+		}
+		ast.Inspect(n, func(n ast.Node) bool {
+			if st, ok := n.(*ast.StructType); ok {
+				if params[st] == nil {
+					params[st] = make(map[string]string)
+				}
+				maps.Copy(params[st], ps)
+			}
+			return true
+		})
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		if err != nil {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.GenDecl:
+			if n.Tok == token.TYPE && !n.Lparen.IsValid() && len(n.Specs) == 1 {
+				declSpec, declDoc = n.Specs[0], n.Doc
+			}
+			return true
+		case *ast.TypeSpec:
+			if st, ok := n.Type.(*ast.StructType); ok {
+				named[st] = n
+				paths[st] = n.Name.Name
+			}
+			if n.TypeParams != nil {
+				inScope(n.Type, typeParams(n.TypeParams))
+			}
+			return true
+		case *ast.FuncDecl:
+			inScope(n, typeParams(n.Type.TypeParams))
+			inScope(n, receiverParams(n.Recv))
+			return true
+		}
+		structType, ok := n.(*ast.StructType)
+		if !ok {
+			return true
+		}
+		if vars := packageVars[structType]; vars != nil {
+			paths[structType] = vars[0]
+		}
+		if path, ok := paths[structType]; ok {
+			for _, field := range structType.Fields.List {
+				if len(field.Names) == 0 {
+					continue
+				}
+				if st, ok := field.Type.(*ast.StructType); ok {
+					inline[st] = true
+				}
+				ast.Inspect(field.Type, func(n ast.Node) bool {
+					if st, ok := n.(*ast.StructType); ok {
+						paths[st] = path + "." + field.Names[0].Name
+						return false
+					}
+					return true
+				})
+			}
+		}
+
+		numFields := 0
+		for _, field := range structType.Fields.List {
+			numFields += max(len(field.Names), 1)
+		}
+		structInfo := StructInfo{
+			Node:     structType,
+			Pos:      fset.Position(structType.Pos()),
+			Fields:   make([]FieldInfo, 0, numFields),
+			Cgo:      cgo,
+			Compiler: opts.Compiler,
+			Arch:     opts.Arch,
+
+			FreeComments: freeComments(fset.File(structType.Pos()), file, structType.Fields),
+		}
+		if typeSpec := named[structType]; typeSpec != nil {
+			structInfo.Name, structInfo.Doc = typeSpec.Name.Name, typeSpec.Doc
+			structInfo.Pos = fset.Position(typeSpec.Name.Pos())
+			if structInfo.Doc == nil && ast.Spec(typeSpec) == declSpec {
+				structInfo.Doc = declDoc
+			}
+			structInfo.Directives = directives(structInfo.Doc)
+			if structInfo.KeepFirst, err = keepFirst(structInfo.Directives); err != nil {
+				err = fmt.Errorf("%s: %s: %v", fset.Position(typeSpec.Pos()), structInfo.Name, err)
+				return false
+			}
+		} else {
+			// Empty structs, as in chan struct{}, and single fields
+			// leave nothing to reorder.
+			if numFields < 2 {
+				return true
+			}
+			structInfo.Name = anonymousName(fset, structType, packageVars[structType], paths[structType])
+			structInfo.Anonymous = true
+			// An inline field type may be reordered along with
+			// the struct declaring it, unless the file spells the
+			// same type out again, as a composite literal must.
+			structInfo.ReportOnly = packageVars[structType] == nil &&
+				(!inline[structType] || spelled[types.ExprString(structType)] > 1)
+		}
+
+		for _, field := range structType.Fields.List {
+			fieldType, size, align := cache.lookup(field.Type, &buf)
+			byParams := false
+			if ps := params[structType]; ps != nil {
+				if byParams = paramSized(field.Type, ps); !byParams {
+					if bound, ok := bindParams(fieldType, ps); ok {
+						size, align = sizes.getFieldSize(bound), sizes.getFieldAlign(bound)
+					}
+				}
+			}
+			tag := ""
+			if field.Tag != nil {
+				tag = field.Tag.Value
+			}
+			ds := directives(field.Doc, field.Comment)
+			names := field.Names
+			if len(names) == 0 {
+				// An embedded field is named after its type.
+				names = []*ast.Ident{embeddedName(field.Type)}
+			}
+			for _, name := range names {
+				pos := name.Pos()
+				if len(field.Names) == 0 {
+					pos = field.Type.Pos()
+				}
+				f := FieldInfo{
+					Name:       name.Name,
+					Embedded:   len(field.Names) == 0,
+					Type:       fieldType,
+					Tag:        tag,
+					Size:       size,
+					Align:      align,
+					Doc:        field.Doc,
+					Comment:    field.Comment,
+					Directives: ds,
+					ParamSized: byParams,
+					Pos:        fset.Position(pos),
+				}
+				// Pads are sized so that they separate what they
+				// were placed between.
+				if IsCacheLinePad(f) {
+					f.Size, f.Align = cacheLinePadSize(f.Type, opts.Arch), 1
+				}
+				structInfo.Fields = append(structInfo.Fields, f)
+				structInfo.ParamSized = structInfo.ParamSized || byParams
+			}
+		}
+
+		// A lock embedded first stays there, as code often expects.
+		if !structInfo.HasDirective("keep-first") && structInfo.LeadingLock() {
+			structInfo.KeepFirst = 1
+		}
+
+		AnalyzeStruct(&structInfo)
+		structs = append(structs, structInfo)
+
+		// Field types may hold further anonymous structs.
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return structs, nil
+}
+
+// anonymousName returns the name of an anonymous struct type: var x for the
+// type of package-level variables x, the dotted path of the field it is the
+// type of, such as Config.limits, or else its file and line, followed by
+// "(anonymous struct)". Names by context stay stable across edits elsewhere
+// in the file.
+func anonymousName(fset *token.FileSet, st *ast.StructType, vars []string, path string) string {
+	switch {
+	case vars != nil:
+		return "var " + strings.Join(vars, ", ") + " (anonymous struct)"
+	case path != "":
+		return path + " (anonymous struct)"
+	}
+	pos := fset.Position(st.Pos())
+	return fmt.Sprintf("%s:%d (anonymous struct)", filepath.Base(pos.Filename), pos.Line)
+}
+
+// AnalyzeStruct computes field offsets and the struct's size and alignment
+// for the fields in their current order. Fields whose size has not been
+// determined yet (zero Align) are sized from their Type first.
+func AnalyzeStruct(s *StructInfo) {
+	sizes := s.sizes()
+	for i := range s.Fields {
+		if s.Fields[i].Align == 0 {
+			s.Fields[i].Size = sizes.getFieldSize(s.Fields[i].Type)
+			s.Fields[i].Align = sizes.getFieldAlign(s.Fields[i].Type)
+		}
+	}
+	layoutFields(s)
+}
+
+// Optimal returns a copy of s with its fields reordered to minimize padding,
+// in the order of OptimalPermutation. s itself is not modified.
+func Optimal(s StructInfo) StructInfo {
+	fields := slices.Clone(s.Fields)
+	s.Fields = fields
+	AnalyzeStruct(&s)
+
+	s.Fields = make([]FieldInfo, len(fields))
+	for i, j := range OptimalPermutation(StructInfo{Fields: fields, KeepFirst: s.KeepFirst}) {
+		s.Fields[i] = fields[j]
+	}
+
+	layoutFields(&s)
+	return s
+}
+
+// OptimalPermutation returns the field order of s that minimizes padding:
+// the i-th field of the optimal layout is s.Fields[order[i]]. If fields are
+// marked //padding:hot, the order keeps them within the first HotLineSize
+// bytes where it can, even at the cost of padding. The first KeepFirst
+// fields and cache-line pads keep their places, the fields between pads
+// being ordered apart. Fields whose layout has not been determined yet are
+// sized from their Type. s itself is not modified.
+func OptimalPermutation(s StructInfo) (order []int) {
+	if order, ok := optimalAfterPrefix(s); ok {
+		return order
+	}
+	if order, ok := bySegments(s, OptimalPermutation); ok {
+		return order
+	}
+	slots := make([]slot, len(s.Fields))
+	hot := make([]bool, len(s.Fields))
+	anyHot := false
+	sizes := s.sizes()
+	for i, f := range s.Fields {
+		if f.Align == 0 {
+			f.Size, f.Align = sizes.getFieldSize(f.Type), sizes.getFieldAlign(f.Type)
+		}
+		slots[i] = slot{f.Size, f.Align}
+		hot[i] = f.HasDirective("hot")
+		anyHot = anyHot || hot[i]
+	}
+	if anyHot {
+		return hotPermutation(slots, hot)
+	}
+	return minimalMoveOrder(slots)
+}
+
+// PackedSize returns the sum of the field sizes of s, the size it would have
+// without any padding. Reordering the fields cannot get below it, and may not
+// reach it if the alignment of the fields forces padding.
+func (s StructInfo) PackedSize() int64 {
+	var size int64
+	for _, f := range s.Fields {
+		size += f.Size
+	}
+	return size
+}
+
+// AlignmentPadding returns the fields whose alignment causes the padding left
+// in the optimal layout of s, in that layout's order: the fields preceded by
+// padding and, if the struct is padded at the end, the first field with the
+// alignment of the struct.
+func AlignmentPadding(s StructInfo) []FieldInfo {
+	o := Optimal(s)
+	var fields []FieldInfo
+	var end int64
+	for _, f := range o.Fields {
+		if f.Offset > end {
+			fields = append(fields, f)
+		}
+		end = f.Offset + f.Size
+	}
+	if o.Size > end {
+		for _, f := range o.Fields {
+			if f.Align == o.Align {
+				if !slices.ContainsFunc(fields, func(g FieldInfo) bool { return g.Name == f.Name }) {
+					fields = append(fields, f)
+				}
+				break
+			}
+		}
+	}
+	return fields
+}
+
+// layoutFields assigns offsets to the fields in their current order and sets
+// the struct's size and alignment.
+func layoutFields(s *StructInfo) {
+	s.Size, s.Align = placeFields(*s, func(i int, offset, _ int64) {
+		s.Fields[i].Offset = offset
+	})
+	s.TooLarge = s.Size >= MaxSize
+}
+
+// placeFields lays out the fields of s in order, calling place with the
+// index and offset of each and the padding before it, and returns the size
+// and alignment of the struct. layoutFields and Narrate share it, so that
+// the narration can't drift from the layout.
+func placeFields(s StructInfo, place func(i int, offset, padding int64)) (size, alignment int64) {
+	p := packer{zeroTail: s.Compiler == CompilerGccgo}
+	for i, f := range s.Fields {
+		end := p.offset
+		offset := p.add(f.Size, f.Align)
+		place(i, offset, offset-end)
+	}
+	return p.size()
+}
+
+// padsZeroTail reports whether s ends in a zero-size field that is padded
+// so that its address stays inside s, as gc does and gccgo does not.
+func (s StructInfo) padsZeroTail() bool {
+	return len(s.Fields) > 0 && s.Fields[len(s.Fields)-1].Size == 0 && s.Compiler != CompilerGccgo
+}
+
+func align(offset, align int64) int64 {
+	return (offset + align - 1) &^ (align - 1)
+}
+
+// embeddedName returns the name of the field embedding typ: the name of the
+// type, without its package, type arguments or pointer.
+func embeddedName(typ ast.Expr) *ast.Ident {
+	for {
+		switch t := typ.(type) {
+		case *ast.StarExpr:
+			typ = t.X
+		case *ast.ParenExpr:
+			typ = t.X
+		case *ast.IndexExpr:
+			typ = t.X
+		case *ast.IndexListExpr:
+			typ = t.X
+		case *ast.SelectorExpr:
+			return t.Sel
+		case *ast.Ident:
+			return t
+		default:
+			return ast.NewIdent("_")
+		}
+	}
+}
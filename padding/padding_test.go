@@ -0,0 +1,268 @@
+package padding_test
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestAnalyzeStruct(t *testing.T) {
+	s := &padding.StructInfo{
+		Name: "TestStruct",
+		Fields: []padding.FieldInfo{
+			{Name: "Field1", Type: "int8"},
+			{Name: "Field2", Type: "int32"},
+			{Name: "Field3", Type: "int16"},
+			{Name: "Field4", Type: "int64"},
+		},
+	}
+
+	padding.AnalyzeStruct(s)
+
+	expectedSizes := []int64{1, 4, 2, 8}
+	expectedAligns := []int64{1, 4, 2, 8}
+	expectedOffsets := []int64{0, 4, 8, 16}
+
+	for i, field := range s.Fields {
+		if field.Size != expectedSizes[i] {
+			t.Errorf("Field %s: expected size %d, got %d", field.Name, expectedSizes[i], field.Size)
+		}
+		if field.Align != expectedAligns[i] {
+			t.Errorf("Field %s: expected align %d, got %d", field.Name, expectedAligns[i], field.Align)
+		}
+		if field.Offset != expectedOffsets[i] {
+			t.Errorf("Field %s: expected offset %d, got %d", field.Name, expectedOffsets[i], field.Offset)
+		}
+	}
+
+	if s.Size != 24 {
+		t.Errorf("Expected struct size 24, got %d", s.Size)
+	}
+	if s.Align != 8 {
+		t.Errorf("Expected struct align 8, got %d", s.Align)
+	}
+}
+
+func TestOptimizeStruct(t *testing.T) {
+	s := &padding.StructInfo{
+		Name: "TestStruct",
+		Fields: []padding.FieldInfo{
+			{Name: "Field1", Type: "int8"},
+			{Name: "Field2", Type: "int64"},
+			{Name: "Field3", Type: "int32"},
+			{Name: "Field4", Type: "int16"},
+		},
+	}
+
+	fmt.Println("Before optimization:")
+	for _, f := range s.Fields {
+		fmt.Printf("Field %s: type=%s, size=%d, align=%d, offset=%d\n", f.Name, f.Type, f.Size, f.Align, f.Offset)
+	}
+
+	input := *s
+	*s = padding.Optimal(input)
+
+	fmt.Println("\nAfter optimization:")
+	for _, f := range s.Fields {
+		fmt.Printf("Field %s: type=%s, size=%d, align=%d, offset=%d\n", f.Name, f.Type, f.Size, f.Align, f.Offset)
+	}
+
+	expectedOrder := []string{"Field2", "Field3", "Field4", "Field1"}
+	expectedOffsets := []int64{0, 8, 12, 14}
+	expectedSizes := []int64{8, 4, 2, 1}
+	expectedAligns := []int64{8, 4, 2, 1}
+
+	for i, field := range s.Fields {
+		if field.Name != expectedOrder[i] {
+			t.Errorf("Expected field %s at position %d, got %s", expectedOrder[i], i, field.Name)
+		}
+		if field.Offset != expectedOffsets[i] {
+			t.Errorf("Field %s: expected offset %d, got %d", field.Name, expectedOffsets[i], field.Offset)
+		}
+		if field.Size != expectedSizes[i] {
+			t.Errorf("Field %s: expected size %d, got %d", field.Name, expectedSizes[i], field.Size)
+		}
+		if field.Align != expectedAligns[i] {
+			t.Errorf("Field %s: expected align %d, got %d", field.Name, expectedAligns[i], field.Align)
+		}
+	}
+
+	if s.Size != 16 {
+		t.Errorf("Expected optimized struct size 16, got %d", s.Size)
+	}
+
+	if s.Align != 8 {
+		t.Errorf("Expected struct alignment 8, got %d", s.Align)
+	}
+
+	if input.Fields[0].Name != "Field1" || input.Fields[1].Name != "Field2" {
+		t.Errorf("Optimal reordered the fields of its argument: %+v", input.Fields)
+	}
+}
+
+func TestOptimalPermutation(t *testing.T) {
+	s := padding.StructInfo{
+		Name: "TestStruct",
+		Fields: []padding.FieldInfo{
+			{Name: "Field1", Type: "int8"},
+			{Name: "Field2", Type: "int64"},
+			{Name: "Field3", Type: "int32"},
+			{Name: "Field4", Type: "int16"},
+		},
+	}
+	if got, want := padding.OptimalPermutation(s), []int{1, 2, 3, 0}; !slices.Equal(got, want) {
+		t.Errorf("OptimalPermutation = %v, want %v", got, want)
+	}
+	if s.Fields[1].Align != 0 {
+		t.Errorf("OptimalPermutation sized the fields of its argument: %+v", s.Fields)
+	}
+}
+
+func TestFprintShowsWaste(t *testing.T) {
+	s := padding.StructInfo{
+		Name: "Event",
+		Fields: []padding.FieldInfo{
+			{Name: "Done", Type: "bool"},
+			{Name: "At", Type: "int64"},
+			{Name: "Kind", Type: "bool"},
+		},
+	}
+	padding.AnalyzeStruct(&s)
+
+	var b strings.Builder
+	padding.Fprint(&b, s)
+	want := `Struct: Event (size: 24 bytes, align: 8, optimal: 16 bytes, packed minimum: 10 bytes, wasted: 8 bytes, padding: 7 inter-field + 7 trailing)
+  Done bool (offset: 0, size: 1, align: 1, padding after: 7)
+  At int64 (offset: 8, size: 8, align: 8)
+  Kind bool (offset: 16, size: 1, align: 1, trailing padding: 7)
+
+`
+	if b.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", b.String(), want)
+	}
+	if s.Fields[0].Name != "Done" || s.Size != 24 {
+		t.Errorf("Fprint changed the struct: %+v", s)
+	}
+
+	b.Reset()
+	padding.Fprint(&b, padding.Optimal(s))
+	if header, _, _ := strings.Cut(b.String(), "\n"); header != "Struct: Event (size: 16 bytes, align: 8, packed minimum: 10 bytes, padding: 0 inter-field + 6 trailing)" {
+		t.Errorf("optimal header = %q", header)
+	}
+}
+
+func TestAlignmentPadding(t *testing.T) {
+	s := padding.StructInfo{
+		Name: "Entry",
+		Fields: []padding.FieldInfo{
+			{Name: "Key", Type: "int64"},
+			{Name: "Ok", Type: "bool"},
+		},
+	}
+	padding.AnalyzeStruct(&s)
+	if s.PackedSize() != 9 {
+		t.Errorf("PackedSize = %d, want 9", s.PackedSize())
+	}
+	var names []string
+	for _, f := range padding.AlignmentPadding(s) {
+		names = append(names, f.Name)
+	}
+	if !slices.Equal(names, []string{"Key"}) {
+		t.Errorf("AlignmentPadding = %v, want [Key]", names)
+	}
+
+	var b strings.Builder
+	padding.Fprint(&b, s)
+	want := `Struct: Entry (size: 16 bytes, align: 8, packed minimum: 9 bytes, padding: 0 inter-field + 7 trailing)
+  Key int64 (offset: 0, size: 8, align: 8)
+  Ok bool (offset: 8, size: 1, align: 1, trailing padding: 7)
+  Reordering won't help: the alignment of Key leaves 7 bytes of padding
+
+`
+	if b.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", b.String(), want)
+	}
+
+	packed := padding.StructInfo{Name: "Flags", Fields: []padding.FieldInfo{{Name: "A", Type: "bool"}, {Name: "B", Type: "bool"}}}
+	padding.AnalyzeStruct(&packed)
+	if fields := padding.AlignmentPadding(packed); len(fields) != 0 || packed.PackedSize() != packed.Size {
+		t.Errorf("Flags: AlignmentPadding = %v, packed size %d, size %d", fields, packed.PackedSize(), packed.Size)
+	}
+}
+
+func TestProcessFile(t *testing.T) {
+	src := `
+package test
+
+type TestStruct struct {
+	Field1 bool ` + "`json:\"field1\"`" + `
+	Field2 int32 ` + "`json:\"field2\"`" + `
+	Field3 int16 ` + "`json:\"field3\"`" + `
+	Field4 int64 ` + "`json:\"field4\"`" + `
+}
+`
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "test.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("Failed to parse test file: %v", err)
+	}
+
+	structs, err := padding.Analyze(fset, f, padding.Options{})
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+
+	if len(structs) != 1 {
+		t.Fatalf("Expected 1 struct, got %d", len(structs))
+	}
+
+	s := structs[0]
+	if s.Name != "TestStruct" {
+		t.Errorf("Expected struct name TestStruct, got %s", s.Name)
+	}
+
+	// The name of each field follows the tab starting its line.
+	pos := func(line int) token.Position {
+		return fset.Position(fset.File(f.Pos()).LineStart(line) + 1)
+	}
+	expectedFields := []padding.FieldInfo{
+		{Name: "Field1", Type: "bool", Tag: "`json:\"field1\"`", Size: 1, Align: 1, Offset: 0, Pos: pos(5)},
+		{Name: "Field2", Type: "int32", Tag: "`json:\"field2\"`", Size: 4, Align: 4, Offset: 4, Pos: pos(6)},
+		{Name: "Field3", Type: "int16", Tag: "`json:\"field3\"`", Size: 2, Align: 2, Offset: 8, Pos: pos(7)},
+		{Name: "Field4", Type: "int64", Tag: "`json:\"field4\"`", Size: 8, Align: 8, Offset: 16, Pos: pos(8)},
+	}
+
+	if !reflect.DeepEqual(s.Fields, expectedFields) {
+		t.Errorf("Fields do not match expected. Got %+v, want %+v", s.Fields, expectedFields)
+	}
+
+	if s.Size != 24 {
+		t.Errorf("Expected struct size 24, got %d", s.Size)
+	}
+
+	if s.Align != 8 {
+		t.Errorf("Expected struct align 8, got %d", s.Align)
+	}
+}
+
+func BenchmarkAnalyzeStruct(b *testing.B) {
+	types := []string{"bool", "int64", "string", "*T", "int16", "[]byte", "float32", "map[string]int"}
+	fields := make([]padding.FieldInfo, 64)
+	for i := range fields {
+		fields[i] = padding.FieldInfo{Name: fmt.Sprintf("F%d", i), Type: types[i%len(types)]}
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		s := padding.StructInfo{Name: "Bench", Fields: slices.Clone(fields)}
+		padding.AnalyzeStruct(&s)
+		padding.Optimal(s)
+	}
+}
@@ -0,0 +1,58 @@
+package padding
+
+import (
+	"go/types"
+	"slices"
+)
+
+// PointerMask returns, for each word of a value of type t laid out by sizes,
+// whether it holds a pointer the garbage collector considers: the words of
+// pointers, unsafe.Pointer, maps, channels and functions, the data words of
+// strings and slices, both words of interfaces, and those of the arrays and
+// structs holding them, however deeply nested. The words of a type parameter
+// are all assumed to hold pointers.
+func PointerMask(t types.Type, sizes types.Sizes) []bool {
+	word := sizes.Sizeof(types.Typ[types.Uintptr])
+	mask := make([]bool, (sizes.Sizeof(t)+word-1)/word)
+	markPointers(mask, t, 0, sizes, word)
+	return mask
+}
+
+// markPointers sets the words of mask holding the pointers of a value of
+// type t at offset.
+func markPointers(mask []bool, t types.Type, offset int64, sizes types.Sizes, word int64) {
+	if _, ok := types.Unalias(t).(*types.TypeParam); ok {
+		for w := offset / word; w < (offset+sizes.Sizeof(t)+word-1)/word; w++ {
+			mask[w] = true
+		}
+		return
+	}
+	switch t := t.Underlying().(type) {
+	case *types.Basic:
+		if t.Kind() == types.String || t.Kind() == types.UnsafePointer {
+			mask[offset/word] = true
+		}
+	case *types.Pointer, *types.Slice, *types.Map, *types.Chan, *types.Signature:
+		mask[offset/word] = true
+	case *types.Interface:
+		mask[offset/word], mask[offset/word+1] = true, true
+	case *types.Array:
+		// Elements holding pointers are word-aligned, so the mask of one
+		// is repeated.
+		elem := PointerMask(t.Elem(), sizes)
+		if !slices.Contains(elem, true) {
+			return
+		}
+		for i := range t.Len() {
+			copy(mask[offset/word+i*int64(len(elem)):], elem)
+		}
+	case *types.Struct:
+		fields := make([]*types.Var, t.NumFields())
+		for i := range fields {
+			fields[i] = t.Field(i)
+		}
+		for i, o := range sizes.Offsetsof(fields) {
+			markPointers(mask, fields[i].Type(), offset+o, sizes, word)
+		}
+	}
+}
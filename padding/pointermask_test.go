@@ -0,0 +1,54 @@
+package padding_test
+
+import (
+	"go/types"
+	"reflect"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestPointerMask(t *testing.T) {
+	sizes := types.SizesFor("gc", "amd64")
+	for _, tt := range []struct {
+		name string
+		src  string
+		want []bool
+	}{
+		{"scalars", "type T struct { a bool; b int64; c [2]float64 }", []bool{false, false, false, false}},
+		{"string and slice", "type T struct { s string; b []byte }", []bool{true, false, true, false, false}},
+		{"interface", "type T struct { n int32; e error }", []bool{false, true, true}},
+		{"pointer kinds", "type T struct { p *int; m map[int]int; c chan int; f func(); u uintptr }", []bool{true, true, true, true, false}},
+		{"array of pointers", "type T struct { n int64; ps [3]*int }", []bool{false, true, true, true}},
+		{"array of scalars", "type T struct { p *int; a [3]int64 }", []bool{true, false, false, false}},
+		{"empty array", "type T struct { p *int; a [0]*int; n int64 }", []bool{true, false}},
+		{
+			"nested structs",
+			"type T struct { n int64; in struct { a int32; inner struct { s string; k int64 } }; p *T }",
+			[]bool{false, false, true, false, false, true},
+		},
+		{
+			"array of structs",
+			"type T struct { slots [2]struct { k int64; v *int } }",
+			[]bool{false, true, false, true},
+		},
+		{"named types", "type P *int; type S struct { x P }; type T struct { n int64; s S }", []bool{false, true}},
+	} {
+		st := checkStruct(t, tt.src)
+		if got := padding.PointerMask(st, sizes); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: PointerMask = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCheckPointers(t *testing.T) {
+	var r padding.StructReport
+	r.CheckPointers([]bool{true, false, true, true, false, false}, 8, 48)
+	if r.PointerBytes != 24 || r.ScanLength != 32 || r.TypedSize != 48 {
+		t.Errorf("pointer bytes %d, scan length %d, typed size %d; want 24, 32, 48", r.PointerBytes, r.ScanLength, r.TypedSize)
+	}
+	r.CheckPointers([]bool{false, false}, 8, 16)
+	if r.PointerBytes != 0 || r.ScanLength != 0 || r.TypedSize != 0 {
+		t.Errorf("without pointers: %d, %d, %d; want zeros", r.PointerBytes, r.ScanLength, r.TypedSize)
+	}
+}
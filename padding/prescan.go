@@ -0,0 +1,32 @@
+package padding
+
+import (
+	"go/scanner"
+	"go/token"
+)
+
+// MayContainStruct reports whether src could declare a struct type, named or
+// anonymous, i.e. whether it contains a `struct` keyword. It works on the
+// token stream, so keywords inside comments and string literals are ignored.
+// The check errs on the side of parsing: any scanner error yields true.
+func MayContainStruct(src []byte) bool {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+
+	var s scanner.Scanner
+	failed := false
+	s.Init(file, src, func(token.Position, string) { failed = true }, 0)
+
+	for {
+		_, tok, _ := s.Scan()
+		if failed {
+			return true
+		}
+		switch tok {
+		case token.EOF:
+			return false
+		case token.STRUCT:
+			return true
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package padding_test
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestMayContainStruct(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want bool
+	}{
+		{"struct decl", "package p\ntype T struct{ A int }\n", true},
+		{"grouped decl", "package p\ntype (\n\tID int\n\tT struct{}\n)\n", true},
+		{"alias", "package p\ntype T = struct{ A int }\n", true},
+		{"no types", "package p\nfunc F() int { return 1 }\n", false},
+		{"type without struct", "package p\ntype Kind int\nconst A Kind = 1\n", false},
+		{"struct without type", "package p\nvar x struct{ A int }\n", true},
+		{"keywords in comment", "package p\n// type T struct{}\n/* type U struct{} */\nfunc F() {}\n", false},
+		{"keywords in string", "package p\nvar s = \"type T struct{}\" + `type U struct{}`\n", false},
+		{"scanner error", "package p\nvar s = \"unterminated\n", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := padding.MayContainStruct([]byte(tt.src)); got != tt.want {
+				t.Errorf("padding.MayContainStruct() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrescanFindingsUnchanged(t *testing.T) {
+	for i, src := range mixedCorpus(40) {
+		withPrescan := parseCorpusFile(t, src, true)
+		withoutPrescan := parseCorpusFile(t, src, false)
+		if !reflect.DeepEqual(withPrescan, withoutPrescan) {
+			t.Errorf("file %d: findings differ with pre-scan: %+v vs %+v", i, withPrescan, withoutPrescan)
+		}
+	}
+}
+
+func BenchmarkPrescan(b *testing.B) {
+	corpus := mixedCorpus(200)
+	for _, prescan := range []bool{false, true} {
+		b.Run(fmt.Sprintf("prescan=%v", prescan), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for _, src := range corpus {
+					parseCorpusFile(b, src, prescan)
+				}
+			}
+		})
+	}
+}
+
+func parseCorpusFile(tb testing.TB, src []byte, prescan bool) []padding.StructInfo {
+	if prescan && !padding.MayContainStruct(src) {
+		return nil
+	}
+	fset := token.NewFileSet()
+	node, err := parser.ParseFile(fset, "corpus.go", src, parser.ParseComments)
+	if err != nil {
+		tb.Fatalf("Failed to parse corpus file: %v", err)
+	}
+	structs, err := padding.Analyze(fset, node, padding.Options{})
+	if err != nil {
+		tb.Fatalf("Analyze failed: %v", err)
+	}
+	return structs
+}
+
+// mixedCorpus generates n Go files where roughly one in four declares structs;
+// the rest are function-only files and generated enum files.
+func mixedCorpus(n int) [][]byte {
+	corpus := make([][]byte, n)
+	for i := range corpus {
+		var b strings.Builder
+		fmt.Fprintf(&b, "// Package p is generated corpus file %d.\npackage p\n\nimport \"strings\"\n\n", i)
+		switch i % 4 {
+		case 0:
+			for j := 0; j < 10; j++ {
+				fmt.Fprintf(&b, "type S%d struct {\n\tA bool\n\tB int64 `json:\"b\"`\n\tC int16 // c\n}\n\n", j)
+			}
+		case 1:
+			b.WriteString("type Kind int\n\nconst (\n")
+			for j := 0; j < 50; j++ {
+				fmt.Fprintf(&b, "\tKind%d Kind = %d\n", j, j)
+			}
+			b.WriteString(")\n\nfunc (k Kind) String() string {\n\tswitch k {\n")
+			for j := 0; j < 50; j++ {
+				fmt.Fprintf(&b, "\tcase Kind%d:\n\t\treturn \"Kind%d\"\n", j, j)
+			}
+			b.WriteString("\t}\n\treturn \"\"\n}\n")
+		default:
+			for j := 0; j < 20; j++ {
+				fmt.Fprintf(&b, "// F%d mentions type T struct{} only in comments.\nfunc F%d(s string) string {\n\treturn strings.Repeat(s, %d) + \"struct\"\n}\n\n", j, j, j)
+			}
+		}
+		corpus[i] = []byte(b.String())
+	}
+	return corpus
+}
@@ -0,0 +1,44 @@
+package padding
+
+import "go/types"
+
+// PromotedField is a field reached through the embedded fields of a struct,
+// placed within the outermost struct.
+type PromotedField struct {
+	Path    string     // embedded field names and the field name, dotted
+	Field   *types.Var // the field
+	Offset  int64      // offset of the field from the start of the outer struct
+	Size    int64      // size of the field in bytes
+	Pointer bool       // embedded by pointer: its fields are not flattened
+}
+
+// PromotedFields returns the fields of the structs embedded by value in st,
+// however deeply, with their offsets within st, in layout order: each
+// embedded field is followed by the fields it promotes. Fields embedded by
+// pointer are listed but not looked into, as what they point to is
+// allocated apart.
+func PromotedFields(st *types.Struct, sizes types.Sizes) []PromotedField {
+	var promoted []PromotedField
+	addPromoted(&promoted, st, "", 0, sizes, false)
+	return promoted
+}
+
+// addPromoted adds the fields of st, placed at base, to promoted with the
+// given path prefix, but for the outermost struct only those it embeds.
+func addPromoted(promoted *[]PromotedField, st *types.Struct, prefix string, base int64, sizes types.Sizes, all bool) {
+	fields, _ := Layout(st, sizes)
+	for _, f := range fields {
+		if !all && !f.Field.Embedded() {
+			continue
+		}
+		path := prefix + f.Field.Name()
+		_, pointer := types.Unalias(f.Field.Type()).Underlying().(*types.Pointer)
+		*promoted = append(*promoted, PromotedField{path, f.Field, base + f.Offset, f.Size, f.Field.Embedded() && pointer})
+		if !f.Field.Embedded() || pointer {
+			continue
+		}
+		if inner, ok := f.Field.Type().Underlying().(*types.Struct); ok {
+			addPromoted(promoted, inner, path+".", base+f.Offset, sizes, true)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package padding_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestPromotedFields(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", `package p
+type Meta struct { Version uint16; ID uint64 }
+type Base struct { Name string; Meta }
+type Conn struct{ fd int }
+type Server struct {
+	open bool
+	Base
+	*Conn
+}
+`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := new(types.Config).Check("p", fset, []*ast.File{file}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := pkg.Scope().Lookup("Server").Type().Underlying().(*types.Struct)
+
+	type field struct {
+		path    string
+		offset  int64
+		pointer bool
+	}
+	var got []field
+	for _, f := range padding.PromotedFields(st, types.SizesFor("gc", "amd64")) {
+		got = append(got, field{f.Path, f.Offset, f.Pointer})
+	}
+	// Conn is embedded by pointer, so fd is not flattened.
+	want := []field{
+		{"Base", 8, false},
+		{"Base.Name", 8, false},
+		{"Base.Meta", 24, false},
+		{"Base.Meta.Version", 24, false},
+		{"Base.Meta.ID", 32, false},
+		{"Conn", 40, true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PromotedFields = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,856 @@
+package padding
+
+import (
+	"cmp"
+	"slices"
+)
+
+// SchemaVersion is the version of the Report schema, MAJOR.MINOR. Additive
+// changes, such as a new optional field, bump the minor version; removing or
+// renaming a field, changing its type or making it required bumps the major
+// version.
+const SchemaVersion = "1.53"
+
+// Report is the result of a run in the form every output format is rendered
+// from. Its JSON encoding is described by Schema.
+type Report struct {
+	SchemaVersion string         `json:"schema_version"`
+	Structs       []StructReport `json:"structs"`
+
+	// PaddingByType is the padding of all the structs attributed to the
+	// field types causing it, largest first, if requested. Since 1.33.
+	PaddingByType []TypePadding `json:"padding_by_type,omitempty"`
+
+	// StaticFootprint lists the package-level variables holding padded
+	// structs, by decreasing padding, if requested. Since 1.34.
+	StaticFootprint []GlobalReport `json:"static_footprint,omitempty"`
+
+	// EstimatedTypes lists the field types whose sizes were guessed, by
+	// decreasing number of fields. Since 1.35.
+	EstimatedTypes []EstimatedType `json:"estimated_types,omitempty"`
+
+	// Compiler is the compiler whose layout rules the structs follow, gc
+	// or gccgo, if known. Since 1.38.
+	Compiler string `json:"compiler,omitempty"`
+
+	// Arch is the GOARCH whose word size and alignment the structs are
+	// laid out for, if known. Since 1.48.
+	Arch string `json:"arch,omitempty"`
+
+	// OS is the GOOS whose files were analyzed, as selected by their
+	// build constraints, if known; empty when the files of every
+	// platform were. Since 1.52.
+	OS string `json:"os,omitempty"`
+
+	// DuplicateLayouts lists the groups of structs with the same layout,
+	// largest first, if requested. Since 1.41.
+	DuplicateLayouts []DuplicateLayout `json:"duplicate_layouts,omitempty"`
+
+	// Files lists the files declaring the structs, and Summary totals the
+	// structs, as -format=json writes them. Since 1.46.
+	Files   []FileReport   `json:"files,omitempty"`
+	Summary *ReportSummary `json:"summary,omitempty"`
+}
+
+// FileReport is a file declaring structs of a report.
+type FileReport struct {
+	File        string   `json:"file"`
+	Structs     []string `json:"structs"`      // names of its structs, in report order
+	WastedBytes int64    `json:"wasted_bytes"` // bytes its structs waste, summed
+}
+
+// ReportSummary totals the structs of a report.
+type ReportSummary struct {
+	Structs     int   `json:"structs"`      // structs analyzed
+	Files       int   `json:"files"`        // files declaring them
+	WastedBytes int64 `json:"wasted_bytes"` // bytes they waste, summed
+
+	// WastefulStructs is the number of structs reordering would shrink.
+	// Since 1.49.
+	WastefulStructs int `json:"wasteful_structs"`
+
+	// ScannedFiles is the number of files the run analyzed, those
+	// declaring no struct included, if known. Since 1.49.
+	ScannedFiles int `json:"scanned_files,omitempty"`
+
+	// TopOffenders lists the structs wasting the most bytes, as
+	// TopOffenders returns them. Since 1.49.
+	TopOffenders []OffenderReport `json:"top_offenders,omitempty"`
+}
+
+// OffenderReport is a struct of ReportSummary.TopOffenders.
+type OffenderReport struct {
+	Position    string `json:"position,omitempty"` // file and line of its declaration
+	Name        string `json:"name"`
+	Size        int64  `json:"size"`
+	OptimalSize int64  `json:"optimal_size"`
+	WastedBytes int64  `json:"wasted_bytes"`
+}
+
+// StructReport is the layout of a single struct type.
+type StructReport struct {
+	File        string        `json:"file,omitempty"`    // file declaring the struct
+	Package     string        `json:"package,omitempty"` // name of the package
+	Module      string        `json:"module,omitempty"`  // module and version of a dependency, since 1.21
+	Name        string        `json:"name"`
+	Size        int64         `json:"size"`
+	Align       int64         `json:"align"`
+	OptimalSize int64         `json:"optimal_size"` // size with the optimal field order
+	WastedBytes int64         `json:"wasted_bytes"` // Size - OptimalSize
+	Variants    []string      `json:"variants,omitempty"`
+	Fields      []FieldReport `json:"fields"`
+
+	// LiveObjects is the number of live instances found in a heap
+	// profile, and LiveWastedBytes the bytes they waste, LiveObjects *
+	// WastedBytes. Both are zero without a profile or if the struct is not
+	// found in it. Since 1.1.
+	LiveObjects     int64 `json:"live_objects,omitempty"`
+	LiveWastedBytes int64 `json:"live_wasted_bytes,omitempty"`
+
+	// AllocSites estimates how often the struct is allocated from the
+	// number of allocation sites in the source, counting a make call with
+	// a constant length as that many sites. Zero unless requested. Since
+	// 1.2.
+	AllocSites int64 `json:"alloc_sites,omitempty"`
+
+	// Instances is the expected number of instances of the struct, if
+	// given, and RecoverableBytes the memory reordering its fields saves
+	// across them, Instances * WastedBytes. Since 1.3.
+	Instances        int64 `json:"instances,omitempty"`
+	RecoverableBytes int64 `json:"recoverable_bytes,omitempty"`
+
+	// PackedSize is the sum of the field sizes, which no field order gets
+	// below. If the struct is already optimal but larger than that,
+	// AlignmentPadding names the fields whose alignment causes the padding
+	// left, so that only changing their types can save more. Since 1.4.
+	PackedSize       int64    `json:"packed_size,omitempty"`
+	AlignmentPadding []string `json:"alignment_padding,omitempty"`
+
+	// CacheLineCrossings lists the fields spanning a boundary between
+	// cache lines of CacheLineSize bytes, and CacheLineOrder a field order
+	// of the same size in which none does, if there is one. They are only
+	// set when cache lines are checked. Since 1.5.
+	CacheLineSize      int64               `json:"cache_line_size,omitempty"`
+	CacheLineCrossings []CacheLineCrossing `json:"cache_line_crossings,omitempty"`
+	CacheLineOrder     []string            `json:"cache_line_order,omitempty"`
+
+	// CacheLines and OptimalCacheLines are the cache lines of
+	// CacheLineSize bytes the struct occupies at Size and at OptimalSize,
+	// starting on a line, when cache lines are checked. Since 1.50.
+	CacheLines        int64 `json:"cache_lines,omitempty"`
+	OptimalCacheLines int64 `json:"optimal_cache_lines,omitempty"`
+
+	// FalseSharing lists the pairs of concurrently written fields sharing a
+	// cache line of CacheLineSize bytes, if checked. Since 1.6.
+	FalseSharing []FalseSharing `json:"false_sharing,omitempty"`
+
+	// AllocSize and OptimalAllocSize are the bytes the runtime allocates
+	// for Size and OptimalSize, rounded up to its size classes, so that a
+	// smaller layout may not save heap memory at all. Since 1.7.
+	AllocSize        int64 `json:"alloc_size,omitempty"`
+	OptimalAllocSize int64 `json:"optimal_alloc_size,omitempty"`
+
+	// PointerPrefix is the length of the prefix of the struct holding its
+	// pointers, which the garbage collector scans. GCOrder is the field
+	// order of the optimal size with the shortest prefix, and
+	// GCOrderPointerPrefix that prefix. They are only set when requested
+	// and the struct holds pointers. Since 1.8.
+	PointerPrefix        int64    `json:"pointer_prefix,omitempty"`
+	GCOrder              []string `json:"gc_order,omitempty"`
+	GCOrderPointerPrefix int64    `json:"gc_order_pointer_prefix,omitempty"`
+
+	// PointerBytes is the number of bytes of the struct in pointer words
+	// the garbage collector considers, and ScanLength the end of the last
+	// of them, out of TypedSize, the size of the struct as laid out by the
+	// type checker. They are zero if the struct holds no pointers or they
+	// were not requested. Since 1.9.
+	PointerBytes int64 `json:"pointer_bytes,omitempty"`
+	ScanLength   int64 `json:"scan_length,omitempty"`
+	TypedSize    int64 `json:"typed_size,omitempty"`
+
+	// Split proposes moving cold fields into a side struct, if requested
+	// and the struct is larger than the threshold. Since 1.10.
+	Split *SplitReport `json:"split,omitempty"`
+
+	// HotFields names the fields marked //padding:hot, in the order of the
+	// optimal layout, and HotOverflow the bytes by which they end past the
+	// first HotLineSize bytes of it, zero if they fit. Since 1.11.
+	HotFields   []string `json:"hot_fields,omitempty"`
+	HotOverflow int64    `json:"hot_overflow,omitempty"`
+
+	// BoolPacks lists the runs of bool fields that packing into bit flags
+	// would save memory on, if suggestions were requested. Since 1.12.
+	BoolPacks []BoolPackReport `json:"bool_packs,omitempty"`
+
+	// Narrowings lists the counter-like fields whose integer type is wider
+	// than those of the other counters, if suggestions were requested.
+	// They are heuristic. Since 1.13.
+	Narrowings []NarrowingReport `json:"narrowings,omitempty"`
+
+	// Indirection is the field that takes most of the struct and whose
+	// move behind a pointer brings the rest within a cache line, if
+	// suggestions were requested. Since 1.28.
+	Indirection *IndirectionReport `json:"indirection,omitempty"`
+
+	// PaddingBytes is all the padding of the struct as laid out by the
+	// type checker, including that inside the struct values nested in it,
+	// and NestedPadding the share of it each named struct type nested in
+	// it leaves, largest first. They are only set when requested and some
+	// of the padding is nested. Since 1.14.
+	PaddingBytes  int64                 `json:"padding_bytes,omitempty"`
+	NestedPadding []NestedPaddingReport `json:"nested_padding,omitempty"`
+
+	// Holes lists the padding of the struct in its current order, each
+	// with an explanation of its cause, if requested. Since 1.15.
+	Holes []HoleReport `json:"holes,omitempty"`
+
+	// NestedWaste is the sum of the NestedWaste of the fields: the bytes
+	// wasted inside the elements of array fields, which reordering the
+	// fields of the element type recovers rather than reordering those of
+	// this struct. Since 1.16.
+	NestedWaste int64 `json:"nested_waste,omitempty"`
+
+	// MovedFields names the fields the optimal order moves, in source
+	// order, if it is smaller; the others keep their relative order.
+	// Since 1.17.
+	MovedFields []string `json:"moved_fields,omitempty"`
+
+	// Layout narrates the layout of the struct step by step, one line per
+	// field or run of padding, and OptimalLayout that of the optimal order
+	// if it is smaller, if requested. Since 1.18.
+	Layout        []string `json:"layout,omitempty"`
+	OptimalLayout []string `json:"optimal_layout,omitempty"`
+
+	// FreeTail is the trailing padding of the struct, where fields can be
+	// added without growing it, and FreeTailRoom the size of the largest
+	// field of 1, 2, 4 or 8 bytes that fits there. OptimalFreeTail is the
+	// trailing padding of the optimal order, if that is smaller. They are
+	// only set when requested. Since 1.19.
+	FreeTail        int64 `json:"free_tail,omitempty"`
+	FreeTailRoom    int64 `json:"free_tail_room,omitempty"`
+	OptimalFreeTail int64 `json:"optimal_free_tail,omitempty"`
+
+	// VisibilitySize is the smallest size of the struct with its exported
+	// fields before its unexported ones, and VisibilityCost the bytes this
+	// costs over OptimalSize, if that order was requested. Since 1.20.
+	VisibilitySize int64 `json:"visibility_size,omitempty"`
+	VisibilityCost int64 `json:"visibility_cost,omitempty"`
+
+	// ExternalWaste is the sum of the ExternalWaste of the fields, if
+	// requested. It is informational: unlike WastedBytes and NestedWaste,
+	// no change to this package recovers it. Since 1.22.
+	ExternalWaste int64 `json:"external_waste,omitempty"`
+
+	// PromotedFields lists the fields reached through embedded structs,
+	// however deeply, with their offsets within this struct, as laid out
+	// by the type checker, if requested. Since 1.23.
+	PromotedFields []PromotedFieldReport `json:"promoted_fields,omitempty"`
+
+	// NoAllocationImpact is set for a struct that wastes bytes but whose
+	// optimal size takes the same allocation size class, and that is not
+	// stored in the arrays or slices of its package, whose contiguous
+	// storage would shrink, if checked. Since 1.24.
+	NoAllocationImpact bool `json:"no_allocation_impact,omitempty"`
+
+	// MarshalOrderChanges is set if the struct carries json, xml, yaml or
+	// csv tags and its fix changes the order encoders marshal its fields
+	// in, which breaks golden files of their output. Since 1.25.
+	MarshalOrderChanges bool `json:"marshal_order_changes,omitempty"`
+
+	// InterFieldPadding is the padding between fields, which reordering
+	// them removes, and TrailingPadding that after the last field, which
+	// rounds the size up to a multiple of the alignment and goes away only
+	// if the fields come to fill a smaller multiple. WastedBytes is what
+	// reordering saves of both. Since 1.26.
+	InterFieldPadding int64 `json:"inter_field_padding,omitempty"`
+	TrailingPadding   int64 `json:"trailing_padding,omitempty"`
+
+	// RedundantPads lists the cache-line pads whose neighbours are on
+	// different cache lines without them, in the current layout or, if
+	// only there, in the fixed one. Since 1.29.
+	RedundantPads []RedundantPadReport `json:"redundant_pads,omitempty"`
+
+	// EditHint is the cheapest manual edit reaching the optimal size, if
+	// the struct is not optimal and hints were requested. Since 1.30.
+	EditHint *EditHintReport `json:"edit_hint,omitempty"`
+
+	// Instantiations lists the instantiations of a generic struct used in
+	// its module with their sizes in CommonOrder, the field order suiting
+	// them all best, which wastes up to CommonOrderWaste bytes in one of
+	// them. Since 1.31.
+	Instantiations   []InstantiationReport `json:"instantiations,omitempty"`
+	CommonOrder      []string              `json:"common_order,omitempty"`
+	CommonOrderWaste int64                 `json:"common_order_waste,omitempty"`
+
+	// NearMiss is the bytes the struct must shave to fit one cache line of
+	// CacheLineSize bytes fewer, if they were few enough to report, and
+	// NearMissReordered whether the optimal order does. LineAlignedSize
+	// is set if the size is a multiple of the cache line. Since 1.32.
+	NearMiss          int64 `json:"near_miss,omitempty"`
+	NearMissReordered bool  `json:"near_miss_reordered,omitempty"`
+	LineAlignedSize   bool  `json:"line_aligned_size,omitempty"`
+
+	// PaddingByType is the padding of the struct attributed to the field
+	// types causing it, as by the function of that name, if requested.
+	// Since 1.33.
+	PaddingByType map[string]int64 `json:"padding_by_type,omitempty"`
+
+	// Estimated is set if the package of the struct failed to type-check,
+	// so its layout comes from the source alone and the results of the
+	// type-checked options are missing. Since 1.36.
+	Estimated bool `json:"estimated,omitempty"`
+
+	// Cgo is set if the struct has fields of C types, from cgo, whose
+	// sizes are guesses, so its layout is approximate. Since 1.37.
+	Cgo bool `json:"cgo,omitempty"`
+
+	// KeepFirst is the number of leading fields the optimal order keeps
+	// in place, and KeepFirstCost the bytes this costs over the optimal
+	// size of the struct free to move them. Since 1.39.
+	KeepFirst     int   `json:"keep_first,omitempty"`
+	KeepFirstCost int64 `json:"keep_first_cost,omitempty"`
+
+	// Skipped is why fix leaves the struct alone when the run excludes
+	// it, such as "contains sync.Mutex". Since 1.40.
+	Skipped string `json:"skipped,omitempty"`
+
+	// Line is the line of File the struct type begins on. Since 1.41.
+	Line int `json:"line,omitempty"`
+
+	// Position is File and Line together, as in "p.go:12", if both are
+	// known. Since 1.46.
+	Position string `json:"position,omitempty"`
+
+	// Column is the column of Line the name of the struct type, or the
+	// struct keyword of an anonymous struct, begins at. Since 1.47.
+	Column int `json:"column,omitempty"`
+
+	// TooLarge is set for a struct of MaxSize bytes or more, which gc
+	// rejects as too large. Its sizes and offsets are left out, along
+	// with everything computed from them. Since 1.42.
+	TooLarge bool `json:"too_large,omitempty"`
+
+	// ParamSized is set for a generic struct with fields whose size
+	// depends on its type parameters. Its size and offsets, known only
+	// once instantiated, are left out, along with everything computed from
+	// them; the other fields keep their size and alignment. Since 1.51.
+	ParamSized bool `json:"param_sized,omitempty"`
+
+	// SkipReasons are the rules that make fix leave the struct alone, when
+	// they are requested, as with -explain-skip. Since 1.43.
+	SkipReasons []SkipReason `json:"skip_reasons,omitempty"`
+}
+
+// SkipReason is a rule that makes fix leave a struct alone.
+type SkipReason struct {
+	// Code identifies the rule, such as "cgo_file" or "unkeyed_literal".
+	Code string `json:"code"`
+	// Reason gives the details, such as the positions of the literals.
+	Reason string `json:"reason"`
+}
+
+// PromotedFieldReport is a field reached through embedded fields.
+type PromotedFieldReport struct {
+	Path    string `json:"path"` // embedded field names and the field name, dotted
+	Type    string `json:"type"`
+	Offset  int64  `json:"offset"` // within the outermost struct
+	Size    int64  `json:"size"`
+	Pointer bool   `json:"pointer,omitempty"` // embedded by pointer, and not flattened
+}
+
+// HoleReport is a run of padding bytes and why it is there.
+type HoleReport struct {
+	Offset      int64  `json:"offset"`
+	Size        int64  `json:"size"`
+	After       string `json:"after"`            // field the padding follows
+	Before      string `json:"before,omitempty"` // field it precedes, empty at the end
+	Explanation string `json:"explanation"`
+}
+
+// NestedPaddingReport is the padding a nested struct type brings into a
+// struct.
+type NestedPaddingReport struct {
+	Type     string `json:"type"`     // the nested struct type, package-qualified if need be
+	Position string `json:"position"` // file and line of its declaration
+	Bytes    int64  `json:"bytes"`
+}
+
+// NarrowingReport is a suggestion to narrow the integer type of a field.
+type NarrowingReport struct {
+	Field     string `json:"field"`
+	Type      string `json:"type"`      // declared type of the field
+	Suggested string `json:"suggested"` // type of its widest sibling counter
+	Saved     int64  `json:"saved"`     // bytes the optimal layout shrinks by
+}
+
+// BoolPackReport is a suggestion to pack bool fields into one flags field.
+type BoolPackReport struct {
+	Fields []string `json:"fields"` // the bool fields, in source order
+	Type   string   `json:"type"`   // type of the flags field
+	Saved  int64    `json:"saved"`  // bytes the optimal layout shrinks by
+}
+
+// IndirectionReport is a suggestion to move a large field behind a pointer.
+type IndirectionReport struct {
+	Field    string `json:"field"`
+	Type     string `json:"type"`
+	Pointer  string `json:"pointer"`   // type replacing it
+	Size     int64  `json:"size"`      // size of the field
+	NewSize  int64  `json:"new_size"`  // optimal size of the struct with the pointer
+	LineSize int64  `json:"line_size"` // cache line the struct then fits in
+}
+
+// EditHintReport is the cheapest manual edit reaching the optimal size of a
+// struct.
+type EditHintReport struct {
+	Kind  string `json:"kind"`            // "move", "swap" or "reorder"
+	Field string `json:"field,omitempty"` // field to move or swap, as "name type"
+	After string `json:"after,omitempty"` // field to move it after, empty to move it first
+	With  string `json:"with,omitempty"`  // field to swap it with
+	Moves int    `json:"moves,omitempty"` // fields the optimal layout moves, for "reorder"
+	Saved int64  `json:"saved"`
+}
+
+// InstantiationReport is an instantiation of a generic struct.
+type InstantiationReport struct {
+	Type        string `json:"type"`         // the instantiated type, such as Entry[int]
+	Size        int64  `json:"size"`         // its size in the common order
+	OptimalSize int64  `json:"optimal_size"` // its size in its own optimal order
+}
+
+// RedundantPadReport is a cache-line pad that separates nothing.
+type RedundantPadReport struct {
+	Type      string `json:"type"`
+	Offset    int64  `json:"offset"`              // in the layout it is redundant in
+	After     string `json:"after"`               // field before it
+	Before    string `json:"before"`              // field after it
+	LineSize  int64  `json:"line_size"`           // cache line size checked
+	Reordered bool   `json:"reordered,omitempty"` // redundant only once fixed
+}
+
+// SplitReport is a proposed hot/cold split of a struct.
+type SplitReport struct {
+	Threshold int64    `json:"threshold"`        // size the hot struct should fit in
+	Fields    []string `json:"fields"`           // fields moving to the side struct
+	Marked    bool     `json:"marked,omitempty"` // chosen by //padding:cold
+	HotSize   int64    `json:"hot_size"`         // optimal size of the hot struct, pointer included
+	ColdSize  int64    `json:"cold_size"`        // optimal size of the side struct
+}
+
+// FalseSharing is a pair of fields written concurrently, such as atomic
+// counters or mutexes, that share a cache line, so that writes to one
+// invalidate the other in the caches of other CPUs.
+type FalseSharing struct {
+	First        string `json:"first"`
+	FirstOffset  int64  `json:"first_offset"`
+	Second       string `json:"second"`
+	SecondOffset int64  `json:"second_offset"`
+	Line         int64  `json:"line"` // offset of the first line they share
+}
+
+// CacheLineCrossing is a field spanning cache line boundaries.
+type CacheLineCrossing struct {
+	Field      string  `json:"field"`
+	Offset     int64   `json:"offset"`
+	Size       int64   `json:"size"`
+	Boundaries []int64 `json:"boundaries"` // offsets of the boundaries inside the field
+}
+
+// FieldReport is the layout of a single field.
+type FieldReport struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Offset int64  `json:"offset"`
+	Size   int64  `json:"size"`
+	Align  int64  `json:"align"`
+
+	// ElementWaste is the bytes wasted by each element of an array or
+	// slice field whose elements are structs of the same package, and
+	// NestedWaste the bytes wasted by all the elements of an array. Since
+	// 1.16.
+	ElementWaste int64 `json:"element_waste,omitempty"`
+	NestedWaste  int64 `json:"nested_waste,omitempty"`
+
+	// ExternalWaste is the bytes wasted inside a field whose type is a
+	// struct of another package, or an array of them, which only that
+	// package can recover, if requested. Since 1.22.
+	ExternalWaste int64 `json:"external_waste,omitempty"`
+
+	// Stride is the distance between the elements of an array or slice
+	// field whose elements are structs of the same package, StrideTrailing
+	// the trailing padding each of them repeats, and OptimalStride the
+	// stride once the element type is fixed, if that is smaller. Since
+	// 1.27.
+	Stride         int64 `json:"stride,omitempty"`
+	StrideTrailing int64 `json:"stride_trailing,omitempty"`
+	OptimalStride  int64 `json:"optimal_stride,omitempty"`
+
+	// CacheLinePad is set for a blank field of type cpu.CacheLinePad or a
+	// 64- or 128-byte array of bytes, which reordering keeps in place.
+	// Since 1.29.
+	CacheLinePad bool `json:"cache_line_pad,omitempty"`
+
+	// Kind is the kind of the underlying type of the field, such as
+	// uint32 or struct, if the type checker sized it, and Estimated is
+	// set if it could not and the size is a guess. Since 1.44.
+	Kind      string `json:"kind,omitempty"`
+	Estimated bool   `json:"estimated,omitempty"`
+
+	// Embedded is set for a field declared with a type and no name, such
+	// as sync.Mutex, whose Name is that of its type. Since 1.45.
+	Embedded bool `json:"embedded,omitempty"`
+
+	// PaddingAfter is the padding between the field and the next one, or
+	// the end of the struct for the last field. Since 1.46.
+	PaddingAfter int64 `json:"padding_after,omitempty"`
+
+	// CacheLine is the index, from 0, of the cache line of the struct's
+	// CacheLineSize bytes the field starts in, when cache lines are
+	// checked. Since 1.50.
+	CacheLine *int64 `json:"cache_line,omitempty"`
+
+	// ParamSized is set for a field whose size depends on the type
+	// parameters of its generic struct; its Offset, Size and Align are
+	// then left out. Since 1.51.
+	ParamSized bool `json:"param_sized,omitempty"`
+
+	// Line and Column are the position in the struct's File of the
+	// field's name, or of the type of an embedded field. Since 1.53.
+	Line   int `json:"line,omitempty"`
+	Column int `json:"column,omitempty"`
+}
+
+// Summarize returns the files declaring structs, in the order of their first
+// struct, and the totals of structs.
+func Summarize(structs []StructReport) ([]FileReport, ReportSummary) {
+	files := []FileReport{}
+	index := make(map[string]int)
+	summary := ReportSummary{Structs: len(structs)}
+	for _, s := range structs {
+		i, ok := index[s.File]
+		if !ok {
+			i = len(files)
+			index[s.File] = i
+			files = append(files, FileReport{File: s.File, Structs: []string{}})
+		}
+		files[i].Structs = append(files[i].Structs, s.Name)
+		files[i].WastedBytes += s.WastedBytes
+		summary.WastedBytes += s.WastedBytes
+		if s.WastedBytes > 0 {
+			summary.WastefulStructs++
+		}
+	}
+	summary.Files = len(files)
+	return files, summary
+}
+
+// TopOffenders returns the n structs wasting the most bytes, or all that
+// waste any if n is not positive, from the most wasteful; structs wasting
+// as much keep their order.
+func TopOffenders(structs []StructReport, n int) []OffenderReport {
+	var offenders []OffenderReport
+	for _, s := range structs {
+		if s.WastedBytes > 0 {
+			offenders = append(offenders, OffenderReport{cmp.Or(s.Position, s.File), s.Name, s.Size, s.OptimalSize, s.WastedBytes})
+		}
+	}
+	slices.SortStableFunc(offenders, func(a, b OffenderReport) int { return cmp.Compare(b.WastedBytes, a.WastedBytes) })
+	if n > 0 && len(offenders) > n {
+		offenders = offenders[:n]
+	}
+	return offenders
+}
+
+// NewReport returns a Report holding structs.
+func NewReport(structs ...StructReport) Report {
+	if structs == nil {
+		structs = []StructReport{}
+	}
+	return Report{SchemaVersion: SchemaVersion, Structs: structs}
+}
+
+// CheckCacheLines sets the cache line fields of r, the report of s, for cache
+// lines of the given size.
+func (r *StructReport) CheckCacheLines(s StructInfo, line int64) {
+	r.CacheLineSize = line
+	r.CacheLineCrossings, r.CacheLineOrder = nil, nil
+	r.CacheLines, r.OptimalCacheLines = CacheLines(r.Size, line), CacheLines(r.OptimalSize, line)
+	for i := range r.Fields {
+		index := r.Fields[i].Offset / line
+		r.Fields[i].CacheLine = &index
+	}
+	for _, c := range CacheLineCrossings(s, line) {
+		r.CacheLineCrossings = append(r.CacheLineCrossings, CacheLineCrossing{
+			Field:      c.Field.Name,
+			Offset:     c.Field.Offset,
+			Size:       c.Field.Size,
+			Boundaries: c.Boundaries,
+		})
+	}
+	if len(r.CacheLineCrossings) == 0 {
+		return
+	}
+	if order, ok := CrossingFreeOrder(s, line); ok {
+		for _, i := range order {
+			r.CacheLineOrder = append(r.CacheLineOrder, s.Fields[i].Name)
+		}
+	}
+}
+
+// CheckPaddingByType attributes the padding of s, the struct of r, to the
+// types of its fields.
+func (r *StructReport) CheckPaddingByType(s StructInfo) {
+	r.PaddingByType = nil
+	if byType := PaddingByType(s); len(byType) > 0 {
+		r.PaddingByType = byType
+	}
+}
+
+// CheckLineFit sets the near miss of r, if its size exceeds a multiple of
+// line by at most threshold bytes, or marks it as line aligned if it is a
+// multiple of line.
+func (r *StructReport) CheckLineFit(line, threshold int64) {
+	r.NearMiss, r.NearMissReordered, r.LineAlignedSize = 0, false, false
+	over, aligned := LineFit(r.Size, line)
+	switch {
+	case aligned:
+		r.LineAlignedSize = true
+	case over > 0 && over <= threshold:
+		r.NearMiss = over
+		r.NearMissReordered = r.OptimalSize <= r.Size-over
+	default:
+		return
+	}
+	r.CacheLineSize = line
+}
+
+// CheckGCOrder sets the pointer prefix of r, the report of s, and the field
+// order of GCOrder.
+func (r *StructReport) CheckGCOrder(s StructInfo) {
+	r.PointerPrefix, r.GCOrder, r.GCOrderPointerPrefix = PointerPrefix(s), nil, 0
+	if r.PointerPrefix == 0 {
+		return
+	}
+	o := GCOrder(s)
+	for _, f := range o.Fields {
+		r.GCOrder = append(r.GCOrder, f.Name)
+	}
+	r.GCOrderPointerPrefix = PointerPrefix(o)
+}
+
+// CheckPointers sets the pointer bytes and scan length of r from the
+// PointerMask of its struct, with words of the given size, and the size of
+// the struct the mask was computed for.
+func (r *StructReport) CheckPointers(mask []bool, word, size int64) {
+	r.PointerBytes, r.ScanLength, r.TypedSize = 0, 0, 0
+	for i, ptr := range mask {
+		if ptr {
+			r.PointerBytes += word
+			r.ScanLength = int64(i+1) * word
+		}
+	}
+	if r.PointerBytes > 0 {
+		r.TypedSize = size
+	}
+}
+
+// CheckSplit sets the split of r, the report of s, proposed by SuggestSplit
+// for the given threshold.
+func (r *StructReport) CheckSplit(s StructInfo, threshold int64) {
+	r.Split = nil
+	split, ok := SuggestSplit(s, threshold)
+	if !ok {
+		return
+	}
+	r.Split = &SplitReport{Threshold: threshold, Marked: split.Marked, HotSize: split.HotSize, ColdSize: split.ColdSize}
+	for _, f := range split.Cold {
+		r.Split.Fields = append(r.Split.Fields, f.Name)
+	}
+}
+
+// CheckSuggestions sets the advisory suggestions of r, the report of s.
+func (r *StructReport) CheckSuggestions(s StructInfo) {
+	r.BoolPacks = nil
+	for _, p := range BoolPacks(s) {
+		r.BoolPacks = append(r.BoolPacks, BoolPackReport(p))
+	}
+	r.Narrowings = nil
+	for _, n := range Narrowings(s) {
+		r.Narrowings = append(r.Narrowings, NarrowingReport(n))
+	}
+}
+
+// CheckIndirection sets the field of r, the report of s, to move behind a
+// pointer, if one takes more than fraction of s and the rest then fits in a
+// cache line of line bytes.
+func (r *StructReport) CheckIndirection(s StructInfo, fraction float64, line int64) {
+	r.Indirection = nil
+	if in, ok := SuggestIndirection(s, fraction, line); ok {
+		r.Indirection = &IndirectionReport{in.Field, in.Type, in.Pointer, in.Size, in.NewSize, line}
+	}
+}
+
+// CheckHint sets the cheapest manual edit reaching the optimal size of s,
+// the struct of r, if it is not optimal.
+func (r *StructReport) CheckHint(s StructInfo) {
+	r.EditHint = nil
+	if h, ok := Hint(s); ok {
+		hr := EditHintReport(h)
+		r.EditHint = &hr
+	}
+}
+
+// CheckCacheLinePads sets the cache-line pads of r, the report of s, that
+// are redundant for cache lines of line bytes, in s or in fixed, the layout
+// fix gives it.
+func (r *StructReport) CheckCacheLinePads(s, fixed StructInfo, line int64) {
+	r.RedundantPads = nil
+	current := RedundantPads(s, line)
+	for _, p := range current {
+		r.RedundantPads = append(r.RedundantPads, RedundantPadReport{p.Type, p.Offset, p.After, p.Before, line, false})
+	}
+	for _, p := range RedundantPads(fixed, line) {
+		if !slices.ContainsFunc(current, func(c RedundantPad) bool { return c.Field == p.Field }) {
+			r.RedundantPads = append(r.RedundantPads, RedundantPadReport{p.Type, p.Offset, p.After, p.Before, line, true})
+		}
+	}
+}
+
+// CheckHoles sets the holes of r, the report of s, with their explanations.
+func (r *StructReport) CheckHoles(s StructInfo) {
+	r.Holes = nil
+	for _, h := range Holes(s) {
+		hr := HoleReport{Offset: h.Offset, Size: h.Size, After: s.Fields[h.After].Name, Explanation: h.Explain(s)}
+		if h.Before >= 0 {
+			hr.Before = s.Fields[h.Before].Name
+		}
+		r.Holes = append(r.Holes, hr)
+	}
+}
+
+// CheckLayout sets the narrations of the layout of r, the report of s, and
+// of its optimal layout if that is smaller.
+func (r *StructReport) CheckLayout(s StructInfo) {
+	r.Layout, r.OptimalLayout = Narrate(s), nil
+	if r.WastedBytes > 0 {
+		r.OptimalLayout = Narrate(Optimal(s))
+	}
+}
+
+// CheckFreeTail sets the trailing padding of r, the report of s, in its
+// current order and, if that is smaller, in the optimal one.
+func (r *StructReport) CheckFreeTail(s StructInfo) {
+	r.FreeTail, r.FreeTailRoom = FreeTail(s)
+	r.OptimalFreeTail = 0
+	if r.WastedBytes > 0 {
+		r.OptimalFreeTail, _ = FreeTail(Optimal(s))
+	}
+}
+
+// CheckVisibility sets the size of s, the struct of r, with its exported
+// fields first, and what that costs over its optimal size.
+func (r *StructReport) CheckVisibility(s StructInfo) {
+	r.VisibilitySize = VisibilityOrder(s).Size
+	r.VisibilityCost = r.VisibilitySize - r.OptimalSize
+}
+
+// CheckAllocationImpact sets whether reordering the fields of r, the report
+// of a struct, leaves the heap memory it takes unchanged, given whether the
+// struct is the element type of arrays or slices.
+func (r *StructReport) CheckAllocationImpact(element bool) {
+	r.NoAllocationImpact = r.WastedBytes > 0 && r.AllocSize == r.OptimalAllocSize && !element
+}
+
+// CheckFalseSharing sets the false sharing of r for cache lines of the given
+// size, given its fields written concurrently in offset order. Their offsets
+// are passed in rather than taken from r, whose fields may have been sized
+// without type information.
+func (r *StructReport) CheckFalseSharing(fields []FieldReport, line int64) {
+	r.CacheLineSize = line
+	r.FalseSharing = nil
+	for i, a := range fields {
+		for _, b := range fields[i+1:] {
+			// The last line of a and the first line of b, which follows it.
+			last, first := (a.Offset+max(a.Size, 1)-1)/line, b.Offset/line
+			if last >= first {
+				r.FalseSharing = append(r.FalseSharing, FalseSharing{a.Name, a.Offset, b.Name, b.Offset, first * line})
+			}
+		}
+	}
+}
+
+// NewStructReport returns the report of s, with the fields in their current
+// order. File and Package are left for the caller to fill in. The report
+// of a struct too large only names its fields and their types, and that of
+// a generic struct sized by its type parameters adds the sizes of the
+// fields that are known.
+func NewStructReport(s StructInfo) StructReport {
+	if s.TooLarge {
+		r := StructReport{Name: s.Name, Variants: s.Variants, TooLarge: true, Fields: make([]FieldReport, len(s.Fields))}
+		for i, f := range s.Fields {
+			r.Fields[i] = FieldReport{Name: f.Name, Type: f.Type, Line: f.Pos.Line, Column: f.Pos.Column}
+		}
+		return r
+	}
+	if s.ParamSized {
+		r := StructReport{Name: s.Name, Variants: s.Variants, ParamSized: true, Fields: make([]FieldReport, len(s.Fields))}
+		for i, f := range s.Fields {
+			r.Fields[i] = FieldReport{Name: f.Name, Type: f.Type, Embedded: f.Embedded, ParamSized: f.ParamSized, Line: f.Pos.Line, Column: f.Pos.Column}
+			if !f.ParamSized {
+				r.Fields[i].Size, r.Fields[i].Align = f.Size, f.Align
+			}
+		}
+		return r
+	}
+	optimal := Optimal(s).Size
+	r := StructReport{
+		Name:        s.Name,
+		Size:        s.Size,
+		Align:       s.Align,
+		OptimalSize: optimal,
+		WastedBytes: s.Size - optimal,
+		PackedSize:  s.PackedSize(),
+		Variants:    s.Variants,
+		Fields:      make([]FieldReport, len(s.Fields)),
+
+		AllocSize:        AllocSize(s.Size),
+		OptimalAllocSize: AllocSize(optimal),
+	}
+	r.HotFields, r.HotOverflow = HotFields(s)
+	r.InterFieldPadding, r.TrailingPadding = SplitPadding(s)
+	if r.WastedBytes > 0 {
+		for _, i := range Moved(OptimalPermutation(s)) {
+			r.MovedFields = append(r.MovedFields, s.Fields[i].Name)
+		}
+	}
+	if r.WastedBytes == 0 && r.Size > r.PackedSize {
+		for _, f := range AlignmentPadding(s) {
+			r.AlignmentPadding = append(r.AlignmentPadding, f.Name)
+		}
+	}
+	for i, f := range s.Fields {
+		r.Fields[i] = FieldReport{
+			Name:         f.Name,
+			Type:         f.Type,
+			Offset:       f.Offset,
+			Size:         f.Size,
+			Align:        f.Align,
+			CacheLinePad: IsCacheLinePad(f),
+			Kind:         f.Kind,
+			Estimated:    f.Estimated,
+			Embedded:     f.Embedded,
+			Line:         f.Pos.Line,
+			Column:       f.Pos.Column,
+		}
+		end := s.Size
+		if i+1 < len(s.Fields) {
+			end = s.Fields[i+1].Offset
+		}
+		r.Fields[i].PaddingAfter = end - f.Offset - f.Size
+		r.Cgo = r.Cgo || CgoType(f.Type)
+	}
+	if r.KeepFirst = s.kept(); r.KeepFirst > 0 {
+		r.KeepFirstCost = KeepFirstCost(s)
+	}
+	return r
+}
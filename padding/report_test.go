@@ -0,0 +1,273 @@
+package padding_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestNewStructReport(t *testing.T) {
+	s := padding.StructInfo{
+		Name: "T",
+		Fields: []padding.FieldInfo{
+			{Name: "a", Type: "bool"},
+			{Name: "b", Type: "int64"},
+			{Name: "c", Type: "bool"},
+		},
+		Variants: []string{"linux"},
+	}
+	padding.AnalyzeStruct(&s)
+
+	want := padding.StructReport{
+		Name:        "T",
+		Size:        24,
+		Align:       8,
+		OptimalSize: 16,
+		WastedBytes: 8,
+		PackedSize:  10,
+		Variants:    []string{"linux"},
+		MovedFields: []string{"b"},
+
+		InterFieldPadding: 7,
+		TrailingPadding:   7,
+
+		AllocSize:        24,
+		OptimalAllocSize: 16,
+		Fields: []padding.FieldReport{
+			{Name: "a", Type: "bool", Offset: 0, Size: 1, Align: 1, PaddingAfter: 7},
+			{Name: "b", Type: "int64", Offset: 8, Size: 8, Align: 8},
+			{Name: "c", Type: "bool", Offset: 16, Size: 1, Align: 1, PaddingAfter: 7},
+		},
+	}
+	if got := padding.NewStructReport(s); !reflect.DeepEqual(got, want) {
+		t.Errorf("NewStructReport = %+v, want %+v", got, want)
+	}
+
+	data, err := json.Marshal(padding.NewReport())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"schema_version":"` + padding.SchemaVersion + `","structs":[]}`; string(data) != want {
+		t.Errorf("empty report = %s, want %s", data, want)
+	}
+}
+
+func TestFprintStructAt(t *testing.T) {
+	const src = `package p
+
+type T struct {
+	a    bool
+	b, c int64
+	*Node
+}
+
+type Node struct{ next *Node }
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "dir/p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	structs, err := padding.Analyze(fset, file, padding.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	for _, f := range structs[0].Fields {
+		lines = append(lines, fmt.Sprintf("%s %d:%d", f.Name, f.Pos.Line, f.Pos.Column))
+	}
+	if want := []string{"a 4:2", "b 5:2", "c 5:5", "Node 6:2"}; !slices.Equal(lines, want) {
+		t.Errorf("field positions %q, want %q", lines, want)
+	}
+
+	r := padding.NewStructReport(structs[0])
+	r.File, r.Line, r.Column = "dir/p.go", structs[0].Pos.Line, structs[0].Pos.Column
+	tests := []struct {
+		name   string
+		print  func(*bytes.Buffer)
+		header string
+		field  string
+	}{
+		{"FprintStruct", func(b *bytes.Buffer) { padding.FprintStruct(b, r) }, "Struct: T (size: 32 bytes", "  a bool (offset: 0"},
+		{"FprintStructAt", func(b *bytes.Buffer) { padding.FprintStructAt(b, r, false) }, "dir/p.go:3:6: struct T (size: 32 bytes", "  a bool (offset: 0"},
+		{"FprintStructAt fields", func(b *bytes.Buffer) { padding.FprintStructAt(b, r, true) }, "dir/p.go:3:6: struct T (size: 32 bytes", "  dir/p.go:5:5: c int64 (offset: 16"},
+	}
+	for _, tt := range tests {
+		var b bytes.Buffer
+		tt.print(&b)
+		out := b.String()
+		if !strings.HasPrefix(out, tt.header) || !strings.Contains(out, "\n"+tt.field) {
+			t.Errorf("%s printed:\n%s\nwant the header %q and a field line %q", tt.name, out, tt.header, tt.field)
+		}
+	}
+
+	// Without its file, the struct is printed as FprintStruct does.
+	var at, plain bytes.Buffer
+	r.File = ""
+	padding.FprintStructAt(&at, r, true)
+	padding.FprintStruct(&plain, r)
+	if at.String() != plain.String() {
+		t.Errorf("FprintStructAt without a file:\n%s\nwant:\n%s", at.String(), plain.String())
+	}
+}
+
+// schemaGolden is the published schema. It changes only together with
+// SchemaVersion; regenerate it with padding-size -schema.
+var schemaGolden = filepath.Join("testdata", "report.schema.json")
+
+func TestSchema(t *testing.T) {
+	golden, err := os.ReadFile(schemaGolden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := padding.Schema()
+	if bytes.Equal(got, golden) {
+		return
+	}
+
+	var old, cur map[string]any
+	if err := json.Unmarshal(golden, &old); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(got, &cur); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkSchemaVersion(old, cur); err != nil {
+		t.Fatal(err)
+	}
+	t.Fatalf("schema differs from %s; regenerate it with padding-size -schema:\n%s", schemaGolden, got)
+}
+
+func TestCheckSchemaVersion(t *testing.T) {
+	base := map[string]any{
+		"version": "1.2",
+		"type":    "object",
+		"properties": map[string]any{
+			"name": map[string]any{"type": "string"},
+			"size": map[string]any{"type": "integer"},
+		},
+		"required": []any{"name"},
+	}
+	change := func(version string, edit func(properties map[string]any, schema map[string]any)) map[string]any {
+		var schema map[string]any
+		data, _ := json.Marshal(base)
+		json.Unmarshal(data, &schema)
+		schema["version"] = version
+		edit(schema["properties"].(map[string]any), schema)
+		return schema
+	}
+	added := func(p, _ map[string]any) { p["align"] = map[string]any{"type": "integer"} }
+	removed := func(p, _ map[string]any) { delete(p, "size") }
+	retyped := func(p, _ map[string]any) { p["size"] = map[string]any{"type": "string"} }
+	required := func(_, s map[string]any) { s["required"] = []any{"name", "size"} }
+
+	tests := []struct {
+		name   string
+		schema map[string]any
+		ok     bool
+	}{
+		{"additive, minor bump", change("1.3", added), true},
+		{"additive, no bump", change("1.2", added), false},
+		{"additive, major bump", change("2.0", added), false},
+		{"removed, major bump", change("2.0", removed), true},
+		{"removed, minor bump", change("1.3", removed), false},
+		{"retyped, minor bump", change("1.3", retyped), false},
+		{"retyped, major bump", change("2.0", retyped), true},
+		{"made required, minor bump", change("1.3", required), false},
+		{"made required, major bump", change("2.0", required), true},
+	}
+	for _, tt := range tests {
+		err := checkSchemaVersion(base, tt.schema)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: checkSchemaVersion = %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+// checkSchemaVersion checks that the version of the schema cur follows the
+// bump rules relative to old: a breaking change requires the next major
+// version, any other change the next minor version.
+func checkSchemaVersion(old, cur map[string]any) error {
+	oldMajor, oldMinor, err := parseSchemaVersion(old["version"])
+	if err != nil {
+		return err
+	}
+	major, minor, err := parseSchemaVersion(cur["version"])
+	if err != nil {
+		return err
+	}
+
+	breaking := breakingChanges("", old, cur)
+	switch {
+	case len(breaking) > 0 && (major != oldMajor+1 || minor != 0):
+		return fmt.Errorf("breaking schema changes require SchemaVersion %d.0, have %d.%d:\n%s",
+			oldMajor+1, major, minor, strings.Join(breaking, "\n"))
+	case len(breaking) == 0 && (major != oldMajor || minor != oldMinor+1):
+		return fmt.Errorf("additive schema changes require SchemaVersion %d.%d, have %d.%d",
+			oldMajor, oldMinor+1, major, minor)
+	}
+	return nil
+}
+
+func parseSchemaVersion(v any) (major, minor int, err error) {
+	s, _ := v.(string)
+	majorText, minorText, ok := strings.Cut(s, ".")
+	major, err1 := strconv.Atoi(majorText)
+	minor, err2 := strconv.Atoi(minorText)
+	if !ok || err1 != nil || err2 != nil {
+		return 0, 0, fmt.Errorf("invalid schema version %v", v)
+	}
+	return major, minor, nil
+}
+
+// breakingChanges describes the changes from the schema old to cur that can
+// invalidate documents or break their consumers: removed properties, changed
+// types and existing properties becoming required.
+func breakingChanges(path string, old, cur map[string]any) []string {
+	var changes []string
+	if !reflect.DeepEqual(old["type"], cur["type"]) {
+		return []string{fmt.Sprintf("%s: type %v changed to %v", path, old["type"], cur["type"])}
+	}
+
+	oldProps, _ := old["properties"].(map[string]any)
+	curProps, _ := cur["properties"].(map[string]any)
+	names := make([]string, 0, len(oldProps))
+	for name := range oldProps {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		p, ok := curProps[name].(map[string]any)
+		if !ok {
+			changes = append(changes, fmt.Sprintf("%s.%s: removed", path, name))
+			continue
+		}
+		changes = append(changes, breakingChanges(path+"."+name, oldProps[name].(map[string]any), p)...)
+	}
+
+	oldRequired, _ := old["required"].([]any)
+	curRequired, _ := cur["required"].([]any)
+	for _, name := range curRequired {
+		if _, existed := oldProps[name.(string)]; existed && !slices.Contains(oldRequired, name) {
+			changes = append(changes, fmt.Sprintf("%s.%s: made required", path, name))
+		}
+	}
+
+	if items, ok := old["items"].(map[string]any); ok {
+		if curItems, ok := cur["items"].(map[string]any); ok {
+			changes = append(changes, breakingChanges(path+"[]", items, curItems)...)
+		}
+	}
+	return changes
+}
@@ -0,0 +1,38 @@
+package padding
+
+// ResolveSizes sizes the fields of each struct in structs whose type is the
+// name of a struct in named, or an array of one with a literal length, with
+// the layout of that struct rather than as an opaque word, and lays the
+// struct out again. It reports whether any field changed size or alignment.
+//
+// A struct in named may itself be in structs, in which case its new layout
+// is seen by the structs containing it on the next call. Since struct types
+// cannot contain each other by value, calling ResolveSizes until it reports
+// false terminates after at most one call per level of nesting.
+func ResolveSizes(structs []*StructInfo, named map[string]*StructInfo) bool {
+	changed := false
+	for _, s := range structs {
+		resized := false
+		for i := range s.Fields {
+			f := &s.Fields[i]
+			elem, count := f.Type, int64(1)
+			if e, n, slice, ok := arrayElement(f.Type); ok && !slice {
+				elem, count = e, n
+			}
+			inner, ok := named[elem]
+			if !ok || inner == s {
+				continue
+			}
+			size, align := mulSize(count, inner.Size), inner.Align
+			if f.Size != size || f.Align != align {
+				f.Size, f.Align = size, align
+				resized = true
+			}
+		}
+		if resized {
+			layoutFields(s)
+			changed = true
+		}
+	}
+	return changed
+}
@@ -0,0 +1,90 @@
+package padding_test
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestResolveSizes(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", `package p
+type Outer struct { m Middle; b bool }
+type Middle struct { in [3]Inner; n int32 }
+type Inner struct { a bool; n int64 }
+`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	structs, err := padding.Analyze(fset, file, padding.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ptrs []*padding.StructInfo
+	named := make(map[string]*padding.StructInfo)
+	for i := range structs {
+		ptrs = append(ptrs, &structs[i])
+		named[structs[i].Name] = &structs[i]
+	}
+
+	// Outer is resized in the round after Middle.
+	rounds := 0
+	for padding.ResolveSizes(ptrs, named) {
+		rounds++
+	}
+	if rounds != 2 {
+		t.Errorf("sizes settled after %d rounds, want 2", rounds)
+	}
+	want := map[string]int64{"Inner": 16, "Middle": 56, "Outer": 64}
+	for _, s := range structs {
+		if s.Size != want[s.Name] {
+			t.Errorf("%s: size %d, want %d", s.Name, s.Size, want[s.Name])
+		}
+	}
+}
+
+func TestResolveSizesTooLarge(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", `package p
+type Huge struct { a bool; in [4611686018427387904]Inner; n int32 }
+type Big struct { a bool; in [70368744177662]Inner; n int32 }
+type Inner struct { a bool; n int64 }
+`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	structs, err := padding.Analyze(fset, file, padding.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ptrs []*padding.StructInfo
+	named := make(map[string]*padding.StructInfo)
+	for i := range structs {
+		ptrs = append(ptrs, &structs[i])
+		named[structs[i].Name] = &structs[i]
+	}
+	for padding.ResolveSizes(ptrs, named) {
+	}
+
+	// 1<<62 elements of 16 bytes overflow int64; Big is 1<<50 - 32 bytes
+	// of array with a field on either side.
+	want := map[string]bool{"Huge": true, "Big": false, "Inner": false}
+	for _, s := range structs {
+		if s.TooLarge != want[s.Name] {
+			t.Errorf("%s: TooLarge = %t, want %t (size %d)", s.Name, s.TooLarge, want[s.Name], s.Size)
+		}
+		for _, f := range s.Fields {
+			if f.Offset < 0 || f.Size < 0 {
+				t.Errorf("%s.%s: offset %d, size %d", s.Name, f.Name, f.Offset, f.Size)
+			}
+		}
+	}
+	if r := padding.NewStructReport(*named["Huge"]); !r.TooLarge || r.Size != 0 || r.WastedBytes != 0 {
+		t.Errorf("report of Huge: too large %t, size %d, wasted %d", r.TooLarge, r.Size, r.WastedBytes)
+	}
+	if want := int64(1<<50 - 16); named["Big"].Size != want {
+		t.Errorf("Big: size %d, want %d", named["Big"].Size, want)
+	}
+}
@@ -0,0 +1,365 @@
+package padding
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"slices"
+	"strings"
+)
+
+// Rewrite returns src, the source file was parsed from, with the field list
+// of each struct's declaration in the struct's current field order, except
+// for structs marked ReportOnly or Cgo. The padding fields Pad adds are
+// written, and those it drops removed. Each field keeps its source text: its
+// names, type expression, tag, doc comment and line comment; names declared
+// together stay in one declaration while they stay adjacent, in their new
+// order, and those split apart each get the tag, the declaration's comments
+// going with the part holding its first name. Structs with FreeComments are
+// left alone, and other comments of the body that belong to no field stay
+// where they are: on the line of the opening brace, or last. A drift-guard
+// Annotation in a struct's doc comment is updated to the struct's current
+// size. Everything else, structs keeping their order included, is left
+// byte-identical. The structs must have been collected from file by Analyze.
+func Rewrite(fset *token.FileSet, file *ast.File, src []byte, structs []StructInfo) ([]byte, error) {
+	edits, err := rewriteEdits(fset, file, src, structs)
+	if err != nil {
+		return nil, err
+	}
+	return spliceEdits(src, edits), nil
+}
+
+// Edit replaces the bytes of a source file from offset Start to End with
+// Text.
+type Edit struct {
+	Start, End int
+	Text       string
+}
+
+// RewriteEdits returns the edits Rewrite makes to src, in source order and
+// none within another, for tools applying them themselves, such as an
+// analyzer suggesting a fix.
+func RewriteEdits(fset *token.FileSet, file *ast.File, src []byte, structs []StructInfo) ([]Edit, error) {
+	edits, err := rewriteEdits(fset, file, src, structs)
+	if err != nil {
+		return nil, err
+	}
+	var out []Edit
+	at := 0
+	for _, e := range sortEdits(edits) {
+		if e.start < at {
+			continue
+		}
+		out = append(out, Edit{e.start, e.end, e.text})
+		at = e.end
+	}
+	return out, nil
+}
+
+// rewriteEdits returns the edits of Rewrite, those made to inline struct
+// types lying within the edit of the struct holding them.
+func rewriteEdits(fset *token.FileSet, file *ast.File, src []byte, structs []StructInfo) ([]textEdit, error) {
+	tf := fset.File(file.Pos())
+	if tf == nil || tf.Size() != len(src) {
+		return nil, errors.New("source does not match the parsed file")
+	}
+
+	// The structs are rewritten innermost first, as Analyze collects them
+	// outermost first, so that a struct moving a field of an inline
+	// struct type writes that type as rewritten.
+	var edits []textEdit
+	for _, s := range slices.Backward(structs) {
+		if s.Node == nil || s.ReportOnly || s.Cgo || s.TooLarge || s.FreeComments {
+			continue
+		}
+		if a, err := ParseAnnotation(s.Doc); err == nil && a != nil {
+			if text := FormatAnnotation(s.Size); a.Comment.Text != text {
+				edits = append(edits, textEdit{tf.Offset(a.Comment.Pos()), tf.Offset(a.Comment.End()), text})
+			}
+		}
+		e, changed, err := rewriteBody(tf, file, src, s, edits)
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			edits = append(edits, e)
+		}
+	}
+	return edits, nil
+}
+
+// textEdit replaces the bytes of a source file from offset start to end with
+// text.
+type textEdit struct {
+	start, end int
+	text       string
+}
+
+// spliceEdits returns src with edits applied. Edits must not overlap, but
+// may lie within another edit, whose text then already holds theirs.
+func spliceEdits(src []byte, edits []textEdit) []byte {
+	var b bytes.Buffer
+	at := 0
+	for _, e := range sortEdits(edits) {
+		if e.start < at {
+			continue
+		}
+		b.Write(src[at:e.start])
+		b.WriteString(e.text)
+		at = e.end
+	}
+	b.Write(src[at:])
+	return b.Bytes()
+}
+
+// sortEdits sorts edits by their start, an edit before those within it.
+func sortEdits(edits []textEdit) []textEdit {
+	slices.SortFunc(edits, func(a, b textEdit) int { return cmp.Or(a.start-b.start, b.end-a.end) })
+	return edits
+}
+
+// spliceRange returns the bytes of src from offset start to end with the
+// edits lying within them applied.
+func spliceRange(src []byte, start, end int, edits []textEdit) []byte {
+	var within []textEdit
+	for _, e := range edits {
+		if start <= e.start && e.end <= end {
+			within = append(within, textEdit{e.start - start, e.end - start, e.text})
+		}
+	}
+	return spliceEdits(src[start:end], within)
+}
+
+// fieldRef is a field of a struct declaration: a name of a, maybe
+// multi-name, *ast.Field, or an embedded field.
+type fieldRef struct {
+	field *ast.Field
+	name  int // index into field.Names; -1 for an embedded field
+}
+
+// rewriteBody returns the edit writing the field list of s, a struct of
+// file, in its current order, and whether its order changed at all. The
+// edits already made to the inline struct types of its fields are written
+// with them.
+func rewriteBody(tf *token.File, file *ast.File, src []byte, s StructInfo, edits []textEdit) (textEdit, bool, error) {
+	fields := s.Node.Fields
+	var refs []fieldRef
+	for _, f := range fields.List {
+		if len(f.Names) == 0 {
+			refs = append(refs, fieldRef{field: f, name: -1})
+		}
+		for i := range f.Names {
+			refs = append(refs, fieldRef{field: f, name: i})
+		}
+	}
+	order, err := fieldOrder(s, refs)
+	if err != nil {
+		return textEdit{}, false, err
+	}
+	if len(order) == len(refs) && slices.IsSorted(order) && (len(order) == 0 || order[0] >= 0) {
+		return textEdit{}, false, nil
+	}
+
+	// The body is written after a comment on the line of its opening
+	// brace, which stays there. Other comments that belong to no field
+	// come after the last field, as s has no FreeComments, and stay last.
+	start := fields.Opening + 1
+	var trailing []*ast.CommentGroup
+	for _, group := range bodyComments(file, fields) {
+		if tf.Line(group.Pos()) == tf.Line(fields.Opening) && start == fields.Opening+1 {
+			start = group.End()
+			continue
+		}
+		trailing = append(trailing, group)
+	}
+
+	text := func(n ast.Node) string {
+		return string(spliceRange(src, tf.Offset(n.Pos()), tf.Offset(n.End()), edits))
+	}
+	var b strings.Builder
+	b.WriteString("type _ struct {\n")
+	for i := 0; i < len(order); {
+		if order[i] < 0 {
+			// A padding field to add.
+			f := s.Fields[i]
+			b.WriteString(f.Name + " " + f.Type)
+			for _, c := range f.Comment.List {
+				b.WriteString(" " + c.Text)
+			}
+			b.WriteString("\n")
+			i++
+			continue
+		}
+		ref := refs[order[i]]
+
+		// Extend the declaration while the next field is another name of
+		// the same *ast.Field, in the order they are laid out in. The
+		// part holding the first name keeps the comments of the
+		// declaration, and those before it.
+		j := i + 1
+		for ref.name >= 0 && j < len(order) && order[j] >= 0 && refs[order[j]].field == ref.field {
+			j++
+		}
+		first := slices.ContainsFunc(order[i:j], func(k int) bool { return refs[k].name <= 0 })
+		if ref.field.Doc != nil && first {
+			b.WriteString(text(ref.field.Doc) + "\n")
+		}
+		if ref.name >= 0 {
+			var names []string
+			for k := i; k < j; k++ {
+				names = append(names, refs[order[k]].field.Names[refs[order[k]].name].Name)
+			}
+			b.WriteString(strings.Join(names, ", ") + " ")
+		}
+		b.WriteString(text(ref.field.Type))
+		if ref.field.Tag != nil {
+			b.WriteString(" " + ref.field.Tag.Value)
+		}
+		if ref.field.Comment != nil && first {
+			b.WriteString(" " + text(ref.field.Comment))
+		}
+		b.WriteString("\n")
+		i = j
+	}
+	for _, group := range trailing {
+		b.WriteString("\n" + text(group) + "\n")
+	}
+	b.WriteString("}\n")
+
+	formatted, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return textEdit{}, false, fmt.Errorf("struct %s: %v", s.Name, err)
+	}
+	lines := bytes.Split(bytes.TrimSpace(formatted), []byte("\n"))
+
+	// format.Source indents the fields by one tab relative to the type
+	// declaration; add the indentation of the line of the closing brace.
+	indent := lineIndent(src, tf, fields.Closing)
+	var out bytes.Buffer
+	out.WriteString("\n")
+	for _, line := range lines[1 : len(lines)-1] {
+		if len(line) > 0 {
+			out.WriteString(indent)
+		}
+		out.Write(line)
+		out.WriteString("\n")
+	}
+	out.WriteString(indent)
+	from, body := tf.Offset(start), out.String()
+	if open := tf.Offset(fields.Opening); from == open+1 && open > 0 && src[open-1] != ' ' {
+		// A body written on one line, as struct{ a, b int }, gets
+		// the space gofmt puts before the brace of one on several.
+		from, body = open, " {"+body
+	}
+	return textEdit{from, tf.Offset(fields.Closing), body}, true, nil
+}
+
+// fieldOrder returns the fields of s as indices into refs, the fields of its
+// declaration in source order. The padding fields of Pad may differ: those
+// s adds are -1, and those it drops are left out.
+func fieldOrder(s StructInfo, refs []fieldRef) ([]int, error) {
+	used := make([]bool, len(refs))
+	order := make([]int, len(s.Fields))
+	for i, f := range s.Fields {
+		k := 0
+		for ; k < len(refs); k++ {
+			r := refs[k]
+			if !used[k] && refName(r) == f.Name && refTag(r) == f.Tag && r.field.Doc == f.Doc && r.field.Comment == f.Comment {
+				break
+			}
+		}
+		switch {
+		case k < len(refs):
+			used[k], order[i] = true, k
+		case IsPaddingField(f):
+			order[i] = -1
+		default:
+			return nil, fmt.Errorf("struct %s: field %s not declared", s.Name, f.Name)
+		}
+	}
+	for k, r := range refs {
+		if !used[k] && (r.name < 0 || !IsPaddingField(FieldInfo{Name: refName(r), Type: types.ExprString(r.field.Type), Comment: r.field.Comment})) {
+			return nil, fmt.Errorf("struct %s: %d fields, %d declared", s.Name, len(s.Fields), len(refs))
+		}
+	}
+	return order, nil
+}
+
+// refName returns the name of the field r.
+func refName(r fieldRef) string {
+	if r.name < 0 {
+		return embeddedName(r.field.Type).Name
+	}
+	return r.field.Names[r.name].Name
+}
+
+// refTag returns the raw tag of the field r, or empty.
+func refTag(r fieldRef) string {
+	if r.field.Tag == nil {
+		return ""
+	}
+	return r.field.Tag.Value
+}
+
+// bodyComments returns the comment groups of file within fields that belong
+// to no field, in source order.
+func bodyComments(file *ast.File, fields *ast.FieldList) []*ast.CommentGroup {
+	i, _ := slices.BinarySearchFunc(file.Comments, fields.Opening, func(group *ast.CommentGroup, pos token.Pos) int {
+		return cmp.Compare(group.Pos(), pos)
+	})
+	var groups []*ast.CommentGroup
+	for _, group := range file.Comments[i:] {
+		if group.End() > fields.Closing {
+			break
+		}
+		if !attached(fields, group) {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// freeComments reports whether fields holds a comment that belongs to no
+// field above one of its fields, other than one on the line of the opening
+// brace.
+func freeComments(tf *token.File, file *ast.File, fields *ast.FieldList) bool {
+	if len(fields.List) == 0 {
+		return false
+	}
+	last := fields.List[len(fields.List)-1].Pos()
+	for i, group := range bodyComments(file, fields) {
+		if i == 0 && tf.Line(group.Pos()) == tf.Line(fields.Opening) {
+			continue
+		}
+		if group.Pos() < last {
+			return true
+		}
+	}
+	return false
+}
+
+// attached reports whether group is the doc or line comment of one of
+// fields, or lies in the type of one.
+func attached(fields *ast.FieldList, group *ast.CommentGroup) bool {
+	for _, f := range fields.List {
+		if f.Doc == group || f.Comment == group || f.Type.Pos() <= group.Pos() && group.End() <= f.Type.End() {
+			return true
+		}
+	}
+	return false
+}
+
+// lineIndent returns the leading whitespace of the line holding pos.
+func lineIndent(src []byte, tf *token.File, pos token.Pos) string {
+	start := tf.Offset(tf.LineStart(tf.Line(pos)))
+	end := start
+	for end < len(src) && (src[end] == ' ' || src[end] == '\t') {
+		end++
+	}
+	return string(src[start:end])
+}
@@ -0,0 +1,235 @@
+package padding_test
+
+import (
+	"bytes"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// rewriteOptimal returns src, the source of path, with its structs in their
+// optimal orders.
+func rewriteOptimal(t *testing.T, path string, src []byte) []byte {
+	t.Helper()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	structs, err := padding.Analyze(fset, file, padding.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range structs {
+		structs[i] = padding.Optimal(structs[i])
+	}
+	out, err := padding.Rewrite(fset, file, src, structs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestRewriteGolden(t *testing.T) {
+	path := filepath.Join("testdata", "rewrite", "comments.go")
+	src, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := rewriteOptimal(t, path, src)
+	want, err := os.ReadFile(filepath.Join("testdata", "rewrite", "comments.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// The struct already optimal, the one headed by a comment of no field,
+	// and the code around the structs, come back byte-identical.
+	for _, keep := range []string{
+		"type Tight struct {\n\tn   int64 // count\n\tok  bool\n\t on bool\n}",
+		"type Sections struct {\n\tok bool\n\n\t// Counters.\n\n\tn   int64\n\tb bool\n}",
+		"func helper()  {  }",
+	} {
+		if !strings.Contains(string(got), keep) {
+			t.Errorf("rewritten source lacks %q", keep)
+		}
+	}
+
+	// Fixing the result again changes nothing.
+	if again := rewriteOptimal(t, path, got); !bytes.Equal(again, got) {
+		t.Errorf("second rewrite changed the source:\n%s", again)
+	}
+}
+
+func TestRewriteMultiName(t *testing.T) {
+	const src = `package p
+
+type Point struct {
+	// The position.
+	X, Y, Z float64 ` + "`json:\"coord\"`" + ` // in meters
+	ok      bool
+	Label   string
+}
+`
+	for _, tt := range []struct {
+		name  string
+		order []string
+		want  string
+	}{
+		{
+			name:  "adjacent",
+			order: []string{"Label", "X", "Y", "Z", "ok"},
+			want: "type Point struct {\n\tLabel string\n\t// The position.\n" +
+				"\tX, Y, Z float64 `json:\"coord\"` // in meters\n\tok      bool\n}\n",
+		},
+		{
+			name:  "adjacent reordered",
+			order: []string{"Z", "X", "Y", "Label", "ok"},
+			want: "type Point struct {\n\t// The position.\n" +
+				"\tZ, X, Y float64 `json:\"coord\"` // in meters\n\tLabel   string\n\tok      bool\n}\n",
+		},
+		{
+			name:  "split",
+			order: []string{"Y", "Label", "X", "Z", "ok"},
+			want: "type Point struct {\n\tY     float64 `json:\"coord\"`\n\tLabel string\n\t// The position.\n" +
+				"\tX, Z float64 `json:\"coord\"` // in meters\n\tok   bool\n}\n",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			fset := token.NewFileSet()
+			file, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			structs, err := padding.Analyze(fset, file, padding.Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := &structs[0]
+			fields := s.Fields
+			s.Fields = nil
+			for _, name := range tt.order {
+				for _, f := range fields {
+					if f.Name == name {
+						s.Fields = append(s.Fields, f)
+					}
+				}
+			}
+			padding.AnalyzeStruct(s)
+			out, err := padding.Rewrite(fset, file, []byte(src), structs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := "package p\n\n" + tt.want; string(out) != want {
+				t.Errorf("got:\n%s\nwant:\n%s", out, want)
+			}
+			if formatted, err := format.Source(out); err != nil || !bytes.Equal(formatted, out) {
+				t.Errorf("rewritten source is not gofmt-clean: %v\n%s", err, out)
+			}
+		})
+	}
+}
+
+func TestRewriteInline(t *testing.T) {
+	const src = `package p
+
+type Record struct {
+	ok   bool
+	Meta struct {
+		Flag    bool // set once
+		Created int64
+		Seen    bool
+		Limits  struct{ burst bool; rate int64; max int32 }
+	}
+	id   int64
+	done bool
+}
+
+type Tight struct {
+	n    int64
+	Pair struct{ a bool; c int32; b bool }
+}
+`
+	const want = `package p
+
+type Record struct {
+	Meta struct {
+		Created int64
+		Flag    bool // set once
+		Seen    bool
+		Limits  struct {
+			rate  int64
+			max   int32
+			burst bool
+		}
+	}
+	id   int64
+	ok   bool
+	done bool
+}
+
+type Tight struct {
+	n    int64
+	Pair struct {
+		c int32
+		a bool
+		b bool
+	}
+}
+`
+	got := rewriteOptimal(t, "p.go", []byte(src))
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if again := rewriteOptimal(t, "p.go", got); !bytes.Equal(again, got) {
+		t.Errorf("second rewrite changed the source:\n%s", again)
+	}
+}
+
+func TestRewriteEdits(t *testing.T) {
+	// Applied in order, the edits give what Rewrite writes, the inline
+	// struct type of Outer rewritten within its edit.
+	path := filepath.Join("testdata", "rewrite", "comments.go")
+	src, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	structs, err := padding.Analyze(fset, file, padding.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range structs {
+		structs[i] = padding.Optimal(structs[i])
+	}
+	edits, err := padding.RewriteEdits(fset, file, src, structs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	at := 0
+	for _, e := range edits {
+		if e.Start < at {
+			t.Fatalf("edit %+v overlaps the one before", e)
+		}
+		b.Write(src[at:e.Start])
+		b.WriteString(e.Text)
+		at = e.End
+	}
+	b.Write(src[at:])
+	if want := rewriteOptimal(t, path, src); b.String() != string(want) {
+		t.Errorf("edits applied:\n%s\nwant, as Rewrite:\n%s", b.String(), want)
+	}
+}
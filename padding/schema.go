@@ -0,0 +1,74 @@
+package padding
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// schemaID identifies the JSON Schema of Report.
+const schemaID = "https://github.com/zakon47/padding-size/report.schema.json"
+
+// Schema returns a JSON Schema document describing the JSON encoding of
+// Report. It is generated from the Go types, so it cannot fall out of step
+// with them; the version of the schema is SchemaVersion.
+func Schema() []byte {
+	schema := typeSchema(reflect.TypeFor[Report]())
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["$id"] = schemaID
+	schema["title"] = "padding-size report"
+	schema["version"] = SchemaVersion
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		panic(err) // the schema holds only maps, slices and strings
+	}
+	return append(data, '\n')
+}
+
+// typeSchema returns the schema of the JSON encoding of values of type t.
+func typeSchema(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Pointer:
+		return typeSchema(t.Elem())
+	case reflect.Struct:
+		properties := make(map[string]any)
+		required := []string{}
+		for i := range t.NumField() {
+			f := t.Field(i)
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if !f.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			properties[name] = typeSchema(f.Type)
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+		// Properties added by later minor versions must not make
+		// documents invalid for consumers of earlier ones, so additional
+		// properties are allowed.
+		return map[string]any{
+			"type":       "object",
+			"properties": properties,
+			"required":   required,
+		}
+	}
+	panic(fmt.Sprintf("padding: no JSON schema for %v", t))
+}
@@ -0,0 +1,31 @@
+package padding
+
+//go:generate go run gen_sizeclasses.go
+
+// pageSize is the granularity of allocations larger than the largest size
+// class.
+const pageSize = 8192
+
+// AllocSize returns the number of bytes the Go runtime allocates on the heap
+// for an object of the given size: the size rounded up to the runtime's next
+// size class, or to a whole number of pages beyond the largest class. Small
+// objects without pointers may share a block through the tiny allocator;
+// that is not accounted for.
+func AllocSize(size int64) int64 {
+	if size <= 0 {
+		return 0
+	}
+	if size > sizeClasses[len(sizeClasses)-1] {
+		return (size + pageSize - 1) / pageSize * pageSize
+	}
+	lo, hi := 1, len(sizeClasses)-1
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if sizeClasses[mid] < size {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return sizeClasses[lo]
+}
@@ -0,0 +1,46 @@
+package padding_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestAllocSize(t *testing.T) {
+	for _, tt := range []struct{ size, want int64 }{
+		{0, 0},
+		{1, 8},
+		{8, 8},
+		{9, 16},
+		{24, 24},
+		{25, 32},
+		{48, 48},
+		{49, 64},
+		{72, 80},
+		{1024, 1024},
+		{1025, 1152},
+		{32768, 32768}, // the largest size class
+		{32769, 40960}, // whole pages beyond it
+		{40960, 40960},
+	} {
+		if got := padding.AllocSize(tt.size); got != tt.want {
+			t.Errorf("AllocSize(%d) = %d, want %d", tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestFprintAllocSize(t *testing.T) {
+	s := padding.StructInfo{Name: "Record"}
+	for _, typ := range []string{"bool", "int64", "int64", "int64", "int64", "int64", "int64", "int64", "bool"} {
+		s.Fields = append(s.Fields, padding.FieldInfo{Name: "F", Type: typ})
+	}
+	padding.AnalyzeStruct(&s)
+
+	var b strings.Builder
+	padding.Fprint(&b, s)
+	header, _, _ := strings.Cut(b.String(), "\n")
+	if want := "Struct: Record (size: 72 bytes (alloc 80), align: 8, optimal: 64 bytes, packed minimum: 58 bytes, wasted: 8 bytes, padding: 7 inter-field + 7 trailing)"; header != want {
+		t.Errorf("header = %q, want %q", header, want)
+	}
+}
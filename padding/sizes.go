@@ -0,0 +1,273 @@
+package padding
+
+import (
+	"bytes"
+	"go/ast"
+	"go/constant"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// Cache memoizes the rendered type expression, size and alignment of field
+// types, keyed by their source text. It is not safe for concurrent use.
+type Cache struct {
+	layouts map[string]typeLayout
+	sizes   archSizes // the architecture the layouts are for
+}
+
+// NewCache returns an empty Cache.
+func NewCache() *Cache {
+	return &Cache{layouts: make(map[string]typeLayout), sizes: amd64Sizes}
+}
+
+// use empties c if its layouts are for another architecture than sizes.
+func (c *Cache) use(sizes archSizes) {
+	if c.sizes != sizes {
+		c.layouts, c.sizes = make(map[string]typeLayout), sizes
+	}
+}
+
+type typeLayout struct {
+	typ         string
+	size, align int64
+}
+
+// lookup returns the layout of the type expression expr. The expression is
+// rendered into buf, so that only types not seen before allocate a string.
+func (c *Cache) lookup(expr ast.Expr, buf *bytes.Buffer) (typ string, size, align int64) {
+	var l typeLayout
+	var ok bool
+	if ident, isIdent := expr.(*ast.Ident); isIdent {
+		l, ok = c.layouts[ident.Name]
+		if !ok {
+			l = c.sizes.newTypeLayout(ident.Name)
+			c.layouts[ident.Name] = l
+		}
+	} else {
+		buf.Reset()
+		types.WriteExpr(buf, expr)
+		l, ok = c.layouts[string(buf.Bytes())]
+		if !ok {
+			l = c.sizes.newTypeLayout(buf.String())
+			c.layouts[l.typ] = l
+		}
+	}
+	return l.typ, l.size, l.align
+}
+
+func (a archSizes) newTypeLayout(fieldType string) typeLayout {
+	return typeLayout{fieldType, a.getFieldSize(fieldType), a.getFieldAlign(fieldType)}
+}
+
+// archSizes are the word size of an architecture and the alignment of its
+// 64-bit values, which is all the sizes of type expressions depend on.
+type archSizes struct {
+	word, align64 int64
+}
+
+// amd64Sizes are the sizes of amd64, those of an empty Options.Arch.
+var amd64Sizes = archSizes{word: 8, align64: 8}
+
+// sizesFor returns the sizes of the architecture arch as laid out by
+// compiler, CompilerGC if empty, or false if the compiler does not support
+// the architecture. An empty arch is amd64.
+func sizesFor(compiler, arch string) (archSizes, bool) {
+	if arch == "" {
+		arch = "amd64"
+	}
+	if compiler == "" {
+		compiler = CompilerGC
+	}
+	sizes := types.SizesFor(compiler, arch)
+	if sizes == nil {
+		return archSizes{}, false
+	}
+	return archSizes{
+		word:    sizes.Sizeof(types.Typ[types.Uintptr]),
+		align64: sizes.Alignof(types.Typ[types.Int64]),
+	}, true
+}
+
+// sizes returns the sizes of the architecture s is laid out for, amd64 if
+// its Arch is empty or unknown.
+func (s StructInfo) sizes() archSizes {
+	if sizes, ok := sizesFor(s.Compiler, s.Arch); ok {
+		return sizes
+	}
+	return amd64Sizes
+}
+
+// cScalars are the sizes, and alignments, of the C scalar types cgo makes
+// available as C.name, as on Linux and macOS. The C compiler decides them,
+// so fields of these types are still estimated. Those of cWords take a word
+// instead.
+var cScalars = map[string]int64{
+	"C.char": 1, "C.schar": 1, "C.uchar": 1,
+	"C.short": 2, "C.ushort": 2,
+	"C.int": 4, "C.uint": 4, "C.float": 4,
+	"C.longlong": 8, "C.ulonglong": 8, "C.double": 8,
+	"C.int8_t": 1, "C.uint8_t": 1, "C.int16_t": 2, "C.uint16_t": 2,
+	"C.int32_t": 4, "C.uint32_t": 4, "C.int64_t": 8, "C.uint64_t": 8,
+}
+
+// cWords are the C scalar types as wide as a word.
+var cWords = map[string]bool{
+	"C.long": true, "C.ulong": true,
+	"C.size_t": true, "C.ssize_t": true, "C.uintptr_t": true, "C.intptr_t": true,
+}
+
+func (a archSizes) getFieldSize(fieldType string) int64 {
+	if size, ok := cScalars[fieldType]; ok {
+		return size
+	}
+	if cWords[fieldType] {
+		return a.word
+	}
+	switch fieldType {
+	case "bool", "int8", "uint8", "byte":
+		return 1
+	case "int16", "uint16":
+		return 2
+	case "int32", "uint32", "float32":
+		return 4
+	case "int64", "uint64", "float64", "complex64":
+		return 8
+	case "complex128":
+		return 16
+	case "int", "uint", "uintptr", "unsafe.Pointer":
+		return a.word
+	case "string", "error", "any":
+		return 2 * a.word // a pointer and a length, or a type and a value
+	default:
+		switch {
+		case strings.HasPrefix(fieldType, "[]"):
+			return 3 * a.word // a pointer, a length and a capacity
+		case strings.HasPrefix(fieldType, "interface{"):
+			return 2 * a.word
+		case wordType(fieldType):
+			return a.word
+		}
+		// An array takes its length in elements, none for a
+		// zero-length array such as the _ [0]func() making a struct
+		// incomparable.
+		if n, elem, ok := arrayType(fieldType); ok {
+			return mulSize(n, a.getFieldSize(elem))
+		}
+		// A struct type literal is laid out from its fields: none for
+		// the struct{} of done channels, markers and sets.
+		if st, ok := structType(fieldType); ok {
+			size, _ := a.structLayout(st)
+			return size
+		}
+		// For other types (structs, arrays, etc.), we need more sophisticated analysis
+		// For simplicity, we'll assume a word, but this should be improved
+		return a.word
+	}
+}
+
+// wordType reports whether fieldType, a type expression as written in the
+// source, is a pointer, map, channel or function type, all of which a
+// single pointer represents.
+func wordType(fieldType string) bool {
+	for _, prefix := range []string{"*", "map[", "chan ", "chan<- ", "<-chan ", "func("} {
+		if strings.HasPrefix(fieldType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// arrayType returns the length and the element type of fieldType, an
+// array type expression such as [4]byte, [3][8]int64 or [1 << 4]Entry, if
+// its length is a constant expression of literals; ok is false for other
+// types, including slices and arrays whose length names a constant, such as
+// [sha256.Size]byte, which only the type checker can resolve. A length
+// overflowing int64 is returned as MaxSize, which is too large anyway.
+func arrayType(fieldType string) (n int64, elem string, ok bool) {
+	if !strings.HasPrefix(fieldType, "[") || strings.HasPrefix(fieldType, "[]") {
+		return 0, "", false
+	}
+	expr, err := parser.ParseExpr(fieldType)
+	if err != nil {
+		return 0, "", false
+	}
+	array, isArray := expr.(*ast.ArrayType)
+	if !isArray || array.Len == nil {
+		return 0, "", false
+	}
+	n, ok = arrayLen(array.Len)
+	return n, types.ExprString(array.Elt), ok
+}
+
+// structType returns the struct type fieldType spells if it is a struct type
+// literal, such as struct{} or struct{ a, b int32 }.
+func structType(fieldType string) (*ast.StructType, bool) {
+	if !strings.HasPrefix(fieldType, "struct{") {
+		return nil, false
+	}
+	expr, err := parser.ParseExpr(fieldType)
+	if err != nil {
+		return nil, false
+	}
+	st, ok := expr.(*ast.StructType)
+	return st, ok
+}
+
+// structLayout returns the size and alignment of st, its fields laid out in
+// order by the gc compiler's rules, a zero-size last field padded included.
+func (a archSizes) structLayout(st *ast.StructType) (size, alignment int64) {
+	var p packer
+	for _, field := range st.Fields.List {
+		typ := types.ExprString(field.Type)
+		for range max(len(field.Names), 1) {
+			p.add(a.getFieldSize(typ), a.getFieldAlign(typ))
+		}
+	}
+	return p.size()
+}
+
+// arrayLen evaluates length, that of an array type, if it is a constant
+// expression of literals, as arrayType does.
+func arrayLen(length ast.Expr) (int64, bool) {
+	tv, err := types.Eval(token.NewFileSet(), nil, token.NoPos, types.ExprString(length))
+	if err != nil || tv.Value == nil {
+		return 0, false
+	}
+	n := constant.ToInt(tv.Value)
+	if n.Kind() != constant.Int || constant.Sign(n) < 0 {
+		return 0, false
+	}
+	if n, exact := constant.Int64Val(n); exact {
+		return n, true
+	}
+	return MaxSize, true
+}
+
+func (a archSizes) getFieldAlign(fieldType string) int64 {
+	if align, ok := cScalars[fieldType]; ok {
+		return min(align, a.align64)
+	}
+	switch fieldType {
+	case "bool", "int8", "uint8", "byte":
+		return 1
+	case "int16", "uint16":
+		return 2
+	case "int32", "uint32", "float32", "complex64":
+		return 4
+	case "int64", "uint64", "float64", "complex128":
+		return a.align64
+	default:
+		// An array has the alignment of its elements, even with none.
+		if _, elem, ok := arrayType(fieldType); ok {
+			return a.getFieldAlign(elem)
+		}
+		if st, ok := structType(fieldType); ok {
+			_, align := a.structLayout(st)
+			return align
+		}
+		// Everything else is made of words.
+		return a.word
+	}
+}
@@ -0,0 +1,314 @@
+package padding_test
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"slices"
+	"testing"
+	"unsafe"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestArraySizes(t *testing.T) {
+	tests := []struct {
+		typ         string
+		size, align int64
+	}{
+		{"[0]int64", 0, 8},
+		{"[0]func()", 0, 8},
+		{"[1]int32", 4, 4},
+		{"[4]byte", 4, 1},
+		{"[16]uint32", 64, 4},
+		{"[3][8]int64", 192, 8},
+		{"[2][3]int16", 12, 2},
+		{"[1 << 4]byte", 16, 1},
+		{"[2 * 3]bool", 6, 1},
+		{"[2]string", 32, 8},
+		{"[3]*Inner", 24, 8},
+		{"[2]Inner", 32, 8},
+		{"[2][2]Inner", 64, 8},
+		{"[0]Inner", 0, 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.typ, func(t *testing.T) {
+			src := "package p\ntype T struct { f " + tt.typ + "; b bool }\ntype Inner struct { a bool; n int64 }\n"
+			fset := token.NewFileSet()
+			file, err := parser.ParseFile(fset, "p.go", src, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			structs, err := padding.Analyze(fset, file, padding.Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			named := map[string]*padding.StructInfo{"Inner": &structs[1]}
+			for padding.ResolveSizes([]*padding.StructInfo{&structs[0], &structs[1]}, named) {
+			}
+			f := structs[0].Fields[0]
+			if f.Size != tt.size || f.Align != tt.align {
+				t.Errorf("size %d, align %d, want %d and %d", f.Size, f.Align, tt.size, tt.align)
+			}
+			if padding.Estimated(f.Type, named) {
+				t.Errorf("%s is estimated", f.Type)
+			}
+
+			// The layout agrees with the type checker's.
+			pkg, err := new(types.Config).Check("p", fset, []*ast.File{file}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gc := types.SizesFor("gc", "amd64").Sizeof(pkg.Scope().Lookup("T").Type()); structs[0].Size != gc {
+				t.Errorf("struct size %d, gc %d", structs[0].Size, gc)
+			}
+		})
+	}
+}
+
+// The structs of zeroSizeSource, declared here as well to be sized by the
+// compiler.
+type (
+	zeroLast struct {
+		a int64
+		b struct{}
+	}
+	zeroFirst struct {
+		b struct{}
+		a int64
+	}
+	zeroMiddle struct {
+		a int64
+		b struct{}
+		c int32
+	}
+	zeroArray struct {
+		a int32
+		b [0]int64
+	}
+	zeroNested struct {
+		a int8
+		b struct{ c struct{} }
+	}
+	zeroOnly struct{ a, b struct{} }
+	literal  struct {
+		a bool
+		b struct {
+			c int8
+			d int64
+		}
+	}
+)
+
+const zeroSizeSource = `package p
+
+type zeroLast struct{ a int64; b struct{} }
+type zeroFirst struct{ b struct{}; a int64 }
+type zeroMiddle struct{ a int64; b struct{}; c int32 }
+type zeroArray struct{ a int32; b [0]int64 }
+type zeroNested struct{ a int8; b struct{ c struct{} } }
+type zeroOnly struct{ a, b struct{} }
+type literal struct{ a bool; b struct{ c int8; d int64 } }
+`
+
+func TestZeroSizeFields(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", zeroSizeSource, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	structs, err := padding.Analyze(fset, file, padding.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sizes := map[string]uintptr{
+		"zeroLast":   unsafe.Sizeof(zeroLast{}),
+		"zeroFirst":  unsafe.Sizeof(zeroFirst{}),
+		"zeroMiddle": unsafe.Sizeof(zeroMiddle{}),
+		"zeroArray":  unsafe.Sizeof(zeroArray{}),
+		"zeroNested": unsafe.Sizeof(zeroNested{}),
+		"zeroOnly":   unsafe.Sizeof(zeroOnly{}),
+		"literal":    unsafe.Sizeof(literal{}),
+	}
+	for _, s := range structs {
+		want, ok := sizes[s.Name]
+		if !ok {
+			continue // the struct types of fields
+		}
+		delete(sizes, s.Name)
+		if s.Size != int64(want) {
+			t.Errorf("%s: size %d, compiler %d", s.Name, s.Size, want)
+		}
+
+		// Reordering never pads a zero-size field last where it
+		// wasn't: zeroLast shrinks to zeroFirst, which stays.
+		o := padding.Optimal(s)
+		if o.Size > s.Size {
+			t.Errorf("%s: optimal size %d, larger than %d", s.Name, o.Size, s.Size)
+		}
+		if last := o.Fields[len(o.Fields)-1]; last.Size == 0 && o.PackedSize() > 0 {
+			t.Errorf("%s: optimal order ends in the zero-size %s", s.Name, last.Name)
+		}
+	}
+	if len(sizes) > 0 {
+		t.Errorf("structs not analyzed: %v", sizes)
+	}
+	if want := int64(unsafe.Sizeof(zeroFirst{})); padding.Optimal(structs[0]).Size != want {
+		t.Errorf("optimal size of zeroLast %d, want that of zeroFirst, %d", padding.Optimal(structs[0]).Size, want)
+	}
+}
+
+func TestArraySizesUnresolved(t *testing.T) {
+	// Lengths naming constants, and elements of other packages, are left
+	// to the type checker: the array is a guess.
+	for _, typ := range []string{"[sha256.Size]byte", "[n]int64", "[4]time.Time"} {
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, "p.go", "package p\ntype T struct { f "+typ+"; b bool }\n", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		structs, err := padding.Analyze(fset, file, padding.Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f := structs[0].Fields[0]; !padding.Estimated(f.Type, nil) {
+			t.Errorf("%s is not estimated", typ)
+		}
+	}
+}
+
+func TestArrayOrder(t *testing.T) {
+	// A large byte array is only 1-aligned: the optimal order packs it
+	// with the small fields, rather than placing it as a word.
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", "package p\ntype T struct { a bool; buf [64]byte; n int32; p *int; b bool }\n", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	structs, err := padding.Analyze(fset, file, padding.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := structs[0]
+	if s.Size != 88 {
+		t.Errorf("size %d, want 88", s.Size)
+	}
+	o := padding.Optimal(s)
+	if o.Size != 80 || o.PackedSize() != 78 {
+		t.Errorf("optimal size %d, packed %d, want 80 and 78", o.Size, o.PackedSize())
+	}
+	// As an 8-byte word, buf would leave 8 bytes of padding around the
+	// small fields.
+	for _, f := range o.Fields {
+		if f.Name == "buf" && (f.Size != 64 || f.Align != 1) {
+			t.Errorf("buf: size %d, align %d, want 64 and 1", f.Size, f.Align)
+		}
+	}
+}
+
+func TestArchSizes(t *testing.T) {
+	// Every field type the expressions size exactly agrees with the type
+	// checker's layout for the architecture.
+	src := `package p
+
+import "unsafe"
+
+type T struct {
+	a bool
+	b int
+	c uintptr
+	d unsafe.Pointer
+	e *int
+	f string
+	g []byte
+	h map[string]int
+	i any
+	j interface{ M() }
+	k func()
+	l chan int
+	m int64
+	n float64
+	o complex128
+	p [3]uint64
+	q int32
+}
+`
+	for _, arch := range []string{"amd64", "386", "arm", "arm64", "wasm", "mips", "riscv64"} {
+		t.Run(arch, func(t *testing.T) {
+			fset := token.NewFileSet()
+			file, err := parser.ParseFile(fset, "p.go", src, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			structs, err := padding.Analyze(fset, file, padding.Options{Arch: arch})
+			if err != nil {
+				t.Fatal(err)
+			}
+			conf := types.Config{Importer: importer.Default()}
+			pkg, err := conf.Check("p", fset, []*ast.File{file}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sizes := types.SizesFor("gc", arch)
+			st := pkg.Scope().Lookup("T").Type().Underlying().(*types.Struct)
+			for i, f := range structs[0].Fields {
+				typ := st.Field(i).Type()
+				if f.Size != sizes.Sizeof(typ) || f.Align != sizes.Alignof(typ) {
+					t.Errorf("%s: size %d, align %d, want %d and %d", f.Type, f.Size, f.Align, sizes.Sizeof(typ), sizes.Alignof(typ))
+				}
+			}
+			if want := sizes.Sizeof(st); structs[0].Size != want {
+				t.Errorf("struct size %d, gc %d", structs[0].Size, want)
+			}
+		})
+	}
+}
+
+func TestArchOrder(t *testing.T) {
+	// On 386 int64 and pointers are only 4-aligned: the same struct is
+	// smaller and its optimal order keeps them in source order.
+	src := "package p\ntype T struct { a bool; b int64; c int32; d *int; e bool }\n"
+	tests := []struct {
+		arch          string
+		size, optimal int64
+		order         []string
+	}{
+		{"amd64", 40, 24, []string{"b", "d", "c", "a", "e"}},
+		{"386", 24, 20, []string{"b", "c", "d", "a", "e"}},
+	}
+	cache := padding.NewCache()
+	for _, tt := range tests {
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, "p.go", src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// One cache serves both: it is emptied for the other architecture.
+		structs, err := padding.Analyze(fset, file, padding.Options{Cache: cache, Arch: tt.arch})
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := structs[0]
+		optimal := padding.Optimal(s)
+		var order []string
+		for _, f := range optimal.Fields {
+			order = append(order, f.Name)
+		}
+		if s.Size != tt.size || optimal.Size != tt.optimal || !slices.Equal(order, tt.order) {
+			t.Errorf("%s: size %d, optimal %d in order %v, want %d, %d and %v", tt.arch, s.Size, optimal.Size, order, tt.size, tt.optimal, tt.order)
+		}
+	}
+}
+
+func TestArchUnknown(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", "package p\ntype T struct { a, b int }\n", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := padding.Analyze(fset, file, padding.Options{Arch: "pdp11"}); err == nil {
+		t.Error("Analyze succeeded for an unknown architecture")
+	}
+}
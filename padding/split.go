@@ -0,0 +1,85 @@
+package padding
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Split is a proposal to move the cold fields of a struct into a side struct
+// referenced by a pointer from the hot struct keeping the other fields.
+type Split struct {
+	Cold     []FieldInfo // fields moving to the side struct, in source order
+	Marked   bool        // whether the fields were chosen by //padding:cold
+	HotSize  int64       // optimal size of the hot struct, pointer included
+	ColdSize int64       // optimal size of the side struct
+}
+
+// SuggestSplit proposes a split of s if even its optimal layout is larger
+// than threshold bytes. The fields marked //padding:cold move if there are
+// any. Otherwise the fields larger than a pointer move, largest first, until
+// the hot struct fits in threshold or one field is left. It reports false if
+// s is small enough or no split would make the hot struct smaller than the
+// optimal layout of s.
+func SuggestSplit(s StructInfo, threshold int64) (Split, bool) {
+	optimal := Optimal(s)
+	if optimal.Size <= threshold || len(s.Fields) < 2 {
+		return Split{}, false
+	}
+
+	cold := make([]bool, len(s.Fields))
+	var split Split
+	for i, f := range s.Fields {
+		if f.HasDirective("cold") {
+			cold[i], split.Marked = true, true
+		}
+	}
+	if split.Marked {
+		split.HotSize, split.ColdSize = splitSizes(s, cold)
+	} else {
+		var candidates []int
+		for i, f := range s.Fields {
+			if f.Size > s.sizes().word {
+				candidates = append(candidates, i)
+			}
+		}
+		slices.SortStableFunc(candidates, func(i, j int) int {
+			return cmp.Compare(s.Fields[j].Size, s.Fields[i].Size)
+		})
+		if len(candidates) == len(s.Fields) {
+			candidates = candidates[:len(candidates)-1]
+		}
+		for _, i := range candidates {
+			cold[i] = true
+			split.HotSize, split.ColdSize = splitSizes(s, cold)
+			if split.HotSize <= threshold {
+				break
+			}
+		}
+	}
+	for i, f := range s.Fields {
+		if cold[i] {
+			split.Cold = append(split.Cold, f)
+		}
+	}
+	if len(split.Cold) == 0 || len(split.Cold) == len(s.Fields) || split.HotSize >= optimal.Size {
+		return Split{}, false
+	}
+	return split, true
+}
+
+// splitSizes returns the optimal sizes of the hot struct, holding the fields
+// of s not marked cold and a pointer to the side struct, and of the side
+// struct holding the cold ones.
+func splitSizes(s StructInfo, cold []bool) (hot, side int64) {
+	word := s.sizes().word
+	h := StructInfo{Fields: []FieldInfo{{Size: word, Align: word}}}
+	var c StructInfo
+	for i, f := range s.Fields {
+		if cold[i] {
+			c.Fields = append(c.Fields, f)
+		} else {
+			h.Fields = append(h.Fields, f)
+		}
+	}
+	return Optimal(h).Size, Optimal(c).Size
+}
@@ -0,0 +1,105 @@
+package padding_test
+
+import (
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// analyzeOne returns the layout of the only struct declared in src.
+func analyzeOne(t *testing.T, src string) padding.StructInfo {
+	t.Helper()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", "package p\n"+src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	structs, err := padding.Analyze(fset, file, padding.Options{})
+	if err != nil || len(structs) != 1 {
+		t.Fatalf("Analyze = %d structs, %v", len(structs), err)
+	}
+	return structs[0]
+}
+
+func TestSuggestSplit(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		src       string
+		threshold int64
+		ok        bool
+		cold      []string
+		marked    bool
+		hot, side int64
+	}{
+		{
+			name:      "small enough",
+			src:       "type T struct { A string; B string; C int64 }",
+			threshold: 64,
+		},
+		{
+			// Moving the largest fields, first A and then B, brings 88
+			// bytes down to 64 with the pointer; C and D stay.
+			name:      "largest first",
+			src:       "type T struct { N int64; A string; B string; M int64; C string; D string; E bool }",
+			threshold: 64,
+			ok:        true, cold: []string{"A", "B"}, hot: 64, side: 32,
+		},
+		{
+			name:      "marked cold",
+			src:       "type T struct {\n\tA string\n\t//padding:cold\n\tB string\n\tC string //padding:cold\n\tN, M, K int64\n}",
+			threshold: 32,
+			ok:        true, cold: []string{"B", "C"}, marked: true, hot: 48, side: 32,
+		},
+		{
+			name:      "nothing larger than a pointer",
+			src:       "type T struct { A, B, C, D, E, F, G, H, I int64 }",
+			threshold: 64,
+		},
+		{
+			// Moving the only large field would leave the struct empty.
+			name:      "one field",
+			src:       "type T struct { A string }",
+			threshold: 8,
+		},
+		{
+			// The side struct would save less than its pointer costs.
+			name:      "not worth a pointer",
+			src:       "type T struct { A int64; B int64 //padding:cold\n}",
+			threshold: 8,
+		},
+	} {
+		s := analyzeOne(t, tt.src)
+		split, ok := padding.SuggestSplit(s, tt.threshold)
+		if ok != tt.ok {
+			t.Errorf("%s: SuggestSplit ok = %v, want %v", tt.name, ok, tt.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		var cold []string
+		for _, f := range split.Cold {
+			cold = append(cold, f.Name)
+		}
+		if !reflect.DeepEqual(cold, tt.cold) || split.Marked != tt.marked || split.HotSize != tt.hot || split.ColdSize != tt.side {
+			t.Errorf("%s: split %v (marked %v), hot %d, side %d; want %v (marked %v), hot %d, side %d",
+				tt.name, cold, split.Marked, split.HotSize, split.ColdSize, tt.cold, tt.marked, tt.hot, tt.side)
+		}
+	}
+}
+
+func TestFprintSplit(t *testing.T) {
+	s := analyzeOne(t, "type T struct { N int64; A string; B string; M int64; C string; D string; E bool }")
+	r := padding.NewStructReport(s)
+	r.CheckSplit(s, 64)
+	var b strings.Builder
+	padding.FprintStruct(&b, r)
+	want := "  Suggested split: moving A, B (the largest fields) into a 32-byte side struct behind a pointer shrinks T from 88 to 64 bytes\n"
+	if !strings.Contains(b.String(), want) {
+		t.Errorf("report lacks %q:\n%s", want, b.String())
+	}
+}
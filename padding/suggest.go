@@ -0,0 +1,216 @@
+package padding
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// minBoolRun is the number of adjacent bool fields from which packing them
+// into bit flags is suggested.
+const minBoolRun = 4
+
+// BoolPack is a run of adjacent bool fields that could be packed into the
+// bits of a single unsigned integer field.
+type BoolPack struct {
+	Fields []string // names of the bool fields, in source order
+	Type   string   // smallest unsigned integer type with a bit for each
+	Saved  int64    // bytes the optimal layout shrinks by when packed
+}
+
+// BoolPacks returns the runs of at least four adjacent bool fields of s whose
+// packing into bit flags would make its optimal layout smaller. A field with
+// a //padding:group directive starts a new run, so that flags of unrelated
+// groups are not packed together. Runs longer than 64 fields are split.
+func BoolPacks(s StructInfo) []BoolPack {
+	optimal := Optimal(s).Size
+	var packs []BoolPack
+	flush := func(run []int) {
+		if len(run) < minBoolRun {
+			return
+		}
+		// The run is replaced by a field of the flags type, in place of
+		// its first field.
+		typ := flagsType(len(run))
+		packed := StructInfo{Fields: make([]FieldInfo, 0, len(s.Fields)-len(run)+1)}
+		for i, f := range s.Fields {
+			switch {
+			case i == run[0]:
+				packed.Fields = append(packed.Fields, FieldInfo{Name: "flags", Type: typ})
+			case i > run[0] && i <= run[len(run)-1]:
+			default:
+				packed.Fields = append(packed.Fields, f)
+			}
+		}
+		AnalyzeStruct(&packed)
+		if saved := optimal - Optimal(packed).Size; saved > 0 {
+			p := BoolPack{Type: typ, Saved: saved}
+			for _, i := range run {
+				p.Fields = append(p.Fields, s.Fields[i].Name)
+			}
+			packs = append(packs, p)
+		}
+	}
+
+	var run []int
+	for i, f := range s.Fields {
+		// A directive on a declaration of several names starts the run
+		// at the first of them.
+		group := f.HasDirective("group") && (i == 0 || f.Doc != s.Fields[i-1].Doc || f.Comment != s.Fields[i-1].Comment)
+		if f.Type != "bool" || group || len(run) == 64 {
+			flush(run)
+			run = nil
+		}
+		if f.Type == "bool" {
+			run = append(run, i)
+		}
+	}
+	flush(run)
+	return packs
+}
+
+// flagsType returns the smallest unsigned integer type with n bits.
+func flagsType(n int) string {
+	switch {
+	case n <= 8:
+		return "uint8"
+	case n <= 16:
+		return "uint16"
+	case n <= 32:
+		return "uint32"
+	}
+	return "uint64"
+}
+
+// FlagConst returns the name of the bit constant suggested for the bool field
+// name, such as flagEnabled for enabled.
+func FlagConst(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return "flag" + string(unicode.ToUpper(r)) + name[size:]
+}
+
+// Narrowing is a field whose integer type is wider than those of the
+// counter-like fields next to it.
+type Narrowing struct {
+	Field     string // name of the field
+	Type      string // its declared type
+	Suggested string // the type of its widest sibling counter
+	Saved     int64  // bytes the optimal layout shrinks by when narrowed
+}
+
+// counterWords are the words of field names that hint at a counter or index,
+// whose range is usually small.
+var counterWords = map[string]bool{
+	"count": true, "counter": true, "cnt": true, "num": true,
+	"index": true, "idx": true, "len": true,
+	"retries": true, "attempts": true, "depth": true, "level": true,
+}
+
+// integerTypes are the predeclared integer types narrowing applies to.
+var integerTypes = map[string]bool{
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true,
+}
+
+// Narrowings returns the fields of s that look like counters, by their
+// integer type and a name such as retryCount or idx, and are wider than all
+// the other counters of s, if narrowing them to the type of the widest of
+// those would make the optimal layout of s smaller. It is a heuristic: only
+// a human can tell whether the values fit. Fields with a //padding:keep-type
+// directive are not reported, but still count as context for the others.
+func Narrowings(s StructInfo) []Narrowing {
+	var counters []int
+	for i, f := range s.Fields {
+		if integerTypes[f.Type] && isCounterName(f.Name) {
+			counters = append(counters, i)
+		}
+	}
+	if len(counters) < 2 {
+		return nil
+	}
+
+	optimal := Optimal(s).Size
+	var narrowings []Narrowing
+	for _, i := range counters {
+		f := s.Fields[i]
+		if f.HasDirective("keep-type") {
+			continue
+		}
+		widest := -1
+		for _, j := range counters {
+			if j != i && (widest < 0 || s.Fields[j].Size > s.Fields[widest].Size) {
+				widest = j
+			}
+		}
+		narrow := s.Fields[widest]
+		if narrow.Size >= f.Size {
+			continue
+		}
+		narrowed := StructInfo{Fields: slices.Clone(s.Fields)}
+		narrowed.Fields[i].Type, narrowed.Fields[i].Size, narrowed.Fields[i].Align = narrow.Type, narrow.Size, narrow.Align
+		AnalyzeStruct(&narrowed)
+		if saved := optimal - Optimal(narrowed).Size; saved > 0 {
+			narrowings = append(narrowings, Narrowing{f.Name, f.Type, narrow.Type, saved})
+		}
+	}
+	return narrowings
+}
+
+// isCounterName reports whether one of the words of the field name, split at
+// underscores and case changes, is a counterWord.
+func isCounterName(name string) bool {
+	var word []rune
+	for i, r := range name {
+		if r == '_' || unicode.IsUpper(r) && i > 0 {
+			if counterWords[strings.ToLower(string(word))] {
+				return true
+			}
+			word = word[:0]
+		}
+		if r != '_' {
+			word = append(word, r)
+		}
+	}
+	return counterWords[strings.ToLower(string(word))]
+}
+
+// Indirection is a field taking most of a struct whose move behind a pointer
+// brings the rest of the struct within a cache line.
+type Indirection struct {
+	Field   string // name of the field
+	Type    string // its declared type
+	Pointer string // the pointer type replacing it
+	Size    int64  // size of the field
+	NewSize int64  // optimal size of the struct with the pointer instead
+}
+
+// SuggestIndirection returns the largest field of s if it takes more than
+// fraction of the size of s, s is larger than a cache line of line bytes,
+// and replacing the field with a pointer to it brings the optimal size of s
+// within one. Such a field, like a scratch buffer, is usually touched far
+// less than the others, which it pushes apart.
+func SuggestIndirection(s StructInfo, fraction float64, line int64) (Indirection, bool) {
+	if s.Size <= line || len(s.Fields) < 2 {
+		return Indirection{}, false
+	}
+	largest := 0
+	for i, f := range s.Fields {
+		if f.Size > s.Fields[largest].Size {
+			largest = i
+		}
+	}
+	f := s.Fields[largest]
+	word := s.sizes().word
+	if float64(f.Size) <= fraction*float64(s.Size) || f.Size <= word {
+		return Indirection{}, false
+	}
+	indirect := StructInfo{Fields: slices.Clone(s.Fields)}
+	indirect.Fields[largest].Type, indirect.Fields[largest].Size, indirect.Fields[largest].Align = "*"+f.Type, word, word
+	AnalyzeStruct(&indirect)
+	size := Optimal(indirect).Size
+	if size > line {
+		return Indirection{}, false
+	}
+	return Indirection{f.Name, f.Type, "*" + f.Type, f.Size, size}, true
+}
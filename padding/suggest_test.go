@@ -0,0 +1,201 @@
+package padding_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestBoolPacks(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		src  string
+		want []padding.BoolPack
+	}{
+		{
+			name: "below the threshold",
+			src:  "type T struct { A, B, C bool }",
+		},
+		{
+			name: "at the threshold",
+			src:  "type T struct { A, B, C, D bool }",
+			want: []padding.BoolPack{{Fields: []string{"A", "B", "C", "D"}, Type: "uint8", Saved: 3}},
+		},
+		{
+			name: "uint16",
+			src:  "type T struct { N int64; A, B, C, D, E, F, G, H, I, J, K, L bool }",
+			want: []padding.BoolPack{{Fields: []string{"A", "B", "C", "D", "E", "F", "G", "H", "I", "J", "K", "L"}, Type: "uint16", Saved: 8}},
+		},
+		{
+			name: "interleaved with other fields",
+			src:  "type T struct { A, B bool; N int8; C, D bool }",
+		},
+		{
+			name: "separated by a group marker",
+			src:  "type T struct {\n\tA, B, C bool\n\t//padding:group\n\tD, E, F bool\n}",
+		},
+		{
+			name: "groups packed separately",
+			src:  "type T struct {\n\tA, B, C, D bool\n\t//padding:group=io\n\tE, F, G, H bool\n}",
+			want: []padding.BoolPack{
+				{Fields: []string{"A", "B", "C", "D"}, Type: "uint8", Saved: 3},
+				{Fields: []string{"E", "F", "G", "H"}, Type: "uint8", Saved: 3},
+			},
+		},
+		{
+			// Five bools fit in the padding after the int64 anyway.
+			name: "nothing saved",
+			src:  "type T struct { N int64; A, B, C, D, E bool }",
+		},
+	} {
+		if got := padding.BoolPacks(analyzeOne(t, tt.src)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: BoolPacks = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFprintBoolPacks(t *testing.T) {
+	s := analyzeOne(t, "type T struct { enabled, Debug, Trace, Force bool }")
+	r := padding.NewStructReport(s)
+	r.CheckSuggestions(s)
+	var b strings.Builder
+	padding.FprintStruct(&b, r)
+	want := `  Suggestion: pack the bools enabled, Debug, Trace, Force into one uint8 field of bit flags, saving 3 bytes (size 4 → 1):
+    const (
+    	flagEnabled uint8 = 1 << iota
+    	flagDebug
+    	flagTrace
+    	flagForce
+    )
+`
+	if !strings.Contains(b.String(), want) {
+		t.Errorf("report lacks\n%s\ngot:\n%s", want, b.String())
+	}
+}
+
+func TestNarrowings(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		src  string
+		want []padding.Narrowing
+	}{
+		{
+			name: "wider than the other counters",
+			src:  "type T struct { ID int64; RetryCount int64; Attempts, Depth int32; Done bool }",
+			want: []padding.Narrowing{{Field: "RetryCount", Type: "int64", Suggested: "int32", Saved: 8}},
+		},
+		{
+			name: "int among uint16 counters",
+			src:  "type T struct { idx int; num_items, level uint16; ok bool }",
+			want: []padding.Narrowing{{Field: "idx", Type: "int", Suggested: "uint16", Saved: 8}},
+		},
+		{
+			name: "silenced",
+			src:  "type T struct {\n\tID int64\n\tRetryCount int64 //padding:keep-type\n\tAttempts, Depth int32\n\tDone bool\n}",
+		},
+		{
+			// ID and Offset don't look like counters, so RetryCount has
+			// no context.
+			name: "no sibling counters",
+			src:  "type T struct { RetryCount int64; ID, Offset int32; Done bool }",
+		},
+		{
+			name: "as wide as another counter",
+			src:  "type T struct { RetryCount, Index int64; Depth int32; Done bool }",
+		},
+		{
+			// Narrowed, Count would leave padding of the same size.
+			name: "nothing saved",
+			src:  "type T struct { ID int64; Count int64; Depth, Level int32 }",
+		},
+	} {
+		if got := padding.Narrowings(analyzeOne(t, tt.src)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Narrowings = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFprintNarrowings(t *testing.T) {
+	s := analyzeOne(t, "type T struct { ID int64; RetryCount int64; Attempts, Depth int32; Done bool }")
+	r := padding.NewStructReport(s)
+	r.CheckSuggestions(s)
+	var b strings.Builder
+	padding.FprintStruct(&b, r)
+	want := "  Suggestion (heuristic, check the range of its values): RetryCount int64 could be int32 like the other counters," +
+		" saving 8 bytes (size 32 → 24); silence with //padding:keep-type\n"
+	if !strings.Contains(b.String(), want) {
+		t.Errorf("report lacks %q:\n%s", want, b.String())
+	}
+}
+
+// sized returns a struct of fields of the given types, where a type of the
+// form [n]Block is an array of n 1-byte-aligned 64-byte blocks, as resolved
+// from a struct of the package.
+func sized(name string, fields ...string) padding.StructInfo {
+	s := padding.StructInfo{Name: name}
+	for i, typ := range fields {
+		f := padding.FieldInfo{Name: string(rune('a' + i)), Type: typ}
+		var n int64
+		if _, err := fmt.Sscanf(typ, "[%d]Block", &n); err == nil {
+			f.Size, f.Align = n*64, 1
+		}
+		s.Fields = append(s.Fields, f)
+	}
+	padding.AnalyzeStruct(&s)
+	return s
+}
+
+func TestSuggestIndirection(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		s        padding.StructInfo
+		fraction float64
+		want     padding.Indirection
+		ok       bool
+	}{
+		{
+			"scratch buffer",
+			sized("T", "int64", "[64]Block", "int32", "bool"),
+			0.5,
+			padding.Indirection{Field: "b", Type: "[64]Block", Pointer: "*[64]Block", Size: 4096, NewSize: 24},
+			true,
+		},
+		// The rest is still larger than a cache line.
+		{"rest too large", sized("T", "string", "string", "string", "string", "string", "[64]Block"), 0.5, padding.Indirection{}, false},
+		// No field takes more than half of the struct.
+		{"not dominant", sized("T", "[3]Block", "[2]Block"), 0.5, padding.Indirection{}, false},
+		// The rest of the struct is a pointer to the largest and the
+		// other field, 128 bytes.
+		{"lower fraction, rest too large", sized("T", "[3]Block", "[2]Block"), 0.4, padding.Indirection{}, false},
+		{"lower fraction", sized("T", "[3]Block", "int64", "int64"), 0.4,
+			padding.Indirection{Field: "a", Type: "[3]Block", Pointer: "*[3]Block", Size: 192, NewSize: 24}, true},
+		// Within a cache line already.
+		{"small", sized("T", "bool", "string", "string"), 0.5, padding.Indirection{}, false},
+	} {
+		got, ok := padding.SuggestIndirection(tt.s, tt.fraction, 64)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("%s: SuggestIndirection = %+v, %v, want %+v, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFprintIndirection(t *testing.T) {
+	s := sized("T", "int64", "[64]Block", "int32", "bool")
+	r := padding.NewStructReport(s)
+	r.CheckIndirection(s, 0.5, 64)
+	var b strings.Builder
+	padding.FprintStruct(&b, r)
+	want := "  Suggestion: b [64]Block takes 4096 of 4112 bytes; as *[64]Block the struct would fit a 64-byte cache line" +
+		" (size 4112 → 24), at the cost of allocating b separately and following the pointer on every access\n"
+	if !strings.Contains(b.String(), want) {
+		t.Errorf("report lacks %q:\n%s", want, b.String())
+	}
+
+	r.CheckIndirection(s, 0.5, 8192)
+	if r.Indirection != nil {
+		t.Errorf("Indirection = %+v within an 8192-byte line", r.Indirection)
+	}
+}
@@ -0,0 +1,19 @@
+package padding
+
+// FreeTail returns the trailing padding of s, the bytes after its last field
+// up to its size, where fields can be added without growing it, and the
+// size of the largest field of 1, 2, 4 or 8 bytes, aligned to its size, that
+// fits there; room is 0 if none does.
+func FreeTail(s StructInfo) (free, room int64) {
+	if len(s.Fields) == 0 {
+		return 0, 0
+	}
+	last := s.Fields[len(s.Fields)-1]
+	end := last.Offset + last.Size
+	for n := int64(8); n >= 1; n /= 2 {
+		if align(end, n)+n <= s.Size {
+			return s.Size - end, n
+		}
+	}
+	return s.Size - end, 0
+}
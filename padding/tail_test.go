@@ -0,0 +1,85 @@
+package padding_test
+
+import (
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestFreeTail(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields []padding.FieldInfo
+		free   int64
+		room   int64
+	}{
+		{
+			name: "none",
+			fields: []padding.FieldInfo{
+				{Name: "a", Type: "int64", Size: 8, Align: 8},
+				{Name: "b", Type: "int32", Size: 4, Align: 4},
+				{Name: "c", Type: "int32", Size: 4, Align: 4},
+			},
+		},
+		{
+			name: "small",
+			fields: []padding.FieldInfo{
+				{Name: "a", Type: "int32", Size: 4, Align: 4},
+				{Name: "b", Type: "int16", Size: 2, Align: 2},
+				{Name: "c", Type: "bool", Size: 1, Align: 1},
+			},
+			free: 1,
+			room: 1,
+		},
+		{
+			// 41 bytes of fields laid out in 48: an int32 fits in the
+			// 7 bytes at the end, an int64 doesn't.
+			name: "tail of a 41-byte layout",
+			fields: []padding.FieldInfo{
+				{Name: "id", Type: "string", Size: 16, Align: 8},
+				{Name: "name", Type: "string", Size: 16, Align: 8},
+				{Name: "n", Type: "int64", Size: 8, Align: 8},
+				{Name: "ok", Type: "bool", Size: 1, Align: 1},
+			},
+			free: 7,
+			room: 4,
+		},
+		{
+			// The largest trailing padding, a word less one byte.
+			name: "maximal",
+			fields: []padding.FieldInfo{
+				{Name: "n", Type: "int64", Size: 8, Align: 8},
+				{Name: "ok", Type: "bool", Size: 1, Align: 1},
+			},
+			free: 7,
+			room: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := padding.StructInfo{Name: "T", Fields: tt.fields}
+			padding.AnalyzeStruct(&s)
+			free, room := padding.FreeTail(s)
+			if free != tt.free || room != tt.room {
+				t.Errorf("FreeTail = %d, %d, want %d, %d", free, room, tt.free, tt.room)
+			}
+		})
+	}
+}
+
+func TestCheckFreeTail(t *testing.T) {
+	s := padding.StructInfo{
+		Name: "T",
+		Fields: []padding.FieldInfo{
+			{Name: "a", Type: "bool", Size: 1, Align: 1},
+			{Name: "b", Type: "int64", Size: 8, Align: 8},
+			{Name: "c", Type: "int16", Size: 2, Align: 2},
+		},
+	}
+	padding.AnalyzeStruct(&s)
+	r := padding.NewStructReport(s)
+	r.CheckFreeTail(s)
+	if r.FreeTail != 6 || r.FreeTailRoom != 4 || r.OptimalFreeTail != 5 {
+		t.Errorf("free tail = %d (room %d), optimal %d, want 6 (room 4), optimal 5", r.FreeTail, r.FreeTailRoom, r.OptimalFreeTail)
+	}
+}
@@ -0,0 +1,79 @@
+package anonymous
+
+import "sync"
+
+// A package-level variable.
+var cache struct {
+	ready bool
+	m     map[string]int
+	mu    sync.Mutex
+	hits  int64
+	done  bool
+}
+
+// A field type.
+type Config struct {
+	Name   string
+	limits struct {
+		burst bool
+		rate  int64
+		max   int32
+	}
+}
+
+// A parameter type.
+func apply(opts struct {
+	verbose bool
+	level   int64
+}) {
+}
+
+func run() {
+	// A variable in a function body.
+	var state struct {
+		done  bool
+		count int64
+		ok    bool
+	}
+	_ = state
+
+	// A composite literal.
+	_ = []struct {
+		in   bool
+		want int64
+	}{{true, 1}}
+
+	// Too small to report.
+	done := make(chan struct{})
+	_ = struct{ n int }{1}
+	_ = done
+}
+
+// Anonymous structs nested two levels deep.
+type Server struct {
+	addr string
+	tls  struct {
+		enabled bool
+		session struct {
+			ticket bool
+			ttl    int64
+			reuse  bool
+		}
+		strict bool
+	}
+}
+
+// A field type spelled out again by a composite literal.
+type Entry struct {
+	meta struct {
+		seen bool
+		at   int64
+		ok   bool
+	}
+}
+
+var entry = Entry{meta: struct {
+	seen bool
+	at   int64
+	ok   bool
+}{true, 1, false}}
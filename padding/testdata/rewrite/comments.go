@@ -0,0 +1,49 @@
+package rewrite
+
+import "sync"
+
+// Conn is a connection to a peer.
+type Conn struct { // guarded by mu
+	// open is set until Close.
+	open bool
+	// Sent and received bytes.
+	sent, recv int64 `json:"-"`
+	flag       bool  // set by the peer
+
+	peers map[string][]*Conn
+	mu    sync.Mutex
+	limit   int32   // unaligned on purpose
+
+	// More fields go here.
+}
+
+// Sections heads its counters with a comment of no field, which reordering
+// would leave above other fields, so it is left alone.
+type Sections struct {
+	ok bool
+
+	// Counters.
+
+	n   int64
+	b bool
+}
+
+// Tight is laid out well already, formatting left as is.
+type Tight struct {
+	n   int64 // count
+	ok  bool
+	 on bool
+}
+
+// Outer moves its field of an anonymous struct type as written.
+type Outer struct {
+	ok    bool
+	inner struct {
+		a bool // first
+		n int64
+	}
+	n int64
+	b bool
+}
+
+func helper()  {  }
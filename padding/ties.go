@@ -0,0 +1,49 @@
+package padding
+
+import (
+	"cmp"
+	"go/ast"
+	"slices"
+)
+
+// SortTies returns a copy of s with the fields that can trade places without
+// changing the layout in alphabetical order among the places they take.
+// Fields trade places if they have the same size and alignment, and also
+// the same pointer words, visibility and //padding:hot directive, so that
+// the orders of GCOrder, VisibilityOrder and hot fields are kept, and only
+// between the same cache-line pads. The other fields, and the first
+// KeepFirst, don't move. s itself is not modified, and sorting the result
+// again changes nothing.
+func SortTies(s StructInfo) StructInfo {
+	type tie struct {
+		size, align, pointers int64
+		exported, hot         bool
+		segment               int
+	}
+	places := make(map[tie][]int)
+	segment := 0
+	word := s.sizes().word
+	for i, f := range s.Fields[s.kept():] {
+		i += s.kept()
+		if IsCacheLinePad(f) {
+			segment++
+			continue
+		}
+		t := tie{f.Size, f.Align, pointerBytes(f.Type, f.Size, word), ast.IsExported(f.Name), f.HasDirective("hot"), segment}
+		places[t] = append(places[t], i)
+	}
+	fields := slices.Clone(s.Fields)
+	for _, p := range places {
+		tied := make([]FieldInfo, len(p))
+		for i, j := range p {
+			tied[i] = s.Fields[j]
+		}
+		slices.SortStableFunc(tied, func(a, b FieldInfo) int { return cmp.Compare(a.Name, b.Name) })
+		for i, j := range p {
+			fields[j] = tied[i]
+		}
+	}
+	s.Fields = fields
+	layoutFields(&s)
+	return s
+}
@@ -0,0 +1,46 @@
+package padding_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestSortTies(t *testing.T) {
+	s := padding.StructInfo{
+		Name: "T",
+		Fields: []padding.FieldInfo{
+			{Name: "zone", Type: "bool", Size: 1, Align: 1},
+			{Name: "id", Type: "int64", Size: 8, Align: 8},
+			{Name: "alive", Type: "bool", Size: 1, Align: 1},
+			{Name: "count", Type: "int64", Size: 8, Align: 8},
+			{Name: "name", Type: "*string", Size: 8, Align: 8},
+			{Name: "Mode", Type: "bool", Size: 1, Align: 1},
+		},
+	}
+	padding.AnalyzeStruct(&s)
+	names := func(s padding.StructInfo) []string {
+		var names []string
+		for _, f := range s.Fields {
+			names = append(names, f.Name)
+		}
+		return names
+	}
+
+	o := padding.Optimal(s)
+	if got, want := names(o), []string{"id", "count", "name", "zone", "alive", "Mode"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("source order = %v, want %v", got, want)
+	}
+	// The pointer name and the exported Mode keep their places.
+	alpha := padding.SortTies(o)
+	if got, want := names(alpha), []string{"count", "id", "name", "alive", "zone", "Mode"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("alphabetical order = %v, want %v", got, want)
+	}
+	if alpha.Size != o.Size || alpha.Fields[1].Offset != 8 {
+		t.Errorf("SortTies changed the layout: size %d, offset of id %d", alpha.Size, alpha.Fields[1].Offset)
+	}
+	if again := padding.SortTies(alpha); !reflect.DeepEqual(names(again), names(alpha)) {
+		t.Errorf("sorting again gives %v", names(again))
+	}
+}
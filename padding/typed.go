@@ -0,0 +1,108 @@
+package padding
+
+import "go/types"
+
+// TypeSizes sizes the fields of s with the type checker's view of them
+// rather than from their type expressions: st is the type-checked struct s
+// was collected from, and sizes lays out its field types, so that named
+// types, aliases and the types of other packages, such as time.Time, get
+// their true size and alignment. Each field also gets the Kind of its
+// underlying type, and s is laid out again. The fields sized by type
+// parameters, laid out only once instantiated, and cache line pads are
+// skipped, but for the Kind of those Analyze bound to a single type. TypeSizes reports whether st matched the fields of s; if not, s
+// is left alone.
+func TypeSizes(s *StructInfo, st *types.Struct, sizes types.Sizes) bool {
+	if st.NumFields() != len(s.Fields) {
+		return false
+	}
+	for i := range st.NumFields() {
+		if st.Field(i).Name() != s.Fields[i].Name {
+			return false
+		}
+	}
+	for i := range st.NumFields() {
+		v, f := st.Field(i), &s.Fields[i]
+		if IsCacheLinePad(*f) {
+			continue
+		}
+		if sizedByParams(v.Type()) {
+			// Analyze sized those bound to a single type by it.
+			if !f.ParamSized {
+				f.Kind = paramKind(v.Type())
+			}
+			continue
+		}
+		f.Size, f.Align = clampSize(sizes.Sizeof(v.Type())), sizes.Alignof(v.Type())
+		f.Kind = Kind(v.Type())
+	}
+	layoutFields(s)
+	return true
+}
+
+// Kind returns the kind of the underlying type of t: the name of a basic
+// type, such as uint32 or unsafe.Pointer, or one of array, chan, func,
+// interface, map, pointer, slice and struct.
+func Kind(t types.Type) string {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		return u.Name()
+	case *types.Array:
+		return "array"
+	case *types.Chan:
+		return "chan"
+	case *types.Signature:
+		return "func"
+	case *types.Interface:
+		return "interface"
+	case *types.Map:
+		return "map"
+	case *types.Pointer:
+		return "pointer"
+	case *types.Slice:
+		return "slice"
+	case *types.Struct:
+		return "struct"
+	}
+	return ""
+}
+
+// sizedByParams reports whether the size of t depends on type parameters,
+// held by value in t, directly or through arrays and structs.
+func sizedByParams(t types.Type) bool {
+	switch t := types.Unalias(t).(type) {
+	case *types.TypeParam:
+		return true
+	case *types.Array:
+		return sizedByParams(t.Elem())
+	case *types.Named:
+		return t.TypeArgs().Len() > 0 && sizedByParams(t.Underlying())
+	case *types.Struct:
+		for i := range t.NumFields() {
+			if sizedByParams(t.Field(i).Type()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// paramKind returns the Kind of t, sized by type parameters, with those
+// constrained to a single type, as by ~int64, taken as that type; the kind
+// of a type parameter constrained otherwise is empty.
+func paramKind(t types.Type) string {
+	tp, ok := types.Unalias(t).(*types.TypeParam)
+	if !ok {
+		return Kind(t)
+	}
+	iface, ok := tp.Constraint().Underlying().(*types.Interface)
+	if !ok || iface.NumEmbeddeds() != 1 {
+		return ""
+	}
+	if union, ok := iface.EmbeddedType(0).(*types.Union); ok {
+		if union.Len() != 1 {
+			return ""
+		}
+		return Kind(union.Term(0).Type())
+	}
+	return Kind(iface.EmbeddedType(0))
+}
@@ -0,0 +1,91 @@
+package padding_test
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestTypeSizes(t *testing.T) {
+	src := `package p
+
+import "time"
+
+type ID uint32
+
+type Short = int16
+
+type T struct {
+	ok   bool
+	when time.Time
+	id   ID
+	s    Short
+	ids  [3]ID
+	e    error
+	m    map[string]int
+}
+
+type G[V any] struct {
+	ok  bool
+	val V
+	n   int64
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{Types: make(map[ast.Expr]types.TypeAndValue)}
+	conf := types.Config{Importer: importer.Default()}
+	if _, err := conf.Check("p", fset, []*ast.File{file}, info); err != nil {
+		t.Fatal(err)
+	}
+	structs, err := padding.Analyze(fset, file, padding.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sizes := types.SizesFor("gc", "amd64")
+
+	s := structs[0]
+	if !padding.TypeSizes(&s, info.TypeOf(s.Node).(*types.Struct), sizes) {
+		t.Fatal("T does not match its type")
+	}
+	want := []struct {
+		size, align int64
+		kind        string
+	}{
+		{1, 1, "bool"}, {24, 8, "struct"}, {4, 4, "uint32"}, {2, 2, "int16"},
+		{12, 4, "array"}, {16, 8, "interface"}, {8, 8, "map"},
+	}
+	for i, f := range s.Fields {
+		if w := want[i]; f.Size != w.size || f.Align != w.align || f.Kind != w.kind {
+			t.Errorf("%s: size %d, align %d, kind %q, want %d, %d, %q", f.Name, f.Size, f.Align, f.Kind, w.size, w.align, w.kind)
+		}
+	}
+	if gc := sizes.Sizeof(info.TypeOf(s.Node)); s.Size != gc {
+		t.Errorf("size %d, gc %d", s.Size, gc)
+	}
+
+	// The field sized by a type parameter keeps its guess.
+	g := structs[1]
+	if !padding.TypeSizes(&g, info.TypeOf(g.Node).(*types.Struct), sizes) {
+		t.Fatal("G does not match its type")
+	}
+	if val := g.Fields[1]; val.Kind != "" || val.Size != 8 {
+		t.Errorf("val: kind %q, size %d, want no kind and the guess of 8", val.Kind, val.Size)
+	}
+	if n := g.Fields[2]; n.Kind != "int64" {
+		t.Errorf("n: kind %q, want int64", n.Kind)
+	}
+
+	// Another struct's type doesn't match.
+	if padding.TypeSizes(&s, info.TypeOf(g.Node).(*types.Struct), sizes) {
+		t.Error("T matches the type of G")
+	}
+}
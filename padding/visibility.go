@@ -0,0 +1,88 @@
+package padding
+
+import (
+	"cmp"
+	"go/ast"
+	"slices"
+)
+
+// VisibilityOrder returns a copy of s with its exported fields before its
+// unexported ones and each block reordered to minimize the padding of the
+// whole struct. s itself is not modified.
+func VisibilityOrder(s StructInfo) StructInfo {
+	fields := slices.Clone(s.Fields)
+	s.Fields = fields
+	AnalyzeStruct(&s)
+
+	s.Fields = make([]FieldInfo, len(fields))
+	for i, j := range VisibilityPermutation(StructInfo{Fields: fields, KeepFirst: s.KeepFirst}) {
+		s.Fields[i] = fields[j]
+	}
+
+	layoutFields(&s)
+	return s
+}
+
+// VisibilityPermutation returns the field order of VisibilityOrder as a
+// permutation: the i-th field of that layout is s.Fields[order[i]]. Each
+// block keeps its order, is sorted by decreasing alignment, or by increasing
+// alignment so that its small fields fill the padding the block before it
+// leaves; of these, the smallest struct moving the fewest fields wins.
+// The first KeepFirst fields and cache-line pads keep their places,
+// exported fields coming first after the former and between each two of the
+// latter. s itself is not modified.
+func VisibilityPermutation(s StructInfo) (order []int) {
+	if order, ok := byPrefix(s, VisibilityPermutation); ok {
+		return order
+	}
+	if order, ok := bySegments(s, VisibilityPermutation); ok {
+		return order
+	}
+	slots := make([]slot, len(s.Fields))
+	sizes := s.sizes()
+	var exported, unexported []int
+	for i, f := range s.Fields {
+		if f.Align == 0 {
+			f.Size, f.Align = sizes.getFieldSize(f.Type), sizes.getFieldAlign(f.Type)
+		}
+		slots[i] = slot{f.Size, f.Align}
+		if ast.IsExported(f.Name) {
+			exported = append(exported, i)
+		} else {
+			unexported = append(unexported, i)
+		}
+	}
+
+	var size int64
+	moved := 0
+	for _, e := range blockOrders(slots, exported) {
+		for _, u := range blockOrders(slots, unexported) {
+			candidate := slices.Concat(e, u)
+			sz, m := slotsSize(slots, candidate), len(Moved(candidate))
+			if order == nil || sz < size || sz == size && m < moved {
+				order, size, moved = candidate, sz, m
+			}
+		}
+	}
+	return order
+}
+
+// blockOrders returns the orders VisibilityPermutation tries for the fields
+// of block, indices into slots: their own, the one of optimalOrder, and by
+// increasing alignment and size with zero-size fields first.
+func blockOrders(slots []slot, block []int) [][]int {
+	sub := make([]slot, len(block))
+	for i, j := range block {
+		sub[i] = slots[j]
+	}
+	ascending := slices.Clone(block)
+	slices.SortStableFunc(ascending, func(i, j int) int {
+		a, b := slots[i], slots[j]
+		return cmp.Or(trueFirst(a.size == 0, b.size == 0), cmp.Compare(a.align, b.align), cmp.Compare(a.size, b.size))
+	})
+	sorted := make([]int, len(block))
+	for i, j := range optimalOrder(sub) {
+		sorted[i] = block[j]
+	}
+	return [][]int{block, sorted, ascending}
+}
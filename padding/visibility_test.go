@@ -0,0 +1,64 @@
+package padding_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+func TestVisibilityOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields []padding.FieldInfo
+		want   []string
+		size   int64
+		cost   int64
+	}{
+		{
+			// The unexported bool and int16 fill the padding after Flag.
+			name: "free",
+			fields: []padding.FieldInfo{
+				{Name: "Flag", Type: "bool", Size: 1, Align: 1},
+				{Name: "ID", Type: "int64", Size: 8, Align: 8},
+				{Name: "count", Type: "int64", Size: 8, Align: 8},
+				{Name: "port", Type: "int16", Size: 2, Align: 2},
+				{Name: "ok", Type: "bool", Size: 1, Align: 1},
+			},
+			want: []string{"ID", "Flag", "ok", "port", "count"},
+			size: 24,
+		},
+		{
+			// A zero-size last field is padded, and the marker can't
+			// move before the exported fields.
+			name: "zero-size marker",
+			fields: []padding.FieldInfo{
+				{Name: "ID", Type: "int64", Size: 8, Align: 8},
+				{Name: "Name", Type: "string", Size: 16, Align: 8},
+				{Name: "_", Type: "struct{}", Size: 0, Align: 1},
+			},
+			want: []string{"ID", "Name", "_"},
+			size: 32,
+			cost: 8,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := padding.StructInfo{Name: "T", Fields: tt.fields}
+			padding.AnalyzeStruct(&s)
+			v := padding.VisibilityOrder(s)
+			var names []string
+			for _, f := range v.Fields {
+				names = append(names, f.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("order = %v, want %v", names, tt.want)
+			}
+			r := padding.NewStructReport(s)
+			r.CheckVisibility(s)
+			if r.VisibilitySize != tt.size || r.VisibilityCost != tt.cost {
+				t.Errorf("size %d, cost %d, want %d, %d", r.VisibilitySize, r.VisibilityCost, tt.size, tt.cost)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package paddingcheck
+
+import (
+	"fmt"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// Layout is the fact exported for every exported struct type: its size and
+// alignment as computed when its package was analyzed. Dependent packages
+// size fields of that type from the fact, so that under go vet, where each
+// package is analyzed on its own, layouts match those of the whole-program
+// CLI.
+type Layout struct {
+	Size  int64
+	Align int64
+}
+
+func (*Layout) AFact() {}
+
+func (l *Layout) String() string {
+	return fmt.Sprintf("layout(size=%d, align=%d)", l.Size, l.Align)
+}
+
+// exportLayout records the layout of the package-level exported type obj.
+func exportLayout(pass *analysis.Pass, obj types.Object, size, align int64) {
+	if !obj.Exported() || obj.Parent() != pass.Pkg.Scope() {
+		return
+	}
+	pass.ExportObjectFact(obj, &Layout{Size: size, Align: align})
+}
+
+// factSizes sizes named types of other packages from their Layout facts and
+// everything else with the underlying Sizes.
+type factSizes struct {
+	types.Sizes
+	pkg    *types.Package
+	layout func(*types.TypeName) (*Layout, bool)
+}
+
+func newFactSizes(pass *analysis.Pass, sizes types.Sizes) factSizes {
+	return factSizes{
+		Sizes: sizes,
+		pkg:   pass.Pkg,
+		layout: func(obj *types.TypeName) (*Layout, bool) {
+			l := new(Layout)
+			return l, pass.ImportObjectFact(obj, l)
+		},
+	}
+}
+
+func (s factSizes) Sizeof(t types.Type) int64 {
+	switch t := t.(type) {
+	case *types.Named:
+		if l, ok := s.imported(t); ok {
+			return l.Size
+		}
+	case *types.Array:
+		if l, ok := s.imported(t.Elem()); ok && t.Len() > 0 {
+			// Elements are laid out at a stride of their aligned size.
+			stride := (l.Size + l.Align - 1) / l.Align * l.Align
+			return stride*(t.Len()-1) + l.Size
+		}
+	}
+	return s.Sizes.Sizeof(t)
+}
+
+func (s factSizes) Alignof(t types.Type) int64 {
+	switch t := t.(type) {
+	case *types.Named:
+		if l, ok := s.imported(t); ok {
+			return l.Align
+		}
+	case *types.Array:
+		if l, ok := s.imported(t.Elem()); ok {
+			return l.Align
+		}
+	}
+	return s.Sizes.Alignof(t)
+}
+
+// imported returns the Layout fact of t if t is a non-generic named type
+// declared in another package.
+func (s factSizes) imported(t types.Type) (*Layout, bool) {
+	named, ok := t.(*types.Named)
+	if !ok || named.TypeArgs() != nil {
+		return nil, false
+	}
+	obj := named.Obj()
+	if obj.Pkg() == nil || obj.Pkg() == s.pkg {
+		return nil, false
+	}
+	return s.layout(obj)
+}
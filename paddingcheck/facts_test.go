@@ -0,0 +1,46 @@
+package paddingcheck
+
+import (
+	"go/token"
+	"go/types"
+	"testing"
+)
+
+// TestFactSizesUsesFacts checks that types of other packages are sized from
+// their facts rather than from the underlying Sizes.
+func TestFactSizesUsesFacts(t *testing.T) {
+	dep := types.NewPackage("example.com/dep", "dep")
+	local := types.NewPackage("example.com/local", "local")
+	newNamed := func(pkg *types.Package, name string) *types.Named {
+		fields := []*types.Var{types.NewField(token.NoPos, pkg, "A", types.Typ[types.Int64], false)}
+		return types.NewNamed(types.NewTypeName(token.NoPos, pkg, name, nil), types.NewStruct(fields, nil), nil)
+	}
+	foreign := newNamed(dep, "T")
+	own := newNamed(local, "T")
+
+	sizes := factSizes{
+		Sizes: types.SizesFor("gc", "amd64"),
+		pkg:   local,
+		layout: func(obj *types.TypeName) (*Layout, bool) {
+			return &Layout{Size: 12, Align: 4}, true
+		},
+	}
+
+	tests := []struct {
+		typ         types.Type
+		size, align int64
+	}{
+		{foreign, 12, 4},
+		{types.NewArray(foreign, 3), 36, 4},
+		{types.NewPointer(foreign), 8, 8},
+		{own, 8, 8},
+	}
+	for _, tt := range tests {
+		if got := sizes.Sizeof(tt.typ); got != tt.size {
+			t.Errorf("Sizeof(%s) = %d, want %d", tt.typ, got, tt.size)
+		}
+		if got := sizes.Alignof(tt.typ); got != tt.align {
+			t.Errorf("Alignof(%s) = %d, want %d", tt.typ, got, tt.align)
+		}
+	}
+}
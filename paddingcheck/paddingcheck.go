@@ -0,0 +1,288 @@
+// Package paddingcheck defines an Analyzer that reports struct types whose
+// fields could be reordered to use less memory.
+//
+// Field sizes and alignments come from the type checker (pass.TypesSizes),
+// so the reported layouts are exact for the target platform. The layout of
+// every exported struct type is exported as a Layout fact, which dependent
+// packages use to size fields of that type.
+package paddingcheck
+
+import (
+	"cmp"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/token"
+	"go/types"
+	"reflect"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+const doc = `report structs that waste memory on padding
+
+The paddingcheck analyzer reports struct types whose size would shrink if
+their fields were reordered, along with the number of bytes that reordering
+would save. Each report carries a suggested fix reordering the fields, unless
+the struct's field order is observable through unsafe.Offsetof, unsafe
+pointer conversions or positional composite literals, or a comment of the
+struct body that belongs to no field heads one of its fields. The order and
+the fix are those of padding-size -fix: a leading embedded lock, the fields
+a //padding:keep-first=N directive pins and cache-line pads keep their
+places.
+
+Structs whose type declaration carries a //padding:ignore doc comment are
+not reported. With -skip-generated, structs in generated files are not
+reported either. With -preserve-marshal-order, structs with json, xml, yaml,
+toml, bson or msgpack tags are not reported, since those encodings follow
+the field order.`
+
+// Analyzer reports struct types with avoidable padding.
+var Analyzer = &analysis.Analyzer{
+	Name:      "paddingcheck",
+	Doc:       doc,
+	Flags:     flags(),
+	Requires:  []*analysis.Analyzer{inspect.Analyzer},
+	Run:       run,
+	FactTypes: []analysis.Fact{new(Layout)},
+}
+
+var (
+	minWaste             int64
+	arch                 string
+	skipGenerated        bool
+	preserveMarshalOrder bool
+)
+
+func flags() flag.FlagSet {
+	fs := flag.NewFlagSet("paddingcheck", flag.ExitOnError)
+	fs.Int64Var(&minWaste, "min-waste", 1, "only report structs wasting at least this many bytes")
+	fs.StringVar(&arch, "arch", "", "compute layouts for this GOARCH instead of the build target")
+	fs.BoolVar(&skipGenerated, "skip-generated", false, "don't report structs in generated files")
+	fs.BoolVar(&preserveMarshalOrder, "preserve-marshal-order", false, "don't report structs with encoding tags, whose field order is visible in marshaled output")
+	return *fs
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	sizes := pass.TypesSizes
+	if arch != "" {
+		sizes = types.SizesFor("gc", arch)
+		if sizes == nil {
+			return nil, fmt.Errorf("unknown architecture %q", arch)
+		}
+	}
+	sizes = newFactSizes(pass, sizes)
+
+	excluded := unsafeOrPositional(pass)
+	generated := make(map[*token.File]bool)
+	if skipGenerated {
+		for _, file := range pass.Files {
+			if ast.IsGenerated(file) {
+				generated[pass.Fset.File(file.Pos())] = true
+			}
+		}
+	}
+
+	// The doc comment of an unparenthesized declaration belongs to the
+	// GenDecl rather than to its only TypeSpec.
+	docs := make(map[*ast.TypeSpec]*ast.CommentGroup)
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.TYPE && !gen.Lparen.IsValid() && len(gen.Specs) == 1 {
+				docs[gen.Specs[0].(*ast.TypeSpec)] = gen.Doc
+			}
+		}
+	}
+
+	// The structs of each file as padding-size collects them, with their
+	// directives, by declaration.
+	infos := make(map[*ast.StructType]padding.StructInfo)
+	files := make(map[*token.File]*ast.File)
+	for _, file := range pass.Files {
+		files[pass.Fset.File(file.Pos())] = file
+		structs, err := padding.Analyze(pass.Fset, file, padding.Options{Arch: cmp.Or(arch, build.Default.GOARCH)})
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range structs {
+			if s.Node != nil {
+				infos[s.Node] = s
+			}
+		}
+	}
+
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	inspect.Preorder([]ast.Node{(*ast.TypeSpec)(nil)}, func(n ast.Node) {
+		spec := n.(*ast.TypeSpec)
+		if spec.TypeParams != nil {
+			return // the layout depends on the instantiation
+		}
+		node, ok := spec.Type.(*ast.StructType)
+		if !ok {
+			return
+		}
+		obj := pass.TypesInfo.Defs[spec.Name]
+		if obj == nil {
+			return
+		}
+		st, ok := obj.Type().Underlying().(*types.Struct)
+		if !ok {
+			return
+		}
+
+		_, current := padding.Layout(st, sizes)
+		exportLayout(pass, obj, current.Size, current.Align)
+		if current.TooLarge || generated[pass.Fset.File(spec.Pos())] || preserveMarshalOrder && hasMarshalTags(st) {
+			return
+		}
+		if doc := spec.Doc; padding.IgnoreDirective(doc) || doc == nil && padding.IgnoreDirective(docs[spec]) {
+			return
+		}
+
+		// The fields are sized by the type checker, and ordered as
+		// padding-size orders them.
+		s, ok := infos[node]
+		if !ok || !padding.TypeSizes(&s, st, sizes) {
+			return
+		}
+		optimal := padding.Optimal(s)
+		waste := s.Size - optimal.Size
+		if waste <= 0 || waste < minWaste {
+			return
+		}
+
+		diag := analysis.Diagnostic{
+			Pos: spec.Pos(),
+			Message: fmt.Sprintf("struct %s is %d bytes but could be %d (%d bytes of padding)",
+				spec.Name.Name, s.Size, optimal.Size, waste),
+		}
+		// Structs accessed through unsafe or built with positional
+		// literals depend on their field order; report but don't fix them.
+		named, _ := obj.Type().(*types.Named)
+		if !excluded[named] {
+			if fix, ok := suggestFix(pass, files[pass.Fset.File(spec.Pos())], optimal); ok {
+				diag.SuggestedFixes = []analysis.SuggestedFix{fix}
+			}
+		}
+		pass.Report(diag)
+	})
+	return nil, nil
+}
+
+// suggestFix returns the fix rewriting the declaration of s, a struct of
+// file in its optimal order, as padding.Rewrite does, or false if Rewrite
+// leaves it alone.
+func suggestFix(pass *analysis.Pass, file *ast.File, s padding.StructInfo) (analysis.SuggestedFix, bool) {
+	if file == nil {
+		return analysis.SuggestedFix{}, false
+	}
+	tf := pass.Fset.File(file.Pos())
+	src, err := pass.ReadFile(tf.Name())
+	if err != nil {
+		return analysis.SuggestedFix{}, false
+	}
+	edits, err := padding.RewriteEdits(pass.Fset, file, src, []padding.StructInfo{s})
+	if err != nil || len(edits) == 0 {
+		return analysis.SuggestedFix{}, false
+	}
+	fix := analysis.SuggestedFix{Message: "Reorder fields to reduce padding"}
+	for _, e := range edits {
+		fix.TextEdits = append(fix.TextEdits, analysis.TextEdit{Pos: tf.Pos(e.Start), End: tf.Pos(e.End), NewText: []byte(e.Text)})
+	}
+	return fix, true
+}
+
+// marshalTagKeys are the struct tag keys of encodings that write fields in
+// declaration order.
+var marshalTagKeys = []string{"json", "xml", "yaml", "toml", "bson", "msgpack"}
+
+// hasMarshalTags reports whether a field of st has a tag for one of
+// marshalTagKeys.
+func hasMarshalTags(st *types.Struct) bool {
+	for i := 0; i < st.NumFields(); i++ {
+		tag := reflect.StructTag(st.Tag(i))
+		for _, key := range marshalTagKeys {
+			if _, ok := tag.Lookup(key); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// unsafeOrPositional collects the struct types of the package that must not
+// be reordered: those whose field offsets are taken with unsafe.Offsetof or
+// whose pointers are converted from unsafe.Pointer, and those built with
+// positional (unkeyed) composite literals.
+func unsafeOrPositional(pass *analysis.Pass) map[*types.Named]bool {
+	excluded := make(map[*types.Named]bool)
+	named := func(t types.Type) *types.Named {
+		if p, ok := t.Underlying().(*types.Pointer); ok {
+			t = p.Elem()
+		}
+		n, _ := t.(*types.Named)
+		return n
+	}
+
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.CompositeLit:
+				if len(n.Elts) == 0 {
+					break
+				}
+				if _, keyed := n.Elts[0].(*ast.KeyValueExpr); keyed {
+					break
+				}
+				if tv, ok := pass.TypesInfo.Types[n]; ok {
+					if t := named(tv.Type); t != nil {
+						excluded[t] = true
+					}
+				}
+			case *ast.CallExpr:
+				fn := unsafeBuiltin(pass, n)
+				if fn == "unsafe.Offsetof" && len(n.Args) == 1 {
+					if sel, ok := ast.Unparen(n.Args[0]).(*ast.SelectorExpr); ok {
+						if selection := pass.TypesInfo.Selections[sel]; selection != nil {
+							if t := named(selection.Recv()); t != nil {
+								excluded[t] = true
+							}
+						}
+					}
+				}
+				// A conversion (*T)(unsafe.Pointer(p)).
+				if tv, ok := pass.TypesInfo.Types[n.Fun]; ok && tv.IsType() && len(n.Args) == 1 {
+					if arg, ok := pass.TypesInfo.Types[n.Args[0]]; ok && types.Identical(arg.Type, types.Typ[types.UnsafePointer]) {
+						if t := named(tv.Type); t != nil {
+							excluded[t] = true
+						}
+					}
+				}
+			}
+			return true
+		})
+	}
+	return excluded
+}
+
+// unsafeBuiltin returns "unsafe.Name" for calls of the builtins of package
+// unsafe and the empty string otherwise.
+func unsafeBuiltin(pass *analysis.Pass, call *ast.CallExpr) string {
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	if b, ok := pass.TypesInfo.Uses[sel.Sel].(*types.Builtin); ok {
+		if pkg, ok := sel.X.(*ast.Ident); ok {
+			if _, isPkg := pass.TypesInfo.Uses[pkg].(*types.PkgName); isPkg {
+				return "unsafe." + b.Name()
+			}
+		}
+	}
+	return ""
+}
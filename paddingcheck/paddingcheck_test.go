@@ -0,0 +1,67 @@
+package paddingcheck_test
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"github.com/zakon47/padding-size/paddingcheck"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), paddingcheck.Analyzer, "a")
+}
+
+func TestMinWaste(t *testing.T) {
+	setFlag(t, "min-waste", "5")
+	analysistest.Run(t, analysistest.TestData(), paddingcheck.Analyzer, "minwaste")
+}
+
+func TestArch(t *testing.T) {
+	setFlag(t, "arch", "386")
+	analysistest.Run(t, analysistest.TestData(), paddingcheck.Analyzer, "arch")
+}
+
+func setFlag(t *testing.T, name, value string) {
+	t.Helper()
+	f := paddingcheck.Analyzer.Flags.Lookup(name)
+	old := f.Value.String()
+	if err := f.Value.Set(value); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Value.Set(old) })
+}
+
+func TestSuggestedFixes(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), paddingcheck.Analyzer, "fix")
+}
+
+func TestFacts(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), paddingcheck.Analyzer, "dep", "user")
+}
+
+func TestSkipGenerated(t *testing.T) {
+	setFlag(t, "skip-generated", "true")
+	analysistest.Run(t, analysistest.TestData(), paddingcheck.Analyzer, "generated")
+}
+
+func TestPreserveMarshalOrder(t *testing.T) {
+	setFlag(t, "preserve-marshal-order", "true")
+	analysistest.Run(t, analysistest.TestData(), paddingcheck.Analyzer, "marshal")
+}
+
+func TestIgnoreDirective(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), paddingcheck.Analyzer, "ignore")
+}
+
+func TestLeadingLock(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), paddingcheck.Analyzer, "lock")
+}
+
+func TestKeepFirst(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), paddingcheck.Analyzer, "keepfirst")
+}
+
+func TestCacheLinePads(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), paddingcheck.Analyzer, "pads")
+}
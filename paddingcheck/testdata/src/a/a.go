@@ -0,0 +1,48 @@
+package a
+
+import (
+	"sync"
+	"time"
+)
+
+type Good struct { // want Good:`layout\(size=16, align=8\)`
+	A int64
+	B int32
+	C bool
+}
+
+type Bad struct { // want `struct Bad is 24 bytes but could be 16 \(8 bytes of padding\)` Bad:`layout\(size=24, align=8\)`
+	A bool
+	B int64
+	C bool
+}
+
+type WithMutex struct { // want `struct WithMutex is 32 bytes but could be 24 \(8 bytes of padding\)` WithMutex:`layout\(size=32, align=8\)`
+	ready bool
+	sync.Mutex
+	count int64
+	done  bool
+}
+
+type Named struct { // want `struct Named is 40 bytes but could be 32 \(8 bytes of padding\)` Named:`layout\(size=40, align=8\)`
+	Flag bool
+	At   time.Time
+	Seq  uint32
+}
+
+type Generic[T any] struct {
+	A bool
+	B T
+	C bool
+}
+
+func local() {
+	type inner struct { // want `struct inner is 12 bytes but could be 8 \(4 bytes of padding\)`
+		a bool
+		b int32
+		c bool
+	}
+	_ = inner{}
+}
+
+type NotAStruct int
@@ -0,0 +1,15 @@
+package arch
+
+// On 386, int64 is only 4-byte aligned, so this struct has no avoidable
+// padding there even though it does on amd64.
+type Counter struct { // want Counter:`layout\(size=16, align=4\)`
+	a bool
+	b int64
+	c int32
+}
+
+type Pointers struct { // want `struct Pointers is 12 bytes but could be 8 \(4 bytes of padding\)` Pointers:`layout\(size=12, align=4\)`
+	a bool
+	p *int
+	c bool
+}
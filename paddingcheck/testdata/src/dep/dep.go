@@ -0,0 +1,19 @@
+package dep
+
+type Header struct { // want `struct Header is 24 bytes but could be 16 \(8 bytes of padding\)` Header:`layout\(size=24, align=8\)`
+	Flag bool
+	ID   int64
+	Done bool
+}
+
+type Small struct { // want Small:`layout\(size=4, align=2\)`
+	A uint16
+	B uint8
+}
+
+// Unexported types export no fact.
+type hidden struct {
+	A int64
+}
+
+var _ hidden
@@ -0,0 +1,62 @@
+package fix
+
+import (
+	"sync"
+	"unsafe"
+)
+
+// Commented keeps doc and line comments with their fields.
+type Commented struct { // want `struct Commented is 24 bytes but could be 16` Commented:`layout\(size=24, align=8\)`
+	// Ready reports whether the value is initialized.
+	Ready bool `json:"ready"`
+	// Count is the number of items.
+	Count int64 `json:"count"` // never negative
+	Done  bool  // set once
+}
+
+type Embedded struct { // want `struct Embedded is 32 bytes but could be 24` Embedded:`layout\(size=32, align=8\)`
+	closed bool
+	sync.Mutex
+	n    int64
+	dead bool
+}
+
+type MultiName struct { // want `struct MultiName is 32 bytes but could be 24` MultiName:`layout\(size=32, align=8\)`
+	a, b bool
+	x, y int64
+	c    bool
+}
+
+func local() {
+	type inner struct { // want `struct inner is 24 bytes but could be 16`
+		ok  bool
+		ptr *int
+		err bool
+	}
+	_ = inner{}
+}
+
+type Positional struct { // want `struct Positional is 24 bytes but could be 16` Positional:`layout\(size=24, align=8\)`
+	a bool
+	b int64
+	c bool
+}
+
+var _ = Positional{true, 1, false}
+
+type Offsets struct { // want `struct Offsets is 24 bytes but could be 16` Offsets:`layout\(size=24, align=8\)`
+	a bool
+	b int64
+	c bool
+}
+
+var _ = unsafe.Offsetof(Offsets{}.b)
+
+type Floating struct { // want `struct Floating is 24 bytes but could be 16` Floating:`layout\(size=24, align=8\)`
+	a bool
+
+	// A comment that belongs to no field.
+
+	b int64
+	c bool
+}
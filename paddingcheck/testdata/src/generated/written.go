@@ -0,0 +1,7 @@
+package generated
+
+type Written struct { // want `struct Written is 24 bytes but could be 16 \(8 bytes of padding\)` Written:`layout\(size=24, align=8\)`
+	A bool
+	B int64
+	C bool
+}
@@ -0,0 +1,46 @@
+package ignore
+
+// Ignored is laid out for a wire format.
+//
+//padding:ignore
+type Ignored struct { // want Ignored:`layout\(size=24, align=8\)`
+	A bool
+	B int64
+	C bool
+}
+
+type (
+	// Grouped opts out inside a parenthesized declaration.
+	//
+	//padding:ignore
+	Grouped struct { // want Grouped:`layout\(size=24, align=8\)`
+		A bool
+		B int64
+		C bool
+	}
+
+	// Reported has no directive.
+	Reported struct { // want `struct Reported is 24 bytes but could be 16 \(8 bytes of padding\)` Reported:`layout\(size=24, align=8\)`
+		A bool
+		B int64
+		C bool
+	}
+)
+
+// The directive is exact.
+//
+//padding:ignored
+type Suffixed struct { // want `struct Suffixed is 24 bytes but could be 16 \(8 bytes of padding\)` Suffixed:`layout\(size=24, align=8\)`
+	A bool
+	B int64
+	C bool
+}
+
+// Spaced is not a directive either.
+//
+// padding:ignore
+type Spaced struct { // want `struct Spaced is 24 bytes but could be 16 \(8 bytes of padding\)` Spaced:`layout\(size=24, align=8\)`
+	A bool
+	B int64
+	C bool
+}
@@ -0,0 +1,11 @@
+package keepfirst
+
+// Header is reordered after its version, which would otherwise move behind n.
+//
+//padding:keep-first=1
+type Header struct { // want `struct Header is 24 bytes but could be 16` Header:`layout\(size=24, align=8\)`
+	version uint8
+	ok      bool
+	n       int64
+	done    bool
+}
@@ -0,0 +1,18 @@
+package lock
+
+import "sync"
+
+// Guarded keeps its lock first, where it wastes nothing.
+type Guarded struct { // want Guarded:`layout\(size=24, align=8\)`
+	sync.Mutex
+	ok bool
+	n  int64
+}
+
+// Loose is reordered after its lock, which stays first.
+type Loose struct { // want `struct Loose is 48 bytes but could be 40` Loose:`layout\(size=48, align=8\)`
+	sync.RWMutex
+	ok   bool
+	n    int64
+	done bool
+}
@@ -0,0 +1,19 @@
+package marshal
+
+type Event struct { // want Event:`layout\(size=24, align=8\)`
+	Ok   bool  `json:"ok"`
+	At   int64 `json:"at"`
+	Done bool  `json:"done"`
+}
+
+type Row struct { // want Row:`layout\(size=24, align=8\)`
+	Ok   bool
+	At   int64 `yaml:"at,omitempty"`
+	Done bool
+}
+
+type Internal struct { // want `struct Internal is 24 bytes but could be 16 \(8 bytes of padding\)` Internal:`layout\(size=24, align=8\)`
+	Ok   bool
+	At   int64 `custom:"at"`
+	Done bool
+}
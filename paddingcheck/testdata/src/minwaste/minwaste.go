@@ -0,0 +1,13 @@
+package minwaste
+
+type Small struct { // 12 bytes, could be 8: below the threshold // want Small:`layout\(size=12, align=4\)`
+	a bool
+	b int32
+	c bool
+}
+
+type Large struct { // want `struct Large is 24 bytes but could be 16 \(8 bytes of padding\)` Large:`layout\(size=24, align=8\)`
+	a bool
+	b int64
+	c bool
+}
@@ -0,0 +1,12 @@
+package pads
+
+// Counters is reordered on each side of its pad, keeping a and n apart.
+type Counters struct { // want `struct Counters is 104 bytes but could be 96` Counters:`layout\(size=104, align=8\)`
+	a  bool
+	n  int64
+	b  bool
+	_  [64]byte
+	c  bool
+	m  int64
+	ok bool
+}
@@ -0,0 +1,17 @@
+package user
+
+import "dep"
+
+// Fields of types from package dep are sized from dep's Layout facts.
+
+type Record struct { // want `struct Record is 40 bytes but could be 32 \(8 bytes of padding\)` Record:`layout\(size=40, align=8\)`
+	Ok bool
+	H  dep.Header
+	N  int32
+}
+
+type Packed struct { // want `struct Packed is 16 bytes but could be 14 \(2 bytes of padding\)` Packed:`layout\(size=16, align=2\)`
+	A bool
+	S [3]dep.Small
+	B bool
+}
@@ -0,0 +1,101 @@
+// Package paddingtest provides test helpers that pin the memory layout of
+// struct types.
+//
+// Layouts are read with reflect, so sizes and offsets are those of the
+// running binary and exact for the GOARCH the tests run on:
+//
+//	func TestConfigLayout(t *testing.T) {
+//		paddingtest.AssertOptimal(t, reflect.TypeOf(Config{}))
+//		paddingtest.AssertSize(t, reflect.TypeOf(Msg{}), 64)
+//	}
+package paddingtest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zakon47/padding-size/padding"
+)
+
+// AssertOptimal reports an error if the fields of the struct type typ could
+// be reordered to make it smaller. The message shows the current and the
+// optimal layout.
+func AssertOptimal(t testing.TB, typ reflect.Type) {
+	t.Helper()
+	s, ok := structInfo(t, typ)
+	if !ok {
+		return
+	}
+	optimal := padding.Optimal(s)
+	if optimal.Size >= s.Size {
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("current layout:\n")
+	padding.Fprint(&b, s)
+	b.WriteString("optimal layout:\n")
+	padding.Fprint(&b, optimal)
+	t.Errorf("%s is %d bytes but could be %d (%d bytes of padding)\n%s",
+		s.Name, s.Size, optimal.Size, s.Size-optimal.Size, strings.TrimRight(b.String(), "\n"))
+}
+
+// AssertSize reports an error if the struct type typ is not size bytes. The
+// message shows the current layout.
+func AssertSize(t testing.TB, typ reflect.Type, size uintptr) {
+	t.Helper()
+	s, ok := structInfo(t, typ)
+	if !ok {
+		return
+	}
+	if typ.Size() == size {
+		return
+	}
+
+	var b strings.Builder
+	padding.Fprint(&b, s)
+	t.Errorf("%s is %d bytes, want %d\n%s", s.Name, typ.Size(), size, strings.TrimRight(b.String(), "\n"))
+}
+
+// structInfo returns the layout of typ as reported by reflect. It reports an
+// error and returns false if typ is not a struct type.
+func structInfo(t testing.TB, typ reflect.Type) (padding.StructInfo, bool) {
+	t.Helper()
+	if typ == nil || typ.Kind() != reflect.Struct {
+		t.Errorf("paddingtest: %v is not a struct type", typ)
+		return padding.StructInfo{}, false
+	}
+
+	s := padding.StructInfo{
+		Name:   typ.String(),
+		Fields: make([]padding.FieldInfo, typ.NumField()),
+		Size:   int64(typ.Size()),
+		Align:  int64(typ.Align()),
+	}
+	for i := range s.Fields {
+		f := typ.Field(i)
+		s.Fields[i] = padding.FieldInfo{
+			Name:     f.Name,
+			Type:     typeName(f.Type, typ.PkgPath()),
+			Size:     int64(f.Type.Size()),
+			Align:    int64(f.Type.Align()),
+			Offset:   int64(f.Offset),
+			Embedded: f.Anonymous,
+		}
+	}
+	// A leading embedded lock stays first, as padding.Analyze keeps it.
+	if s.LeadingLock() {
+		s.KeepFirst = 1
+	}
+	return s, true
+}
+
+// typeName returns the name of t as the source of package pkg writes it:
+// unqualified if t is declared in pkg, as a noCopy marker is.
+func typeName(t reflect.Type, pkg string) string {
+	if t.Name() != "" && t.PkgPath() == pkg {
+		return t.Name()
+	}
+	return t.String()
+}
@@ -0,0 +1,128 @@
+package paddingtest_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/zakon47/padding-size/paddingtest"
+)
+
+// recorder is a testing.TB that records errors instead of failing.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// The types below use fields whose size and alignment are the same on every
+// architecture, so the expected messages are too.
+
+type Optimal struct {
+	A int32
+	B int16
+	C bool
+}
+
+type Wasteful struct {
+	Ready bool
+	Count int32
+	Done  bool
+}
+
+func TestAssertOptimal(t *testing.T) {
+	r := &recorder{TB: t}
+	paddingtest.AssertOptimal(r, reflect.TypeOf(Optimal{}))
+	if len(r.errors) != 0 {
+		t.Errorf("AssertOptimal(Optimal) failed: %v", r.errors)
+	}
+}
+
+func TestAssertOptimalMessage(t *testing.T) {
+	r := &recorder{TB: t}
+	paddingtest.AssertOptimal(r, reflect.TypeOf(Wasteful{}))
+
+	want := `paddingtest_test.Wasteful is 12 bytes but could be 8 (4 bytes of padding)
+current layout:
+Struct: paddingtest_test.Wasteful (size: 12 bytes (alloc 16), align: 4, optimal: 8 bytes, packed minimum: 6 bytes, wasted: 4 bytes, padding: 3 inter-field + 3 trailing)
+  Ready bool (offset: 0, size: 1, align: 1, padding after: 3)
+  Count int32 (offset: 4, size: 4, align: 4)
+  Done bool (offset: 8, size: 1, align: 1, trailing padding: 3)
+
+optimal layout:
+Struct: paddingtest_test.Wasteful (size: 8 bytes, align: 4, packed minimum: 6 bytes, padding: 0 inter-field + 2 trailing)
+  Count int32 (offset: 0, size: 4, align: 4)
+  Ready bool (offset: 4, size: 1, align: 1)
+  Done bool (offset: 5, size: 1, align: 1, trailing padding: 2)
+  Reordering won't help: the alignment of Count leaves 2 bytes of padding`
+	if len(r.errors) != 1 || r.errors[0] != want {
+		t.Errorf("AssertOptimal(Wasteful) reported %q, want %q", r.errors, want)
+	}
+}
+
+// Guarded keeps its lock first, as padding-size does, and is optimal so.
+type Guarded struct {
+	sync.Mutex
+	N int64
+	A bool
+	B bool
+}
+
+// LooseGuarded wastes padding after its lock, which stays first.
+type LooseGuarded struct {
+	sync.Mutex
+	A bool
+	N int64
+	B bool
+}
+
+func TestAssertOptimalLeadingLock(t *testing.T) {
+	r := &recorder{TB: t}
+	paddingtest.AssertOptimal(r, reflect.TypeOf(Guarded{}))
+	if len(r.errors) != 0 {
+		t.Errorf("AssertOptimal(Guarded) failed: %v", r.errors)
+	}
+
+	paddingtest.AssertOptimal(r, reflect.TypeOf(LooseGuarded{}))
+	if len(r.errors) != 1 {
+		t.Fatalf("AssertOptimal(LooseGuarded) reported %q, want one error", r.errors)
+	}
+	_, optimal, _ := strings.Cut(r.errors[0], "optimal layout:\n")
+	if _, first, _ := strings.Cut(optimal, "\n"); !strings.HasPrefix(first, "  sync.Mutex ") {
+		t.Errorf("optimal layout moves the lock:\n%s", optimal)
+	}
+}
+
+func TestAssertSize(t *testing.T) {
+	r := &recorder{TB: t}
+	paddingtest.AssertSize(r, reflect.TypeOf(Wasteful{}), 12)
+	if len(r.errors) != 0 {
+		t.Errorf("AssertSize(Wasteful, 12) failed: %v", r.errors)
+	}
+
+	paddingtest.AssertSize(r, reflect.TypeOf(Wasteful{}), 8)
+	want := `paddingtest_test.Wasteful is 12 bytes, want 8
+Struct: paddingtest_test.Wasteful (size: 12 bytes (alloc 16), align: 4, optimal: 8 bytes, packed minimum: 6 bytes, wasted: 4 bytes, padding: 3 inter-field + 3 trailing)
+  Ready bool (offset: 0, size: 1, align: 1, padding after: 3)
+  Count int32 (offset: 4, size: 4, align: 4)
+  Done bool (offset: 8, size: 1, align: 1, trailing padding: 3)`
+	if len(r.errors) != 1 || r.errors[0] != want {
+		t.Errorf("AssertSize(Wasteful, 8) reported %q, want %q", r.errors, want)
+	}
+}
+
+func TestNotAStruct(t *testing.T) {
+	r := &recorder{TB: t}
+	paddingtest.AssertOptimal(r, reflect.TypeOf(0))
+	paddingtest.AssertSize(r, reflect.TypeOf(&Optimal{}), 8)
+	if len(r.errors) != 2 {
+		t.Errorf("got %d errors for non-struct types, want 2: %v", len(r.errors), r.errors)
+	}
+}